@@ -2,7 +2,7 @@ package acme
 
 import (
 	"crypto"
-	"crypto/rand"
+	"crypto/ecdsa"
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
@@ -21,6 +21,7 @@ type Account struct {
 	PrivateKey         []byte
 	DomainsCertificate DomainsCertificates
 	ChallengeCerts     map[string]*ChallengeCert
+	HTTPChallenges     map[string]map[string]string
 }
 
 // ChallengeCert stores a challenge certificate
@@ -56,18 +57,27 @@ func (a *Account) Init() error {
 	return nil
 }
 
-// NewAccount creates an account
-func NewAccount(email string) (*Account, error) {
+// NewAccount creates an account with a new private key of type keyType
+func NewAccount(email string, keyType acme.KeyType) (*Account, error) {
 	// Create a user. New accounts need an email and private key to start
-	privateKey, err := rsa.GenerateKey(rand.Reader, 4096)
+	privateKey, err := generatePrivateKey(keyType)
 	if err != nil {
 		return nil, err
 	}
+	var der []byte
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		der = x509.MarshalPKCS1PrivateKey(key)
+	case *ecdsa.PrivateKey:
+		if der, err = x509.MarshalECPrivateKey(key); err != nil {
+			return nil, err
+		}
+	}
 	domainsCerts := DomainsCertificates{Certs: []*DomainsCertificate{}}
 	domainsCerts.Init()
 	return &Account{
 		Email:              email,
-		PrivateKey:         x509.MarshalPKCS1PrivateKey(privateKey),
+		PrivateKey:         der,
 		DomainsCertificate: DomainsCertificates{Certs: domainsCerts.Certs},
 		ChallengeCerts:     map[string]*ChallengeCert{}}, nil
 }
@@ -87,6 +97,9 @@ func (a *Account) GetPrivateKey() crypto.PrivateKey {
 	if privateKey, err := x509.ParsePKCS1PrivateKey(a.PrivateKey); err == nil {
 		return privateKey
 	}
+	if privateKey, err := x509.ParseECPrivateKey(a.PrivateKey); err == nil {
+		return privateKey
+	}
 	log.Errorf("Cannot unmarshall private key %+v", a.PrivateKey)
 	return nil
 }
@@ -2,11 +2,14 @@ package acme
 
 import (
 	"context"
+	"crypto"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"github.com/BurntSushi/ty/fun"
 	"github.com/cenk/backoff"
+	"github.com/codegangsta/negroni"
 	"github.com/containous/staert"
 	"github.com/containous/traefik/cluster"
 	"github.com/containous/traefik/log"
@@ -17,24 +20,49 @@ import (
 	fmtlog "log"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
 // ACME allows to connect to lets encrypt and retrieve certs
 type ACME struct {
-	Email               string   `description:"Email address used for registration"`
-	Domains             []Domain `description:"SANs (alternative domains) to each main domain using format: --acme.domains='main.com,san1.com,san2.com' --acme.domains='main.net,san1.net,san2.net'"`
-	Storage             string   `description:"File or key used for certificates storage."`
-	StorageFile         string   // deprecated
-	OnDemand            bool     `description:"Enable on demand certificate. This will request a certificate from Let's Encrypt during the first TLS handshake for a hostname that does not yet have a certificate."`
-	OnHostRule          bool     `description:"Enable certificate generation on frontends Host rules."`
-	CAServer            string   `description:"CA server to use."`
-	EntryPoint          string   `description:"Entrypoint to proxy acme challenge to."`
+	Email               string         `description:"Email address used for registration"`
+	Domains             []Domain       `description:"SANs (alternative domains) to each main domain using format: --acme.domains='main.com,san1.com,san2.com' --acme.domains='main.net,san1.net,san2.net'"`
+	Storage             string         `description:"File or key used for certificates storage."`
+	StorageFile         string         // deprecated
+	OnDemand            bool           `description:"Enable on demand certificate. This will request a certificate from Let's Encrypt during the first TLS handshake for a hostname that does not yet have a certificate."`
+	OnHostRule          bool           `description:"Enable certificate generation on frontends Host rules."`
+	CAServer            string         `description:"CA server to use."`
+	EntryPoint          string         `description:"Entrypoint to proxy acme challenge to."`
+	HTTPChallenge       *HTTPChallenge `description:"Use the HTTP-01 challenge instead of TLS-SNI-01"`
+	DNSChallenge        *DNSChallenge  `description:"Use the DNS-01 challenge instead of TLS-SNI-01"`
+	KeyType             string         `description:"Key type of the certificates: RSA2048, RSA4096, RSA8192, EC256 or EC384"`
+	AccountKeyType      string         `description:"Key type of a new account: RSA2048, RSA4096, RSA8192, EC256 or EC384"`
+	RotateKeys          bool           `description:"Generate a new private key at each renewal, instead of reusing the current one"`
 	client              *acme.Client
 	defaultCertificate  *tls.Certificate
 	store               cluster.Store
 	challengeProvider   *challengeProvider
+	httpProvider        *httpChallengeProvider
+	dnsProvider         *dnsChallengeProvider
+	keyType             acme.KeyType
+	accountKeyType      acme.KeyType
 	checkOnDemandDomain func(domain string) bool
+	renewals            map[string]*RenewalAttempt
+	renewalsLock        sync.RWMutex
+}
+
+// RenewalAttempt holds the outcome of the last renewal attempt of a certificate
+type RenewalAttempt struct {
+	Date  time.Time `json:"date"`
+	Error string    `json:"error,omitempty"`
+}
+
+// CertificateStatus describes a certificate managed by ACME
+type CertificateStatus struct {
+	Domains     Domain
+	Certificate *x509.Certificate
+	LastRenewal *RenewalAttempt
 }
 
 //Domains parse []Domain
@@ -74,23 +102,65 @@ func (ds *Domains) SetValue(val interface{}) {
 
 // Domain holds a domain name with SANs
 type Domain struct {
-	Main string
-	SANs []string
+	Main    string
+	SANs    []string
+	KeyFile string `json:",omitempty"`
+}
+
+// privateKey returns the externally provided private key of the domain, nil if none
+func (d Domain) privateKey() (crypto.PrivateKey, error) {
+	if len(d.KeyFile) == 0 {
+		return nil, nil
+	}
+	return loadPrivateKey(d.KeyFile)
+}
+
+// SetDefaultCertificate sets the certificate served to the clients requesting
+// a domain without certificate, instead of a generated one.
+func (a *ACME) SetDefaultCertificate(certificate *tls.Certificate) {
+	a.defaultCertificate = certificate
 }
 
 func (a *ACME) init() error {
 	acme.Logger = fmtlog.New(ioutil.Discard, "", 0)
 	// no certificates in TLS config, so we add a default one
-	cert, err := generateDefaultCertificate()
-	if err != nil {
-		return err
+	var err error
+	if a.defaultCertificate == nil {
+		if a.defaultCertificate, err = generateDefaultCertificate(); err != nil {
+			return err
+		}
 	}
-	a.defaultCertificate = cert
 	// TODO: to remove in the futurs
 	if len(a.StorageFile) > 0 && len(a.Storage) == 0 {
 		log.Warnf("ACME.StorageFile is deprecated, use ACME.Storage instead")
 		a.Storage = a.StorageFile
 	}
+	if a.keyType, err = parseKeyType(a.KeyType); err != nil {
+		return err
+	}
+	if a.accountKeyType, err = parseKeyType(a.AccountKeyType); err != nil {
+		return err
+	}
+	for _, domain := range a.Domains {
+		if len(domain.KeyFile) > 0 {
+			if _, err := loadPrivateKey(domain.KeyFile); err != nil {
+				return fmt.Errorf("Invalid private key for domain %s: %v", domain.Main, err)
+			}
+		}
+	}
+	if a.HTTPChallenge != nil && a.DNSChallenge != nil {
+		return errors.New("Only one of the HTTP and DNS challenges can be configured")
+	}
+	if a.DNSChallenge != nil {
+		a.dnsProvider, err = newDNSChallengeProvider(a.DNSChallenge)
+		if err != nil {
+			return err
+		}
+		if len(a.DNSChallenge.Resolvers) > 0 {
+			// the propagation check of lego uses these resolvers to find the authoritative nameservers
+			acme.RecursiveNameservers = a.DNSChallenge.Resolvers.nameservers()
+		}
+	}
 	return nil
 }
 
@@ -132,6 +202,9 @@ func (a *ACME) CreateClusterConfig(leadership *cluster.Leadership, tlsConfig *tl
 
 	a.store = datastore
 	a.challengeProvider = &challengeProvider{store: a.store}
+	if a.HTTPChallenge != nil {
+		a.getHTTPChallengeProvider().setStore(a.store)
+	}
 
 	ticker := time.NewTicker(24 * time.Hour)
 	leadership.Pool.AddGoCtx(func(ctx context.Context) {
@@ -163,7 +236,7 @@ func (a *ACME) CreateClusterConfig(leadership *cluster.Leadership, tlsConfig *tl
 			account.Init()
 			var needRegister bool
 			if account == nil || len(account.Email) == 0 {
-				account, err = NewAccount(a.Email)
+				account, err = NewAccount(a.Email, a.accountKeyType)
 				if err != nil {
 					return err
 				}
@@ -233,6 +306,9 @@ func (a *ACME) CreateLocalConfig(tlsConfig *tls.Config, checkOnDemandDomain func
 	localStore := NewLocalStore(a.Storage)
 	a.store = localStore
 	a.challengeProvider = &challengeProvider{store: a.store}
+	if a.HTTPChallenge != nil {
+		a.getHTTPChallengeProvider().setStore(a.store)
+	}
 
 	var needRegister bool
 	var account *Account
@@ -247,7 +323,7 @@ func (a *ACME) CreateLocalConfig(tlsConfig *tls.Config, checkOnDemandDomain func
 		account = object.(*Account)
 	} else {
 		log.Infof("Generating ACME Account...")
-		account, err = NewAccount(a.Email)
+		account, err = NewAccount(a.Email, a.accountKeyType)
 		if err != nil {
 			return err
 		}
@@ -344,7 +420,12 @@ func (a *ACME) retrieveCertificates() {
 			domains := []string{}
 			domains = append(domains, domain.Main)
 			domains = append(domains, domain.SANs...)
-			certificateResource, err := a.getDomainsCertificates(domains)
+			privateKey, err := domain.privateKey()
+			if err != nil {
+				log.Errorf("Error loading private key for domain %s: %s", domains, err.Error())
+				continue
+			}
+			certificateResource, err := a.getDomainsCertificates(domains, privateKey)
 			if err != nil {
 				log.Errorf("Error getting ACME certificate for domain %s: %s", domains, err.Error())
 				continue
@@ -376,15 +457,22 @@ func (a *ACME) renewCertificates() error {
 	for _, certificateResource := range account.DomainsCertificate.Certs {
 		if certificateResource.needRenew() {
 			log.Debugf("Renewing certificate %+v", certificateResource.Domains)
+			privateKey, err := a.renewalPrivateKey(certificateResource)
+			if err != nil {
+				log.Errorf("Error loading private key for domain %s: %v", certificateResource.Domains.Main, err)
+				a.recordRenewal(certificateResource.Domains, err)
+				continue
+			}
 			renewedCert, err := a.client.RenewCertificate(acme.CertificateResource{
 				Domain:        certificateResource.Certificate.Domain,
 				CertURL:       certificateResource.Certificate.CertURL,
 				CertStableURL: certificateResource.Certificate.CertStableURL,
-				PrivateKey:    certificateResource.Certificate.PrivateKey,
+				PrivateKey:    privateKey,
 				Certificate:   certificateResource.Certificate.Certificate,
 			}, true)
 			if err != nil {
 				log.Errorf("Error renewing certificate: %v", err)
+				a.recordRenewal(certificateResource.Domains, err)
 				continue
 			}
 			log.Debugf("Renewed certificate %+v", certificateResource.Domains)
@@ -403,43 +491,128 @@ func (a *ACME) renewCertificates() error {
 			err = account.DomainsCertificate.renewCertificates(renewedACMECert, certificateResource.Domains)
 			if err != nil {
 				log.Errorf("Error renewing certificate: %v", err)
+				a.recordRenewal(certificateResource.Domains, err)
 				continue
 			}
 
 			if err = transaction.Commit(account); err != nil {
 				log.Errorf("Error Saving ACME account %+v: %s", account, err.Error())
+				a.recordRenewal(certificateResource.Domains, err)
 				continue
 			}
+			a.recordRenewal(certificateResource.Domains, nil)
 		}
 	}
 	return nil
 }
 
+// renewalPrivateKey returns the PEM private key the certificate is renewed
+// for: the external key of its domain, the current key unless keys are
+// rotated, or nil for a new one.
+func (a *ACME) renewalPrivateKey(certificateResource *DomainsCertificate) ([]byte, error) {
+	privateKey, err := certificateResource.Domains.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	if privateKey != nil {
+		return pemEncode(privateKey), nil
+	}
+	if a.RotateKeys {
+		return nil, nil
+	}
+	return certificateResource.Certificate.PrivateKey, nil
+}
+
+func (a *ACME) recordRenewal(domain Domain, err error) {
+	attempt := &RenewalAttempt{Date: time.Now()}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	a.renewalsLock.Lock()
+	defer a.renewalsLock.Unlock()
+	if a.renewals == nil {
+		a.renewals = make(map[string]*RenewalAttempt)
+	}
+	a.renewals[domain.Main] = attempt
+}
+
+// Certificates returns the status of the certificates currently managed by ACME
+func (a *ACME) Certificates() []CertificateStatus {
+	if a.store == nil {
+		return nil
+	}
+	account := a.store.Get().(*Account)
+	dc := &account.DomainsCertificate
+	dc.lock.RLock()
+	defer dc.lock.RUnlock()
+	a.renewalsLock.RLock()
+	defer a.renewalsLock.RUnlock()
+
+	certificates := []CertificateStatus{}
+	for _, domainsCertificate := range dc.Certs {
+		if domainsCertificate.tlsCert == nil || len(domainsCertificate.tlsCert.Certificate) == 0 {
+			continue
+		}
+		leaf, err := x509.ParseCertificate(domainsCertificate.tlsCert.Certificate[0])
+		if err != nil {
+			log.Errorf("Error parsing ACME certificate for domain %s: %s", domainsCertificate.Domains.Main, err.Error())
+			continue
+		}
+		certificates = append(certificates, CertificateStatus{
+			Domains:     domainsCertificate.Domains,
+			Certificate: leaf,
+			LastRenewal: a.renewals[domainsCertificate.Domains.Main],
+		})
+	}
+	return certificates
+}
+
 func (a *ACME) buildACMEClient(account *Account) (*acme.Client, error) {
 	log.Debugf("Building ACME client...")
 	caServer := "https://acme-v01.api.letsencrypt.org/directory"
 	if len(a.CAServer) > 0 {
 		caServer = a.CAServer
 	}
-	client, err := acme.NewClient(caServer, account, acme.RSA4096)
+	client, err := acme.NewClient(caServer, account, a.keyType)
 	if err != nil {
 		return nil, err
 	}
-	client.ExcludeChallenges([]acme.Challenge{acme.HTTP01, acme.DNS01})
-	err = client.SetChallengeProvider(acme.TLSSNI01, a.challengeProvider)
+	if a.HTTPChallenge != nil {
+		client.ExcludeChallenges([]acme.Challenge{acme.TLSSNI01, acme.DNS01})
+		err = client.SetChallengeProvider(acme.HTTP01, a.getHTTPChallengeProvider())
+	} else if a.DNSChallenge != nil {
+		client.ExcludeChallenges([]acme.Challenge{acme.HTTP01, acme.TLSSNI01})
+		err = client.SetChallengeProvider(acme.DNS01, a.dnsProvider)
+	} else {
+		client.ExcludeChallenges([]acme.Challenge{acme.HTTP01, acme.DNS01})
+		err = client.SetChallengeProvider(acme.TLSSNI01, a.challengeProvider)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return client, nil
 }
 
+// HTTPChallengeHandler returns the middleware answering the HTTP-01
+// challenges, to use on the HTTPChallenge entrypoint.
+func (a *ACME) HTTPChallengeHandler() negroni.Handler {
+	return a.getHTTPChallengeProvider()
+}
+
+func (a *ACME) getHTTPChallengeProvider() *httpChallengeProvider {
+	if a.httpProvider == nil {
+		a.httpProvider = &httpChallengeProvider{config: a.HTTPChallenge}
+	}
+	return a.httpProvider
+}
+
 func (a *ACME) loadCertificateOnDemand(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
 	domain := types.CanonicalDomain(clientHello.ServerName)
 	account := a.store.Get().(*Account)
 	if certificateResource, ok := account.DomainsCertificate.getCertificateForDomain(domain); ok {
 		return certificateResource.tlsCert, nil
 	}
-	certificate, err := a.getDomainsCertificates([]string{domain})
+	certificate, err := a.getDomainsCertificates([]string{domain}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -495,7 +668,7 @@ func (a *ACME) LoadCertificateForDomains(domains []string) {
 			// domain already exists
 			return
 		}
-		certificate, err := a.getDomainsCertificates(domains)
+		certificate, err := a.getDomainsCertificates(domains, nil)
 		if err != nil {
 			log.Errorf("Error getting ACME certificates %+v : %v", domains, err)
 			return
@@ -520,11 +693,13 @@ func (a *ACME) LoadCertificateForDomains(domains []string) {
 	})
 }
 
-func (a *ACME) getDomainsCertificates(domains []string) (*Certificate, error) {
+// getDomainsCertificates obtains a certificate for domains, for privateKey
+// or for a new private key if nil.
+func (a *ACME) getDomainsCertificates(domains []string, privateKey crypto.PrivateKey) (*Certificate, error) {
 	domains = fun.Map(types.CanonicalDomain, domains).([]string)
 	log.Debugf("Loading ACME certificates %s...", domains)
 	bundle := true
-	certificate, failures := a.client.ObtainCertificate(domains, bundle, nil)
+	certificate, failures := a.client.ObtainCertificate(domains, bundle, privateKey)
 	if len(failures) > 0 {
 		log.Error(failures)
 		return nil, fmt.Errorf("Cannot obtain certificates %s+v", failures)
@@ -1,6 +1,14 @@
 package acme
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
 	"reflect"
 	"sync"
 	"testing"
@@ -256,3 +264,69 @@ bZME3gHPYCk1QFZUptriMCJ5fMjCgxeOTR+FAkstb/lTRuCc4UyILJguIMar
 		t.Errorf("Expected new certificate %+v \nGot %+v", newCertificate, domainsCertificates.Certs[0].Certificate)
 	}
 }
+
+func TestNewAccountKeyTypes(t *testing.T) {
+	for _, keyType := range []string{"EC256", "RSA2048"} {
+		legoKeyType, err := parseKeyType(keyType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		account, err := NewAccount("test@traefik.io", legoKeyType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch key := account.GetPrivateKey().(type) {
+		case *ecdsa.PrivateKey:
+			if keyType != "EC256" || key.Curve != elliptic.P256() {
+				t.Errorf("%s: got an EC key on %s", keyType, key.Curve.Params().Name)
+			}
+		case *rsa.PrivateKey:
+			if keyType != "RSA2048" || key.N.BitLen() != 2048 {
+				t.Errorf("%s: got a %d bits RSA key", keyType, key.N.BitLen())
+			}
+		default:
+			t.Errorf("%s: got key %+v", keyType, key)
+		}
+	}
+	if _, err := parseKeyType("DSA1024"); err == nil {
+		t.Errorf("Expected an error for key type DSA1024")
+	}
+}
+
+func TestRenewalPrivateKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile, err := ioutil.TempFile("", "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(keyFile.Name())
+	pem.Encode(keyFile, &pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	keyFile.Close()
+
+	currentKey := []byte("current key")
+	certificate := &DomainsCertificate{Domains: Domain{Main: "foo.com"}, Certificate: &Certificate{PrivateKey: currentKey}}
+	external := &DomainsCertificate{Domains: Domain{Main: "bar.com", KeyFile: keyFile.Name()}, Certificate: &Certificate{PrivateKey: currentKey}}
+
+	a := &ACME{}
+	if privateKey, _ := a.renewalPrivateKey(certificate); !reflect.DeepEqual(privateKey, currentKey) {
+		t.Errorf("Expected the current key to be reused, got %s", privateKey)
+	}
+	a.RotateKeys = true
+	if privateKey, _ := a.renewalPrivateKey(certificate); privateKey != nil {
+		t.Errorf("Expected a new key, got %s", privateKey)
+	}
+	privateKey, err := a.renewalPrivateKey(external)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(privateKey, pemEncode(key)) {
+		t.Errorf("Expected the external key, got %s", privateKey)
+	}
+}
@@ -3,6 +3,7 @@ package acme
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -11,11 +12,80 @@ import (
 	"crypto/x509/pkix"
 	"encoding/hex"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"time"
+
+	"github.com/xenolf/lego/acme"
 )
 
+// keyTypes are the supported key types of the accounts and certificates
+var keyTypes = map[string]acme.KeyType{
+	"RSA2048": acme.RSA2048,
+	"RSA4096": acme.RSA4096,
+	"RSA8192": acme.RSA8192,
+	"EC256":   acme.EC256,
+	"EC384":   acme.EC384,
+}
+
+// parseKeyType returns the lego key type of keyType, RSA4096 if empty
+func parseKeyType(keyType string) (acme.KeyType, error) {
+	if len(keyType) == 0 {
+		return acme.RSA4096, nil
+	}
+	if legoKeyType, ok := keyTypes[keyType]; ok {
+		return legoKeyType, nil
+	}
+	return "", fmt.Errorf("Invalid key type %s, expected RSA2048, RSA4096, RSA8192, EC256 or EC384", keyType)
+}
+
+func generatePrivateKey(keyType acme.KeyType) (crypto.PrivateKey, error) {
+	switch keyType {
+	case acme.EC256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case acme.EC384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case acme.RSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case acme.RSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case acme.RSA8192:
+		return rsa.GenerateKey(rand.Reader, 8192)
+	}
+	return nil, fmt.Errorf("Invalid key type %s", keyType)
+}
+
+// loadPrivateKey reads the PEM encoded private key of file, in PKCS#1, SEC 1 or PKCS#8 format
+func loadPrivateKey(file string) (crypto.PrivateKey, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("No PEM private key found in %s", file)
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch key.(type) {
+		case *rsa.PrivateKey, *ecdsa.PrivateKey:
+			return key, nil
+		}
+		return nil, errors.New("Unsupported private key type in " + file)
+	}
+	return nil, fmt.Errorf("Unsupported PEM block %s in %s", block.Type, file)
+}
+
 func generateDefaultCertificate() (*tls.Certificate, error) {
 	rsaPrivKey, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
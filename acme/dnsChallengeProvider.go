@@ -0,0 +1,111 @@
+package acme
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/externaldns"
+	"github.com/containous/traefik/log"
+	"github.com/xenolf/lego/acme"
+)
+
+var _ acme.ChallengeProviderTimeout = (*dnsChallengeProvider)(nil)
+
+// DNSChallenge configures the DNS-01 challenge, publishing the TXT records with a DNS provider
+type DNSChallenge struct {
+	Route53            *externaldns.Route53    `description:"Publish the challenges in an AWS Route53 hosted zone"`
+	Cloudflare         *externaldns.Cloudflare `description:"Publish the challenges in Cloudflare"`
+	RFC2136            *externaldns.RFC2136    `description:"Publish the challenges with RFC2136 dynamic updates"`
+	PropagationTimeout int64                   `description:"Maximum duration in seconds of the propagation check, defaults to the provider's"`
+	PollingInterval    int64                   `description:"Interval in seconds between two propagation checks, defaults to the provider's"`
+	Resolvers          Resolvers               `description:"Recursive resolvers used by the propagation check instead of the public ones, host:port"`
+}
+
+// Resolvers holds the recursive resolvers used by the propagation check
+type Resolvers []string
+
+// Set adds strings elem into the the parser
+// it splits str on , and ;
+func (r *Resolvers) Set(str string) error {
+	fargs := func(c rune) bool {
+		return c == ',' || c == ';'
+	}
+	*r = append(*r, strings.FieldsFunc(str, fargs)...)
+	return nil
+}
+
+// Get []string
+func (r *Resolvers) Get() interface{} { return Resolvers(*r) }
+
+// String return slice in a string
+func (r *Resolvers) String() string { return fmt.Sprintf("%v", *r) }
+
+// SetValue sets []string into the parser
+func (r *Resolvers) SetValue(val interface{}) {
+	*r = Resolvers(val.(Resolvers))
+}
+
+// nameservers returns the resolvers with the default DNS port if missing
+func (r Resolvers) nameservers() []string {
+	nameservers := []string{}
+	for _, resolver := range r {
+		if _, _, err := net.SplitHostPort(resolver); err != nil {
+			resolver = net.JoinHostPort(resolver, "53")
+		}
+		nameservers = append(nameservers, resolver)
+	}
+	return nameservers
+}
+
+// dnsChallengeProvider publishes the TXT records of the DNS-01 challenges
+type dnsChallengeProvider struct {
+	provider externaldns.Provider
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// newDNSChallengeProvider returns the DNS-01 provider of config, with the
+// propagation check settings of the DNS provider unless overridden.
+func newDNSChallengeProvider(config *DNSChallenge) (*dnsChallengeProvider, error) {
+	var providers []*dnsChallengeProvider
+	if config.Route53 != nil {
+		// Route53 changes are applied to all its nameservers within 60 seconds
+		providers = append(providers, &dnsChallengeProvider{provider: config.Route53, timeout: 2 * time.Minute, interval: 4 * time.Second})
+	}
+	if config.Cloudflare != nil {
+		providers = append(providers, &dnsChallengeProvider{provider: config.Cloudflare, timeout: 2 * time.Minute, interval: 2 * time.Second})
+	}
+	if config.RFC2136 != nil {
+		providers = append(providers, &dnsChallengeProvider{provider: config.RFC2136, timeout: 60 * time.Second, interval: 2 * time.Second})
+	}
+	if len(providers) != 1 {
+		return nil, errors.New("Exactly one DNS challenge provider must be configured: route53, cloudflare or rfc2136")
+	}
+	provider := providers[0]
+	if config.PropagationTimeout > 0 {
+		provider.timeout = time.Duration(config.PropagationTimeout) * time.Second
+	}
+	if config.PollingInterval > 0 {
+		provider.interval = time.Duration(config.PollingInterval) * time.Second
+	}
+	return provider, nil
+}
+
+func (d *dnsChallengeProvider) Present(domain, token, keyAuth string) error {
+	log.Debugf("DNS challenge Present %s", domain)
+	fqdn, value, ttl := acme.DNS01Record(domain, keyAuth)
+	return d.provider.Upsert(externaldns.Record{Name: strings.TrimSuffix(fqdn, "."), Type: "TXT", Value: value, TTL: ttl})
+}
+
+func (d *dnsChallengeProvider) CleanUp(domain, token, keyAuth string) error {
+	log.Debugf("DNS challenge CleanUp %s", domain)
+	fqdn, _, _ := acme.DNS01Record(domain, keyAuth)
+	return d.provider.Delete(strings.TrimSuffix(fqdn, "."), "TXT")
+}
+
+func (d *dnsChallengeProvider) Timeout() (timeout, interval time.Duration) {
+	return d.timeout, d.interval
+}
@@ -0,0 +1,79 @@
+package acme
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/externaldns"
+)
+
+type fakeDNSProvider struct {
+	records map[string]externaldns.Record
+}
+
+func (f *fakeDNSProvider) Records(name string) ([]externaldns.Record, error) {
+	return nil, nil
+}
+
+func (f *fakeDNSProvider) Upsert(record externaldns.Record) error {
+	f.records[record.Name] = record
+	return nil
+}
+
+func (f *fakeDNSProvider) Delete(name, recordType string) error {
+	delete(f.records, name)
+	return nil
+}
+
+func TestNewDNSChallengeProvider(t *testing.T) {
+	provider, err := newDNSChallengeProvider(&DNSChallenge{Route53: &externaldns.Route53{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeout, interval := provider.Timeout(); timeout != 2*time.Minute || interval != 4*time.Second {
+		t.Errorf("got route53 defaults %s %s", timeout, interval)
+	}
+
+	provider, err = newDNSChallengeProvider(&DNSChallenge{RFC2136: &externaldns.RFC2136{}, PropagationTimeout: 300, PollingInterval: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeout, interval := provider.Timeout(); timeout != 5*time.Minute || interval != 10*time.Second {
+		t.Errorf("got overridden %s %s", timeout, interval)
+	}
+
+	for _, config := range []*DNSChallenge{{}, {Route53: &externaldns.Route53{}, Cloudflare: &externaldns.Cloudflare{}}} {
+		if _, err := newDNSChallengeProvider(config); err == nil {
+			t.Errorf("expected an error for %+v", config)
+		}
+	}
+}
+
+func TestDNSChallengeProviderRecords(t *testing.T) {
+	fake := &fakeDNSProvider{records: map[string]externaldns.Record{}}
+	provider := &dnsChallengeProvider{provider: fake}
+	if err := provider.Present("foo.com", "token", "token.key"); err != nil {
+		t.Fatal(err)
+	}
+	record, ok := fake.records["_acme-challenge.foo.com"]
+	if !ok || record.Type != "TXT" || len(record.Value) == 0 {
+		t.Fatalf("got records %+v", fake.records)
+	}
+	if err := provider.CleanUp("foo.com", "token", "token.key"); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.records) != 0 {
+		t.Errorf("records not cleaned up: %+v", fake.records)
+	}
+}
+
+func TestResolversNameservers(t *testing.T) {
+	resolvers := Resolvers{}
+	resolvers.Set("10.0.0.53,dns.internal:5353")
+	resolvers.Set("[fd00::53]:53;fd00::54")
+	expected := []string{"10.0.0.53:53", "dns.internal:5353", "[fd00::53]:53", "[fd00::54]:53"}
+	if nameservers := resolvers.nameservers(); !reflect.DeepEqual(expected, nameservers) {
+		t.Errorf("expected %v, got %v", expected, nameservers)
+	}
+}
@@ -0,0 +1,172 @@
+package acme
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/containous/traefik/cluster"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/xenolf/lego/acme"
+)
+
+// httpChallengePath is the path of the HTTP-01 challenges, without the token
+const httpChallengePath = "/.well-known/acme-challenge/"
+
+var _ acme.ChallengeProvider = (*httpChallengeProvider)(nil)
+
+// HTTPChallenge configures the HTTP-01 challenge, answered on a plain HTTP entrypoint
+type HTTPChallenge struct {
+	EntryPoint       string           `description:"Entrypoint answering the HTTP-01 challenges, it may listen on another port than 80 behind a proxy"`
+	PathPrefix       string           `description:"Path prefix of the challenges, when a proxy forwards them under a prefix"`
+	DelegatedDomains DelegatedDomains `description:"Answer the challenges of these domains only, *.domain matches the subdomains"`
+}
+
+// DelegatedDomains holds the domains whose HTTP-01 challenges are answered by traefik
+type DelegatedDomains []string
+
+// Set adds strings elem into the the parser
+// it splits str on , and ;
+func (d *DelegatedDomains) Set(str string) error {
+	fargs := func(c rune) bool {
+		return c == ',' || c == ';'
+	}
+	*d = append(*d, strings.FieldsFunc(str, fargs)...)
+	return nil
+}
+
+// Get []string
+func (d *DelegatedDomains) Get() interface{} { return DelegatedDomains(*d) }
+
+// String return slice in a string
+func (d *DelegatedDomains) String() string { return fmt.Sprintf("%v", *d) }
+
+// SetValue sets []string into the parser
+func (d *DelegatedDomains) SetValue(val interface{}) {
+	*d = DelegatedDomains(val.(DelegatedDomains))
+}
+
+// Match returns true if the challenges of domain are delegated to traefik,
+// that is if the list is empty or holds domain or a wildcard of its parent.
+func (d DelegatedDomains) Match(domain string) bool {
+	if len(d) == 0 {
+		return true
+	}
+	for _, delegated := range d {
+		delegated = types.CanonicalDomain(delegated)
+		if strings.HasPrefix(delegated, "*.") {
+			if strings.HasSuffix(domain, delegated[1:]) {
+				return true
+			}
+		} else if domain == delegated {
+			return true
+		}
+	}
+	return false
+}
+
+// httpChallengeProvider stores the HTTP-01 key authorizations in the account,
+// so that every traefik of a cluster answers the challenges.
+type httpChallengeProvider struct {
+	config *HTTPChallenge
+	lock   sync.RWMutex
+	store  cluster.Store
+}
+
+func (c *httpChallengeProvider) setStore(store cluster.Store) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.store = store
+}
+
+func (c *httpChallengeProvider) Present(domain, token, keyAuth string) error {
+	log.Debugf("HTTP challenge Present %s", domain)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	transaction, object, err := c.store.Begin()
+	if err != nil {
+		return err
+	}
+	account := object.(*Account)
+	if account.HTTPChallenges == nil {
+		account.HTTPChallenges = map[string]map[string]string{}
+	}
+	if account.HTTPChallenges[domain] == nil {
+		account.HTTPChallenges[domain] = map[string]string{}
+	}
+	account.HTTPChallenges[domain][token] = keyAuth
+	return transaction.Commit(account)
+}
+
+func (c *httpChallengeProvider) CleanUp(domain, token, keyAuth string) error {
+	log.Debugf("HTTP challenge CleanUp %s", domain)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	transaction, object, err := c.store.Begin()
+	if err != nil {
+		return err
+	}
+	account := object.(*Account)
+	if tokens, ok := account.HTTPChallenges[domain]; ok {
+		delete(tokens, token)
+		if len(tokens) == 0 {
+			delete(account.HTTPChallenges, domain)
+		}
+	}
+	return transaction.Commit(account)
+}
+
+// ServeHTTP answers the HTTP-01 challenges of the delegated domains, and
+// passes the other requests to next.
+// The domain is read from X-Forwarded-Host when a proxy forwards the challenges.
+func (c *httpChallengeProvider) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	challengePath := strings.TrimSuffix(c.config.PathPrefix, "/") + httpChallengePath
+	if !strings.HasPrefix(r.URL.Path, challengePath) {
+		next(rw, r)
+		return
+	}
+	domain := challengeDomain(r)
+	if !c.config.DelegatedDomains.Match(domain) {
+		next(rw, r)
+		return
+	}
+	token := strings.TrimPrefix(r.URL.Path, challengePath)
+	if keyAuth, ok := c.keyAuth(domain, token); ok {
+		log.Debugf("HTTP challenge answered for %s", domain)
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.Write([]byte(keyAuth))
+		return
+	}
+	log.Debugf("No HTTP challenge %s for %s", token, domain)
+	http.NotFound(rw, r)
+}
+
+func (c *httpChallengeProvider) keyAuth(domain, token string) (string, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.store == nil {
+		return "", false
+	}
+	account, ok := c.store.Get().(*Account)
+	if !ok || account == nil {
+		return "", false
+	}
+	keyAuth, ok := account.HTTPChallenges[domain][token]
+	return keyAuth, ok
+}
+
+// challengeDomain returns the domain validated by r, from the first
+// X-Forwarded-Host or from the Host header.
+func challengeDomain(r *http.Request) string {
+	host := r.Host
+	if forwardedHost := r.Header.Get("X-Forwarded-Host"); len(forwardedHost) > 0 {
+		host = strings.TrimSpace(strings.Split(forwardedHost, ",")[0])
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	return types.CanonicalDomain(host)
+}
@@ -0,0 +1,103 @@
+package acme
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func newTestHTTPChallengeProvider(t *testing.T, config *HTTPChallenge) *httpChallengeProvider {
+	file, err := ioutil.TempFile("", "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	store := NewLocalStore(file.Name())
+	store.account = &Account{}
+	provider := &httpChallengeProvider{config: config}
+	provider.setStore(store)
+	return provider
+}
+
+func serveHTTPChallenge(provider *httpChallengeProvider, host, forwardedHost, path string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest("GET", "http://"+host+path, nil)
+	if len(forwardedHost) > 0 {
+		request.Header.Set("X-Forwarded-Host", forwardedHost)
+	}
+	recorder := httptest.NewRecorder()
+	provider.ServeHTTP(recorder, request, func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	})
+	return recorder
+}
+
+func TestHTTPChallengeProvider(t *testing.T) {
+	provider := newTestHTTPChallengeProvider(t, &HTTPChallenge{PathPrefix: "/edge/"})
+	defer os.Remove(provider.store.(*LocalStore).file)
+	if err := provider.Present("foo.com", "token", "token.key"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		host, forwardedHost, path string
+		status                    int
+		body                      string
+	}{
+		{"foo.com", "", "/edge/.well-known/acme-challenge/token", http.StatusOK, "token.key"},
+		{"traefik:8080", "FOO.com:80, proxy", "/edge/.well-known/acme-challenge/token", http.StatusOK, "token.key"},
+		{"bar.com", "", "/edge/.well-known/acme-challenge/token", http.StatusNotFound, ""},
+		{"foo.com", "", "/edge/.well-known/acme-challenge/other", http.StatusNotFound, ""},
+		{"foo.com", "", "/.well-known/acme-challenge/token", http.StatusTeapot, ""},
+		{"foo.com", "", "/edge/index.html", http.StatusTeapot, ""},
+	}
+	for _, c := range cases {
+		recorder := serveHTTPChallenge(provider, c.host, c.forwardedHost, c.path)
+		if recorder.Code != c.status || len(c.body) > 0 && recorder.Body.String() != c.body {
+			t.Errorf("%s %s %s: got %d %q, expected %d %q", c.host, c.forwardedHost, c.path, recorder.Code, recorder.Body.String(), c.status, c.body)
+		}
+	}
+
+	if err := provider.CleanUp("foo.com", "token", "token.key"); err != nil {
+		t.Fatal(err)
+	}
+	if recorder := serveHTTPChallenge(provider, "foo.com", "", "/edge/.well-known/acme-challenge/token"); recorder.Code != http.StatusNotFound {
+		t.Errorf("got %d after clean up, expected %d", recorder.Code, http.StatusNotFound)
+	}
+	if len(provider.store.Get().(*Account).HTTPChallenges) != 0 {
+		t.Errorf("challenges not cleaned up: %+v", provider.store.Get().(*Account).HTTPChallenges)
+	}
+}
+
+func TestHTTPChallengeDelegatedDomains(t *testing.T) {
+	provider := newTestHTTPChallengeProvider(t, &HTTPChallenge{DelegatedDomains: DelegatedDomains{"foo.com", "*.bar.com"}})
+	defer os.Remove(provider.store.(*LocalStore).file)
+	for _, domain := range []string{"foo.com", "www.bar.com", "baz.com"} {
+		if err := provider.Present(domain, "token", "token.key"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkMap := map[string]int{
+		"foo.com":     http.StatusOK,
+		"www.bar.com": http.StatusOK,
+		"bar.com":     http.StatusTeapot,
+		"www.foo.com": http.StatusTeapot,
+		"baz.com":     http.StatusTeapot,
+	}
+	for host, status := range checkMap {
+		if recorder := serveHTTPChallenge(provider, host, "", "/.well-known/acme-challenge/token"); recorder.Code != status {
+			t.Errorf("%s: got %d, expected %d", host, recorder.Code, status)
+		}
+	}
+}
+
+func TestDelegatedDomainsSet(t *testing.T) {
+	domains := DelegatedDomains{}
+	domains.Set("foo.com,*.bar.com")
+	domains.Set("baz.com")
+	if len(domains) != 3 || domains[1] != "*.bar.com" || domains[2] != "baz.com" {
+		t.Errorf("got %v", domains)
+	}
+}
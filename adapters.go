@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares"
 )
 
 // OxyLogger implements oxy Logger interface with logrus.
@@ -29,6 +30,7 @@ func (oxylogger *OxyLogger) Errorf(format string, args ...interface{}) {
 }
 
 func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	middlewares.SetErrorReason(r, middlewares.ReasonNoRoute)
 	http.NotFound(w, r)
 	//templatesRenderer.HTML(w, http.StatusNotFound, "notFound", nil)
 }
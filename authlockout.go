@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/types"
+)
+
+// AuthLockouts keeps the brute-force lockouts of the entrypoints and frontends
+// authentications, so that the locked out clients stay locked out across the
+// configuration reloads.
+type AuthLockouts struct {
+	mutex    sync.Mutex
+	lockouts map[authLockoutKey]*middlewares.AuthLockout
+}
+
+type authLockoutKey struct {
+	entryPoint string
+	frontend   string
+}
+
+// AuthLockoutStatus holds the lockouts of the authentication of an entrypoint or a frontend.
+type AuthLockoutStatus struct {
+	EntryPoint string `json:"entryPoint,omitempty"`
+	Frontend   string `json:"frontend,omitempty"`
+	*middlewares.AuthLockoutStats
+}
+
+// NewAuthLockouts returns an empty AuthLockouts.
+func NewAuthLockouts() *AuthLockouts {
+	return &AuthLockouts{lockouts: map[authLockoutKey]*middlewares.AuthLockout{}}
+}
+
+// Authenticator returns an authenticator for auth, sharing its lockout with the
+// previous authenticators of the same entrypoint or frontend.
+func (a *AuthLockouts) Authenticator(entryPoint, frontend string, auth *types.Auth) (*middlewares.Authenticator, error) {
+	if auth == nil || auth.LockoutConfig() == nil {
+		return middlewares.NewAuthenticator(auth)
+	}
+	key := authLockoutKey{entryPoint: entryPoint, frontend: frontend}
+	a.mutex.Lock()
+	lockout, ok := a.lockouts[key]
+	if ok {
+		lockout.SetConfig(auth.LockoutConfig())
+	} else {
+		lockout = middlewares.NewAuthLockout(auth.LockoutConfig())
+		a.lockouts[key] = lockout
+	}
+	a.mutex.Unlock()
+	return middlewares.NewAuthenticatorWithLockout(auth, lockout)
+}
+
+// SetFrontends drops the lockouts of the frontends that are not in frontends anymore.
+func (a *AuthLockouts) SetFrontends(frontends map[string]bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for key := range a.lockouts {
+		if len(key.frontend) > 0 && !frontends[key.frontend] {
+			delete(a.lockouts, key)
+		}
+	}
+}
+
+// Data returns the lockouts of the entrypoints, then of the frontends, sorted by name.
+func (a *AuthLockouts) Data() []*AuthLockoutStatus {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	statuses := []*AuthLockoutStatus{}
+	for key, lockout := range a.lockouts {
+		statuses = append(statuses, &AuthLockoutStatus{EntryPoint: key.entryPoint, Frontend: key.frontend, AuthLockoutStats: lockout.Stats()})
+	}
+	sort.Sort(authLockoutStatusesByName(statuses))
+	return statuses
+}
+
+// authHandler returns a handler authenticating the requests before they are served by next.
+func authHandler(authenticator *middlewares.Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		authenticator.ServeHTTP(rw, r, next.ServeHTTP)
+	})
+}
+
+type authLockoutStatusesByName []*AuthLockoutStatus
+
+func (a authLockoutStatusesByName) Len() int      { return len(a) }
+func (a authLockoutStatusesByName) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a authLockoutStatusesByName) Less(i, j int) bool {
+	if (len(a[i].EntryPoint) == 0) != (len(a[j].EntryPoint) == 0) {
+		return len(a[i].EntryPoint) > 0
+	}
+	if a[i].EntryPoint != a[j].EntryPoint {
+		return a[i].EntryPoint < a[j].EntryPoint
+	}
+	return a[i].Frontend < a[j].Frontend
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthLockoutsReload(t *testing.T) {
+	lockouts := NewAuthLockouts()
+	auth := &types.Auth{Basic: &types.Basic{Users: []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}, Lockout: &types.Lockout{}}}
+	for _, name := range []string{"frontend2", "frontend1"} {
+		if _, err := lockouts.Authenticator("", name, auth); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := lockouts.Authenticator("https", "", auth); err != nil {
+		t.Fatal(err)
+	}
+	// without lockout, nothing is kept
+	if _, err := lockouts.Authenticator("", "frontend3", &types.Auth{Basic: &types.Basic{}}); err != nil {
+		t.Fatal(err)
+	}
+	first := lockouts.lockouts[authLockoutKey{frontend: "frontend1"}]
+
+	// the lockouts are kept across the reloads
+	if _, err := lockouts.Authenticator("", "frontend1", auth); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, first == lockouts.lockouts[authLockoutKey{frontend: "frontend1"}])
+
+	lockouts.SetFrontends(map[string]bool{"frontend1": true})
+	statuses := lockouts.Data()
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, "https", statuses[0].EntryPoint)
+		assert.Equal(t, "frontend1", statuses[1].Frontend)
+	}
+}
@@ -0,0 +1,156 @@
+package aws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	// metadataURL is the EC2 instance metadata service
+	metadataURL = "http://169.254.169.254/latest"
+	// taskCredentialsURL is the ECS task credentials service
+	taskCredentialsURL = "http://169.254.170.2"
+	metadataClient     = &http.Client{Timeout: 5 * time.Second}
+)
+
+// metadataToken returns an IMDSv2 session token, or an empty token if the
+// metadata service only supports IMDSv1.
+func metadataToken() string {
+	request, err := http.NewRequest("PUT", metadataURL+"/api/token", nil)
+	if err != nil {
+		return ""
+	}
+	request.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	response, err := metadataClient.Do(request)
+	if err != nil {
+		return ""
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return ""
+	}
+	token, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return ""
+	}
+	return string(token)
+}
+
+func getJSON(url string, header http.Header, value interface{}) error {
+	data, err := get(url, header)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), value)
+}
+
+func get(url string, header http.Header) (string, error) {
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	response, err := metadataClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s answered %s", url, response.Status)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// metadata returns the value of an EC2 instance metadata path.
+func metadata(path string) (string, error) {
+	header := http.Header{}
+	if token := metadataToken(); len(token) > 0 {
+		header.Set("X-aws-ec2-metadata-token", token)
+	}
+	return get(metadataURL+"/meta-data/"+path, header)
+}
+
+// InstanceID returns the ID of the EC2 instance traefik runs on.
+func InstanceID() (string, error) {
+	return metadata("instance-id")
+}
+
+// TaskIP returns the IP of the ECS task traefik runs in, if the task uses the awsvpc network mode.
+func TaskIP() (string, error) {
+	metadataURI := os.Getenv("ECS_CONTAINER_METADATA_URI_V4")
+	if len(metadataURI) == 0 {
+		return "", errors.New("not running in an ECS task")
+	}
+	container := struct {
+		Networks []struct {
+			NetworkMode   string
+			IPv4Addresses []string
+		}
+	}{}
+	if err := getJSON(metadataURI, nil, &container); err != nil {
+		return "", err
+	}
+	for _, network := range container.Networks {
+		if network.NetworkMode == "awsvpc" && len(network.IPv4Addresses) > 0 {
+			return network.IPv4Addresses[0], nil
+		}
+	}
+	return "", errors.New("the ECS task doesn't use the awsvpc network mode")
+}
+
+type roleCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	Token           string
+}
+
+// GetCredentials returns the given credentials if they are set, else the
+// credentials of the environment, of the ECS task role or of the EC2 instance role.
+func GetCredentials(accessKeyID, secretAccessKey string) (*Credentials, error) {
+	if len(accessKeyID) > 0 && len(secretAccessKey) > 0 {
+		return &Credentials{AccessKeyID: accessKeyID, SecretAccessKey: secretAccessKey}, nil
+	}
+	if len(os.Getenv("AWS_ACCESS_KEY_ID")) > 0 && len(os.Getenv("AWS_SECRET_ACCESS_KEY")) > 0 {
+		return &Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	role := &roleCredentials{}
+	if relativeURI := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); len(relativeURI) > 0 {
+		if err := getJSON(taskCredentialsURL+relativeURI, nil, role); err != nil {
+			return nil, fmt.Errorf("Error getting the ECS task role credentials: %v", err)
+		}
+	} else {
+		roleName, err := metadata("iam/security-credentials/")
+		if err != nil {
+			return nil, errors.New("AWS credentials must be set: no access key, and no EC2 instance role")
+		}
+		header := http.Header{}
+		if token := metadataToken(); len(token) > 0 {
+			header.Set("X-aws-ec2-metadata-token", token)
+		}
+		roleName = strings.SplitN(roleName, "\n", 2)[0]
+		if err := getJSON(metadataURL+"/meta-data/iam/security-credentials/"+roleName, header, role); err != nil {
+			return nil, fmt.Errorf("Error getting the EC2 instance role credentials: %v", err)
+		}
+	}
+	if len(role.AccessKeyID) == 0 || len(role.SecretAccessKey) == 0 {
+		return nil, errors.New("Empty AWS role credentials")
+	}
+	return &Credentials{AccessKeyID: role.AccessKeyID, SecretAccessKey: role.SecretAccessKey, SessionToken: role.Token}, nil
+}
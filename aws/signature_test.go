@@ -0,0 +1,37 @@
+package aws
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSignRequest(t *testing.T) {
+	// get-vanilla case of the AWS signature version 4 test suite
+	request, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials := &Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	SignRequest(request, nil, credentials, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if authorization := request.Header.Get("Authorization"); authorization != expected {
+		t.Fatalf("expected %s, got %s", expected, authorization)
+	}
+}
+
+func TestCanonicalQueryString(t *testing.T) {
+	query := map[string][]string{
+		"type":     {"TXT"},
+		"name":     {"_traefik-owner.foo bar.example.com."},
+		"maxitems": {"10"},
+	}
+	expected := "maxitems=10&name=_traefik-owner.foo%20bar.example.com.&type=TXT"
+	if actual := canonicalQueryString(query); actual != expected {
+		t.Fatalf("expected %s, got %s", expected, actual)
+	}
+}
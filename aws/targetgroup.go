@@ -0,0 +1,179 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// elbAPIVersion is the version of the Elastic Load Balancing (v2) API
+	elbAPIVersion = "2015-12-01"
+	// defaultDeregistrationDelay is the deregistration delay of the target groups by default
+	defaultDeregistrationDelay = 300 * time.Second
+)
+
+// TargetGroup registers traefik in an ALB or NLB target group
+type TargetGroup struct {
+	ARN                 string `description:"ARN of the target group"`
+	EntryPoint          string `description:"Entrypoint whose port is registered in the target group"`
+	TargetID            string `description:"ID of the registered target: an instance ID or an IP. Defaults to the IP of the ECS task in awsvpc network mode, or to the EC2 instance ID"`
+	DeregistrationDelay int64  `description:"Seconds to keep serving after the deregistration, while the load-balancer drains the connections. Defaults to the deregistration delay of the target group"`
+	AccessKeyID         string `description:"AWS access key ID. Defaults to the AWS_ACCESS_KEY_ID environment variable, or the instance role"`
+	SecretAccessKey     string `description:"AWS secret access key. Defaults to the AWS_SECRET_ACCESS_KEY environment variable, or the instance role"`
+	endpoint            string
+	target              url.Values
+	lock                sync.Mutex
+}
+
+type targetGroupAttributes struct {
+	Attributes []struct {
+		Key   string
+		Value string
+	} `xml:"DescribeTargetGroupAttributesResult>Attributes>member"`
+}
+
+type elbErrorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+// region returns the region of the target group, from its ARN
+// arn:aws:elasticloadbalancing:<region>:<account>:targetgroup/<name>/<id>.
+func (t *TargetGroup) region() (string, error) {
+	parts := strings.Split(t.ARN, ":")
+	if len(parts) < 6 || parts[2] != "elasticloadbalancing" || len(parts[3]) == 0 {
+		return "", fmt.Errorf("Invalid target group ARN %s", t.ARN)
+	}
+	return parts[3], nil
+}
+
+func (t *TargetGroup) call(action string, parameters url.Values, result interface{}) error {
+	region, err := t.region()
+	if err != nil {
+		return err
+	}
+	credentials, err := GetCredentials(t.AccessKeyID, t.SecretAccessKey)
+	if err != nil {
+		return err
+	}
+	endpoint := t.endpoint
+	if len(endpoint) == 0 {
+		endpoint = "https://elasticloadbalancing." + region + ".amazonaws.com/"
+	}
+
+	form := url.Values{}
+	for name, values := range parameters {
+		form[name] = values
+	}
+	form.Set("Action", action)
+	form.Set("Version", elbAPIVersion)
+	form.Set("TargetGroupArn", t.ARN)
+	body := []byte(form.Encode())
+	request, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	SignRequest(request, body, credentials, region, "elasticloadbalancing", time.Now().UTC())
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		errorResponse := &elbErrorResponse{}
+		if err := xml.Unmarshal(data, errorResponse); err != nil || len(errorResponse.Code) == 0 {
+			return fmt.Errorf("%s answered %s", action, response.Status)
+		}
+		return fmt.Errorf("%s answered %s: %s: %s", action, response.Status, errorResponse.Code, errorResponse.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return xml.Unmarshal(data, result)
+}
+
+func (t *TargetGroup) targetID() (string, error) {
+	if len(t.TargetID) > 0 {
+		return t.TargetID, nil
+	}
+	if ip, err := TaskIP(); err == nil {
+		return ip, nil
+	}
+	id, err := InstanceID()
+	if err != nil {
+		return "", errors.New("Target ID must be set when not running on ECS or EC2")
+	}
+	return id, nil
+}
+
+// Register registers the traefik target, with port, in the target group.
+func (t *TargetGroup) Register(port int) error {
+	id, err := t.targetID()
+	if err != nil {
+		return err
+	}
+	target := url.Values{}
+	target.Set("Targets.member.1.Id", id)
+	target.Set("Targets.member.1.Port", strconv.Itoa(port))
+	if err := t.call("RegisterTargets", target, nil); err != nil {
+		return err
+	}
+	t.lock.Lock()
+	t.target = target
+	t.lock.Unlock()
+	return nil
+}
+
+// Deregister deregisters the traefik target from the target group, and returns
+// the delay the load-balancer takes to drain its connections. The delay is
+// the default one if it can't be read, along with the error.
+func (t *TargetGroup) Deregister() (time.Duration, error) {
+	t.lock.Lock()
+	target := t.target
+	t.target = nil
+	t.lock.Unlock()
+	if target == nil {
+		return 0, errors.New("Target is not registered")
+	}
+	if err := t.call("DeregisterTargets", target, nil); err != nil {
+		return 0, err
+	}
+	if t.DeregistrationDelay > 0 {
+		return time.Duration(t.DeregistrationDelay) * time.Second, nil
+	}
+	return t.deregistrationDelay()
+}
+
+// deregistrationDelay reads the deregistration delay attribute of the target group.
+func (t *TargetGroup) deregistrationDelay() (time.Duration, error) {
+	attributes := &targetGroupAttributes{}
+	if err := t.call("DescribeTargetGroupAttributes", url.Values{}, attributes); err != nil {
+		return defaultDeregistrationDelay, err
+	}
+	for _, attribute := range attributes.Attributes {
+		if attribute.Key == "deregistration_delay.timeout_seconds" {
+			seconds, err := strconv.Atoi(attribute.Value)
+			if err != nil {
+				return defaultDeregistrationDelay, err
+			}
+			return time.Duration(seconds) * time.Second, nil
+		}
+	}
+	return defaultDeregistrationDelay, errors.New("No deregistration delay attribute")
+}
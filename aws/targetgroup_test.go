@@ -0,0 +1,85 @@
+package aws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTargetGroupRegistration(t *testing.T) {
+	actions := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/elasticloadbalancing/aws4_request") {
+			t.Errorf("unexpected authorization %s", r.Header.Get("Authorization"))
+		}
+		action := r.PostForm.Get("Action")
+		actions = append(actions, action+" "+r.PostForm.Get("Targets.member.1.Id")+" "+r.PostForm.Get("Targets.member.1.Port"))
+		switch action {
+		case "RegisterTargets", "DeregisterTargets":
+			w.Write([]byte(`<` + action + `Response/>`))
+		case "DescribeTargetGroupAttributes":
+			w.Write([]byte(`<DescribeTargetGroupAttributesResponse>
+  <DescribeTargetGroupAttributesResult>
+    <Attributes>
+      <member><Key>stickiness.enabled</Key><Value>false</Value></member>
+      <member><Key>deregistration_delay.timeout_seconds</Key><Value>45</Value></member>
+    </Attributes>
+  </DescribeTargetGroupAttributesResult>
+</DescribeTargetGroupAttributesResponse>`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`<ErrorResponse><Error><Code>InvalidAction</Code><Message>unknown</Message></Error></ErrorResponse>`))
+		}
+	}))
+	defer ts.Close()
+
+	targetGroup := &TargetGroup{
+		ARN:             "arn:aws:elasticloadbalancing:eu-west-1:123456789012:targetgroup/traefik/73e2d6bc24d8a067",
+		TargetID:        "i-0123456789abcdef0",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		endpoint:        ts.URL,
+	}
+	if _, err := targetGroup.Deregister(); err == nil {
+		t.Fatal("expected an error deregistering an unregistered target")
+	}
+	if err := targetGroup.Register(8080); err != nil {
+		t.Fatal(err)
+	}
+	delay, err := targetGroup.Deregister()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delay != 45*time.Second {
+		t.Fatalf("expected a 45s delay, got %s", delay)
+	}
+	expected := []string{
+		"RegisterTargets i-0123456789abcdef0 8080",
+		"DeregisterTargets i-0123456789abcdef0 8080",
+		"DescribeTargetGroupAttributes  ",
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Fatalf("expected actions %v, got %v", expected, actions)
+	}
+
+	if err := targetGroup.call("Unknown", nil, nil); err == nil || !strings.Contains(err.Error(), "InvalidAction") {
+		t.Fatalf("expected an InvalidAction error, got %v", err)
+	}
+}
+
+func TestTargetGroupRegion(t *testing.T) {
+	targetGroup := &TargetGroup{ARN: "arn:aws:elasticloadbalancing:eu-west-1:123456789012:targetgroup/traefik/73e2d6bc24d8a067"}
+	if region, err := targetGroup.region(); err != nil || region != "eu-west-1" {
+		t.Fatalf("expected region eu-west-1, got %s %v", region, err)
+	}
+	targetGroup.ARN = "traefik"
+	if _, err := targetGroup.region(); err == nil {
+		t.Fatal("expected an error for an invalid ARN")
+	}
+}
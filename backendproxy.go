@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+)
+
+// dialFunc opens a connection to addr.
+type dialFunc func(network, addr string) (net.Conn, error)
+
+// createProxyDialer returns the dial function opening the connections to the
+// servers of a backend through the proxy or the tunnel of config, reached with
+// forward.
+func createProxyDialer(config *types.BackendProxy, forward proxy.Dialer) (dialFunc, error) {
+	proxyURL, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backend proxy URL: %v", err)
+	}
+	if len(proxyURL.Host) == 0 {
+		return nil, fmt.Errorf("invalid backend proxy URL %s, expected a host", config.URL)
+	}
+	switch proxyURL.Scheme {
+	case "socks5":
+		var auth *proxy.Auth
+		if len(config.Username) > 0 {
+			auth = &proxy.Auth{User: config.Username, Password: config.Password}
+		}
+		socks, err := proxy.SOCKS5("tcp", defaultPort(proxyURL.Host, "1080"), auth, forward)
+		if err != nil {
+			return nil, err
+		}
+		return socks.Dial, nil
+	case "http", "https":
+		d := &connectDialer{forward: forward, addr: defaultPort(proxyURL.Host, "80")}
+		if proxyURL.Scheme == "https" {
+			d.addr = defaultPort(proxyURL.Host, "443")
+			d.tlsConfig = &tls.Config{ServerName: proxyURL.Host}
+			if host, _, err := net.SplitHostPort(proxyURL.Host); err == nil {
+				d.tlsConfig.ServerName = host
+			}
+		}
+		if len(config.Username) > 0 {
+			d.authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(config.Username+":"+config.Password))
+		}
+		return d.dial, nil
+	case "ssh":
+		return createSSHDialer(config, proxyURL, forward)
+	}
+	return nil, fmt.Errorf("unsupported backend proxy scheme %q, expected socks5, http, https or ssh", proxyURL.Scheme)
+}
+
+// defaultPort returns hostPort, with port if it has none.
+func defaultPort(hostPort, port string) string {
+	if _, _, err := net.SplitHostPort(hostPort); err == nil {
+		return hostPort
+	}
+	return net.JoinHostPort(hostPort, port)
+}
+
+// connectDialer tunnels the connections through an HTTP proxy with CONNECT.
+type connectDialer struct {
+	forward       proxy.Dialer
+	addr          string
+	tlsConfig     *tls.Config
+	authorization string
+}
+
+func (d *connectDialer) dial(network, addr string) (net.Conn, error) {
+	conn, err := d.forward.Dial("tcp", d.addr)
+	if err != nil {
+		return nil, err
+	}
+	if d.tlsConfig != nil {
+		conn = tls.Client(conn, d.tlsConfig)
+	}
+	conn.SetDeadline(time.Now().Add(backendDialTimeout))
+	request := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if len(d.authorization) > 0 {
+		request.Header.Set("Proxy-Authorization", d.authorization)
+	}
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused the tunnel to %s: %s", d.addr, addr, response.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were read in a buffer.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// sshDialer forwards the connections through an SSH server. The SSH
+// connection is shared by the connections of the backend, and opened again
+// once closed.
+type sshDialer struct {
+	forward proxy.Dialer
+	addr    string
+	config  *ssh.ClientConfig
+	lock    sync.Mutex
+	client  *ssh.Client
+}
+
+func createSSHDialer(config *types.BackendProxy, proxyURL *url.URL, forward proxy.Dialer) (dialFunc, error) {
+	if len(config.HostKey) == 0 {
+		return nil, errors.New("the host key of the SSH server of the backend proxy is required")
+	}
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(config.HostKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH host key: %v", err)
+	}
+	d := &sshDialer{
+		forward: forward,
+		addr:    defaultPort(proxyURL.Host, "22"),
+		config: &ssh.ClientConfig{
+			User: config.Username,
+			HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+				if !bytes.Equal(key.Marshal(), hostKey.Marshal()) {
+					return fmt.Errorf("the SSH host key of %s doesn't match", hostname)
+				}
+				return nil
+			},
+		},
+	}
+	if len(config.PrivateKey) > 0 {
+		keyPEM, err := readPEM(config.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSH private key: %v", err)
+		}
+		d.config.Auth = append(d.config.Auth, ssh.PublicKeys(signer))
+	}
+	if len(config.Password) > 0 {
+		d.config.Auth = append(d.config.Auth, ssh.Password(config.Password))
+	}
+	if len(d.config.Auth) == 0 {
+		return nil, errors.New("no private key nor password for the SSH server of the backend proxy")
+	}
+	return d.dial, nil
+}
+
+// connect returns the SSH connection, opened if needed.
+func (d *sshDialer) connect() (*ssh.Client, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.client != nil {
+		return d.client, nil
+	}
+	conn, err := d.forward.Dial("tcp", d.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(backendDialTimeout))
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, d.addr, d.config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	client := ssh.NewClient(sshConn, channels, requests)
+	d.client = client
+	go func() {
+		err := client.Wait()
+		log.Debugf("SSH connection to %s closed: %v", d.addr, err)
+		d.lock.Lock()
+		if d.client == client {
+			d.client = nil
+		}
+		d.lock.Unlock()
+	}()
+	return client, nil
+}
+
+func (d *sshDialer) dial(network, addr string) (net.Conn, error) {
+	client, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.Dial(network, addr)
+	if _, refused := err.(*ssh.OpenChannelError); err == nil || refused {
+		return conn, err
+	}
+	// the SSH connection is broken: it's opened again once
+	client.Close()
+	d.lock.Lock()
+	if d.client == client {
+		d.client = nil
+	}
+	d.lock.Unlock()
+	if client, err = d.connect(); err != nil {
+		return nil, err
+	}
+	return client.Dial(network, addr)
+}
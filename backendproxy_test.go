@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+// pipe copies the bytes between a and b until one of them closes.
+func pipe(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(a, b)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(b, a)
+		done <- struct{}{}
+	}()
+	<-done
+	a.Close()
+	b.Close()
+}
+
+// startTestListener serves the connections of a local listener with handle.
+func startTestListener(t *testing.T, handle func(net.Conn)) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+	return listener
+}
+
+// serveTestSOCKS5 serves the SOCKS5 CONNECT requests of conn authenticated
+// with the test password.
+func serveTestSOCKS5(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return
+	}
+	io.ReadFull(reader, make([]byte, header[1]))
+	conn.Write([]byte{5, 2})
+	// username and password authentication
+	version, _ := reader.ReadByte()
+	length, _ := reader.ReadByte()
+	user := make([]byte, length)
+	io.ReadFull(reader, user)
+	length, _ = reader.ReadByte()
+	password := make([]byte, length)
+	io.ReadFull(reader, password)
+	if version != 1 || string(user) != "test" || string(password) != "test" {
+		conn.Write([]byte{1, 1})
+		return
+	}
+	conn.Write([]byte{1, 0})
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(reader, request); err != nil || request[3] != 1 {
+		return
+	}
+	addr := make([]byte, 6)
+	io.ReadFull(reader, addr)
+	upstream, err := net.Dial("tcp", (&net.TCPAddr{IP: net.IP(addr[:4]), Port: int(binary.BigEndian.Uint16(addr[4:]))}).String())
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	pipe(&bufferedConn{Conn: conn, reader: reader}, upstream)
+}
+
+// serveTestSSH forwards the direct-tcpip channels of conn, authenticated with
+// the test password.
+func serveTestSSH(hostKey ssh.Signer) func(net.Conn) {
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if meta.User() != "test" || string(password) != "test" {
+				return nil, io.EOF
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+	return func(conn net.Conn) {
+		_, channels, requests, err := ssh.NewServerConn(conn, config)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(requests)
+		for newChannel := range channels {
+			var destination struct {
+				Host       string
+				Port       uint32
+				OriginHost string
+				OriginPort uint32
+			}
+			if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &destination) != nil {
+				newChannel.Reject(ssh.UnknownChannelType, "unsupported channel")
+				continue
+			}
+			upstream, err := net.Dial("tcp", net.JoinHostPort(destination.Host, strconv.Itoa(int(destination.Port))))
+			if err != nil {
+				newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			channel, channelRequests, err := newChannel.Accept()
+			if err != nil {
+				upstream.Close()
+				continue
+			}
+			go ssh.DiscardRequests(channelRequests)
+			go func() {
+				go func() {
+					io.Copy(channel, upstream)
+					channel.CloseWrite()
+				}()
+				io.Copy(upstream, channel)
+				upstream.Close()
+			}()
+		}
+	}
+}
+
+func TestBackendProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("backend"))
+	}))
+	defer backend.Close()
+
+	connectProxy := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != "CONNECT" || r.Header.Get("Proxy-Authorization") != "Basic dGVzdDp0ZXN0" {
+			http.Error(rw, http.StatusText(http.StatusProxyAuthRequired), http.StatusProxyAuthRequired)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, _ := rw.(http.Hijacker).Hijack()
+		conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+		pipe(conn, upstream)
+	}))
+	defer connectProxy.Close()
+
+	socksProxy := startTestListener(t, serveTestSOCKS5)
+	defer socksProxy.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	hostKey, err := ssh.NewSignerFromKey(key)
+	assert.NoError(t, err)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherHostKey, _ := ssh.NewSignerFromKey(otherKey)
+	sshServer := startTestListener(t, serveTestSSH(hostKey))
+	defer sshServer.Close()
+
+	cases := []struct {
+		desc   string
+		proxy  *types.BackendProxy
+		reject bool
+	}{
+		{desc: "CONNECT", proxy: &types.BackendProxy{URL: connectProxy.URL, Username: "test", Password: "test"}},
+		{desc: "CONNECT without credentials", proxy: &types.BackendProxy{URL: connectProxy.URL}, reject: true},
+		{desc: "SOCKS5", proxy: &types.BackendProxy{URL: "socks5://" + socksProxy.Addr().String(), Username: "test", Password: "test"}},
+		{desc: "SOCKS5 with a wrong password", proxy: &types.BackendProxy{URL: "socks5://" + socksProxy.Addr().String(), Username: "test", Password: "other"}, reject: true},
+		{desc: "SSH", proxy: &types.BackendProxy{URL: "ssh://" + sshServer.Addr().String(), Username: "test", Password: "test", HostKey: string(ssh.MarshalAuthorizedKey(hostKey.PublicKey()))}},
+		{desc: "SSH with another host key", proxy: &types.BackendProxy{URL: "ssh://" + sshServer.Addr().String(), Username: "test", Password: "test", HostKey: string(ssh.MarshalAuthorizedKey(otherHostKey.PublicKey()))}, reject: true},
+	}
+	for _, c := range cases {
+		transport, err := createBackendTransport(GlobalConfiguration{}, &types.BackendTLS{}, c.proxy, nil, time.Second)
+		if !assert.NoError(t, err, c.desc) {
+			continue
+		}
+		client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+		for i := 0; i < 2; i++ {
+			response, err := client.Get(backend.URL)
+			if c.reject {
+				assert.Error(t, err, c.desc)
+				continue
+			}
+			if !assert.NoError(t, err, c.desc) {
+				continue
+			}
+			body, _ := ioutil.ReadAll(response.Body)
+			response.Body.Close()
+			assert.Equal(t, "backend", string(body), c.desc)
+		}
+		transport.(*http.Transport).CloseIdleConnections()
+	}
+
+	invalid := []*types.BackendProxy{
+		{URL: "ftp://127.0.0.1:21"},
+		{URL: "socks5://"},
+		{URL: "ssh://127.0.0.1:22", Password: "test"},
+		{URL: "ssh://127.0.0.1:22", HostKey: string(ssh.MarshalAuthorizedKey(hostKey.PublicKey()))},
+	}
+	for _, proxy := range invalid {
+		_, err := createBackendTransport(GlobalConfiguration{}, &types.BackendTLS{}, proxy, nil, time.Second)
+		assert.Error(t, err, proxy.URL)
+	}
+}
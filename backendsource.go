@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/containous/traefik/types"
+	"golang.org/x/net/proxy"
+)
+
+// createSourceDialer returns the dialer opening the connections to the
+// servers of a backend from the source address of config.
+func createSourceDialer(config *types.BackendSource, dialer *net.Dialer) (proxy.Dialer, error) {
+	switch {
+	case len(config.IP) > 0 && len(config.Interface) > 0:
+		return nil, errors.New("the source of a backend is either an IP or an interface")
+	case len(config.IP) > 0:
+		ip := net.ParseIP(config.IP)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source IP %s", config.IP)
+		}
+		sourceDialer := *dialer
+		sourceDialer.LocalAddr = &net.TCPAddr{IP: ip}
+		return &sourceDialer, nil
+	case len(config.Interface) > 0:
+		return &interfaceDialer{dialer: dialer, name: config.Interface}, nil
+	}
+	return dialer, nil
+}
+
+// interfaceDialer opens the connections from the address of a network
+// interface. The address is looked up at each connection, as a tunnel
+// interface may come up, or change its address, after traefik starts.
+type interfaceDialer struct {
+	dialer *net.Dialer
+	name   string
+}
+
+func (d *interfaceDialer) Dial(network, addr string) (net.Conn, error) {
+	ip, err := d.address(addr)
+	if err != nil {
+		return nil, err
+	}
+	sourceDialer := *d.dialer
+	sourceDialer.LocalAddr = &net.TCPAddr{IP: ip}
+	return sourceDialer.Dial(network, addr)
+}
+
+// address returns the address of the interface of the family of the IP of
+// addr, IPv4 first when addr has a host name. The link-local addresses are
+// skipped.
+func (d *interfaceDialer) address(addr string) (net.IP, error) {
+	iface, err := net.InterfaceByName(d.name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source interface %s: %v", d.name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the addresses of the interface %s: %v", d.name, err)
+	}
+	wantIPv4, wantIPv6 := true, true
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if ip := net.ParseIP(host); ip != nil {
+			wantIPv4 = ip.To4() != nil
+			wantIPv6 = !wantIPv4
+		}
+	}
+	var ipv6 net.IP
+	for _, ifaceAddr := range addrs {
+		ipNet, ok := ifaceAddr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			if wantIPv4 {
+				return ipNet.IP, nil
+			}
+		} else if wantIPv6 && ipv6 == nil {
+			ipv6 = ipNet.IP
+		}
+	}
+	if ipv6 == nil {
+		return nil, fmt.Errorf("no address of the interface %s to reach %s", d.name, addr)
+	}
+	return ipv6, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendSource(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the loopback addresses other than 127.0.0.1 are only routed by linux")
+	}
+	backend := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		rw.Write([]byte(host))
+	}))
+	defer backend.Close()
+
+	loopback := "lo"
+	cases := []struct {
+		desc     string
+		source   *types.BackendSource
+		expected string
+	}{
+		{desc: "source IP", source: &types.BackendSource{IP: "127.0.0.2"}, expected: "127.0.0.2"},
+		{desc: "source interface", source: &types.BackendSource{Interface: loopback}, expected: "127.0.0.1"},
+	}
+	for _, c := range cases {
+		transport, err := createBackendTransport(GlobalConfiguration{}, &types.BackendTLS{}, nil, c.source, time.Second)
+		if !assert.NoError(t, err, c.desc) {
+			continue
+		}
+		response, err := (&http.Client{Transport: transport}).Get(backend.URL)
+		if !assert.NoError(t, err, c.desc) {
+			continue
+		}
+		body, _ := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		assert.Equal(t, c.expected, string(body), c.desc)
+	}
+
+	transport, err := createBackendTransport(GlobalConfiguration{}, &types.BackendTLS{}, nil, &types.BackendSource{Interface: "missing0"}, time.Second)
+	assert.NoError(t, err)
+	_, err = (&http.Client{Transport: transport}).Get(backend.URL)
+	assert.Error(t, err)
+
+	invalid := []*types.BackendSource{
+		{IP: "127.0.0"},
+		{IP: "127.0.0.2", Interface: loopback},
+	}
+	for _, source := range invalid {
+		_, err := createBackendTransport(GlobalConfiguration{}, &types.BackendTLS{}, nil, source, time.Second)
+		assert.Error(t, err, source.IP)
+	}
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/containous/traefik/version"
+)
+
+const (
+	// captureDefaultMaxBodySize is the default size limit of the captured bodies
+	captureDefaultMaxBodySize = 64 * 1024
+	// captureBatchSize is the number of entries written per HAR file
+	captureBatchSize = 100
+	// captureFlushInterval is the maximum delay before the pending entries are written
+	captureFlushInterval = time.Minute
+	// captureRedacted replaces the values of the redacted headers
+	captureRedacted = "[REDACTED]"
+)
+
+// captureDefaultRedactHeaders hold credentials, they are always redacted
+var captureDefaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+var captureFileNameReplacer = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// TrafficCapture records sampled requests of the frontends in HAR format, for
+// their replay in staging. The entries of each frontend are written by batches,
+// and at least every minute while Run is running.
+type TrafficCapture struct {
+	mutex     sync.Mutex
+	frontends map[string]*captureFrontend
+	client    *http.Client
+}
+
+type captureFrontend struct {
+	config  types.Capture
+	entries []*harEntry
+}
+
+// NewTrafficCapture returns a TrafficCapture without frontends.
+func NewTrafficCapture() *TrafficCapture {
+	return &TrafficCapture{
+		frontends: make(map[string]*captureFrontend),
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// validateCapture checks that capture has a destination.
+func validateCapture(capture *types.Capture) error {
+	if len(capture.Directory) == 0 && len(capture.URL) == 0 {
+		return errors.New("capture directory or URL must be set")
+	}
+	if capture.SampleRate < 0 || capture.SampleRate > 1 {
+		return fmt.Errorf("invalid capture sample rate %v, expected a number between 0 and 1", capture.SampleRate)
+	}
+	return nil
+}
+
+// Handler returns a handler recording a sample of the requests served by next for frontendName.
+func (c *TrafficCapture) Handler(frontendName string, capture *types.Capture, next http.Handler) http.Handler {
+	config := *capture
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = captureDefaultMaxBodySize
+	}
+	redactor := newHeaderRedactor(append(captureDefaultRedactHeaders, config.RedactHeaders...))
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if config.SampleRate > 0 && rand.Float64() >= config.SampleRate {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		requestBody := captureRequestBody(r, config.MaxBodySize)
+		recorder := &captureResponseWriter{ResponseWriter: rw, statusCode: http.StatusOK, maxBodySize: config.MaxBodySize}
+		start := time.Now()
+		next.ServeHTTP(recorder, r)
+		entry := newHAREntry(r, requestBody, recorder, start, time.Since(start), redactor)
+		c.record(frontendName, config, entry)
+	})
+}
+
+func (c *TrafficCapture) record(frontendName string, config types.Capture, entry *harEntry) {
+	c.mutex.Lock()
+	frontend, ok := c.frontends[frontendName]
+	if !ok {
+		frontend = &captureFrontend{}
+		c.frontends[frontendName] = frontend
+	}
+	frontend.config = config
+	frontend.entries = append(frontend.entries, entry)
+	var batch []*harEntry
+	if len(frontend.entries) >= captureBatchSize {
+		batch, frontend.entries = frontend.entries, nil
+	}
+	c.mutex.Unlock()
+	if batch != nil {
+		safe.Go(func() {
+			c.write(frontendName, config, batch)
+		})
+	}
+}
+
+// SetFrontends drops the frontends that are not captured anymore, after writing their pending entries.
+func (c *TrafficCapture) SetFrontends(frontendNames map[string]bool) {
+	c.mutex.Lock()
+	dropped := map[string]*captureFrontend{}
+	for frontendName, frontend := range c.frontends {
+		if !frontendNames[frontendName] {
+			dropped[frontendName] = frontend
+			delete(c.frontends, frontendName)
+		}
+	}
+	c.mutex.Unlock()
+	for frontendName, frontend := range dropped {
+		c.write(frontendName, frontend.config, frontend.entries)
+	}
+}
+
+// Flush writes the pending entries of all the frontends.
+func (c *TrafficCapture) Flush() {
+	c.mutex.Lock()
+	pending := map[string]*captureFrontend{}
+	for frontendName, frontend := range c.frontends {
+		if len(frontend.entries) > 0 {
+			pending[frontendName] = &captureFrontend{config: frontend.config, entries: frontend.entries}
+			frontend.entries = nil
+		}
+	}
+	c.mutex.Unlock()
+	for frontendName, frontend := range pending {
+		c.write(frontendName, frontend.config, frontend.entries)
+	}
+}
+
+// Run flushes the pending entries periodically until stop is closed, and a last time before returning.
+func (c *TrafficCapture) Run(stop chan bool) {
+	ticker := time.NewTicker(captureFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			c.Flush()
+			return
+		case <-ticker.C:
+			c.Flush()
+		}
+	}
+}
+
+// write stores entries in a new HAR file, in the directory and under the URL of config.
+func (c *TrafficCapture) write(frontendName string, config types.Capture, entries []*harEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	document := &harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "traefik", Version: version.Version},
+		Entries: entries,
+	}}
+	data, err := json.Marshal(document)
+	if err != nil {
+		log.Errorf("Error encoding the capture of frontend %s: %v", frontendName, err)
+		return
+	}
+	name := captureFileNameReplacer.ReplaceAllString(frontendName, "_") + "-" + time.Now().UTC().Format("20060102T150405.000000000Z") + ".har"
+	if len(config.Directory) > 0 {
+		if err := writeCaptureFile(config.Directory, name, data); err != nil {
+			log.Errorf("Error writing the capture of frontend %s: %v", frontendName, err)
+		}
+	}
+	if len(config.URL) > 0 {
+		if err := c.uploadCapture(strings.TrimSuffix(config.URL, "/")+"/"+name, data); err != nil {
+			log.Errorf("Error uploading the capture of frontend %s: %v", frontendName, err)
+		}
+	}
+	log.Debugf("Captured %d requests of frontend %s in %s", len(entries), frontendName, name)
+}
+
+func writeCaptureFile(directory, name string, data []byte) error {
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(directory, name), data, 0600)
+}
+
+func (c *TrafficCapture) uploadCapture(url string, data []byte) error {
+	request, err := http.NewRequest("PUT", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s from %s", response.Status, url)
+	}
+	return nil
+}
+
+// headerRedactor tells the headers whose values must not be captured.
+type headerRedactor struct {
+	names    map[string]bool
+	prefixes []string
+}
+
+func newHeaderRedactor(headers []string) *headerRedactor {
+	redactor := &headerRedactor{names: map[string]bool{}}
+	for _, header := range headers {
+		if strings.HasSuffix(header, "*") {
+			redactor.prefixes = append(redactor.prefixes, http.CanonicalHeaderKey(strings.TrimSuffix(header, "*")))
+		} else {
+			redactor.names[http.CanonicalHeaderKey(header)] = true
+		}
+	}
+	return redactor
+}
+
+func (h *headerRedactor) redacted(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	if h.names[name] {
+		return true
+	}
+	for _, prefix := range h.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *headerRedactor) harHeaders(header http.Header) []harNameValue {
+	names := []string{}
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	headers := []harNameValue{}
+	for _, name := range names {
+		for _, value := range header[name] {
+			if h.redacted(name) {
+				value = captureRedacted
+			}
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+// capturedBody is the beginning of a body, up to the size limit of the capture.
+type capturedBody struct {
+	data      []byte
+	size      int64
+	truncated bool
+}
+
+// captureRequestBody reads the beginning of the body of r, and restores it for the next handlers.
+func captureRequestBody(r *http.Request, maxBodySize int64) *capturedBody {
+	body := &capturedBody{size: r.ContentLength}
+	if r.Body == nil || r.ContentLength == 0 {
+		body.size = 0
+		return body
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	if err != nil {
+		body.truncated = true
+	}
+	if int64(len(data)) > maxBodySize {
+		data = data[:maxBodySize]
+		body.truncated = true
+	}
+	body.data = data
+	if body.size < 0 && !body.truncated {
+		body.size = int64(len(data))
+	}
+	return body
+}
+
+// captureResponseWriter records the status code and the beginning of the response body.
+type captureResponseWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	maxBodySize int64
+	body        bytes.Buffer
+	size        int64
+}
+
+func (w *captureResponseWriter) WriteHeader(status int) {
+	w.ResponseWriter.WriteHeader(status)
+	w.statusCode = status
+}
+
+func (w *captureResponseWriter) Write(data []byte) (int, error) {
+	if remaining := w.maxBodySize - int64(w.body.Len()); remaining > 0 {
+		if int64(len(data)) > remaining {
+			w.body.Write(data[:remaining])
+		} else {
+			w.body.Write(data)
+		}
+	}
+	w.size += int64(len(data))
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *captureResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *captureResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *captureResponseWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+// harDocument is an HTTP Archive 1.2 document, see http://www.softwareishard.com/blog/har-12-spec/
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harContent    `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func newHAREntry(r *http.Request, requestBody *capturedBody, recorder *captureResponseWriter, start time.Time, duration time.Duration, redactor *headerRedactor) *harEntry {
+	milliseconds := float64(duration) / float64(time.Millisecond)
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	query := r.URL.Query()
+	keys := []string{}
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	queryString := []harNameValue{}
+	for _, key := range keys {
+		for _, value := range query[key] {
+			queryString = append(queryString, harNameValue{Name: key, Value: value})
+		}
+	}
+	entry := &harEntry{
+		StartedDateTime: start.UTC().Format(time.RFC3339Nano),
+		Time:            milliseconds,
+		Request: harRequest{
+			Method:      r.Method,
+			URL:         scheme + "://" + r.Host + r.URL.RequestURI(),
+			HTTPVersion: r.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     redactor.harHeaders(r.Header),
+			QueryString: queryString,
+			HeadersSize: -1,
+			BodySize:    requestBody.size,
+		},
+		Response: harResponse{
+			Status:      recorder.statusCode,
+			StatusText:  http.StatusText(recorder.statusCode),
+			HTTPVersion: r.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     redactor.harHeaders(recorder.Header()),
+			Content:     harBodyContent(recorder.Header().Get("Content-Type"), recorder.body.Bytes(), recorder.size),
+			RedirectURL: recorder.Header().Get("Location"),
+			HeadersSize: -1,
+			BodySize:    recorder.size,
+		},
+		Timings: harTimings{Wait: milliseconds},
+	}
+	if len(requestBody.data) > 0 {
+		postData := harBodyContent(r.Header.Get("Content-Type"), requestBody.data, requestBody.size)
+		entry.Request.PostData = &postData
+	}
+	truncated := []string{}
+	if requestBody.truncated {
+		truncated = append(truncated, "request")
+	}
+	if int64(recorder.body.Len()) < recorder.size {
+		truncated = append(truncated, "response")
+	}
+	if len(truncated) > 0 {
+		entry.Comment = strings.Join(truncated, " and ") + " body truncated"
+	}
+	return entry
+}
+
+// harBodyContent returns the HAR content of a body, base64 encoded if it is not text.
+func harBodyContent(mimeType string, data []byte, size int64) harContent {
+	content := harContent{Size: size, MimeType: mimeType}
+	if utf8.Valid(data) {
+		content.Text = string(data)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(data)
+		content.Encoding = "base64"
+	}
+	return content
+}
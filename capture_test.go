@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func readCapturedEntries(t *testing.T, directory string) []*harEntry {
+	files, err := filepath.Glob(filepath.Join(directory, "*.har"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []*harEntry{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		document := &harDocument{}
+		if err := json.Unmarshal(data, document); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "1.2", document.Log.Version)
+		entries = append(entries, document.Log.Entries...)
+	}
+	return entries
+}
+
+func TestTrafficCaptureHandler(t *testing.T) {
+	directory, err := ioutil.TempDir("", "capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	capture := NewTrafficCapture()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		rw.Header().Set("Set-Cookie", "session=secret")
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.WriteHeader(http.StatusCreated)
+		rw.Write([]byte("received " + string(body)))
+	})
+	handler := capture.Handler("frontend/1", &types.Capture{Directory: directory, MaxBodySize: 8, RedactHeaders: []string{"x-api-*"}}, next)
+
+	request := httptest.NewRequest("POST", "http://foo.com/orders?b=2&a=1", strings.NewReader("0123456789"))
+	request.Header.Set("Authorization", "Bearer token")
+	request.Header.Set("X-Api-Key", "key")
+	request.Header.Set("X-Request-Id", "42")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	// the backend still gets the whole body
+	assert.Equal(t, "received 0123456789", recorder.Body.String())
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	capture.Flush()
+	entries := readCapturedEntries(t, directory)
+	if !assert.Len(t, entries, 1) {
+		return
+	}
+	entry := entries[0]
+	assert.Equal(t, "POST", entry.Request.Method)
+	assert.Equal(t, "http://foo.com/orders?b=2&a=1", entry.Request.URL)
+	assert.Equal(t, []harNameValue{{"a", "1"}, {"b", "2"}}, entry.Request.QueryString)
+	assert.Equal(t, []harNameValue{{"Authorization", captureRedacted}, {"X-Api-Key", captureRedacted}, {"X-Request-Id", "42"}}, entry.Request.Headers)
+	assert.Equal(t, int64(10), entry.Request.BodySize)
+	assert.Equal(t, "01234567", entry.Request.PostData.Text)
+	assert.Equal(t, http.StatusCreated, entry.Response.Status)
+	assert.Contains(t, entry.Response.Headers, harNameValue{"Set-Cookie", captureRedacted})
+	assert.Equal(t, "received", entry.Response.Content.Text)
+	assert.Equal(t, int64(19), entry.Response.Content.Size)
+	assert.Equal(t, "request and response body truncated", entry.Comment)
+	matches, _ := filepath.Glob(filepath.Join(directory, "frontend_1-*.har"))
+	assert.Len(t, matches, 1)
+}
+
+func TestTrafficCaptureUpload(t *testing.T) {
+	uploads := map[string]*harDocument{}
+	store := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		document := &harDocument{}
+		if r.Method != "PUT" || json.NewDecoder(r.Body).Decode(document) != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		uploads[r.URL.Path] = document
+	}))
+	defer store.Close()
+
+	capture := NewTrafficCapture()
+	handler := capture.Handler("frontend1", &types.Capture{URL: store.URL + "/captures/"}, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}))
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+	// the pending entries of the removed frontends are written
+	capture.SetFrontends(map[string]bool{})
+	if !assert.Len(t, uploads, 1) {
+		return
+	}
+	for path, document := range uploads {
+		assert.True(t, strings.HasPrefix(path, "/captures/frontend1-"), path)
+		assert.Len(t, document.Log.Entries, 3)
+	}
+}
+
+func TestValidateCapture(t *testing.T) {
+	assert.NoError(t, validateCapture(&types.Capture{Directory: "/tmp", SampleRate: 0.1}))
+	assert.Error(t, validateCapture(&types.Capture{}))
+	assert.Error(t, validateCapture(&types.Capture{URL: "http://store", SampleRate: 2}))
+}
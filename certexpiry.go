@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// certificateExpiryScanner periodically logs warnings for the certificates
+// served by the entrypoints that expire within the warning delay, so that a
+// failed renewal or a forgotten static certificate is noticed in time.
+type certificateExpiryScanner struct {
+	warnDays     int
+	certificates func() []*CertificateInfo
+}
+
+func newCertificateExpiryScanner(config *types.CertExpiry, certificates func() []*CertificateInfo) *certificateExpiryScanner {
+	return &certificateExpiryScanner{warnDays: config.WarnDays, certificates: certificates}
+}
+
+// Run scans the certificates every interval until stop is closed.
+func (s *certificateExpiryScanner) Run(stop chan bool, interval time.Duration) {
+	s.scan(time.Now())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			s.scan(now)
+		}
+	}
+}
+
+// scan logs the certificates expired or expiring within the warning delay,
+// and returns how many were reported.
+func (s *certificateExpiryScanner) scan(now time.Time) int {
+	reported := 0
+	deadline := now.Add(time.Duration(s.warnDays) * 24 * time.Hour)
+	for _, certificate := range s.certificates() {
+		switch {
+		case now.After(certificate.NotAfter):
+			log.Errorf("Certificate %s (%s) of entrypoint %s expired on %s", certificate.Subject, certificate.Resolver, certificate.EntryPoint, certificate.NotAfter.UTC().Format(time.RFC3339))
+		case deadline.After(certificate.NotAfter):
+			days := int(certificate.NotAfter.Sub(now).Hours() / 24)
+			log.Warnf("Certificate %s (%s) of entrypoint %s expires in %d days, on %s", certificate.Subject, certificate.Resolver, certificate.EntryPoint, days, certificate.NotAfter.UTC().Format(time.RFC3339))
+		default:
+			continue
+		}
+		reported++
+	}
+	return reported
+}
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeCertificateMetrics writes the expiry of certificates as a gauge, in
+// the Prometheus text exposition format.
+func writeCertificateMetrics(w io.Writer, certificates []*CertificateInfo) {
+	fmt.Fprintln(w, "# HELP traefik_tls_certificate_not_after Expiry of the certificates served by the entrypoints, as a Unix timestamp.")
+	fmt.Fprintln(w, "# TYPE traefik_tls_certificate_not_after gauge")
+	for _, certificate := range certificates {
+		fmt.Fprintf(w, "traefik_tls_certificate_not_after{entrypoint=\"%s\",resolver=\"%s\",subject=\"%s\",sans=\"%s\"} %d\n",
+			prometheusLabelEscaper.Replace(certificate.EntryPoint),
+			prometheusLabelEscaper.Replace(certificate.Resolver),
+			prometheusLabelEscaper.Replace(certificate.Subject),
+			prometheusLabelEscaper.Replace(strings.Join(certificate.SANs, ",")),
+			certificate.NotAfter.Unix())
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificateExpiryScan(t *testing.T) {
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	certificates := []*CertificateInfo{
+		{EntryPoint: "https", Resolver: "static", Subject: "expired.localhost", NotAfter: now.Add(-time.Hour)},
+		{EntryPoint: "https", Resolver: "acme", Subject: "soon.localhost", NotAfter: now.Add(10 * 24 * time.Hour)},
+		{EntryPoint: "https", Resolver: "acme", Subject: "later.localhost", NotAfter: now.Add(60 * 24 * time.Hour)},
+	}
+	scanner := newCertificateExpiryScanner(&types.CertExpiry{WarnDays: 21}, func() []*CertificateInfo { return certificates })
+	assert.Equal(t, 2, scanner.scan(now))
+
+	scanner = newCertificateExpiryScanner(&types.CertExpiry{WarnDays: 5}, func() []*CertificateInfo { return certificates })
+	assert.Equal(t, 1, scanner.scan(now))
+}
+
+func TestWriteCertificateMetrics(t *testing.T) {
+	notAfter := time.Date(2017, 1, 31, 10, 12, 0, 0, time.UTC)
+	var buffer bytes.Buffer
+	writeCertificateMetrics(&buffer, []*CertificateInfo{
+		{EntryPoint: "https", Resolver: "static", Subject: `local"1.com`, SANs: []string{"local1.com", "test1.local1.com"}, NotAfter: notAfter},
+	})
+	assert.Equal(t, "# HELP traefik_tls_certificate_not_after Expiry of the certificates served by the entrypoints, as a Unix timestamp.\n"+
+		"# TYPE traefik_tls_certificate_not_after gauge\n"+
+		`traefik_tls_certificate_not_after{entrypoint="https",resolver="static",subject="local\"1.com",sans="local1.com,test1.local1.com"} 1485857520`+"\n",
+		buffer.String())
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidDESEDE3CBC     = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// loadX509KeyPair loads the key pair of the certificate, from its files if
+// isAPath or from the contents of its fields otherwise, decrypting the
+// private key with the passphrase of the certificate when it is encrypted.
+// Without KeyFile, CertFile is a PKCS#12 bundle.
+func (c Certificate) loadX509KeyPair(isAPath bool) (tls.Certificate, error) {
+	if len(c.KeyFile) == 0 {
+		return c.loadPKCS12(isAPath)
+	}
+	if len(c.KeyPassphrase) == 0 && len(c.KeyPassphraseFile) == 0 {
+		if isAPath {
+			return tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		}
+		return tls.X509KeyPair([]byte(c.CertFile), []byte(c.KeyFile))
+	}
+	certPEM, keyPEM := []byte(c.CertFile), []byte(c.KeyFile)
+	if isAPath {
+		var err error
+		if certPEM, err = ioutil.ReadFile(c.CertFile); err != nil {
+			return tls.Certificate{}, err
+		}
+		if keyPEM, err = ioutil.ReadFile(c.KeyFile); err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+	passphrase, err := c.keyPassphrase()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM, err = decryptPrivateKey(keyPEM, passphrase)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// loadPKCS12 loads the key pair of the PKCS#12 bundle CertFile, a file if
+// isAPath or its base64 encoded content otherwise, decrypted with the
+// passphrase of the certificate.
+func (c Certificate) loadPKCS12(isAPath bool) (tls.Certificate, error) {
+	var bundle []byte
+	var err error
+	if isAPath {
+		bundle, err = ioutil.ReadFile(c.CertFile)
+	} else {
+		bundle, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(c.CertFile), ""))
+	}
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("error reading the PKCS#12 bundle: %v", err)
+	}
+	passphrase, err := c.keyPassphrase()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	blocks, err := pkcs12.ToPEM(bundle, string(passphrase))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("error decoding the PKCS#12 bundle: %v", err)
+	}
+	return pkcs12KeyPair(blocks)
+}
+
+// pkcs12KeyPair returns the key pair of the blocks of a PKCS#12 bundle, with
+// the certificate of the private key as leaf followed by the others as chain.
+func pkcs12KeyPair(blocks []*pem.Block) (tls.Certificate, error) {
+	var keyPEM []byte
+	var certificates [][]byte
+	for _, block := range blocks {
+		// the attributes of the bag, like friendlyName, are dropped
+		encoded := pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: block.Bytes})
+		if block.Type == "CERTIFICATE" {
+			certificates = append(certificates, encoded)
+		} else if keyPEM == nil {
+			keyPEM = encoded
+		}
+	}
+	if keyPEM == nil {
+		return tls.Certificate{}, errors.New("no private key in the PKCS#12 bundle")
+	}
+	// the bags aren't ordered, the leaf is found by matching the private key
+	for i, leaf := range certificates {
+		certPEM := append([]byte{}, leaf...)
+		for j, certificate := range certificates {
+			if j != i {
+				certPEM = append(certPEM, certificate...)
+			}
+		}
+		if pair, err := tls.X509KeyPair(certPEM, keyPEM); err == nil {
+			return pair, nil
+		}
+	}
+	return tls.Certificate{}, errors.New("no certificate of the PKCS#12 bundle matches its private key")
+}
+
+// keyPassphrase returns the passphrase of the private key, read from
+// KeyPassphraseFile when it is set.
+func (c Certificate) keyPassphrase() ([]byte, error) {
+	if len(c.KeyPassphraseFile) == 0 {
+		return []byte(c.KeyPassphrase), nil
+	}
+	passphrase, err := ioutil.ReadFile(c.KeyPassphraseFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the key passphrase file: %v", err)
+	}
+	return []byte(strings.TrimRight(string(passphrase), "\r\n")), nil
+}
+
+// decryptPrivateKey returns keyPEM with its encrypted private keys decrypted
+// with passphrase: the PKCS#1 and SEC 1 keys encrypted with the legacy PEM
+// encryption, and the PKCS#8 keys encrypted with PBES2. The other blocks are
+// kept unchanged.
+func decryptPrivateKey(keyPEM []byte, passphrase []byte) ([]byte, error) {
+	var result bytes.Buffer
+	for {
+		var block *pem.Block
+		block, keyPEM = pem.Decode(keyPEM)
+		if block == nil {
+			break
+		}
+		switch {
+		case x509.IsEncryptedPEMBlock(block):
+			der, err := x509.DecryptPEMBlock(block, passphrase)
+			if err != nil {
+				return nil, fmt.Errorf("error decrypting the private key: %v", err)
+			}
+			block = &pem.Block{Type: block.Type, Bytes: der}
+		case block.Type == "ENCRYPTED PRIVATE KEY":
+			der, err := decryptPKCS8(block.Bytes, passphrase)
+			if err != nil {
+				return nil, fmt.Errorf("error decrypting the private key: %v", err)
+			}
+			block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+		}
+		if err := pem.Encode(&result, block); err != nil {
+			return nil, err
+		}
+	}
+	return result.Bytes(), nil
+}
+
+// decryptPKCS8 decrypts the DER encoded PKCS#8 EncryptedPrivateKeyInfo der,
+// encrypted with PBES2 (RFC 8018), as written by openssl pkcs8 -topk8.
+func decryptPKCS8(der []byte, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported encryption scheme %s, only PBES2 is supported", info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation function %s, only PBKDF2 is supported", params.KeyDerivationFunc.Algorithm)
+	}
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, err
+	}
+	var prf func() hash.Hash
+	switch {
+	case len(kdfParams.PRF.Algorithm) == 0, kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	default:
+		return nil, fmt.Errorf("unsupported pseudorandom function %s", kdfParams.PRF.Algorithm)
+	}
+
+	var keyLength int
+	var newCipher func(key []byte) (cipher.Block, error)
+	switch scheme := params.EncryptionScheme.Algorithm; {
+	case scheme.Equal(oidAES128CBC):
+		keyLength, newCipher = 16, aes.NewCipher
+	case scheme.Equal(oidAES192CBC):
+		keyLength, newCipher = 24, aes.NewCipher
+	case scheme.Equal(oidAES256CBC):
+		keyLength, newCipher = 32, aes.NewCipher
+	case scheme.Equal(oidDESEDE3CBC):
+		keyLength, newCipher = 24, des.NewTripleDESCipher
+	default:
+		return nil, fmt.Errorf("unsupported cipher %s", scheme)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+
+	key := pbkdf2Key(passphrase, kdfParams.Salt, kdfParams.IterationCount, keyLength, prf)
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(info.EncryptedData) == 0 || len(info.EncryptedData)%block.BlockSize() != 0 {
+		return nil, errors.New("invalid encrypted data")
+	}
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+
+	// a wrong passphrase is mostly detected by an invalid padding
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > block.BlockSize() || !bytes.Equal(decrypted[len(decrypted)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("incorrect passphrase")
+	}
+	decrypted = decrypted[:len(decrypted)-padding]
+	if _, err := x509.ParsePKCS8PrivateKey(decrypted); err != nil {
+		return nil, errors.New("incorrect passphrase")
+	}
+	return decrypted, nil
+}
+
+// pbkdf2Key derives a key of keyLength bytes from password and salt, with
+// PBKDF2 (RFC 8018) using the HMAC of prf.
+func pbkdf2Key(password, salt []byte, iterations, keyLength int, prf func() hash.Hash) []byte {
+	mac := hmac.New(prf, password)
+	size := mac.Size()
+	var key []byte
+	counter := make([]byte, 4)
+	for i := 1; len(key) < keyLength; i++ {
+		binary.BigEndian.PutUint32(counter, uint32(i))
+		mac.Reset()
+		mac.Write(salt)
+		mac.Write(counter)
+		u := mac.Sum(nil)
+		t := make([]byte, size)
+		copy(t, u)
+		for n := 1; n < iterations; n++ {
+			mac.Reset()
+			mac.Write(u)
+			u = mac.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLength]
+}
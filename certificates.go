@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"sort"
+	"time"
+
+	"github.com/containous/traefik/acme"
+	"github.com/containous/traefik/log"
+)
+
+// CertificateInfo describes a certificate served by an entrypoint
+type CertificateInfo struct {
+	EntryPoint  string               `json:"entryPoint"`
+	Resolver    string               `json:"resolver"`
+	Subject     string               `json:"subject"`
+	Issuer      string               `json:"issuer"`
+	SANs        []string             `json:"sans,omitempty"`
+	NotBefore   time.Time            `json:"notBefore"`
+	NotAfter    time.Time            `json:"notAfter"`
+	ExpiresIn   int64                `json:"expiresIn"`
+	Expired     bool                 `json:"expired"`
+	LastRenewal *acme.RenewalAttempt `json:"lastRenewal,omitempty"`
+}
+
+func newCertificateInfo(entryPointName string, resolver string, leaf *x509.Certificate, now time.Time) *CertificateInfo {
+	return &CertificateInfo{
+		EntryPoint: entryPointName,
+		Resolver:   resolver,
+		Subject:    leaf.Subject.CommonName,
+		Issuer:     leaf.Issuer.CommonName,
+		SANs:       leaf.DNSNames,
+		NotBefore:  leaf.NotBefore,
+		NotAfter:   leaf.NotAfter,
+		ExpiresIn:  int64(leaf.NotAfter.Sub(now).Seconds()),
+		Expired:    now.After(leaf.NotAfter),
+	}
+}
+
+func parseLeaf(certificate tls.Certificate) (*x509.Certificate, error) {
+	if certificate.Leaf != nil {
+		return certificate.Leaf, nil
+	}
+	return x509.ParseCertificate(certificate.Certificate[0])
+}
+
+// getCertificates returns the certificates served by the entrypoints,
+// static ones first and then those managed by ACME.
+func (server *Server) getCertificates() []*CertificateInfo {
+	now := time.Now()
+	certificates := []*CertificateInfo{}
+
+	entryPointNames := []string{}
+	for entryPointName := range server.serverEntryPoints {
+		entryPointNames = append(entryPointNames, entryPointName)
+	}
+	sort.Strings(entryPointNames)
+
+	for _, entryPointName := range entryPointNames {
+		entryPoint := server.globalConfiguration.EntryPoints[entryPointName]
+		httpServer := server.serverEntryPoints[entryPointName].httpServer
+		if entryPoint == nil || entryPoint.TLS == nil || httpServer == nil || httpServer.TLSConfig == nil {
+			continue
+		}
+		// static certificates are loaded first, before the ACME default one
+		served := httpServer.TLSConfig.Certificates
+		if store, ok := server.certificateStores[entryPointName]; ok {
+			served = store.Certificates()
+		} else if len(served) > len(entryPoint.TLS.Certificates) {
+			served = served[:len(entryPoint.TLS.Certificates)]
+		}
+		for _, certificate := range served {
+			leaf, err := parseLeaf(certificate)
+			if err != nil {
+				log.Errorf("Error parsing certificate of entrypoint %s: %v", entryPointName, err)
+				continue
+			}
+			certificates = append(certificates, newCertificateInfo(entryPointName, "static", leaf, now))
+		}
+	}
+
+	if server.globalConfiguration.ACME != nil {
+		for _, status := range server.globalConfiguration.ACME.Certificates() {
+			certificate := newCertificateInfo(server.globalConfiguration.ACME.EntryPoint, "acme", status.Certificate, now)
+			certificate.LastRenewal = status.LastRenewal
+			certificates = append(certificates, certificate)
+		}
+	}
+	return certificates
+}
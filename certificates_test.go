@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mailgun/manners"
+)
+
+func generateTestCertificate(t *testing.T, domain string, notAfter time.Time) tls.Certificate {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		Issuer:       pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: privateKey}
+}
+
+func TestGetCertificates(t *testing.T) {
+	notAfter := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	static := generateTestCertificate(t, "static.localhost", notAfter)
+	// appended after the static certificates, like the ACME default certificate
+	fallback := generateTestCertificate(t, "default.localhost", notAfter)
+
+	server := &Server{
+		globalConfiguration: GlobalConfiguration{
+			EntryPoints: EntryPoints{
+				"http":  &EntryPoint{},
+				"https": &EntryPoint{TLS: &TLS{Certificates: Certificates{{CertFile: "cert", KeyFile: "key"}}}},
+			},
+		},
+		serverEntryPoints: serverEntryPoints{
+			"http": &serverEntryPoint{
+				httpServer: manners.NewWithServer(&http.Server{}),
+			},
+			"https": &serverEntryPoint{
+				httpServer: manners.NewWithServer(&http.Server{TLSConfig: &tls.Config{Certificates: []tls.Certificate{static, fallback}}}),
+			},
+		},
+	}
+
+	certificates := server.getCertificates()
+	if len(certificates) != 1 {
+		t.Fatalf("expected 1 certificate, got %d", len(certificates))
+	}
+	certificate := certificates[0]
+	if certificate.EntryPoint != "https" || certificate.Resolver != "static" {
+		t.Errorf("expected static certificate of entrypoint https, got %s of entrypoint %s", certificate.Resolver, certificate.EntryPoint)
+	}
+	if certificate.Subject != "static.localhost" || certificate.Issuer != "static.localhost" {
+		t.Errorf("expected subject and issuer static.localhost, got %s and %s", certificate.Subject, certificate.Issuer)
+	}
+	if !reflect.DeepEqual(certificate.SANs, []string{"static.localhost"}) {
+		t.Errorf("expected SANs [static.localhost], got %v", certificate.SANs)
+	}
+	if !certificate.NotAfter.Equal(notAfter) {
+		t.Errorf("expected expiry %v, got %v", notAfter, certificate.NotAfter)
+	}
+	if certificate.Expired || certificate.ExpiresIn <= 24*3600 {
+		t.Errorf("expected certificate to expire in about 48 hours, got %d seconds", certificate.ExpiresIn)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/tls"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/containous/traefik/log"
+)
+
+// certificatesCheckInterval is the interval between the checks of the
+// modifications of the static certificate files of the entrypoints
+const certificatesCheckInterval = 10 * time.Second
+
+// certificateStore holds the static certificates of a TLS entrypoint, and
+// reloads the key pairs read from files when the files are modified, so that
+// the renewed certificates are served without a restart. A name can have both
+// an RSA and an ECDSA certificate: the ECDSA one is served to the clients
+// supporting it, and the RSA one to the others.
+type certificateStore struct {
+	entryPoint   string
+	certificates Certificates
+	modTimes     []time.Time
+	current      atomic.Value // *loadedCertificates
+}
+
+type loadedCertificates struct {
+	certificates      []tls.Certificate
+	nameToCertificate map[string]*tls.Certificate
+	// ecdsaNameToCertificate holds the ECDSA certificates of the names having an RSA one too
+	ecdsaNameToCertificate map[string]*tls.Certificate
+}
+
+// newCertificateStore returns the store of the certificates of entryPointName
+// loaded in config, or nil if none of them is read from files and no name has
+// both an RSA and an ECDSA certificate.
+func newCertificateStore(entryPointName string, certificates Certificates, config *tls.Config) *certificateStore {
+	store := &certificateStore{entryPoint: entryPointName, certificates: certificates, modTimes: make([]time.Time, len(certificates))}
+	files := false
+	for i, certificate := range certificates {
+		if modTime, ok := certificateModTime(certificate); ok {
+			store.modTimes[i] = modTime
+			files = true
+		}
+	}
+	store.set(config.Certificates[:len(certificates)])
+	if !files && len(store.get().ecdsaNameToCertificate) == 0 {
+		return nil
+	}
+	return store
+}
+
+// certificateModTime returns the last modification of the files of
+// certificate, and false if it isn't read from files.
+func certificateModTime(certificate Certificate) (time.Time, bool) {
+	certInfo, errCert := os.Stat(certificate.CertFile)
+	if errCert == nil && len(certificate.KeyFile) == 0 {
+		// PKCS#12 bundle
+		return certInfo.ModTime(), true
+	}
+	keyInfo, errKey := os.Stat(certificate.KeyFile)
+	if errCert != nil || errKey != nil {
+		return time.Time{}, false
+	}
+	if keyInfo.ModTime().After(certInfo.ModTime()) {
+		return keyInfo.ModTime(), true
+	}
+	return certInfo.ModTime(), true
+}
+
+func (s *certificateStore) set(certificates []tls.Certificate) {
+	var rsaCertificates, ecdsaCertificates []tls.Certificate
+	for _, certificate := range certificates {
+		if leaf, err := parseLeaf(certificate); err == nil {
+			if _, ok := leaf.PublicKey.(*ecdsa.PublicKey); ok {
+				ecdsaCertificates = append(ecdsaCertificates, certificate)
+				continue
+			}
+		}
+		rsaCertificates = append(rsaCertificates, certificate)
+	}
+	rsaConfig := &tls.Config{Certificates: rsaCertificates}
+	rsaConfig.BuildNameToCertificate()
+	ecdsaConfig := &tls.Config{Certificates: ecdsaCertificates}
+	ecdsaConfig.BuildNameToCertificate()
+	loaded := &loadedCertificates{
+		certificates:           certificates,
+		nameToCertificate:      rsaConfig.NameToCertificate,
+		ecdsaNameToCertificate: map[string]*tls.Certificate{},
+	}
+	// the names with an ECDSA certificate only are served with it to every client
+	for name, certificate := range ecdsaConfig.NameToCertificate {
+		if _, ok := loaded.nameToCertificate[name]; ok {
+			loaded.ecdsaNameToCertificate[name] = certificate
+		} else {
+			loaded.nameToCertificate[name] = certificate
+		}
+	}
+	s.current.Store(loaded)
+}
+
+func (s *certificateStore) get() *loadedCertificates {
+	return s.current.Load().(*loadedCertificates)
+}
+
+// Certificates returns the certificates currently served.
+func (s *certificateStore) Certificates() []tls.Certificate {
+	return s.get().certificates
+}
+
+// Run checks the certificate files every interval until stop is closed.
+func (s *certificateStore) Run(stop chan bool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.reload()
+		}
+	}
+}
+
+// reload loads the key pairs whose files were modified since their last load,
+// and swaps them in. A pair failing to load, like a certificate renewed before
+// its key, keeps being served until its files load.
+func (s *certificateStore) reload() {
+	var reloaded []tls.Certificate
+	for i, certificate := range s.certificates {
+		modTime, ok := certificateModTime(certificate)
+		if !ok || modTime.Equal(s.modTimes[i]) {
+			continue
+		}
+		pair, err := certificate.loadX509KeyPair(true)
+		if err != nil {
+			log.Errorf("Error reloading certificate %s of entrypoint %s, keeping the previous one: %v", certificate.CertFile, s.entryPoint, err)
+			continue
+		}
+		if reloaded == nil {
+			reloaded = append([]tls.Certificate{}, s.get().certificates...)
+		}
+		reloaded[i] = pair
+		s.modTimes[i] = modTime
+		log.Infof("Reloaded certificate %s of entrypoint %s", certificate.CertFile, s.entryPoint)
+	}
+	if reloaded != nil {
+		s.set(reloaded)
+	}
+}
+
+// match wraps getCertificate to serve the current certificate of the server
+// name of the clients, when getCertificate has none.
+func (s *certificateStore) match(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if getCertificate != nil {
+			if certificate, err := getCertificate(clientHello); certificate != nil || err != nil {
+				return certificate, err
+			}
+		}
+		loaded := s.get()
+		name := strings.TrimSuffix(strings.ToLower(clientHello.ServerName), ".")
+		names := []string{name}
+		if labels := strings.Split(name, "."); len(labels) > 1 {
+			labels[0] = "*"
+			names = append(names, strings.Join(labels, "."))
+		}
+		acceptsECDSA := len(loaded.ecdsaNameToCertificate) > 0 && supportsECDSA(clientHello)
+		for _, candidate := range names {
+			if certificate, ok := loaded.ecdsaNameToCertificate[candidate]; ok && acceptsECDSA {
+				return certificate, nil
+			}
+			if certificate, ok := loaded.nameToCertificate[candidate]; ok {
+				return certificate, nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+// supportsECDSA returns true if the client of clientHello accepts an ECDSA
+// certificate: it must offer an ECDHE_ECDSA cipher suite, and a NIST curve
+// when it lists its supported curves.
+func supportsECDSA(clientHello *tls.ClientHelloInfo) bool {
+	if len(clientHello.SupportedCurves) > 0 {
+		found := false
+		for _, curve := range clientHello.SupportedCurves {
+			switch curve {
+			case tls.CurveP256, tls.CurveP384, tls.CurveP521:
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, suite := range clientHello.CipherSuites {
+		switch suite {
+		case tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384:
+			return true
+		}
+	}
+	return false
+}
+
+// fallback wraps getCertificate to serve the current default certificate when
+// getCertificate has none, instead of the one loaded at startup.
+func (s *certificateStore) fallback(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if certificate, err := getCertificate(clientHello); certificate != nil || err != nil {
+			return certificate, err
+		}
+		return &s.get().certificates[0], nil
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCertificate(t *testing.T, certificate tls.Certificate, certFile, keyFile string, modTime time.Time) {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(certificate.PrivateKey.(*rsa.PrivateKey))})
+	for file, data := range map[string][]byte{certFile: certPEM, keyFile: keyPEM} {
+		if err := ioutil.WriteFile(file, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func servedName(t *testing.T, getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error), serverName string) string {
+	certificate, err := getCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertificateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "traefik-certificates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	notAfter := time.Now().Add(48 * time.Hour)
+	modTime := time.Now().Add(-time.Hour)
+	writeTestCertificate(t, generateTestCertificate(t, "old.localhost", notAfter), certFile, keyFile, modTime)
+
+	other := generateTestCertificate(t, "other.localhost", notAfter)
+	certificates := Certificates{{CertFile: certFile, KeyFile: keyFile}, {CertFile: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: other.Certificate[0]})), KeyFile: string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(other.PrivateKey.(*rsa.PrivateKey))}))}}
+	config, err := certificates.CreateTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newCertificateStore("https", certificates, config)
+	if store == nil {
+		t.Fatal("expected a store for the certificate files")
+	}
+	getCertificate := store.fallback(store.match(nil))
+	if name := servedName(t, getCertificate, "unknown.localhost"); name != "old.localhost" {
+		t.Errorf("expected the default certificate old.localhost, got %s", name)
+	}
+
+	// a renewed certificate without its key is ignored
+	renewed := generateTestCertificate(t, "new.localhost", notAfter)
+	writeTestCertificate(t, renewed, certFile, filepath.Join(dir, "other-key.pem"), modTime.Add(time.Minute))
+	store.reload()
+	if name := servedName(t, getCertificate, "old.localhost"); name != "old.localhost" {
+		t.Errorf("expected the previous certificate old.localhost, got %s", name)
+	}
+
+	writeTestCertificate(t, renewed, certFile, keyFile, modTime.Add(2*time.Minute))
+	store.reload()
+	for serverName, expected := range map[string]string{"new.localhost": "new.localhost", "unknown.localhost": "new.localhost", "other.localhost": "other.localhost"} {
+		if name := servedName(t, getCertificate, serverName); name != expected {
+			t.Errorf("%s: expected certificate %s, got %s", serverName, expected, name)
+		}
+	}
+	if served := store.Certificates(); len(served) != 2 {
+		t.Errorf("expected 2 certificates, got %d", len(served))
+	}
+
+	if newCertificateStore("https", certificates[1:], config) != nil {
+		t.Error("expected no store without certificate files")
+	}
+}
+
+func TestCertificateStoreECDSA(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "dual.localhost"},
+		DNSNames:     []string{"dual.localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaCertificate := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: privateKey}
+	rsaCertificate := generateTestCertificate(t, "dual.localhost", time.Now().Add(time.Hour))
+
+	config := &tls.Config{Certificates: []tls.Certificate{rsaCertificate, ecdsaCertificate}}
+	store := newCertificateStore("https", Certificates{{}, {}}, config)
+	if store == nil {
+		t.Fatal("expected a store for the RSA and ECDSA certificates of a name")
+	}
+	getCertificate := store.match(nil)
+	for _, test := range []struct {
+		desc        string
+		clientHello *tls.ClientHelloInfo
+		ecdsa       bool
+	}{
+		{
+			desc:        "ECDSA client",
+			clientHello: &tls.ClientHelloInfo{CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, SupportedCurves: []tls.CurveID{tls.CurveP256}},
+			ecdsa:       true,
+		},
+		{
+			desc:        "legacy client",
+			clientHello: &tls.ClientHelloInfo{CipherSuites: []uint16{tls.TLS_RSA_WITH_AES_128_CBC_SHA}},
+		},
+		{
+			desc:        "client without NIST curve",
+			clientHello: &tls.ClientHelloInfo{CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, SupportedCurves: []tls.CurveID{tls.CurveID(29)}},
+		},
+	} {
+		test.clientHello.ServerName = "dual.localhost"
+		certificate, err := getCertificate(test.clientHello)
+		if err != nil || certificate == nil {
+			t.Fatalf("%s: expected a certificate, got %v", test.desc, err)
+		}
+		if _, ok := certificate.PrivateKey.(*ecdsa.PrivateKey); ok != test.ecdsa {
+			t.Errorf("%s: expected an ECDSA certificate %t, got %t", test.desc, test.ecdsa, ok)
+		}
+	}
+
+	if newCertificateStore("https", Certificates{{}}, &tls.Config{Certificates: []tls.Certificate{ecdsaCertificate}}) != nil {
+		t.Error("expected no store for a single certificate")
+	}
+}
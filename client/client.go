@@ -0,0 +1,220 @@
+// Package client is a typed Go client for the REST API of the traefik web backend,
+// as described by its OpenAPI definition served at /api/openapi.json.
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/containous/traefik/types"
+)
+
+// Client calls the REST API of a traefik web backend
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	username   string
+	password   string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client used to call the API, http.DefaultClient by default
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithBasicAuth sets the credentials used when the web backend requires basic authentication
+func WithBasicAuth(username, password string) Option {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// NewClient returns a Client for the web backend at baseURL, e.g. http://localhost:8080
+func NewClient(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// APIError is returned when the API answers with an unexpected status code
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("traefik API error %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound returns true if err is an APIError for a missing resource
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+func (c *Client) do(method string, path string, body interface{}, result interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	request, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if len(c.username) > 0 {
+		request.SetBasicAuth(c.username, c.password)
+	}
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return &APIError{StatusCode: response.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
+
+func (c *Client) get(path string, result interface{}) error {
+	return c.do("GET", path, nil, result)
+}
+
+func escape(segments ...string) string {
+	escaped := []string{}
+	for _, segment := range segments {
+		escaped = append(escaped, (&url.URL{Path: segment}).EscapedPath())
+	}
+	return strings.Join(escaped, "/")
+}
+
+// Ping checks the web backend is alive
+func (c *Client) Ping() error {
+	return c.get("/ping", nil)
+}
+
+// Health returns the statistics of the requests served by traefik
+func (c *Client) Health() (*Health, error) {
+	health := &Health{}
+	return health, c.get("/health", health)
+}
+
+// Version returns the version of traefik
+func (c *Client) Version() (*Version, error) {
+	version := &Version{}
+	return version, c.get("/api/version", version)
+}
+
+// Configurations returns the configurations of all providers, by provider name
+func (c *Client) Configurations() (map[string]*types.Configuration, error) {
+	configurations := map[string]*types.Configuration{}
+	return configurations, c.get("/api/providers", &configurations)
+}
+
+// Configuration returns the configuration of a provider
+func (c *Client) Configuration(provider string) (*types.Configuration, error) {
+	configuration := &types.Configuration{}
+	return configuration, c.get("/api/providers/"+escape(provider), configuration)
+}
+
+// PutConfiguration replaces the configuration of the web provider
+func (c *Client) PutConfiguration(configuration *types.Configuration) error {
+	return c.do("PUT", "/api/providers/web", configuration, nil)
+}
+
+// Backends returns the backends of a provider
+func (c *Client) Backends(provider string) (map[string]*types.Backend, error) {
+	backends := map[string]*types.Backend{}
+	return backends, c.get("/api/providers/"+escape(provider)+"/backends", &backends)
+}
+
+// Backend returns a backend of a provider
+func (c *Client) Backend(provider, backend string) (*types.Backend, error) {
+	result := &types.Backend{}
+	return result, c.get("/api/providers/"+escape(provider, "backends", backend), result)
+}
+
+// Servers returns the servers of a backend
+func (c *Client) Servers(provider, backend string) (map[string]types.Server, error) {
+	servers := map[string]types.Server{}
+	return servers, c.get("/api/providers/"+escape(provider, "backends", backend, "servers"), &servers)
+}
+
+// Frontends returns the frontends of a provider
+func (c *Client) Frontends(provider string) (map[string]*types.Frontend, error) {
+	frontends := map[string]*types.Frontend{}
+	return frontends, c.get("/api/providers/"+escape(provider)+"/frontends", &frontends)
+}
+
+// Frontend returns a frontend of a provider
+func (c *Client) Frontend(provider, frontend string) (*types.Frontend, error) {
+	result := &types.Frontend{}
+	return result, c.get("/api/providers/"+escape(provider, "frontends", frontend), result)
+}
+
+// Routes returns the routes of a frontend
+func (c *Client) Routes(provider, frontend string) (map[string]types.Route, error) {
+	routes := map[string]types.Route{}
+	return routes, c.get("/api/providers/"+escape(provider, "frontends", frontend, "routes"), &routes)
+}
+
+// SLO returns the service level objectives compliance of the frontends
+func (c *Client) SLO() ([]*SLOStatus, error) {
+	statuses := []*SLOStatus{}
+	return statuses, c.get("/api/slo", &statuses)
+}
+
+// Certificates returns the certificates served by the entrypoints
+func (c *Client) Certificates() ([]*Certificate, error) {
+	certificates := []*Certificate{}
+	return certificates, c.get("/api/certificates", &certificates)
+}
+
+// Pipeline returns the ordered middlewares crossed by the requests of a frontend
+func (c *Client) Pipeline(frontend string) (*FrontendPipeline, error) {
+	pipeline := &FrontendPipeline{}
+	return pipeline, c.get("/api/frontends/"+escape(frontend)+"/pipeline", pipeline)
+}
+
+// TestRoute runs a synthetic request through the routing table, without forwarding it
+func (c *Client) TestRoute(routeTest *RouteTest) ([]*RouteTestResult, error) {
+	results := []*RouteTestResult{}
+	return results, c.do("POST", "/api/test-route", routeTest, &results)
+}
+
+// Conflicts returns the shadowed and overlapping frontend rules
+func (c *Client) Conflicts() ([]*RouteConflict, error) {
+	conflicts := []*RouteConflict{}
+	return conflicts, c.get("/api/conflicts", &conflicts)
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/containous/traefik/types"
+)
+
+func TestClient(t *testing.T) {
+	configuration := &types.Configuration{
+		Backends: map[string]*types.Backend{
+			"backend1": {Servers: map[string]types.Server{"server1": {URL: "http://127.0.0.1:8080", Weight: 1}}},
+		},
+	}
+	var putBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "test" || password != "secret" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /api/version":
+			rw.Write([]byte(`{"Version":"v1.1.2","Codename":"camembert"}`))
+		case "GET /api/providers/file/backends/backend1":
+			json.NewEncoder(rw).Encode(configuration.Backends["backend1"])
+		case "GET /api/frontends/my%20frontend/pipeline":
+			rw.Write([]byte(`{"frontend":"my frontend","provider":"file","entryPoints":{"http":[{"name":"accessLog","level":"entrypoint"}]}}`))
+		case "PUT /api/providers/web":
+			putBody, _ = ioutil.ReadAll(r.Body)
+			rw.Write([]byte(`{}`))
+		default:
+			http.NotFound(rw, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", WithBasicAuth("test", "secret"))
+
+	version, err := client.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.Version != "v1.1.2" || version.Codename != "camembert" {
+		t.Errorf("unexpected version %+v", version)
+	}
+
+	backend, err := client.Backend("file", "backend1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(backend, configuration.Backends["backend1"]) {
+		t.Errorf("expected backend %+v, got %+v", configuration.Backends["backend1"], backend)
+	}
+
+	pipeline, err := client.Pipeline("my frontend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pipeline.Frontend != "my frontend" || len(pipeline.EntryPoints["http"]) != 1 {
+		t.Errorf("unexpected pipeline %+v", pipeline)
+	}
+
+	if err := client.PutConfiguration(configuration); err != nil {
+		t.Fatal(err)
+	}
+	sent := &types.Configuration{}
+	if err := json.Unmarshal(putBody, sent); err != nil || !reflect.DeepEqual(sent, configuration) {
+		t.Errorf("expected configuration %+v to be sent, got %s", configuration, putBody)
+	}
+
+	if _, err := client.Frontend("file", "unknown"); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	_, err = NewClient(server.URL).Version()
+	if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected an unauthorized error, got %v", err)
+	}
+}
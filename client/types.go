@@ -0,0 +1,145 @@
+package client
+
+import (
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+// Version is the version of traefik
+type Version struct {
+	Version  string
+	Codename string
+}
+
+// Health holds the statistics of the requests served by traefik
+type Health struct {
+	Pid                    int            `json:"pid"`
+	UpTime                 string         `json:"uptime"`
+	UpTimeSec              float64        `json:"uptime_sec"`
+	Time                   string         `json:"time"`
+	TimeUnix               int64          `json:"unixtime"`
+	StatusCodeCount        map[string]int `json:"status_code_count"`
+	TotalStatusCodeCount   map[string]int `json:"total_status_code_count"`
+	Count                  int            `json:"count"`
+	TotalCount             int            `json:"total_count"`
+	TotalResponseTime      string         `json:"total_response_time"`
+	TotalResponseTimeSec   float64        `json:"total_response_time_sec"`
+	AverageResponseTime    string         `json:"average_response_time"`
+	AverageResponseTimeSec float64        `json:"average_response_time_sec"`
+	RecentErrors           []*RecentError `json:"recent_errors,omitempty"`
+	SLO                    []*SLOStatus   `json:"slo,omitempty"`
+	Errors                 []*ErrorCount  `json:"errors,omitempty"`
+}
+
+// RecentError is a recent request answered with a 4xx or 5xx status code
+type RecentError struct {
+	StatusCode int       `json:"status_code"`
+	Status     string    `json:"status"`
+	Method     string    `json:"method"`
+	Host       string    `json:"host"`
+	Path       string    `json:"path"`
+	Time       time.Time `json:"time"`
+}
+
+// ErrorCount is the number of error responses of a frontend with the same
+// status code and reason. Origin is traefik for the errors generated by traefik
+// itself, backend for the errors returned by the backends.
+type ErrorCount struct {
+	Frontend   string `json:"frontend"`
+	StatusCode int    `json:"status_code"`
+	Origin     string `json:"origin"`
+	Reason     string `json:"reason,omitempty"`
+	Count      int64  `json:"count"`
+}
+
+// SLOStatus is the SLO compliance of a frontend
+type SLOStatus struct {
+	Frontend     string       `json:"frontend"`
+	Objective    types.SLO    `json:"objective"`
+	Windows      []*SLOWindow `json:"windows"`
+	Alerting     bool         `json:"alerting"`
+	AlertingText string       `json:"alerting_text,omitempty"`
+}
+
+// SLOWindow is the SLO compliance of a frontend over a rolling window
+type SLOWindow struct {
+	Window                string  `json:"window"`
+	Requests              int64   `json:"requests"`
+	Errors                int64   `json:"errors"`
+	SlowRequests          int64   `json:"slow_requests"`
+	Streams               int64   `json:"streams"`
+	StreamsDuration       string  `json:"streams_average_duration,omitempty"`
+	Availability          float64 `json:"availability"`
+	LatencyCompliance     float64 `json:"latency_compliance"`
+	AvailabilityBurnRate  float64 `json:"availability_burn_rate"`
+	LatencyBurnRate       float64 `json:"latency_burn_rate"`
+	AvailabilityCompliant bool    `json:"availability_compliant"`
+	LatencyCompliant      bool    `json:"latency_compliant"`
+}
+
+// Certificate describes a certificate served by an entrypoint
+type Certificate struct {
+	EntryPoint  string          `json:"entryPoint"`
+	Resolver    string          `json:"resolver"`
+	Subject     string          `json:"subject"`
+	Issuer      string          `json:"issuer"`
+	SANs        []string        `json:"sans,omitempty"`
+	NotBefore   time.Time       `json:"notBefore"`
+	NotAfter    time.Time       `json:"notAfter"`
+	ExpiresIn   int64           `json:"expiresIn"`
+	Expired     bool            `json:"expired"`
+	LastRenewal *RenewalAttempt `json:"lastRenewal,omitempty"`
+}
+
+// RenewalAttempt holds the outcome of the last renewal attempt of an ACME certificate
+type RenewalAttempt struct {
+	Date  time.Time `json:"date"`
+	Error string    `json:"error,omitempty"`
+}
+
+// PipelineStep describes a middleware crossed by the requests of a frontend
+type PipelineStep struct {
+	Name        string `json:"name"`
+	Level       string `json:"level"`
+	Description string `json:"description,omitempty"`
+}
+
+// FrontendPipeline holds the ordered middlewares crossed by the requests of a frontend, per entrypoint
+type FrontendPipeline struct {
+	Frontend    string                    `json:"frontend"`
+	Provider    string                    `json:"provider"`
+	EntryPoints map[string][]PipelineStep `json:"entryPoints"`
+}
+
+// RouteTest describes a synthetic request to run through the routing table
+type RouteTest struct {
+	EntryPoint string            `json:"entryPoint,omitempty"`
+	Method     string            `json:"method,omitempty"`
+	Host       string            `json:"host"`
+	Path       string            `json:"path,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+}
+
+// RouteTestResult reports how an entrypoint would handle a synthetic request
+type RouteTestResult struct {
+	EntryPoint string         `json:"entryPoint"`
+	Matched    bool           `json:"matched"`
+	Frontend   string         `json:"frontend,omitempty"`
+	Provider   string         `json:"provider,omitempty"`
+	Pipeline   []PipelineStep `json:"pipeline,omitempty"`
+	Backend    string         `json:"backend,omitempty"`
+	Server     string         `json:"server,omitempty"`
+	Servers    []string       `json:"servers,omitempty"`
+	Selection  string         `json:"selection,omitempty"`
+}
+
+// RouteConflict reports a shadowed, ambiguous or overlapping frontend rule
+type RouteConflict struct {
+	Type          string `json:"type"`
+	EntryPoint    string `json:"entryPoint"`
+	Frontend      string `json:"frontend"`
+	Provider      string `json:"provider"`
+	Other         string `json:"other"`
+	OtherProvider string `json:"otherProvider"`
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/types"
+)
+
+// ConcurrencyLimits keeps the concurrency limiters of the frontends, shared by
+// their entrypoints, so that the in-flight requests of the clients stay counted
+// across the configuration reloads.
+type ConcurrencyLimits struct {
+	mutex    sync.Mutex
+	limiters map[string]*middlewares.ConcurrencyLimiter
+}
+
+// NewConcurrencyLimits returns an empty ConcurrencyLimits.
+func NewConcurrencyLimits() *ConcurrencyLimits {
+	return &ConcurrencyLimits{limiters: map[string]*middlewares.ConcurrencyLimiter{}}
+}
+
+// Limiter returns the concurrency limiter of frontend, enforcing config.
+func (c *ConcurrencyLimits) Limiter(frontend string, config *types.ConcurrencyLimit) (*middlewares.ConcurrencyLimiter, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if limiter, ok := c.limiters[frontend]; ok {
+		if err := limiter.SetConfig(config); err != nil {
+			return nil, err
+		}
+		return limiter, nil
+	}
+	limiter, err := middlewares.NewConcurrencyLimiter(config)
+	if err != nil {
+		return nil, err
+	}
+	c.limiters[frontend] = limiter
+	return limiter, nil
+}
+
+// SetFrontends drops the limiters of the frontends that are not in frontends anymore.
+func (c *ConcurrencyLimits) SetFrontends(frontends map[string]bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for frontend := range c.limiters {
+		if !frontends[frontend] {
+			delete(c.limiters, frontend)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimitsReload(t *testing.T) {
+	limits := NewConcurrencyLimits()
+	first, err := limits.Limiter("frontend1", &types.ConcurrencyLimit{Amount: 10})
+	assert.NoError(t, err)
+	_, err = limits.Limiter("frontend2", &types.ConcurrencyLimit{Amount: 10})
+	assert.NoError(t, err)
+
+	// the limiters are kept across the reloads, with their new limit
+	limiter, err := limits.Limiter("frontend1", &types.ConcurrencyLimit{Amount: 5})
+	assert.NoError(t, err)
+	assert.True(t, first == limiter)
+	_, err = limits.Limiter("frontend1", &types.ConcurrencyLimit{Amount: 0})
+	assert.Error(t, err)
+
+	limits.SetFrontends(map[string]bool{"frontend1": true})
+	assert.Len(t, limits.limiters, 1)
+	assert.Contains(t, limits.limiters, "frontend1")
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/docker/libkv/store"
+)
+
+// configurationCache saves the loaded dynamic configurations, so that traefik
+// can boot from them when its providers are unreachable. The configuration of
+// a provider is replaced as soon as the provider sends a new one.
+type configurationCache struct {
+	config *types.ConfigCache
+	mutex  sync.Mutex
+	cached map[string]*types.Configuration
+}
+
+func newConfigurationCache(config *types.ConfigCache) *configurationCache {
+	return &configurationCache{config: config, cached: map[string]*types.Configuration{}}
+}
+
+// load reads the cached configurations of providerNames.
+func (c *configurationCache) load(providerNames []string) (configs, error) {
+	data, err := ioutil.ReadFile(c.config.File)
+	if os.IsNotExist(err) {
+		return configs{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cached := configs{}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, err
+	}
+	configurations := configs{}
+	for _, providerName := range providerNames {
+		if configuration, ok := cached[providerName]; ok && configuration != nil {
+			configurations[providerName] = configuration
+		}
+	}
+	return configurations, nil
+}
+
+// save replaces the cache with configurations.
+func (c *configurationCache) save(configurations configs) {
+	data, err := json.Marshal(configurations)
+	if err != nil {
+		log.Errorf("Error encoding the configuration cache: %v", err)
+		return
+	}
+	// the configurations may hold credentials, such as basic auth users
+	if err := ioutil.WriteFile(c.config.File+".tmp", data, 0600); err != nil {
+		log.Errorf("Error saving the configuration cache to %s: %v", c.config.File, err)
+		return
+	}
+	if err := os.Rename(c.config.File+".tmp", c.config.File); err != nil {
+		log.Errorf("Error saving the configuration cache to %s: %v", c.config.File, err)
+	}
+}
+
+// boot sends the cached configurations of the providers that didn't send a
+// configuration before the cache timeout, or at once when the cache is pinned,
+// so that the routes are served while the providers warm up.
+func (c *configurationCache) boot(server *Server, stop chan bool) {
+	configurations, err := c.load(server.providerNames())
+	if err != nil {
+		log.Errorf("Error loading the configuration cache from %s: %v", c.config.File, err)
+		return
+	}
+	if len(configurations) == 0 {
+		return
+	}
+	timeout := time.Duration(c.config.Timeout) * time.Second
+	if !c.config.Pin {
+		select {
+		case <-stop:
+			return
+		case <-time.After(timeout):
+		}
+	}
+	currentConfigurations := server.currentConfigurations.Get().(configs)
+	for providerName, configuration := range configurations {
+		if _, ok := currentConfigurations[providerName]; ok {
+			continue
+		}
+		if c.config.Pin {
+			log.Infof("Loading the pinned configuration of provider %s until it sends its own", providerName)
+		} else {
+			log.Warnf("No configuration received from provider %s in %s, loading its cached configuration", providerName, timeout)
+		}
+		c.mutex.Lock()
+		c.cached[providerName] = configuration
+		c.mutex.Unlock()
+		server.configurationValidatedChan <- types.ConfigMessage{ProviderName: providerName, Configuration: configuration}
+	}
+}
+
+// isStale returns true if configMsg is a cached configuration, and its provider
+// sent a newer one in the meantime.
+func (c *configurationCache) isStale(configMsg types.ConfigMessage, currentConfigurations configs) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	cached, ok := c.cached[configMsg.ProviderName]
+	if !ok || cached != configMsg.Configuration {
+		return false
+	}
+	delete(c.cached, configMsg.ProviderName)
+	_, ok = currentConfigurations[configMsg.ProviderName]
+	return ok
+}
+
+// providerNames returns the names of the configured providers, as set in their configuration messages.
+func (server *Server) providerNames() []string {
+	globalConfiguration := server.globalConfiguration
+	names := []string{}
+	for name, enabled := range map[string]bool{
+		"docker":             globalConfiguration.Docker != nil,
+		"marathon":           globalConfiguration.Marathon != nil,
+		"file":               globalConfiguration.File != nil,
+		"web":                globalConfiguration.Web != nil,
+		string(store.CONSUL): globalConfiguration.Consul != nil,
+		"consul_catalog":     globalConfiguration.ConsulCatalog != nil,
+		string(store.ETCD):   globalConfiguration.Etcd != nil,
+		string(store.ZK):     globalConfiguration.Zookeeper != nil,
+		string(store.BOLTDB): globalConfiguration.Boltdb != nil,
+		"kubernetes":         globalConfiguration.Kubernetes != nil,
+		"mesos":              globalConfiguration.Mesos != nil,
+		"eureka":             globalConfiguration.Eureka != nil,
+		"webapi":             globalConfiguration.WebAPI != nil,
+	} {
+		if enabled {
+			names = append(names, name)
+		}
+	}
+	if globalConfiguration.Plugin != nil {
+		names = append(names, globalConfiguration.Plugin.ConfigurationName())
+	}
+	return names
+}
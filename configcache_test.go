@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigurationCacheBoot(t *testing.T) {
+	directory, err := ioutil.TempDir("", "configcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	cache := newConfigurationCache(&types.ConfigCache{File: filepath.Join(directory, "cache.json")})
+	cache.save(configs{
+		"consul":     {Backends: map[string]*types.Backend{"backend1": {}}},
+		"kubernetes": {Backends: map[string]*types.Backend{"backend2": {}}},
+		"docker":     {Backends: map[string]*types.Backend{"backend3": {}}},
+	})
+
+	server := &Server{
+		configurationValidatedChan: make(chan types.ConfigMessage, 10),
+		globalConfiguration:        GlobalConfiguration{Consul: &provider.Consul{}, Kubernetes: &provider.Kubernetes{}},
+	}
+	// kubernetes is reachable, docker is not configured anymore
+	server.currentConfigurations.Set(configs{"kubernetes": {}})
+	cache.boot(server, make(chan bool))
+	close(server.configurationValidatedChan)
+
+	messages := []types.ConfigMessage{}
+	for configMsg := range server.configurationValidatedChan {
+		messages = append(messages, configMsg)
+	}
+	if !assert.Len(t, messages, 1) {
+		return
+	}
+	assert.Equal(t, "consul", messages[0].ProviderName)
+	assert.Contains(t, messages[0].Configuration.Backends, "backend1")
+
+	// consul came back before its cached configuration was loaded
+	assert.True(t, cache.isStale(messages[0], configs{"consul": {}}))
+	assert.False(t, cache.isStale(messages[0], configs{}))
+}
+
+func TestConfigurationCacheMissingFile(t *testing.T) {
+	cache := newConfigurationCache(&types.ConfigCache{File: filepath.Join(os.TempDir(), "missing-configuration-cache.json")})
+	configurations, err := cache.load([]string{"consul"})
+	assert.NoError(t, err)
+	assert.Empty(t, configurations)
+}
+
+func TestConfigurationCachePin(t *testing.T) {
+	directory, err := ioutil.TempDir("", "configcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	// the pinned configuration doesn't wait for the timeout
+	cache := newConfigurationCache(&types.ConfigCache{File: filepath.Join(directory, "cache.json"), Timeout: 3600, Pin: true})
+	cache.save(configs{"consul": {Backends: map[string]*types.Backend{"backend1": {}}}})
+
+	server := &Server{
+		configurationValidatedChan: make(chan types.ConfigMessage, 10),
+		globalConfiguration:        GlobalConfiguration{Consul: &provider.Consul{}},
+	}
+	server.currentConfigurations.Set(configs{})
+	cache.boot(server, make(chan bool))
+	close(server.configurationValidatedChan)
+
+	configMsg, ok := <-server.configurationValidatedChan
+	assert.True(t, ok)
+	assert.Equal(t, "consul", configMsg.ProviderName)
+	assert.Contains(t, configMsg.Configuration.Backends, "backend1")
+}
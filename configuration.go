@@ -10,14 +10,27 @@ import (
 	"time"
 
 	"github.com/containous/traefik/acme"
+	"github.com/containous/traefik/aws"
+	"github.com/containous/traefik/externaldns"
+	"github.com/containous/traefik/featureflags"
+	"github.com/containous/traefik/internalca"
 	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/registration"
 	"github.com/containous/traefik/types"
 )
 
 // TraefikConfiguration holds GlobalConfiguration and other stuff
 type TraefikConfiguration struct {
 	GlobalConfiguration `mapstructure:",squash"`
-	ConfigFile          string `short:"c" description:"Configuration file to use (TOML)."`
+	ConfigFile          string   `short:"c" description:"Configuration file to use (TOML)."`
+	Include             Includes `description:"Configuration files loaded after the main one, glob patterns relative to its directory"`
+	Output              string   `description:"Output format of the command result: text or json"`
+	ServiceName         string   `description:"Name of the Windows service of the installservice, uninstallservice and runservice commands"`
+}
+
+// VersionConfiguration holds the version command configuration.
+type VersionConfiguration struct {
+	Output string `description:"Output format of the command result: text or json"`
 }
 
 // GlobalConfiguration holds global configuration (with providers, etc.).
@@ -28,6 +41,7 @@ type GlobalConfiguration struct {
 	CheckNewVersion           bool                    `description:"Periodically check if a new version has been released"`
 	AccessLogsFile            string                  `description:"Access logs file"`
 	TraefikLogsFile           string                  `description:"Traefik logs file"`
+	EventLog                  string                  `description:"Source of the Windows EventLog to write the traefik logs to, like the service name of installservice"`
 	LogLevel                  string                  `short:"l" description:"Log level"`
 	EntryPoints               EntryPoints             `description:"Entrypoints definition using format: --entryPoints='Name:http Address::8000 Redirect.EntryPoint:https' --entryPoints='Name:https Address::4442 TLS:tests/traefik.crt,tests/traefik.key;prod/traefik.crt,prod/traefik.key'"`
 	Cluster                   *types.Cluster          `description:"Enable clustering"`
@@ -35,9 +49,25 @@ type GlobalConfiguration struct {
 	ACME                      *acme.ACME              `description:"Enable ACME (Let's Encrypt): automatic SSL"`
 	DefaultEntryPoints        DefaultEntryPoints      `description:"Entrypoints to be used by frontends that do not specify any entrypoint"`
 	ProvidersThrottleDuration time.Duration           `description:"Backends throttle duration: minimum duration between 2 events from providers before applying a new configuration. It avoids unnecessary reloads if multiples events are sent in a short amount of time."`
+	ProvidersMinApplyInterval time.Duration           `description:"Minimum duration between 2 reloads of the configuration. The configurations of the providers received in between are applied together by the next reload."`
 	MaxIdleConnsPerHost       int                     `description:"If non-zero, controls the maximum idle (keep-alive) to keep per-host.  If zero, DefaultMaxIdleConnsPerHost is used"`
 	InsecureSkipVerify        bool                    `description:"Disable SSL certificate verification"`
 	Retry                     *Retry                  `description:"Enable retry sending request if network error"`
+	DNS                       *externaldns.DNS        `description:"Enable DNS records publishing for the frontends hosts"`
+	RealIP                    *types.RealIP           `description:"Enable client IP restoration for the requests sent by trusted CDNs"`
+	TargetGroup               *aws.TargetGroup        `description:"Register traefik in an AWS ALB or NLB target group"`
+	ConsulRegistration        *registration.Consul    `description:"Register the entrypoints as services of the Consul agent"`
+	FeatureFlags              *featureflags.Config    `description:"Enable middlewares toggled per frontend by feature flags"`
+	InternalCA                *internalca.CA          `description:"Enable the internal CA issuing serving certificates to the backends"`
+	Usage                     *types.Usage            `description:"Enable the bandwidth and requests accounting per frontend"`
+	ConfigurationCache        *types.ConfigCache      `description:"Enable booting from the last dynamic configuration when the providers are unreachable"`
+	ConfigurationFreeze       *types.ConfigFreeze     `description:"Enable holding back the dynamic configuration updates during change freezes"`
+	ConfigurationHistory      *types.ConfigHistory    `description:"Enable the history of the applied dynamic configurations, and their rollback through the API"`
+	CertificateExpiry         *types.CertExpiry       `description:"Enable the warning logs of the certificates close to their expiry"`
+	SessionTickets            *types.SessionTickets   `description:"Enable the rotation of the TLS session ticket keys, shared by the traefik instances in cluster mode"`
+	DefaultCertificate        *types.DefaultCert      `description:"Enable the self-signed certificate generated for the TLS entrypoints without certificates, and served by ACME to the unknown domains"`
+	Sandbox                   *types.Sandbox          `description:"Enable running as an unprivileged user once the entrypoints are bound, and the seccomp filter"`
+	FIPS                      bool                    `description:"Restrict the TLS parameters of the entrypoints and the backends to the FIPS-approved ones, whatever their options"`
 	Docker                    *provider.Docker        `description:"Enable Docker backend"`
 	File                      *provider.File          `description:"Enable File backend"`
 	Web                       *WebProvider            `description:"Enable Web backend"`
@@ -51,6 +81,7 @@ type GlobalConfiguration struct {
 	Mesos                     *provider.Mesos         `description:"Enable Mesos backend"`
 	Eureka                    *provider.Eureka        `description:"Enable Eureka backend"`
 	WebAPI                    *provider.WebAPI        `description:"Enable WebAPI backend"`
+	Plugin                    *provider.Plugin        `description:"Enable plugin backend"`
 }
 
 // DefaultEntryPoints holds default entry points
@@ -104,7 +135,7 @@ func (ep *EntryPoints) String() string {
 // Set's argument is a string to be parsed to set the flag.
 // It's a comma-separated list, so we split it.
 func (ep *EntryPoints) Set(value string) error {
-	regex := regexp.MustCompile("(?:Name:(?P<Name>\\S*))\\s*(?:Address:(?P<Address>\\S*))?\\s*(?:TLS:(?P<TLS>\\S*))?\\s*((?P<TLSACME>TLS))?\\s*(?:CA:(?P<CA>\\S*))?\\s*(?:Redirect.EntryPoint:(?P<RedirectEntryPoint>\\S*))?\\s*(?:Redirect.Regex:(?P<RedirectRegex>\\S*))?\\s*(?:Redirect.Replacement:(?P<RedirectReplacement>\\S*))?\\s*(?:Compress:(?P<Compress>\\S*))?")
+	regex := regexp.MustCompile("(?:Name:(?P<Name>\\S*))\\s*(?:Address:(?P<Address>\\S*))?\\s*(?:TLS:(?P<TLS>\\S*))?\\s*((?P<TLSACME>TLS))?\\s*(?:CA:(?P<CA>\\S*))?\\s*(?:Redirect.EntryPoint:(?P<RedirectEntryPoint>\\S*))?\\s*(?:Redirect.Regex:(?P<RedirectRegex>\\S*))?\\s*(?:Redirect.Replacement:(?P<RedirectReplacement>\\S*))?\\s*(?:Redirect.Auto:(?P<RedirectAuto>\\S*))?\\s*(?:Compress:(?P<Compress>\\S*))?")
 	match := regex.FindAllStringSubmatch(value, -1)
 	if match == nil {
 		return errors.New("Bad EntryPoints format: " + value)
@@ -140,6 +171,7 @@ func (ep *EntryPoints) Set(value string) error {
 			EntryPoint:  result["RedirectEntryPoint"],
 			Regex:       result["RedirectRegex"],
 			Replacement: result["RedirectReplacement"],
+			Auto:        strings.EqualFold(result["RedirectAuto"], "true"),
 		}
 	}
 
@@ -175,12 +207,17 @@ func (ep *EntryPoints) Type() string {
 
 // EntryPoint holds an entry point configuration of the reverse proxy (ip, port, TLS...)
 type EntryPoint struct {
-	Network  string
-	Address  string
-	TLS      *TLS
-	Redirect *Redirect
-	Auth     *types.Auth
-	Compress bool
+	Network         string
+	Address         string
+	TLS             *TLS
+	Redirect        *Redirect
+	Auth            *types.Auth
+	Compress        bool
+	Paths           *types.PathNormalization
+	Headers         *types.HeaderPolicy
+	RedirectMap     *types.RedirectMap
+	SecurityHeaders *types.SecurityHeaders
+	ForwardProxy    *types.ForwardProxy
 }
 
 // Redirect configures a redirection of an entry point to another, or to an URL
@@ -188,18 +225,24 @@ type Redirect struct {
 	EntryPoint  string
 	Regex       string
 	Replacement string
+	Auto        bool
 }
 
 // TLS configures TLS for an entry point
 type TLS struct {
-	MinVersion    string
-	CipherSuites  []string
-	Certificates  Certificates
-	ClientCAFiles []string
+	MinVersion        string
+	MaxVersion        string
+	CipherSuites      []string
+	Certificates      Certificates
+	ClientCAFiles     []string
+	RequestClientCert bool
+	HandshakeTimeout  int64
+	StrictSNI         bool
+	SNIExemptions     []string
 }
 
-// Map of allowed TLS minimum versions
-var minVersion = map[string]uint16{
+// Map of allowed TLS versions
+var tlsVersions = map[string]uint16{
 	`VersionTLS10`: tls.VersionTLS10,
 	`VersionTLS11`: tls.VersionTLS11,
 	`VersionTLS12`: tls.VersionTLS12,
@@ -207,16 +250,70 @@ var minVersion = map[string]uint16{
 
 // Map of TLS CipherSuites from crypto/tls
 var cipherSuites = map[string]uint16{
-	`TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256`: tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-	`TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384`: tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-	`TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA`:    tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
-	`TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA`:    tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
-	`TLS_RSA_WITH_AES_128_GCM_SHA256`:       tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
-	`TLS_RSA_WITH_AES_256_GCM_SHA384`:       tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
-	`TLS_RSA_WITH_AES_128_CBC_SHA`:          tls.TLS_RSA_WITH_AES_128_CBC_SHA,
-	`TLS_RSA_WITH_AES_256_CBC_SHA`:          tls.TLS_RSA_WITH_AES_256_CBC_SHA,
-	`TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA`:   tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
-	`TLS_RSA_WITH_3DES_EDE_CBC_SHA`:         tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+	`TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256`: tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	`TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384`: tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	`TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256`:   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	`TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384`:   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	`TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA`:      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	`TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA`:      tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	`TLS_RSA_WITH_AES_128_GCM_SHA256`:         tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	`TLS_RSA_WITH_AES_256_GCM_SHA384`:         tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	`TLS_RSA_WITH_AES_128_CBC_SHA`:            tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	`TLS_RSA_WITH_AES_256_CBC_SHA`:            tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	`TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA`:     tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+	`TLS_RSA_WITH_3DES_EDE_CBC_SHA`:           tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+}
+
+// The TLS 1.3 version and cipher suites, which crypto/tls can't negotiate yet,
+// are rejected instead of being ignored.
+var unsupportedTLS13 = map[string]bool{
+	`VersionTLS13`:                 true,
+	`TLS_AES_128_GCM_SHA256`:       true,
+	`TLS_AES_256_GCM_SHA384`:       true,
+	`TLS_CHACHA20_POLY1305_SHA256`: true,
+}
+
+// applyVersions sets the TLS versions and the cipher suites of config.
+func (t *TLS) applyVersions(config *tls.Config) error {
+	if len(t.MinVersion) > 0 {
+		version, exists := tlsVersions[t.MinVersion]
+		if !exists {
+			return invalidTLSOption("MinVersion", t.MinVersion)
+		}
+		config.PreferServerCipherSuites = true
+		config.MinVersion = version
+	}
+	if len(t.MaxVersion) > 0 {
+		version, exists := tlsVersions[t.MaxVersion]
+		if !exists {
+			return invalidTLSOption("MaxVersion", t.MaxVersion)
+		}
+		if version < config.MinVersion {
+			return fmt.Errorf("MaxVersion %s is lower than MinVersion %s", t.MaxVersion, t.MinVersion)
+		}
+		config.MaxVersion = version
+	}
+	if t.CipherSuites == nil {
+		return nil
+	}
+	//if our list of CipherSuites is defined in the entrypoint config, we can re-initilize the suites list as empty
+	config.CipherSuites = make([]uint16, 0)
+	for _, cipher := range t.CipherSuites {
+		cipherConst, exists := cipherSuites[cipher]
+		if !exists {
+			//CipherSuite listed in the toml does not exist in our listed
+			return invalidTLSOption("CipherSuite", cipher)
+		}
+		config.CipherSuites = append(config.CipherSuites, cipherConst)
+	}
+	return nil
+}
+
+func invalidTLSOption(option, value string) error {
+	if unsupportedTLS13[value] {
+		return fmt.Errorf("Invalid %s: %s, TLS 1.3 is not supported yet", option, value)
+	}
+	return errors.New("Invalid " + option + ": " + value)
 }
 
 // Certificates defines traefik certificates type
@@ -232,7 +329,10 @@ func (certs *Certificates) CreateTLSConfig() (*tls.Config, error) {
 		isAPath := false
 		_, errCert := os.Stat(v.CertFile)
 		_, errKey := os.Stat(v.KeyFile)
-		if errCert == nil {
+		if len(v.KeyFile) == 0 {
+			// PKCS#12 bundle
+			isAPath = errCert == nil
+		} else if errCert == nil {
 			if errKey == nil {
 				isAPath = true
 			} else {
@@ -242,18 +342,9 @@ func (certs *Certificates) CreateTLSConfig() (*tls.Config, error) {
 			return nil, fmt.Errorf("bad TLS Certificate KeyFile format, expected a path")
 		}
 
-		cert := tls.Certificate{}
-		var err error
-		if isAPath {
-			cert, err = tls.LoadX509KeyPair(v.CertFile, v.KeyFile)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			cert, err = tls.X509KeyPair([]byte(v.CertFile), []byte(v.KeyFile))
-			if err != nil {
-				return nil, err
-			}
+		cert, err := v.loadX509KeyPair(isAPath)
+		if err != nil {
+			return nil, err
 		}
 		config.Certificates = append(config.Certificates, cert)
 	}
@@ -268,7 +359,11 @@ func (certs *Certificates) String() string {
 	}
 	var result []string
 	for _, certificate := range *certs {
-		result = append(result, certificate.CertFile+","+certificate.KeyFile)
+		files := certificate.CertFile + "," + certificate.KeyFile
+		if len(certificate.KeyPassphraseFile) > 0 {
+			files += "," + certificate.KeyPassphraseFile
+		}
+		result = append(result, files)
 	}
 	return strings.Join(result, ";")
 }
@@ -276,17 +371,22 @@ func (certs *Certificates) String() string {
 // Set is the method to set the flag value, part of the flag.Value interface.
 // Set's argument is a string to be parsed to set the flag.
 // It's a comma-separated list, so we split it.
+// An optional third file holds the passphrase of an encrypted key.
 func (certs *Certificates) Set(value string) error {
 	certificates := strings.Split(value, ";")
 	for _, certificate := range certificates {
 		files := strings.Split(certificate, ",")
-		if len(files) != 2 {
+		if len(files) != 2 && len(files) != 3 {
 			return errors.New("Bad certificates format: " + value)
 		}
-		*certs = append(*certs, Certificate{
+		cert := Certificate{
 			CertFile: files[0],
 			KeyFile:  files[1],
-		})
+		}
+		if len(files) == 3 {
+			cert.KeyPassphraseFile = files[2]
+		}
+		*certs = append(*certs, cert)
 	}
 	return nil
 }
@@ -298,9 +398,13 @@ func (certs *Certificates) Type() string {
 
 // Certificate holds a SSL cert/key pair
 // Certs and Key could be either a file path, or the file content itself
+// An encrypted Key is decrypted with KeyPassphrase, or with the content of KeyPassphraseFile
+// Without Key, Cert is a PKCS#12 bundle, its content being base64 encoded
 type Certificate struct {
-	CertFile string
-	KeyFile  string
+	CertFile          string
+	KeyFile           string
+	KeyPassphrase     string
+	KeyPassphraseFile string
 }
 
 // Retry contains request retry config
@@ -349,6 +453,7 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 	var defaultConsulCatalog provider.ConsulCatalog
 	defaultConsulCatalog.Endpoint = "127.0.0.1:8500"
 	defaultConsulCatalog.Constraints = types.Constraints{}
+	defaultConsulCatalog.ConnectService = provider.DefaultConsulCatalogConnectService
 
 	// default Etcd
 	var defaultEtcd provider.Etcd
@@ -385,6 +490,90 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 	defaultMesos.ExposedByDefault = true
 	defaultMesos.Constraints = types.Constraints{}
 
+	// default Plugin
+	var defaultPlugin provider.Plugin
+	defaultPlugin.Watch = true
+	defaultPlugin.Constraints = types.Constraints{}
+
+	// default DNS
+	var defaultDNS externaldns.DNS
+	defaultDNS.TTL = 300
+	defaultDNS.OwnerID = "traefik"
+	defaultDNS.Domains = externaldns.Domains{}
+	defaultDNS.Route53 = &externaldns.Route53{}
+	defaultDNS.Cloudflare = &externaldns.Cloudflare{}
+	defaultDNS.RFC2136 = &externaldns.RFC2136{
+		TSIGAlgorithm: "hmac-sha256",
+	}
+
+	// default RealIP
+	var defaultRealIP types.RealIP
+	defaultRealIP.CDNs = types.CDNs{}
+	defaultRealIP.RefreshInterval = 86400
+
+	// default TargetGroup
+	var defaultTargetGroup aws.TargetGroup
+	defaultTargetGroup.EntryPoint = "http"
+
+	// default ConsulRegistration
+	var defaultConsulRegistration registration.Consul
+	defaultConsulRegistration.Endpoint = "127.0.0.1:8500"
+	defaultConsulRegistration.Name = "traefik"
+	defaultConsulRegistration.Check = "ttl"
+	defaultConsulRegistration.CheckInterval = "10s"
+	defaultConsulRegistration.DeregisterCriticalServiceAfter = "1m"
+
+	// default FeatureFlags
+	var defaultFeatureFlags featureflags.Config
+	defaultFeatureFlags.PollInterval = 10
+
+	// default InternalCA
+	var defaultInternalCA internalca.CA
+	defaultInternalCA.Name = "Traefik Internal CA"
+	defaultInternalCA.CertificateValidity = 86400
+
+	// default Usage
+	var defaultUsage types.Usage
+	defaultUsage.FlushInterval = 60
+	defaultUsage.Retention = 35
+
+	// default ConfigurationCache
+	var defaultConfigurationCache types.ConfigCache
+	defaultConfigurationCache.File = "configuration-cache.json"
+	defaultConfigurationCache.Timeout = 10
+
+	// default ConfigurationFreeze
+	var defaultConfigurationFreeze types.ConfigFreeze
+	defaultConfigurationFreeze.Windows = types.FreezeWindows{}
+
+	// default ConfigurationHistory
+	var defaultConfigurationHistory types.ConfigHistory
+	defaultConfigurationHistory.Size = 10
+	defaultConfigurationHistory.KVKey = "traefik/history"
+
+	// default CertificateExpiry
+	var defaultCertificateExpiry types.CertExpiry
+	defaultCertificateExpiry.WarnDays = 21
+	defaultCertificateExpiry.CheckInterval = 3600
+
+	// default DefaultCertificate
+	var defaultDefaultCertificate types.DefaultCert
+	defaultDefaultCertificate.Subject = "TRAEFIK DEFAULT CERT"
+	defaultDefaultCertificate.SANs = types.SANs{}
+	defaultDefaultCertificate.KeyType = "RSA2048"
+	defaultDefaultCertificate.Validity = 365
+
+	// default SessionTickets
+	var defaultSessionTickets types.SessionTickets
+	defaultSessionTickets.RotationInterval = 43200
+	defaultSessionTickets.Keys = 3
+	defaultSessionTickets.Storage = "traefik/sessiontickets"
+
+	// default Sandbox
+	var defaultSandbox types.Sandbox
+	defaultSandbox.User = "nobody"
+	defaultSandbox.Seccomp = true
+
 	defaultConfiguration := GlobalConfiguration{
 		Docker:        &defaultDocker,
 		File:          &defaultFile,
@@ -397,7 +586,23 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 		Boltdb:        &defaultBoltDb,
 		Kubernetes:    &defaultKubernetes,
 		Mesos:         &defaultMesos,
+		Plugin:        &defaultPlugin,
 		Retry:         &Retry{},
+		DNS:           &defaultDNS,
+		RealIP:        &defaultRealIP,
+		TargetGroup:   &defaultTargetGroup,
+
+		ConsulRegistration:   &defaultConsulRegistration,
+		FeatureFlags:         &defaultFeatureFlags,
+		InternalCA:           &defaultInternalCA,
+		Usage:                &defaultUsage,
+		ConfigurationCache:   &defaultConfigurationCache,
+		ConfigurationFreeze:  &defaultConfigurationFreeze,
+		ConfigurationHistory: &defaultConfigurationHistory,
+		CertificateExpiry:    &defaultCertificateExpiry,
+		SessionTickets:       &defaultSessionTickets,
+		DefaultCertificate:   &defaultDefaultCertificate,
+		Sandbox:              &defaultSandbox,
 	}
 	return &TraefikConfiguration{
 		GlobalConfiguration: defaultConfiguration,
@@ -419,7 +624,9 @@ func NewTraefikConfiguration() *TraefikConfiguration {
 			MaxIdleConnsPerHost:       200,
 			CheckNewVersion:           true,
 		},
-		ConfigFile: "",
+		ConfigFile:  "",
+		Output:      outputText,
+		ServiceName: "traefik",
 	}
 }
 
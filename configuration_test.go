@@ -0,0 +1,42 @@
+package main
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSApplyVersions(t *testing.T) {
+	config := &tls.Config{}
+	err := (&TLS{MinVersion: "VersionTLS11", MaxVersion: "VersionTLS12", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}}).applyVersions(config)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS11), config.MinVersion)
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MaxVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, config.CipherSuites)
+
+	config = &tls.Config{}
+	assert.NoError(t, (&TLS{MaxVersion: "VersionTLS11"}).applyVersions(config))
+	assert.Equal(t, uint16(tls.VersionTLS11), config.MaxVersion)
+	assert.Nil(t, config.CipherSuites)
+
+	for _, invalid := range []*TLS{
+		{MaxVersion: "VersionTLS14"},
+		{MinVersion: "VersionTLS12", MaxVersion: "VersionTLS11"},
+		{CipherSuites: []string{"TLS_UNKNOWN"}},
+	} {
+		assert.Error(t, invalid.applyVersions(&tls.Config{}), "%+v", invalid)
+	}
+
+	// crypto/tls can't negotiate TLS 1.3 yet
+	for _, unsupported := range []*TLS{
+		{MinVersion: "VersionTLS13"},
+		{MinVersion: "VersionTLS12", MaxVersion: "VersionTLS13"},
+		{CipherSuites: []string{"TLS_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}},
+	} {
+		err := unsupported.applyVersions(&tls.Config{})
+		if assert.Error(t, err, "%+v", unsupported) {
+			assert.Contains(t, err.Error(), "TLS 1.3 is not supported", "%+v", unsupported)
+		}
+	}
+}
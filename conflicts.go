@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/containous/traefik/types"
+)
+
+// RouteConflict reports a frontend that can't be reached, or that claims the
+// same requests as a frontend of another provider. Type is "shadowed" when every
+// request matching Frontend is caught first by Other, which has a higher priority,
+// "ambiguous" when both have the same priority and one catches every request of
+// the other, and "overlap" when they come from different providers and claim
+// overlapping hosts and paths.
+type RouteConflict struct {
+	Type          string `json:"type"`
+	EntryPoint    string `json:"entryPoint"`
+	Frontend      string `json:"frontend"`
+	Provider      string `json:"provider"`
+	Other         string `json:"other"`
+	OtherProvider string `json:"otherProvider"`
+}
+
+func (c *RouteConflict) String() string {
+	switch c.Type {
+	case "shadowed":
+		return fmt.Sprintf("Frontend %s (%s) on entrypoint %s can never match, it is shadowed by frontend %s (%s)", c.Frontend, c.Provider, c.EntryPoint, c.Other, c.OtherProvider)
+	case "ambiguous":
+		return fmt.Sprintf("Frontends %s (%s) and %s (%s) on entrypoint %s have the same priority and match the same requests", c.Frontend, c.Provider, c.Other, c.OtherProvider, c.EntryPoint)
+	default:
+		return fmt.Sprintf("Frontends %s (%s) and %s (%s) on entrypoint %s claim overlapping hosts and paths", c.Frontend, c.Provider, c.Other, c.OtherProvider, c.EntryPoint)
+	}
+}
+
+// pathMatcher is a Path or PathPrefix argument.
+type pathMatcher struct {
+	value  string
+	prefix bool
+}
+
+// ruleMatcher is the set of requests matched by a frontend: each dimension is
+// a list of groups that must all match, a group matching if one of its values matches.
+// Regexp rules can't be compared, they only cover identical regexp rules.
+type ruleMatcher struct {
+	hosts   [][]string
+	paths   [][]pathMatcher
+	methods [][]string
+	headers [][]string
+	opaque  []string
+}
+
+type analyzedFrontend struct {
+	name     string
+	provider string
+	priority int
+	matcher  *ruleMatcher
+}
+
+func newRuleMatcher(frontend *types.Frontend) (*ruleMatcher, error) {
+	matcher := &ruleMatcher{}
+	rules := Rules{}
+	for _, route := range frontend.Routes {
+		err := rules.parseRules(route.Rule, func(functionName string, function interface{}, arguments []string) error {
+			switch functionName {
+			case "Host":
+				hosts := []string{}
+				for _, host := range arguments {
+					hosts = append(hosts, types.CanonicalDomain(host))
+				}
+				matcher.hosts = append(matcher.hosts, hosts)
+			case "Path", "PathStrip", "PathPrefix", "PathPrefixStrip":
+				paths := []pathMatcher{}
+				for _, path := range arguments {
+					paths = append(paths, pathMatcher{value: path, prefix: strings.HasPrefix(functionName, "PathPrefix")})
+				}
+				matcher.paths = append(matcher.paths, paths)
+			case "Method":
+				methods := []string{}
+				for _, method := range arguments {
+					methods = append(methods, strings.ToUpper(method))
+				}
+				matcher.methods = append(matcher.methods, methods)
+			case "Headers":
+				for i := 0; i+1 < len(arguments); i += 2 {
+					matcher.headers = append(matcher.headers, []string{arguments[i] + "=" + arguments[i+1]})
+				}
+			default:
+				matcher.opaque = append(matcher.opaque, functionName+":"+strings.Join(arguments, ","))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matcher, nil
+}
+
+// coversGroups returns true if each of the a groups is covered by one of the b
+// groups, i.e. if every request matching all b groups matches all a groups.
+func coversGroups(a, b int, groupCovers func(i, j int) bool) bool {
+	for i := 0; i < a; i++ {
+		covered := false
+		for j := 0; j < b; j++ {
+			if groupCovers(i, j) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func coversStrings(a, b [][]string) bool {
+	return coversGroups(len(a), len(b), func(i, j int) bool {
+		for _, value := range b[j] {
+			found := false
+			for _, candidate := range a[i] {
+				if candidate == value {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (p pathMatcher) covers(other pathMatcher) bool {
+	if p.prefix && !strings.Contains(p.value, "{") {
+		return strings.HasPrefix(other.value, p.value)
+	}
+	return p == other
+}
+
+func coversPaths(a, b [][]pathMatcher) bool {
+	return coversGroups(len(a), len(b), func(i, j int) bool {
+		for _, path := range b[j] {
+			found := false
+			for _, candidate := range a[i] {
+				if candidate.covers(path) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// covers returns true if m matches at least every request matched by other.
+func (m *ruleMatcher) covers(other *ruleMatcher) bool {
+	for _, opaque := range m.opaque {
+		found := false
+		for _, candidate := range other.opaque {
+			if candidate == opaque {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return coversStrings(m.hosts, other.hosts) &&
+		coversPaths(m.paths, other.paths) &&
+		coversStrings(m.methods, other.methods) &&
+		coversStrings(m.headers, other.headers)
+}
+
+// overlaps returns true if m and other may claim the same host and path.
+func (m *ruleMatcher) overlaps(other *ruleMatcher) bool {
+	hostsOverlap := len(m.hosts) == 0 || len(other.hosts) == 0
+	for _, hosts := range m.hosts {
+		for _, host := range hosts {
+			for _, otherHosts := range other.hosts {
+				for _, otherHost := range otherHosts {
+					hostsOverlap = hostsOverlap || host == otherHost
+				}
+			}
+		}
+	}
+	pathsOverlap := len(m.paths) == 0 || len(other.paths) == 0
+	for _, paths := range m.paths {
+		for _, path := range paths {
+			for _, otherPaths := range other.paths {
+				for _, otherPath := range otherPaths {
+					pathsOverlap = pathsOverlap || path.covers(otherPath) || otherPath.covers(path)
+				}
+			}
+		}
+	}
+	return hostsOverlap && pathsOverlap
+}
+
+// frontendPriority returns the priority of the frontend route, as computed by loadConfig.
+func frontendPriority(frontend *types.Frontend) int {
+	if frontend.Priority > 0 {
+		return frontend.Priority
+	}
+	priority := 0
+	for _, route := range frontend.Routes {
+		priority += len(route.Rule)
+	}
+	return priority
+}
+
+type analyzedFrontends []*analyzedFrontend
+
+func (f analyzedFrontends) Len() int      { return len(f) }
+func (f analyzedFrontends) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+func (f analyzedFrontends) Less(i, j int) bool {
+	if f[i].priority == f[j].priority {
+		if f[i].provider == f[j].provider {
+			return f[i].name < f[j].name
+		}
+		return f[i].provider < f[j].provider
+	}
+	return f[i].priority > f[j].priority
+}
+
+// analyzeRouteConflicts reports, per entrypoint, the frontends shadowed by a
+// frontend of higher priority and the overlapping claims of different providers.
+func analyzeRouteConflicts(configurations configs) []*RouteConflict {
+	entryPoints := map[string]analyzedFrontends{}
+	for providerName, configuration := range configurations {
+		if configuration == nil {
+			continue
+		}
+		for frontendName, frontend := range configuration.Frontends {
+			matcher, err := newRuleMatcher(frontend)
+			if err != nil {
+				// invalid rules are reported when loading the configuration
+				continue
+			}
+			analyzed := &analyzedFrontend{name: frontendName, provider: providerName, priority: frontendPriority(frontend), matcher: matcher}
+			for _, entryPointName := range frontend.EntryPoints {
+				entryPoints[entryPointName] = append(entryPoints[entryPointName], analyzed)
+			}
+		}
+	}
+
+	entryPointNames := []string{}
+	for entryPointName := range entryPoints {
+		entryPointNames = append(entryPointNames, entryPointName)
+	}
+	sort.Strings(entryPointNames)
+
+	conflicts := []*RouteConflict{}
+	for _, entryPointName := range entryPointNames {
+		frontends := entryPoints[entryPointName]
+		sort.Sort(frontends)
+		for j, frontend := range frontends {
+			for _, other := range frontends[:j] {
+				conflict := &RouteConflict{
+					EntryPoint:    entryPointName,
+					Frontend:      frontend.name,
+					Provider:      frontend.provider,
+					Other:         other.name,
+					OtherProvider: other.provider,
+				}
+				switch {
+				case other.priority > frontend.priority && other.matcher.covers(frontend.matcher):
+					conflict.Type = "shadowed"
+				case other.priority == frontend.priority && (other.matcher.covers(frontend.matcher) || frontend.matcher.covers(other.matcher)):
+					conflict.Type = "ambiguous"
+				case other.provider != frontend.provider && other.matcher.overlaps(frontend.matcher):
+					conflict.Type = "overlap"
+				default:
+					continue
+				}
+				conflicts = append(conflicts, conflict)
+				if conflict.Type == "shadowed" {
+					break
+				}
+			}
+		}
+	}
+	return conflicts
+}
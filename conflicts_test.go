@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/containous/traefik/types"
+)
+
+func TestAnalyzeRouteConflicts(t *testing.T) {
+	frontend := func(priority int, rules ...string) *types.Frontend {
+		routes := map[string]types.Route{}
+		for i, rule := range rules {
+			routes[string('a'+rune(i))] = types.Route{Rule: rule}
+		}
+		return &types.Frontend{EntryPoints: []string{"http"}, Routes: routes, Priority: priority}
+	}
+	configurations := configs{
+		"file": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"api":       frontend(100, "Host:test.localhost;PathPrefix:/api"),
+				"users":     frontend(0, "Host:test.localhost;PathPrefix:/api/users"),
+				"admin":     frontend(0, "Host:test.localhost", "PathPrefix:/admin"),
+				"adminPost": frontend(0, "Host:test.localhost", "PathPrefix:/admin;Method:POST"),
+				"regexp":    frontend(0, "HostRegexp:{subdomain:[a-z]+}.localhost"),
+				"same1":     frontend(10, "Host:same.localhost"),
+				"same2":     frontend(10, "Host:same.localhost,other.localhost"),
+			},
+		},
+		"docker": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"whoami": frontend(0, "Host:Test.localhost;Path:/whoami"),
+			},
+		},
+		"invalid": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"invalid": frontend(0, "Foo:bar"),
+			},
+		},
+	}
+
+	expected := []*RouteConflict{
+		{Type: "shadowed", EntryPoint: "http", Frontend: "users", Provider: "file", Other: "api", OtherProvider: "file"},
+		// regexp rules can't be compared, HostRegexp may claim any host
+		{Type: "overlap", EntryPoint: "http", Frontend: "whoami", Provider: "docker", Other: "regexp", OtherProvider: "file"},
+		{Type: "ambiguous", EntryPoint: "http", Frontend: "same2", Provider: "file", Other: "same1", OtherProvider: "file"},
+	}
+	actual := analyzeRouteConflicts(configurations)
+	if !reflect.DeepEqual(actual, expected) {
+		for _, conflict := range actual {
+			t.Log(conflict)
+		}
+		t.Fatalf("expected %d conflicts, got %d", len(expected), len(actual))
+	}
+}
+
+func TestRuleMatcherCovers(t *testing.T) {
+	cases := []struct {
+		rule     string
+		other    string
+		expected bool
+	}{
+		{"Host:a.localhost", "Host:a.localhost;Path:/foo", true},
+		{"Host:a.localhost;Path:/foo", "Host:a.localhost", false},
+		{"Host:a.localhost,b.localhost", "Host:b.localhost", true},
+		{"Host:b.localhost", "Host:a.localhost,b.localhost", false},
+		{"PathPrefix:/foo", "Path:/foo/bar", true},
+		{"Path:/foo", "PathPrefix:/foo", false},
+		{"PathPrefix:/foo/{id}", "Path:/foo/bar", false},
+		{"Method:GET,POST", "Method:get", true},
+		{"Headers:Content-Type,application/json", "Path:/foo", false},
+		{"HostRegexp:{sub:.*}.localhost", "HostRegexp:{sub:.*}.localhost;Path:/foo", true},
+		{"HostRegexp:{sub:.*}.localhost", "Host:a.localhost", false},
+	}
+	for _, c := range cases {
+		matcher, err := newRuleMatcher(&types.Frontend{Routes: map[string]types.Route{"route": {Rule: c.rule}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, err := newRuleMatcher(&types.Frontend{Routes: map[string]types.Route{"route": {Rule: c.other}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := matcher.covers(other); actual != c.expected {
+			t.Errorf("expected %s covering %s to be %v", c.rule, c.other, c.expected)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// loadDefaultCertificate returns the self-signed certificate of config,
+// loaded from its files if they hold a certificate not expired yet, else
+// generated, and saved to its files if any.
+func loadDefaultCertificate(config *types.DefaultCert) (*tls.Certificate, error) {
+	if (len(config.CertFile) == 0) != (len(config.KeyFile) == 0) {
+		return nil, errors.New("both the certificate and key files of the default certificate are required to save it")
+	}
+	if len(config.CertFile) > 0 {
+		certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		switch {
+		case err == nil:
+			leaf, err := parseLeaf(certificate)
+			if err != nil {
+				return nil, err
+			}
+			if time.Now().Before(leaf.NotAfter) {
+				log.Infof("Loaded the default certificate %s, valid until %s", config.CertFile, leaf.NotAfter.Format(time.RFC3339))
+				return &certificate, nil
+			}
+			log.Warnf("The default certificate %s expired on %s, generating a new one", config.CertFile, leaf.NotAfter.Format(time.RFC3339))
+		case os.IsNotExist(err):
+		default:
+			return nil, fmt.Errorf("invalid default certificate %s: %v", config.CertFile, err)
+		}
+	}
+
+	certPEM, keyPEM, err := generateDefaultCertificate(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.CertFile) > 0 {
+		if err := ioutil.WriteFile(config.KeyFile, keyPEM, 0600); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(config.CertFile, certPEM, 0644); err != nil {
+			return nil, err
+		}
+		log.Infof("Saved the generated default certificate to %s", config.CertFile)
+	}
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// generateDefaultCertificate returns a PEM encoded self-signed certificate
+// and its private key, with the subject, domains, key type and validity of
+// config.
+func generateDefaultCertificate(config *types.DefaultCert) ([]byte, []byte, error) {
+	key, err := generateDefaultCertificateKey(config.KeyType)
+	if err != nil {
+		return nil, nil, err
+	}
+	var keyBlock *pem.Block
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		keyBlock = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	case *ecdsa.PrivateKey:
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		keyBlock = &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}
+	}
+
+	domains := []string(config.SANs)
+	if len(domains) == 0 {
+		randomBytes := make([]byte, 100)
+		if _, err := rand.Read(randomBytes); err != nil {
+			return nil, nil, err
+		}
+		zBytes := sha256.Sum256(randomBytes)
+		z := hex.EncodeToString(zBytes[:sha256.Size])
+		domains = []string{fmt.Sprintf("%s.%s.traefik.default", z[:32], z[32:])}
+	}
+	validity := config.Validity
+	if validity <= 0 {
+		validity = 365
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: config.Subject},
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.AddDate(0, 0, validity),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              domains,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return certPEM, pem.EncodeToMemory(keyBlock), nil
+}
+
+func generateDefaultCertificateKey(keyType string) (crypto.Signer, error) {
+	switch keyType {
+	case "", "RSA2048":
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case "RSA4096":
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case "EC256":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EC384":
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	}
+	return nil, fmt.Errorf("Invalid key type %s of the default certificate, expected RSA2048, RSA4096, EC256 or EC384", keyType)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateDefaultCertificate(t *testing.T) {
+	certificate, err := loadDefaultCertificate(&types.DefaultCert{
+		Subject:  "internal.example.com",
+		SANs:     types.SANs{"internal.example.com", "*.internal.example.com"},
+		KeyType:  "EC256",
+		Validity: 30,
+	})
+	assert.NoError(t, err)
+	leaf, err := parseLeaf(*certificate)
+	assert.NoError(t, err)
+	assert.Equal(t, "internal.example.com", leaf.Subject.CommonName)
+	assert.Equal(t, []string{"internal.example.com", "*.internal.example.com"}, leaf.DNSNames)
+	assert.Equal(t, elliptic.P256(), leaf.PublicKey.(*ecdsa.PublicKey).Curve)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), leaf.NotAfter, time.Minute)
+
+	// a random domain by default
+	certificate, err = loadDefaultCertificate(&types.DefaultCert{Subject: "TRAEFIK DEFAULT CERT"})
+	assert.NoError(t, err)
+	leaf, err = parseLeaf(*certificate)
+	assert.NoError(t, err)
+	assert.Len(t, leaf.DNSNames, 1)
+	assert.True(t, strings.HasSuffix(leaf.DNSNames[0], ".traefik.default"))
+
+	_, err = loadDefaultCertificate(&types.DefaultCert{KeyType: "DSA"})
+	assert.Error(t, err)
+	_, err = loadDefaultCertificate(&types.DefaultCert{CertFile: "default.crt"})
+	assert.Error(t, err)
+}
+
+func TestPersistDefaultCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "traefik-defaultcert")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	config := &types.DefaultCert{
+		Subject:  "TRAEFIK DEFAULT CERT",
+		CertFile: filepath.Join(dir, "default.crt"),
+		KeyFile:  filepath.Join(dir, "default.key"),
+		Validity: 1,
+	}
+
+	generated, err := loadDefaultCertificate(config)
+	assert.NoError(t, err)
+	keyInfo, err := os.Stat(config.KeyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), keyInfo.Mode().Perm())
+
+	// the saved certificate is loaded at the next starts
+	loaded, err := loadDefaultCertificate(config)
+	assert.NoError(t, err)
+	assert.Equal(t, generated.Certificate, loaded.Certificate)
+
+	// an expired one is replaced
+	expired := generateTestCertificate(t, "expired.localhost", time.Now().Add(-time.Hour))
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: expired.Certificate[0]})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(expired.PrivateKey.(*rsa.PrivateKey))})
+	assert.NoError(t, ioutil.WriteFile(config.CertFile, certPEM, 0644))
+	assert.NoError(t, ioutil.WriteFile(config.KeyFile, keyPEM, 0600))
+	loaded, err = loadDefaultCertificate(config)
+	assert.NoError(t, err)
+	leaf, err := parseLeaf(*loaded)
+	assert.NoError(t, err)
+	assert.Equal(t, "TRAEFIK DEFAULT CERT", leaf.Subject.CommonName)
+}
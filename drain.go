@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares"
+)
+
+// defaultDrainWindow is the time left to the in-flight requests of a drained
+// frontend when the drain doesn't set its window.
+const defaultDrainWindow = 30 * time.Second
+
+// drainRetryAfter is the Retry-After, in seconds, of the requests rejected by a drained frontend.
+const drainRetryAfter = 30
+
+// FrontendDrains stops the frontends drained through the API from accepting
+// new requests, and lets their in-flight requests and websockets bleed off
+// over the window of the drain. The drains outlive the configuration reloads,
+// the providers owning the frontends are left untouched.
+type FrontendDrains struct {
+	mutex     sync.Mutex
+	frontends map[string]*frontendDrainState
+}
+
+// FrontendDrain is the drain of a frontend. The new requests are redirected to
+// Redirect when set, rejected with a 503 otherwise.
+type FrontendDrain struct {
+	Frontend string    `json:"frontend"`
+	Redirect string    `json:"redirect,omitempty"`
+	Window   string    `json:"window,omitempty"`
+	Since    time.Time `json:"since"`
+	Deadline time.Time `json:"deadline"`
+	InFlight int       `json:"inFlight"`
+}
+
+// frontendDrainState tracks the in-flight requests of a frontend, and its drain if any.
+type frontendDrainState struct {
+	drain    *FrontendDrain
+	timer    *time.Timer
+	inFlight map[*drainedRequest]struct{}
+}
+
+// drainedRequest is an in-flight request of a frontend, canceled with its
+// hijacked connection when the window of the drain expires.
+type drainedRequest struct {
+	mutex  sync.Mutex
+	cancel context.CancelFunc
+	conn   net.Conn
+	closed bool
+}
+
+// NewFrontendDrains returns a FrontendDrains without drained frontends.
+func NewFrontendDrains() *FrontendDrains {
+	return &FrontendDrains{frontends: make(map[string]*frontendDrainState)}
+}
+
+// validateDrain checks the redirect and the window of drain.
+func validateDrain(drain *FrontendDrain) error {
+	if len(drain.Redirect) > 0 {
+		redirect, err := url.Parse(drain.Redirect)
+		if err != nil {
+			return fmt.Errorf("invalid redirect %q: %v", drain.Redirect, err)
+		}
+		if !redirect.IsAbs() {
+			return fmt.Errorf("invalid redirect %q, expected an absolute URL", drain.Redirect)
+		}
+	}
+	if len(drain.Window) > 0 {
+		window, err := time.ParseDuration(drain.Window)
+		if err != nil {
+			return fmt.Errorf("invalid window %q: %v", drain.Window, err)
+		}
+		if window < 0 {
+			return fmt.Errorf("invalid negative window %s", drain.Window)
+		}
+	}
+	return nil
+}
+
+// Drain stops frontendName from accepting new requests, its in-flight
+// requests are canceled once the window of drain expires. Draining a drained
+// frontend again replaces its drain, and restarts its window.
+func (d *FrontendDrains) Drain(frontendName string, drain *FrontendDrain) (*FrontendDrain, error) {
+	if err := validateDrain(drain); err != nil {
+		return nil, err
+	}
+	window := defaultDrainWindow
+	if len(drain.Window) > 0 {
+		window, _ = time.ParseDuration(drain.Window)
+	}
+	now := time.Now()
+	current := &FrontendDrain{
+		Frontend: frontendName,
+		Redirect: drain.Redirect,
+		Window:   window.String(),
+		Since:    now,
+		Deadline: now.Add(window),
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	state := d.state(frontendName)
+	if state.timer != nil {
+		state.timer.Stop()
+	}
+	state.drain = current
+	state.timer = time.AfterFunc(window, func() {
+		d.expire(frontendName, current)
+	})
+	log.Infof("Draining frontend %s, %d in-flight requests left %s", frontendName, len(state.inFlight), window)
+	return d.snapshot(state), nil
+}
+
+// Resume lets frontendName accept new requests again, it returns false if the
+// frontend wasn't drained.
+func (d *FrontendDrains) Resume(frontendName string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	state, ok := d.frontends[frontendName]
+	if !ok || state.drain == nil {
+		return false
+	}
+	state.timer.Stop()
+	state.drain = nil
+	state.timer = nil
+	d.release(frontendName, state)
+	log.Infof("Resuming frontend %s", frontendName)
+	return true
+}
+
+// Get returns the drain of frontendName, or nil if it isn't drained.
+func (d *FrontendDrains) Get(frontendName string) *FrontendDrain {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	state, ok := d.frontends[frontendName]
+	if !ok || state.drain == nil {
+		return nil
+	}
+	return d.snapshot(state)
+}
+
+// Data returns the drains, sorted by frontend.
+func (d *FrontendDrains) Data() []*FrontendDrain {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	drains := []*FrontendDrain{}
+	for _, state := range d.frontends {
+		if state.drain != nil {
+			drains = append(drains, d.snapshot(state))
+		}
+	}
+	sort.Sort(frontendDrainsByName(drains))
+	return drains
+}
+
+// Handler returns a handler rejecting the requests of frontendName while it
+// is drained, and tracking the requests passed to next until they are done.
+func (d *FrontendDrains) Handler(frontendName string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		request, drain := d.begin(frontendName, cancel)
+		if drain != nil {
+			middlewares.SetErrorReason(r, middlewares.ReasonFrontendDrained)
+			if len(drain.Redirect) > 0 {
+				http.Redirect(rw, r, drain.Redirect, http.StatusFound)
+				return
+			}
+			rw.Header().Set("Connection", "close")
+			rw.Header().Set("Retry-After", fmt.Sprint(drainRetryAfter))
+			http.Error(rw, "Service draining", http.StatusServiceUnavailable)
+			return
+		}
+		defer d.end(frontendName, request)
+		next.ServeHTTP(&drainResponseWriter{ResponseWriter: rw, request: request}, r.WithContext(ctx))
+	})
+}
+
+func (d *FrontendDrains) begin(frontendName string, cancel context.CancelFunc) (*drainedRequest, *FrontendDrain) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	state := d.state(frontendName)
+	if state.drain != nil {
+		return nil, state.drain
+	}
+	request := &drainedRequest{cancel: cancel}
+	state.inFlight[request] = struct{}{}
+	return request, nil
+}
+
+func (d *FrontendDrains) end(frontendName string, request *drainedRequest) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	state := d.frontends[frontendName]
+	delete(state.inFlight, request)
+	if len(state.inFlight) == 0 && state.drain != nil {
+		log.Infof("Frontend %s drained", frontendName)
+	}
+	d.release(frontendName, state)
+}
+
+// expire cancels the requests still in flight when the window of drain expires.
+func (d *FrontendDrains) expire(frontendName string, drain *FrontendDrain) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	state, ok := d.frontends[frontendName]
+	if !ok || state.drain != drain {
+		// resumed or drained again meanwhile
+		return
+	}
+	if len(state.inFlight) > 0 {
+		log.Infof("Drain window of frontend %s expired, canceling %d in-flight requests", frontendName, len(state.inFlight))
+	}
+	for request := range state.inFlight {
+		request.close()
+	}
+}
+
+// state returns the state of frontendName, created if needed. d.mutex must be held.
+func (d *FrontendDrains) state(frontendName string) *frontendDrainState {
+	state, ok := d.frontends[frontendName]
+	if !ok {
+		state = &frontendDrainState{inFlight: make(map[*drainedRequest]struct{})}
+		d.frontends[frontendName] = state
+	}
+	return state
+}
+
+// release forgets the state of frontendName once it is neither drained nor
+// serving requests. d.mutex must be held.
+func (d *FrontendDrains) release(frontendName string, state *frontendDrainState) {
+	if state.drain == nil && len(state.inFlight) == 0 {
+		delete(d.frontends, frontendName)
+	}
+}
+
+// snapshot returns a copy of the drain of state. d.mutex must be held.
+func (d *FrontendDrains) snapshot(state *frontendDrainState) *FrontendDrain {
+	drain := *state.drain
+	drain.InFlight = len(state.inFlight)
+	return &drain
+}
+
+func (r *drainedRequest) hijacked(conn net.Conn) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.conn = conn
+	return !r.closed
+}
+
+func (r *drainedRequest) close() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.closed = true
+	r.cancel()
+	if r.conn != nil {
+		// the websockets ignore the cancelation of their request
+		r.conn.Close()
+	}
+}
+
+// drainResponseWriter records the connection hijacked by the websockets, to
+// close it when the window of the drain expires.
+type drainResponseWriter struct {
+	http.ResponseWriter
+	request *drainedRequest
+}
+
+func (w *drainResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *drainResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buffer, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err != nil {
+		return conn, buffer, err
+	}
+	if !w.request.hijacked(conn) {
+		conn.Close()
+	}
+	return conn, buffer, nil
+}
+
+func (w *drainResponseWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+type frontendDrainsByName []*FrontendDrain
+
+func (a frontendDrainsByName) Len() int           { return len(a) }
+func (a frontendDrainsByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a frontendDrainsByName) Less(i, j int) bool { return a[i].Frontend < a[j].Frontend }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrontendDrainsRejectNewRequests(t *testing.T) {
+	drains := NewFrontendDrains()
+	handler := drains.Handler("frontend1", faultBackend)
+
+	_, err := drains.Drain("frontend1", &FrontendDrain{Window: "1m"})
+	assert.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "30", recorder.Header().Get("Retry-After"))
+
+	// the other frontends are left untouched
+	recorder = httptest.NewRecorder()
+	drains.Handler("frontend2", faultBackend).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	_, err = drains.Drain("frontend1", &FrontendDrain{Redirect: "https://maintenance.localhost/"})
+	assert.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusFound, recorder.Code)
+	assert.Equal(t, "https://maintenance.localhost/", recorder.Header().Get("Location"))
+
+	assert.True(t, drains.Resume("frontend1"))
+	assert.False(t, drains.Resume("frontend1"))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, drains.Data())
+}
+
+func TestFrontendDrainsBleedOff(t *testing.T) {
+	drains := NewFrontendDrains()
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	handler := drains.Handler("frontend1", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(canceled)
+	}))
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	<-started
+
+	drain, err := drains.Drain("frontend1", &FrontendDrain{Window: "50ms"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, drain.InFlight)
+	select {
+	case <-canceled:
+		t.Fatal("in-flight request canceled before the end of the window")
+	case <-time.After(20 * time.Millisecond):
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("in-flight request not canceled at the end of the window")
+	}
+}
+
+func TestValidateDrain(t *testing.T) {
+	assert.NoError(t, validateDrain(&FrontendDrain{Redirect: "https://localhost/", Window: "10s"}))
+	assert.Error(t, validateDrain(&FrontendDrain{Redirect: "/maintenance"}))
+	assert.Error(t, validateDrain(&FrontendDrain{Window: "10"}))
+	assert.Error(t, validateDrain(&FrontendDrain{Window: "-1s"}))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// dryRunner is implemented by the providers that can run in dry-run mode.
+type dryRunner interface {
+	IsDryRun() bool
+}
+
+// dryRunConfigurations keeps the last configurations of the providers running
+// in dry-run mode. They are published through the API, with their changes and
+// their route conflicts against the live configuration, but never applied.
+type dryRunConfigurations struct {
+	mutex          sync.RWMutex
+	configurations map[string]*dryRunConfiguration
+}
+
+type dryRunConfiguration struct {
+	received      time.Time
+	configuration *types.Configuration
+}
+
+// DryRunResult is the last configuration of a dry-run provider, with what
+// applying it would change to the live configuration.
+type DryRunResult struct {
+	Provider      string               `json:"provider"`
+	Received      time.Time            `json:"received"`
+	Changes       []string             `json:"changes"`
+	Conflicts     []*RouteConflict     `json:"conflicts"`
+	Configuration *types.Configuration `json:"configuration"`
+}
+
+func newDryRunConfigurations() *dryRunConfigurations {
+	return &dryRunConfigurations{configurations: map[string]*dryRunConfiguration{}}
+}
+
+// listen keeps the configurations sent by a dry-run provider to
+// configurationChan, with the default values of setDefaults, instead of
+// applying them.
+func (d *dryRunConfigurations) listen(stop chan bool, configurationChan chan types.ConfigMessage, setDefaults func(*types.Configuration)) {
+	for {
+		select {
+		case <-stop:
+			return
+		case configMsg := <-configurationChan:
+			if configMsg.Configuration == nil {
+				continue
+			}
+			setDefaults(configMsg.Configuration)
+			log.Infof("Dry-run configuration received from provider %s, not applied", configMsg.ProviderName)
+			d.set(configMsg)
+		}
+	}
+}
+
+func (d *dryRunConfigurations) set(configMsg types.ConfigMessage) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.configurations[configMsg.ProviderName] = &dryRunConfiguration{received: time.Now().UTC(), configuration: configMsg.Configuration}
+}
+
+// Get returns the dry-run result of providerName against current, or nil if
+// the provider sent no configuration.
+func (d *dryRunConfigurations) Get(providerName string, current configs) *DryRunResult {
+	d.mutex.RLock()
+	dryRun, ok := d.configurations[providerName]
+	d.mutex.RUnlock()
+	if !ok {
+		return nil
+	}
+	configurations := make(configs)
+	for name, configuration := range current {
+		configurations[name] = configuration
+	}
+	configurations[providerName] = dryRun.configuration
+	conflicts := []*RouteConflict{}
+	for _, conflict := range analyzeRouteConflicts(configurations) {
+		if conflict.Provider == providerName || conflict.OtherProvider == providerName {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return &DryRunResult{
+		Provider:      providerName,
+		Received:      dryRun.received,
+		Changes:       configsChanges(current, configurations),
+		Conflicts:     conflicts,
+		Configuration: dryRun.configuration,
+	}
+}
+
+// Results returns the dry-run results of all the providers against current, sorted by provider.
+func (d *dryRunConfigurations) Results(current configs) []*DryRunResult {
+	d.mutex.RLock()
+	names := []string{}
+	for name := range d.configurations {
+		names = append(names, name)
+	}
+	d.mutex.RUnlock()
+	sort.Strings(names)
+	results := []*DryRunResult{}
+	for _, name := range names {
+		if result := d.Get(name, current); result != nil {
+			results = append(results, result)
+		}
+	}
+	return results
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunConfigurations(t *testing.T) {
+	dryRun := newDryRunConfigurations()
+	current := configs{"file": {
+		Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend1", EntryPoints: []string{"http"}, Routes: map[string]types.Route{"route1": {Rule: "Host:foo.bar"}}}},
+		Backends:  map[string]*types.Backend{"backend1": {}},
+	}}
+	assert.Nil(t, dryRun.Get("kubernetes", current))
+
+	stop := make(chan bool)
+	defer close(stop)
+	configurationChan := make(chan types.ConfigMessage)
+	go dryRun.listen(stop, configurationChan, func(configuration *types.Configuration) {
+		for _, frontend := range configuration.Frontends {
+			frontend.EntryPoints = []string{"http"}
+		}
+	})
+	configurationChan <- types.ConfigMessage{ProviderName: "kubernetes", Configuration: &types.Configuration{
+		Frontends: map[string]*types.Frontend{"foo.bar": {Backend: "backend2", Routes: map[string]types.Route{"foo.bar": {Rule: "Host:foo.bar"}}}},
+		Backends:  map[string]*types.Backend{"backend2": {}},
+	}}
+	// the configuration is kept once the next one is received
+	configurationChan <- types.ConfigMessage{ProviderName: "kubernetes"}
+
+	result := dryRun.Get("kubernetes", current)
+	assert.NotNil(t, result)
+	assert.Equal(t, []string{"kubernetes: backend backend2 added", "kubernetes: frontend foo.bar added"}, result.Changes)
+	assert.Len(t, result.Conflicts, 1)
+	assert.Equal(t, "ambiguous", result.Conflicts[0].Type)
+	assert.Equal(t, "http", result.Conflicts[0].EntryPoint)
+
+	results := dryRun.Results(current)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "kubernetes", results[0].Provider)
+}
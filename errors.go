@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/containous/traefik/middlewares"
+)
+
+const (
+	// errorOriginTraefik tags the error responses generated by traefik itself
+	errorOriginTraefik = "traefik"
+	// errorOriginBackend tags the error responses returned by the backends
+	errorOriginBackend = "backend"
+)
+
+// ErrorRecorder is a middleware counting the 4xx and 5xx responses per
+// frontend, telling the errors generated by traefik, tagged with a reason
+// code, from the errors returned by the backends.
+type ErrorRecorder struct {
+	mutex  sync.RWMutex
+	counts map[errorKey]int64
+}
+
+type errorKey struct {
+	frontend   string
+	statusCode int
+	reason     string
+}
+
+// ErrorCount is the number of error responses of a frontend with the same status code and reason.
+type ErrorCount struct {
+	Frontend   string `json:"frontend"`
+	StatusCode int    `json:"status_code"`
+	Origin     string `json:"origin"`
+	Reason     string `json:"reason,omitempty"`
+	Count      int64  `json:"count"`
+}
+
+// NewErrorRecorder returns an empty ErrorRecorder.
+func NewErrorRecorder() *ErrorRecorder {
+	return &ErrorRecorder{counts: make(map[errorKey]int64)}
+}
+
+func (e *ErrorRecorder) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	r = middlewares.WithRequestInfo(r)
+	recorder := &errorResponseWriter{rw, http.StatusOK}
+	next(recorder, r)
+	if recorder.statusCode < 400 {
+		return
+	}
+	key := errorKey{
+		frontend:   middlewares.GetFrontendName(r),
+		statusCode: recorder.statusCode,
+		reason:     middlewares.GetErrorReason(r),
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.counts[key]++
+}
+
+// Data returns the error counts, sorted by frontend, status code and reason.
+// The errors of the requests that were not routed have an empty frontend.
+func (e *ErrorRecorder) Data() []*ErrorCount {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	counts := []*ErrorCount{}
+	for key, count := range e.counts {
+		origin := errorOriginBackend
+		if len(key.reason) > 0 {
+			origin = errorOriginTraefik
+		}
+		counts = append(counts, &ErrorCount{
+			Frontend:   key.frontend,
+			StatusCode: key.statusCode,
+			Origin:     origin,
+			Reason:     key.reason,
+			Count:      count,
+		})
+	}
+	sort.Sort(errorCountsByKey(counts))
+	return counts
+}
+
+// errorResponseWriter captures the status code of the response, and still
+// lets websockets hijack the connection and streams flush.
+type errorResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *errorResponseWriter) WriteHeader(status int) {
+	w.ResponseWriter.WriteHeader(status)
+	w.statusCode = status
+}
+
+func (w *errorResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *errorResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *errorResponseWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+type errorCountsByKey []*ErrorCount
+
+func (a errorCountsByKey) Len() int      { return len(a) }
+func (a errorCountsByKey) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a errorCountsByKey) Less(i, j int) bool {
+	if a[i].Frontend != a[j].Frontend {
+		return a[i].Frontend < a[j].Frontend
+	}
+	if a[i].StatusCode != a[j].StatusCode {
+		return a[i].StatusCode < a[j].StatusCode
+	}
+	return a[i].Reason < a[j].Reason
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/codegangsta/negroni"
+	"github.com/containous/traefik/middlewares"
+)
+
+func TestErrorRecorder(t *testing.T) {
+	recorder := NewErrorRecorder()
+	router := http.NewServeMux()
+	router.Handle("/backend", middlewares.FrontendHandler("frontend1", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	})))
+	router.Handle("/timeout", middlewares.FrontendHandler("frontend1", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		middlewares.SetErrorReason(r, middlewares.ReasonBackendTimeout)
+		rw.WriteHeader(http.StatusGatewayTimeout)
+	})))
+	router.Handle("/ok", middlewares.FrontendHandler("frontend2", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})))
+	router.HandleFunc("/", notFoundHandler)
+	handler := negroni.New(recorder)
+	handler.UseHandler(router)
+
+	for _, path := range []string{"/backend", "/timeout", "/timeout", "/ok", "/unknown"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	expected := []*ErrorCount{
+		{Frontend: "", StatusCode: http.StatusNotFound, Origin: errorOriginTraefik, Reason: middlewares.ReasonNoRoute, Count: 1},
+		{Frontend: "frontend1", StatusCode: http.StatusInternalServerError, Origin: errorOriginBackend, Count: 1},
+		{Frontend: "frontend1", StatusCode: http.StatusGatewayTimeout, Origin: errorOriginTraefik, Reason: middlewares.ReasonBackendTimeout, Count: 2},
+	}
+	if data := recorder.Data(); !reflect.DeepEqual(data, expected) {
+		for _, count := range data {
+			t.Logf("%+v", *count)
+		}
+		t.Fatal("unexpected error counts")
+	}
+}
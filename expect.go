@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+// Modes of the Expect: 100-continue handling of a backend
+const (
+	expectForward = "forward"
+	expectLocal   = "local"
+	expectStrip   = "strip"
+	// defaultExpectTimeout is the default wait for the 100 Continue of the servers, in milliseconds
+	defaultExpectTimeout = 1000
+)
+
+// checkExpect returns an error if the mode of expect is unknown.
+func checkExpect(expect *types.Expect) error {
+	switch expect.Mode {
+	case "", expectForward, expectLocal, expectStrip:
+		return nil
+	}
+	return fmt.Errorf("invalid expect mode %q, expected forward, local or strip", expect.Mode)
+}
+
+// expectTimeout returns the wait of the transport for the 100 Continue of the servers.
+func expectTimeout(expect *types.Expect) time.Duration {
+	if expect == nil || expect.Timeout <= 0 {
+		return defaultExpectTimeout * time.Millisecond
+	}
+	return time.Duration(expect.Timeout) * time.Millisecond
+}
+
+// expectHandler returns a handler applying the local or strip mode of expect
+// to the requests before they are forwarded by next.
+func expectHandler(expect *types.Expect, next http.Handler) http.Handler {
+	if expect.Mode != expectLocal && expect.Mode != expectStrip {
+		return next
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Expect"), "100-continue") {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		r.Header.Del("Expect")
+		if expect.Mode == expectLocal && r.Body != nil {
+			// the first read of the body makes the server answer 100 Continue
+			body := bufio.NewReader(r.Body)
+			body.Peek(1)
+			r.Body = &expectBody{Reader: body, Closer: r.Body}
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+type expectBody struct {
+	io.Reader
+	io.Closer
+}
+
+// defaultBackendTransport returns a transport configured like the default
+// transport, for the servers of a backend waiting timeout for the 100 Continue.
+func defaultBackendTransport(timeout time.Duration) http.RoundTripper {
+	defaultTransport := http.DefaultTransport.(*http.Transport)
+	return &http.Transport{
+		Proxy:                 defaultTransport.Proxy,
+		Dial:                  defaultTransport.Dial,
+		DialContext:           defaultTransport.DialContext,
+		TLSClientConfig:       defaultTransport.TLSClientConfig,
+		MaxIdleConns:          defaultTransport.MaxIdleConns,
+		MaxIdleConnsPerHost:   defaultTransport.MaxIdleConnsPerHost,
+		IdleConnTimeout:       defaultTransport.IdleConnTimeout,
+		TLSHandshakeTimeout:   defaultTransport.TLSHandshakeTimeout,
+		ExpectContinueTimeout: timeout,
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpectLocal(t *testing.T) {
+	var expect, body string
+	ts := httptest.NewServer(expectHandler(&types.Expect{Mode: expectLocal}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expect = r.Header.Get("Expect")
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+	})))
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("POST / HTTP/1.1\r\nHost: test\r\nContent-Length: 4\r\nExpect: 100-continue\r\n\r\n"))
+	reader := bufio.NewReader(conn)
+	// the body is only sent after the 100 Continue
+	status, err := reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 100 Continue", strings.TrimSpace(status))
+	reader.ReadString('\n')
+	conn.Write([]byte("test"))
+	response, err := http.ReadResponse(reader, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "", expect)
+	assert.Equal(t, "test", body)
+}
+
+func TestExpectStrip(t *testing.T) {
+	handler := expectHandler(&types.Expect{Mode: expectStrip}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Expect", r.Header.Get("Expect"))
+	}))
+	request := httptest.NewRequest("POST", "/", strings.NewReader("test"))
+	request.Header.Set("Expect", "100-continue")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, "", recorder.Header().Get("X-Expect"))
+
+	next := http.NotFoundHandler()
+	assert.NotNil(t, expectHandler(&types.Expect{}, next))
+	assert.NoError(t, checkExpect(&types.Expect{Mode: "forward"}))
+	assert.Error(t, checkExpect(&types.Expect{Mode: "drop"}))
+}
@@ -0,0 +1,195 @@
+package externaldns
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const cloudflareAPIURL = "https://api.cloudflare.com/client/v4"
+
+// Cloudflare publishes records with the Cloudflare API
+type Cloudflare struct {
+	Email   string `description:"Email of the Cloudflare account. Can be set with the CLOUDFLARE_EMAIL environment variable"`
+	APIKey  string `description:"API key of the Cloudflare account. Can be set with the CLOUDFLARE_API_KEY environment variable"`
+	apiURL  string
+	zones   map[string]string
+	zonesMu sync.Mutex
+}
+
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+}
+
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result json.RawMessage `json:"result"`
+}
+
+func (c *Cloudflare) do(method, path string, body interface{}, result interface{}) error {
+	email, apiKey := c.Email, c.APIKey
+	if len(email) == 0 {
+		email = os.Getenv("CLOUDFLARE_EMAIL")
+	}
+	if len(apiKey) == 0 {
+		apiKey = os.Getenv("CLOUDFLARE_API_KEY")
+	}
+	if len(email) == 0 || len(apiKey) == 0 {
+		return errors.New("Cloudflare email and API key must be set")
+	}
+	apiURL := c.apiURL
+	if len(apiURL) == 0 {
+		apiURL = cloudflareAPIURL
+	}
+
+	var content []byte
+	if body != nil {
+		var err error
+		if content, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	request, err := http.NewRequest(method, apiURL+path, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("X-Auth-Email", email)
+	request.Header.Set("X-Auth-Key", apiKey)
+	request.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	cfResponse := &cloudflareResponse{}
+	if err := json.NewDecoder(response.Body).Decode(cfResponse); err != nil {
+		return fmt.Errorf("Cloudflare answered %s: %v", response.Status, err)
+	}
+	if !cfResponse.Success {
+		messages := []string{}
+		for _, cfError := range cfResponse.Errors {
+			messages = append(messages, fmt.Sprintf("%d: %s", cfError.Code, cfError.Message))
+		}
+		return fmt.Errorf("Cloudflare answered %s: %s", response.Status, strings.Join(messages, ", "))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(cfResponse.Result, result)
+}
+
+// zoneID returns the ID of the zone of name, the longest zone name name ends with.
+func (c *Cloudflare) zoneID(name string) (string, error) {
+	c.zonesMu.Lock()
+	defer c.zonesMu.Unlock()
+	if c.zones == nil {
+		c.zones = make(map[string]string)
+	}
+	labels := strings.Split(name, ".")
+	for i := 0; i < len(labels)-1; i++ {
+		zoneName := strings.Join(labels[i:], ".")
+		if id, ok := c.zones[zoneName]; ok {
+			return id, nil
+		}
+		zones := []struct {
+			ID string `json:"id"`
+		}{}
+		if err := c.do("GET", "/zones?name="+url.QueryEscape(zoneName), nil, &zones); err != nil {
+			return "", err
+		}
+		if len(zones) > 0 {
+			c.zones[zoneName] = zones[0].ID
+			return zones[0].ID, nil
+		}
+	}
+	return "", fmt.Errorf("No Cloudflare zone found for %s", name)
+}
+
+func (c *Cloudflare) records(name, recordType string) (string, []cloudflareRecord, error) {
+	zoneID, err := c.zoneID(name)
+	if err != nil {
+		return "", nil, err
+	}
+	query := url.Values{}
+	query.Set("name", name)
+	query.Set("per_page", "100")
+	if len(recordType) > 0 {
+		query.Set("type", recordType)
+	}
+	records := []cloudflareRecord{}
+	if err := c.do("GET", "/zones/"+zoneID+"/dns_records?"+query.Encode(), nil, &records); err != nil {
+		return "", nil, err
+	}
+	return zoneID, records, nil
+}
+
+// Records lists the managed records of name
+func (c *Cloudflare) Records(name string) ([]Record, error) {
+	_, cfRecords, err := c.records(name, "")
+	if err != nil {
+		return nil, err
+	}
+	records := []Record{}
+	for _, cfRecord := range cfRecords {
+		if isManagedType(cfRecord.Type) {
+			records = append(records, Record{Name: name, Type: cfRecord.Type, Value: cfRecord.Content, TTL: cfRecord.TTL})
+		}
+	}
+	return records, nil
+}
+
+// Upsert updates the first record of the name and type of record, and deletes the others
+func (c *Cloudflare) Upsert(record Record) error {
+	zoneID, cfRecords, err := c.records(record.Name, record.Type)
+	if err != nil {
+		return err
+	}
+	cfRecord := cloudflareRecord{Type: record.Type, Name: record.Name, Content: record.Value, TTL: record.TTL}
+	if cfRecord.TTL <= 0 {
+		// automatic TTL
+		cfRecord.TTL = 1
+	}
+	if len(cfRecords) == 0 {
+		return c.do("POST", "/zones/"+zoneID+"/dns_records", cfRecord, nil)
+	}
+	if err := c.do("PUT", "/zones/"+zoneID+"/dns_records/"+cfRecords[0].ID, cfRecord, nil); err != nil {
+		return err
+	}
+	for _, other := range cfRecords[1:] {
+		if err := c.do("DELETE", "/zones/"+zoneID+"/dns_records/"+other.ID, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete deletes the records of name of the given type
+func (c *Cloudflare) Delete(name, recordType string) error {
+	zoneID, cfRecords, err := c.records(name, recordType)
+	if err != nil {
+		return err
+	}
+	for _, cfRecord := range cfRecords {
+		if err := c.do("DELETE", "/zones/"+zoneID+"/dns_records/"+cfRecord.ID, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
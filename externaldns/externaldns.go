@@ -0,0 +1,328 @@
+package externaldns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	// ownerPrefix is the label prepended to a host to name its ownership TXT record.
+	// The ownership record can't share the name of the host, which may be a CNAME.
+	ownerPrefix = "_traefik-owner."
+	// resyncInterval is the delay before retrying the records that failed to be published or deleted.
+	resyncInterval = time.Minute
+)
+
+// managedTypes are the record types read and written by the publisher
+var managedTypes = []string{"A", "AAAA", "CNAME", "TXT"}
+
+// DNS holds the configuration of the DNS records publishing
+type DNS struct {
+	Target     string      `description:"Address the published records point to: an IP address (A or AAAA records) or a hostname (CNAME records)"`
+	TTL        int         `description:"TTL of the published records, in seconds"`
+	OwnerID    string      `description:"Identifier written in the ownership TXT records. Only the records owned by this identifier are updated or deleted"`
+	Domains    Domains     `description:"Only publish the hosts of these domains, using format: --dns.domains='example.com,example.org'"`
+	Route53    *Route53    `description:"Publish records in an AWS Route53 hosted zone"`
+	Cloudflare *Cloudflare `description:"Publish records in Cloudflare"`
+	RFC2136    *RFC2136    `description:"Publish records with RFC2136 dynamic updates"`
+}
+
+// Domains holds the domains the records are published for
+type Domains []string
+
+// Set adds strings elem into the the parser
+// it splits str on , and ;
+func (d *Domains) Set(str string) error {
+	fargs := func(c rune) bool {
+		return c == ',' || c == ';'
+	}
+	*d = append(*d, strings.FieldsFunc(str, fargs)...)
+	return nil
+}
+
+// Get []string
+func (d *Domains) Get() interface{} { return Domains(*d) }
+
+// String return slice in a string
+func (d *Domains) String() string { return fmt.Sprintf("%v", *d) }
+
+// SetValue sets []string into the parser
+func (d *Domains) SetValue(val interface{}) {
+	*d = Domains(val.(Domains))
+}
+
+// Record is a DNS record. Name is not fully qualified, without the trailing dot.
+type Record struct {
+	Name  string
+	Type  string
+	Value string
+	TTL   int
+}
+
+// Provider manages the records of a DNS service
+type Provider interface {
+	// Records returns the A, AAAA, CNAME and TXT records of name
+	Records(name string) ([]Record, error)
+	// Upsert creates record, replacing the records of the same name and type
+	Upsert(record Record) error
+	// Delete deletes the records of name of the given type
+	Delete(name, recordType string) error
+}
+
+// Publisher keeps the DNS records of the frontends hosts in sync, from a
+// single goroutine started by Run.
+type Publisher struct {
+	config    *DNS
+	provider  Provider
+	published map[string]bool
+	hosts     chan []string
+	desired   []string
+}
+
+// NewPublisher checks the DNS configuration and returns a Publisher using the configured provider.
+func NewPublisher(config *DNS) (*Publisher, error) {
+	if len(config.Target) == 0 {
+		return nil, errors.New("DNS target must be set")
+	}
+	if len(config.OwnerID) == 0 {
+		return nil, errors.New("DNS owner ID must be set")
+	}
+	if len(config.Domains) == 0 {
+		return nil, errors.New("DNS domains must list the domains to publish records for")
+	}
+	var providers []Provider
+	if config.Route53 != nil {
+		providers = append(providers, config.Route53)
+	}
+	if config.Cloudflare != nil {
+		providers = append(providers, config.Cloudflare)
+	}
+	if config.RFC2136 != nil {
+		providers = append(providers, config.RFC2136)
+	}
+	if len(providers) != 1 {
+		return nil, errors.New("Exactly one DNS provider must be configured: route53, cloudflare or rfc2136")
+	}
+	return newPublisher(config, providers[0]), nil
+}
+
+func newPublisher(config *DNS, provider Provider) *Publisher {
+	return &Publisher{
+		config:    config,
+		provider:  provider,
+		published: make(map[string]bool),
+		hosts:     make(chan []string, 1),
+	}
+}
+
+// Update sets the hosts the records must be published for. Only the latest
+// hosts are kept while the publisher is busy.
+func (p *Publisher) Update(hosts []string) {
+	select {
+	case <-p.hosts:
+	default:
+	}
+	p.hosts <- hosts
+}
+
+// Run publishes the records of the updated hosts until stop is closed.
+func (p *Publisher) Run(stop chan bool) {
+	ticker := time.NewTicker(resyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case hosts := <-p.hosts:
+			p.desired = hosts
+			p.sync()
+		case <-ticker.C:
+			p.sync()
+		}
+	}
+}
+
+// manages returns true if host belongs to one of the configured domains.
+func (p *Publisher) manages(host string) bool {
+	for _, domain := range p.config.Domains {
+		domain = types.CanonicalDomain(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// sync publishes the records of the desired hosts not published yet, and
+// deletes the records of the published hosts that are not desired anymore.
+func (p *Publisher) sync() {
+	desired := make(map[string]bool)
+	for _, host := range p.desired {
+		host = types.CanonicalDomain(host)
+		if p.manages(host) {
+			desired[host] = true
+		}
+	}
+	for _, host := range sortedKeys(desired) {
+		if p.published[host] {
+			continue
+		}
+		if err := p.publish(host); err != nil {
+			log.Errorf("Error publishing DNS record of %s: %v", host, err)
+			continue
+		}
+		log.Infof("Published DNS record of %s", host)
+		p.published[host] = true
+	}
+	for _, host := range sortedKeys(p.published) {
+		if desired[host] {
+			continue
+		}
+		if err := p.unpublish(host); err != nil {
+			log.Errorf("Error deleting DNS record of %s: %v", host, err)
+			continue
+		}
+		log.Infof("Deleted DNS record of %s", host)
+		delete(p.published, host)
+	}
+}
+
+func (p *Publisher) ownerValue() string {
+	return "heritage=traefik,traefik/owner=" + p.config.OwnerID
+}
+
+// notOwnedError is returned when the records of a host belong to someone else.
+type notOwnedError string
+
+func (e notOwnedError) Error() string {
+	return string(e)
+}
+
+// owned returns true if host is owned by this publisher, and an error if it
+// exists without being owned by it.
+func (p *Publisher) owned(host string) (bool, error) {
+	records, err := p.provider.Records(ownerPrefix + host)
+	if err != nil {
+		return false, err
+	}
+	foreign := false
+	for _, record := range records {
+		if record.Type != "TXT" {
+			continue
+		}
+		if record.Value == p.ownerValue() {
+			return true, nil
+		}
+		if strings.HasPrefix(record.Value, "heritage=traefik,") {
+			foreign = true
+		}
+	}
+	if foreign {
+		return false, notOwnedError(host + " is owned by another traefik instance")
+	}
+	records, err = p.provider.Records(host)
+	if err != nil {
+		return false, err
+	}
+	if len(records) > 0 {
+		return false, notOwnedError(host + " already has records that are not owned by traefik")
+	}
+	return false, nil
+}
+
+func (p *Publisher) targetRecord(host string) Record {
+	record := Record{Name: host, Value: p.config.Target, TTL: p.config.TTL}
+	if ip := net.ParseIP(p.config.Target); ip == nil {
+		record.Type = "CNAME"
+		record.Value = types.CanonicalDomain(p.config.Target)
+	} else if ip.To4() != nil {
+		record.Type = "A"
+	} else {
+		record.Type = "AAAA"
+	}
+	return record
+}
+
+func (p *Publisher) publish(host string) error {
+	owned, err := p.owned(host)
+	if err != nil {
+		return err
+	}
+	target := p.targetRecord(host)
+	if owned {
+		// the target may have changed type since the record was published
+		records, err := p.provider.Records(host)
+		if err != nil {
+			return err
+		}
+		for _, recordType := range recordTypes(records) {
+			if recordType != target.Type {
+				if err := p.provider.Delete(host, recordType); err != nil {
+					return err
+				}
+			}
+		}
+	} else {
+		// claim the host before publishing its record
+		err := p.provider.Upsert(Record{Name: ownerPrefix + host, Type: "TXT", Value: p.ownerValue(), TTL: p.config.TTL})
+		if err != nil {
+			return err
+		}
+	}
+	return p.provider.Upsert(target)
+}
+
+func (p *Publisher) unpublish(host string) error {
+	owned, err := p.owned(host)
+	if _, ok := err.(notOwnedError); ok {
+		// never delete the records of someone else
+		log.Warnf("Not deleting DNS records of %s: %v", host, err)
+		return nil
+	}
+	if err != nil || !owned {
+		return err
+	}
+	records, err := p.provider.Records(host)
+	if err != nil {
+		return err
+	}
+	for _, recordType := range recordTypes(records) {
+		if err := p.provider.Delete(host, recordType); err != nil {
+			return err
+		}
+	}
+	return p.provider.Delete(ownerPrefix+host, "TXT")
+}
+
+func recordTypes(records []Record) []string {
+	seen := make(map[string]bool)
+	for _, record := range records {
+		seen[record.Type] = true
+	}
+	return sortedKeys(seen)
+}
+
+func sortedKeys(keys map[string]bool) []string {
+	sorted := []string{}
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func isManagedType(recordType string) bool {
+	for _, managedType := range managedTypes {
+		if recordType == managedType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package externaldns
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type recordKey struct {
+	name       string
+	recordType string
+}
+
+type fakeProvider struct {
+	records map[recordKey]Record
+}
+
+func newFakeProvider(records ...Record) *fakeProvider {
+	provider := &fakeProvider{records: make(map[recordKey]Record)}
+	for _, record := range records {
+		provider.records[recordKey{record.Name, record.Type}] = record
+	}
+	return provider
+}
+
+func (f *fakeProvider) Records(name string) ([]Record, error) {
+	records := []Record{}
+	for key, record := range f.records {
+		if key.name == name {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (f *fakeProvider) Upsert(record Record) error {
+	f.records[recordKey{record.Name, record.Type}] = record
+	return nil
+}
+
+func (f *fakeProvider) Delete(name, recordType string) error {
+	delete(f.records, recordKey{name, recordType})
+	return nil
+}
+
+func (f *fakeProvider) names() []string {
+	names := []string{}
+	for key := range f.records {
+		names = append(names, key.recordType+" "+key.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestPublisherSync(t *testing.T) {
+	provider := newFakeProvider(
+		Record{Name: "manual.example.com", Type: "A", Value: "192.0.2.1", TTL: 60},
+		Record{Name: "other.example.com", Type: "CNAME", Value: "lb.example.net", TTL: 60},
+		Record{Name: ownerPrefix + "other.example.com", Type: "TXT", Value: "heritage=traefik,traefik/owner=node2", TTL: 60},
+	)
+	publisher := newPublisher(&DNS{
+		Target:  "203.0.113.10",
+		TTL:     300,
+		OwnerID: "node1",
+		Domains: Domains{"example.com"},
+	}, provider)
+
+	publisher.desired = []string{"foo.example.com", "Bar.Example.com", "manual.example.com", "other.example.com", "foo.example.org"}
+	publisher.sync()
+	expected := []string{
+		"A bar.example.com",
+		"A foo.example.com",
+		"A manual.example.com",
+		"CNAME other.example.com",
+		"TXT " + ownerPrefix + "bar.example.com",
+		"TXT " + ownerPrefix + "foo.example.com",
+		"TXT " + ownerPrefix + "other.example.com",
+	}
+	if !reflect.DeepEqual(provider.names(), expected) {
+		t.Fatalf("expected records %v, got %v", expected, provider.names())
+	}
+	if provider.records[recordKey{"manual.example.com", "A"}].Value != "192.0.2.1" {
+		t.Fatal("a record not owned by traefik was overwritten")
+	}
+	expectedPublished := map[string]bool{"bar.example.com": true, "foo.example.com": true}
+	if !reflect.DeepEqual(publisher.published, expectedPublished) {
+		t.Fatalf("expected published hosts %v, got %v", expectedPublished, publisher.published)
+	}
+
+	publisher.desired = []string{"bar.example.com"}
+	publisher.sync()
+	expected = []string{
+		"A bar.example.com",
+		"A manual.example.com",
+		"CNAME other.example.com",
+		"TXT " + ownerPrefix + "bar.example.com",
+		"TXT " + ownerPrefix + "other.example.com",
+	}
+	if !reflect.DeepEqual(provider.names(), expected) {
+		t.Fatalf("expected records %v, got %v", expected, provider.names())
+	}
+}
+
+func TestPublisherTargetChange(t *testing.T) {
+	provider := newFakeProvider(
+		Record{Name: "foo.example.com", Type: "A", Value: "203.0.113.10", TTL: 300},
+		Record{Name: ownerPrefix + "foo.example.com", Type: "TXT", Value: "heritage=traefik,traefik/owner=node1", TTL: 300},
+	)
+	publisher := newPublisher(&DNS{
+		Target:  "lb.example.net",
+		TTL:     300,
+		OwnerID: "node1",
+		Domains: Domains{"example.com"},
+	}, provider)
+
+	publisher.desired = []string{"foo.example.com"}
+	publisher.sync()
+	expected := []string{"CNAME foo.example.com", "TXT " + ownerPrefix + "foo.example.com"}
+	if !reflect.DeepEqual(provider.names(), expected) {
+		t.Fatalf("expected records %v, got %v", expected, provider.names())
+	}
+	if value := provider.records[recordKey{"foo.example.com", "CNAME"}].Value; value != "lb.example.net" {
+		t.Fatalf("expected CNAME to lb.example.net, got %s", value)
+	}
+}
+
+func TestNewPublisher(t *testing.T) {
+	cases := []struct {
+		config  *DNS
+		invalid bool
+	}{
+		{config: &DNS{Target: "203.0.113.10", OwnerID: "node1", Domains: Domains{"example.com"}, RFC2136: &RFC2136{}}},
+		{config: &DNS{OwnerID: "node1", Domains: Domains{"example.com"}, RFC2136: &RFC2136{}}, invalid: true},
+		{config: &DNS{Target: "203.0.113.10", Domains: Domains{"example.com"}, RFC2136: &RFC2136{}}, invalid: true},
+		{config: &DNS{Target: "203.0.113.10", OwnerID: "node1", RFC2136: &RFC2136{}}, invalid: true},
+		{config: &DNS{Target: "203.0.113.10", OwnerID: "node1", Domains: Domains{"example.com"}}, invalid: true},
+		{config: &DNS{Target: "203.0.113.10", OwnerID: "node1", Domains: Domains{"example.com"}, RFC2136: &RFC2136{}, Cloudflare: &Cloudflare{}}, invalid: true},
+	}
+	for i, c := range cases {
+		_, err := NewPublisher(c.config)
+		if c.invalid != (err != nil) {
+			t.Errorf("case %d: expected invalid %t, got error %v", i, c.invalid, err)
+		}
+	}
+}
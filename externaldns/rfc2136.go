@@ -0,0 +1,141 @@
+package externaldns
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// RFC2136 publishes records with dynamic updates sent to an authoritative nameserver
+type RFC2136 struct {
+	Nameserver    string `description:"Nameserver accepting the dynamic updates, host:port"`
+	Zone          string `description:"Zone the records are published in"`
+	TSIGKey       string `description:"Name of the TSIG key signing the updates"`
+	TSIGSecret    string `description:"Base64 secret of the TSIG key. Can be set with the RFC2136_TSIG_SECRET environment variable"`
+	TSIGAlgorithm string `description:"Algorithm of the TSIG key: hmac-md5, hmac-sha1, hmac-sha256 or hmac-sha512"`
+}
+
+func (r *RFC2136) client() *dns.Client {
+	client := &dns.Client{Timeout: 10 * time.Second}
+	if secret := r.tsigSecret(); len(r.TSIGKey) > 0 && len(secret) > 0 {
+		client.TsigSecret = map[string]string{dns.Fqdn(r.TSIGKey): secret}
+	}
+	return client
+}
+
+func (r *RFC2136) tsigSecret() string {
+	if len(r.TSIGSecret) > 0 {
+		return r.TSIGSecret
+	}
+	return os.Getenv("RFC2136_TSIG_SECRET")
+}
+
+func (r *RFC2136) tsigAlgorithm() string {
+	switch strings.ToLower(r.TSIGAlgorithm) {
+	case "hmac-md5":
+		return dns.HmacMD5
+	case "hmac-sha1":
+		return dns.HmacSHA1
+	case "hmac-sha512":
+		return dns.HmacSHA512
+	default:
+		return dns.HmacSHA256
+	}
+}
+
+func (r *RFC2136) exchange(m *dns.Msg) (*dns.Msg, error) {
+	if len(r.Nameserver) == 0 {
+		return nil, errors.New("RFC2136 nameserver must be set")
+	}
+	client := r.client()
+	if client.TsigSecret != nil {
+		m.SetTsig(dns.Fqdn(r.TSIGKey), r.tsigAlgorithm(), 300, time.Now().Unix())
+	}
+	reply, _, err := client.Exchange(m, r.Nameserver)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Rcode != dns.RcodeSuccess && reply.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("nameserver %s answered %s", r.Nameserver, dns.RcodeToString[reply.Rcode])
+	}
+	return reply, nil
+}
+
+// Records queries the nameserver for the managed records of name
+func (r *RFC2136) Records(name string) ([]Record, error) {
+	records := []Record{}
+	for _, recordType := range managedTypes {
+		m := new(dns.Msg)
+		m.SetQuestion(dns.Fqdn(name), dns.StringToType[recordType])
+		m.RecursionDesired = false
+		reply, err := r.exchange(m)
+		if err != nil {
+			return nil, err
+		}
+		for _, rr := range reply.Answer {
+			header := rr.Header()
+			if !strings.EqualFold(header.Name, dns.Fqdn(name)) || dns.TypeToString[header.Rrtype] != recordType {
+				continue
+			}
+			record := Record{Name: name, Type: recordType, TTL: int(header.Ttl)}
+			switch rr := rr.(type) {
+			case *dns.A:
+				record.Value = rr.A.String()
+			case *dns.AAAA:
+				record.Value = rr.AAAA.String()
+			case *dns.CNAME:
+				record.Value = strings.TrimSuffix(rr.Target, ".")
+			case *dns.TXT:
+				record.Value = strings.Join(rr.Txt, "")
+			}
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// update removes the records of name of the given type, and inserts insert if it is not nil.
+func (r *RFC2136) update(name, recordType string, insert dns.RR) error {
+	if len(r.Zone) == 0 {
+		return errors.New("RFC2136 zone must be set")
+	}
+	m := new(dns.Msg)
+	m.SetUpdate(dns.Fqdn(r.Zone))
+	m.RemoveRRset([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: dns.Fqdn(name), Rrtype: dns.StringToType[recordType], Class: dns.ClassINET}}})
+	if insert != nil {
+		m.Insert([]dns.RR{insert})
+	}
+	reply, err := r.exchange(m)
+	if err != nil {
+		return err
+	}
+	if reply.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("nameserver %s refused the update: %s", r.Nameserver, dns.RcodeToString[reply.Rcode])
+	}
+	return nil
+}
+
+// Upsert replaces the records of the name and type of record in a single update
+func (r *RFC2136) Upsert(record Record) error {
+	value := record.Value
+	switch record.Type {
+	case "CNAME":
+		value = dns.Fqdn(value)
+	case "TXT":
+		value = `"` + value + `"`
+	}
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", dns.Fqdn(record.Name), record.TTL, record.Type, value))
+	if err != nil {
+		return err
+	}
+	return r.update(record.Name, record.Type, rr)
+}
+
+// Delete removes the records of name of the given type
+func (r *RFC2136) Delete(name, recordType string) error {
+	return r.update(name, recordType, nil)
+}
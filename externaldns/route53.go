@@ -0,0 +1,205 @@
+package externaldns
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/aws"
+)
+
+const (
+	route53APIURL = "https://route53.amazonaws.com/2013-04-01"
+	// route53Region is the region signing the requests to the global Route53 endpoint
+	route53Region = "us-east-1"
+	route53XMLNS  = "https://route53.amazonaws.com/doc/2013-04-01/"
+)
+
+// Route53 publishes records in an AWS Route53 hosted zone
+type Route53 struct {
+	HostedZoneID    string `description:"ID of the hosted zone the records are published in"`
+	AccessKeyID     string `description:"AWS access key ID. Defaults to the AWS_ACCESS_KEY_ID environment variable, or the instance role"`
+	SecretAccessKey string `description:"AWS secret access key. Defaults to the AWS_SECRET_ACCESS_KEY environment variable, or the instance role"`
+	apiURL          string
+	now             func() time.Time
+}
+
+type route53ResourceRecordSet struct {
+	Name            string   `xml:"Name"`
+	Type            string   `xml:"Type"`
+	TTL             int      `xml:"TTL,omitempty"`
+	ResourceRecords []string `xml:"ResourceRecords>ResourceRecord>Value"`
+}
+
+type route53Change struct {
+	Action            string                   `xml:"Action"`
+	ResourceRecordSet route53ResourceRecordSet `xml:"ResourceRecordSet"`
+}
+
+type route53ChangeRequest struct {
+	XMLName xml.Name        `xml:"ChangeResourceRecordSetsRequest"`
+	XMLNS   string          `xml:"xmlns,attr"`
+	Changes []route53Change `xml:"ChangeBatch>Changes>Change"`
+}
+
+type route53ListResponse struct {
+	ResourceRecordSets []route53ResourceRecordSet `xml:"ResourceRecordSets>ResourceRecordSet"`
+}
+
+type route53ErrorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+func (r *Route53) do(method, path string, query url.Values, body interface{}, result interface{}) error {
+	if len(r.HostedZoneID) == 0 {
+		return errors.New("Route53 hosted zone ID must be set")
+	}
+	credentials, err := aws.GetCredentials(r.AccessKeyID, r.SecretAccessKey)
+	if err != nil {
+		return err
+	}
+	apiURL := r.apiURL
+	if len(apiURL) == 0 {
+		apiURL = route53APIURL
+	}
+	now := time.Now
+	if r.now != nil {
+		now = r.now
+	}
+
+	var content []byte
+	if body != nil {
+		if content, err = xml.Marshal(body); err != nil {
+			return err
+		}
+		content = append([]byte(xml.Header), content...)
+	}
+	requestURL := apiURL + "/hostedzone/" + strings.TrimPrefix(r.HostedZoneID, "/hostedzone/") + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+	request, err := http.NewRequest(method, requestURL, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "text/xml")
+	}
+	aws.SignRequest(request, content, credentials, route53Region, "route53", now().UTC())
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		errorResponse := &route53ErrorResponse{}
+		if err := xml.Unmarshal(data, errorResponse); err != nil || len(errorResponse.Code) == 0 {
+			return fmt.Errorf("Route53 answered %s", response.Status)
+		}
+		return fmt.Errorf("Route53 answered %s: %s: %s", response.Status, errorResponse.Code, errorResponse.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return xml.Unmarshal(data, result)
+}
+
+// recordSets returns the record sets of name, of recordType if it is not empty.
+func (r *Route53) recordSets(name, recordType string) ([]route53ResourceRecordSet, error) {
+	query := url.Values{}
+	query.Set("name", name+".")
+	if len(recordType) > 0 {
+		query.Set("type", recordType)
+	}
+	query.Set("maxitems", "10")
+	response := &route53ListResponse{}
+	if err := r.do("GET", "/rrset", query, nil, response); err != nil {
+		return nil, err
+	}
+	// the list starts at name, keep the record sets of name only
+	recordSets := []route53ResourceRecordSet{}
+	for _, recordSet := range response.ResourceRecordSets {
+		if strings.EqualFold(strings.TrimSuffix(recordSet.Name, "."), name) && (len(recordType) == 0 || recordSet.Type == recordType) {
+			recordSets = append(recordSets, recordSet)
+		}
+	}
+	return recordSets, nil
+}
+
+func (r *Route53) change(changes ...route53Change) error {
+	changeRequest := &route53ChangeRequest{XMLNS: route53XMLNS, Changes: changes}
+	return r.do("POST", "/rrset", nil, changeRequest, nil)
+}
+
+// Records lists the managed records of name
+func (r *Route53) Records(name string) ([]Record, error) {
+	recordSets, err := r.recordSets(name, "")
+	if err != nil {
+		return nil, err
+	}
+	records := []Record{}
+	for _, recordSet := range recordSets {
+		if !isManagedType(recordSet.Type) {
+			continue
+		}
+		for _, value := range recordSet.ResourceRecords {
+			record := Record{Name: name, Type: recordSet.Type, Value: value, TTL: recordSet.TTL}
+			switch recordSet.Type {
+			case "CNAME":
+				record.Value = strings.TrimSuffix(value, ".")
+			case "TXT":
+				record.Value = strings.Trim(value, `"`)
+			}
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// Upsert replaces the record set of the name and type of record
+func (r *Route53) Upsert(record Record) error {
+	value := record.Value
+	if record.Type == "TXT" {
+		value = `"` + value + `"`
+	}
+	ttl := record.TTL
+	if ttl <= 0 {
+		ttl = 300
+	}
+	return r.change(route53Change{
+		Action: "UPSERT",
+		ResourceRecordSet: route53ResourceRecordSet{
+			Name:            record.Name + ".",
+			Type:            record.Type,
+			TTL:             ttl,
+			ResourceRecords: []string{value},
+		},
+	})
+}
+
+// Delete deletes the record set of name of the given type. Route53 only
+// deletes record sets matching exactly, they are read first.
+func (r *Route53) Delete(name, recordType string) error {
+	recordSets, err := r.recordSets(name, recordType)
+	if err != nil || len(recordSets) == 0 {
+		return err
+	}
+	changes := []route53Change{}
+	for _, recordSet := range recordSets {
+		changes = append(changes, route53Change{Action: "DELETE", ResourceRecordSet: recordSet})
+	}
+	return r.change(changes...)
+}
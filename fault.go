@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/types"
+)
+
+// FaultInjector injects the faults configured on the frontends, unless it is
+// switched off through the API.
+type FaultInjector struct {
+	disabled int32
+}
+
+// FaultInjectionStatus is the state of the fault injection switch.
+type FaultInjectionStatus struct {
+	Enabled bool `json:"enabled"`
+}
+
+// NewFaultInjector returns an enabled FaultInjector.
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{}
+}
+
+// Enabled returns true if the faults are injected.
+func (f *FaultInjector) Enabled() bool {
+	return atomic.LoadInt32(&f.disabled) == 0
+}
+
+// SetEnabled switches the fault injection of all the frontends on or off.
+func (f *FaultInjector) SetEnabled(enabled bool) {
+	var disabled int32
+	if !enabled {
+		disabled = 1
+	}
+	if atomic.SwapInt32(&f.disabled, disabled) != disabled {
+		log.Infof("Fault injection enabled: %t", enabled)
+	}
+}
+
+// validateFault checks the percentages and the abort status of fault.
+func validateFault(fault *types.Fault) error {
+	for name, percent := range map[string]float64{"delay": fault.DelayPercent, "abort": fault.AbortPercent, "drop": fault.DropPercent} {
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("invalid %s percentage %v, expected a number between 0 and 100", name, percent)
+		}
+	}
+	if fault.Delay < 0 {
+		return fmt.Errorf("invalid negative delay %d", fault.Delay)
+	}
+	if fault.AbortStatus != 0 && (fault.AbortStatus < 400 || fault.AbortStatus > 599) {
+		return fmt.Errorf("invalid abort status %d, expected a 4XX or 5XX status code", fault.AbortStatus)
+	}
+	return nil
+}
+
+// Handler returns a handler injecting fault in the requests served by next.
+func (f *FaultInjector) Handler(fault *types.Fault, next http.Handler) http.Handler {
+	config := *fault
+	if config.AbortStatus == 0 {
+		config.AbortStatus = http.StatusServiceUnavailable
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !f.Enabled() {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		if injectFault(config.DropPercent) {
+			if hijacker, ok := rw.(http.Hijacker); ok {
+				if conn, _, err := hijacker.Hijack(); err == nil {
+					middlewares.SetErrorReason(r, middlewares.ReasonFaultInjected)
+					conn.Close()
+					return
+				}
+			}
+			// the connection can't be dropped, abort the request instead
+			abortRequest(rw, r, config.AbortStatus)
+			return
+		}
+		if config.Delay > 0 && injectFault(config.DelayPercent) {
+			select {
+			case <-time.After(time.Duration(config.Delay) * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if injectFault(config.AbortPercent) {
+			abortRequest(rw, r, config.AbortStatus)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func injectFault(percent float64) bool {
+	return percent > 0 && rand.Float64()*100 < percent
+}
+
+func abortRequest(rw http.ResponseWriter, r *http.Request, status int) {
+	middlewares.SetErrorReason(r, middlewares.ReasonFaultInjected)
+	rw.WriteHeader(status)
+	rw.Write([]byte(http.StatusText(status)))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var faultBackend = http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+	rw.Write([]byte("OK"))
+})
+
+func TestFaultInjectorAbort(t *testing.T) {
+	injector := NewFaultInjector()
+	handler := injector.Handler(&types.Fault{AbortStatus: http.StatusBadGateway, AbortPercent: 100}, faultBackend)
+
+	request := middlewares.WithRequestInfo(httptest.NewRequest("GET", "/", nil))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusBadGateway, recorder.Code)
+	assert.Equal(t, middlewares.ReasonFaultInjected, middlewares.GetErrorReason(request))
+
+	// the off switch passes all the requests to the backends
+	injector.SetEnabled(false)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.False(t, injector.Enabled())
+}
+
+func TestFaultInjectorDelay(t *testing.T) {
+	handler := NewFaultInjector().Handler(&types.Fault{Delay: 50, DelayPercent: 100}, faultBackend)
+	start := time.Now()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+}
+
+func TestFaultInjectorDrop(t *testing.T) {
+	server := httptest.NewServer(NewFaultInjector().Handler(&types.Fault{DropPercent: 100}, faultBackend))
+	defer server.Close()
+	_, err := http.Get(server.URL)
+	assert.Error(t, err)
+
+	// without a connection to drop, the request is aborted
+	recorder := httptest.NewRecorder()
+	NewFaultInjector().Handler(&types.Fault{DropPercent: 100}, faultBackend).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
+
+func TestFaultsHandlers(t *testing.T) {
+	defer faultInjector.SetEnabled(true)
+	provider := &WebProvider{ReadOnly: true}
+
+	recorder := httptest.NewRecorder()
+	provider.putFaultsHandler(recorder, httptest.NewRequest("PUT", "/api/faults", strings.NewReader(`{"enabled": false}`)))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"enabled": false}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	provider.putFaultsHandler(recorder, httptest.NewRequest("PUT", "/api/faults", strings.NewReader(`{"enabled": true}`)))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.False(t, faultInjector.Enabled())
+}
+
+func TestValidateFault(t *testing.T) {
+	assert.NoError(t, validateFault(&types.Fault{Delay: 100, DelayPercent: 50, AbortStatus: 500, AbortPercent: 1}))
+	assert.Error(t, validateFault(&types.Fault{AbortPercent: 101}))
+	assert.Error(t, validateFault(&types.Fault{Delay: -1}))
+	assert.Error(t, validateFault(&types.Fault{AbortStatus: 200, AbortPercent: 10}))
+}
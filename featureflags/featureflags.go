@@ -0,0 +1,142 @@
+package featureflags
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NYTimes/gziphandler"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares"
+)
+
+const (
+	// Maintenance answers the requests of the frontend with 503 Service Unavailable
+	Maintenance = "maintenance"
+	// Compress compresses the responses of the frontend
+	Compress = "compress"
+	// ConfigurationFreeze holds back the dynamic configuration updates, it is never set per frontend
+	ConfigurationFreeze = "configurationFreeze"
+	// maintenanceRetryAfter is the Retry-After header of the maintenance responses, in seconds
+	maintenanceRetryAfter = 60
+)
+
+// Config holds the configuration of the feature flags toggling middlewares per frontend
+type Config struct {
+	File         string `description:"JSON or TOML file holding the flags"`
+	KV           *KV    `description:"Key of a KV store holding the flags, as JSON"`
+	Relay        *Relay `description:"LaunchDarkly-compatible relay serving the flags"`
+	PollInterval int64  `description:"Interval in seconds between two loads of the flags"`
+}
+
+// source loads the values of the flags.
+type source interface {
+	load() (map[string]bool, error)
+}
+
+// Flags holds the flags loaded from the configured source. A flag named after
+// a toggle applies to every frontend, a flag named toggle.frontend to a
+// single one and overrides the former.
+type Flags struct {
+	source source
+	lock   sync.RWMutex
+	flags  map[string]bool
+}
+
+// New returns the Flags of the configured source. No flag is enabled until Refresh is called.
+func New(config *Config) (*Flags, error) {
+	sources := []source{}
+	if len(config.File) > 0 {
+		sources = append(sources, &fileSource{filename: config.File})
+	}
+	if config.KV != nil {
+		sources = append(sources, config.KV)
+	}
+	if config.Relay != nil {
+		sources = append(sources, config.Relay)
+	}
+	if len(sources) != 1 {
+		return nil, errors.New("Exactly one feature flags source must be configured: file, kv or relay")
+	}
+	return newFlags(sources[0]), nil
+}
+
+func newFlags(source source) *Flags {
+	return &Flags{source: source, flags: make(map[string]bool)}
+}
+
+// Refresh loads the flags from the source, the previous flags are kept on failures.
+func (f *Flags) Refresh() error {
+	flags, err := f.source.load()
+	if err != nil {
+		return err
+	}
+	f.lock.Lock()
+	previous := f.flags
+	f.flags = flags
+	f.lock.Unlock()
+
+	names := []string{}
+	for name := range flags {
+		names = append(names, name)
+	}
+	for name := range previous {
+		if _, ok := flags[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if previous[name] != flags[name] {
+			log.Infof("Feature flag %s is now %t", name, flags[name])
+		}
+	}
+	return nil
+}
+
+// Run refreshes the flags now, then every interval until stop is closed.
+func (f *Flags) Run(stop chan bool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := f.Refresh(); err != nil {
+			log.Errorf("Error loading feature flags, keeping the previous ones: %v", err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Enabled returns true if toggle is enabled for frontendName.
+func (f *Flags) Enabled(toggle, frontendName string) bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	if enabled, ok := f.flags[toggle+"."+frontendName]; ok {
+		return enabled
+	}
+	return f.flags[toggle]
+}
+
+// Handler returns a handler applying the middlewares toggled for frontendName in front of next.
+func (f *Flags) Handler(frontendName string, next http.Handler) http.Handler {
+	compressed := gziphandler.GzipHandler(next)
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if f.Enabled(Maintenance, frontendName) {
+			middlewares.SetErrorReason(r, middlewares.ReasonMaintenance)
+			rw.Header().Set("Retry-After", strconv.Itoa(maintenanceRetryAfter))
+			http.Error(rw, "Service under maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		if f.Enabled(Compress, frontendName) {
+			compressed.ServeHTTP(rw, r)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,147 @@
+package featureflags
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeSource struct {
+	flags map[string]bool
+	err   error
+}
+
+func (s *fakeSource) load() (map[string]bool, error) {
+	return s.flags, s.err
+}
+
+func TestEnabled(t *testing.T) {
+	source := &fakeSource{flags: map[string]bool{"maintenance": true, "maintenance.frontend2": false, "compress.frontend1": true}}
+	flags := newFlags(source)
+	if flags.Enabled(Maintenance, "frontend1") {
+		t.Fatal("expected no flag before the first refresh")
+	}
+	if err := flags.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		toggle   string
+		frontend string
+		expected bool
+	}{
+		{Maintenance, "frontend1", true},
+		{Maintenance, "frontend2", false},
+		{Compress, "frontend1", true},
+		{Compress, "frontend2", false},
+	}
+	for _, c := range cases {
+		if enabled := flags.Enabled(c.toggle, c.frontend); enabled != c.expected {
+			t.Errorf("expected %s for %s to be %t, got %t", c.toggle, c.frontend, c.expected, enabled)
+		}
+	}
+
+	source.err = errors.New("unavailable")
+	if err := flags.Refresh(); err == nil {
+		t.Fatal("expected the source error")
+	}
+	if !flags.Enabled(Maintenance, "frontend1") {
+		t.Fatal("expected the previous flags to be kept")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	flags := newFlags(&fakeSource{flags: map[string]bool{"maintenance.frontend1": true, "compress.frontend2": true}})
+	if err := flags.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	next := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("ok"))
+	})
+
+	recorder := httptest.NewRecorder()
+	flags.Handler("frontend1", next).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected a maintenance response, got %d %v", recorder.Code, recorder.Header())
+	}
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	recorder = httptest.NewRecorder()
+	flags.Handler("frontend2", next).ServeHTTP(recorder, request)
+	if recorder.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected a gzip response, got %v", recorder.Header())
+	}
+
+	recorder = httptest.NewRecorder()
+	flags.Handler("frontend3", next).ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "ok" {
+		t.Fatalf("expected the untouched response, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "featureflags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"flags.json": `{"maintenance": true}`,
+		"flags.toml": `maintenance = true`,
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		flags, err := (&fileSource{filename: filename}).load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !flags["maintenance"] {
+			t.Errorf("expected maintenance to be enabled by %s", name)
+		}
+	}
+}
+
+func TestRelay(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sdk/latest-flags" || r.Header.Get("Authorization") != "sdk-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{
+  "maintenance": {"on": true, "offVariation": 1, "fallthrough": {"variation": 0}, "variations": [true, false]},
+  "compress": {"on": false, "offVariation": 1, "fallthrough": {"variation": 0}, "variations": [true, false]},
+  "color": {"on": true, "fallthrough": {"variation": 0}, "variations": ["blue"]}
+}`))
+	}))
+	defer ts.Close()
+
+	flags, err := (&Relay{URL: ts.URL + "/", SDKKey: "sdk-key"}).load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != 2 || !flags["maintenance"] || flags["compress"] {
+		t.Fatalf("unexpected flags %v", flags)
+	}
+	if _, err := (&Relay{URL: ts.URL, SDKKey: "wrong"}).load(); err == nil {
+		t.Fatal("expected an error with a wrong SDK key")
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(&Config{}); err == nil {
+		t.Fatal("expected an error without source")
+	}
+	if _, err := New(&Config{File: "flags.json", Relay: &Relay{}}); err == nil {
+		t.Fatal("expected an error with two sources")
+	}
+	if _, err := New(&Config{File: "flags.json"}); err != nil {
+		t.Fatal(err)
+	}
+}
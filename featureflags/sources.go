@@ -0,0 +1,128 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/docker/libkv"
+	"github.com/docker/libkv/store"
+	"github.com/docker/libkv/store/boltdb"
+	"github.com/docker/libkv/store/consul"
+	"github.com/docker/libkv/store/etcd"
+	"github.com/docker/libkv/store/zookeeper"
+)
+
+func init() {
+	boltdb.Register()
+	consul.Register()
+	etcd.Register()
+	zookeeper.Register()
+}
+
+// fileSource loads the flags from a JSON or TOML file, mapping the flag names to booleans.
+type fileSource struct {
+	filename string
+}
+
+func (s *fileSource) load() (map[string]bool, error) {
+	data, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	flags := make(map[string]bool)
+	if strings.ToLower(filepath.Ext(s.filename)) == ".toml" {
+		err = toml.Unmarshal(data, &flags)
+	} else {
+		err = json.Unmarshal(data, &flags)
+	}
+	return flags, err
+}
+
+// KV loads the flags from the JSON value of a key of a KV store
+type KV struct {
+	Backend  string `description:"KV store: consul, etcd, zookeeper or boltdb"`
+	Endpoint string `description:"Comma separated server endpoints"`
+	Key      string `description:"Key holding the flags"`
+	kvclient store.Store
+}
+
+func (k *KV) load() (map[string]bool, error) {
+	if k.kvclient == nil {
+		kvclient, err := libkv.NewStore(
+			store.Backend(k.Backend),
+			strings.Split(k.Endpoint, ","),
+			&store.Config{ConnectionTimeout: 30 * time.Second, Bucket: "traefik"},
+		)
+		if err != nil {
+			return nil, err
+		}
+		k.kvclient = kvclient
+	}
+	pair, err := k.kvclient.Get(k.Key)
+	if err == store.ErrKeyNotFound {
+		return map[string]bool{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	flags := make(map[string]bool)
+	return flags, json.Unmarshal(pair.Value, &flags)
+}
+
+// Relay loads the flags from a LaunchDarkly-compatible relay. Targeting rules
+// are not evaluated: a flag takes its fallthrough variation when it is on, and
+// its off variation otherwise. Only the boolean variations are kept.
+type Relay struct {
+	URL    string `description:"URL of the relay"`
+	SDKKey string `description:"SDK key of the environment"`
+}
+
+type relayFlag struct {
+	On           bool          `json:"on"`
+	OffVariation *int          `json:"offVariation"`
+	Variations   []interface{} `json:"variations"`
+	Fallthrough  struct {
+		Variation *int `json:"variation"`
+	} `json:"fallthrough"`
+}
+
+func (r *Relay) load() (map[string]bool, error) {
+	request, err := http.NewRequest("GET", strings.TrimSuffix(r.URL, "/")+"/sdk/latest-flags", nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", r.SDKKey)
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Relay answered %s", response.Status)
+	}
+	relayFlags := make(map[string]*relayFlag)
+	if err := json.NewDecoder(response.Body).Decode(&relayFlags); err != nil {
+		return nil, err
+	}
+	flags := make(map[string]bool)
+	for name, flag := range relayFlags {
+		variation := flag.OffVariation
+		if flag.On {
+			variation = flag.Fallthrough.Variation
+		}
+		if variation == nil || *variation < 0 || *variation >= len(flag.Variations) {
+			continue
+		}
+		if value, ok := flag.Variations[*variation].(bool); ok {
+			flags[name] = value
+		}
+	}
+	return flags, nil
+}
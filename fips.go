@@ -0,0 +1,235 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/containous/traefik/log"
+)
+
+// fipsBuild is set by the builds with the fips tag, linked with BoringCrypto.
+var fipsBuild bool
+
+// fipsCipherSuites are the FIPS-approved cipher suites, the AES-GCM ones.
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// fipsCurves are the FIPS-approved curves, the NIST ones.
+var fipsCurves = []tls.CurveID{tls.CurveP256, tls.CurveP384, tls.CurveP521}
+
+// defaultCurvesWithX25519 is whether the default curves of crypto/tls include
+// X25519, which is not approved: the defaults of Go 1.7 are the NIST curves,
+// X25519 came with Go 1.8.
+var defaultCurvesWithX25519 = goVersionAtLeast(runtime.Version(), 1, 8)
+
+// FIPSStatus is the FIPS compliance of the TLS entrypoints
+type FIPSStatus struct {
+	Enabled     bool                       `json:"enabled"`
+	Build       bool                       `json:"build"`
+	Compliant   bool                       `json:"compliant"`
+	EntryPoints map[string]*FIPSEntryPoint `json:"entryPoints"`
+}
+
+// FIPSEntryPoint is the FIPS compliance of the TLS parameters of an entrypoint
+type FIPSEntryPoint struct {
+	Compliant    bool     `json:"compliant"`
+	MinVersion   string   `json:"minVersion"`
+	MaxVersion   string   `json:"maxVersion"`
+	CipherSuites []string `json:"cipherSuites"`
+	Violations   []string `json:"violations,omitempty"`
+}
+
+// fipsEnabled returns whether the TLS parameters are restricted to the
+// FIPS-approved ones, by the configuration or by the build.
+func (server *Server) fipsEnabled() bool {
+	return fipsBuild || server.globalConfiguration.FIPS
+}
+
+// enforceFIPS restricts config to TLS 1.2, with the FIPS-approved cipher
+// suites and curves, whatever the TLS options of the entrypoint. The
+// configured cipher suites which are approved are kept, in their order.
+func enforceFIPS(config *tls.Config) {
+	config.MinVersion = tls.VersionTLS12
+	config.MaxVersion = tls.VersionTLS12
+	config.PreferServerCipherSuites = true
+	var cipherSuites []uint16
+	for _, cipherSuite := range config.CipherSuites {
+		if fipsCipherSuite(cipherSuite) {
+			cipherSuites = append(cipherSuites, cipherSuite)
+		}
+	}
+	if len(cipherSuites) == 0 {
+		cipherSuites = append(cipherSuites, fipsCipherSuites...)
+	}
+	config.CipherSuites = cipherSuites
+	config.CurvePreferences = fipsCurves
+}
+
+func fipsCipherSuite(cipherSuite uint16) bool {
+	for _, approved := range fipsCipherSuites {
+		if cipherSuite == approved {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFIPS returns the parameters of config which aren't FIPS-approved.
+func checkFIPS(config *tls.Config) []string {
+	var violations []string
+	if config.MinVersion < tls.VersionTLS12 {
+		violations = append(violations, "versions older than TLS 1.2 are accepted")
+	}
+	if config.MaxVersion == 0 || config.MaxVersion > tls.VersionTLS12 {
+		violations = append(violations, "TLS 1.3 is accepted, its cipher suites can't be restricted")
+	}
+	if len(config.CipherSuites) == 0 {
+		violations = append(violations, "the default cipher suites are accepted")
+	}
+	for _, cipherSuite := range config.CipherSuites {
+		if !fipsCipherSuite(cipherSuite) {
+			violations = append(violations, "cipher suite "+cipherSuiteName(cipherSuite)+" is not approved")
+		}
+	}
+	if len(config.CurvePreferences) == 0 && defaultCurvesWithX25519 {
+		violations = append(violations, "the default curves, including X25519, are accepted")
+	}
+	for _, curve := range config.CurvePreferences {
+		if curve != tls.CurveP256 && curve != tls.CurveP384 && curve != tls.CurveP521 {
+			violations = append(violations, fmt.Sprintf("curve %d is not approved", curve))
+		}
+	}
+	for _, certificate := range config.Certificates {
+		if violation := checkFIPSKey(certificate); len(violation) > 0 {
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+// checkFIPSKey returns why the key of certificate isn't FIPS-approved, if
+// it isn't: RSA keys must have 2048 bits at least, ECDSA keys an approved
+// curve.
+func checkFIPSKey(certificate tls.Certificate) string {
+	leaf, err := parseLeaf(certificate)
+	if err != nil {
+		return ""
+	}
+	switch key := leaf.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < 2048 {
+			return fmt.Sprintf("the RSA key of certificate %s has %d bits, less than 2048", leaf.Subject.CommonName, key.N.BitLen())
+		}
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() && key.Curve != elliptic.P384() && key.Curve != elliptic.P521() {
+			return "the ECDSA key of certificate " + leaf.Subject.CommonName + " has a curve not approved"
+		}
+	default:
+		return "the key of certificate " + leaf.Subject.CommonName + " is not approved"
+	}
+	return ""
+}
+
+// getFIPSStatus returns the FIPS compliance of the TLS entrypoints.
+func (server *Server) getFIPSStatus() *FIPSStatus {
+	status := &FIPSStatus{
+		Enabled:     server.fipsEnabled(),
+		Build:       fipsBuild,
+		Compliant:   true,
+		EntryPoints: map[string]*FIPSEntryPoint{},
+	}
+	for entryPointName, serverEntryPoint := range server.serverEntryPoints {
+		httpServer := serverEntryPoint.httpServer
+		if httpServer == nil || httpServer.TLSConfig == nil {
+			continue
+		}
+		config := httpServer.TLSConfig
+		entryPoint := &FIPSEntryPoint{
+			MinVersion:   tlsVersionName(config.MinVersion),
+			MaxVersion:   tlsVersionName(config.MaxVersion),
+			CipherSuites: []string{},
+			Violations:   checkFIPS(config),
+		}
+		for _, cipherSuite := range config.CipherSuites {
+			entryPoint.CipherSuites = append(entryPoint.CipherSuites, cipherSuiteName(cipherSuite))
+		}
+		entryPoint.Compliant = len(entryPoint.Violations) == 0
+		status.Compliant = status.Compliant && entryPoint.Compliant
+		status.EntryPoints[entryPointName] = entryPoint
+	}
+	return status
+}
+
+// logFIPSViolations logs the parameters of the TLS entrypoints which aren't
+// FIPS-approved, when FIPS is enforced.
+func (server *Server) logFIPSViolations() {
+	status := server.getFIPSStatus()
+	var entryPointNames []string
+	for entryPointName := range status.EntryPoints {
+		entryPointNames = append(entryPointNames, entryPointName)
+	}
+	sort.Strings(entryPointNames)
+	for _, entryPointName := range entryPointNames {
+		for _, violation := range status.EntryPoints[entryPointName].Violations {
+			log.Errorf("Entrypoint %s is not FIPS compliant: %s", entryPointName, violation)
+		}
+	}
+}
+
+func tlsVersionName(version uint16) string {
+	if version == 0 {
+		return ""
+	}
+	for name, value := range tlsVersions {
+		if value == version {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+func cipherSuiteName(cipherSuite uint16) string {
+	for name, value := range cipherSuites {
+		if value == cipherSuite {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", cipherSuite)
+}
+
+// goVersionAtLeast returns whether the Go release version, like go1.7.6 or
+// go1.8rc1, is major.minor or later. The development versions are assumed to
+// be recent.
+func goVersionAtLeast(version string, major, minor int) bool {
+	if !strings.HasPrefix(version, "go") {
+		return true
+	}
+	parts := strings.SplitN(strings.TrimPrefix(version, "go"), ".", 3)
+	numbers := make([]int, 2)
+	for i := 0; i < len(parts) && i < 2; i++ {
+		// the leading digits, without the suffixes of the pre-releases
+		digits := strings.IndexFunc(parts[i], func(r rune) bool { return r < '0' || r > '9' })
+		if digits < 0 {
+			digits = len(parts[i])
+		}
+		number, err := strconv.Atoi(parts[i][:digits])
+		if err != nil {
+			return true
+		}
+		numbers[i] = number
+	}
+	return numbers[0] > major || numbers[0] == major && numbers[1] >= minor
+}
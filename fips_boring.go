@@ -0,0 +1,11 @@
+// +build fips
+
+package main
+
+// The fips builds need a Go toolchain linked with BoringCrypto: fipsonly
+// restricts crypto/tls to the FIPS-approved parameters in the whole process.
+import _ "crypto/tls/fipsonly"
+
+func init() {
+	fipsBuild = true
+}
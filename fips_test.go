@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mailgun/manners"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnforceFIPS(t *testing.T) {
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS10,
+		CipherSuites: []uint16{tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+		// X25519
+		CurvePreferences: []tls.CurveID{29},
+	}
+	assert.Len(t, checkFIPS(config), 4)
+
+	enforceFIPS(config)
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MaxVersion)
+	// the approved cipher suites of the options are kept
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}, config.CipherSuites)
+	assert.Empty(t, checkFIPS(config))
+
+	config = &tls.Config{}
+	enforceFIPS(config)
+	assert.Equal(t, fipsCipherSuites, config.CipherSuites)
+	assert.Equal(t, fipsCurves, config.CurvePreferences)
+}
+
+func TestGetFIPSStatus(t *testing.T) {
+	// generated with a 1024 bits RSA key
+	weak := generateTestCertificate(t, "weak.localhost", time.Now().Add(24*time.Hour))
+	compliant := &tls.Config{}
+	enforceFIPS(compliant)
+	nonCompliant := &tls.Config{Certificates: []tls.Certificate{weak}}
+	enforceFIPS(nonCompliant)
+
+	server := &Server{
+		globalConfiguration: GlobalConfiguration{FIPS: true},
+		serverEntryPoints: serverEntryPoints{
+			"http":  &serverEntryPoint{httpServer: manners.NewWithServer(&http.Server{})},
+			"https": &serverEntryPoint{httpServer: manners.NewWithServer(&http.Server{TLSConfig: compliant})},
+			"weak":  &serverEntryPoint{httpServer: manners.NewWithServer(&http.Server{TLSConfig: nonCompliant})},
+		},
+	}
+	status := server.getFIPSStatus()
+	assert.True(t, status.Enabled)
+	assert.False(t, status.Compliant)
+	assert.Len(t, status.EntryPoints, 2)
+	assert.True(t, status.EntryPoints["https"].Compliant)
+	assert.Equal(t, "VersionTLS12", status.EntryPoints["https"].MinVersion)
+	assert.Contains(t, status.EntryPoints["https"].CipherSuites, "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256")
+	assert.Equal(t, []string{"the RSA key of certificate weak.localhost has 1024 bits, less than 2048"}, status.EntryPoints["weak"].Violations)
+}
+
+func TestGoVersionAtLeast(t *testing.T) {
+	assert.False(t, goVersionAtLeast("go1.7.6", 1, 8))
+	assert.False(t, goVersionAtLeast("go1.7", 1, 8))
+	assert.True(t, goVersionAtLeast("go1.8rc1", 1, 8))
+	assert.True(t, goVersionAtLeast("go1.10.3", 1, 8))
+	assert.True(t, goVersionAtLeast("go2", 1, 8))
+	assert.True(t, goVersionAtLeast("devel +a1b2c3d", 1, 8))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/containous/traefik/middlewares"
+)
+
+// writeForwardProxyMetrics writes the egress counters of the forward proxies
+// of the entrypoints, in the Prometheus text exposition format.
+func writeForwardProxyMetrics(w io.Writer, forwardProxies map[string]*middlewares.ForwardProxy) {
+	entryPoints := []string{}
+	stats := map[string]middlewares.ForwardProxyStats{}
+	for entryPoint, forwardProxy := range forwardProxies {
+		entryPoints = append(entryPoints, entryPoint)
+		stats[entryPoint] = forwardProxy.Stats()
+	}
+	if len(entryPoints) == 0 {
+		return
+	}
+	sort.Strings(entryPoints)
+
+	counters := []struct {
+		name  string
+		help  string
+		value func(middlewares.EgressStats) int64
+	}{
+		{"traefik_egress_requests_total", "Requests forwarded by the forward proxies, by allowed destination.", func(s middlewares.EgressStats) int64 { return s.Requests }},
+		{"traefik_egress_tunnels_total", "CONNECT tunnels opened by the forward proxies, by allowed destination.", func(s middlewares.EgressStats) int64 { return s.Tunnels }},
+		{"traefik_egress_errors_total", "Requests and tunnels of the forward proxies that failed to reach their destination.", func(s middlewares.EgressStats) int64 { return s.Errors }},
+		{"traefik_egress_sent_bytes_total", "Bytes sent to the destinations by the forward proxies.", func(s middlewares.EgressStats) int64 { return s.BytesSent }},
+		{"traefik_egress_received_bytes_total", "Bytes received from the destinations by the forward proxies.", func(s middlewares.EgressStats) int64 { return s.BytesReceived }},
+	}
+	for _, counter := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n", counter.name, counter.help)
+		fmt.Fprintf(w, "# TYPE %s counter\n", counter.name)
+		for _, entryPoint := range entryPoints {
+			destinations := []string{}
+			for destination := range stats[entryPoint].Destinations {
+				destinations = append(destinations, destination)
+			}
+			sort.Strings(destinations)
+			for _, destination := range destinations {
+				fmt.Fprintf(w, "%s{entrypoint=\"%s\",destination=\"%s\"} %d\n", counter.name,
+					prometheusLabelEscaper.Replace(entryPoint),
+					prometheusLabelEscaper.Replace(destination),
+					counter.value(stats[entryPoint].Destinations[destination]))
+			}
+		}
+	}
+	fmt.Fprintln(w, "# HELP traefik_egress_denied_total Requests of the forward proxies to destinations not allowed.")
+	fmt.Fprintln(w, "# TYPE traefik_egress_denied_total counter")
+	for _, entryPoint := range entryPoints {
+		fmt.Fprintf(w, "traefik_egress_denied_total{entrypoint=\"%s\"} %d\n", prometheusLabelEscaper.Replace(entryPoint), stats[entryPoint].Denied)
+	}
+	fmt.Fprintln(w, "# HELP traefik_egress_auth_failures_total Requests of the forward proxies failing the proxy authentication.")
+	fmt.Fprintln(w, "# TYPE traefik_egress_auth_failures_total counter")
+	for _, entryPoint := range entryPoints {
+		fmt.Fprintf(w, "traefik_egress_auth_failures_total{entrypoint=\"%s\"} %d\n", prometheusLabelEscaper.Replace(entryPoint), stats[entryPoint].AuthFailures)
+	}
+}
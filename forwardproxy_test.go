@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteForwardProxyMetrics(t *testing.T) {
+	var buffer bytes.Buffer
+	writeForwardProxyMetrics(&buffer, map[string]*middlewares.ForwardProxy{})
+	assert.Empty(t, buffer.String())
+
+	forwardProxy, err := middlewares.NewForwardProxy(&types.ForwardProxy{AllowedDestinations: []string{"*.example.com:443", "api.internal"}})
+	assert.NoError(t, err)
+	writeForwardProxyMetrics(&buffer, map[string]*middlewares.ForwardProxy{"egress": forwardProxy})
+	metrics := buffer.String()
+	assert.Contains(t, metrics, "# TYPE traefik_egress_requests_total counter\n"+
+		`traefik_egress_requests_total{entrypoint="egress",destination="*.example.com:443"} 0`+"\n"+
+		`traefik_egress_requests_total{entrypoint="egress",destination="api.internal"} 0`+"\n")
+	assert.Contains(t, metrics, `traefik_egress_received_bytes_total{entrypoint="egress",destination="api.internal"} 0`+"\n")
+	assert.Contains(t, metrics, `traefik_egress_denied_total{entrypoint="egress"} 0`+"\n")
+	assert.Equal(t, 7, strings.Count(metrics, "# TYPE"))
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/featureflags"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// configurationFreezeCheckInterval is the interval between two checks of the
+// end of a freeze, to apply the configuration updates held back.
+const configurationFreezeCheckInterval = 5 * time.Second
+
+// configurationFreeze holds back the dynamic configuration updates during the
+// change freezes: the declared windows, the freezes switched on through the
+// API, and while the configurationFreeze feature flag is enabled. The updates
+// are either rejected, or the last one of each provider is applied when the
+// freeze ends.
+type configurationFreeze struct {
+	windows []freezeWindow
+	reject  bool
+	flags   *featureflags.Flags
+	mutex   sync.Mutex
+	frozen  bool
+	pending map[string]types.ConfigMessage
+}
+
+// freezeWindow is a declared change freeze, from start included to end excluded.
+type freezeWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// ConfigurationFreezeStatus is the state of the configuration freeze.
+type ConfigurationFreezeStatus struct {
+	Frozen  bool     `json:"frozen"`
+	Reason  string   `json:"reason,omitempty"`
+	Reject  bool     `json:"reject"`
+	Pending []string `json:"pending,omitempty"`
+}
+
+func newConfigurationFreeze(config *types.ConfigFreeze, flags *featureflags.Flags) (*configurationFreeze, error) {
+	freeze := &configurationFreeze{reject: config.Reject, flags: flags, pending: map[string]types.ConfigMessage{}}
+	for _, window := range config.Windows {
+		bounds := strings.Split(window, "/")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("invalid freeze window %q, expected start/end RFC3339 times", window)
+		}
+		start, err := time.Parse(time.RFC3339, bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid start of freeze window %q: %v", window, err)
+		}
+		end, err := time.Parse(time.RFC3339, bounds[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid end of freeze window %q: %v", window, err)
+		}
+		if !start.Before(end) {
+			return nil, fmt.Errorf("invalid freeze window %q, the start must be before the end", window)
+		}
+		freeze.windows = append(freeze.windows, freezeWindow{start: start, end: end})
+	}
+	return freeze, nil
+}
+
+// reason returns why the configuration is frozen at now, or an empty string
+// if it isn't.
+func (f *configurationFreeze) reason(now time.Time) string {
+	f.mutex.Lock()
+	frozen := f.frozen
+	f.mutex.Unlock()
+	if frozen {
+		return "switched on through the API"
+	}
+	for _, window := range f.windows {
+		if !now.Before(window.start) && now.Before(window.end) {
+			return fmt.Sprintf("freeze window until %s", window.end.Format(time.RFC3339))
+		}
+	}
+	if f.flags != nil && f.flags.Enabled(featureflags.ConfigurationFreeze, "") {
+		return "feature flag " + featureflags.ConfigurationFreeze
+	}
+	return ""
+}
+
+// SetFrozen switches the freeze on or off through the API, the windows and
+// the feature flag still apply.
+func (f *configurationFreeze) SetFrozen(frozen bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.frozen != frozen {
+		log.Infof("Configuration freeze switched on: %t", frozen)
+	}
+	f.frozen = frozen
+}
+
+// Status returns the state of the freeze.
+func (f *configurationFreeze) Status() *ConfigurationFreezeStatus {
+	reason := f.reason(time.Now())
+	status := &ConfigurationFreezeStatus{Frozen: len(reason) > 0, Reason: reason, Reject: f.reject}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for providerName := range f.pending {
+		status.Pending = append(status.Pending, providerName)
+	}
+	sort.Strings(status.Pending)
+	return status
+}
+
+// hold returns true if configMsg is held back by a freeze, logging what it
+// would have changed. The first configuration of a provider is never held
+// back, so that traefik doesn't boot without routes during a freeze.
+func (f *configurationFreeze) hold(configMsg types.ConfigMessage, currentConfigurations configs) bool {
+	current, ok := currentConfigurations[configMsg.ProviderName]
+	if !ok {
+		return false
+	}
+	reason := f.reason(time.Now())
+	if len(reason) == 0 {
+		// a newer configuration supersedes the one held back
+		f.mutex.Lock()
+		delete(f.pending, configMsg.ProviderName)
+		f.mutex.Unlock()
+		return false
+	}
+	changes := strings.Join(configurationChanges(current, configMsg.Configuration), ", ")
+	if f.reject {
+		log.Warnf("Configuration frozen (%s), rejecting the configuration of provider %s, which would have changed: %s", reason, configMsg.ProviderName, changes)
+		return true
+	}
+	log.Warnf("Configuration frozen (%s), holding back the configuration of provider %s until the freeze ends, which would change: %s", reason, configMsg.ProviderName, changes)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.pending[configMsg.ProviderName] = configMsg
+	return true
+}
+
+// release returns the configurations held back, sorted by provider, once the
+// freeze is over.
+func (f *configurationFreeze) release(now time.Time) []types.ConfigMessage {
+	if len(f.reason(now)) > 0 {
+		return nil
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	providerNames := []string{}
+	for providerName := range f.pending {
+		providerNames = append(providerNames, providerName)
+	}
+	sort.Strings(providerNames)
+	released := []types.ConfigMessage{}
+	for _, providerName := range providerNames {
+		released = append(released, f.pending[providerName])
+		delete(f.pending, providerName)
+	}
+	return released
+}
+
+// Run sends the configurations held back to configurationChan when the freeze ends.
+func (f *configurationFreeze) Run(stop chan bool, configurationChan chan<- types.ConfigMessage) {
+	ticker := time.NewTicker(configurationFreezeCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			for _, configMsg := range f.release(now) {
+				log.Infof("Configuration freeze over, applying the configuration of provider %s", configMsg.ProviderName)
+				configurationChan <- configMsg
+			}
+		}
+	}
+}
+
+// configurationChanges describes the frontends and backends added, removed or
+// modified by configuration, sorted.
+func configurationChanges(previous, configuration *types.Configuration) []string {
+	changes := []string{}
+	previousFrontends := map[string]*types.Frontend{}
+	previousBackends := map[string]*types.Backend{}
+	if previous != nil {
+		previousFrontends = previous.Frontends
+		previousBackends = previous.Backends
+	}
+	frontends := map[string]*types.Frontend{}
+	backends := map[string]*types.Backend{}
+	if configuration != nil {
+		frontends = configuration.Frontends
+		backends = configuration.Backends
+	}
+	for name, frontend := range frontends {
+		if previousFrontend, ok := previousFrontends[name]; !ok {
+			changes = append(changes, "frontend "+name+" added")
+		} else if !reflect.DeepEqual(previousFrontend, frontend) {
+			changes = append(changes, "frontend "+name+" modified")
+		}
+	}
+	for name := range previousFrontends {
+		if _, ok := frontends[name]; !ok {
+			changes = append(changes, "frontend "+name+" removed")
+		}
+	}
+	for name, backend := range backends {
+		if previousBackend, ok := previousBackends[name]; !ok {
+			changes = append(changes, "backend "+name+" added")
+		} else if !reflect.DeepEqual(previousBackend, backend) {
+			changes = append(changes, "backend "+name+" modified")
+		}
+	}
+	for name := range previousBackends {
+		if _, ok := backends[name]; !ok {
+			changes = append(changes, "backend "+name+" removed")
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConfigurationFreeze(t *testing.T) {
+	freeze, err := newConfigurationFreeze(&types.ConfigFreeze{Windows: types.FreezeWindows{"2016-12-20T00:00:00Z/2017-01-03T00:00:00Z"}}, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, freeze.reason(time.Date(2016, 12, 25, 0, 0, 0, 0, time.UTC)))
+	assert.Empty(t, freeze.reason(time.Date(2017, 1, 3, 0, 0, 0, 0, time.UTC)))
+
+	for _, window := range []string{"2016-12-20T00:00:00Z", "2016-12-20/2017-01-03", "2017-01-03T00:00:00Z/2016-12-20T00:00:00Z"} {
+		_, err := newConfigurationFreeze(&types.ConfigFreeze{Windows: types.FreezeWindows{window}}, nil)
+		assert.Error(t, err, window)
+	}
+}
+
+func TestConfigurationFreezeHold(t *testing.T) {
+	freeze, _ := newConfigurationFreeze(&types.ConfigFreeze{}, nil)
+	current := configs{"file": {Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend1"}}}}
+	update := types.ConfigMessage{ProviderName: "file", Configuration: &types.Configuration{Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend2"}}}}
+
+	assert.False(t, freeze.hold(update, current))
+
+	freeze.SetFrozen(true)
+	// the first configuration of a provider is applied
+	assert.False(t, freeze.hold(types.ConfigMessage{ProviderName: "docker", Configuration: &types.Configuration{}}, current))
+	assert.True(t, freeze.hold(update, current))
+	assert.Equal(t, []string{"file"}, freeze.Status().Pending)
+	assert.Empty(t, freeze.release(time.Now()))
+
+	freeze.SetFrozen(false)
+	released := freeze.release(time.Now())
+	assert.Equal(t, []types.ConfigMessage{update}, released)
+	assert.Empty(t, freeze.Status().Pending)
+
+	rejecting, _ := newConfigurationFreeze(&types.ConfigFreeze{Reject: true}, nil)
+	rejecting.SetFrozen(true)
+	assert.True(t, rejecting.hold(update, current))
+	assert.Empty(t, rejecting.Status().Pending)
+}
+
+func TestConfigurationChanges(t *testing.T) {
+	previous := &types.Configuration{
+		Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend1"}, "frontend2": {Backend: "backend1"}},
+		Backends:  map[string]*types.Backend{"backend1": {}},
+	}
+	configuration := &types.Configuration{
+		Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend2"}, "frontend3": {Backend: "backend2"}},
+		Backends:  map[string]*types.Backend{"backend2": {}},
+	}
+	assert.Equal(t, []string{
+		"backend backend1 removed",
+		"backend backend2 added",
+		"frontend frontend1 modified",
+		"frontend frontend2 removed",
+		"frontend frontend3 added",
+	}, configurationChanges(previous, configuration))
+	assert.Empty(t, configurationChanges(previous, previous))
+}
+
+func TestFreezeHandlers(t *testing.T) {
+	freeze, _ := newConfigurationFreeze(&types.ConfigFreeze{}, nil)
+	server := &Server{configurationFreeze: freeze, configurationValidatedChan: make(chan types.ConfigMessage, 1)}
+	provider := &WebProvider{ReadOnly: true, server: server}
+
+	recorder := httptest.NewRecorder()
+	provider.putFreezeHandler(recorder, httptest.NewRequest("PUT", "/api/freeze", strings.NewReader(`{"frozen": true}`)))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"frozen": true, "reason": "switched on through the API", "reject": false}`, recorder.Body.String())
+
+	update := types.ConfigMessage{ProviderName: "file", Configuration: &types.Configuration{}}
+	assert.True(t, freeze.hold(update, configs{"file": {}}))
+
+	recorder = httptest.NewRecorder()
+	provider.putFreezeHandler(recorder, httptest.NewRequest("PUT", "/api/freeze", strings.NewReader(`{"frozen": false}`)))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// the configurations held back are applied when the freeze is switched off
+	provider.ReadOnly = false
+	recorder = httptest.NewRecorder()
+	provider.putFreezeHandler(recorder, httptest.NewRequest("PUT", "/api/freeze", strings.NewReader(`{"frozen": false}`)))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, update, <-server.configurationValidatedChan)
+}
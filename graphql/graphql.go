@@ -0,0 +1,147 @@
+// Package graphql analyzes the GraphQL queries received by the frontends, to
+// route them on their operations and limit their cost before the backends
+// execute them.
+package graphql
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Types of the GraphQL operations
+const (
+	Query        = "query"
+	Mutation     = "mutation"
+	Subscription = "subscription"
+)
+
+const (
+	// maxNesting bounds the nesting of the selection sets and the values, so
+	// that the parsing of a hostile query can't exhaust the stack.
+	maxNesting = 512
+	// maxComplexity caps the complexity of the fragments spread many times.
+	maxComplexity = 1 << 30
+)
+
+// Operation is the GraphQL operation executed by a query. Depth is the nesting
+// of its fields, and Complexity the number of its fields, the fragments spread
+// included.
+type Operation struct {
+	Type       string
+	Name       string
+	Depth      int
+	Complexity int
+}
+
+// String returns the type and the name of the operation.
+func (o *Operation) String() string {
+	if len(o.Name) == 0 {
+		return o.Type
+	}
+	return o.Type + " " + o.Name
+}
+
+// selection is a field, a fragment spread or an inline fragment.
+type selection struct {
+	spread     string
+	selections []*selection
+	field      bool
+}
+
+type operation struct {
+	typ        string
+	name       string
+	selections []*selection
+}
+
+type document struct {
+	operations []*operation
+	fragments  map[string][]*selection
+}
+
+// Parse returns the operation named operationName of the GraphQL query, or
+// its only operation if operationName is empty.
+func Parse(query, operationName string) (*Operation, error) {
+	p := &parser{lexer: lexer{input: query}}
+	doc, err := p.parseDocument()
+	if err != nil {
+		return nil, err
+	}
+	var selected *operation
+	for _, op := range doc.operations {
+		if len(operationName) == 0 && len(doc.operations) == 1 || op.name == operationName && len(operationName) > 0 {
+			selected = op
+			break
+		}
+	}
+	if selected == nil {
+		if len(operationName) == 0 {
+			return nil, errors.New("operation name required by a query of several operations")
+		}
+		return nil, fmt.Errorf("unknown operation %q", operationName)
+	}
+	m := &measure{fragments: doc.fragments, costs: map[string]*cost{}}
+	c, err := m.selections(selected.selections)
+	if err != nil {
+		return nil, err
+	}
+	return &Operation{Type: selected.typ, Name: selected.name, Depth: c.depth, Complexity: c.complexity}, nil
+}
+
+type cost struct {
+	depth      int
+	complexity int
+}
+
+// measure computes the cost of the selection sets, each fragment once.
+type measure struct {
+	fragments map[string][]*selection
+	costs     map[string]*cost
+}
+
+func (m *measure) selections(selections []*selection) (*cost, error) {
+	total := &cost{}
+	for _, s := range selections {
+		var c *cost
+		var err error
+		if len(s.spread) > 0 {
+			c, err = m.fragment(s.spread)
+		} else {
+			c, err = m.selections(s.selections)
+		}
+		if err != nil {
+			return nil, err
+		}
+		depth, complexity := c.depth, c.complexity
+		if s.field {
+			depth, complexity = depth+1, complexity+1
+		}
+		if depth > total.depth {
+			total.depth = depth
+		}
+		if total.complexity += complexity; total.complexity > maxComplexity {
+			total.complexity = maxComplexity
+		}
+	}
+	return total, nil
+}
+
+func (m *measure) fragment(name string) (*cost, error) {
+	if c, ok := m.costs[name]; ok {
+		if c == nil {
+			return nil, fmt.Errorf("fragment %s spread within itself", name)
+		}
+		return c, nil
+	}
+	selections, ok := m.fragments[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown fragment %s", name)
+	}
+	m.costs[name] = nil
+	c, err := m.selections(selections)
+	if err != nil {
+		return nil, err
+	}
+	m.costs[name] = c
+	return c, nil
+}
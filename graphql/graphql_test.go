@@ -0,0 +1,77 @@
+package graphql
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		query         string
+		operationName string
+		expected      Operation
+	}{
+		{`{ me { name } }`, "", Operation{Type: Query, Depth: 2, Complexity: 2}},
+		{`query GetUser($id: ID! = "1") @cached(ttl: 60) {
+			user(id: $id, filter: {tags: ["a", "b"], name: "}{"}) {
+				id, name # comment }
+				friends(first: 10) { ...userFields }
+				... on Admin @include(if: true) { permissions { name } }
+			}
+		}
+		fragment userFields on User { id name avatar: picture(size: 64) }`, "", Operation{Type: Query, Name: "GetUser", Depth: 3, Complexity: 9}},
+		{`query A { a } mutation B { b { c d } }`, "B", Operation{Type: Mutation, Name: "B", Depth: 2, Complexity: 3}},
+		{`subscription OnEvent { event(filter: """a "quoted" \""" block""") { id } }`, "", Operation{Type: Subscription, Name: "OnEvent", Depth: 2, Complexity: 2}},
+		{`query { a { ...f } } fragment f on T { b { ...g } c: b { ...g } } fragment g on T { d e }`, "", Operation{Type: Query, Depth: 3, Complexity: 7}},
+	}
+	for _, c := range cases {
+		operation, err := Parse(c.query, c.operationName)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.query, err)
+		} else if *operation != c.expected {
+			t.Errorf("%s: expected %+v, got %+v", c.query, c.expected, *operation)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, query := range []string{
+		``,
+		`{ a `,
+		`{ }`,
+		`{ a(b: "c) }`,
+		`query A { a } query B { b }`,
+		`{ ...f }`,
+		`{ ...f } fragment f on T { a ...f }`,
+		`{ a } fragment f on T { a } fragment f on T { b }`,
+		`type Query { a: String }`,
+		strings.Repeat("{ a ", 1000) + strings.Repeat("}", 1000),
+	} {
+		if _, err := Parse(query, ""); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+	if _, err := Parse(`query A { a }`, "B"); err == nil {
+		t.Error("expected an error for an unknown operation")
+	}
+}
+
+func TestParseFragmentsComplexity(t *testing.T) {
+	// each fragment spreads the next one 10 times
+	query := "{ ...f0 } fragment f0 on T { " + strings.Repeat("...f1 ", 10) + "}"
+	for i := 1; i < 20; i++ {
+		next := "a"
+		if i < 19 {
+			next = strings.Repeat("...f"+strconv.Itoa(i+1)+" ", 10)
+		}
+		query += " fragment f" + strconv.Itoa(i) + " on T { x: a { " + next + "} }"
+	}
+	operation, err := Parse(query, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if operation.Complexity != maxComplexity || operation.Depth != 20 {
+		t.Errorf("expected the capped complexity and a depth of 20, got %+v", *operation)
+	}
+}
@@ -0,0 +1,337 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenValue
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// lexer splits a GraphQL document in tokens, skipping the ignored tokens:
+// white space, commas and comments.
+type lexer struct {
+	input string
+	pos   int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.input) && l.input[l.pos] != '\n' && l.input[l.pos] != '\r' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.input[l.pos:], "\xef\xbb\xbf"):
+			l.pos += 3
+		default:
+			return l.token()
+		}
+	}
+	return token{kind: tokenEOF}, nil
+}
+
+func (l *lexer) token() (token, error) {
+	start := l.pos
+	c := l.input[l.pos]
+	switch {
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.pos++
+		return token{kind: tokenPunctuator, value: l.input[start:l.pos]}, nil
+	case strings.HasPrefix(l.input[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunctuator, value: "..."}, nil
+	case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		for l.pos < len(l.input) && isNameChar(l.input[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.input[start:l.pos]}, nil
+	case c == '-' || '0' <= c && c <= '9':
+		l.pos++
+		for l.pos < len(l.input) && (isNameChar(l.input[l.pos]) || l.input[l.pos] == '.' || l.input[l.pos] == '+' || l.input[l.pos] == '-') {
+			l.pos++
+		}
+		return token{kind: tokenValue, value: l.input[start:l.pos]}, nil
+	case strings.HasPrefix(l.input[l.pos:], `"""`):
+		end := strings.Index(strings.Replace(l.input[l.pos+3:], `\"""`, "xxxx", -1), `"""`)
+		if end < 0 {
+			return token{}, errors.New("unterminated block string")
+		}
+		l.pos += 3 + end + 3
+		return token{kind: tokenValue, value: l.input[start:l.pos]}, nil
+	case c == '"':
+		for l.pos++; l.pos < len(l.input); l.pos++ {
+			switch l.input[l.pos] {
+			case '\\':
+				l.pos++
+			case '\n', '\r':
+				return token{}, errors.New("unterminated string")
+			case '"':
+				l.pos++
+				return token{kind: tokenValue, value: l.input[start:l.pos]}, nil
+			}
+		}
+		return token{}, errors.New("unterminated string")
+	}
+	return token{}, fmt.Errorf("unexpected character %q", c)
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// parser parses the executable definitions of a GraphQL document, keeping
+// only their selection sets: the arguments, variables and directives are skipped.
+type parser struct {
+	lexer   lexer
+	current token
+	nesting int
+}
+
+func (p *parser) advance() error {
+	t, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.current = t
+	return nil
+}
+
+func (p *parser) is(value string) bool {
+	return p.current.kind == tokenPunctuator && p.current.value == value
+}
+
+func (p *parser) expect(value string) error {
+	if !p.is(value) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.current.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.current.value
+	return name, p.advance()
+}
+
+func (p *parser) unexpected() error {
+	if p.current.kind == tokenEOF {
+		return errors.New("unexpected end of the query")
+	}
+	return fmt.Errorf("unexpected %q", p.current.value)
+}
+
+func (p *parser) parseDocument() (*document, error) {
+	doc := &document{fragments: map[string][]*selection{}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	for p.current.kind != tokenEOF {
+		switch {
+		case p.is("{"):
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{typ: Query, selections: selections})
+		case p.current.kind == tokenName && p.current.value == "fragment":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[name]; ok {
+				return nil, fmt.Errorf("duplicate fragment %s", name)
+			}
+			if err := p.parseTypeCondition(); err != nil {
+				return nil, err
+			}
+			if err := p.skipDirectives(); err != nil {
+				return nil, err
+			}
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.fragments[name] = selections
+		case p.current.kind == tokenName && (p.current.value == Query || p.current.value == Mutation || p.current.value == Subscription):
+			op := &operation{typ: p.current.value}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if p.current.kind == tokenName {
+				op.name = p.current.value
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+			}
+			if p.is("(") {
+				if err := p.skipParentheses(); err != nil {
+					return nil, err
+				}
+			}
+			if err := p.skipDirectives(); err != nil {
+				return nil, err
+			}
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			op.selections = selections
+			doc.operations = append(doc.operations, op)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, errors.New("no operation")
+	}
+	return doc, nil
+}
+
+func (p *parser) parseSelectionSet() ([]*selection, error) {
+	if p.nesting++; p.nesting > maxNesting {
+		return nil, errors.New("selection sets nested too deeply")
+	}
+	defer func() { p.nesting-- }()
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	selections := []*selection{}
+	for !p.is("}") {
+		s, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+	if len(selections) == 0 {
+		return nil, errors.New("empty selection set")
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) parseSelection() (*selection, error) {
+	if p.is("...") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.current.kind == tokenName && p.current.value != "on" {
+			spread := p.current.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			return &selection{spread: spread}, p.skipDirectives()
+		}
+		if p.current.kind == tokenName {
+			if err := p.parseTypeCondition(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.skipDirectives(); err != nil {
+			return nil, err
+		}
+		selections, err := p.parseSelectionSet()
+		return &selection{selections: selections}, err
+	}
+	if _, err := p.name(); err != nil {
+		return nil, err
+	}
+	// alias: name
+	if p.is(":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if _, err := p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.is("(") {
+		if err := p.skipParentheses(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.skipDirectives(); err != nil {
+		return nil, err
+	}
+	s := &selection{field: true}
+	if p.is("{") {
+		selections, err := p.parseSelectionSet()
+		if err != nil {
+			return nil, err
+		}
+		s.selections = selections
+	}
+	return s, nil
+}
+
+func (p *parser) parseTypeCondition() error {
+	if p.current.kind != tokenName || p.current.value != "on" {
+		return p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+	_, err := p.name()
+	return err
+}
+
+func (p *parser) skipDirectives() error {
+	for p.is("@") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if p.is("(") {
+			if err := p.skipParentheses(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipParentheses skips the arguments or the variable definitions starting at
+// the current (, with the values they hold.
+func (p *parser) skipParentheses() error {
+	depth := 0
+	for {
+		switch {
+		case p.current.kind == tokenEOF:
+			return p.unexpected()
+		case p.is("(") || p.is("[") || p.is("{"):
+			if depth++; depth > maxNesting {
+				return errors.New("values nested too deeply")
+			}
+		case p.is(")") || p.is("]") || p.is("}"):
+			depth--
+		}
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/docker/libkv"
+	"github.com/docker/libkv/store"
+)
+
+var errUnknownVersion = errors.New("unknown configuration version")
+
+// configurationHistory keeps the last dynamic configurations applied, so that
+// their changes can be reviewed and rolled back through the API. The history
+// is also saved to a key of a KV store when one is configured, to outlive
+// the restarts.
+type configurationHistory struct {
+	size     int
+	mutex    sync.RWMutex
+	versions []*ConfigurationVersion
+	kvclient store.Store
+	kvKey    string
+}
+
+// ConfigurationVersion is a dynamic configuration applied, with its changes
+// from the previous version. It was applied after an update of Provider, or
+// by the rollback to the RollbackOf version.
+type ConfigurationVersion struct {
+	Version        int       `json:"version"`
+	Applied        time.Time `json:"applied"`
+	Provider       string    `json:"provider,omitempty"`
+	RollbackOf     int       `json:"rollbackOf,omitempty"`
+	Changes        []string  `json:"changes"`
+	Configurations configs   `json:"configurations,omitempty"`
+}
+
+// configurationRollback asks the configuration listener to roll back to version.
+type configurationRollback struct {
+	version int
+	result  chan configurationRollbackResult
+}
+
+type configurationRollbackResult struct {
+	version *ConfigurationVersion
+	err     error
+}
+
+func newConfigurationHistory(config *types.ConfigHistory) *configurationHistory {
+	size := config.Size
+	if size <= 0 {
+		size = 10
+	}
+	history := &configurationHistory{size: size}
+	if len(config.KVBackend) == 0 {
+		return history
+	}
+	history.kvKey = config.KVKey
+	kvclient, err := libkv.NewStore(
+		store.Backend(config.KVBackend),
+		strings.Split(config.KVEndpoint, ","),
+		&store.Config{ConnectionTimeout: 30 * time.Second, Bucket: "traefik"},
+	)
+	if err != nil {
+		log.Errorf("Error connecting to the configuration history store, the history is only kept in memory: %v", err)
+		return history
+	}
+	history.kvclient = kvclient
+	pair, err := kvclient.Get(history.kvKey)
+	if err == store.ErrKeyNotFound {
+		return history
+	}
+	if err == nil {
+		err = json.Unmarshal(pair.Value, &history.versions)
+	}
+	if err != nil {
+		log.Errorf("Error loading the configuration history from %s: %v", history.kvKey, err)
+		history.versions = nil
+	}
+	return history
+}
+
+// record adds the configurations applied to the history, with their changes
+// from the previous ones.
+func (h *configurationHistory) record(providerName string, rollbackOf int, previous, configurations configs) *ConfigurationVersion {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	version := &ConfigurationVersion{
+		Version:        1,
+		Applied:        time.Now().UTC(),
+		Provider:       providerName,
+		RollbackOf:     rollbackOf,
+		Changes:        configsChanges(previous, configurations),
+		Configurations: configurations,
+	}
+	if len(h.versions) > 0 {
+		version.Version = h.versions[len(h.versions)-1].Version + 1
+	}
+	h.versions = append(h.versions, version)
+	if len(h.versions) > h.size {
+		h.versions = append([]*ConfigurationVersion{}, h.versions[len(h.versions)-h.size:]...)
+	}
+	if h.kvclient != nil {
+		h.save()
+	}
+	return version
+}
+
+// save writes the history to the KV store. h.mutex must be held.
+func (h *configurationHistory) save() {
+	data, err := json.Marshal(h.versions)
+	if err != nil {
+		log.Errorf("Error encoding the configuration history: %v", err)
+		return
+	}
+	if err := h.kvclient.Put(h.kvKey, data, nil); err != nil {
+		log.Errorf("Error saving the configuration history to %s: %v", h.kvKey, err)
+	}
+}
+
+// Versions returns the versions of the history, the most recent first,
+// without their configurations.
+func (h *configurationHistory) Versions() []*ConfigurationVersion {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	versions := []*ConfigurationVersion{}
+	for i := len(h.versions) - 1; i >= 0; i-- {
+		version := *h.versions[i]
+		version.Configurations = nil
+		versions = append(versions, &version)
+	}
+	return versions
+}
+
+// Get returns the version of the history numbered number, or nil if it is unknown.
+func (h *configurationHistory) Get(number int) *ConfigurationVersion {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for _, version := range h.versions {
+		if version.Version == number {
+			return version
+		}
+	}
+	return nil
+}
+
+// rollbackConfigurations applies the configurations of version of the history.
+// It must be called by the configuration listener, which applies the updates
+// of the providers.
+func (server *Server) rollbackConfigurations(number int) (*ConfigurationVersion, error) {
+	version := server.configurationHistory.Get(number)
+	if version == nil {
+		return nil, errUnknownVersion
+	}
+	currentConfigurations := server.currentConfigurations.Get().(configs)
+	newConfigurations := make(configs)
+	for providerName, configuration := range version.Configurations {
+		newConfigurations[providerName] = configuration
+	}
+	if err := server.applyConfigurations(newConfigurations); err != nil {
+		return nil, err
+	}
+	log.Warnf("Configuration rolled back to version %d", number)
+	return server.configurationHistory.record("", number, currentConfigurations, newConfigurations), nil
+}
+
+// configsChanges describes the changes of the configurations of the providers, sorted.
+func configsChanges(previous, configurations configs) []string {
+	changes := []string{}
+	for providerName, configuration := range configurations {
+		for _, change := range configurationChanges(previous[providerName], configuration) {
+			changes = append(changes, providerName+": "+change)
+		}
+	}
+	for providerName, configuration := range previous {
+		if _, ok := configurations[providerName]; !ok {
+			for _, change := range configurationChanges(configuration, nil) {
+				changes = append(changes, providerName+": "+change)
+			}
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigurationHistory(t *testing.T) {
+	history := newConfigurationHistory(&types.ConfigHistory{Size: 2})
+	configurations1 := configs{"file": {Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend1"}}}}
+	configurations2 := configs{"file": {Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend2"}}}}
+	configurations3 := configs{"file": configurations2["file"], "docker": {Backends: map[string]*types.Backend{"backend3": {}}}}
+
+	history.record("file", 0, configs{}, configurations1)
+	history.record("file", 0, configurations1, configurations2)
+	version := history.record("docker", 0, configurations2, configurations3)
+	assert.Equal(t, 3, version.Version)
+	assert.Equal(t, []string{"docker: backend backend3 added"}, version.Changes)
+
+	// the oldest versions are dropped
+	assert.Nil(t, history.Get(1))
+	assert.Equal(t, configurations2, history.Get(2).Configurations)
+	versions := history.Versions()
+	assert.Len(t, versions, 2)
+	assert.Equal(t, 3, versions[0].Version)
+	assert.Equal(t, "docker", versions[0].Provider)
+	assert.Nil(t, versions[0].Configurations)
+	assert.Equal(t, []string{"file: frontend frontend1 modified"}, versions[1].Changes)
+
+	rollback := history.record("", 2, configurations3, configurations2)
+	assert.Equal(t, 4, rollback.Version)
+	assert.Equal(t, 2, rollback.RollbackOf)
+	assert.Equal(t, []string{"docker: backend backend3 removed"}, rollback.Changes)
+}
+
+func TestHistoryHandlers(t *testing.T) {
+	history := newConfigurationHistory(&types.ConfigHistory{})
+	history.record("file", 0, configs{}, configs{"file": {Backends: map[string]*types.Backend{"backend1": {}}}})
+	provider := &WebProvider{server: &Server{configurationHistory: history}}
+	router := mux.NewRouter()
+	router.Methods("GET").Path("/api/config/history").HandlerFunc(provider.getHistoryHandler)
+	router.Methods("GET").Path("/api/config/history/{version:[0-9]+}").HandlerFunc(provider.getHistoryVersionHandler)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/config/history/1", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"backend1"`)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/config/history/2", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/config/history", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), `"backend1"`)
+}
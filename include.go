@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/containous/flaeg"
+	"github.com/containous/staert"
+)
+
+// Includes holds the glob patterns of the configuration files included by the main one
+type Includes []string
+
+// Set adds strings elem into the the parser
+// it splits str on , and ;
+func (i *Includes) Set(str string) error {
+	fargs := func(c rune) bool {
+		return c == ',' || c == ';'
+	}
+	*i = append(*i, strings.FieldsFunc(str, fargs)...)
+	return nil
+}
+
+// Get []string
+func (i *Includes) Get() interface{} { return Includes(*i) }
+
+// String return slice in a string
+func (i *Includes) String() string { return fmt.Sprintf("%v", *i) }
+
+// SetValue sets []string into the parser
+func (i *Includes) SetValue(val interface{}) {
+	*i = Includes(val.(Includes))
+}
+
+// includeSource is a staert source loading the files included by the main
+// TOML configuration file. The files of a pattern are merged in lexical order
+// into the main file: their tables are merged key by key, and their other
+// values replace the values of the files loaded before them.
+type includeSource struct {
+	main *staert.TomlSource
+}
+
+// Parse loads the main file merged with the included files into the configuration of cmd
+func (s *includeSource) Parse(cmd *flaeg.Command) (*flaeg.Command, error) {
+	configuration := cmd.Config.(*TraefikConfiguration)
+	mainFile := s.main.ConfigFileUsed()
+	files, err := includedFiles(mainFile, configuration.Include)
+	if err != nil || len(files) == 0 {
+		return cmd, err
+	}
+	merged := map[string]interface{}{}
+	if _, err := toml.DecodeFile(mainFile, &merged); err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		included := map[string]interface{}{}
+		if _, err := toml.DecodeFile(file, &included); err != nil {
+			return nil, fmt.Errorf("error reading included file %s: %s", file, err)
+		}
+		for key := range included {
+			if strings.EqualFold(key, "include") {
+				return nil, fmt.Errorf("error reading included file %s: nested includes are not supported", file)
+			}
+		}
+		mergeTOMLTables(merged, included)
+	}
+
+	mergedFile, err := ioutil.TempFile("", "traefik")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(mergedFile.Name())
+	err = toml.NewEncoder(mergedFile).Encode(merged)
+	mergedFile.Close()
+	if err != nil {
+		return nil, err
+	}
+	return staert.NewTomlSource("traefik", []string{mergedFile.Name()}).Parse(cmd)
+}
+
+// mergeTOMLTables merges the table src into dst, the TOML keys are case insensitive.
+func mergeTOMLTables(dst, src map[string]interface{}) {
+	for key, value := range src {
+		dstKey := key
+		for existing := range dst {
+			if strings.EqualFold(existing, key) {
+				dstKey = existing
+				break
+			}
+		}
+		srcTable, srcIsTable := value.(map[string]interface{})
+		dstTable, dstIsTable := dst[dstKey].(map[string]interface{})
+		if srcIsTable && dstIsTable {
+			mergeTOMLTables(dstTable, srcTable)
+		} else {
+			dst[dstKey] = value
+		}
+	}
+}
+
+// includedFiles returns the files matching patterns, relative to the directory of mainFile.
+func includedFiles(mainFile string, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	if len(mainFile) == 0 {
+		return nil, errors.New("includes are only supported in a configuration file")
+	}
+	files := []string{}
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(mainFile), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern %s: %s", pattern, err)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if match != mainFile {
+				files = append(files, match)
+			}
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/staert"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigurationFile(t *testing.T, file, content string) {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func loadIncludedConfiguration(t *testing.T, file string) (*TraefikConfiguration, error) {
+	configuration := NewTraefikConfiguration()
+	command := &flaeg.Command{Name: "traefik", Config: configuration, DefaultPointersConfig: NewTraefikDefaultPointersConfiguration()}
+	toml := staert.NewTomlSource("traefik", []string{file})
+	if _, err := toml.Parse(command); err != nil {
+		t.Fatal(err)
+	}
+	_, err := (&includeSource{main: toml}).Parse(command)
+	return configuration, err
+}
+
+func TestIncludeSource(t *testing.T) {
+	directory, err := ioutil.TempDir("", "include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	writeConfigurationFile(t, filepath.Join(directory, "traefik.toml"), `
+include = ["conf.d/*.toml"]
+logLevel = "INFO"
+graceTimeOut = 5
+
+[entryPoints]
+  [entryPoints.http]
+  address = ":80"
+`)
+	writeConfigurationFile(t, filepath.Join(directory, "conf.d", "10-entrypoints.toml"), `
+[entryPoints]
+  [entryPoints.https]
+  address = ":443"
+`)
+	writeConfigurationFile(t, filepath.Join(directory, "conf.d", "20-tuning.toml"), `
+logLevel = "WARN"
+graceTimeOut = 30
+`)
+	writeConfigurationFile(t, filepath.Join(directory, "conf.d", "30-override.toml"), `
+graceTimeOut = 20
+
+[consul]
+endpoint = "consul:8500"
+`)
+
+	configuration, err := loadIncludedConfiguration(t, filepath.Join(directory, "traefik.toml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "WARN", configuration.LogLevel)
+	assert.Equal(t, int64(20), configuration.GraceTimeOut)
+	if assert.Len(t, configuration.EntryPoints, 2) {
+		assert.Equal(t, ":80", configuration.EntryPoints["http"].Address)
+		assert.Equal(t, ":443", configuration.EntryPoints["https"].Address)
+	}
+	if assert.NotNil(t, configuration.Consul) {
+		assert.Equal(t, "consul:8500", configuration.Consul.Endpoint)
+		// the defaults of the sections enabled by an included file are set
+		assert.Equal(t, "traefik", configuration.Consul.Prefix)
+	}
+	assert.Equal(t, Includes{"conf.d/*.toml"}, configuration.Include)
+}
+
+func TestIncludeSourceNested(t *testing.T) {
+	directory, err := ioutil.TempDir("", "include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	writeConfigurationFile(t, filepath.Join(directory, "traefik.toml"), `include = ["included.toml"]`)
+	writeConfigurationFile(t, filepath.Join(directory, "included.toml"), `include = ["other.toml"]`)
+	_, err = loadIncludedConfiguration(t, filepath.Join(directory, "traefik.toml"))
+	assert.Error(t, err)
+}
+
+func TestIncludeSourceMergesSections(t *testing.T) {
+	directory, err := ioutil.TempDir("", "include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	writeConfigurationFile(t, filepath.Join(directory, "traefik.toml"), `
+include = ["consul.toml"]
+
+[consul]
+endpoint = "consul:8500"
+`)
+	writeConfigurationFile(t, filepath.Join(directory, "consul.toml"), `
+[Consul]
+prefix = "edge"
+`)
+	configuration, err := loadIncludedConfiguration(t, filepath.Join(directory, "traefik.toml"))
+	if assert.NoError(t, err) && assert.NotNil(t, configuration.Consul) {
+		assert.Equal(t, "consul:8500", configuration.Consul.Endpoint)
+		assert.Equal(t, "edge", configuration.Consul.Prefix)
+	}
+}
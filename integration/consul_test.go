@@ -14,8 +14,8 @@ import (
 
 	"errors"
 	"github.com/containous/traefik/cluster"
-	"github.com/containous/traefik/integration/utils"
 	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/traefiktest"
 	checker "github.com/vdemeester/shakers"
 	"io/ioutil"
 	"os"
@@ -47,7 +47,7 @@ func (s *ConsulSuite) setupConsul(c *check.C) {
 	s.kv = kv
 
 	// wait for consul
-	err = utils.Try(60*time.Second, func() error {
+	err = traefiktest.Try(60*time.Second, func() error {
 		_, err := kv.Exists("test")
 		if err != nil {
 			return err
@@ -86,7 +86,7 @@ func (s *ConsulSuite) setupConsulTLS(c *check.C) {
 	s.kv = kv
 
 	// wait for consul
-	err = utils.Try(60*time.Second, func() error {
+	err = traefiktest.Try(60*time.Second, func() error {
 		_, err := kv.Exists("test")
 		if err != nil {
 			return err
@@ -182,7 +182,7 @@ func (s *ConsulSuite) TestNominalConfiguration(c *check.C) {
 	}
 
 	// wait for consul
-	err = utils.Try(60*time.Second, func() error {
+	err = traefiktest.Try(60*time.Second, func() error {
 		_, err := s.kv.Exists("traefik/frontends/frontend2/routes/test_2/rule")
 		if err != nil {
 			return err
@@ -192,7 +192,7 @@ func (s *ConsulSuite) TestNominalConfiguration(c *check.C) {
 	c.Assert(err, checker.IsNil)
 
 	// wait for traefik
-	err = utils.TryRequest("http://127.0.0.1:8081/api/providers", 60*time.Second, func(res *http.Response) error {
+	err = traefiktest.TryRequest("http://127.0.0.1:8081/api/providers", 60*time.Second, func(res *http.Response) error {
 		body, err := ioutil.ReadAll(res.Body)
 		if err != nil {
 			return err
@@ -253,7 +253,7 @@ func (s *ConsulSuite) TestGlobalConfiguration(c *check.C) {
 	c.Assert(err, checker.IsNil)
 
 	// wait for consul
-	err = utils.Try(60*time.Second, func() error {
+	err = traefiktest.Try(60*time.Second, func() error {
 		_, err := s.kv.Exists("traefik/entrypoints/http/address")
 		if err != nil {
 			return err
@@ -320,7 +320,7 @@ func (s *ConsulSuite) TestGlobalConfiguration(c *check.C) {
 	}
 
 	// wait for consul
-	err = utils.Try(60*time.Second, func() error {
+	err = traefiktest.Try(60*time.Second, func() error {
 		_, err := s.kv.Exists("traefik/frontends/frontend2/routes/test_2/rule")
 		if err != nil {
 			return err
@@ -330,7 +330,7 @@ func (s *ConsulSuite) TestGlobalConfiguration(c *check.C) {
 	c.Assert(err, checker.IsNil)
 
 	// wait for traefik
-	err = utils.TryRequest("http://127.0.0.1:8080/api/providers", 60*time.Second, func(res *http.Response) error {
+	err = traefiktest.TryRequest("http://127.0.0.1:8080/api/providers", 60*time.Second, func(res *http.Response) error {
 		body, err := ioutil.ReadAll(res.Body)
 		if err != nil {
 			return err
@@ -362,7 +362,7 @@ func (s *ConsulSuite) skipTestGlobalConfigurationWithClientTLS(c *check.C) {
 	c.Assert(err, checker.IsNil)
 
 	// wait for consul
-	err = utils.Try(60*time.Second, func() error {
+	err = traefiktest.Try(60*time.Second, func() error {
 		_, err := s.kv.Exists("traefik/web/address")
 		if err != nil {
 			return err
@@ -386,7 +386,7 @@ func (s *ConsulSuite) skipTestGlobalConfigurationWithClientTLS(c *check.C) {
 	defer cmd.Process.Kill()
 
 	// wait for traefik
-	err = utils.TryRequest("http://127.0.0.1:8081/api/providers", 60*time.Second, func(res *http.Response) error {
+	err = traefiktest.TryRequest("http://127.0.0.1:8081/api/providers", 60*time.Second, func(res *http.Response) error {
 		_, err := ioutil.ReadAll(res.Body)
 		if err != nil {
 			return err
@@ -418,7 +418,7 @@ func (s *ConsulSuite) TestCommandStoreConfig(c *check.C) {
 
 	for key, value := range checkmap {
 		var p *store.KVPair
-		err = utils.Try(60*time.Second, func() error {
+		err = traefiktest.Try(60*time.Second, func() error {
 			p, err = s.kv.Get(key)
 			if err != nil {
 				return err
@@ -11,7 +11,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
-	"github.com/containous/traefik/integration/utils"
+	"github.com/containous/traefik/traefiktest"
 	"github.com/docker/libkv"
 	"github.com/docker/libkv/store"
 	"github.com/docker/libkv/store/etcd"
@@ -45,7 +45,7 @@ func (s *EtcdSuite) SetUpTest(c *check.C) {
 	s.kv = kv
 
 	// wait for etcd
-	err = utils.Try(60*time.Second, func() error {
+	err = traefiktest.Try(60*time.Second, func() error {
 		_, err := kv.Exists("test")
 		if err != nil {
 			return fmt.Errorf("Etcd connection error to %s: %v", url, err)
@@ -140,7 +140,7 @@ func (s *EtcdSuite) TestNominalConfiguration(c *check.C) {
 	}
 
 	// wait for etcd
-	err = utils.Try(60*time.Second, func() error {
+	err = traefiktest.Try(60*time.Second, func() error {
 		_, err := s.kv.Exists("/traefik/frontends/frontend2/routes/test_2/rule")
 		if err != nil {
 			return err
@@ -150,7 +150,7 @@ func (s *EtcdSuite) TestNominalConfiguration(c *check.C) {
 	c.Assert(err, checker.IsNil)
 
 	// wait for traefik
-	err = utils.TryRequest("http://127.0.0.1:8081/api/providers", 60*time.Second, func(res *http.Response) error {
+	err = traefiktest.TryRequest("http://127.0.0.1:8081/api/providers", 60*time.Second, func(res *http.Response) error {
 		body, err := ioutil.ReadAll(res.Body)
 		if err != nil {
 			return err
@@ -210,7 +210,7 @@ func (s *EtcdSuite) TestGlobalConfiguration(c *check.C) {
 	c.Assert(err, checker.IsNil)
 
 	// wait for etcd
-	err = utils.Try(60*time.Second, func() error {
+	err = traefiktest.Try(60*time.Second, func() error {
 		_, err := s.kv.Exists("/traefik/entrypoints/http/address")
 		if err != nil {
 			return err
@@ -277,7 +277,7 @@ func (s *EtcdSuite) TestGlobalConfiguration(c *check.C) {
 	}
 
 	// wait for etcd
-	err = utils.Try(60*time.Second, func() error {
+	err = traefiktest.Try(60*time.Second, func() error {
 		_, err := s.kv.Exists("/traefik/frontends/frontend2/routes/test_2/rule")
 		if err != nil {
 			return err
@@ -287,7 +287,7 @@ func (s *EtcdSuite) TestGlobalConfiguration(c *check.C) {
 	c.Assert(err, checker.IsNil)
 
 	// wait for traefik
-	err = utils.TryRequest("http://127.0.0.1:8080/api/providers", 60*time.Second, func(res *http.Response) error {
+	err = traefiktest.TryRequest("http://127.0.0.1:8080/api/providers", 60*time.Second, func(res *http.Response) error {
 		body, err := ioutil.ReadAll(res.Body)
 		if err != nil {
 			return err
@@ -389,7 +389,7 @@ func (s *EtcdSuite) TestCertificatesContentstWithSNIConfigHandshake(c *check.C)
 	}
 
 	// wait for etcd
-	err = utils.Try(60*time.Second, func() error {
+	err = traefiktest.Try(60*time.Second, func() error {
 		_, err := s.kv.Exists("/traefik/frontends/frontend2/routes/test_2/rule")
 		if err != nil {
 			return err
@@ -403,7 +403,7 @@ func (s *EtcdSuite) TestCertificatesContentstWithSNIConfigHandshake(c *check.C)
 	defer cmd.Process.Kill()
 
 	// wait for traefik
-	err = utils.TryRequest("http://127.0.0.1:8080/api/providers", 60*time.Second, func(res *http.Response) error {
+	err = traefiktest.TryRequest("http://127.0.0.1:8080/api/providers", 60*time.Second, func(res *http.Response) error {
 		body, err := ioutil.ReadAll(res.Body)
 		if err != nil {
 			return err
@@ -453,7 +453,7 @@ func (s *EtcdSuite) TestCommandStoreConfig(c *check.C) {
 
 	for key, value := range checkmap {
 		var p *store.KVPair
-		err = utils.Try(60*time.Second, func() error {
+		err = traefiktest.Try(60*time.Second, func() error {
 			p, err = s.kv.Get(key)
 			if err != nil {
 				return err
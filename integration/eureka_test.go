@@ -11,7 +11,7 @@ import (
 	"text/template"
 	"time"
 
-	"github.com/containous/traefik/integration/utils"
+	"github.com/containous/traefik/traefiktest"
 	"github.com/go-check/check"
 
 	checker "github.com/vdemeester/shakers"
@@ -41,7 +41,7 @@ func (s *EurekaSuite) TestSimpleConfiguration(c *check.C) {
 	eurekaURL := "http://" + eurekaHost + ":8761/eureka/apps"
 
 	// wait for eureka
-	err = utils.TryRequest(eurekaURL, 60*time.Second, func(res *http.Response) error {
+	err = traefiktest.TryRequest(eurekaURL, 60*time.Second, func(res *http.Response) error {
 		if err != nil {
 			return err
 		}
@@ -81,7 +81,7 @@ func (s *EurekaSuite) TestSimpleConfiguration(c *check.C) {
 	c.Assert(resp.StatusCode, checker.Equals, 204)
 
 	// wait for traefik
-	err = utils.TryRequest("http://127.0.0.1:8080/api/providers", 60*time.Second, func(res *http.Response) error {
+	err = traefiktest.TryRequest("http://127.0.0.1:8080/api/providers", 60*time.Second, func(res *http.Response) error {
 		body, err := ioutil.ReadAll(res.Body)
 		if err != nil {
 			return err
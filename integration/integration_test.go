@@ -3,14 +3,10 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
-	"os"
 	"os/exec"
-	"path/filepath"
 	"testing"
-	"text/template"
 
-	"github.com/containous/traefik/integration/utils"
+	"github.com/containous/traefik/traefiktest"
 	"github.com/go-check/check"
 
 	compose "github.com/libkermit/compose/check"
@@ -56,36 +52,19 @@ func (s *BaseSuite) createComposeProject(c *check.C, name string) {
 }
 
 func (s *BaseSuite) traefikCmd(c *check.C, args ...string) (*exec.Cmd, string) {
-	cmd, out, err := utils.RunCommand(traefikBinary, args...)
+	cmd, out, err := traefiktest.RunCommand(traefikBinary, args...)
 	c.Assert(err, checker.IsNil, check.Commentf("Fail to run %s with %v", traefikBinary, args))
 	return cmd, out
 }
 
 func (s *BaseSuite) adaptFileForHost(c *check.C, path string) string {
-	dockerHost := os.Getenv("DOCKER_HOST")
-	if dockerHost == "" {
-		// Default docker socket
-		dockerHost = "unix:///var/run/docker.sock"
-	}
-	tempObjects := struct{ DockerHost string }{dockerHost}
-	return s.adaptFile(c, path, tempObjects)
+	file, err := traefiktest.AdaptFileForHost(path)
+	c.Assert(err, checker.IsNil)
+	return file
 }
 
 func (s *BaseSuite) adaptFile(c *check.C, path string, tempObjects interface{}) string {
-	// Load file
-	tmpl, err := template.ParseFiles(path)
+	file, err := traefiktest.AdaptFile(path, tempObjects)
 	c.Assert(err, checker.IsNil)
-
-	folder, prefix := filepath.Split(path)
-	tmpFile, err := ioutil.TempFile(folder, prefix)
-	c.Assert(err, checker.IsNil)
-	defer tmpFile.Close()
-
-	err = tmpl.ExecuteTemplate(tmpFile, prefix, tempObjects)
-	c.Assert(err, checker.IsNil)
-	err = tmpFile.Sync()
-
-	c.Assert(err, checker.IsNil)
-
-	return tmpFile.Name()
+	return file
 }
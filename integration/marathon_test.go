@@ -17,7 +17,7 @@ func (s *MarathonSuite) SetUpSuite(c *check.C) {
 	s.createComposeProject(c, "marathon")
 	s.composeProject.Start(c)
 	// wait for marathon
-	// err := utils.TryRequest("http://127.0.0.1:8080/ping", 60*time.Second, func(res *http.Response) error {
+	// err := traefiktest.TryRequest("http://127.0.0.1:8080/ping", 60*time.Second, func(res *http.Response) error {
 	// 	body, err := ioutil.ReadAll(res.Body)
 	// 	if err != nil {
 	// 		return err
@@ -0,0 +1,363 @@
+package internalca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/staert"
+	"github.com/containous/traefik/cluster"
+	"github.com/containous/traefik/log"
+)
+
+const (
+	// caValidity is the validity of the generated CA certificate
+	caValidity = 10 * 365 * 24 * time.Hour
+	// clockSkew backdates the certificates, for the servers whose clock is late
+	clockSkew = 5 * time.Minute
+)
+
+// CA holds the configuration of the internal certificate authority issuing
+// short-lived serving certificates to the backends
+type CA struct {
+	Storage             string       `description:"File or key used for the CA storage, a key of the cluster store in cluster mode"`
+	Name                string       `description:"Common name of the CA certificate"`
+	CertificateValidity int64        `description:"Validity in seconds of the issued certificates"`
+	Token               string       `description:"Bearer token required to issue certificates"`
+	AllowedNames        AllowedNames `description:"Domain suffixes and CIDRs of the names of the issued certificates"`
+	store               cluster.Store
+	lock                sync.RWMutex
+	certificate         *x509.Certificate
+	key                 crypto.Signer
+}
+
+// AllowedNames holds the domain suffixes and the CIDRs the names of the
+// issued certificates must be in
+type AllowedNames []string
+
+// Set adds strings elem into the the parser
+// it splits str on , and ;
+func (n *AllowedNames) Set(str string) error {
+	fargs := func(c rune) bool {
+		return c == ',' || c == ';'
+	}
+	*n = append(*n, strings.FieldsFunc(str, fargs)...)
+	return nil
+}
+
+// Get []string
+func (n *AllowedNames) Get() interface{} { return AllowedNames(*n) }
+
+// String return slice in a string
+func (n *AllowedNames) String() string { return fmt.Sprintf("%v", *n) }
+
+// SetValue sets []string into the parser
+func (n *AllowedNames) SetValue(val interface{}) {
+	*n = AllowedNames(val.(AllowedNames))
+}
+
+// parse returns the domain suffixes, lowercased, and the networks of the names.
+func (n AllowedNames) parse() ([]string, []*net.IPNet, error) {
+	domains := []string{}
+	networks := []*net.IPNet{}
+	for _, name := range n {
+		if _, network, err := net.ParseCIDR(name); err == nil {
+			networks = append(networks, network)
+			continue
+		}
+		domain := strings.Trim(strings.ToLower(strings.TrimSpace(name)), ".")
+		if len(domain) == 0 || strings.ContainsAny(domain, "*/ ") {
+			return nil, nil, fmt.Errorf("invalid allowed name %q, expected a domain suffix or a CIDR", name)
+		}
+		domains = append(domains, domain)
+	}
+	return domains, networks, nil
+}
+
+// allowedDomain returns true if name is one of domains or a subdomain of one.
+func allowedDomain(name string, domains []string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	for _, domain := range domains {
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedIP returns true if ip is in one of networks.
+func allowedIP(ip net.IP, networks []*net.IPNet) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authority is the CA key and certificate, PEM encoded, as stored
+type Authority struct {
+	Certificate string
+	PrivateKey  string
+}
+
+// CreateLocalConfig loads the CA from its storage file, generating it on the first start.
+func (ca *CA) CreateLocalConfig() error {
+	if err := ca.validate(); err != nil {
+		return err
+	}
+	localStore := newLocalStore(ca.Storage)
+	ca.store = localStore
+	if fileInfo, err := os.Stat(ca.Storage); err == nil && fileInfo.Size() != 0 {
+		log.Infof("Loading internal CA...")
+		object, err := localStore.Load()
+		if err != nil {
+			return err
+		}
+		return ca.setAuthority(object.(*Authority))
+	}
+	log.Infof("Generating internal CA...")
+	authority, err := ca.generate()
+	if err != nil {
+		return err
+	}
+	transaction, _, err := localStore.Begin()
+	if err != nil {
+		return err
+	}
+	if err := transaction.Commit(authority); err != nil {
+		return err
+	}
+	return ca.setAuthority(authority)
+}
+
+// CreateClusterConfig loads the CA from the cluster store, the leader generates it on the first start.
+func (ca *CA) CreateClusterConfig(leadership *cluster.Leadership) error {
+	if err := ca.validate(); err != nil {
+		return err
+	}
+	listener := func(object cluster.Object) error {
+		authority := object.(*Authority)
+		if len(authority.Certificate) == 0 {
+			return nil
+		}
+		return ca.setAuthority(authority)
+	}
+	datastore, err := cluster.NewDataStore(
+		leadership.Pool.Ctx(),
+		staert.KvSource{
+			Store:  leadership.Store,
+			Prefix: ca.Storage,
+		},
+		&Authority{},
+		listener)
+	if err != nil {
+		return err
+	}
+	ca.store = datastore
+
+	leadership.AddListener(func(elected bool) error {
+		if !elected {
+			return nil
+		}
+		object, err := ca.store.Load()
+		if err != nil {
+			return err
+		}
+		if authority := object.(*Authority); len(authority.Certificate) > 0 {
+			return ca.setAuthority(authority)
+		}
+		log.Infof("Generating internal CA...")
+		authority, err := ca.generate()
+		if err != nil {
+			return err
+		}
+		transaction, _, err := ca.store.Begin()
+		if err != nil {
+			return err
+		}
+		if err := transaction.Commit(authority); err != nil {
+			return err
+		}
+		return ca.setAuthority(authority)
+	})
+	return nil
+}
+
+func (ca *CA) validate() error {
+	if len(ca.Storage) == 0 {
+		return errors.New("Empty Store, please provide a key for the internal CA storage")
+	}
+	if len(ca.Token) == 0 {
+		return errors.New("A token is required to issue certificates from the internal CA")
+	}
+	if len(ca.AllowedNames) == 0 {
+		return errors.New("Allowed names are required to issue certificates from the internal CA")
+	}
+	_, _, err := ca.AllowedNames.parse()
+	return err
+}
+
+func (ca *CA) generate() (*Authority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	domains, _, err := ca.AllowedNames.parse()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: ca.Name},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		// the clients verifying the name constraints reject the certificates
+		// for other domains, even if they were issued with the CA key
+		PermittedDNSDomains:         domains,
+		PermittedDNSDomainsCritical: len(domains) > 0,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Authority{
+		Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}, nil
+}
+
+func (ca *CA) setAuthority(authority *Authority) error {
+	keyPair, err := tls.X509KeyPair([]byte(authority.Certificate), []byte(authority.PrivateKey))
+	if err != nil {
+		return fmt.Errorf("invalid internal CA: %v", err)
+	}
+	certificate, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("invalid internal CA: %v", err)
+	}
+	signer, ok := keyPair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return errors.New("invalid internal CA: unsupported private key")
+	}
+	if domains, _, err := ca.AllowedNames.parse(); err == nil {
+		for _, domain := range domains {
+			if !allowedDomain(domain, certificate.PermittedDNSDomains) {
+				log.Warnf("The internal CA certificate doesn't constrain the issued certificates to %s, remove its storage to generate a constrained one", domain)
+				break
+			}
+		}
+	}
+	ca.lock.Lock()
+	defer ca.lock.Unlock()
+	ca.certificate = certificate
+	ca.key = signer
+	return nil
+}
+
+// Certificate returns the CA certificate, or nil if the CA is not loaded yet.
+func (ca *CA) Certificate() *x509.Certificate {
+	ca.lock.RLock()
+	defer ca.lock.RUnlock()
+	return ca.certificate
+}
+
+// Authorized returns true if token allows to issue certificates.
+func (ca *CA) Authorized(token string) bool {
+	return len(ca.Token) > 0 && subtle.ConstantTimeCompare([]byte(token), []byte(ca.Token)) == 1
+}
+
+// Sign issues a serving certificate for the names of csr, and returns it PEM
+// encoded. The names, and the common name, must be in the allowed names.
+func (ca *CA) Sign(csr *x509.CertificateRequest) ([]byte, error) {
+	ca.lock.RLock()
+	certificate, key := ca.certificate, ca.key
+	ca.lock.RUnlock()
+	if certificate == nil {
+		return nil, errors.New("internal CA is not loaded yet")
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid certificate request signature: %v", err)
+	}
+	dnsNames := csr.DNSNames
+	if len(dnsNames) == 0 && len(csr.IPAddresses) == 0 && len(csr.Subject.CommonName) > 0 {
+		dnsNames = []string{csr.Subject.CommonName}
+	}
+	if len(dnsNames) == 0 && len(csr.IPAddresses) == 0 {
+		return nil, errors.New("certificate request without names")
+	}
+	domains, networks, err := ca.AllowedNames.parse()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range dnsNames {
+		if !allowedDomain(name, domains) {
+			return nil, fmt.Errorf("DNS name %s is not allowed", name)
+		}
+	}
+	for _, ip := range csr.IPAddresses {
+		if !allowedIP(ip, networks) {
+			return nil, fmt.Errorf("IP address %s is not allowed", ip)
+		}
+	}
+	if commonName := csr.Subject.CommonName; len(commonName) > 0 {
+		if ip := net.ParseIP(commonName); ip != nil && !allowedIP(ip, networks) || ip == nil && !allowedDomain(commonName, domains) {
+			return nil, fmt.Errorf("common name %s is not allowed", commonName)
+		}
+	}
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	validity := time.Duration(ca.CertificateValidity) * time.Second
+	if validity <= 0 {
+		validity = 24 * time.Hour
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: csr.Subject.CommonName},
+		DNSNames:     dnsNames,
+		IPAddresses:  csr.IPAddresses,
+		NotBefore:    now.Add(-clockSkew),
+		NotAfter:     now.Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, certificate, csr.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Issued internal certificate %s for %v %v, valid until %s", serialNumber.Text(16), dnsNames, csr.IPAddresses, template.NotAfter)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+func newSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
@@ -0,0 +1,136 @@
+package internalca
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newCSR(t *testing.T, template *x509.CertificateRequest) *x509.CertificateRequest {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return csr
+}
+
+func TestLocalCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "internalca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	storage := filepath.Join(dir, "ca.json")
+
+	if err := (&CA{Storage: storage}).CreateLocalConfig(); err == nil {
+		t.Fatal("expected an error without token")
+	}
+	if err := (&CA{Storage: storage, Token: "secret"}).CreateLocalConfig(); err == nil {
+		t.Fatal("expected an error without allowed names")
+	}
+	if err := (&CA{Storage: storage, Token: "secret", AllowedNames: AllowedNames{"*.internal"}}).CreateLocalConfig(); err == nil {
+		t.Fatal("expected an error with an invalid allowed name")
+	}
+	ca := &CA{Storage: storage, Name: "Test CA", CertificateValidity: 3600, Token: "secret", AllowedNames: AllowedNames{".Internal", "10.0.0.0/8"}}
+	if err := ca.CreateLocalConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if ca.Certificate() == nil || ca.Certificate().Subject.CommonName != "Test CA" || !ca.Certificate().IsCA {
+		t.Fatalf("unexpected CA certificate %+v", ca.Certificate())
+	}
+	if permitted := ca.Certificate().PermittedDNSDomains; len(permitted) != 1 || permitted[0] != "internal" || !ca.Certificate().PermittedDNSDomainsCritical {
+		t.Fatalf("expected the CA certificate to be constrained to the internal domain, got %v", permitted)
+	}
+	if fileInfo, err := os.Stat(storage); err != nil || fileInfo.Mode().Perm() != 0600 {
+		t.Fatalf("expected a private storage file, got %v %v", fileInfo, err)
+	}
+
+	reloaded := &CA{Storage: storage, Token: "secret", AllowedNames: AllowedNames{"internal"}}
+	if err := reloaded.CreateLocalConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Certificate().Equal(ca.Certificate()) {
+		t.Fatal("expected the stored CA to be reloaded")
+	}
+
+	if !ca.Authorized("secret") || ca.Authorized("wrong") || ca.Authorized("") {
+		t.Fatal("unexpected token authorization")
+	}
+}
+
+func TestSign(t *testing.T) {
+	ca := &CA{CertificateValidity: 3600, AllowedNames: AllowedNames{"internal", "10.0.0.0/8"}}
+	authority, err := ca.generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ca.Sign(newCSR(t, &x509.CertificateRequest{DNSNames: []string{"backend1.internal"}})); err == nil {
+		t.Fatal("expected an error before the CA is loaded")
+	}
+	if err := ca.setAuthority(authority); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ca.Sign(newCSR(t, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "backend1.internal"}, DNSNames: []string{"backend1.internal"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Certificate())
+	if _, err := certificate.Verify(x509.VerifyOptions{DNSName: "backend1.internal", Roots: roots}); err != nil {
+		t.Fatal(err)
+	}
+	if validity := certificate.NotAfter.Sub(time.Now()); validity > time.Hour || validity < 59*time.Minute {
+		t.Fatalf("unexpected validity %s", validity)
+	}
+
+	data, err = ca.Sign(newCSR(t, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "backend2.internal"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ = pem.Decode(data)
+	if certificate, err = x509.ParseCertificate(block.Bytes); err != nil || len(certificate.DNSNames) != 1 || certificate.DNSNames[0] != "backend2.internal" {
+		t.Fatalf("expected the common name as DNS name, got %v %v", certificate.DNSNames, err)
+	}
+
+	if _, err := ca.Sign(newCSR(t, &x509.CertificateRequest{})); err == nil {
+		t.Fatal("expected an error without names")
+	}
+
+	if _, err := ca.Sign(newCSR(t, &x509.CertificateRequest{IPAddresses: []net.IP{net.ParseIP("10.0.0.1")}})); err != nil {
+		t.Fatal(err)
+	}
+	for _, denied := range []*x509.CertificateRequest{
+		{DNSNames: []string{"backend1.internal", "proxy.example.com"}},
+		{DNSNames: []string{"notinternal"}},
+		{Subject: pkix.Name{CommonName: "proxy.example.com"}},
+		{Subject: pkix.Name{CommonName: "proxy.example.com"}, DNSNames: []string{"backend1.internal"}},
+		{IPAddresses: []net.IP{net.ParseIP("192.168.0.1")}},
+	} {
+		if _, err := ca.Sign(newCSR(t, denied)); err == nil {
+			t.Errorf("expected an error for the names %q %v %v", denied.Subject.CommonName, denied.DNSNames, denied.IPAddresses)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package internalca
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/containous/traefik/cluster"
+	"github.com/containous/traefik/log"
+)
+
+var _ cluster.Store = (*localStore)(nil)
+
+// localStore is a store using a file as storage
+type localStore struct {
+	file        string
+	storageLock sync.RWMutex
+	authority   *Authority
+}
+
+func newLocalStore(file string) *localStore {
+	return &localStore{
+		file: file,
+	}
+}
+
+// Get atomically a struct from the file storage
+func (s *localStore) Get() cluster.Object {
+	s.storageLock.RLock()
+	defer s.storageLock.RUnlock()
+	return s.authority
+}
+
+// Load loads file into store
+func (s *localStore) Load() (cluster.Object, error) {
+	s.storageLock.Lock()
+	defer s.storageLock.Unlock()
+	authority := &Authority{}
+	file, err := ioutil.ReadFile(s.file)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(file, authority); err != nil {
+		return nil, err
+	}
+	s.authority = authority
+	log.Infof("Loaded internal CA from store %s", s.file)
+	return authority, nil
+}
+
+// Begin creates a transaction with the file storage.
+func (s *localStore) Begin() (cluster.Transaction, cluster.Object, error) {
+	s.storageLock.Lock()
+	return &localTransaction{localStore: s}, s.authority, nil
+}
+
+var _ cluster.Transaction = (*localTransaction)(nil)
+
+type localTransaction struct {
+	*localStore
+	dirty bool
+}
+
+// Commit allows to set an object in the file storage
+func (t *localTransaction) Commit(object cluster.Object) error {
+	if t.dirty {
+		return fmt.Errorf("transaction already used, please begin a new one")
+	}
+	defer t.storageLock.Unlock()
+	t.localStore.authority = object.(*Authority)
+
+	data, err := json.MarshalIndent(object, "", "  ")
+	if err != nil {
+		return err
+	}
+	// the file holds the CA private key
+	if err := ioutil.WriteFile(t.file, data, 0600); err != nil {
+		return err
+	}
+	t.dirty = true
+	return nil
+}
@@ -0,0 +1,202 @@
+package ldap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// BER tags used by the LDAP messages (RFC 4511)
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x30
+	tagSet         = 0x31
+
+	tagBindRequest          = 0x60
+	tagBindResponse         = 0x61
+	tagUnbindRequest        = 0x42
+	tagSearchRequest        = 0x63
+	tagSearchResultEntry    = 0x64
+	tagSearchResultDone     = 0x65
+	tagSearchResultRef      = 0x73
+	tagExtendedRequest      = 0x77
+	tagExtendedResponse     = 0x78
+	tagSimpleAuthentication = 0x80
+	tagExtendedRequestName  = 0x80
+
+	constructed = 0x20
+	// maxPacketSize bounds the size of the messages read from the servers
+	maxPacketSize = 16 * 1024 * 1024
+)
+
+// packet is a BER element, holding either a value or children elements
+type packet struct {
+	tag      byte
+	value    []byte
+	children []*packet
+}
+
+func newSequence(tag byte, children ...*packet) *packet {
+	return &packet{tag: tag, children: children}
+}
+
+func newString(tag byte, value string) *packet {
+	return &packet{tag: tag, value: []byte(value)}
+}
+
+func newInteger(tag byte, value int64) *packet {
+	// minimal two's complement encoding
+	encoded := []byte{byte(value)}
+	for value > 127 || value < -128 {
+		value >>= 8
+		encoded = append([]byte{byte(value)}, encoded...)
+	}
+	return &packet{tag: tag, value: encoded}
+}
+
+func newBoolean(value bool) *packet {
+	if value {
+		return &packet{tag: tagBoolean, value: []byte{0xff}}
+	}
+	return &packet{tag: tagBoolean, value: []byte{0}}
+}
+
+func (p *packet) isConstructed() bool {
+	return p.tag&constructed != 0
+}
+
+// bytes returns the BER encoding of p
+func (p *packet) bytes() []byte {
+	value := p.value
+	if p.isConstructed() {
+		value = []byte{}
+		for _, child := range p.children {
+			value = append(value, child.bytes()...)
+		}
+	}
+	return append(append([]byte{p.tag}, encodeLength(len(value))...), value...)
+}
+
+func encodeLength(length int) []byte {
+	if length < 128 {
+		return []byte{byte(length)}
+	}
+	encoded := []byte{}
+	for ; length > 0; length >>= 8 {
+		encoded = append([]byte{byte(length)}, encoded...)
+	}
+	return append([]byte{0x80 | byte(len(encoded))}, encoded...)
+}
+
+// integer returns the value of an INTEGER or ENUMERATED packet
+func (p *packet) integer() (int64, error) {
+	if len(p.value) == 0 || len(p.value) > 8 {
+		return 0, fmt.Errorf("invalid integer of %d bytes", len(p.value))
+	}
+	value := int64(int8(p.value[0]))
+	for _, b := range p.value[1:] {
+		value = value<<8 | int64(b)
+	}
+	return value, nil
+}
+
+// child returns the child i of p, or an error if p has less children
+func (p *packet) child(i int) (*packet, error) {
+	if i >= len(p.children) {
+		return nil, fmt.Errorf("invalid element 0x%x, missing element %d", p.tag, i)
+	}
+	return p.children[i], nil
+}
+
+// readPacket reads a BER element from reader
+func readPacket(reader *bufio.Reader) (*packet, error) {
+	tag, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if tag&0x1f == 0x1f {
+		return nil, errors.New("unsupported multi-byte BER tag")
+	}
+	length, err := readLength(reader)
+	if err != nil {
+		return nil, err
+	}
+	value := make([]byte, length)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return nil, err
+	}
+	return decodePacket(tag, value)
+}
+
+func readLength(reader *bufio.Reader) (int, error) {
+	first, err := reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if first < 0x80 {
+		return int(first), nil
+	}
+	size := int(first & 0x7f)
+	if size == 0 || size > 4 {
+		return 0, errors.New("unsupported BER length")
+	}
+	length := 0
+	for i := 0; i < size; i++ {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		length = length<<8 | int(b)
+	}
+	if length > maxPacketSize {
+		return 0, fmt.Errorf("BER element of %d bytes is too large", length)
+	}
+	return length, nil
+}
+
+func decodePacket(tag byte, value []byte) (*packet, error) {
+	p := &packet{tag: tag, value: value}
+	if !p.isConstructed() {
+		return p, nil
+	}
+	for offset := 0; offset < len(value); {
+		if offset+2 > len(value) {
+			return nil, errors.New("truncated BER element")
+		}
+		childTag := value[offset]
+		length, lengthSize, err := decodeLength(value[offset+1:])
+		if err != nil {
+			return nil, err
+		}
+		start := offset + 1 + lengthSize
+		if start+length > len(value) || length < 0 {
+			return nil, errors.New("truncated BER element")
+		}
+		child, err := decodePacket(childTag, value[start:start+length])
+		if err != nil {
+			return nil, err
+		}
+		p.children = append(p.children, child)
+		offset = start + length
+	}
+	return p, nil
+}
+
+func decodeLength(data []byte) (int, int, error) {
+	if data[0] < 0x80 {
+		return int(data[0]), 1, nil
+	}
+	size := int(data[0] & 0x7f)
+	if size == 0 || size > 4 || len(data) < 1+size {
+		return 0, 0, errors.New("invalid BER length")
+	}
+	length := 0
+	for _, b := range data[1 : 1+size] {
+		length = length<<8 | int(b)
+	}
+	return length, 1 + size, nil
+}
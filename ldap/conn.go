@@ -0,0 +1,265 @@
+package ldap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// LDAP result codes
+const (
+	resultSuccess            = 0
+	resultInvalidCredentials = 49
+)
+
+// startTLSOID is the name of the StartTLS extended operation (RFC 4511)
+const startTLSOID = "1.3.6.1.4.1.1466.20037"
+
+// errInvalidCredentials is returned by bind when the server rejects the credentials
+var errInvalidCredentials = errors.New("invalid credentials")
+
+// entry is an entry returned by a search
+type entry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+// conn is a connection to an LDAP server, used by one request at a time
+type conn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	timeout   time.Duration
+	messageID int64
+}
+
+// parseURL returns the URL of an ldap:// or ldaps:// server, with its default port,
+// and its host name.
+func parseURL(rawURL string) (*url.URL, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		host, port = u.Host, ""
+	}
+	switch u.Scheme {
+	case "ldap":
+		if len(port) == 0 {
+			port = "389"
+		}
+	case "ldaps":
+		if len(port) == 0 {
+			port = "636"
+		}
+	default:
+		return nil, "", fmt.Errorf("invalid LDAP URL scheme %q, expected ldap or ldaps", u.Scheme)
+	}
+	if len(host) == 0 {
+		return nil, "", fmt.Errorf("invalid LDAP URL %q, missing host", rawURL)
+	}
+	u.Host = net.JoinHostPort(host, port)
+	return u, host, nil
+}
+
+// dial connects to the server at u, returned by parseURL.
+func dial(u *url.URL, startTLS bool, tlsConfig *tls.Config, timeout time.Duration) (*conn, error) {
+	netConn, err := net.DialTimeout("tcp", u.Host, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "ldaps" {
+		netConn = tls.Client(netConn, tlsConfig)
+	}
+	c := &conn{conn: netConn, reader: bufio.NewReader(netConn), timeout: timeout}
+	if startTLS && u.Scheme == "ldap" {
+		if err := c.startTLS(tlsConfig); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// startTLS upgrades the connection to TLS with the StartTLS extended operation.
+func (c *conn) startTLS(config *tls.Config) error {
+	response, err := c.request(newSequence(tagExtendedRequest, newString(tagExtendedRequestName, startTLSOID)), tagExtendedResponse)
+	if err != nil {
+		return err
+	}
+	if err := checkResult(response); err != nil {
+		return fmt.Errorf("StartTLS failed: %v", err)
+	}
+	tlsConn := tls.Client(c.conn, config)
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// bind authenticates the connection with a simple bind of dn and password.
+func (c *conn) bind(dn, password string) error {
+	request := newSequence(tagBindRequest,
+		newInteger(tagInteger, 3),
+		newString(tagOctetString, dn),
+		newString(tagSimpleAuthentication, password),
+	)
+	response, err := c.request(request, tagBindResponse)
+	if err != nil {
+		return err
+	}
+	return checkResult(response)
+}
+
+// search returns the entries matching filter in the subtree of baseDN, or
+// baseDN itself if baseObject is true.
+func (c *conn) search(baseDN string, baseObject bool, filter *packet, attributes []string) ([]*entry, error) {
+	scope := int64(2)
+	if baseObject {
+		scope = 0
+	}
+	attributesPacket := newSequence(tagSequence)
+	for _, attribute := range attributes {
+		attributesPacket.children = append(attributesPacket.children, newString(tagOctetString, attribute))
+	}
+	request := newSequence(tagSearchRequest,
+		newString(tagOctetString, baseDN),
+		newInteger(tagEnumerated, scope),
+		// never dereference aliases
+		newInteger(tagEnumerated, 0),
+		// two entries are enough to tell an ambiguous user
+		newInteger(tagInteger, 2),
+		newInteger(tagInteger, int64(c.timeout/time.Second)),
+		newBoolean(false),
+		filter,
+		attributesPacket,
+	)
+	messageID, err := c.send(request)
+	if err != nil {
+		return nil, err
+	}
+	entries := []*entry{}
+	for {
+		op, err := c.receive(messageID)
+		if err != nil {
+			return nil, err
+		}
+		switch op.tag {
+		case tagSearchResultEntry:
+			e, err := decodeEntry(op)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		case tagSearchResultRef:
+			// referrals are not followed
+		case tagSearchResultDone:
+			if err := checkResult(op); err != nil {
+				return nil, err
+			}
+			return entries, nil
+		default:
+			return nil, fmt.Errorf("unexpected LDAP response 0x%x to a search", op.tag)
+		}
+	}
+}
+
+// close unbinds and closes the connection.
+func (c *conn) close() {
+	c.send(&packet{tag: tagUnbindRequest})
+	c.conn.Close()
+}
+
+// request sends op and returns the response, which must have the tag responseTag.
+func (c *conn) request(op *packet, responseTag byte) (*packet, error) {
+	messageID, err := c.send(op)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.receive(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if response.tag != responseTag {
+		return nil, fmt.Errorf("unexpected LDAP response 0x%x, expected 0x%x", response.tag, responseTag)
+	}
+	return response, nil
+}
+
+func (c *conn) send(op *packet) (int64, error) {
+	c.messageID++
+	message := newSequence(tagSequence, newInteger(tagInteger, c.messageID), op)
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	_, err := c.conn.Write(message.bytes())
+	return c.messageID, err
+}
+
+func (c *conn) receive(messageID int64) (*packet, error) {
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	message, err := readPacket(c.reader)
+	if err != nil {
+		return nil, err
+	}
+	if message.tag != tagSequence || len(message.children) < 2 {
+		return nil, errors.New("invalid LDAP message")
+	}
+	id, err := message.children[0].integer()
+	if err != nil {
+		return nil, err
+	}
+	if id != messageID {
+		return nil, fmt.Errorf("unexpected LDAP message ID %d, expected %d", id, messageID)
+	}
+	return message.children[1], nil
+}
+
+// checkResult returns an error if the LDAPResult of response is not a success.
+func checkResult(response *packet) error {
+	if len(response.children) < 3 {
+		return errors.New("invalid LDAP result")
+	}
+	code, err := response.children[0].integer()
+	if err != nil {
+		return err
+	}
+	switch code {
+	case resultSuccess:
+		return nil
+	case resultInvalidCredentials:
+		return errInvalidCredentials
+	}
+	return fmt.Errorf("LDAP error %d: %s", code, response.children[2].value)
+}
+
+func decodeEntry(op *packet) (*entry, error) {
+	dn, err := op.child(0)
+	if err != nil {
+		return nil, err
+	}
+	e := &entry{dn: string(dn.value), attributes: map[string][]string{}}
+	attributes, err := op.child(1)
+	if err != nil {
+		return nil, err
+	}
+	for _, attribute := range attributes.children {
+		name, err := attribute.child(0)
+		if err != nil {
+			return nil, err
+		}
+		values, err := attribute.child(1)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values.children {
+			e.attributes[string(name.value)] = append(e.attributes[string(name.value)], string(value.value))
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,331 @@
+package ldap
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+const (
+	defaultUserFilter     = "(uid=%s)"
+	defaultGroupAttribute = "memberOf"
+	defaultPoolSize       = 4
+	defaultTimeout        = 5
+	// maxCachedUsers is the number of cached users above which the expired
+	// authentications are dropped.
+	maxCachedUsers = 10000
+)
+
+// ErrInvalidCredentials is returned by Authenticate when the username or password is wrong
+var ErrInvalidCredentials = errors.New("invalid LDAP credentials")
+
+// User is an authenticated LDAP user
+type User struct {
+	DN     string
+	Groups []string
+}
+
+// MemberOf returns true if u is a member of one of groups, given either by
+// their distinguished name or the value of their first RDN (i.e. their cn),
+// or if groups is empty.
+func (u *User) MemberOf(groups []string) bool {
+	if len(groups) == 0 {
+		return true
+	}
+	for _, group := range groups {
+		for _, userGroup := range u.Groups {
+			if strings.EqualFold(group, userGroup) || strings.EqualFold(group, firstRDNValue(userGroup)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Directory authenticates users against an LDAP server, keeping a pool of
+// connections and caching the successful authentications.
+type Directory struct {
+	config     types.LDAP
+	url        *url.URL
+	tlsConfig  *tls.Config
+	userFilter string
+	timeout    time.Duration
+	pool       chan *conn
+	mutex      sync.Mutex
+	salt       []byte
+	cache      map[string]*cachedUser
+}
+
+type cachedUser struct {
+	user     *User
+	password []byte
+	expires  time.Time
+}
+
+var (
+	directoriesMutex sync.Mutex
+	directories      = map[string]*Directory{}
+)
+
+// GetDirectory returns the Directory of config, shared by all the configurations
+// differing only by their groups or realm so that they share the connections
+// pool and the cache.
+func GetDirectory(config *types.LDAP) (*Directory, error) {
+	shared := *config
+	shared.Groups = nil
+	shared.Realm = ""
+	encoded, err := json.Marshal(shared)
+	if err != nil {
+		return nil, err
+	}
+	directoriesMutex.Lock()
+	defer directoriesMutex.Unlock()
+	if directory, ok := directories[string(encoded)]; ok {
+		return directory, nil
+	}
+	directory, err := NewDirectory(&shared)
+	if err != nil {
+		return nil, err
+	}
+	directories[string(encoded)] = directory
+	return directory, nil
+}
+
+// NewDirectory returns a Directory for config.
+func NewDirectory(config *types.LDAP) (*Directory, error) {
+	if len(config.URL) == 0 {
+		return nil, errors.New("LDAP URL is required")
+	}
+	u, host, err := parseURL(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.UserDN) == 0 && len(config.BaseDN) == 0 {
+		return nil, errors.New("LDAP userDN or baseDN is required")
+	}
+	d := &Directory{
+		config:     *config,
+		url:        u,
+		tlsConfig:  &tls.Config{ServerName: host, InsecureSkipVerify: config.InsecureSkipVerify},
+		userFilter: config.UserFilter,
+		timeout:    time.Duration(config.Timeout) * time.Second,
+		cache:      map[string]*cachedUser{},
+		salt:       make([]byte, 16),
+	}
+	if len(d.userFilter) == 0 {
+		d.userFilter = defaultUserFilter
+	}
+	if !strings.Contains(d.userFilter, "%s") {
+		return nil, fmt.Errorf("LDAP userFilter %q must contain %%s", d.userFilter)
+	}
+	if _, err := parseFilter(fmt.Sprintf(d.userFilter, "user")); err != nil {
+		return nil, err
+	}
+	if len(config.UserDN) > 0 && !strings.Contains(config.UserDN, "%s") {
+		return nil, fmt.Errorf("LDAP userDN %q must contain %%s", config.UserDN)
+	}
+	if len(d.config.GroupAttribute) == 0 {
+		d.config.GroupAttribute = defaultGroupAttribute
+	}
+	if d.timeout <= 0 {
+		d.timeout = defaultTimeout * time.Second
+	}
+	poolSize := config.PoolSize
+	if poolSize <= 0 {
+		poolSize = defaultPoolSize
+	}
+	d.pool = make(chan *conn, poolSize)
+	if len(config.CA) > 0 {
+		ca, err := ioutil.ReadFile(config.CA)
+		if err != nil {
+			return nil, err
+		}
+		d.tlsConfig.RootCAs = x509.NewCertPool()
+		if !d.tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid LDAP CA %s", config.CA)
+		}
+	}
+	if _, err := rand.Read(d.salt); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Authenticate checks the password of username, returning ErrInvalidCredentials
+// if it is wrong and another error if the server couldn't tell.
+func (d *Directory) Authenticate(username, password string) (*User, error) {
+	// an empty password would be an unauthenticated bind, which succeeds
+	if len(username) == 0 || len(password) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+	hashed := d.hash(password)
+	if user := d.cached(username, hashed, time.Now()); user != nil {
+		return user, nil
+	}
+
+	c, pooled, err := d.get()
+	if err != nil {
+		return nil, err
+	}
+	user, err := d.authenticate(c, username, password)
+	if err != nil && err != ErrInvalidCredentials && pooled {
+		// the server may have closed the idle connection, retry with a new one
+		c.close()
+		c, err = dial(d.url, d.config.StartTLS, d.tlsConfig, d.timeout)
+		if err != nil {
+			return nil, err
+		}
+		user, err = d.authenticate(c, username, password)
+	}
+	if err != nil && err != ErrInvalidCredentials {
+		c.close()
+		return nil, err
+	}
+	d.put(c)
+	if err != nil {
+		return nil, err
+	}
+	d.store(username, hashed, user, time.Now())
+	return user, nil
+}
+
+func (d *Directory) authenticate(c *conn, username, password string) (*User, error) {
+	user := &User{}
+	var groups []string
+	if len(d.config.UserDN) > 0 {
+		user.DN = fmt.Sprintf(d.config.UserDN, EscapeDN(username))
+	} else {
+		if err := c.bind(d.config.BindDN, d.config.BindPassword); err != nil {
+			if err == errInvalidCredentials {
+				return nil, errors.New("invalid LDAP bindDN credentials")
+			}
+			return nil, err
+		}
+		filter, err := parseFilter(fmt.Sprintf(d.userFilter, EscapeFilter(username)))
+		if err != nil {
+			return nil, err
+		}
+		entries, err := c.search(d.config.BaseDN, false, filter, []string{d.config.GroupAttribute})
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) != 1 {
+			return nil, ErrInvalidCredentials
+		}
+		user.DN = entries[0].dn
+		groups = entries[0].groupValues(d.config.GroupAttribute)
+	}
+
+	if err := c.bind(user.DN, password); err != nil {
+		if err == errInvalidCredentials {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if len(d.config.UserDN) > 0 {
+		filter, _ := parseFilter("(objectClass=*)")
+		entries, err := c.search(user.DN, true, filter, []string{d.config.GroupAttribute})
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			groups = entries[0].groupValues(d.config.GroupAttribute)
+		}
+	}
+	user.Groups = groups
+	return user, nil
+}
+
+// get returns a connection of the pool, or a new connection if the pool is empty
+func (d *Directory) get() (*conn, bool, error) {
+	select {
+	case c := <-d.pool:
+		return c, true, nil
+	default:
+		c, err := dial(d.url, d.config.StartTLS, d.tlsConfig, d.timeout)
+		return c, false, err
+	}
+}
+
+func (d *Directory) put(c *conn) {
+	select {
+	case d.pool <- c:
+	default:
+		c.close()
+	}
+}
+
+func (d *Directory) hash(password string) []byte {
+	hash := sha256.Sum256(append(append([]byte{}, d.salt...), password...))
+	return hash[:]
+}
+
+func (d *Directory) cached(username string, hashed []byte, now time.Time) *User {
+	if d.config.CacheTTL <= 0 {
+		return nil
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	cached, ok := d.cache[username]
+	if !ok || now.After(cached.expires) || subtle.ConstantTimeCompare(cached.password, hashed) != 1 {
+		return nil
+	}
+	return cached.user
+}
+
+func (d *Directory) store(username string, hashed []byte, user *User, now time.Time) {
+	if d.config.CacheTTL <= 0 {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(d.cache) >= maxCachedUsers {
+		for cachedUsername, cached := range d.cache {
+			if now.After(cached.expires) {
+				delete(d.cache, cachedUsername)
+			}
+		}
+	}
+	d.cache[username] = &cachedUser{user: user, password: hashed, expires: now.Add(time.Duration(d.config.CacheTTL) * time.Second)}
+}
+
+// groupValues returns the values of the attribute of e, whose name is case insensitive
+func (e *entry) groupValues(attribute string) []string {
+	for name, values := range e.attributes {
+		if strings.EqualFold(name, attribute) {
+			return values
+		}
+	}
+	return nil
+}
+
+// firstRDNValue returns the value of the first RDN of dn, "admins" for "cn=admins,dc=example,dc=org"
+func firstRDNValue(dn string) string {
+	end := len(dn)
+	for i := 0; i < len(dn); i++ {
+		if dn[i] == '\\' {
+			i++
+		} else if dn[i] == ',' || dn[i] == '+' {
+			end = i
+			break
+		}
+	}
+	rdn := dn[:end]
+	equal := strings.IndexByte(rdn, '=')
+	if equal < 0 {
+		return ""
+	}
+	return strings.TrimSpace(rdn[equal+1:])
+}
@@ -0,0 +1,160 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Filter choices (RFC 4511)
+const (
+	filterAnd            = 0xa0
+	filterOr             = 0xa1
+	filterNot            = 0xa2
+	filterEqualityMatch  = 0xa3
+	filterGreaterOrEqual = 0xa5
+	filterLessOrEqual    = 0xa6
+	filterPresent        = 0x87
+	filterApproxMatch    = 0xa8
+)
+
+// EscapeFilter escapes value for its use in an LDAP search filter (RFC 4515)
+func EscapeFilter(value string) string {
+	escaped := ""
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '*', '(', ')', '\\', 0:
+			escaped += fmt.Sprintf("\\%02x", c)
+		default:
+			escaped += string(c)
+		}
+	}
+	return escaped
+}
+
+// EscapeDN escapes value for its use as an attribute value of a distinguished name (RFC 4514)
+func EscapeDN(value string) string {
+	escaped := ""
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case strings.IndexByte(`,+"\<>;=`, c) >= 0:
+			escaped += "\\" + string(c)
+		case c == 0:
+			escaped += "\\00"
+		case i == 0 && (c == ' ' || c == '#'), i == len(value)-1 && c == ' ':
+			escaped += "\\" + string(c)
+		default:
+			escaped += string(c)
+		}
+	}
+	return escaped
+}
+
+// parseFilter parses the string representation of a search filter (RFC 4515).
+// The substrings filters are not supported.
+func parseFilter(filter string) (*packet, error) {
+	p, rest, err := parseFilterItem(strings.TrimSpace(filter))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP filter %q: %v", filter, err)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("invalid LDAP filter %q: unexpected %q", filter, rest)
+	}
+	return p, nil
+}
+
+func parseFilterItem(filter string) (*packet, string, error) {
+	if len(filter) < 2 || filter[0] != '(' {
+		return nil, "", fmt.Errorf("expected ( at %q", filter)
+	}
+	filter = filter[1:]
+	switch filter[0] {
+	case '&', '|':
+		tag := byte(filterAnd)
+		if filter[0] == '|' {
+			tag = filterOr
+		}
+		set := &packet{tag: tag}
+		filter = filter[1:]
+		for len(filter) > 0 && filter[0] == '(' {
+			child, rest, err := parseFilterItem(filter)
+			if err != nil {
+				return nil, "", err
+			}
+			set.children = append(set.children, child)
+			filter = rest
+		}
+		if len(set.children) == 0 {
+			return nil, "", fmt.Errorf("empty filter set")
+		}
+		return closeFilterItem(set, filter)
+	case '!':
+		child, rest, err := parseFilterItem(filter[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		return closeFilterItem(newSequence(filterNot, child), rest)
+	}
+
+	end := strings.IndexByte(filter, ')')
+	if end < 0 {
+		return nil, "", fmt.Errorf("missing ) in %q", filter)
+	}
+	item, rest := filter[:end], filter[end+1:]
+	equal := strings.IndexByte(item, '=')
+	if equal < 1 {
+		return nil, "", fmt.Errorf("invalid filter item %q", item)
+	}
+	attribute, value := item[:equal], item[equal+1:]
+	tag := byte(filterEqualityMatch)
+	switch attribute[len(attribute)-1] {
+	case '>':
+		tag = filterGreaterOrEqual
+	case '<':
+		tag = filterLessOrEqual
+	case '~':
+		tag = filterApproxMatch
+	}
+	if tag != filterEqualityMatch {
+		attribute = attribute[:len(attribute)-1]
+	}
+	if tag == filterEqualityMatch && value == "*" {
+		return newString(filterPresent, attribute), rest, nil
+	}
+	if strings.IndexByte(value, '*') >= 0 {
+		return nil, "", fmt.Errorf("substrings filters are not supported")
+	}
+	unescaped, err := unescapeFilterValue(value)
+	if err != nil {
+		return nil, "", err
+	}
+	return newSequence(tag, newString(tagOctetString, attribute), newString(tagOctetString, unescaped)), rest, nil
+}
+
+func closeFilterItem(p *packet, filter string) (*packet, string, error) {
+	if len(filter) == 0 || filter[0] != ')' {
+		return nil, "", fmt.Errorf("missing ) at %q", filter)
+	}
+	return p, filter[1:], nil
+}
+
+func unescapeFilterValue(value string) (string, error) {
+	unescaped := []byte{}
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			unescaped = append(unescaped, value[i])
+			continue
+		}
+		if i+2 >= len(value) {
+			return "", fmt.Errorf("invalid escape in %q", value)
+		}
+		decoded, err := hex.DecodeString(value[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %q", value)
+		}
+		unescaped = append(unescaped, decoded...)
+		i += 2
+	}
+	return string(unescaped), nil
+}
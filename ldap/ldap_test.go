@@ -0,0 +1,281 @@
+package ldap
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServer is a minimal LDAP server, with the users of dc=example,dc=org
+type fakeServer struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	binds    int
+	down     bool
+}
+
+var fakeUsers = map[string]string{
+	"cn=admin,dc=example,dc=org":            "adminpw",
+	"uid=alice,ou=people,dc=example,dc=org": "alicepw",
+	"uid=bob,ou=people,dc=example,dc=org":   "bobpw",
+}
+
+var fakeGroups = map[string][]string{
+	"uid=alice,ou=people,dc=example,dc=org": {"cn=admins,ou=groups,dc=example,dc=org"},
+	"uid=bob,ou=people,dc=example,dc=org":   {"cn=users,ou=groups,dc=example,dc=org"},
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{listener: listener}
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) url() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *fakeServer) bindCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.binds
+}
+
+func (s *fakeServer) serve(c net.Conn) {
+	defer c.Close()
+	reader := bufio.NewReader(c)
+	for {
+		message, err := readPacket(reader)
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		down := s.down
+		s.mutex.Unlock()
+		if down {
+			return
+		}
+		id := message.children[0]
+		op := message.children[1]
+		switch op.tag {
+		case tagBindRequest:
+			s.mutex.Lock()
+			s.binds++
+			s.mutex.Unlock()
+			dn, password := string(op.children[1].value), string(op.children[2].value)
+			code := int64(resultSuccess)
+			if expected, ok := fakeUsers[dn]; !ok || expected != password {
+				code = resultInvalidCredentials
+			}
+			c.Write(fakeResult(id, tagBindResponse, code))
+		case tagSearchRequest:
+			base := string(op.children[0].value)
+			scope, _ := op.children[1].integer()
+			filter := op.children[6]
+			for dn := range fakeUsers {
+				if scope == 0 && dn != base || scope != 0 && !fakeMatch(dn, filter) {
+					continue
+				}
+				values := newSequence(tagSet)
+				for _, group := range fakeGroups[dn] {
+					values.children = append(values.children, newString(tagOctetString, group))
+				}
+				entry := newSequence(tagSearchResultEntry,
+					newString(tagOctetString, dn),
+					newSequence(tagSequence, newSequence(tagSequence, newString(tagOctetString, "memberOf"), values)),
+				)
+				c.Write(newSequence(tagSequence, id, entry).bytes())
+			}
+			c.Write(fakeResult(id, tagSearchResultDone, resultSuccess))
+		case tagUnbindRequest:
+			return
+		}
+	}
+}
+
+// fakeMatch matches the uid of dn against the (uid=...) filter, possibly in an and filter
+func fakeMatch(dn string, filter *packet) bool {
+	if filter.tag == filterAnd {
+		for _, child := range filter.children {
+			if !fakeMatch(dn, child) {
+				return false
+			}
+		}
+		return true
+	}
+	if filter.tag == filterEqualityMatch && string(filter.children[0].value) == "uid" {
+		return strings.HasPrefix(dn, "uid="+string(filter.children[1].value)+",")
+	}
+	return true
+}
+
+func fakeResult(id *packet, tag byte, code int64) []byte {
+	return newSequence(tagSequence, id, newSequence(tag,
+		newInteger(tagEnumerated, code),
+		newString(tagOctetString, ""),
+		newString(tagOctetString, ""),
+	)).bytes()
+}
+
+func TestDirectorySearchAndBind(t *testing.T) {
+	server := newFakeServer(t)
+	defer server.listener.Close()
+
+	directory, err := NewDirectory(&types.LDAP{
+		URL:          server.url(),
+		BindDN:       "cn=admin,dc=example,dc=org",
+		BindPassword: "adminpw",
+		BaseDN:       "ou=people,dc=example,dc=org",
+		UserFilter:   "(&(objectClass=person)(uid=%s))",
+	})
+	assert.NoError(t, err)
+
+	user, err := directory.Authenticate("alice", "alicepw")
+	assert.NoError(t, err)
+	assert.Equal(t, "uid=alice,ou=people,dc=example,dc=org", user.DN)
+	assert.True(t, user.MemberOf([]string{"ADMINS"}))
+	assert.True(t, user.MemberOf([]string{"cn=admins,ou=groups,dc=example,dc=org"}))
+	assert.False(t, user.MemberOf([]string{"users"}))
+
+	_, err = directory.Authenticate("alice", "bobpw")
+	assert.Equal(t, ErrInvalidCredentials, err)
+	_, err = directory.Authenticate("carol", "carolpw")
+	assert.Equal(t, ErrInvalidCredentials, err)
+	_, err = directory.Authenticate("alice", "")
+	assert.Equal(t, ErrInvalidCredentials, err)
+	_, err = directory.Authenticate("*", "alicepw")
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
+
+func TestDirectoryUserDN(t *testing.T) {
+	server := newFakeServer(t)
+	defer server.listener.Close()
+
+	directory, err := NewDirectory(&types.LDAP{
+		URL:    server.url(),
+		UserDN: "uid=%s,ou=people,dc=example,dc=org",
+	})
+	assert.NoError(t, err)
+
+	user, err := directory.Authenticate("bob", "bobpw")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cn=users,ou=groups,dc=example,dc=org"}, user.Groups)
+	_, err = directory.Authenticate("bob,ou=people,dc=example,dc=org", "bobpw")
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
+
+func TestDirectoryCache(t *testing.T) {
+	server := newFakeServer(t)
+	defer server.listener.Close()
+
+	directory, err := NewDirectory(&types.LDAP{
+		URL:      server.url(),
+		UserDN:   "uid=%s,ou=people,dc=example,dc=org",
+		CacheTTL: 60,
+	})
+	assert.NoError(t, err)
+
+	_, err = directory.Authenticate("alice", "alicepw")
+	assert.NoError(t, err)
+	binds := server.bindCount()
+	_, err = directory.Authenticate("alice", "alicepw")
+	assert.NoError(t, err)
+	assert.Equal(t, binds, server.bindCount(), "the authentication should be cached")
+
+	_, err = directory.Authenticate("alice", "wrong")
+	assert.Equal(t, ErrInvalidCredentials, err, "a cached user should not be authenticated with another password")
+	assert.Equal(t, binds+1, server.bindCount())
+}
+
+func TestDirectoryUnavailable(t *testing.T) {
+	server := newFakeServer(t)
+	defer server.listener.Close()
+
+	directory, err := NewDirectory(&types.LDAP{
+		URL:    server.url(),
+		UserDN: "uid=%s,ou=people,dc=example,dc=org",
+	})
+	assert.NoError(t, err)
+	_, err = directory.Authenticate("alice", "alicepw")
+	assert.NoError(t, err)
+
+	server.mutex.Lock()
+	server.down = true
+	server.mutex.Unlock()
+	_, err = directory.Authenticate("alice", "alicepw")
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrInvalidCredentials, err)
+}
+
+func TestGetDirectory(t *testing.T) {
+	first, err := GetDirectory(&types.LDAP{URL: "ldap://localhost", UserDN: "uid=%s,dc=org", Groups: []string{"a"}})
+	assert.NoError(t, err)
+	second, err := GetDirectory(&types.LDAP{URL: "ldap://localhost", UserDN: "uid=%s,dc=org", Groups: []string{"b"}, Realm: "b"})
+	assert.NoError(t, err)
+	assert.True(t, first == second, "the directories should be shared")
+	third, err := GetDirectory(&types.LDAP{URL: "ldap://localhost", UserDN: "uid=%s,dc=com"})
+	assert.NoError(t, err)
+	assert.False(t, first == third)
+
+	_, err = GetDirectory(&types.LDAP{URL: "http://localhost", UserDN: "uid=%s,dc=org"})
+	assert.Error(t, err)
+	_, err = GetDirectory(&types.LDAP{URL: "ldap://localhost", BaseDN: "dc=org", UserFilter: "(uid=%s"})
+	assert.Error(t, err)
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := parseFilter("(&(objectClass=person)(!(uid=a\\2ab))(|(cn>=a)(mail=*)))")
+	assert.NoError(t, err)
+	assert.Equal(t, byte(filterAnd), filter.tag)
+	assert.Len(t, filter.children, 3)
+	assert.Equal(t, byte(filterNot), filter.children[1].tag)
+	assert.Equal(t, "a*b", string(filter.children[1].children[0].children[1].value))
+	assert.Equal(t, byte(filterGreaterOrEqual), filter.children[2].children[0].tag)
+	assert.Equal(t, byte(filterPresent), filter.children[2].children[1].tag)
+
+	for _, invalid := range []string{"", "uid=a", "(uid=a", "(uid=a*)", "(&)", "(uid=a)(cn=b)", "(uid=\\2)"} {
+		_, err := parseFilter(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, "a\\2a\\28b\\29\\5c", EscapeFilter("a*(b)\\"))
+	assert.Equal(t, "\\ a\\,b\\+c\\=d\\ ", EscapeDN(" a,b+c=d "))
+	assert.Equal(t, "\\#a", EscapeDN("#a"))
+	assert.Equal(t, "admins", firstRDNValue("cn=admins,ou=groups,dc=example,dc=org"))
+	assert.Equal(t, "a\\,b", firstRDNValue("cn=a\\,b,dc=org"))
+}
+
+func TestBERRoundTrip(t *testing.T) {
+	for _, value := range []int64{0, 1, 127, 128, 255, 256, 65535, -1, -129, 1 << 40} {
+		p := newInteger(tagInteger, value)
+		decoded, err := decodePacket(p.tag, p.value)
+		assert.NoError(t, err)
+		integer, err := decoded.integer()
+		assert.NoError(t, err)
+		assert.Equal(t, value, integer)
+	}
+	long := newSequence(tagSequence, newString(tagOctetString, strings.Repeat("a", 300)))
+	encoded := long.bytes()
+	decoded, err := readPacket(bufio.NewReader(strings.NewReader(string(encoded))))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 300), string(decoded.children[0].value))
+}
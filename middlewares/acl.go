@@ -0,0 +1,136 @@
+package middlewares
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/ryanuber/go-glob"
+)
+
+const (
+	aclAllow = "allow"
+	aclDeny  = "deny"
+)
+
+// ACL is a middleware authorizing the requests of a frontend with its access
+// control list: the first rule matching the method, the path, the user and the
+// IP of a request allows or denies it, and the requests matching no rule get
+// the default action. The denied requests are answered 403.
+type ACL struct {
+	rules         []aclRule
+	defaultAction string
+}
+
+type aclRule struct {
+	methods map[string]bool
+	paths   []string
+	users   map[string]bool
+	ips     []*net.IPNet
+	action  string
+}
+
+// NewACL returns an ACL enforcing config.
+func NewACL(config *types.ACL) (*ACL, error) {
+	acl := &ACL{defaultAction: strings.ToLower(config.DefaultAction)}
+	if len(acl.defaultAction) == 0 {
+		acl.defaultAction = aclAllow
+	}
+	if acl.defaultAction != aclAllow && acl.defaultAction != aclDeny {
+		return nil, fmt.Errorf("invalid ACL default action %q, expected allow or deny", config.DefaultAction)
+	}
+	for i, ruleConfig := range config.Rules {
+		rule := aclRule{action: strings.ToLower(ruleConfig.Action), paths: ruleConfig.Paths}
+		if rule.action != aclAllow && rule.action != aclDeny {
+			return nil, fmt.Errorf("invalid action %q of ACL rule %d, expected allow or deny", ruleConfig.Action, i)
+		}
+		if len(ruleConfig.Methods) > 0 {
+			rule.methods = map[string]bool{}
+			for _, method := range ruleConfig.Methods {
+				rule.methods[strings.ToUpper(method)] = true
+			}
+		}
+		if len(ruleConfig.Users) > 0 {
+			rule.users = map[string]bool{}
+			for _, user := range ruleConfig.Users {
+				rule.users[user] = true
+			}
+		}
+		for _, ip := range ruleConfig.IPs {
+			if !strings.Contains(ip, "/") {
+				if strings.Contains(ip, ":") {
+					ip += "/128"
+				} else {
+					ip += "/32"
+				}
+			}
+			_, ipNet, err := net.ParseCIDR(ip)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IP range of ACL rule %d: %v", i, err)
+			}
+			rule.ips = append(rule.ips, ipNet)
+		}
+		acl.rules = append(acl.rules, rule)
+	}
+	return acl, nil
+}
+
+// allowed returns whether the ACL allows r.
+func (a *ACL) allowed(r *http.Request) bool {
+	for _, rule := range a.rules {
+		if rule.match(r) {
+			return rule.action == aclAllow
+		}
+	}
+	return a.defaultAction == aclAllow
+}
+
+func (rule *aclRule) match(r *http.Request) bool {
+	if rule.methods != nil && !rule.methods[r.Method] {
+		return false
+	}
+	if len(rule.paths) > 0 {
+		matched := false
+		for _, pattern := range rule.paths {
+			if glob.Glob(pattern, r.URL.Path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if rule.users != nil && !rule.users[GetAuthenticatedUser(r)] {
+		return false
+	}
+	if len(rule.ips) > 0 {
+		ip := net.ParseIP(lockoutClientIP(r))
+		if ip == nil {
+			return false
+		}
+		for _, ipNet := range rule.ips {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Handler returns a handler passing the requests allowed by the ACL to next.
+func (a *ACL) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !a.allowed(r) {
+			log.Debugf("ACL denied %s %s to %q from %s", r.Method, r.URL.Path, GetAuthenticatedUser(r), r.RemoteAddr)
+			SetErrorReason(r, ReasonACLDenied)
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestACL(t *testing.T) {
+	acl, err := NewACL(&types.ACL{
+		Rules: []types.ACLRule{
+			{Paths: []string{"/admin/*"}, Users: []string{"admin"}, IPs: []string{"10.0.0.0/8", "192.168.1.1"}, Action: "allow"},
+			{Paths: []string{"/admin/*"}, Action: "deny"},
+			{Methods: []string{"get", "HEAD"}, Action: "allow"},
+		},
+		DefaultAction: "deny",
+	})
+	assert.NoError(t, err)
+	handler := acl.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}))
+
+	cases := []struct {
+		desc       string
+		method     string
+		path       string
+		user       string
+		remoteAddr string
+		expected   int
+	}{
+		{desc: "read", method: "GET", path: "/users", remoteAddr: "1.2.3.4:1234", expected: http.StatusOK},
+		{desc: "write", method: "POST", path: "/users", remoteAddr: "1.2.3.4:1234", expected: http.StatusForbidden},
+		{desc: "admin", method: "POST", path: "/admin/users", user: "admin", remoteAddr: "10.1.2.3:1234", expected: http.StatusOK},
+		{desc: "admin single IP", method: "POST", path: "/admin/users", user: "admin", remoteAddr: "192.168.1.1:1234", expected: http.StatusOK},
+		{desc: "admin from another network", method: "GET", path: "/admin/users", user: "admin", remoteAddr: "1.2.3.4:1234", expected: http.StatusForbidden},
+		{desc: "another user", method: "GET", path: "/admin/users", user: "reader", remoteAddr: "10.1.2.3:1234", expected: http.StatusForbidden},
+		{desc: "anonymous", method: "GET", path: "/admin/users", remoteAddr: "10.1.2.3:1234", expected: http.StatusForbidden},
+	}
+	for _, c := range cases {
+		request := WithRequestInfo(httptest.NewRequest(c.method, c.path, nil))
+		request.RemoteAddr = c.remoteAddr
+		setAuthenticatedUser(request, c.user)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, c.expected, recorder.Code, c.desc)
+		if c.expected == http.StatusForbidden {
+			assert.Equal(t, ReasonACLDenied, GetErrorReason(request), c.desc)
+		}
+	}
+
+	// allowed by default
+	acl, err = NewACL(&types.ACL{Rules: []types.ACLRule{{Methods: []string{"DELETE"}, Action: "deny"}}})
+	assert.NoError(t, err)
+	assert.True(t, acl.allowed(httptest.NewRequest("GET", "/", nil)))
+	assert.False(t, acl.allowed(httptest.NewRequest("DELETE", "/", nil)))
+
+	_, err = NewACL(&types.ACL{DefaultAction: "reject"})
+	assert.Error(t, err)
+	_, err = NewACL(&types.ACL{Rules: []types.ACLRule{{Action: "permit"}}})
+	assert.Error(t, err)
+	_, err = NewACL(&types.ACL{Rules: []types.ACLRule{{IPs: []string{"10.0.0.0/33"}, Action: "allow"}}})
+	assert.Error(t, err)
+}
@@ -4,37 +4,117 @@ import (
 	"fmt"
 	"github.com/abbot/go-http-auth"
 	"github.com/codegangsta/negroni"
+	"github.com/containous/traefik/ldap"
 	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/totp"
 	"github.com/containous/traefik/types"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// Authenticator is a middleware that provides HTTP basic and digest authentication
+// Authenticator is a middleware that provides HTTP basic, digest and LDAP authentication,
+// with an optional TOTP second factor and single sign-on
 type Authenticator struct {
 	handler negroni.Handler
 	users   map[string]string
+	totp    *totp.Verifier
+	sso     *sso
+	lockout *AuthLockout
 }
 
 // NewAuthenticator builds a new Autenticator given a config
 func NewAuthenticator(authConfig *types.Auth) (*Authenticator, error) {
+	var lockout *AuthLockout
+	if authConfig != nil {
+		if config := authConfig.LockoutConfig(); config != nil {
+			lockout = NewAuthLockout(config)
+		}
+	}
+	return NewAuthenticatorWithLockout(authConfig, lockout)
+}
+
+// NewAuthenticatorWithLockout builds a new Autenticator given a config, locking
+// out the clients failing the authentication or its second factor with lockout
+// if it is not nil.
+func NewAuthenticatorWithLockout(authConfig *types.Auth, lockout *AuthLockout) (*Authenticator, error) {
 	if authConfig == nil {
 		return nil, fmt.Errorf("Error creating Authenticator: auth is nil")
 	}
 	var err error
-	authenticator := Authenticator{}
+	authenticator := Authenticator{lockout: lockout}
+	if authConfig.TOTP != nil {
+		authenticator.totp, err = totp.GetVerifier(authConfig.TOTP)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if authConfig.Basic != nil {
 		authenticator.users, err = parserBasicUsers(authConfig.Basic.Users)
 		if err != nil {
 			return nil, err
 		}
-		basicAuth := auth.NewBasicAuthenticator("traefik", authenticator.secretBasic)
+		basic := *authConfig.Basic
+		if len(basic.Realm) == 0 {
+			basic.Realm = "traefik"
+		}
+		basicAuth := auth.NewBasicAuthenticator(basic.Realm, authenticator.secretBasic)
 		authenticator.handler = negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+			if authenticator.lockedOut(w, r) {
+				return
+			}
 			if username := basicAuth.CheckAuth(r); username == "" {
 				log.Debugf("Auth failed...")
-				basicAuth.RequireAuth(w, r)
-			} else {
-				next.ServeHTTP(w, r)
+				SetErrorReason(r, ReasonAuthFailed)
+				authenticator.failure(r)
+				requireBasicAuth(w, &basic)
+			} else if authenticator.checkSecondFactor(w, r, username) {
+				authenticator.serveAuthenticated(w, r, username, next)
+			}
+		})
+	} else if authConfig.LDAP != nil {
+		directory, err := ldap.GetDirectory(authConfig.LDAP)
+		if err != nil {
+			return nil, err
+		}
+		basic := types.Basic{Realm: authConfig.LDAP.Realm}
+		if len(basic.Realm) == 0 {
+			basic.Realm = "traefik"
+		}
+		groups := authConfig.LDAP.Groups
+		authenticator.handler = negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+			if authenticator.lockedOut(w, r) {
+				return
+			}
+			username, password, ok := r.BasicAuth()
+			if !ok {
+				SetErrorReason(r, ReasonAuthFailed)
+				requireBasicAuth(w, &basic)
+				return
+			}
+			user, err := directory.Authenticate(username, password)
+			if err == ldap.ErrInvalidCredentials {
+				log.Debugf("LDAP auth failed for %s", username)
+				SetErrorReason(r, ReasonAuthFailed)
+				authenticator.failure(r)
+				requireBasicAuth(w, &basic)
+				return
+			}
+			if err != nil {
+				log.Errorf("Error authenticating %s with LDAP: %v", username, err)
+				SetErrorReason(r, ReasonAuthUnavailable)
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+			if !user.MemberOf(groups) {
+				log.Debugf("LDAP user %s is not a member of the required groups", user.DN)
+				SetErrorReason(r, ReasonAuthFailed)
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			if authenticator.checkSecondFactor(w, r, username) {
+				authenticator.serveAuthenticated(w, r, username, next)
 			}
 		})
 	} else if authConfig.Digest != nil {
@@ -44,16 +124,113 @@ func NewAuthenticator(authConfig *types.Auth) (*Authenticator, error) {
 		}
 		digestAuth := auth.NewDigestAuthenticator("traefik", authenticator.secretDigest)
 		authenticator.handler = negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+			if authenticator.lockedOut(w, r) {
+				return
+			}
 			if username, _ := digestAuth.CheckAuth(r); username == "" {
+				SetErrorReason(r, ReasonAuthFailed)
+				authenticator.failure(r)
 				digestAuth.RequireAuth(w, r)
-			} else {
+			} else if authenticator.checkSecondFactor(w, r, username) {
+				authenticator.serveAuthenticated(w, r, username, next)
+			}
+		})
+	}
+	if authConfig.SSO != nil {
+		if authenticator.handler == nil {
+			return nil, fmt.Errorf("Error creating Authenticator: SSO requires a basic, digest or LDAP authentication")
+		}
+		authenticator.sso, err = newSSO(authConfig.SSO)
+		if err != nil {
+			return nil, err
+		}
+		handler := authenticator.handler
+		authenticator.handler = negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+			if user := authenticator.sso.user(r, time.Now()); len(user) > 0 {
+				setAuthenticatedUser(r, user)
 				next.ServeHTTP(w, r)
+				return
 			}
+			handler.ServeHTTP(w, r, next)
 		})
 	}
 	return &authenticator, nil
 }
 
+// lockedOut returns true if the client of r is locked out, sending the 429 response.
+func (a *Authenticator) lockedOut(w http.ResponseWriter, r *http.Request) bool {
+	if a.lockout == nil {
+		return false
+	}
+	remaining := a.lockout.check(r, time.Now())
+	if remaining <= 0 {
+		return false
+	}
+	log.Debugf("Auth locked out for %s", remaining)
+	SetErrorReason(r, ReasonAuthLockedOut)
+	w.Header().Set("Retry-After", strconv.Itoa(int((remaining+time.Second-1)/time.Second)))
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+	return true
+}
+
+// failure records an authentication failure of the client of r for the lockout.
+func (a *Authenticator) failure(r *http.Request) {
+	if a.lockout != nil {
+		a.lockout.failure(r, time.Now())
+	}
+}
+
+// serveAuthenticated serves the request of the authenticated username with
+// next, issuing its SSO cookie first.
+func (a *Authenticator) serveAuthenticated(w http.ResponseWriter, r *http.Request, username string, next http.HandlerFunc) {
+	if a.lockout != nil {
+		a.lockout.success(r)
+	}
+	setAuthenticatedUser(r, username)
+	if a.sso != nil {
+		a.sso.issue(w, r, username, time.Now())
+	}
+	next.ServeHTTP(w, r)
+}
+
+// checkSecondFactor returns true if the request of username has its one-time
+// password, or if no second factor is required. Otherwise it sends the error response.
+func (a *Authenticator) checkSecondFactor(w http.ResponseWriter, r *http.Request, username string) bool {
+	if a.totp == nil {
+		return true
+	}
+	ok, err := a.totp.Verify(username, a.totp.RequestCode(r), time.Now())
+	if err != nil {
+		log.Errorf("Error loading the TOTP seed of %s: %v", username, err)
+		SetErrorReason(r, ReasonAuthUnavailable)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return false
+	}
+	if !ok {
+		log.Debugf("TOTP auth failed for %s", username)
+		SetErrorReason(r, ReasonAuthFailed)
+		a.failure(r)
+		http.Error(w, "401 Unauthorized: missing or invalid one-time password", http.StatusUnauthorized)
+	}
+	return ok
+}
+
+// requireBasicAuth sends the 401 response of a basic authentication failure.
+func requireBasicAuth(w http.ResponseWriter, basic *types.Basic) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="`+strings.Replace(basic.Realm, `"`, `\"`, -1)+`"`)
+	if len(basic.FailureBody) == 0 {
+		http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	contentType := basic.FailureContentType
+	if len(contentType) == 0 {
+		contentType = "text/plain; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusUnauthorized)
+	w.Write([]byte(basic.FailureBody))
+}
+
 func parserBasicUsers(users types.Users) (map[string]string, error) {
 	userMap := make(map[string]string)
 	for _, user := range users {
@@ -1,14 +1,19 @@
 package middlewares
 
 import (
+	"bufio"
 	"fmt"
 	"github.com/codegangsta/negroni"
 	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 )
 
 func TestBasicAuthFail(t *testing.T) {
@@ -101,3 +106,227 @@ func TestDigestAuthFail(t *testing.T) {
 	assert.NoError(t, err, "there should be no error")
 	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "they should be equal")
 }
+
+func TestBasicAuthFailureBody(t *testing.T) {
+	authMiddleware, err := NewAuthenticator(&types.Auth{
+		Basic: &types.Basic{
+			Users:              []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"},
+			Realm:              "admin",
+			FailureBody:        `{"error": "authentication required"}`,
+			FailureContentType: "application/json",
+		},
+	})
+	assert.NoError(t, err, "there should be no error")
+
+	n := negroni.New(authMiddleware)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	recorder := httptest.NewRecorder()
+	n.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, `Basic realm="admin"`, recorder.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `{"error": "authentication required"}`, recorder.Body.String())
+}
+
+func TestBasicAuthLockout(t *testing.T) {
+	lockout := NewAuthLockout(&types.Lockout{MaxFailures: 2, Duration: 60, MaxDuration: 100})
+	authMiddleware, err := NewAuthenticatorWithLockout(&types.Auth{
+		Basic: &types.Basic{
+			Users: []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"},
+		},
+	}, lockout)
+	assert.NoError(t, err, "there should be no error")
+	n := negroni.New(authMiddleware)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(remoteAddr, password string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.RemoteAddr = remoteAddr
+		request.SetBasicAuth("test", password)
+		recorder := httptest.NewRecorder()
+		n.ServeHTTP(recorder, request)
+		return recorder
+	}
+	assert.Equal(t, http.StatusUnauthorized, serve("192.0.2.1:1000", "wrong").Code)
+	// a success resets the failures
+	assert.Equal(t, http.StatusOK, serve("192.0.2.1:1000", "test").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("192.0.2.1:1001", "wrong").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("192.0.2.1:1002", "wrong").Code)
+
+	// locked out, even with the right password
+	recorder := serve("192.0.2.1:1003", "test")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "60", recorder.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, serve("192.0.2.2:1000", "test").Code)
+	assert.Equal(t, &AuthLockoutStats{LockedOutClients: 1, Lockouts: 1, RejectedRequests: 1}, lockout.Stats())
+}
+
+func TestAuthLockoutDurations(t *testing.T) {
+	lockout := NewAuthLockout(&types.Lockout{MaxFailures: 1, Duration: 60, MaxDuration: 100})
+	request := httptest.NewRequest("GET", "/", nil)
+	now := time.Now()
+	lockout.failure(request, now)
+	assert.Equal(t, 60*time.Second, lockout.check(request, now))
+	// the duration doubles on each lockout, up to the max duration
+	now = now.Add(time.Minute)
+	lockout.failure(request, now)
+	assert.Equal(t, 100*time.Second, lockout.check(request, now))
+	now = now.Add(100 * time.Second)
+	assert.Equal(t, time.Duration(0), lockout.check(request, now))
+}
+
+func TestLDAPAuthUnavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	// nothing listens anymore on the address
+	listener.Close()
+	authMiddleware, err := NewAuthenticator(&types.Auth{
+		LDAP: &types.LDAP{
+			URL:    "ldap://" + listener.Addr().String(),
+			UserDN: "uid=%s,dc=example,dc=org",
+			Realm:  "directory",
+		},
+	})
+	assert.NoError(t, err, "there should be no error")
+	n := negroni.New(authMiddleware)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	recorder := httptest.NewRecorder()
+	n.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, `Basic realm="directory"`, recorder.Header().Get("WWW-Authenticate"))
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.SetBasicAuth("test", "test")
+	recorder = httptest.NewRecorder()
+	n.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
+
+func TestBasicAuthTOTP(t *testing.T) {
+	seeds, err := ioutil.TempFile("", "totp")
+	assert.NoError(t, err)
+	defer os.Remove(seeds.Name())
+	// base32 of 12345678901234567890
+	seeds.WriteString("test:GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ\n")
+	seeds.Close()
+
+	lockout := NewAuthLockout(&types.Lockout{MaxFailures: 2})
+	authMiddleware, err := NewAuthenticatorWithLockout(&types.Auth{
+		Basic: &types.Basic{Users: []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}},
+		TOTP:  &types.TOTP{SeedsFile: seeds.Name()},
+	}, lockout)
+	assert.NoError(t, err, "there should be no error")
+	n := negroni.New(authMiddleware)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(code string) int {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.SetBasicAuth("test", "test")
+		request.Header.Set("X-TOTP-Code", code)
+		recorder := httptest.NewRecorder()
+		n.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, serve(""))
+	assert.Equal(t, http.StatusUnauthorized, serve("000000"))
+	// the second factor failures count for the lockout
+	assert.Equal(t, http.StatusTooManyRequests, serve("000000"))
+}
+
+// serveFakeLDAP answers the LDAP binds of any password with a success, and the
+// searches with no entry, until listener is closed.
+func serveFakeLDAP(listener net.Listener) {
+	for {
+		c, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func(c net.Conn) {
+			defer c.Close()
+			reader := bufio.NewReader(c)
+			for {
+				header := make([]byte, 2)
+				if _, err := io.ReadFull(reader, header); err != nil {
+					return
+				}
+				length := int(header[1])
+				if length >= 0x80 {
+					size := make([]byte, length&0x7f)
+					if _, err := io.ReadFull(reader, size); err != nil {
+						return
+					}
+					length = 0
+					for _, b := range size {
+						length = length<<8 | int(b)
+					}
+				}
+				message := make([]byte, length)
+				if _, err := io.ReadFull(reader, message); err != nil {
+					return
+				}
+				// the message ID, then the operation
+				id := message[:2+int(message[1])]
+				var responseTag byte
+				switch message[len(id)] {
+				case 0x60:
+					responseTag = 0x61
+				case 0x63:
+					responseTag = 0x65
+				default:
+					return
+				}
+				// success result code, without matched DN nor diagnostic message
+				result := []byte{responseTag, 7, 0x0a, 1, 0, 0x04, 0, 0x04, 0}
+				response := append([]byte{0x30, byte(len(id) + len(result))}, id...)
+				c.Write(append(response, result...))
+			}
+		}(c)
+	}
+}
+
+func TestLDAPAuthTOTPLockout(t *testing.T) {
+	seeds, err := ioutil.TempFile("", "totp")
+	assert.NoError(t, err)
+	defer os.Remove(seeds.Name())
+	seeds.WriteString("test:GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ\n")
+	seeds.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	go serveFakeLDAP(listener)
+
+	authMiddleware, err := NewAuthenticator(&types.Auth{
+		LDAP: &types.LDAP{
+			URL:     "ldap://" + listener.Addr().String(),
+			UserDN:  "uid=%s,dc=example,dc=org",
+			Lockout: &types.Lockout{MaxFailures: 3},
+		},
+		TOTP: &types.TOTP{SeedsFile: seeds.Name()},
+	})
+	assert.NoError(t, err, "there should be no error")
+	n := negroni.New(authMiddleware)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(code string) int {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.SetBasicAuth("test", "test")
+		request.Header.Set("X-TOTP-Code", code)
+		recorder := httptest.NewRecorder()
+		n.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, serve(fmt.Sprintf("%06d", i)), "the password is valid, not the code")
+	}
+	assert.Equal(t, http.StatusTooManyRequests, serve("000003"))
+}
+
+func TestAuthLockoutConfig(t *testing.T) {
+	lockout := &types.Lockout{MaxFailures: 3}
+	assert.Equal(t, lockout, (&types.Auth{Digest: &types.Digest{Lockout: lockout}}).LockoutConfig())
+	assert.Equal(t, lockout, (&types.Auth{LDAP: &types.LDAP{Lockout: lockout}}).LockoutConfig())
+	assert.Nil(t, (&types.Auth{Basic: &types.Basic{}}).LockoutConfig())
+	assert.Equal(t, &types.Lockout{}, (&types.Auth{Basic: &types.Basic{}, TOTP: &types.TOTP{}}).LockoutConfig(), "the TOTP codes can't be guessed")
+}
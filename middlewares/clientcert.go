@@ -0,0 +1,113 @@
+package middlewares
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/ryanuber/go-glob"
+)
+
+// ClientCertChecker is a middleware verifying the client certificates of the
+// requests of a frontend against its own CAs, so that the frontends sharing an
+// entrypoint can require different client certificates, or none.
+type ClientCertChecker struct {
+	pool     *x509.CertPool
+	optional bool
+	allowed  []string
+}
+
+// NewClientCertChecker returns a ClientCertChecker verifying the client
+// certificates with the CAs of the files of config.
+func NewClientCertChecker(config *types.ClientCA) (*ClientCertChecker, error) {
+	if len(config.Files) == 0 {
+		return nil, fmt.Errorf("no client CA file")
+	}
+	c := &ClientCertChecker{pool: x509.NewCertPool(), optional: config.Optional, allowed: config.AllowedSubjects}
+	for _, file := range config.Files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid certificate in %s: %v", file, err)
+			}
+			c.pool.AddCert(certificate)
+			found = true
+		}
+		if !found {
+			return nil, fmt.Errorf("no certificate in %s", file)
+		}
+	}
+	return c, nil
+}
+
+// Handler returns a handler passing the requests with a valid client certificate to next.
+func (c *ClientCertChecker) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			if c.optional {
+				next.ServeHTTP(rw, r)
+				return
+			}
+			SetErrorReason(r, ReasonClientCertMissing)
+			http.Error(rw, "Client certificate required", http.StatusForbidden)
+			return
+		}
+		if err := c.verify(r.TLS.PeerCertificates); err != nil {
+			log.Debugf("Rejecting the client certificate of %s: %v", r.TLS.PeerCertificates[0].Subject.CommonName, err)
+			SetErrorReason(r, ReasonClientCertInvalid)
+			http.Error(rw, "Invalid client certificate", http.StatusForbidden)
+			return
+		}
+		if info := getRequestInfo(r); info != nil {
+			info.clientCertVerified = true
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// verify checks that the first of certificates is issued by the CAs, with the
+// other certificates as intermediates, and that its subject is allowed.
+func (c *ClientCertChecker) verify(certificates []*x509.Certificate) error {
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+	leaf := certificates[0]
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         c.pool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return err
+	}
+	if len(c.allowed) == 0 {
+		return nil
+	}
+	subjects := append([]string{leaf.Subject.CommonName}, leaf.DNSNames...)
+	subjects = append(subjects, leaf.EmailAddresses...)
+	for _, ip := range leaf.IPAddresses {
+		subjects = append(subjects, ip.String())
+	}
+	for _, pattern := range c.allowed {
+		for _, subject := range subjects {
+			if len(subject) > 0 && glob.Glob(strings.ToLower(pattern), strings.ToLower(subject)) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("subject %s not allowed", strings.Join(subjects, ", "))
+}
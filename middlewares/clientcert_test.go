@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateTestClientCert(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return certificate, key
+}
+
+func TestClientCertChecker(t *testing.T) {
+	ca, caKey := generateTestClientCert(t, "ca", nil, nil)
+	otherCA, otherCAKey := generateTestClientCert(t, "other ca", nil, nil)
+	client, _ := generateTestClientCert(t, "client1.example.com", ca, caKey)
+	denied, _ := generateTestClientCert(t, "client2.test", ca, caKey)
+	foreign, _ := generateTestClientCert(t, "client1.example.com", otherCA, otherCAKey)
+
+	caFile, err := ioutil.TempFile("", "clientca")
+	assert.NoError(t, err)
+	defer os.Remove(caFile.Name())
+	pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})
+	caFile.Close()
+
+	checker, err := NewClientCertChecker(&types.ClientCA{Files: []string{caFile.Name()}, AllowedSubjects: []string{"*.example.com"}})
+	assert.NoError(t, err)
+	handler := checker.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}))
+
+	for _, test := range []struct {
+		certificate *x509.Certificate
+		status      int
+		reason      string
+	}{
+		{nil, http.StatusForbidden, ReasonClientCertMissing},
+		{client, http.StatusOK, ""},
+		{denied, http.StatusForbidden, ReasonClientCertInvalid},
+		{foreign, http.StatusForbidden, ReasonClientCertInvalid},
+	} {
+		request := WithRequestInfo(httptest.NewRequest("GET", "/", nil))
+		request.TLS = &tls.ConnectionState{}
+		if test.certificate != nil {
+			request.TLS.PeerCertificates = []*x509.Certificate{test.certificate}
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, test.status, recorder.Code)
+		assert.Equal(t, test.reason, GetErrorReason(request))
+	}
+
+	optional, err := NewClientCertChecker(&types.ClientCA{Files: []string{caFile.Name()}, Optional: true})
+	assert.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	optional.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	_, err = NewClientCertChecker(&types.ClientCA{Files: []string{os.DevNull}})
+	assert.Error(t, err)
+}
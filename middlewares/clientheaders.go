@@ -0,0 +1,129 @@
+package middlewares
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+// ClientHeaders is a middleware forwarding the verified client certificate of
+// the requests to the backends in request headers, so that they can authorize
+// the requests on the mTLS identity of the clients. The headers sent by the
+// clients are always removed, a certificate is forwarded only when verified by
+// the entrypoint or by the client CA of the frontend.
+type ClientHeaders struct {
+	config types.ClientHeaders
+}
+
+// NewClientHeaders returns a ClientHeaders setting the headers of config.
+func NewClientHeaders(config *types.ClientHeaders) (*ClientHeaders, error) {
+	headers := []string{config.Certificate, config.Subject, config.SANs, config.Serial, config.NotAfter}
+	empty := true
+	for _, header := range headers {
+		if strings.ContainsAny(header, " :\r\n") {
+			return nil, fmt.Errorf("invalid client header name %q", header)
+		}
+		empty = empty && len(header) == 0
+	}
+	if empty {
+		return nil, fmt.Errorf("no client header")
+	}
+	return &ClientHeaders{config: *config}, nil
+}
+
+// ClientCertVerified returns true if the client certificate of r was verified,
+// during the TLS handshake or by the client CA of the frontend.
+func ClientCertVerified(r *http.Request) bool {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return false
+	}
+	if len(r.TLS.VerifiedChains) > 0 {
+		return true
+	}
+	info := getRequestInfo(r)
+	return info != nil && info.clientCertVerified
+}
+
+// Handler returns a handler setting the headers of the client certificate of the requests passed to next.
+func (c *ClientHeaders) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		values := map[string]string{}
+		if ClientCertVerified(r) {
+			certificate := r.TLS.PeerCertificates[0]
+			sans := append([]string{}, certificate.DNSNames...)
+			sans = append(sans, certificate.EmailAddresses...)
+			for _, ip := range certificate.IPAddresses {
+				sans = append(sans, ip.String())
+			}
+			values[c.config.Certificate] = base64.StdEncoding.EncodeToString(certificate.Raw)
+			values[c.config.Subject] = subjectDN(certificate.Subject)
+			values[c.config.SANs] = strings.Join(sans, ",")
+			values[c.config.Serial] = certificate.SerialNumber.String()
+			values[c.config.NotAfter] = certificate.NotAfter.UTC().Format(time.RFC3339)
+		}
+		for _, header := range []string{c.config.Certificate, c.config.Subject, c.config.SANs, c.config.Serial, c.config.NotAfter} {
+			if len(header) == 0 {
+				continue
+			}
+			r.Header.Del(header)
+			if value := values[header]; len(value) > 0 {
+				r.Header.Set(header, value)
+			}
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// dnAttributeTypes are the short names of the RFC 2253 attribute types.
+var dnAttributeTypes = map[string]string{
+	"2.5.4.3":  "CN",
+	"2.5.4.5":  "SERIALNUMBER",
+	"2.5.4.6":  "C",
+	"2.5.4.7":  "L",
+	"2.5.4.8":  "ST",
+	"2.5.4.9":  "STREET",
+	"2.5.4.10": "O",
+	"2.5.4.11": "OU",
+	"2.5.4.17": "POSTALCODE",
+}
+
+// dnEscaper escapes the special characters of the RFC 2253 attribute values.
+var dnEscaper = strings.NewReplacer(`,`, `\,`, `+`, `\+`, `"`, `\"`, `\`, `\\`, `<`, `\<`, `>`, `\>`, `;`, `\;`)
+
+// subjectDN returns the RFC 2253 string of the subject of a parsed
+// certificate, such as CN=client1,OU=ops, its attributes in the reverse
+// order of the certificate. The attributes without short name are written
+// as their OID and the hex of their DER value.
+func subjectDN(subject pkix.Name) string {
+	attributes := make([]string, 0, len(subject.Names))
+	for i := len(subject.Names) - 1; i >= 0; i-- {
+		attribute := subject.Names[i]
+		oid := attribute.Type.String()
+		name, ok := dnAttributeTypes[oid]
+		value, isString := attribute.Value.(string)
+		if !ok || !isString {
+			der, err := asn1.Marshal(attribute.Value)
+			if err != nil {
+				continue
+			}
+			attributes = append(attributes, oid+"=#"+hex.EncodeToString(der))
+			continue
+		}
+		value = dnEscaper.Replace(value)
+		if strings.HasPrefix(value, "#") || strings.HasPrefix(value, " ") {
+			value = `\` + value
+		}
+		if strings.HasSuffix(value, " ") {
+			value = value[:len(value)-1] + `\ `
+		}
+		attributes = append(attributes, name+"="+value)
+	}
+	return strings.Join(attributes, ",")
+}
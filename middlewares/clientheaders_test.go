@@ -0,0 +1,82 @@
+package middlewares
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientHeaders(t *testing.T) {
+	ca, caKey := generateTestClientCert(t, "ca", nil, nil)
+	client, _ := generateTestClientCert(t, "client1", ca, caKey)
+
+	clientHeaders, err := NewClientHeaders(&types.ClientHeaders{
+		Certificate: "X-Forwarded-Tls-Client-Cert",
+		Subject:     "X-Forwarded-Tls-Client-Subject",
+		NotAfter:    "X-Forwarded-Tls-Client-Not-After",
+	})
+	assert.NoError(t, err)
+	var forwarded http.Header
+	handler := clientHeaders.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header
+	}))
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}, VerifiedChains: [][]*x509.Certificate{{client, ca}}}
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(client.Raw), forwarded.Get("X-Forwarded-Tls-Client-Cert"))
+	assert.Equal(t, "CN=client1", forwarded.Get("X-Forwarded-Tls-Client-Subject"))
+	assert.Equal(t, client.NotAfter.UTC().Format(time.RFC3339), forwarded.Get("X-Forwarded-Tls-Client-Not-After"))
+
+	// the headers of the clients are removed, and the certificates not verified aren't forwarded
+	request = WithRequestInfo(httptest.NewRequest("GET", "/", nil))
+	request.Header.Set("X-Forwarded-Tls-Client-Subject", "CN=admin")
+	request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	assert.Empty(t, forwarded.Get("X-Forwarded-Tls-Client-Subject"))
+
+	// unless verified by the client CA of the frontend
+	getRequestInfo(request).clientCertVerified = true
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	assert.Equal(t, "CN=client1", forwarded.Get("X-Forwarded-Tls-Client-Subject"))
+
+	_, err = NewClientHeaders(&types.ClientHeaders{})
+	assert.Error(t, err)
+	_, err = NewClientHeaders(&types.ClientHeaders{Subject: "X-Client Subject"})
+	assert.Error(t, err)
+}
+
+func TestSubjectDN(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Country:            []string{"FR"},
+			Organization:       []string{"Example, Inc."},
+			OrganizationalUnit: []string{"ops"},
+			CommonName:         "#client1 ",
+			ExtraNames:         []pkix.AttributeTypeAndValue{{Type: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}, Value: "ops@example.com"}},
+		},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	assert.Equal(t, `1.2.840.113549.1.9.1=#0c0f6f7073406578616d706c652e636f6d,CN=\#client1\ ,OU=ops,O=Example\, Inc.,C=FR`, subjectDN(certificate.Subject))
+}
@@ -0,0 +1,159 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/containous/traefik/types"
+)
+
+// defaultCoalesceMaxBodySize is the largest response body shared with the
+// coalesced requests, when the frontend doesn't set one.
+const defaultCoalesceMaxBodySize = 1 << 20
+
+// Coalescer is a middleware coalescing the concurrent identical GET and HEAD
+// requests of a frontend into a single request to its backend, whose response
+// is sent to all of them, so that a cache stampede reaches the backend once.
+// The requests are identical when they have the same method, host, URL,
+// Authorization and Cookie headers, and values of the Vary headers of the
+// frontend. A response larger than MaxBodySize is not shared: the waiting
+// requests are then forwarded to the backend one by one.
+type Coalescer struct {
+	varyHeaders []string
+	maxBodySize int64
+	lock        sync.Mutex
+	calls       map[string]*coalescedCall
+}
+
+// coalescedCall is the response of the request forwarded to the backend for
+// the identical requests received meanwhile.
+type coalescedCall struct {
+	done     chan struct{}
+	status   int
+	header   http.Header
+	body     bytes.Buffer
+	overflow bool
+}
+
+// NewCoalescer returns a Coalescer of the requests identical for config.
+// The extraVaryHeaders also tell the requests apart, like the header bucketing
+// the users of an experiment.
+func NewCoalescer(config *types.Coalesce, extraVaryHeaders ...string) (*Coalescer, error) {
+	if config.MaxBodySize < 0 {
+		return nil, errors.New("invalid negative coalescing max body size")
+	}
+	c := &Coalescer{maxBodySize: config.MaxBodySize, calls: map[string]*coalescedCall{}}
+	if c.maxBodySize == 0 {
+		c.maxBodySize = defaultCoalesceMaxBodySize
+	}
+	for _, header := range append(append([]string{"Authorization", "Cookie"}, config.VaryHeaders...), extraVaryHeaders...) {
+		if len(header) > 0 {
+			c.varyHeaders = append(c.varyHeaders, http.CanonicalHeaderKey(header))
+		}
+	}
+	return c, nil
+}
+
+// key returns the key of the requests identical to r, or false if r can't be coalesced.
+func (c *Coalescer) key(r *http.Request) (string, bool) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		return "", false
+	}
+	if r.ContentLength != 0 || r.Header.Get("Upgrade") != "" {
+		return "", false
+	}
+	key := []string{r.Method, r.Host, r.URL.RequestURI()}
+	for _, header := range c.varyHeaders {
+		key = append(key, strings.Join(r.Header[header], "\n"))
+	}
+	return strings.Join(key, "\x00"), true
+}
+
+// Handler returns a handler coalescing the identical requests forwarded to next.
+func (c *Coalescer) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		key, ok := c.key(r)
+		if !ok {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		c.lock.Lock()
+		if call, ok := c.calls[key]; ok {
+			c.lock.Unlock()
+			<-call.done
+			if call.overflow || call.status == 0 {
+				next.ServeHTTP(rw, r)
+				return
+			}
+			for name, values := range call.header {
+				rw.Header()[name] = values
+			}
+			rw.WriteHeader(call.status)
+			rw.Write(call.body.Bytes())
+			return
+		}
+		call := &coalescedCall{done: make(chan struct{})}
+		c.calls[key] = call
+		c.lock.Unlock()
+
+		defer func() {
+			c.lock.Lock()
+			delete(c.calls, key)
+			c.lock.Unlock()
+			close(call.done)
+		}()
+		next.ServeHTTP(&coalescingWriter{ResponseWriter: rw, call: call, maxBodySize: c.maxBodySize}, r)
+	})
+}
+
+// coalescingWriter writes the response of the forwarded request, and keeps a
+// copy of it for the coalesced requests.
+type coalescingWriter struct {
+	http.ResponseWriter
+	call        *coalescedCall
+	maxBodySize int64
+}
+
+func (w *coalescingWriter) WriteHeader(status int) {
+	if w.call.status == 0 {
+		w.call.status = status
+		w.call.header = make(http.Header, len(w.Header()))
+		for name, values := range w.Header() {
+			w.call.header[name] = append([]string(nil), values...)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *coalescingWriter) Write(data []byte) (int, error) {
+	if w.call.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.call.overflow {
+		if int64(w.call.body.Len()+len(data)) > w.maxBodySize {
+			w.call.overflow = true
+			w.call.body = bytes.Buffer{}
+		} else {
+			w.call.body.Write(data)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush sends the buffered data of the forwarded response to its client.
+func (w *coalescingWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// CloseNotify returns the close notifications of the connection of the forwarded request.
+func (w *coalescingWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
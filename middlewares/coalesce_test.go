@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalescer(t *testing.T) {
+	coalescer, err := NewCoalescer(&types.Coalesce{VaryHeaders: []string{"accept-encoding"}, MaxBodySize: 10})
+	assert.NoError(t, err)
+	var calls int32
+	started := make(chan bool, 1)
+	release := make(chan bool)
+	handler := coalescer.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/slow" || r.URL.Path == "/large" {
+			started <- true
+			<-release
+		}
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.WriteHeader(http.StatusAccepted)
+		if r.URL.Path == "/large" {
+			rw.Write([]byte(strings.Repeat("x", 11)))
+			return
+		}
+		rw.Write([]byte("response"))
+	}))
+	serve := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(method, path, nil)
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// the identical requests received during the forwarded one share its response
+	var wg sync.WaitGroup
+	recorders := make([]*httptest.ResponseRecorder, 5)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		recorders[0] = serve("GET", "/slow", nil)
+	}()
+	<-started
+	for i := 1; i < len(recorders); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recorders[i] = serve("GET", "/slow", nil)
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	release <- true
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, recorder := range recorders {
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+		assert.Equal(t, "text/plain", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "response", recorder.Body.String())
+	}
+
+	// the requests differing by their vary headers or credentials, and the other methods, are not coalesced
+	atomic.StoreInt32(&calls, 0)
+	assert.Equal(t, http.StatusAccepted, serve("GET", "/", map[string]string{"Accept-Encoding": "gzip"}).Code)
+	assert.Equal(t, http.StatusAccepted, serve("GET", "/", map[string]string{"Authorization": "Bearer token"}).Code)
+	assert.Equal(t, http.StatusAccepted, serve("POST", "/", nil).Code)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	_, ok := coalescer.key(httptest.NewRequest("POST", "/", nil))
+	assert.False(t, ok)
+	key1, _ := coalescer.key(httptest.NewRequest("GET", "/", nil))
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("Cookie", "session=1")
+	key2, _ := coalescer.key(request)
+	assert.NotEqual(t, key1, key2)
+
+	// the responses larger than the max body size are not shared
+	atomic.StoreInt32(&calls, 0)
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- serve("GET", "/large", nil)
+	}()
+	<-started
+	go func() {
+		done <- serve("GET", "/large", nil)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	release <- true
+	<-started
+	release <- true
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, 11, (<-done).Body.Len())
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	_, err = NewCoalescer(&types.Coalesce{MaxBodySize: -1})
+	assert.Error(t, err)
+}
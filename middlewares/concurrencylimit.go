@@ -0,0 +1,101 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/vulcand/oxy/utils"
+)
+
+// ConcurrencyLimiter is a middleware limiting the in-flight requests of each
+// client of a frontend, identified by the extractor of its configuration,
+// whatever their rate: the requests over the limit are answered 429 until the
+// previous ones of the client complete. The requests without a value for the
+// extractor share a single limit.
+type ConcurrencyLimiter struct {
+	lock      sync.Mutex
+	amount    int64
+	extractor utils.SourceExtractor
+	inFlight  map[string]int64
+}
+
+// NewConcurrencyLimiter returns a ConcurrencyLimiter enforcing config.
+func NewConcurrencyLimiter(config *types.ConcurrencyLimit) (*ConcurrencyLimiter, error) {
+	c := &ConcurrencyLimiter{inFlight: map[string]int64{}}
+	if err := c.SetConfig(config); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SetConfig replaces the limit and the extractor of c, keeping the in-flight
+// requests counted.
+func (c *ConcurrencyLimiter) SetConfig(config *types.ConcurrencyLimit) error {
+	if config.Amount <= 0 {
+		return errors.New("the concurrency limit amount must be positive")
+	}
+	extractorFunc := config.ExtractorFunc
+	if len(extractorFunc) == 0 {
+		extractorFunc = "client.ip"
+	}
+	extractor, err := utils.NewExtractor(extractorFunc)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.amount = config.Amount
+	c.extractor = extractor
+	return nil
+}
+
+// InFlight returns the number of in-flight requests of each client.
+func (c *ConcurrencyLimiter) InFlight() map[string]int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	inFlight := make(map[string]int64, len(c.inFlight))
+	for key, count := range c.inFlight {
+		inFlight[key] = count
+	}
+	return inFlight
+}
+
+func (c *ConcurrencyLimiter) acquire(r *http.Request) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key, _, err := c.extractor.Extract(r)
+	if err != nil {
+		key = ""
+	}
+	if c.inFlight[key] >= c.amount {
+		return key, false
+	}
+	c.inFlight[key]++
+	return key, true
+}
+
+func (c *ConcurrencyLimiter) release(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.inFlight[key]--; c.inFlight[key] <= 0 {
+		delete(c.inFlight, key)
+	}
+}
+
+// Handler returns a handler limiting the in-flight requests of each client to next.
+func (c *ConcurrencyLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		key, ok := c.acquire(r)
+		if !ok {
+			log.Debugf("Rejecting the request to %s: too many in-flight requests for %q", r.URL.Path, key)
+			SetErrorReason(r, ReasonConcurrencyLimit)
+			http.Error(rw, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		defer c.release(key)
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	limiter, err := NewConcurrencyLimiter(&types.ConcurrencyLimit{Amount: 1, ExtractorFunc: "request.header.X-Api-Key"})
+	assert.NoError(t, err)
+	started := make(chan bool)
+	release := make(chan bool)
+	handler := limiter.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- true
+			<-release
+		}
+	}))
+	request := func(path, apiKey string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := WithRequestInfo(httptest.NewRequest("GET", path, nil))
+		request.Header.Set("X-Api-Key", apiKey)
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	done := make(chan int)
+	go func() {
+		done <- request("/slow", "key1").Code
+	}()
+	<-started
+	assert.Equal(t, map[string]int64{"key1": 1}, limiter.InFlight())
+
+	recorder := request("/", "key1")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	// the other clients aren't limited
+	assert.Equal(t, http.StatusOK, request("/", "key2").Code)
+
+	release <- true
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Empty(t, limiter.InFlight())
+	assert.Equal(t, http.StatusOK, request("/", "key1").Code)
+
+	_, err = NewConcurrencyLimiter(&types.ConcurrencyLimit{})
+	assert.Error(t, err)
+	_, err = NewConcurrencyLimiter(&types.ConcurrencyLimit{Amount: 1, ExtractorFunc: "request.unknown"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,121 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"sort"
+
+	"github.com/containous/traefik/types"
+)
+
+const (
+	// ExperimentHeader is the request header holding the experiment name sent to the backends
+	ExperimentHeader = "X-Traefik-Experiment"
+	// VariantHeader is the request header holding the assigned variant sent to the backends
+	VariantHeader = "X-Traefik-Variant"
+	// variantCookiePrefix prefixes the cookie holding the assigned variant, for the clients and the analytics
+	variantCookiePrefix = "_traefik_experiment_"
+)
+
+type experimentVariant struct {
+	name    string
+	weight  uint32
+	handler http.Handler
+}
+
+// Experiment is a middleware assigning the requests to the variants of an A/B test
+type Experiment struct {
+	name        string
+	header      string
+	cookie      string
+	variants    []experimentVariant
+	totalWeight uint32
+}
+
+// NewExperiment returns a new Experiment. handlers holds the handler of each variant of experiment.
+func NewExperiment(experiment *types.Experiment, handlers map[string]http.Handler) (*Experiment, error) {
+	if len(experiment.Name) == 0 {
+		return nil, errors.New("experiment name must be set")
+	}
+	e := &Experiment{name: experiment.Name, header: experiment.Header, cookie: experiment.Cookie}
+	names := []string{}
+	for name := range experiment.Variants {
+		names = append(names, name)
+	}
+	// variants are sorted so that a user stays in the same bucket across reloads
+	sort.Strings(names)
+	for _, name := range names {
+		variant := experiment.Variants[name]
+		if variant.Weight < 0 {
+			return nil, fmt.Errorf("negative weight %d for variant %s", variant.Weight, name)
+		}
+		if handlers[name] == nil {
+			return nil, fmt.Errorf("no handler for variant %s", name)
+		}
+		e.variants = append(e.variants, experimentVariant{name: name, weight: uint32(variant.Weight), handler: handlers[name]})
+		e.totalWeight += uint32(variant.Weight)
+	}
+	if e.totalWeight == 0 {
+		return nil, errors.New("experiment must have a variant with a positive weight")
+	}
+	return e, nil
+}
+
+func (e *Experiment) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	variant := e.assign(r)
+	r.Header.Set(ExperimentHeader, e.name)
+	r.Header.Set(VariantHeader, variant.name)
+	if cookie, err := r.Cookie(e.cookieName()); err != nil || cookie.Value != variant.name {
+		http.SetCookie(rw, &http.Cookie{Name: e.cookieName(), Value: variant.name, Path: "/"})
+	}
+	variant.handler.ServeHTTP(rw, r)
+}
+
+func (e *Experiment) cookieName() string {
+	return variantCookiePrefix + e.name
+}
+
+// assign returns the variant of the user identifying the request. The clients
+// that can't be identified keep the variant of their cookie, or get a random one.
+func (e *Experiment) assign(r *http.Request) *experimentVariant {
+	if user := e.user(r); len(user) > 0 {
+		hash := fnv.New32a()
+		hash.Write([]byte(e.name + ":" + user))
+		return e.pick(hash.Sum32() % e.totalWeight)
+	}
+	if cookie, err := r.Cookie(e.cookieName()); err == nil {
+		for i := range e.variants {
+			if e.variants[i].name == cookie.Value && e.variants[i].weight > 0 {
+				return &e.variants[i]
+			}
+		}
+	}
+	return e.pick(uint32(rand.Int63n(int64(e.totalWeight))))
+}
+
+func (e *Experiment) user(r *http.Request) string {
+	if len(e.header) > 0 {
+		if user := r.Header.Get(e.header); len(user) > 0 {
+			return user
+		}
+	}
+	if len(e.cookie) > 0 {
+		if cookie, err := r.Cookie(e.cookie); err == nil {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+func (e *Experiment) pick(bucket uint32) *experimentVariant {
+	for i := range e.variants {
+		if bucket < e.variants[i].weight {
+			return &e.variants[i]
+		}
+		bucket -= e.variants[i].weight
+	}
+	return &e.variants[len(e.variants)-1]
+}
@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func variantHandler(name string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(name + " " + r.Header.Get(ExperimentHeader) + " " + r.Header.Get(VariantHeader)))
+	})
+}
+
+func newTestExperiment(t *testing.T) *Experiment {
+	experiment, err := NewExperiment(&types.Experiment{
+		Name:   "checkout",
+		Header: "X-User-Id",
+		Cookie: "session",
+		Variants: map[string]types.Variant{
+			"control": {Weight: 1},
+			"new":     {Weight: 1, Backend: "backend2"},
+			"retired": {Weight: 0},
+		},
+	}, map[string]http.Handler{
+		"control": variantHandler("backend1"),
+		"new":     variantHandler("backend2"),
+		"retired": variantHandler("backend1"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return experiment
+}
+
+func TestExperimentDeterministicBucketing(t *testing.T) {
+	experiment := newTestExperiment(t)
+	variants := map[string]int{}
+	for i := 0; i < 200; i++ {
+		user := strings.Repeat("u", i%50+1)
+		var body string
+		for j := 0; j < 3; j++ {
+			request := httptest.NewRequest("GET", "/", nil)
+			request.Header.Set("X-User-Id", user)
+			recorder := httptest.NewRecorder()
+			experiment.ServeHTTP(recorder, request)
+			if j > 0 {
+				assert.Equal(t, body, recorder.Body.String(), "user %s changed variant", user)
+			}
+			body = recorder.Body.String()
+		}
+		variants[body]++
+	}
+	assert.Len(t, variants, 2)
+	assert.NotContains(t, variants, "backend1 checkout retired")
+	for body := range variants {
+		assert.Contains(t, []string{"backend1 checkout control", "backend2 checkout new"}, body)
+	}
+}
+
+func TestExperimentCookies(t *testing.T) {
+	experiment := newTestExperiment(t)
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	recorder := httptest.NewRecorder()
+	experiment.ServeHTTP(recorder, request)
+	variant := strings.Fields(recorder.Body.String())[2]
+	assert.Contains(t, recorder.Header().Get("Set-Cookie"), "_traefik_experiment_checkout="+variant)
+
+	// an anonymous client keeps the variant of its cookie
+	for _, name := range []string{"control", "new"} {
+		request = httptest.NewRequest("GET", "/", nil)
+		request.AddCookie(&http.Cookie{Name: "_traefik_experiment_checkout", Value: name})
+		recorder = httptest.NewRecorder()
+		experiment.ServeHTTP(recorder, request)
+		assert.Equal(t, name, strings.Fields(recorder.Body.String())[2])
+		assert.Empty(t, recorder.Header().Get("Set-Cookie"))
+	}
+
+	// a retired variant is reassigned
+	request = httptest.NewRequest("GET", "/", nil)
+	request.AddCookie(&http.Cookie{Name: "_traefik_experiment_checkout", Value: "retired"})
+	recorder = httptest.NewRecorder()
+	experiment.ServeHTTP(recorder, request)
+	assert.NotEqual(t, "retired", strings.Fields(recorder.Body.String())[2])
+	assert.NotEmpty(t, recorder.Header().Get("Set-Cookie"))
+}
+
+func TestNewExperimentErrors(t *testing.T) {
+	handlers := map[string]http.Handler{"a": variantHandler("backend1")}
+	cases := []*types.Experiment{
+		{Variants: map[string]types.Variant{"a": {Weight: 1}}},
+		{Name: "e", Variants: map[string]types.Variant{"a": {Weight: 0}}},
+		{Name: "e", Variants: map[string]types.Variant{"a": {Weight: -1}}},
+		{Name: "e", Variants: map[string]types.Variant{"b": {Weight: 1}}},
+	}
+	for _, experiment := range cases {
+		_, err := NewExperiment(experiment, handlers)
+		assert.Error(t, err, "%+v", experiment)
+	}
+}
@@ -0,0 +1,308 @@
+package middlewares
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abbot/go-http-auth"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/ryanuber/go-glob"
+)
+
+// ForwardProxy is a middleware making an entrypoint the egress proxy of its
+// clients: it tunnels the CONNECT requests, and forwards the requests with an
+// absolute URI, to the allowed destinations. The other requests are routed as
+// usual. The denied destinations are answered 403, and the clients failing
+// the proxy authentication 407.
+type ForwardProxy struct {
+	// first for the 64-bit alignment of the atomic operations
+	denied       int64
+	authFailures int64
+	destinations []*egressDestination
+	basicAuth    *auth.BasicAuth
+	dialer       *net.Dialer
+	proxy        *httputil.ReverseProxy
+}
+
+// egressDestination is an allowed destination pattern, and the egress counters
+// of the destinations it matches.
+type egressDestination struct {
+	stats   EgressStats
+	pattern string
+	host    string
+	port    string
+}
+
+// EgressStats are the egress counters of an allowed destination pattern of a
+// forward proxy.
+type EgressStats struct {
+	Requests      int64 `json:"requests"`
+	Tunnels       int64 `json:"tunnels"`
+	Errors        int64 `json:"errors"`
+	BytesSent     int64 `json:"bytesSent"`
+	BytesReceived int64 `json:"bytesReceived"`
+}
+
+// ForwardProxyStats are the egress counters of a forward proxy, by allowed
+// destination pattern.
+type ForwardProxyStats struct {
+	Destinations map[string]EgressStats `json:"destinations"`
+	Denied       int64                  `json:"denied"`
+	AuthFailures int64                  `json:"authFailures"`
+}
+
+// NewForwardProxy returns a ForwardProxy to the destinations allowed by config.
+func NewForwardProxy(config *types.ForwardProxy) (*ForwardProxy, error) {
+	if len(config.AllowedDestinations) == 0 {
+		return nil, errors.New("no allowed destination for the forward proxy")
+	}
+	p := &ForwardProxy{
+		dialer: &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		},
+	}
+	for _, pattern := range config.AllowedDestinations {
+		destination := &egressDestination{pattern: pattern, host: strings.ToLower(pattern)}
+		if host, port, err := net.SplitHostPort(destination.host); err == nil {
+			destination.host, destination.port = host, port
+		}
+		p.destinations = append(p.destinations, destination)
+	}
+	if len(config.Users) > 0 {
+		users, err := parserBasicUsers(config.Users)
+		if err != nil {
+			return nil, err
+		}
+		p.basicAuth = auth.NewBasicAuthenticator("traefik", func(user, realm string) string {
+			return users[user]
+		})
+	}
+	p.proxy = &httputil.ReverseProxy{
+		// the requests already have their absolute destination URL
+		Director: func(*http.Request) {},
+		Transport: &http.Transport{
+			Dial:                p.dialer.Dial,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+	return p, nil
+}
+
+// Stats returns the egress counters of p.
+func (p *ForwardProxy) Stats() ForwardProxyStats {
+	stats := ForwardProxyStats{
+		Destinations: make(map[string]EgressStats, len(p.destinations)),
+		Denied:       atomic.LoadInt64(&p.denied),
+		AuthFailures: atomic.LoadInt64(&p.authFailures),
+	}
+	for _, destination := range p.destinations {
+		stats.Destinations[destination.pattern] = EgressStats{
+			Requests:      atomic.LoadInt64(&destination.stats.Requests),
+			Tunnels:       atomic.LoadInt64(&destination.stats.Tunnels),
+			Errors:        atomic.LoadInt64(&destination.stats.Errors),
+			BytesSent:     atomic.LoadInt64(&destination.stats.BytesSent),
+			BytesReceived: atomic.LoadInt64(&destination.stats.BytesReceived),
+		}
+	}
+	return stats
+}
+
+// destination returns the first allowed destination matching hostPort, or nil.
+func (p *ForwardProxy) destination(hostPort string) *egressDestination {
+	host, port, err := net.SplitHostPort(strings.ToLower(hostPort))
+	if err != nil {
+		return nil
+	}
+	for _, destination := range p.destinations {
+		if (len(destination.port) == 0 || destination.port == port) && glob.Glob(destination.host, host) {
+			return destination
+		}
+	}
+	return nil
+}
+
+// authenticate returns the user authenticated by the Proxy-Authorization
+// header of r, or false.
+func (p *ForwardProxy) authenticate(r *http.Request) (string, bool) {
+	if p.basicAuth == nil {
+		return "", true
+	}
+	user := p.basicAuth.CheckAuth(&http.Request{Header: http.Header{"Authorization": r.Header["Proxy-Authorization"]}})
+	return user, len(user) > 0
+}
+
+// ServeHTTP proxies the CONNECT and absolute URI requests, and passes the others to next.
+func (p *ForwardProxy) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Method != "CONNECT" && !r.URL.IsAbs() {
+		next(rw, r)
+		return
+	}
+	user, ok := p.authenticate(r)
+	if !ok {
+		atomic.AddInt64(&p.authFailures, 1)
+		log.Debugf("Forward proxy auth failed for %s", r.RemoteAddr)
+		SetErrorReason(r, ReasonAuthFailed)
+		rw.Header().Set("Proxy-Authenticate", `Basic realm="traefik"`)
+		http.Error(rw, http.StatusText(http.StatusProxyAuthRequired), http.StatusProxyAuthRequired)
+		return
+	}
+	setAuthenticatedUser(r, user)
+	r.Header.Del("Proxy-Authorization")
+
+	hostPort := r.Host
+	if r.Method != "CONNECT" {
+		hostPort = r.URL.Host
+		if _, _, err := net.SplitHostPort(hostPort); err != nil {
+			port := "80"
+			if r.URL.Scheme == "https" {
+				port = "443"
+			}
+			hostPort = net.JoinHostPort(hostPort, port)
+		}
+	}
+	destination := p.destination(hostPort)
+	if destination == nil {
+		atomic.AddInt64(&p.denied, 1)
+		log.Debugf("Forward proxy denied %s to %q from %s", hostPort, user, r.RemoteAddr)
+		SetErrorReason(r, ReasonEgressDenied)
+		http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	if r.Method == "CONNECT" {
+		p.tunnel(rw, r, hostPort, destination)
+		return
+	}
+	atomic.AddInt64(&destination.stats.Requests, 1)
+	if r.Body != nil {
+		r.Body = &egressBody{ReadCloser: r.Body, count: &destination.stats.BytesSent}
+	}
+	writer := &egressWriter{ResponseWriter: rw}
+	p.proxy.ServeHTTP(writer, r)
+	atomic.AddInt64(&destination.stats.BytesReceived, writer.written)
+	if writer.status == http.StatusBadGateway {
+		atomic.AddInt64(&destination.stats.Errors, 1)
+	}
+}
+
+// tunnel connects the client of r to hostPort, until one of them closes its connection.
+func (p *ForwardProxy) tunnel(rw http.ResponseWriter, r *http.Request, hostPort string, destination *egressDestination) {
+	atomic.AddInt64(&destination.stats.Tunnels, 1)
+	hijacker, ok := rw.(http.Hijacker)
+	if !ok {
+		atomic.AddInt64(&destination.stats.Errors, 1)
+		http.Error(rw, "Tunnels are not supported over this connection", http.StatusInternalServerError)
+		return
+	}
+	upstream, err := p.dialer.Dial("tcp", hostPort)
+	if err != nil {
+		atomic.AddInt64(&destination.stats.Errors, 1)
+		log.Debugf("Error tunneling to %s: %v", hostPort, err)
+		SetErrorReason(r, ReasonBackendUnreachable)
+		http.Error(rw, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+	defer upstream.Close()
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		atomic.AddInt64(&destination.stats.Errors, 1)
+		log.Errorf("Error hijacking the connection of %s: %v", r.RemoteAddr, err)
+		return
+	}
+	defer client.Close()
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		// the bytes sent by the client after its request are buffered
+		io.Copy(&egressCounter{Writer: upstream, count: &destination.stats.BytesSent}, buffered)
+		closeWrite(upstream)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(&egressCounter{Writer: client, count: &destination.stats.BytesReceived}, upstream)
+		closeWrite(client)
+	}()
+	wg.Wait()
+}
+
+// closeWrite half-closes conn, or closes it if it can't be half-closed.
+func closeWrite(conn net.Conn) {
+	if closer, ok := conn.(interface {
+		CloseWrite() error
+	}); ok {
+		closer.CloseWrite()
+		return
+	}
+	conn.Close()
+}
+
+// egressCounter counts the bytes tunneled from or to a destination.
+type egressCounter struct {
+	io.Writer
+	count *int64
+}
+
+func (c *egressCounter) Write(p []byte) (int, error) {
+	n, err := c.Writer.Write(p)
+	atomic.AddInt64(c.count, int64(n))
+	return n, err
+}
+
+// egressBody counts the bytes of a request body sent to a destination.
+type egressBody struct {
+	io.ReadCloser
+	count *int64
+}
+
+func (b *egressBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(b.count, int64(n))
+	return n, err
+}
+
+// egressWriter records the status and counts the bytes of a response received
+// from a destination.
+type egressWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (w *egressWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *egressWriter) Write(data []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(data)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush sends the buffered data of the response to the client.
+func (w *egressWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// CloseNotify returns the close notifications of the connection of the client.
+func (w *egressWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
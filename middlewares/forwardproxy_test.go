@@ -0,0 +1,104 @@
+package middlewares
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForwardProxy(t *testing.T) {
+	destination := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("plain " + r.Header.Get("Proxy-Authorization")))
+	}))
+	defer destination.Close()
+	tlsDestination := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("tunneled"))
+	}))
+	defer tlsDestination.Close()
+	denied := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}))
+	defer denied.Close()
+
+	forwardProxy, err := NewForwardProxy(&types.ForwardProxy{
+		AllowedDestinations: []string{
+			strings.TrimPrefix(destination.URL, "http://"),
+			"127.0.0.*:" + strings.Split(tlsDestination.URL, ":")[2],
+		},
+		Users: []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"},
+	})
+	assert.NoError(t, err)
+	proxy := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		forwardProxy.ServeHTTP(rw, WithRequestInfo(r), func(rw http.ResponseWriter, r *http.Request) {
+			rw.Write([]byte("routed"))
+		})
+	}))
+	defer proxy.Close()
+
+	client := func(user string) *http.Client {
+		proxyURL, _ := url.Parse(proxy.URL)
+		if len(user) > 0 {
+			proxyURL.User = url.UserPassword(user, "test")
+		}
+		return &http.Client{Transport: &http.Transport{
+			Proxy:           http.ProxyURL(proxyURL),
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}}
+	}
+
+	cases := []struct {
+		desc     string
+		user     string
+		url      string
+		expected int
+		body     string
+	}{
+		{desc: "absolute URI", user: "test", url: destination.URL, expected: http.StatusOK, body: "plain "},
+		{desc: "CONNECT", user: "test", url: tlsDestination.URL, expected: http.StatusOK, body: "tunneled"},
+		{desc: "denied destination", user: "test", url: denied.URL, expected: http.StatusForbidden},
+		{desc: "unknown user", user: "other", url: destination.URL, expected: http.StatusProxyAuthRequired},
+		{desc: "anonymous", url: destination.URL, expected: http.StatusProxyAuthRequired},
+	}
+	for _, c := range cases {
+		response, err := client(c.user).Get(c.url)
+		if c.expected != http.StatusOK && strings.HasPrefix(c.url, "https") {
+			// the CONNECT failures are errors of the client
+			assert.Error(t, err, c.desc)
+			continue
+		}
+		if !assert.NoError(t, err, c.desc) {
+			continue
+		}
+		body, _ := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		assert.Equal(t, c.expected, response.StatusCode, c.desc)
+		if c.expected == http.StatusOK {
+			assert.Equal(t, c.body, string(body), c.desc)
+		}
+	}
+
+	// the requests to the entrypoint itself are routed
+	response, err := http.Get(proxy.URL + "/health")
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	assert.Equal(t, "routed", string(body))
+
+	stats := forwardProxy.Stats()
+	assert.Equal(t, int64(1), stats.Denied)
+	assert.Equal(t, int64(2), stats.AuthFailures)
+	plain := stats.Destinations[strings.TrimPrefix(destination.URL, "http://")]
+	assert.Equal(t, int64(1), plain.Requests)
+	assert.Equal(t, int64(len("plain ")), plain.BytesReceived)
+	tunneled := stats.Destinations["127.0.0.*:"+strings.Split(tlsDestination.URL, ":")[2]]
+	assert.Equal(t, int64(1), tunneled.Tunnels)
+	assert.True(t, tunneled.BytesSent > 0 && tunneled.BytesReceived > 0)
+
+	_, err = NewForwardProxy(&types.ForwardProxy{})
+	assert.Error(t, err)
+}
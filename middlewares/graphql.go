@@ -0,0 +1,187 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/containous/traefik/graphql"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// maxGraphQLBody is the size of the largest GraphQL body analyzed, in bytes
+const maxGraphQLBody = 1 << 20
+
+var errGraphQLBodyTooLarge = fmt.Errorf("GraphQL body larger than %d bytes", maxGraphQLBody)
+
+type graphQLRequest struct {
+	Query         string `json:"query"`
+	OperationName string `json:"operationName"`
+}
+
+type graphQLAnalysis struct {
+	operations []*graphql.Operation
+	err        error
+}
+
+// GetGraphQLOperations returns the GraphQL operations executed by r, several
+// for a batch, or nil if r isn't a GraphQL request. The body of r is read up to
+// 1MB, and left unread for the next handlers. The operations are parsed once
+// per request.
+func GetGraphQLOperations(r *http.Request) ([]*graphql.Operation, error) {
+	info := getRequestInfo(r)
+	if info != nil && info.graphql != nil {
+		return info.graphql.operations, info.graphql.err
+	}
+	analysis := &graphQLAnalysis{}
+	analysis.operations, analysis.err = parseGraphQL(r)
+	if info != nil {
+		info.graphql = analysis
+	}
+	return analysis.operations, analysis.err
+}
+
+func parseGraphQL(r *http.Request) ([]*graphql.Operation, error) {
+	var requests []graphQLRequest
+	switch r.Method {
+	case "GET":
+		query := r.URL.Query()
+		if len(query.Get("query")) == 0 {
+			return nil, nil
+		}
+		requests = []graphQLRequest{{Query: query.Get("query"), OperationName: query.Get("operationName")}}
+	case "POST":
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/json" && mediaType != "application/graphql" || r.Body == nil {
+			return nil, nil
+		}
+		body, err := peekBody(r, maxGraphQLBody)
+		if err != nil {
+			return nil, err
+		}
+		if mediaType == "application/graphql" {
+			requests = []graphQLRequest{{Query: string(body), OperationName: r.URL.Query().Get("operationName")}}
+		} else if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '[' {
+			if err := json.Unmarshal(body, &requests); err != nil {
+				return nil, err
+			}
+		} else {
+			request := graphQLRequest{}
+			if err := json.Unmarshal(body, &request); err != nil {
+				return nil, err
+			}
+			requests = []graphQLRequest{request}
+		}
+	default:
+		return nil, nil
+	}
+	operations := []*graphql.Operation{}
+	for _, request := range requests {
+		// like the persisted queries, sent with their hash
+		if len(request.Query) == 0 {
+			continue
+		}
+		operation, err := graphql.Parse(request.Query, request.OperationName)
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, operation)
+	}
+	if len(operations) == 0 {
+		return nil, nil
+	}
+	return operations, nil
+}
+
+// peekBody returns the body of r, and sets it back for the next readers, or an
+// error if it is larger than max bytes.
+func peekBody(r *http.Request, max int64) ([]byte, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, max+1))
+	r.Body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > max {
+		return nil, errGraphQLBodyTooLarge
+	}
+	return body, nil
+}
+
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// GraphQLLimiter is a middleware rejecting the GraphQL requests deeper or more
+// complex than the limits of a frontend, before the backends execute them.
+type GraphQLLimiter struct {
+	config types.GraphQL
+}
+
+// NewGraphQLLimiter returns a GraphQLLimiter enforcing config.
+func NewGraphQLLimiter(config *types.GraphQL) (*GraphQLLimiter, error) {
+	if config.MaxDepth < 0 || config.MaxComplexity < 0 {
+		return nil, errors.New("invalid negative GraphQL limit")
+	}
+	return &GraphQLLimiter{config: *config}, nil
+}
+
+func (g *GraphQLLimiter) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	operations, err := GetGraphQLOperations(r)
+	if err != nil {
+		log.Debugf("Rejecting the GraphQL request to %s: %v", r.URL.Path, err)
+		SetErrorReason(r, ReasonGraphQLInvalid)
+		graphQLError(rw, err.Error())
+		return
+	}
+	for _, operation := range operations {
+		switch {
+		case g.config.MaxDepth > 0 && operation.Depth > g.config.MaxDepth:
+			err = fmt.Errorf("depth %d of %s over the limit of %d", operation.Depth, operation, g.config.MaxDepth)
+		case g.config.MaxComplexity > 0 && operation.Complexity > g.config.MaxComplexity:
+			err = fmt.Errorf("complexity %d of %s over the limit of %d", operation.Complexity, operation, g.config.MaxComplexity)
+		}
+		if err != nil {
+			log.Debugf("Rejecting the GraphQL request to %s: %v", r.URL.Path, err)
+			SetErrorReason(r, ReasonGraphQLLimit)
+			graphQLError(rw, err.Error())
+			return
+		}
+	}
+	next(rw, r)
+}
+
+// Handler returns a handler enforcing the GraphQL limits before next.
+func (g *GraphQLLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		g.ServeHTTP(rw, r, next.ServeHTTP)
+	})
+}
+
+// graphQLError answers 400 with a GraphQL error response.
+func graphQLError(rw http.ResponseWriter, message string) {
+	body, _ := json.Marshal(map[string][]map[string]string{"errors": {{"message": message}}})
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusBadRequest)
+	rw.Write(body)
+}
+
+// graphQLLogValue returns the operations of r for the access log, if they were parsed.
+func graphQLLogValue(r *http.Request) string {
+	info := getRequestInfo(r)
+	if info == nil || info.graphql == nil || len(info.graphql.operations) == 0 {
+		return "-"
+	}
+	operations := []string{}
+	for _, operation := range info.graphql.operations {
+		operations = append(operations, operation.String())
+	}
+	return strings.Join(operations, ",")
+}
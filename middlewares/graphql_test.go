@@ -0,0 +1,81 @@
+package middlewares
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGraphQLOperations(t *testing.T) {
+	body := `[{"query": "query A { a { b } }"}, {"query": "mutation B($x: Int) { b(x: $x) }", "variables": {"x": 1}}]`
+	request := WithRequestInfo(httptest.NewRequest("POST", "/graphql", strings.NewReader(body)))
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	operations, err := GetGraphQLOperations(request)
+	assert.NoError(t, err)
+	if assert.Len(t, operations, 2) {
+		assert.Equal(t, "query A", operations[0].String())
+		assert.Equal(t, "mutation B", operations[1].String())
+	}
+	// the body is left for the backend, and parsed once
+	read, _ := ioutil.ReadAll(request.Body)
+	assert.Equal(t, body, string(read))
+	operations, err = GetGraphQLOperations(request)
+	assert.NoError(t, err)
+	assert.Len(t, operations, 2)
+	assert.Equal(t, "query A,mutation B", graphQLLogValue(request))
+
+	request = httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape("query C { c }"), nil)
+	operations, err = GetGraphQLOperations(request)
+	assert.NoError(t, err)
+	if assert.Len(t, operations, 1) {
+		assert.Equal(t, "query C", operations[0].String())
+	}
+
+	request = httptest.NewRequest("POST", "/graphql", strings.NewReader("{ d }"))
+	request.Header.Set("Content-Type", "application/graphql")
+	operations, err = GetGraphQLOperations(request)
+	assert.NoError(t, err)
+	assert.Len(t, operations, 1)
+
+	request = httptest.NewRequest("POST", "/upload", strings.NewReader("{ d }"))
+	request.Header.Set("Content-Type", "text/plain")
+	operations, err = GetGraphQLOperations(request)
+	assert.NoError(t, err)
+	assert.Nil(t, operations)
+
+	request = httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "`+strings.Repeat(" ", maxGraphQLBody)+`{ a }"}`))
+	request.Header.Set("Content-Type", "application/json")
+	_, err = GetGraphQLOperations(request)
+	assert.Equal(t, errGraphQLBodyTooLarge, err)
+}
+
+func TestGraphQLLimiter(t *testing.T) {
+	limiter, err := NewGraphQLLimiter(&types.GraphQL{MaxDepth: 2, MaxComplexity: 3})
+	assert.NoError(t, err)
+	cases := []struct {
+		query    string
+		expected int
+		reason   string
+	}{
+		{`{ a { b c } }`, http.StatusOK, ""},
+		{`{ a { b { c } } }`, http.StatusBadRequest, ReasonGraphQLLimit},
+		{`{ a { b c d } }`, http.StatusBadRequest, ReasonGraphQLLimit},
+		{`{ a { b `, http.StatusBadRequest, ReasonGraphQLInvalid},
+	}
+	for _, c := range cases {
+		request := WithRequestInfo(httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(c.query), nil))
+		recorder := httptest.NewRecorder()
+		limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(recorder, request)
+		assert.Equal(t, c.expected, recorder.Code, c.query)
+		assert.Equal(t, c.reason, GetErrorReason(request), c.query)
+		if c.expected == http.StatusBadRequest {
+			assert.Contains(t, recorder.Body.String(), `{"errors":[{"message":`, c.query)
+		}
+	}
+}
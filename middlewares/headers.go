@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// forwardedHeaders are the headers telling the backends about the clients, trusted by the forwarder
+var forwardedHeaders = []string{
+	"Forwarded",
+	"X-Forwarded-For",
+	"X-Forwarded-Host",
+	"X-Forwarded-Port",
+	"X-Forwarded-Proto",
+	"X-Forwarded-Server",
+	"X-Real-Ip",
+}
+
+// HeaderSanitizer is a middleware sanitizing the request headers of the clients
+type HeaderSanitizer struct {
+	config types.HeaderPolicy
+}
+
+// NewHeaderSanitizer returns a HeaderSanitizer applying policy.
+func NewHeaderSanitizer(policy *types.HeaderPolicy) *HeaderSanitizer {
+	return &HeaderSanitizer{config: *policy}
+}
+
+func (h *HeaderSanitizer) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	for name, values := range r.Header {
+		for _, value := range values {
+			if h.config.MaxValueLength > 0 && len(value) > h.config.MaxValueLength {
+				log.Debugf("Rejecting the header %s of %d bytes", name, len(value))
+				SetErrorReason(r, ReasonInvalidHeader)
+				http.Error(rw, http.StatusText(http.StatusRequestHeaderFieldsTooLarge), http.StatusRequestHeaderFieldsTooLarge)
+				return
+			}
+			if h.config.RejectControlChars && hasControlChars(value) {
+				log.Debugf("Rejecting the header %s with control characters", name)
+				SetErrorReason(r, ReasonInvalidHeader)
+				http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if h.config.StripConnection {
+		for _, value := range r.Header["Connection"] {
+			for _, name := range strings.Split(value, ",") {
+				// the websockets upgrades are handled by the forwarder
+				if name = strings.TrimSpace(name); len(name) > 0 && !strings.EqualFold(name, "Upgrade") {
+					r.Header.Del(name)
+				}
+			}
+		}
+	}
+	if h.config.StripForwarded {
+		for _, name := range forwardedHeaders {
+			r.Header.Del(name)
+		}
+	}
+	for _, name := range h.config.StripHeaders {
+		r.Header.Del(name)
+	}
+	if h.config.EmitForwarded {
+		forwarded := forwardedElement(r)
+		if prior := r.Header.Get("Forwarded"); len(prior) > 0 {
+			forwarded = prior + ", " + forwarded
+		}
+		r.Header.Set("Forwarded", forwarded)
+	}
+	next(rw, r)
+}
+
+// forwardedElement returns the Forwarded element of the client of r (RFC 7239)
+func forwardedElement(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if strings.Contains(ip, ":") {
+		ip = `"[` + ip + `]"`
+	}
+	proto := "http"
+	if r.TLS != nil {
+		proto = "https"
+	}
+	element := "for=" + ip + ";proto=" + proto
+	if len(r.Host) > 0 {
+		element += `;host="` + strings.NewReplacer(`"`, "", `\`, "").Replace(r.Host) + `"`
+	}
+	return element
+}
+
+// hasControlChars returns true if value holds a control character other than a tab
+func hasControlChars(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c < ' ' && c != '\t' || c == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package middlewares
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderSanitizer(t *testing.T) {
+	sanitizer := NewHeaderSanitizer(&types.HeaderPolicy{
+		StripForwarded:  true,
+		StripConnection: true,
+		StripHeaders:    []string{"X-Internal-User"},
+		EmitForwarded:   true,
+	})
+	var header http.Header
+	next := func(rw http.ResponseWriter, r *http.Request) { header = r.Header }
+
+	request := httptest.NewRequest("GET", "http://example.org/", nil)
+	request.RemoteAddr = "192.0.2.1:1234"
+	request.Header.Set("X-Forwarded-For", "10.0.0.1")
+	request.Header.Set("X-Real-Ip", "10.0.0.1")
+	request.Header.Set("Forwarded", "for=10.0.0.1")
+	request.Header.Set("X-Internal-User", "admin")
+	request.Header.Set("Connection", "X-Auth-Token, Upgrade")
+	request.Header.Set("X-Auth-Token", "secret")
+	request.Header.Set("Upgrade", "websocket")
+	sanitizer.ServeHTTP(httptest.NewRecorder(), request, next)
+
+	assert.Equal(t, "", header.Get("X-Forwarded-For"))
+	assert.Equal(t, "", header.Get("X-Real-Ip"))
+	assert.Equal(t, "", header.Get("X-Internal-User"))
+	assert.Equal(t, "", header.Get("X-Auth-Token"))
+	assert.Equal(t, "websocket", header.Get("Upgrade"))
+	assert.Equal(t, `for=192.0.2.1;proto=http;host="example.org"`, header.Get("Forwarded"))
+}
+
+func TestHeaderSanitizerForwarded(t *testing.T) {
+	sanitizer := NewHeaderSanitizer(&types.HeaderPolicy{EmitForwarded: true})
+	request := httptest.NewRequest("GET", "https://example.org/", nil)
+	request.RemoteAddr = "[2001:db8::1]:1234"
+	request.TLS = &tls.ConnectionState{}
+	request.Header.Set("Forwarded", "for=198.51.100.1")
+	sanitizer.ServeHTTP(httptest.NewRecorder(), request, func(http.ResponseWriter, *http.Request) {})
+	assert.Equal(t, `for=198.51.100.1, for="[2001:db8::1]";proto=https;host="example.org"`, request.Header.Get("Forwarded"))
+}
+
+func TestHeaderSanitizerRejections(t *testing.T) {
+	sanitizer := NewHeaderSanitizer(&types.HeaderPolicy{MaxValueLength: 16, RejectControlChars: true})
+	serve := func(value string) int {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.Header.Set("X-Test", value)
+		recorder := httptest.NewRecorder()
+		sanitizer.ServeHTTP(recorder, request, func(http.ResponseWriter, *http.Request) {})
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusOK, serve("a\tb"))
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, serve(strings.Repeat("a", 17)))
+	assert.Equal(t, http.StatusBadRequest, serve("a\x00b"))
+	assert.Equal(t, http.StatusBadRequest, serve("a\x7fb"))
+}
@@ -0,0 +1,152 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	defaultLockoutMaxFailures = 5
+	defaultLockoutDuration    = 60
+	defaultLockoutMaxDuration = 3600
+	// lockoutMaxClients is the number of clients tracked above which the
+	// clients without recent failures are forgotten.
+	lockoutMaxClients = 10000
+)
+
+// AuthLockout locks out the client IPs failing to authenticate too many times
+// in a row, for a duration doubling on each new lockout of the same client.
+type AuthLockout struct {
+	mutex    sync.Mutex
+	config   types.Lockout
+	clients  map[string]*lockoutClient
+	lockouts int64
+	rejected int64
+}
+
+type lockoutClient struct {
+	failures    int
+	lockouts    uint
+	lockedUntil time.Time
+	lastFailure time.Time
+}
+
+// AuthLockoutStats are the lockouts since the creation of an AuthLockout.
+type AuthLockoutStats struct {
+	LockedOutClients int   `json:"locked_out_clients"`
+	Lockouts         int64 `json:"lockouts"`
+	RejectedRequests int64 `json:"rejected_requests"`
+}
+
+// NewAuthLockout returns an AuthLockout enforcing config.
+func NewAuthLockout(config *types.Lockout) *AuthLockout {
+	lockout := &AuthLockout{clients: map[string]*lockoutClient{}}
+	lockout.SetConfig(config)
+	return lockout
+}
+
+// SetConfig replaces the configuration of l, the clients keep their failures.
+func (l *AuthLockout) SetConfig(config *types.Lockout) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.config = *config
+	if l.config.MaxFailures <= 0 {
+		l.config.MaxFailures = defaultLockoutMaxFailures
+	}
+	if l.config.Duration <= 0 {
+		l.config.Duration = defaultLockoutDuration
+	}
+	if l.config.MaxDuration < l.config.Duration {
+		l.config.MaxDuration = defaultLockoutMaxDuration
+		if l.config.MaxDuration < l.config.Duration {
+			l.config.MaxDuration = l.config.Duration
+		}
+	}
+}
+
+// check returns the remaining lockout duration of the client of r, 0 if it is not locked out.
+func (l *AuthLockout) check(r *http.Request, now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	client, ok := l.clients[lockoutClientIP(r)]
+	if !ok || !now.Before(client.lockedUntil) {
+		return 0
+	}
+	l.rejected++
+	return client.lockedUntil.Sub(now)
+}
+
+// failure records an authentication failure of the client of r.
+func (l *AuthLockout) failure(r *http.Request, now time.Time) {
+	ip := lockoutClientIP(r)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	client, ok := l.clients[ip]
+	if !ok {
+		if len(l.clients) >= lockoutMaxClients {
+			l.forget(now)
+		}
+		client = &lockoutClient{}
+		l.clients[ip] = client
+	}
+	client.failures++
+	client.lastFailure = now
+	if client.failures < l.config.MaxFailures {
+		return
+	}
+	duration := time.Duration(l.config.Duration) * time.Second << client.lockouts
+	if maxDuration := time.Duration(l.config.MaxDuration) * time.Second; duration > maxDuration || duration <= 0 {
+		duration = maxDuration
+	} else {
+		client.lockouts++
+	}
+	client.failures = 0
+	client.lockedUntil = now.Add(duration)
+	l.lockouts++
+	log.Warnf("Client %s locked out for %s after %d authentication failures", ip, duration, l.config.MaxFailures)
+}
+
+// success forgets the failures of the client of r.
+func (l *AuthLockout) success(r *http.Request) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	delete(l.clients, lockoutClientIP(r))
+}
+
+// forget drops the clients that are not locked out and didn't fail for the
+// longest lockout duration.
+func (l *AuthLockout) forget(now time.Time) {
+	expiry := now.Add(-time.Duration(l.config.MaxDuration) * time.Second)
+	for ip, client := range l.clients {
+		if now.After(client.lockedUntil) && client.lastFailure.Before(expiry) {
+			delete(l.clients, ip)
+		}
+	}
+}
+
+// Stats returns the lockouts statistics of l.
+func (l *AuthLockout) Stats() *AuthLockoutStats {
+	now := time.Now()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	stats := &AuthLockoutStats{Lockouts: l.lockouts, RejectedRequests: l.rejected}
+	for _, client := range l.clients {
+		if now.Before(client.lockedUntil) {
+			stats.LockedOutClients++
+		}
+	}
+	return stats
+}
+
+func lockoutClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -69,6 +69,7 @@ func SetBackend2FrontendMap(newMap *map[string]string) {
 }
 
 func (l *Logger) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	r = WithRequestInfo(r)
 	if l.file == nil {
 		next(rw, r)
 	} else {
@@ -137,14 +138,34 @@ func (fblh frontendBackendLoggingHandler) ServeHTTP(rw http.ResponseWriter, req
 	backend := infoRw.GetBackend()
 	status := infoRw.GetStatus()
 	size := infoRw.GetSize()
+	reason := GetErrorReason(req)
+	if len(reason) == 0 {
+		reason = "-"
+	}
+
+	tlsVersion, tlsCipherSuite, tlsServerName, tlsProtocol, tlsClientSubject := "-", "-", "-", "-", "-"
+	if tlsInfo := GetTLSInfo(req); tlsInfo != nil {
+		tlsVersion, tlsCipherSuite = tlsInfo.Version, tlsInfo.CipherSuite
+		tlsServerName = orDash(tlsInfo.ServerName)
+		tlsProtocol = orDash(tlsInfo.Protocol)
+		tlsClientSubject = orDash(tlsInfo.ClientSubject)
+	}
 
 	elapsed := time.Now().UTC().Sub(startTime.UTC())
 	elapsedMillis := elapsed.Nanoseconds() / 1000000
-	fmt.Fprintf(fblh.writer, `%s - %s [%s] "%s %s %s" %d %d "%s" "%s" %s "%s" "%s" %dms%s`,
-		host, username, ts, method, uri, proto, status, size, referer, agent, fblh.reqid, frontend, backend, elapsedMillis, "\n")
+	fmt.Fprintf(fblh.writer, `%s - %s [%s] "%s %s %s" %d %d "%s" "%s" %s "%s" "%s" %dms "%s" "%s" "%s" "%s" "%s" "%s" "%s"%s`,
+		host, username, ts, method, uri, proto, status, size, referer, agent, fblh.reqid, frontend, backend, elapsedMillis, reason,
+		tlsVersion, tlsCipherSuite, tlsServerName, tlsProtocol, strings.Replace(tlsClientSubject, `"`, `'`, -1), graphQLLogValue(req), "\n")
 
 }
 
+func orDash(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+	return value
+}
+
 func (lirw *logInfoResponseWriter) Header() http.Header {
 	return lirw.rw.Header()
 }
@@ -71,7 +71,7 @@ func TestLogger(t *testing.T) {
 	} else if tokens, err := shellwords.Parse(string(logdata)); err != nil {
 		fmt.Printf("%s\n", err.Error())
 		assert.Nil(t, err)
-	} else if assert.Equal(t, 14, len(tokens), printLogdata(logdata)) {
+	} else if assert.Equal(t, 21, len(tokens), printLogdata(logdata)) {
 		assert.Equal(t, testHostname, tokens[0], printLogdata(logdata))
 		assert.Equal(t, testUsername, tokens[2], printLogdata(logdata))
 		assert.Equal(t, fmt.Sprintf("%s %s %s", testMethod, testPath, testProto), tokens[5], printLogdata(logdata))
@@ -82,6 +82,11 @@ func TestLogger(t *testing.T) {
 		assert.Equal(t, "1", tokens[10], printLogdata(logdata))
 		assert.Equal(t, testFrontendName, tokens[11], printLogdata(logdata))
 		assert.Equal(t, testBackendName, tokens[12], printLogdata(logdata))
+		assert.Equal(t, ReasonBackendTimeout, tokens[14], printLogdata(logdata))
+		// not received over TLS
+		assert.Equal(t, []string{"-", "-", "-", "-", "-"}, tokens[15:20], printLogdata(logdata))
+		// not a GraphQL request
+		assert.Equal(t, "-", tokens[20], printLogdata(logdata))
 	}
 }
 
@@ -94,7 +99,7 @@ func printLogdata(logdata []byte) string {
 	return fmt.Sprintf(
 		"\nExpected: %s\n"+
 			"Actual:   %s",
-		"TestHost - TestUser [13/Apr/2016:07:14:19 -0700] \"POST http://testpath HTTP/0.0\" 123 12 \"testReferer\" \"testUserAgent\" 1 \"testFrontend\" \"http://127.0.0.1/testBackend\" 1ms",
+		"TestHost - TestUser [13/Apr/2016:07:14:19 -0700] \"POST http://testpath HTTP/0.0\" 123 12 \"testReferer\" \"testUserAgent\" 1 \"testFrontend\" \"http://127.0.0.1/testBackend\" 1ms \"backend_timeout\" \"-\" \"-\" \"-\" \"-\" \"-\" \"-\"",
 		string(logdata))
 }
 
@@ -102,6 +107,7 @@ func LogWriterTestHandlerFunc(rw http.ResponseWriter, r *http.Request) {
 	rw.Write([]byte(helloWorld))
 	rw.WriteHeader(testStatus)
 	saveBackendNameForLogger(r, testBackendName)
+	SetErrorReason(r, ReasonBackendTimeout)
 }
 
 func (lrw *logtestResponseWriter) Header() http.Header {
@@ -0,0 +1,191 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// PathNormalizer is a middleware normalizing the request paths before they are routed
+type PathNormalizer struct {
+	config types.PathNormalization
+}
+
+// NewPathNormalizer returns a PathNormalizer applying config.
+func NewPathNormalizer(config *types.PathNormalization) *PathNormalizer {
+	return &PathNormalizer{config: *config}
+}
+
+func (n *PathNormalizer) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	escaped := r.URL.EscapedPath()
+	normalized, err := normalizePath(escaped, &n.config)
+	if err != nil {
+		log.Debugf("Rejecting the path %s: %v", escaped, err)
+		SetErrorReason(r, ReasonInvalidPath)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if normalized != escaped {
+		path, err := unescapePath(normalized)
+		if err != nil {
+			SetErrorReason(r, ReasonInvalidPath)
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		r.URL.Path = path
+		r.URL.RawPath = normalized
+		r.RequestURI = r.URL.RequestURI()
+	}
+	next(rw, r)
+}
+
+// normalizePath returns the normalization of the escaped path
+func normalizePath(escaped string, config *types.PathNormalization) (string, error) {
+	if !strings.HasPrefix(escaped, "/") {
+		// like the * of OPTIONS
+		return escaped, nil
+	}
+	if config.Strict {
+		if err := checkPath(escaped); err != nil {
+			return "", err
+		}
+	}
+	if config.NormalizeEscapes {
+		escaped = normalizeEscapes(escaped)
+	}
+	if !config.MergeSlashes && !config.RemoveDotSegments {
+		return escaped, nil
+	}
+	segments := strings.Split(escaped[1:], "/")
+	normalized := []string{}
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch {
+		case config.MergeSlashes && len(segment) == 0 && !last:
+			continue
+		case config.RemoveDotSegments && dotSegment(segment) == 1:
+			if last {
+				normalized = append(normalized, "")
+			}
+			continue
+		case config.RemoveDotSegments && dotSegment(segment) == 2:
+			if len(normalized) > 0 {
+				normalized = normalized[:len(normalized)-1]
+			}
+			if last {
+				normalized = append(normalized, "")
+			}
+			continue
+		}
+		normalized = append(normalized, segment)
+	}
+	return "/" + strings.Join(normalized, "/"), nil
+}
+
+// checkPath returns an error if the escaped path is ambiguous: the
+// backends may not decode it, or resolve its dot segments, the same way.
+func checkPath(escaped string) error {
+	depth := 0
+	for _, segment := range strings.Split(escaped[1:], "/") {
+		switch dotSegment(segment) {
+		case 1:
+		case 2:
+			if depth == 0 {
+				return errors.New(".. segment above the root")
+			}
+			depth--
+		default:
+			depth++
+		}
+	}
+	for i := 0; i < len(escaped); i++ {
+		switch escaped[i] {
+		case '\\':
+			return errors.New("backslash")
+		case '%':
+			if i+2 >= len(escaped) || !isHex(escaped[i+1]) || !isHex(escaped[i+2]) {
+				return errors.New("invalid escape")
+			}
+			switch unhex(escaped[i+1])<<4 | unhex(escaped[i+2]) {
+			case '/', '\\', 0:
+				return fmt.Errorf("escaped %s", escaped[i:i+3])
+			}
+			i += 2
+		}
+	}
+	return nil
+}
+
+// dotSegment returns 1 for a . segment, 2 for a .. segment, possibly escaped, and 0 otherwise
+func dotSegment(segment string) int {
+	switch strings.Replace(strings.ToLower(segment), "%2e", ".", -1) {
+	case ".":
+		return 1
+	case "..":
+		return 2
+	}
+	return 0
+}
+
+// normalizeEscapes decodes the escaped unreserved characters and uppercases the other escapes (RFC 3986 6.2.2)
+func normalizeEscapes(escaped string) string {
+	normalized := make([]byte, 0, len(escaped))
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] != '%' || i+2 >= len(escaped) || !isHex(escaped[i+1]) || !isHex(escaped[i+2]) {
+			normalized = append(normalized, escaped[i])
+			continue
+		}
+		if c := unhex(escaped[i+1])<<4 | unhex(escaped[i+2]); isUnreserved(c) {
+			normalized = append(normalized, c)
+		} else {
+			normalized = append(normalized, '%', upperHex(escaped[i+1]), upperHex(escaped[i+2]))
+		}
+		i += 2
+	}
+	return string(normalized)
+}
+
+func unescapePath(escaped string) (string, error) {
+	unescaped := make([]byte, 0, len(escaped))
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] != '%' {
+			unescaped = append(unescaped, escaped[i])
+			continue
+		}
+		if i+2 >= len(escaped) || !isHex(escaped[i+1]) || !isHex(escaped[i+2]) {
+			return "", errors.New("invalid escape")
+		}
+		unescaped = append(unescaped, unhex(escaped[i+1])<<4|unhex(escaped[i+2]))
+		i += 2
+	}
+	return string(unescaped), nil
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
+
+func upperHex(c byte) byte {
+	if 'a' <= c && c <= 'f' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
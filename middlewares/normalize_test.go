@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePath(t *testing.T) {
+	all := &types.PathNormalization{MergeSlashes: true, RemoveDotSegments: true, NormalizeEscapes: true}
+	paths := map[string]string{
+		"/":                   "/",
+		"//":                  "/",
+		"/a//b///c/":          "/a/b/c/",
+		"/a/./b/../c":         "/a/c",
+		"/a/b/..":             "/a/",
+		"/a/%2e%2E/b":         "/b",
+		"/../a":               "/a",
+		"/%7euser/%2f/%c3%a9": "/~user/%2F/%C3%A9",
+		"/admin/.%2e//secret": "/secret",
+		"*":                   "*",
+	}
+	for path, expected := range paths {
+		normalized, err := normalizePath(path, all)
+		assert.NoError(t, err, path)
+		assert.Equal(t, expected, normalized, path)
+	}
+
+	normalized, _ := normalizePath("/a//./b", &types.PathNormalization{MergeSlashes: true})
+	assert.Equal(t, "/a/./b", normalized)
+	normalized, _ = normalizePath("/a//../b", &types.PathNormalization{RemoveDotSegments: true})
+	assert.Equal(t, "/a/b", normalized)
+}
+
+func TestNormalizePathStrict(t *testing.T) {
+	strict := &types.PathNormalization{Strict: true, RemoveDotSegments: true}
+	for _, path := range []string{"/../etc/passwd", "/a/../../b", "/a%2fb", "/a%5Cb", "/a%00", "/a\\b", "/a%zz", "/a%2"} {
+		_, err := normalizePath(path, strict)
+		assert.Error(t, err, path)
+	}
+	normalized, err := normalizePath("/a/../b", strict)
+	assert.NoError(t, err)
+	assert.Equal(t, "/b", normalized)
+}
+
+func TestPathNormalizer(t *testing.T) {
+	normalizer := NewPathNormalizer(&types.PathNormalization{MergeSlashes: true, RemoveDotSegments: true, Strict: true})
+	var path, requestURI string
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		path, requestURI = r.URL.Path, r.RequestURI
+	}
+
+	recorder := httptest.NewRecorder()
+	normalizer.ServeHTTP(recorder, httptest.NewRequest("GET", "/public//../admin/%41?q=1", nil), next)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "/admin/A", path)
+	assert.Equal(t, "/admin/%41?q=1", requestURI)
+
+	recorder = httptest.NewRecorder()
+	normalizer.ServeHTTP(recorder, httptest.NewRequest("GET", "/public/..%2fadmin", nil), next)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
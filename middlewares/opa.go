@@ -0,0 +1,164 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	defaultOPAURL     = "http://127.0.0.1:8181"
+	defaultOPATimeout = 1000
+)
+
+// OPA is a middleware authorizing the requests of a frontend with the policy
+// decisions of an Open Policy Agent server. The decision is either a boolean,
+// or an object with an allow boolean, the headers to set on the allowed
+// request, and the status of the denied one, 403 by default.
+type OPA struct {
+	decisionURL string
+	failOpen    bool
+	client      *http.Client
+}
+
+// opaInput holds the attributes of a request given to the policy, the header
+// names in lower case and their values comma separated.
+type opaInput struct {
+	Method   string              `json:"method"`
+	Scheme   string              `json:"scheme"`
+	Host     string              `json:"host"`
+	Path     string              `json:"path"`
+	Query    map[string][]string `json:"query"`
+	Headers  map[string]string   `json:"headers"`
+	ClientIP string              `json:"clientIP"`
+	User     string              `json:"user,omitempty"`
+	Frontend string              `json:"frontend,omitempty"`
+}
+
+type opaDecision struct {
+	Allow   bool              `json:"allow"`
+	Headers map[string]string `json:"headers"`
+	Status  int               `json:"status"`
+}
+
+// NewOPA returns an OPA querying the decisions of config.
+func NewOPA(config *types.OPA) (*OPA, error) {
+	if len(strings.Trim(config.Path, "/")) == 0 {
+		return nil, errors.New("no OPA decision path")
+	}
+	if config.Timeout < 0 {
+		return nil, errors.New("invalid negative OPA timeout")
+	}
+	serverURL := config.URL
+	if len(serverURL) == 0 {
+		serverURL = defaultOPAURL
+	}
+	if _, err := url.Parse(serverURL); err != nil {
+		return nil, fmt.Errorf("invalid OPA URL %s: %v", serverURL, err)
+	}
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultOPATimeout
+	}
+	return &OPA{
+		decisionURL: strings.TrimRight(serverURL, "/") + "/v1/data/" + strings.Trim(config.Path, "/"),
+		failOpen:    config.FailOpen,
+		client:      &http.Client{Timeout: time.Duration(timeout) * time.Millisecond},
+	}, nil
+}
+
+// decide returns the decision of the policy for r.
+func (o *OPA) decide(r *http.Request) (*opaDecision, error) {
+	input := opaInput{
+		Method:   r.Method,
+		Scheme:   "http",
+		Host:     r.Host,
+		Path:     r.URL.Path,
+		Query:    r.URL.Query(),
+		Headers:  map[string]string{},
+		ClientIP: lockoutClientIP(r),
+		User:     GetAuthenticatedUser(r),
+		Frontend: GetFrontendName(r),
+	}
+	if r.TLS != nil {
+		input.Scheme = "https"
+	}
+	for name, values := range r.Header {
+		input.Headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest("POST", o.decisionURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := o.client.Do(request.WithContext(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	var result struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Result) == 0 {
+		return nil, errors.New("undefined decision")
+	}
+	decision := &opaDecision{}
+	if err := json.Unmarshal(result.Result, &decision.Allow); err == nil {
+		return decision, nil
+	}
+	if err := json.Unmarshal(result.Result, decision); err != nil {
+		return nil, fmt.Errorf("invalid decision, expected a boolean or an object: %v", err)
+	}
+	return decision, nil
+}
+
+// Handler returns a handler passing the requests allowed by the policy to next,
+// with the headers of the decision.
+func (o *OPA) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		decision, err := o.decide(r)
+		if err != nil {
+			if o.failOpen {
+				log.Warnf("Error querying the OPA decision for %s %s, allowing the request: %v", r.Method, r.URL.Path, err)
+				next.ServeHTTP(rw, r)
+				return
+			}
+			log.Errorf("Error querying the OPA decision for %s %s: %v", r.Method, r.URL.Path, err)
+			SetErrorReason(r, ReasonPolicyUnavailable)
+			http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		if !decision.Allow {
+			status := decision.Status
+			if status < 400 || status > 599 {
+				status = http.StatusForbidden
+			}
+			log.Debugf("OPA denied %s %s to %q from %s", r.Method, r.URL.Path, GetAuthenticatedUser(r), r.RemoteAddr)
+			SetErrorReason(r, ReasonPolicyDenied)
+			http.Error(rw, http.StatusText(status), status)
+			return
+		}
+		for name, value := range decision.Headers {
+			r.Header.Set(name, value)
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
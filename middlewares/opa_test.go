@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOPA(t *testing.T) {
+	// a policy allowing the admins, and the reads of the others
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var query struct {
+			Input opaInput `json:"input"`
+		}
+		if r.URL.Path != "/v1/data/httpapi/authz" || json.NewDecoder(r.Body).Decode(&query) != nil {
+			http.NotFound(rw, r)
+			return
+		}
+		input := query.Input
+		switch {
+		case input.Path == "/slow":
+			time.Sleep(200 * time.Millisecond)
+			rw.Write([]byte(`{"result": true}`))
+		case input.Path == "/undefined":
+			rw.Write([]byte(`{}`))
+		case input.User == "admin":
+			rw.Write([]byte(`{"result": {"allow": true, "headers": {"X-Role": "admin"}}}`))
+		case input.Headers["x-api-key"] == "":
+			rw.Write([]byte(`{"result": {"allow": false, "status": 401}}`))
+		case input.Method == "GET":
+			rw.Write([]byte(`{"result": true}`))
+		default:
+			rw.Write([]byte(`{"result": false}`))
+		}
+	}))
+	defer ts.Close()
+
+	opa, err := NewOPA(&types.OPA{URL: ts.URL, Path: "/httpapi/authz", Timeout: 100})
+	assert.NoError(t, err)
+	var role string
+	handler := opa.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		role = r.Header.Get("X-Role")
+	}))
+
+	cases := []struct {
+		desc     string
+		method   string
+		path     string
+		user     string
+		apiKey   string
+		expected int
+		reason   string
+		role     string
+	}{
+		{desc: "read", method: "GET", path: "/users", apiKey: "key", expected: http.StatusOK},
+		{desc: "write", method: "POST", path: "/users", apiKey: "key", expected: http.StatusForbidden, reason: ReasonPolicyDenied},
+		{desc: "admin", method: "POST", path: "/users", user: "admin", expected: http.StatusOK, role: "admin"},
+		{desc: "without key", method: "GET", path: "/users", expected: http.StatusUnauthorized, reason: ReasonPolicyDenied},
+		{desc: "undefined decision", method: "GET", path: "/undefined", expected: http.StatusServiceUnavailable, reason: ReasonPolicyUnavailable},
+		{desc: "timeout", method: "GET", path: "/slow", expected: http.StatusServiceUnavailable, reason: ReasonPolicyUnavailable},
+	}
+	for _, c := range cases {
+		role = ""
+		request := WithRequestInfo(httptest.NewRequest(c.method, c.path, nil))
+		if len(c.apiKey) > 0 {
+			request.Header.Set("X-Api-Key", c.apiKey)
+		}
+		setAuthenticatedUser(request, c.user)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, c.expected, recorder.Code, c.desc)
+		assert.Equal(t, c.reason, GetErrorReason(request), c.desc)
+		assert.Equal(t, c.role, role, c.desc)
+	}
+
+	// allowed when the decisions fail
+	opa, err = NewOPA(&types.OPA{URL: ts.URL, Path: "other/authz", FailOpen: true})
+	assert.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	opa.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})).ServeHTTP(recorder, httptest.NewRequest("POST", "/users", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	_, err = NewOPA(&types.OPA{URL: ts.URL})
+	assert.Error(t, err)
+	_, err = NewOPA(&types.OPA{Path: "httpapi/authz", Timeout: -1})
+	assert.Error(t, err)
+}
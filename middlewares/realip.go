@@ -0,0 +1,242 @@
+package middlewares
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// cdn describes where a CDN publishes its IP ranges, and which header holds the client IP.
+type cdn struct {
+	rangesURLs  []string
+	parseRanges func(body io.Reader) ([]*net.IPNet, error)
+	clientIP    func(r *http.Request) string
+}
+
+var cdns = map[string]*cdn{
+	"cloudflare": {
+		rangesURLs:  []string{"https://www.cloudflare.com/ips-v4", "https://www.cloudflare.com/ips-v6"},
+		parseRanges: parseCIDRList,
+		clientIP: func(r *http.Request) string {
+			return r.Header.Get("CF-Connecting-IP")
+		},
+	},
+	"fastly": {
+		rangesURLs:  []string{"https://api.fastly.com/public-ip-list"},
+		parseRanges: parseFastlyRanges,
+		clientIP: func(r *http.Request) string {
+			return r.Header.Get("Fastly-Client-IP")
+		},
+	},
+	"cloudfront": {
+		rangesURLs:  []string{"https://ip-ranges.amazonaws.com/ip-ranges.json"},
+		parseRanges: parseCloudFrontRanges,
+		clientIP: func(r *http.Request) string {
+			if address := r.Header.Get("CloudFront-Viewer-Address"); len(address) > 0 {
+				// ip:port, the ip may be an IPv6 address without brackets
+				if i := strings.LastIndex(address, ":"); i > 0 {
+					return address[:i]
+				}
+				return address
+			}
+			// CloudFront appends the viewer address to X-Forwarded-For
+			forwardedFor := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+			return strings.TrimSpace(forwardedFor[len(forwardedFor)-1])
+		},
+	},
+}
+
+// RealIP is a middleware that restores the client IP of the requests sent by a
+// trusted CDN, so the logs and the next middlewares see the client rather than the CDN.
+type RealIP struct {
+	cdnNames []string
+	client   *http.Client
+	lock     sync.RWMutex
+	ranges   map[string][]*net.IPNet
+}
+
+// NewRealIP builds a new RealIP given a config. The IP ranges are empty until Refresh is called.
+func NewRealIP(config *types.RealIP) (*RealIP, error) {
+	if config == nil || len(config.CDNs) == 0 {
+		return nil, fmt.Errorf("Error creating RealIP: no CDN configured")
+	}
+	realIP := &RealIP{
+		client: &http.Client{Timeout: 30 * time.Second},
+		ranges: make(map[string][]*net.IPNet),
+	}
+	for _, name := range config.CDNs {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := cdns[name]; !ok {
+			return nil, fmt.Errorf("Error creating RealIP: unknown CDN %s", name)
+		}
+		realIP.cdnNames = append(realIP.cdnNames, name)
+	}
+	return realIP, nil
+}
+
+// Refresh fetches the published IP ranges of the CDNs. The previous ranges of
+// a CDN are kept if they can't be fetched.
+func (r *RealIP) Refresh() {
+	for _, name := range r.cdnNames {
+		ranges, err := r.fetchRanges(cdns[name])
+		if err != nil {
+			log.Errorf("Error fetching the IP ranges of %s, keeping the previous ones: %v", name, err)
+			continue
+		}
+		log.Debugf("Fetched %d IP ranges of %s", len(ranges), name)
+		r.setRanges(name, ranges)
+	}
+}
+
+// Run refreshes the IP ranges now, then every interval until stop is closed.
+func (r *RealIP) Run(stop chan bool, interval time.Duration) {
+	r.Refresh()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.Refresh()
+		}
+	}
+}
+
+func (r *RealIP) fetchRanges(c *cdn) ([]*net.IPNet, error) {
+	ranges := []*net.IPNet{}
+	for _, rangesURL := range c.rangesURLs {
+		response, err := r.client.Get(rangesURL)
+		if err != nil {
+			return nil, err
+		}
+		if response.StatusCode != http.StatusOK {
+			response.Body.Close()
+			return nil, fmt.Errorf("%s answered %s", rangesURL, response.Status)
+		}
+		urlRanges, err := c.parseRanges(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, urlRanges...)
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no IP range published")
+	}
+	return ranges, nil
+}
+
+// setRanges replaces the IP ranges of a CDN.
+func (r *RealIP) setRanges(name string, ranges []*net.IPNet) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.ranges[name] = ranges
+}
+
+// trustedCDN returns the CDN the ip belongs to, nil if none.
+func (r *RealIP) trustedCDN(ip net.IP) *cdn {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for _, name := range r.cdnNames {
+		for _, ipRange := range r.ranges[name] {
+			if ipRange.Contains(ip) {
+				return cdns[name]
+			}
+		}
+	}
+	return nil
+}
+
+// ServeHTTP is a function used by negroni
+func (r *RealIP) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	host, port, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		next.ServeHTTP(rw, req)
+		return
+	}
+	if trusted := r.trustedCDN(net.ParseIP(host)); trusted != nil {
+		if clientIP := net.ParseIP(strings.TrimSpace(trusted.clientIP(req))); clientIP != nil {
+			req.RemoteAddr = net.JoinHostPort(clientIP.String(), port)
+		}
+	}
+	next.ServeHTTP(rw, req)
+}
+
+// parseCIDRList parses a list of IP ranges, one per line.
+func parseCIDRList(body io.Reader) ([]*net.IPNet, error) {
+	ranges := []*net.IPNet{}
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		_, ipRange, err := net.ParseCIDR(line)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, ipRange)
+	}
+	return ranges, scanner.Err()
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	ranges := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, ipRange)
+	}
+	return ranges, nil
+}
+
+func parseFastlyRanges(body io.Reader) ([]*net.IPNet, error) {
+	list := struct {
+		Addresses     []string `json:"addresses"`
+		IPv6Addresses []string `json:"ipv6_addresses"`
+	}{}
+	if err := json.NewDecoder(body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return parseCIDRs(append(list.Addresses, list.IPv6Addresses...))
+}
+
+func parseCloudFrontRanges(body io.Reader) ([]*net.IPNet, error) {
+	list := struct {
+		Prefixes []struct {
+			IPPrefix string `json:"ip_prefix"`
+			Service  string `json:"service"`
+		} `json:"prefixes"`
+		IPv6Prefixes []struct {
+			IPv6Prefix string `json:"ipv6_prefix"`
+			Service    string `json:"service"`
+		} `json:"ipv6_prefixes"`
+	}{}
+	if err := json.NewDecoder(body).Decode(&list); err != nil {
+		return nil, err
+	}
+	cidrs := []string{}
+	for _, prefix := range list.Prefixes {
+		if prefix.Service == "CLOUDFRONT" {
+			cidrs = append(cidrs, prefix.IPPrefix)
+		}
+	}
+	for _, prefix := range list.IPv6Prefixes {
+		if prefix.Service == "CLOUDFRONT" {
+			cidrs = append(cidrs, prefix.IPv6Prefix)
+		}
+	}
+	return parseCIDRs(cidrs)
+}
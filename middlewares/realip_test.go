@@ -0,0 +1,105 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealIP(t *testing.T) {
+	realIP, err := NewRealIP(&types.RealIP{CDNs: types.CDNs{"cloudflare", "CloudFront"}})
+	assert.NoError(t, err)
+	cloudflareRanges, err := parseCIDRList(strings.NewReader("173.245.48.0/20\n\n2400:cb00::/32\n"))
+	assert.NoError(t, err)
+	realIP.setRanges("cloudflare", cloudflareRanges)
+	cloudFrontRanges, err := parseCloudFrontRanges(strings.NewReader(`{
+		"prefixes": [{"ip_prefix": "13.32.0.0/15", "service": "CLOUDFRONT"}, {"ip_prefix": "3.5.140.0/22", "service": "AMAZON"}],
+		"ipv6_prefixes": [{"ipv6_prefix": "2600:9000::/28", "service": "CLOUDFRONT"}]
+	}`))
+	assert.NoError(t, err)
+	assert.Len(t, cloudFrontRanges, 2)
+	realIP.setRanges("cloudfront", cloudFrontRanges)
+
+	cases := []struct {
+		desc       string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			desc:       "cloudflare",
+			remoteAddr: "173.245.48.10:4242",
+			headers:    map[string]string{"CF-Connecting-IP": "198.51.100.7"},
+			expected:   "198.51.100.7:4242",
+		},
+		{
+			desc:       "cloudflare ipv6",
+			remoteAddr: "[2400:cb00::1]:4242",
+			headers:    map[string]string{"CF-Connecting-IP": "2001:db8::7"},
+			expected:   "[2001:db8::7]:4242",
+		},
+		{
+			desc:       "untrusted peer",
+			remoteAddr: "192.0.2.1:4242",
+			headers:    map[string]string{"CF-Connecting-IP": "198.51.100.7"},
+			expected:   "192.0.2.1:4242",
+		},
+		{
+			desc:       "header of another cdn",
+			remoteAddr: "173.245.48.10:4242",
+			headers:    map[string]string{"Fastly-Client-IP": "198.51.100.7"},
+			expected:   "173.245.48.10:4242",
+		},
+		{
+			desc:       "invalid client ip",
+			remoteAddr: "173.245.48.10:4242",
+			headers:    map[string]string{"CF-Connecting-IP": "unknown"},
+			expected:   "173.245.48.10:4242",
+		},
+		{
+			desc:       "cloudfront viewer address",
+			remoteAddr: "13.33.1.1:4242",
+			headers:    map[string]string{"CloudFront-Viewer-Address": "2001:db8::7:5555", "X-Forwarded-For": "10.0.0.1, 198.51.100.8"},
+			expected:   "[2001:db8::7]:4242",
+		},
+		{
+			desc:       "cloudfront forwarded for",
+			remoteAddr: "13.33.1.1:4242",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.1, 198.51.100.8"},
+			expected:   "198.51.100.8:4242",
+		},
+	}
+	for _, c := range cases {
+		request, err := http.NewRequest("GET", "http://example.com/", nil)
+		assert.NoError(t, err)
+		request.RemoteAddr = c.remoteAddr
+		for name, value := range c.headers {
+			request.Header.Set(name, value)
+		}
+		remoteAddr := ""
+		realIP.ServeHTTP(httptest.NewRecorder(), request, func(rw http.ResponseWriter, r *http.Request) {
+			remoteAddr = r.RemoteAddr
+		})
+		assert.Equal(t, c.expected, remoteAddr, c.desc)
+	}
+}
+
+func TestNewRealIP(t *testing.T) {
+	_, err := NewRealIP(&types.RealIP{})
+	assert.Error(t, err)
+	_, err = NewRealIP(&types.RealIP{CDNs: types.CDNs{"akamai"}})
+	assert.Error(t, err)
+}
+
+func TestParseFastlyRanges(t *testing.T) {
+	ranges, err := parseFastlyRanges(strings.NewReader(`{"addresses": ["23.235.32.0/20"], "ipv6_addresses": ["2a04:4e40::/32"]}`))
+	assert.NoError(t, err)
+	assert.Len(t, ranges, 2)
+	assert.True(t, ranges[0].Contains(net.ParseIP("23.235.33.1")))
+	assert.True(t, ranges[1].Contains(net.ParseIP("2a04:4e40::1")))
+}
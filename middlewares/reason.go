@@ -0,0 +1,155 @@
+package middlewares
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/vulcand/oxy/connlimit"
+	"github.com/vulcand/oxy/utils"
+)
+
+// Reason codes of the error responses generated by traefik itself, as opposed
+// to the error responses returned by the backends.
+const (
+	ReasonNoRoute            = "no_route"
+	ReasonNoServer           = "no_server"
+	ReasonBackendUnreachable = "backend_unreachable"
+	ReasonBackendTimeout     = "backend_timeout"
+	ReasonCircuitOpen        = "circuit_open"
+	ReasonMaxConn            = "max_conn"
+	ReasonAuthFailed         = "auth_failed"
+	ReasonAuthLockedOut      = "auth_locked_out"
+	ReasonAuthUnavailable    = "auth_unavailable"
+	ReasonMaintenance        = "maintenance"
+	ReasonFaultInjected      = "fault_injected"
+	ReasonInvalidPath        = "invalid_path"
+	ReasonInvalidHeader      = "invalid_header"
+	ReasonMaxDuration        = "max_duration"
+	ReasonStreamStalled      = "stream_stalled"
+	ReasonSNIMismatch        = "sni_mismatch"
+	ReasonGraphQLInvalid     = "graphql_invalid"
+	ReasonGraphQLLimit       = "graphql_limit"
+	ReasonFrontendDrained    = "frontend_drained"
+	ReasonClientCertMissing  = "client_cert_missing"
+	ReasonClientCertInvalid  = "client_cert_invalid"
+	ReasonConcurrencyLimit   = "concurrency_limit"
+	ReasonACLDenied          = "acl_denied"
+	ReasonPolicyDenied       = "policy_denied"
+	ReasonPolicyUnavailable  = "policy_unavailable"
+	ReasonEgressDenied       = "egress_denied"
+)
+
+type requestInfoKey struct{}
+
+// requestInfo is shared by the middlewares crossed by a request, it tells the
+// entrypoint middlewares which frontend served it, and why traefik failed it.
+// It also keeps the GraphQL operations of the request once parsed, whether
+// a frontend verified its client certificate, and the authenticated user.
+type requestInfo struct {
+	frontend           string
+	reason             string
+	graphql            *graphQLAnalysis
+	clientCertVerified bool
+	user               string
+}
+
+// WithRequestInfo returns r with a context holding the frontend and the error
+// reason set by the next middlewares. r is returned as is if it already has one.
+func WithRequestInfo(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, &requestInfo{}))
+}
+
+func getRequestInfo(r *http.Request) *requestInfo {
+	info, _ := r.Context().Value(requestInfoKey{}).(*requestInfo)
+	return info
+}
+
+// SetFrontendName records the name of the frontend serving r.
+func SetFrontendName(r *http.Request, frontendName string) {
+	if info := getRequestInfo(r); info != nil {
+		info.frontend = frontendName
+	}
+}
+
+// GetFrontendName returns the name of the frontend serving r, or an empty
+// string if r was not routed.
+func GetFrontendName(r *http.Request) string {
+	if info := getRequestInfo(r); info != nil {
+		return info.frontend
+	}
+	return ""
+}
+
+// SetErrorReason records the reason code of the error response generated by traefik for r.
+func SetErrorReason(r *http.Request, reason string) {
+	if info := getRequestInfo(r); info != nil {
+		info.reason = reason
+	}
+}
+
+// GetErrorReason returns the reason code of the error response generated by
+// traefik for r, or an empty string if the response comes from a backend.
+func GetErrorReason(r *http.Request) string {
+	if info := getRequestInfo(r); info != nil {
+		return info.reason
+	}
+	return ""
+}
+
+// setAuthenticatedUser records the user authenticated for r.
+func setAuthenticatedUser(r *http.Request, user string) {
+	if info := getRequestInfo(r); info != nil {
+		info.user = user
+	}
+}
+
+// GetAuthenticatedUser returns the user authenticated for r by the entrypoint
+// or the frontend, or an empty string.
+func GetAuthenticatedUser(r *http.Request) string {
+	if info := getRequestInfo(r); info != nil {
+		return info.user
+	}
+	return ""
+}
+
+// FrontendHandler returns a handler recording frontendName as the frontend of the requests served by next.
+func FrontendHandler(frontendName string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		SetFrontendName(r, frontendName)
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// ForwardErrorHandler records the reason of the errors forwarding the requests to the servers.
+var ForwardErrorHandler = utils.ErrorHandlerFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		SetErrorReason(r, ReasonBackendTimeout)
+	} else {
+		SetErrorReason(r, ReasonBackendUnreachable)
+	}
+	utils.DefaultHandler.ServeHTTP(w, r, err)
+})
+
+// LoadBalancerErrorHandler records the reason of the errors picking a server of a backend.
+var LoadBalancerErrorHandler = utils.ErrorHandlerFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+	SetErrorReason(r, ReasonNoServer)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+})
+
+// ConnLimitErrorHandler records the reason of the requests rejected by the connection limit of a backend.
+var ConnLimitErrorHandler = utils.ErrorHandlerFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+	SetErrorReason(r, ReasonMaxConn)
+	(&connlimit.ConnErrHandler{}).ServeHTTP(w, r, err)
+})
+
+// CircuitBreakerFallback records the reason of the requests rejected by an open circuit breaker.
+var CircuitBreakerFallback = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	SetErrorReason(r, ReasonCircuitOpen)
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write([]byte(http.StatusText(http.StatusServiceUnavailable)))
+})
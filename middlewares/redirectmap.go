@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/containous/traefik/redirects"
+	"github.com/containous/traefik/types"
+)
+
+// RedirectMap is a middleware answering the requests of the sources of a
+// redirect map with a redirect to their destination.
+type RedirectMap struct {
+	redirects *redirects.Map
+}
+
+// NewRedirectMap returns a RedirectMap loading the redirects of config.
+func NewRedirectMap(config *types.RedirectMap) (*RedirectMap, error) {
+	m, err := redirects.New(config)
+	if err != nil {
+		return nil, err
+	}
+	return &RedirectMap{redirects: m}, nil
+}
+
+func (m *RedirectMap) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	redirect := m.redirects.Lookup(r.Host, r.URL.Path, r.URL.RawQuery)
+	if redirect == nil {
+		next(rw, r)
+		return
+	}
+	http.Redirect(rw, r, redirect.URL, redirect.Status)
+}
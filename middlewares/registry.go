@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/codegangsta/negroni"
+)
+
+// Constructor creates the handler of a registered middleware for a frontend.
+type Constructor func(frontendName string) (negroni.Handler, error)
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]Constructor{}
+)
+
+// Register makes the middleware created by constructor available to the
+// frontends listing name in their middlewares. It is meant to be called by
+// the init functions of the packages added by forks and embedders, and panics
+// if name is registered twice or constructor is nil.
+func Register(name string, constructor Constructor) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if constructor == nil {
+		panic("middlewares: Register constructor is nil for " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("middlewares: Register called twice for " + name)
+	}
+	registry[name] = constructor
+}
+
+// Registered returns the sorted names of the registered middlewares.
+func Registered() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	names := []string{}
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewRegistered returns the handler of the registered middleware name for
+// frontendName, passing the requests to next.
+func NewRegistered(name string, frontendName string, next http.Handler) (http.Handler, error) {
+	registryLock.RLock()
+	constructor, ok := registry[name]
+	registryLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown middleware %q", name)
+	}
+	handler, err := constructor(frontendName)
+	if err != nil {
+		return nil, err
+	}
+	n := negroni.New(handler)
+	n.UseHandler(next)
+	return n, nil
+}
@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codegangsta/negroni"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	Register("test-frontend-header", func(frontendName string) (negroni.Handler, error) {
+		return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+			r.Header.Set("X-Frontend", frontendName)
+			next(rw, r)
+		}), nil
+	})
+	Register("test-failing", func(frontendName string) (negroni.Handler, error) {
+		return nil, errors.New("invalid frontend " + frontendName)
+	})
+	assert.Contains(t, Registered(), "test-frontend-header")
+	assert.Panics(t, func() {
+		Register("test-frontend-header", func(frontendName string) (negroni.Handler, error) { return nil, nil })
+	})
+
+	var forwarded *http.Request
+	handler, err := NewRegistered("test-frontend-header", "frontend1", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		forwarded = r
+	}))
+	assert.NoError(t, err)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "frontend1", forwarded.Header.Get("X-Frontend"))
+
+	_, err = NewRegistered("test-failing", "frontend1", http.NotFoundHandler())
+	assert.EqualError(t, err, "invalid frontend frontend1")
+	_, err = NewRegistered("test-unknown", "frontend1", http.NotFoundHandler())
+	assert.Error(t, err)
+}
@@ -33,6 +33,8 @@ func NewRetry(attempts int, next http.Handler) *Retry {
 func (retry *Retry) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	attempts := 1
 	for {
+		// the reason of a failed attempt doesn't apply to the next one
+		SetErrorReason(r, "")
 		recorder := NewRecorder()
 		recorder.responseWriter = rw
 		retry.next.ServeHTTP(recorder, r)
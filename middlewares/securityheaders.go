@@ -0,0 +1,153 @@
+package middlewares
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/containous/traefik/types"
+)
+
+// Security headers profiles
+const (
+	SecurityProfileBasic  = "basic"
+	SecurityProfileStrict = "strict"
+)
+
+type securityProfile struct {
+	headers      map[string]string
+	stripHeaders []string
+	stsSeconds   int64
+	stsOptions   string
+}
+
+var securityProfiles = map[string]securityProfile{
+	SecurityProfileBasic: {
+		headers: map[string]string{
+			"X-Content-Type-Options": "nosniff",
+			"X-Frame-Options":        "SAMEORIGIN",
+			"Referrer-Policy":        "strict-origin-when-cross-origin",
+		},
+		stripHeaders: []string{"X-Powered-By"},
+	},
+	SecurityProfileStrict: {
+		headers: map[string]string{
+			"X-Content-Type-Options": "nosniff",
+			"X-Frame-Options":        "DENY",
+			"Referrer-Policy":        "no-referrer",
+		},
+		stripHeaders: []string{"Server", "X-Powered-By", "X-AspNet-Version", "X-AspNetMvc-Version"},
+		stsSeconds:   31536000,
+		stsOptions:   "; includeSubDomains",
+	},
+}
+
+// SecurityHeaders is a middleware adding the security headers of a profile to
+// the responses, and stripping the headers revealing the software of the servers.
+// The headers already set, by the backends or the security headers of a
+// frontend, are kept.
+type SecurityHeaders struct {
+	headers      map[string]string
+	stripHeaders []string
+	sts          string
+}
+
+// NewSecurityHeaders returns a SecurityHeaders applying config, or an error if its profile is unknown.
+func NewSecurityHeaders(config *types.SecurityHeaders) (*SecurityHeaders, error) {
+	profile, ok := securityProfiles[config.Profile]
+	if !ok && len(config.Profile) > 0 {
+		return nil, fmt.Errorf("invalid security headers profile %q, expected basic or strict", config.Profile)
+	}
+	if config.STSSeconds < 0 {
+		return nil, fmt.Errorf("invalid negative STS seconds %d", config.STSSeconds)
+	}
+	s := &SecurityHeaders{headers: map[string]string{}}
+	for name, value := range profile.headers {
+		s.headers[name] = value
+	}
+	// an empty value removes a header of the profile
+	for name, value := range config.Headers {
+		name = http.CanonicalHeaderKey(name)
+		if len(value) == 0 {
+			delete(s.headers, name)
+		} else {
+			s.headers[name] = value
+		}
+	}
+	s.stripHeaders = append(append(s.stripHeaders, profile.stripHeaders...), config.StripHeaders...)
+	stsSeconds := profile.stsSeconds
+	if config.STSSeconds > 0 {
+		stsSeconds = config.STSSeconds
+	}
+	if stsSeconds > 0 {
+		s.sts = "max-age=" + strconv.FormatInt(stsSeconds, 10) + profile.stsOptions
+	}
+	return s, nil
+}
+
+func (s *SecurityHeaders) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	next(&securityHeadersWriter{ResponseWriter: rw, headers: s, tls: r.TLS != nil}, r)
+}
+
+// Handler returns a handler adding the security headers to the responses of next.
+func (s *SecurityHeaders) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		s.ServeHTTP(rw, r, next.ServeHTTP)
+	})
+}
+
+func (s *SecurityHeaders) apply(header http.Header, tls bool) {
+	for _, name := range s.stripHeaders {
+		header.Del(name)
+	}
+	for name, value := range s.headers {
+		if _, ok := header[name]; !ok {
+			header.Set(name, value)
+		}
+	}
+	// the browsers ignore the HSTS headers received over HTTP (RFC 6797)
+	if tls && len(s.sts) > 0 && len(header.Get("Strict-Transport-Security")) == 0 {
+		header.Set("Strict-Transport-Security", s.sts)
+	}
+}
+
+type securityHeadersWriter struct {
+	http.ResponseWriter
+	headers     *SecurityHeaders
+	tls         bool
+	wroteHeader bool
+}
+
+func (w *securityHeadersWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.headers.apply(w.ResponseWriter.Header(), w.tls)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *securityHeadersWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *securityHeadersWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *securityHeadersWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *securityHeadersWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	backend := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "nginx/1.10")
+		w.Header().Set("X-Powered-By", "PHP/7.0")
+		w.Header().Set("Referrer-Policy", "origin")
+		w.Write([]byte("ok"))
+	})
+	strict, err := NewSecurityHeaders(&types.SecurityHeaders{
+		Profile:      SecurityProfileStrict,
+		Headers:      map[string]string{"content-security-policy": "default-src 'self'", "X-Frame-Options": ""},
+		StripHeaders: []string{"X-Generator"},
+	})
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.TLS = &tls.ConnectionState{}
+	recorder := httptest.NewRecorder()
+	strict.Handler(backend).ServeHTTP(recorder, request)
+	header := recorder.Header()
+	assert.Equal(t, "", header.Get("Server"))
+	assert.Equal(t, "", header.Get("X-Powered-By"))
+	assert.Equal(t, "origin", header.Get("Referrer-Policy"))
+	assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+	assert.Equal(t, "default-src 'self'", header.Get("Content-Security-Policy"))
+	assert.Equal(t, "", header.Get("X-Frame-Options"))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", header.Get("Strict-Transport-Security"))
+
+	request.TLS = nil
+	recorder = httptest.NewRecorder()
+	strict.Handler(backend).ServeHTTP(recorder, request)
+	assert.Equal(t, "", recorder.Header().Get("Strict-Transport-Security"))
+
+	// the headers of the frontend win over those of the entrypoint
+	basic, err := NewSecurityHeaders(&types.SecurityHeaders{Profile: SecurityProfileBasic, STSSeconds: 600})
+	assert.NoError(t, err)
+	request.TLS = &tls.ConnectionState{}
+	recorder = httptest.NewRecorder()
+	strict.Handler(basic.Handler(http.NotFoundHandler())).ServeHTTP(recorder, request)
+	header = recorder.Header()
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "SAMEORIGIN", header.Get("X-Frame-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", header.Get("Referrer-Policy"))
+	assert.Equal(t, "max-age=600", header.Get("Strict-Transport-Security"))
+
+	_, err = NewSecurityHeaders(&types.SecurityHeaders{Profile: "paranoid"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/containous/traefik/log"
+)
+
+// SNIChecker is a middleware rejecting the requests received over TLS with a
+// Host header not matching the server name (SNI) of their connection, so that
+// the host rules can't be reached through the connection of another host.
+type SNIChecker struct {
+	exemptions []string
+}
+
+// NewSNIChecker returns a SNIChecker not checking the hosts of exemptions,
+// which are host names or *.domain wildcards.
+func NewSNIChecker(exemptions []string) *SNIChecker {
+	checker := &SNIChecker{}
+	for _, exemption := range exemptions {
+		checker.exemptions = append(checker.exemptions, strings.ToLower(exemption))
+	}
+	return checker
+}
+
+func (c *SNIChecker) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.TLS == nil {
+		next(rw, r)
+		return
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	serverName := strings.TrimSuffix(strings.ToLower(r.TLS.ServerName), ".")
+	if host == serverName || c.exempted(host) {
+		next(rw, r)
+		return
+	}
+	// the clients can't send an IP address as server name
+	if len(serverName) == 0 && net.ParseIP(strings.Trim(host, "[]")) != nil {
+		next(rw, r)
+		return
+	}
+	log.Debugf("Rejecting the request to host %s on a connection to server name %s", host, serverName)
+	SetErrorReason(r, ReasonSNIMismatch)
+	// the HTTP/2 clients retry the misdirected requests on a new connection
+	http.Error(rw, "Misdirected Request", 421)
+}
+
+func (c *SNIChecker) exempted(host string) bool {
+	for _, exemption := range c.exemptions {
+		if exemption == host || strings.HasPrefix(exemption, "*.") && strings.HasSuffix(host, exemption[1:]) {
+			return true
+		}
+	}
+	return false
+}
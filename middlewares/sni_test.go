@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSNIChecker(t *testing.T) {
+	checker := NewSNIChecker([]string{"legacy.example.com", "*.Internal.example.com"})
+	next := func(w http.ResponseWriter, r *http.Request) {}
+	cases := []struct {
+		host       string
+		serverName string
+		tls        bool
+		expected   int
+	}{
+		{"test.example.com", "", false, http.StatusOK},
+		{"test.example.com", "test.example.com", true, http.StatusOK},
+		{"Test.example.com:443", "test.example.com", true, http.StatusOK},
+		{"test.example.com.", "test.example.com", true, http.StatusOK},
+		{"admin.example.com", "test.example.com", true, 421},
+		{"admin.example.com", "", true, 421},
+		{"legacy.example.com", "test.example.com", true, http.StatusOK},
+		{"api.internal.example.com", "test.example.com", true, http.StatusOK},
+		{"internal.example.com", "test.example.com", true, 421},
+		{"10.0.0.1", "", true, http.StatusOK},
+		{"[::1]:443", "", true, http.StatusOK},
+	}
+	for _, c := range cases {
+		request := WithRequestInfo(httptest.NewRequest("GET", "/", nil))
+		request.Host = c.host
+		if c.tls {
+			request.TLS = &tls.ConnectionState{ServerName: c.serverName}
+		} else {
+			request.TLS = nil
+		}
+		recorder := httptest.NewRecorder()
+		checker.ServeHTTP(recorder, request, next)
+		assert.Equal(t, c.expected, recorder.Code, c.host+" "+c.serverName)
+		if c.expected == 421 {
+			assert.Equal(t, ReasonSNIMismatch, GetErrorReason(request))
+		}
+	}
+}
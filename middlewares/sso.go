@@ -0,0 +1,91 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+const (
+	defaultSSOMaxAge = 8 * 3600
+	// ssoMinSecretSize is the minimum size of the secrets signing the cookies
+	ssoMinSecretSize = 16
+)
+
+// sso issues and checks the single sign-on cookies of an authentication
+// group, holding the name of the user and the expiry of the cookie, signed
+// with the secret of the group.
+type sso struct {
+	config types.SSO
+}
+
+func newSSO(config *types.SSO) (*sso, error) {
+	s := &sso{config: *config}
+	if len(s.config.Group) == 0 {
+		return nil, errors.New("SSO group is required")
+	}
+	if len(s.config.Secret) < ssoMinSecretSize {
+		return nil, errors.New("SSO secret must be at least 16 characters long")
+	}
+	if len(s.config.CookieName) == 0 {
+		s.config.CookieName = "traefik_sso_" + s.config.Group
+	}
+	if s.config.MaxAge <= 0 {
+		s.config.MaxAge = defaultSSOMaxAge
+	}
+	return s, nil
+}
+
+// user returns the user of the valid SSO cookie of r, or an empty string.
+func (s *sso) user(r *http.Request, now time.Time) string {
+	cookie, err := r.Cookie(s.config.CookieName)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, s.sign(parts[0]+"."+parts[1])) {
+		return ""
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() >= expires {
+		return ""
+	}
+	user, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ""
+	}
+	return string(user)
+}
+
+// issue sets the SSO cookie of user on w.
+func (s *sso) issue(w http.ResponseWriter, r *http.Request, user string, now time.Time) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(user)) + "." + strconv.FormatInt(now.Unix()+int64(s.config.MaxAge), 10)
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.config.CookieName,
+		Value:    payload + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload)),
+		Path:     "/",
+		Domain:   s.config.Domain,
+		MaxAge:   s.config.MaxAge,
+		Secure:   r.TLS != nil,
+		HttpOnly: true,
+	})
+}
+
+// sign returns the signature of payload, bound to the group so that the
+// cookies of a group are not accepted by another one sharing its secret
+func (s *sso) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(s.config.Secret))
+	mac.Write([]byte(s.config.Group + "\n" + payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,80 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codegangsta/negroni"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSSOAuthenticator(t *testing.T, users []string, group string) *negroni.Negroni {
+	authMiddleware, err := NewAuthenticator(&types.Auth{
+		Basic: &types.Basic{Users: users},
+		SSO:   &types.SSO{Group: group, Secret: "0123456789abcdef", Domain: "example.org"},
+	})
+	assert.NoError(t, err, "there should be no error")
+	n := negroni.New(authMiddleware)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	return n
+}
+
+func TestSSOAcrossFrontends(t *testing.T) {
+	first := newSSOAuthenticator(t, []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}, "internal")
+	second := newSSOAuthenticator(t, []string{"other:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}, "internal")
+	otherGroup := newSSOAuthenticator(t, []string{"other:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"}, "external")
+
+	request := httptest.NewRequest("GET", "http://app1.example.org/", nil)
+	request.SetBasicAuth("test", "test")
+	recorder := httptest.NewRecorder()
+	first.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	cookies := (&http.Response{Header: recorder.Header()}).Cookies()
+	assert.Len(t, cookies, 1)
+	cookie := cookies[0]
+	assert.Equal(t, "traefik_sso_internal", cookie.Name)
+	assert.Equal(t, "example.org", cookie.Domain)
+	assert.True(t, cookie.HttpOnly)
+
+	serve := func(n *negroni.Negroni, cookie *http.Cookie) int {
+		request := httptest.NewRequest("GET", "http://app2.example.org/", nil)
+		request.AddCookie(cookie)
+		recorder := httptest.NewRecorder()
+		n.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusOK, serve(second, cookie), "the cookie should be accepted by the frontends of the group")
+	otherCookie := *cookie
+	otherCookie.Name = "traefik_sso_external"
+	assert.Equal(t, http.StatusUnauthorized, serve(otherGroup, &otherCookie), "the cookie should not be accepted by another group")
+
+	tampered := *cookie
+	parts := strings.Split(tampered.Value, ".")
+	parts[1] = "99999999999"
+	tampered.Value = strings.Join(parts, ".")
+	assert.Equal(t, http.StatusUnauthorized, serve(second, &tampered))
+}
+
+func TestSSOExpiry(t *testing.T) {
+	s, err := newSSO(&types.SSO{Group: "internal", Secret: "0123456789abcdef", MaxAge: 60})
+	assert.NoError(t, err)
+	now := time.Now()
+	recorder := httptest.NewRecorder()
+	s.issue(recorder, httptest.NewRequest("GET", "/", nil), "test", now)
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("Cookie", recorder.Header().Get("Set-Cookie"))
+	assert.Equal(t, "test", s.user(request, now.Add(59*time.Second)))
+	assert.Equal(t, "", s.user(request, now.Add(60*time.Second)))
+
+	_, err = newSSO(&types.SSO{Group: "internal", Secret: "short"})
+	assert.Error(t, err)
+	_, err = newSSO(&types.SSO{Secret: "0123456789abcdef"})
+	assert.Error(t, err)
+	_, err = NewAuthenticator(&types.Auth{SSO: &types.SSO{Group: "internal", Secret: "0123456789abcdef"}})
+	assert.Error(t, err)
+}
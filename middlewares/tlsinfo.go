@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var tlsVersionNames = map[uint16]string{
+	tls.VersionSSL30: "SSL3.0",
+	tls.VersionTLS10: "TLS1.0",
+	tls.VersionTLS11: "TLS1.1",
+	tls.VersionTLS12: "TLS1.2",
+	0x0304:           "TLS1.3",
+}
+
+var tlsCipherSuiteNames = map[uint16]string{
+	tls.TLS_RSA_WITH_RC4_128_SHA:                "TLS_RSA_WITH_RC4_128_SHA",
+	tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA:           "TLS_RSA_WITH_3DES_EDE_CBC_SHA",
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA:            "TLS_RSA_WITH_AES_128_CBC_SHA",
+	tls.TLS_RSA_WITH_AES_256_CBC_SHA:            "TLS_RSA_WITH_AES_256_CBC_SHA",
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256:         "TLS_RSA_WITH_AES_128_GCM_SHA256",
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384:         "TLS_RSA_WITH_AES_256_GCM_SHA384",
+	tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA:        "TLS_ECDHE_ECDSA_WITH_RC4_128_SHA",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA:    "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA:    "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA",
+	tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA:          "TLS_ECDHE_RSA_WITH_RC4_128_SHA",
+	tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA:     "TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA",
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA:      "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+	tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA:      "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA",
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256:   "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384:   "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384: "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+	0x1301: "TLS_AES_128_GCM_SHA256",
+	0x1302: "TLS_AES_256_GCM_SHA384",
+	0x1303: "TLS_CHACHA20_POLY1305_SHA256",
+}
+
+// TLSVersionName returns the name of the TLS version, like TLS1.2.
+func TLSVersionName(version uint16) string {
+	if name, ok := tlsVersionNames[version]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+// TLSCipherSuiteName returns the name of the TLS cipher suite.
+func TLSCipherSuiteName(cipherSuite uint16) string {
+	if name, ok := tlsCipherSuiteNames[cipherSuite]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", cipherSuite)
+}
+
+// TLSInfo describes the TLS connection of a request.
+type TLSInfo struct {
+	Version       string
+	CipherSuite   string
+	ServerName    string
+	Protocol      string
+	ClientSubject string
+}
+
+// GetTLSInfo returns the description of the TLS connection of r, or nil if r wasn't received over TLS.
+func GetTLSInfo(r *http.Request) *TLSInfo {
+	if r.TLS == nil {
+		return nil
+	}
+	info := &TLSInfo{
+		Version:     TLSVersionName(r.TLS.Version),
+		CipherSuite: TLSCipherSuiteName(r.TLS.CipherSuite),
+		ServerName:  r.TLS.ServerName,
+		Protocol:    r.TLS.NegotiatedProtocol,
+	}
+	if len(r.TLS.PeerCertificates) > 0 {
+		subject := r.TLS.PeerCertificates[0].Subject
+		names := []string{}
+		if len(subject.CommonName) > 0 {
+			names = append(names, "CN="+subject.CommonName)
+		}
+		for _, ou := range subject.OrganizationalUnit {
+			names = append(names, "OU="+ou)
+		}
+		for _, o := range subject.Organization {
+			names = append(names, "O="+o)
+		}
+		info.ClientSubject = strings.Join(names, ",")
+	}
+	return info
+}
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTLSInfo(t *testing.T) {
+	request := httptest.NewRequest("GET", "/", nil)
+	assert.Nil(t, GetTLSInfo(request))
+
+	request.TLS = &tls.ConnectionState{
+		Version:            tls.VersionTLS11,
+		CipherSuite:        tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		ServerName:         "example.com",
+		NegotiatedProtocol: "h2",
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{
+			CommonName:         "client1",
+			OrganizationalUnit: []string{"ops"},
+			Organization:       []string{"Example"},
+		}}},
+	}
+	assert.Equal(t, &TLSInfo{
+		Version:       "TLS1.1",
+		CipherSuite:   "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		ServerName:    "example.com",
+		Protocol:      "h2",
+		ClientSubject: "CN=client1,OU=ops,O=Example",
+	}, GetTLSInfo(request))
+	assert.Equal(t, "0x0999", TLSCipherSuiteName(0x0999))
+}
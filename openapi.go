@@ -0,0 +1,2654 @@
+package main
+
+// openAPISpec is the OpenAPI definition of the web backend API, served at /api/openapi.json.
+// It must be updated with the routes of the WebProvider and the shapes of their responses.
+var openAPISpec = []byte(`{
+  "openapi": "3.0.0",
+  "info": {
+    "title": "Træfɪk API",
+    "description": "REST API of the traefik web backend",
+    "version": "1.0"
+  },
+  "paths": {
+    "/health": {
+      "get": {
+        "operationId": "getHealth",
+        "summary": "Statistics of the requests served by traefik",
+        "responses": {
+          "200": {
+            "description": "Health",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Health"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/ping": {
+      "get": {
+        "operationId": "ping",
+        "summary": "Liveness check",
+        "responses": {
+          "200": {
+            "description": "OK",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/ping/ready": {
+      "get": {
+        "operationId": "getReadiness",
+        "summary": "Readiness check, with the statuses of the providers",
+        "responses": {
+          "200": {
+            "description": "Ready, or degraded with the last configuration of the degraded providers applied",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Readiness"
+                }
+              }
+            }
+          },
+          "503": {
+            "description": "Starting, no configuration applied yet",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Readiness"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/metrics": {
+      "get": {
+        "operationId": "getMetrics",
+        "summary": "Expiry of the certificates served, egress counters of the forward proxies, statuses of the providers, and SLO compliance of the frontends, in the Prometheus text format",
+        "responses": {
+          "200": {
+            "description": "Metrics",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/api": {
+      "get": {
+        "operationId": "getConfigurations",
+        "summary": "Configurations of all providers",
+        "responses": {
+          "200": {
+            "description": "Configurations",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "additionalProperties": {
+                    "$ref": "#/components/schemas/Configuration"
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/api/openapi.json": {
+      "get": {
+        "operationId": "getOpenAPI",
+        "summary": "This OpenAPI definition",
+        "responses": {
+          "200": {
+            "description": "OpenAPI definition",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/api/version": {
+      "get": {
+        "operationId": "getVersion",
+        "summary": "Version of traefik",
+        "responses": {
+          "200": {
+            "description": "Version",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Version"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/api/slo": {
+      "get": {
+        "operationId": "getSLO",
+        "summary": "Service level objectives compliance of the frontends",
+        "responses": {
+          "200": {
+            "description": "SLO compliance",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "array",
+                  "items": {
+                    "$ref": "#/components/schemas/SLOStatus"
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/api/certificates": {
+      "get": {
+        "operationId": "getCertificates",
+        "summary": "Certificates served by the entrypoints",
+        "responses": {
+          "200": {
+            "description": "Certificates",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "array",
+                  "items": {
+                    "$ref": "#/components/schemas/Certificate"
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/api/fips": {
+      "get": {
+        "operationId": "getFIPS",
+        "summary": "FIPS compliance of the TLS parameters of the entrypoints",
+        "responses": {
+          "200": {
+            "description": "FIPS compliance",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/FIPSStatus"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/api/ca": {
+      "get": {
+        "operationId": "getCA",
+        "summary": "Certificate of the internal CA",
+        "responses": {
+          "200": {
+            "description": "PEM encoded CA certificate",
+            "content": {
+              "application/x-pem-file": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Internal CA is not enabled"
+          }
+        }
+      }
+    },
+    "/api/ca/certificates": {
+      "post": {
+        "operationId": "postCACertificate",
+        "summary": "Issue a serving certificate from the internal CA",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/x-pem-file": {
+              "schema": {
+                "type": "string",
+                "description": "PEM encoded certificate request"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "PEM encoded certificate, followed by the CA certificate",
+            "content": {
+              "application/x-pem-file": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid certificate request, or names not allowed"
+          },
+          "401": {
+            "description": "Invalid internal CA token"
+          },
+          "404": {
+            "description": "Internal CA is not enabled"
+          }
+        }
+      }
+    },
+    "/api/faults": {
+      "get": {
+        "operationId": "getFaults",
+        "summary": "Fault injection switch",
+        "responses": {
+          "200": {
+            "description": "Fault injection state",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/FaultInjectionStatus"
+                }
+              }
+            }
+          }
+        }
+      },
+      "put": {
+        "operationId": "putFaults",
+        "summary": "Switch the fault injection of all the frontends on or off",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/FaultInjectionStatus"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "Fault injection state",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/FaultInjectionStatus"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid body"
+          },
+          "403": {
+            "description": "Read only mode, only switching off is allowed"
+          }
+        }
+      }
+    },
+    "/api/config/history": {
+      "get": {
+        "operationId": "getHistory",
+        "summary": "Last applied dynamic configurations, the most recent first, with their changes and without their content",
+        "responses": {
+          "200": {
+            "description": "Configuration versions",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "array",
+                  "items": {
+                    "$ref": "#/components/schemas/ConfigurationVersion"
+                  }
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Configuration history is not enabled"
+          }
+        }
+      }
+    },
+    "/api/config/history/{version}": {
+      "get": {
+        "operationId": "getHistoryVersion",
+        "summary": "Applied dynamic configuration with its changes and content",
+        "parameters": [
+          {
+            "name": "version",
+            "in": "path",
+            "required": true,
+            "description": "Version number",
+            "schema": {
+              "type": "integer"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Configuration version",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ConfigurationVersion"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not found"
+          }
+        }
+      }
+    },
+    "/api/config/history/{version}/rollback": {
+      "post": {
+        "operationId": "rollbackConfiguration",
+        "summary": "Apply a version of the history again, until the providers send new configurations",
+        "parameters": [
+          {
+            "name": "version",
+            "in": "path",
+            "required": true,
+            "description": "Version number",
+            "schema": {
+              "type": "integer"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Configuration version of the rollback",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ConfigurationVersion"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "Read only mode"
+          },
+          "404": {
+            "description": "Not found"
+          },
+          "500": {
+            "description": "Configuration loading error"
+          }
+        }
+      }
+    },
+    "/api/freeze": {
+      "get": {
+        "operationId": "getFreeze",
+        "summary": "Configuration freeze state",
+        "responses": {
+          "200": {
+            "description": "Configuration freeze state",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ConfigurationFreezeStatus"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Configuration freeze is not enabled"
+          }
+        }
+      },
+      "put": {
+        "operationId": "putFreeze",
+        "summary": "Switch the configuration freeze on or off, the freeze windows and feature flag still apply",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/ConfigurationFreezeStatus"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "Configuration freeze state",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ConfigurationFreezeStatus"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid body"
+          },
+          "403": {
+            "description": "Read only mode, only switching on is allowed"
+          },
+          "404": {
+            "description": "Configuration freeze is not enabled"
+          }
+        }
+      }
+    },
+    "/api/drains": {
+      "get": {
+        "operationId": "getDrains",
+        "summary": "Drained frontends",
+        "responses": {
+          "200": {
+            "description": "Drains sorted by frontend",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "array",
+                  "items": {
+                    "$ref": "#/components/schemas/FrontendDrain"
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/api/frontends/{frontend}/drain": {
+      "parameters": [
+        {
+          "name": "frontend",
+          "in": "path",
+          "required": true,
+          "description": "Frontend name",
+          "schema": {
+            "type": "string"
+          }
+        }
+      ],
+      "get": {
+        "operationId": "getDrain",
+        "summary": "Drain of a frontend",
+        "responses": {
+          "200": {
+            "description": "Drain",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/FrontendDrain"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Frontend not drained"
+          }
+        }
+      },
+      "put": {
+        "operationId": "putDrain",
+        "summary": "Reject or redirect the new requests of a frontend, and cancel its in-flight requests once the window expires",
+        "requestBody": {
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/FrontendDrain"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "Drain",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/FrontendDrain"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid body"
+          },
+          "403": {
+            "description": "Read only mode"
+          },
+          "404": {
+            "description": "Not found"
+          }
+        }
+      },
+      "delete": {
+        "operationId": "deleteDrain",
+        "summary": "Let a drained frontend accept new requests again",
+        "responses": {
+          "204": {
+            "description": "Frontend resumed"
+          },
+          "403": {
+            "description": "Read only mode"
+          },
+          "404": {
+            "description": "Frontend not drained"
+          }
+        }
+      }
+    },
+    "/api/usage": {
+      "get": {
+        "operationId": "getUsage",
+        "summary": "Requests and bytes received and sent per frontend and tenant over a time window",
+        "parameters": [
+          {
+            "name": "from",
+            "in": "query",
+            "description": "Start of the window, RFC3339, rounded down to the hour",
+            "schema": {
+              "type": "string",
+              "format": "date-time"
+            }
+          },
+          {
+            "name": "to",
+            "in": "query",
+            "description": "End of the window, RFC3339, now by default",
+            "schema": {
+              "type": "string",
+              "format": "date-time"
+            }
+          },
+          {
+            "name": "window",
+            "in": "query",
+            "description": "Duration of the window ending at to when from is not set, 24h by default",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Usage over the window",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/UsageReport"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid window"
+          },
+          "404": {
+            "description": "Usage accounting is not enabled"
+          }
+        }
+      }
+    },
+    "/api/conflicts": {
+      "get": {
+        "operationId": "getConflicts",
+        "summary": "Shadowed and overlapping frontend rules",
+        "responses": {
+          "200": {
+            "description": "Conflicts",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "array",
+                  "items": {
+                    "$ref": "#/components/schemas/RouteConflict"
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/api/dryrun": {
+      "get": {
+        "operationId": "getDryRuns",
+        "summary": "Last configurations of the dry-run providers, never applied",
+        "responses": {
+          "200": {
+            "description": "Dry-run results",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "array",
+                  "items": {
+                    "$ref": "#/components/schemas/DryRunResult"
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/api/dryrun/{provider}": {
+      "get": {
+        "operationId": "getDryRun",
+        "summary": "Last configuration of a dry-run provider, with its changes and conflicts against the live configuration",
+        "parameters": [
+          {
+            "name": "provider",
+            "in": "path",
+            "required": true,
+            "description": "Provider name",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Dry-run result",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/DryRunResult"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not found"
+          }
+        }
+      }
+    },
+    "/api/frontends/{frontend}/pipeline": {
+      "get": {
+        "operationId": "getPipeline",
+        "summary": "Ordered middlewares crossed by the requests of a frontend",
+        "responses": {
+          "200": {
+            "description": "Pipeline",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/FrontendPipeline"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not found"
+          }
+        },
+        "parameters": [
+          {
+            "name": "frontend",
+            "in": "path",
+            "required": true,
+            "description": "Frontend name",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ]
+      }
+    },
+    "/api/frontends/{frontend}/tap": {
+      "get": {
+        "operationId": "tapFrontend",
+        "summary": "Live feed of the requests served by a frontend, as server-sent events of TapEvent",
+        "responses": {
+          "200": {
+            "description": "Event stream",
+            "content": {
+              "text/event-stream": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid parameters"
+          },
+          "403": {
+            "description": "Web authentication is not enabled"
+          },
+          "404": {
+            "description": "Not found"
+          }
+        },
+        "parameters": [
+          {
+            "name": "frontend",
+            "in": "path",
+            "required": true,
+            "description": "Frontend name",
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "duration",
+            "in": "query",
+            "description": "Tap duration, default 30s, at most 5m",
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "sample",
+            "in": "query",
+            "description": "Rate of requests to report, in ]0, 1]",
+            "schema": {
+              "type": "number"
+            }
+          },
+          {
+            "name": "headers",
+            "in": "query",
+            "description": "Comma separated list of request headers to report, the credential headers being redacted",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ]
+      }
+    },
+    "/api/test-route": {
+      "post": {
+        "operationId": "testRoute",
+        "summary": "Run a synthetic request through the routing table, without forwarding it",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/RouteTest"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "Result per entrypoint",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "array",
+                  "items": {
+                    "$ref": "#/components/schemas/RouteTestResult"
+                  }
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid request"
+          }
+        }
+      }
+    },
+    "/api/prometheus/targets": {
+      "get": {
+        "operationId": "getPrometheusTargets",
+        "summary": "Backend servers, as Prometheus HTTP service discovery target groups",
+        "responses": {
+          "200": {
+            "description": "Target groups",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "array",
+                  "items": {
+                    "$ref": "#/components/schemas/PrometheusTargetGroup"
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/api/providers": {
+      "get": {
+        "operationId": "getProviders",
+        "summary": "Configurations of all providers",
+        "responses": {
+          "200": {
+            "description": "Configurations",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "additionalProperties": {
+                    "$ref": "#/components/schemas/Configuration"
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/api/providers/{provider}": {
+      "get": {
+        "operationId": "getProvider",
+        "summary": "Configuration of a provider",
+        "responses": {
+          "200": {
+            "description": "Configuration",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Configuration"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not found"
+          }
+        },
+        "parameters": [
+          {
+            "name": "provider",
+            "in": "path",
+            "required": true,
+            "description": "Provider name, e.g. file, docker or web",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ]
+      },
+      "put": {
+        "operationId": "putProvider",
+        "summary": "Replace the configuration of the web provider",
+        "parameters": [
+          {
+            "name": "provider",
+            "in": "path",
+            "required": true,
+            "description": "Provider name, e.g. file, docker or web",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/Configuration"
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "Configurations of all providers",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "additionalProperties": {
+                    "$ref": "#/components/schemas/Configuration"
+                  }
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid configuration, or provider other than web"
+          },
+          "403": {
+            "description": "Read only mode"
+          }
+        }
+      }
+    },
+    "/api/providers/{provider}/backends": {
+      "get": {
+        "operationId": "getBackends",
+        "summary": "Backends of a provider",
+        "responses": {
+          "200": {
+            "description": "Backends",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "additionalProperties": {
+                    "$ref": "#/components/schemas/Backend"
+                  }
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not found"
+          }
+        },
+        "parameters": [
+          {
+            "name": "provider",
+            "in": "path",
+            "required": true,
+            "description": "Provider name, e.g. file, docker or web",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ]
+      }
+    },
+    "/api/providers/{provider}/backends/{backend}": {
+      "get": {
+        "operationId": "getBackend",
+        "summary": "Backend of a provider",
+        "responses": {
+          "200": {
+            "description": "Backend",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Backend"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not found"
+          }
+        },
+        "parameters": [
+          {
+            "name": "provider",
+            "in": "path",
+            "required": true,
+            "description": "Provider name, e.g. file, docker or web",
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "backend",
+            "in": "path",
+            "required": true,
+            "description": "Backend name",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ]
+      }
+    },
+    "/api/providers/{provider}/backends/{backend}/servers": {
+      "get": {
+        "operationId": "getServers",
+        "summary": "Servers of a backend",
+        "responses": {
+          "200": {
+            "description": "Servers",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "additionalProperties": {
+                    "$ref": "#/components/schemas/Server"
+                  }
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not found"
+          }
+        },
+        "parameters": [
+          {
+            "name": "provider",
+            "in": "path",
+            "required": true,
+            "description": "Provider name, e.g. file, docker or web",
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "backend",
+            "in": "path",
+            "required": true,
+            "description": "Backend name",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ]
+      }
+    },
+    "/api/providers/{provider}/backends/{backend}/servers/{server}": {
+      "get": {
+        "operationId": "getServer",
+        "summary": "Server of a backend",
+        "responses": {
+          "200": {
+            "description": "Server",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Server"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not found"
+          }
+        },
+        "parameters": [
+          {
+            "name": "provider",
+            "in": "path",
+            "required": true,
+            "description": "Provider name, e.g. file, docker or web",
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "backend",
+            "in": "path",
+            "required": true,
+            "description": "Backend name",
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "server",
+            "in": "path",
+            "required": true,
+            "description": "Server name",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ]
+      }
+    },
+    "/api/providers/{provider}/frontends": {
+      "get": {
+        "operationId": "getFrontends",
+        "summary": "Frontends of a provider",
+        "responses": {
+          "200": {
+            "description": "Frontends",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "additionalProperties": {
+                    "$ref": "#/components/schemas/Frontend"
+                  }
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not found"
+          }
+        },
+        "parameters": [
+          {
+            "name": "provider",
+            "in": "path",
+            "required": true,
+            "description": "Provider name, e.g. file, docker or web",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ]
+      }
+    },
+    "/api/providers/{provider}/frontends/{frontend}": {
+      "get": {
+        "operationId": "getFrontend",
+        "summary": "Frontend of a provider",
+        "responses": {
+          "200": {
+            "description": "Frontend",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Frontend"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not found"
+          }
+        },
+        "parameters": [
+          {
+            "name": "provider",
+            "in": "path",
+            "required": true,
+            "description": "Provider name, e.g. file, docker or web",
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "frontend",
+            "in": "path",
+            "required": true,
+            "description": "Frontend name",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ]
+      }
+    },
+    "/api/providers/{provider}/frontends/{frontend}/routes": {
+      "get": {
+        "operationId": "getRoutes",
+        "summary": "Routes of a frontend",
+        "responses": {
+          "200": {
+            "description": "Routes",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "additionalProperties": {
+                    "$ref": "#/components/schemas/Route"
+                  }
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not found"
+          }
+        },
+        "parameters": [
+          {
+            "name": "provider",
+            "in": "path",
+            "required": true,
+            "description": "Provider name, e.g. file, docker or web",
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "frontend",
+            "in": "path",
+            "required": true,
+            "description": "Frontend name",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ]
+      }
+    },
+    "/api/providers/{provider}/frontends/{frontend}/routes/{route}": {
+      "get": {
+        "operationId": "getRoute",
+        "summary": "Route of a frontend",
+        "responses": {
+          "200": {
+            "description": "Route",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Route"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not found"
+          }
+        },
+        "parameters": [
+          {
+            "name": "provider",
+            "in": "path",
+            "required": true,
+            "description": "Provider name, e.g. file, docker or web",
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "frontend",
+            "in": "path",
+            "required": true,
+            "description": "Frontend name",
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "route",
+            "in": "path",
+            "required": true,
+            "description": "Route name",
+            "schema": {
+              "type": "string"
+            }
+          }
+        ]
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Readiness": {
+        "type": "object",
+        "properties": {
+          "status": {
+            "type": "string",
+            "enum": ["starting", "ready", "degraded"]
+          },
+          "providers": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/ProviderStatus"
+            }
+          }
+        }
+      },
+      "ProviderStatus": {
+        "type": "object",
+        "properties": {
+          "provider": {
+            "type": "string"
+          },
+          "status": {
+            "type": "string",
+            "enum": ["ok", "degraded"]
+          },
+          "error": {
+            "type": "string"
+          },
+          "failures": {
+            "type": "integer"
+          },
+          "lastApplied": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "lastFailure": {
+            "type": "string",
+            "format": "date-time"
+          }
+        }
+      },
+      "Configuration": {
+        "type": "object",
+        "properties": {
+          "backends": {
+            "type": "object",
+            "additionalProperties": {
+              "$ref": "#/components/schemas/Backend"
+            }
+          },
+          "frontends": {
+            "type": "object",
+            "additionalProperties": {
+              "$ref": "#/components/schemas/Frontend"
+            }
+          }
+        }
+      },
+      "Backend": {
+        "type": "object",
+        "properties": {
+          "servers": {
+            "type": "object",
+            "additionalProperties": {
+              "$ref": "#/components/schemas/Server"
+            }
+          },
+          "circuitBreaker": {
+            "type": "object",
+            "properties": {
+              "expression": {
+                "type": "string"
+              }
+            }
+          },
+          "loadBalancer": {
+            "type": "object",
+            "properties": {
+              "method": {
+                "type": "string",
+                "enum": [
+                  "wrr",
+                  "drr"
+                ]
+              },
+              "sticky": {
+                "type": "boolean"
+              }
+            }
+          },
+          "maxConn": {
+            "type": "object",
+            "properties": {
+              "amount": {
+                "type": "integer"
+              },
+              "extractorFunc": {
+                "type": "string"
+              }
+            }
+          },
+          "tls": {
+            "type": "object",
+            "properties": {
+              "rootCAs": {
+                "type": "array",
+                "items": {
+                  "type": "string"
+                }
+              },
+              "serverName": {
+                "type": "string"
+              },
+              "pinnedSPKI": {
+                "type": "array",
+                "items": {
+                  "type": "string"
+                }
+              },
+              "internalCA": {
+                "type": "boolean"
+              },
+              "insecureSkipVerify": {
+                "type": "boolean"
+              }
+            }
+          },
+          "expect": {
+            "type": "object",
+            "properties": {
+              "mode": {
+                "type": "string",
+                "enum": [
+                  "forward",
+                  "local",
+                  "strip"
+                ]
+              },
+              "timeout": {
+                "type": "integer"
+              }
+            }
+          },
+          "streaming": {
+            "type": "boolean"
+          },
+          "timeouts": {
+            "type": "object",
+            "properties": {
+              "maxDuration": {
+                "type": "integer"
+              },
+              "stall": {
+                "type": "integer"
+              }
+            }
+          },
+          "proxy": {
+            "type": "object",
+            "properties": {
+              "url": {
+                "type": "string"
+              },
+              "username": {
+                "type": "string"
+              },
+              "hostKey": {
+                "type": "string"
+              }
+            }
+          },
+          "source": {
+            "type": "object",
+            "properties": {
+              "ip": {
+                "type": "string"
+              },
+              "interface": {
+                "type": "string"
+              }
+            }
+          }
+        }
+      },
+      "Server": {
+        "type": "object",
+        "properties": {
+          "url": {
+            "type": "string"
+          },
+          "weight": {
+            "type": "integer"
+          }
+        }
+      },
+      "Route": {
+        "type": "object",
+        "properties": {
+          "rule": {
+            "type": "string"
+          }
+        }
+      },
+      "Frontend": {
+        "type": "object",
+        "properties": {
+          "entryPoints": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "backend": {
+            "type": "string"
+          },
+          "routes": {
+            "type": "object",
+            "additionalProperties": {
+              "$ref": "#/components/schemas/Route"
+            }
+          },
+          "passHostHeader": {
+            "type": "boolean"
+          },
+          "priority": {
+            "type": "integer"
+          },
+          "slo": {
+            "$ref": "#/components/schemas/SLO"
+          },
+          "experiment": {
+            "$ref": "#/components/schemas/Experiment"
+          },
+          "capture": {
+            "$ref": "#/components/schemas/Capture"
+          },
+          "fault": {
+            "$ref": "#/components/schemas/Fault"
+          },
+          "tenant": {
+            "type": "string"
+          },
+          "auth": {
+            "$ref": "#/components/schemas/Auth"
+          },
+          "securityHeaders": {
+            "$ref": "#/components/schemas/SecurityHeaders"
+          },
+          "graphql": {
+            "type": "object",
+            "properties": {
+              "maxDepth": {
+                "type": "integer"
+              },
+              "maxComplexity": {
+                "type": "integer"
+              }
+            }
+          },
+          "clientCA": {
+            "$ref": "#/components/schemas/ClientCA"
+          },
+          "clientHeaders": {
+            "$ref": "#/components/schemas/ClientHeaders"
+          },
+          "acl": {
+            "$ref": "#/components/schemas/ACL"
+          },
+          "opa": {
+            "type": "object",
+            "description": "Authorization of the requests by an Open Policy Agent server",
+            "properties": {
+              "url": {
+                "type": "string",
+                "description": "http://127.0.0.1:8181 if empty"
+              },
+              "path": {
+                "type": "string",
+                "description": "Path of the policy decision, like httpapi/authz"
+              },
+              "timeout": {
+                "type": "integer",
+                "description": "Milliseconds, 1000 if zero"
+              },
+              "failOpen": {
+                "type": "boolean"
+              }
+            }
+          },
+          "coalesce": {
+            "type": "object",
+            "description": "Coalescing of the concurrent identical GET and HEAD requests",
+            "properties": {
+              "varyHeaders": {
+                "type": "array",
+                "items": {
+                  "type": "string"
+                }
+              },
+              "maxBodySize": {
+                "type": "integer"
+              }
+            }
+          },
+          "concurrencyLimit": {
+            "type": "object",
+            "description": "Maximum number of in-flight requests of each client",
+            "properties": {
+              "amount": {
+                "type": "integer"
+              },
+              "extractorFunc": {
+                "type": "string",
+                "description": "client.ip, request.host or request.header.<name>"
+              }
+            }
+          },
+          "middlewares": {
+            "type": "array",
+            "description": "Registered middlewares applied in order",
+            "items": {
+              "type": "string"
+            }
+          }
+        }
+      },
+      "ClientHeaders": {
+        "type": "object",
+        "description": "Names of the request headers forwarding the verified client certificate, the empty ones are not set",
+        "properties": {
+          "certificate": {
+            "type": "string",
+            "description": "Base64 DER of the certificate"
+          },
+          "subject": {
+            "type": "string"
+          },
+          "sans": {
+            "type": "string",
+            "description": "Comma separated subject alternative names"
+          },
+          "serial": {
+            "type": "string"
+          },
+          "notAfter": {
+            "type": "string",
+            "description": "Expiration of the certificate, RFC3339"
+          }
+        }
+      },
+      "ACL": {
+        "type": "object",
+        "description": "Access control list, the first rule matching a request allows or denies it",
+        "properties": {
+          "rules": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "properties": {
+                "methods": {
+                  "type": "array",
+                  "description": "All the methods if empty",
+                  "items": {
+                    "type": "string"
+                  }
+                },
+                "paths": {
+                  "type": "array",
+                  "description": "Glob patterns, all the paths if empty",
+                  "items": {
+                    "type": "string"
+                  }
+                },
+                "users": {
+                  "type": "array",
+                  "description": "Authenticated users, all the clients if empty",
+                  "items": {
+                    "type": "string"
+                  }
+                },
+                "ips": {
+                  "type": "array",
+                  "description": "IPs and CIDR ranges, all the clients if empty",
+                  "items": {
+                    "type": "string"
+                  }
+                },
+                "action": {
+                  "type": "string",
+                  "enum": [
+                    "allow",
+                    "deny"
+                  ]
+                }
+              }
+            }
+          },
+          "defaultAction": {
+            "type": "string",
+            "enum": [
+              "allow",
+              "deny"
+            ],
+            "description": "Action of the requests matching no rule, allow by default"
+          }
+        }
+      },
+      "ClientCA": {
+        "type": "object",
+        "properties": {
+          "files": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "optional": {
+            "type": "boolean"
+          },
+          "allowedSubjects": {
+            "type": "array",
+            "description": "Glob patterns matching the common name or a subject alternative name",
+            "items": {
+              "type": "string"
+            }
+          }
+        }
+      },
+      "SecurityHeaders": {
+        "type": "object",
+        "properties": {
+          "profile": {
+            "type": "string",
+            "enum": [
+              "basic",
+              "strict"
+            ]
+          },
+          "stsSeconds": {
+            "type": "integer"
+          },
+          "headers": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "string"
+            }
+          },
+          "stripHeaders": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          }
+        }
+      },
+      "Auth": {
+        "type": "object",
+        "properties": {
+          "Basic": {
+            "type": "object",
+            "properties": {
+              "Users": {
+                "type": "array",
+                "items": {
+                  "type": "string"
+                }
+              },
+              "realm": {
+                "type": "string"
+              },
+              "failureBody": {
+                "type": "string"
+              },
+              "failureContentType": {
+                "type": "string"
+              },
+              "lockout": {
+                "type": "object",
+                "properties": {
+                  "maxFailures": {
+                    "type": "integer"
+                  },
+                  "duration": {
+                    "type": "integer"
+                  },
+                  "maxDuration": {
+                    "type": "integer"
+                  }
+                }
+              }
+            }
+          },
+          "Digest": {
+            "type": "object",
+            "properties": {
+              "Users": {
+                "type": "array",
+                "items": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "LDAP": {
+            "type": "object",
+            "properties": {
+              "url": {
+                "type": "string"
+              },
+              "startTLS": {
+                "type": "boolean"
+              },
+              "insecureSkipVerify": {
+                "type": "boolean"
+              },
+              "ca": {
+                "type": "string"
+              },
+              "bindDN": {
+                "type": "string"
+              },
+              "bindPassword": {
+                "type": "string"
+              },
+              "baseDN": {
+                "type": "string"
+              },
+              "userFilter": {
+                "type": "string"
+              },
+              "userDN": {
+                "type": "string"
+              },
+              "groupAttribute": {
+                "type": "string"
+              },
+              "groups": {
+                "type": "array",
+                "items": {
+                  "type": "string"
+                }
+              },
+              "cacheTTL": {
+                "type": "integer"
+              },
+              "poolSize": {
+                "type": "integer"
+              },
+              "timeout": {
+                "type": "integer"
+              },
+              "realm": {
+                "type": "string"
+              }
+            }
+          },
+          "TOTP": {
+            "type": "object",
+            "properties": {
+              "seedsFile": {
+                "type": "string"
+              },
+              "kvBackend": {
+                "type": "string"
+              },
+              "kvEndpoint": {
+                "type": "string"
+              },
+              "kvPrefix": {
+                "type": "string"
+              },
+              "header": {
+                "type": "string"
+              },
+              "formField": {
+                "type": "string"
+              },
+              "digits": {
+                "type": "integer"
+              },
+              "period": {
+                "type": "integer"
+              },
+              "skew": {
+                "type": "integer"
+              }
+            }
+          },
+          "SSO": {
+            "type": "object",
+            "properties": {
+              "group": {
+                "type": "string"
+              },
+              "secret": {
+                "type": "string"
+              },
+              "domain": {
+                "type": "string"
+              },
+              "cookieName": {
+                "type": "string"
+              },
+              "maxAge": {
+                "type": "integer"
+              }
+            }
+          }
+        }
+      },
+      "Fault": {
+        "type": "object",
+        "properties": {
+          "delay": {
+            "type": "integer"
+          },
+          "delayPercent": {
+            "type": "number"
+          },
+          "abortStatus": {
+            "type": "integer"
+          },
+          "abortPercent": {
+            "type": "number"
+          },
+          "dropPercent": {
+            "type": "number"
+          }
+        }
+      },
+      "FaultInjectionStatus": {
+        "type": "object",
+        "properties": {
+          "enabled": {
+            "type": "boolean"
+          }
+        }
+      },
+      "ConfigurationVersion": {
+        "type": "object",
+        "properties": {
+          "version": {
+            "type": "integer"
+          },
+          "applied": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "provider": {
+            "type": "string",
+            "description": "Provider whose update was applied"
+          },
+          "rollbackOf": {
+            "type": "integer",
+            "description": "Version rolled back to"
+          },
+          "changes": {
+            "type": "array",
+            "description": "Frontends and backends added, modified or removed from the previous version",
+            "items": {
+              "type": "string"
+            }
+          },
+          "configurations": {
+            "type": "object",
+            "additionalProperties": {
+              "$ref": "#/components/schemas/Configuration"
+            }
+          }
+        }
+      },
+      "DryRunResult": {
+        "type": "object",
+        "properties": {
+          "provider": {
+            "type": "string"
+          },
+          "received": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "changes": {
+            "type": "array",
+            "description": "Frontends and backends the configuration would add, modify or remove",
+            "items": {
+              "type": "string"
+            }
+          },
+          "conflicts": {
+            "type": "array",
+            "description": "Conflicts of the frontends of the configuration with the live ones",
+            "items": {
+              "$ref": "#/components/schemas/RouteConflict"
+            }
+          },
+          "configuration": {
+            "$ref": "#/components/schemas/Configuration"
+          }
+        }
+      },
+      "ConfigurationFreezeStatus": {
+        "type": "object",
+        "properties": {
+          "frozen": {
+            "type": "boolean"
+          },
+          "reason": {
+            "type": "string",
+            "description": "Freeze switched through the API, freeze window or feature flag"
+          },
+          "reject": {
+            "type": "boolean",
+            "description": "The updates received during a freeze are rejected instead of held back"
+          },
+          "pending": {
+            "type": "array",
+            "description": "Providers whose last configuration is held back until the freeze ends",
+            "items": {
+              "type": "string"
+            }
+          }
+        }
+      },
+      "FrontendDrain": {
+        "type": "object",
+        "properties": {
+          "frontend": {
+            "type": "string"
+          },
+          "redirect": {
+            "type": "string",
+            "description": "Absolute URL the new requests are redirected to, rejected with a 503 when empty"
+          },
+          "window": {
+            "type": "string",
+            "description": "Time left to the in-flight requests and websockets before they are canceled, 30s by default"
+          },
+          "since": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "deadline": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "inFlight": {
+            "type": "integer"
+          }
+        }
+      },
+      "Capture": {
+        "type": "object",
+        "properties": {
+          "sampleRate": {
+            "type": "number"
+          },
+          "directory": {
+            "type": "string"
+          },
+          "url": {
+            "type": "string"
+          },
+          "maxBodySize": {
+            "type": "integer"
+          },
+          "redactHeaders": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          }
+        }
+      },
+      "Experiment": {
+        "type": "object",
+        "properties": {
+          "name": {
+            "type": "string"
+          },
+          "header": {
+            "type": "string"
+          },
+          "cookie": {
+            "type": "string"
+          },
+          "variants": {
+            "type": "object",
+            "additionalProperties": {
+              "$ref": "#/components/schemas/Variant"
+            }
+          }
+        }
+      },
+      "Variant": {
+        "type": "object",
+        "properties": {
+          "weight": {
+            "type": "integer"
+          },
+          "backend": {
+            "type": "string"
+          }
+        }
+      },
+      "SLO": {
+        "type": "object",
+        "properties": {
+          "availability": {
+            "type": "number"
+          },
+          "latency": {
+            "type": "integer"
+          },
+          "latencyTarget": {
+            "type": "number"
+          },
+          "streamingPaths": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          }
+        }
+      },
+      "Version": {
+        "type": "object",
+        "properties": {
+          "Version": {
+            "type": "string"
+          },
+          "Codename": {
+            "type": "string"
+          }
+        }
+      },
+      "Health": {
+        "type": "object",
+        "properties": {
+          "pid": {
+            "type": "integer"
+          },
+          "uptime": {
+            "type": "string"
+          },
+          "uptime_sec": {
+            "type": "number"
+          },
+          "time": {
+            "type": "string"
+          },
+          "unixtime": {
+            "type": "integer"
+          },
+          "status_code_count": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "integer"
+            }
+          },
+          "total_status_code_count": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "integer"
+            }
+          },
+          "count": {
+            "type": "integer"
+          },
+          "total_count": {
+            "type": "integer"
+          },
+          "total_response_time": {
+            "type": "string"
+          },
+          "total_response_time_sec": {
+            "type": "number"
+          },
+          "average_response_time": {
+            "type": "string"
+          },
+          "average_response_time_sec": {
+            "type": "number"
+          },
+          "recent_errors": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "properties": {
+                "status_code": {
+                  "type": "integer"
+                },
+                "status": {
+                  "type": "string"
+                },
+                "method": {
+                  "type": "string"
+                },
+                "host": {
+                  "type": "string"
+                },
+                "path": {
+                  "type": "string"
+                },
+                "time": {
+                  "type": "string",
+                  "format": "date-time"
+                }
+              }
+            }
+          },
+          "slo": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/SLOStatus"
+            }
+          },
+          "errors": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/ErrorCount"
+            }
+          },
+          "usage": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/UsageCount"
+            }
+          },
+          "auth_lockouts": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/AuthLockoutStatus"
+            }
+          }
+        }
+      },
+      "AuthLockoutStatus": {
+        "type": "object",
+        "properties": {
+          "entryPoint": {
+            "type": "string"
+          },
+          "frontend": {
+            "type": "string"
+          },
+          "locked_out_clients": {
+            "type": "integer"
+          },
+          "lockouts": {
+            "type": "integer"
+          },
+          "rejected_requests": {
+            "type": "integer"
+          }
+        }
+      },
+      "UsageCount": {
+        "type": "object",
+        "properties": {
+          "frontend": {
+            "type": "string"
+          },
+          "tenant": {
+            "type": "string"
+          },
+          "requests": {
+            "type": "integer"
+          },
+          "bytes_in": {
+            "type": "integer"
+          },
+          "bytes_out": {
+            "type": "integer"
+          }
+        }
+      },
+      "UsageReport": {
+        "type": "object",
+        "properties": {
+          "from": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "to": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "frontends": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/UsageCount"
+            }
+          },
+          "tenants": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/UsageCount"
+            }
+          }
+        }
+      },
+      "ErrorCount": {
+        "type": "object",
+        "properties": {
+          "frontend": {
+            "type": "string"
+          },
+          "status_code": {
+            "type": "integer"
+          },
+          "origin": {
+            "type": "string",
+            "enum": ["traefik", "backend"]
+          },
+          "reason": {
+            "type": "string"
+          },
+          "count": {
+            "type": "integer"
+          }
+        }
+      },
+      "SLOStatus": {
+        "type": "object",
+        "properties": {
+          "frontend": {
+            "type": "string"
+          },
+          "objective": {
+            "$ref": "#/components/schemas/SLO"
+          },
+          "windows": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "properties": {
+                "window": {
+                  "type": "string"
+                },
+                "requests": {
+                  "type": "integer"
+                },
+                "errors": {
+                  "type": "integer"
+                },
+                "slow_requests": {
+                  "type": "integer"
+                },
+                "streams": {
+                  "type": "integer"
+                },
+                "streams_average_duration": {
+                  "type": "string"
+                },
+                "availability": {
+                  "type": "number"
+                },
+                "latency_compliance": {
+                  "type": "number"
+                },
+                "availability_burn_rate": {
+                  "type": "number"
+                },
+                "latency_burn_rate": {
+                  "type": "number"
+                },
+                "availability_compliant": {
+                  "type": "boolean"
+                },
+                "latency_compliant": {
+                  "type": "boolean"
+                }
+              }
+            }
+          },
+          "alerting": {
+            "type": "boolean"
+          },
+          "alerting_text": {
+            "type": "string"
+          }
+        }
+      },
+      "FIPSStatus": {
+        "type": "object",
+        "properties": {
+          "enabled": {
+            "type": "boolean",
+            "description": "Whether the TLS parameters are restricted to the FIPS-approved ones"
+          },
+          "build": {
+            "type": "boolean",
+            "description": "Whether traefik is built with the fips tag, linked with BoringCrypto"
+          },
+          "compliant": {
+            "type": "boolean"
+          },
+          "entryPoints": {
+            "type": "object",
+            "additionalProperties": {
+              "$ref": "#/components/schemas/FIPSEntryPoint"
+            }
+          }
+        }
+      },
+      "FIPSEntryPoint": {
+        "type": "object",
+        "properties": {
+          "compliant": {
+            "type": "boolean"
+          },
+          "minVersion": {
+            "type": "string"
+          },
+          "maxVersion": {
+            "type": "string"
+          },
+          "cipherSuites": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "violations": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          }
+        }
+      },
+      "Certificate": {
+        "type": "object",
+        "properties": {
+          "entryPoint": {
+            "type": "string"
+          },
+          "resolver": {
+            "type": "string",
+            "enum": [
+              "static",
+              "acme"
+            ]
+          },
+          "subject": {
+            "type": "string"
+          },
+          "issuer": {
+            "type": "string"
+          },
+          "sans": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "notBefore": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "notAfter": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "expiresIn": {
+            "type": "integer",
+            "description": "Seconds left before expiry"
+          },
+          "expired": {
+            "type": "boolean"
+          },
+          "lastRenewal": {
+            "type": "object",
+            "properties": {
+              "date": {
+                "type": "string",
+                "format": "date-time"
+              },
+              "error": {
+                "type": "string"
+              }
+            }
+          }
+        }
+      },
+      "PipelineStep": {
+        "type": "object",
+        "properties": {
+          "name": {
+            "type": "string"
+          },
+          "level": {
+            "type": "string"
+          },
+          "description": {
+            "type": "string"
+          }
+        }
+      },
+      "FrontendPipeline": {
+        "type": "object",
+        "properties": {
+          "frontend": {
+            "type": "string"
+          },
+          "provider": {
+            "type": "string"
+          },
+          "entryPoints": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "array",
+              "items": {
+                "$ref": "#/components/schemas/PipelineStep"
+              }
+            }
+          }
+        }
+      },
+      "TapEvent": {
+        "type": "object",
+        "properties": {
+          "frontend": {
+            "type": "string"
+          },
+          "time": {
+            "type": "string",
+            "format": "date-time"
+          },
+          "method": {
+            "type": "string"
+          },
+          "host": {
+            "type": "string"
+          },
+          "path": {
+            "type": "string"
+          },
+          "status": {
+            "type": "integer"
+          },
+          "duration_ms": {
+            "type": "number"
+          },
+          "headers": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "string"
+            }
+          }
+        }
+      },
+      "RouteTest": {
+        "type": "object",
+        "properties": {
+          "entryPoint": {
+            "type": "string"
+          },
+          "method": {
+            "type": "string"
+          },
+          "host": {
+            "type": "string"
+          },
+          "path": {
+            "type": "string"
+          },
+          "headers": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "string"
+            }
+          }
+        },
+        "required": [
+          "host"
+        ]
+      },
+      "RouteTestResult": {
+        "type": "object",
+        "properties": {
+          "entryPoint": {
+            "type": "string"
+          },
+          "matched": {
+            "type": "boolean"
+          },
+          "frontend": {
+            "type": "string"
+          },
+          "provider": {
+            "type": "string"
+          },
+          "pipeline": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/PipelineStep"
+            }
+          },
+          "backend": {
+            "type": "string"
+          },
+          "server": {
+            "type": "string"
+          },
+          "servers": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "selection": {
+            "type": "string"
+          }
+        }
+      },
+      "PrometheusTargetGroup": {
+        "type": "object",
+        "properties": {
+          "targets": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "labels": {
+            "type": "object",
+            "additionalProperties": {
+              "type": "string"
+            }
+          }
+        }
+      },
+      "RouteConflict": {
+        "type": "object",
+        "properties": {
+          "type": {
+            "type": "string",
+            "enum": [
+              "shadowed",
+              "ambiguous",
+              "overlap"
+            ]
+          },
+          "entryPoint": {
+            "type": "string"
+          },
+          "frontend": {
+            "type": "string"
+          },
+          "provider": {
+            "type": "string"
+          },
+          "other": {
+            "type": "string"
+          },
+          "otherProvider": {
+            "type": "string"
+          }
+        }
+      }
+    }
+  }
+}
+`)
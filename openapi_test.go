@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	spec := struct {
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}{}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("invalid OpenAPI definition: %v", err)
+	}
+
+	for _, path := range []string{"/health", "/ping/ready", "/api/version", "/api/openapi.json", "/api/providers/{provider}", "/api/test-route"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("path %s is not defined", path)
+		}
+	}
+
+	refs := regexp.MustCompile(`"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(string(openAPISpec), -1)
+	for _, ref := range refs {
+		if _, ok := spec.Components.Schemas[ref[1]]; !ok {
+			t.Errorf("schema %s is referenced but not defined", ref[1])
+		}
+	}
+	for path, operations := range spec.Paths {
+		if !strings.HasPrefix(path, "/") {
+			t.Errorf("path %s must start with /", path)
+		}
+		if len(operations) == 0 {
+			t.Errorf("path %s has no operation", path)
+		}
+	}
+}
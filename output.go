@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	fmtlog "log"
+	"os"
+)
+
+// Exit codes of the traefik commands, stable for automation
+const (
+	exitCodeOK = iota
+	// exitCodeError is returned when a command fails while running
+	exitCodeError
+	// exitCodeUsage is returned when the command line can't be parsed
+	exitCodeUsage
+	// exitCodeConfig is returned when the configuration can't be loaded, from a file or a KV store
+	exitCodeConfig
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// CommandResult is printed on stdout by the commands run with --output=json.
+// Its schema is stable: fields are only added, never renamed or removed.
+type CommandResult struct {
+	Command  string      `json:"command"`
+	Success  bool        `json:"success"`
+	ExitCode int         `json:"exitCode"`
+	Error    string      `json:"error,omitempty"`
+	Result   interface{} `json:"result,omitempty"`
+}
+
+// commandOutput prints the outcome of a command in the requested output format.
+type commandOutput struct {
+	command string
+	format  string
+}
+
+func newCommandOutput(command string, format string) (*commandOutput, error) {
+	switch format {
+	case "", outputText:
+		format = outputText
+	case outputJSON:
+	default:
+		return &commandOutput{command: command, format: outputText}, fmt.Errorf("Unknown output format %s, expected %s or %s", format, outputText, outputJSON)
+	}
+	return &commandOutput{command: command, format: format}, nil
+}
+
+func (o *commandOutput) isJSON() bool {
+	return o.format == outputJSON
+}
+
+// success prints result if the output format is json.
+func (o *commandOutput) success(result interface{}) error {
+	if !o.isJSON() {
+		return nil
+	}
+	return o.print(&CommandResult{Command: o.command, Success: true, ExitCode: exitCodeOK, Result: result})
+}
+
+// exit prints err, as a log line or as a json CommandResult, and exits with exitCode.
+func (o *commandOutput) exit(exitCode int, err error) {
+	if o.isJSON() {
+		o.print(&CommandResult{Command: o.command, ExitCode: exitCode, Error: err.Error()})
+	} else {
+		fmtlog.Println(err)
+	}
+	os.Exit(exitCode)
+}
+
+func (o *commandOutput) print(result *CommandResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
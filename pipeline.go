@@ -0,0 +1,363 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/featureflags"
+	"github.com/containous/traefik/types"
+)
+
+// PipelineStep describes a middleware crossed by the requests of a frontend
+type PipelineStep struct {
+	Name        string `json:"name"`
+	Level       string `json:"level"`
+	Description string `json:"description,omitempty"`
+}
+
+// FrontendPipeline holds the ordered middlewares crossed by the requests of a frontend, per entrypoint
+type FrontendPipeline struct {
+	Frontend    string                    `json:"frontend"`
+	Provider    string                    `json:"provider"`
+	EntryPoints map[string][]PipelineStep `json:"entryPoints"`
+}
+
+// getFrontendPipeline returns the pipeline of a frontend, as built by loadConfig
+// from the current configurations, or nil if the frontend doesn't exist.
+func (server *Server) getFrontendPipeline(frontendName string) *FrontendPipeline {
+	currentConfigurations := server.currentConfigurations.Get().(configs)
+	providerNames := []string{}
+	for providerName := range currentConfigurations {
+		providerNames = append(providerNames, providerName)
+	}
+	sort.Strings(providerNames)
+
+	for _, providerName := range providerNames {
+		configuration := currentConfigurations[providerName]
+		if configuration == nil {
+			continue
+		}
+		frontend, ok := configuration.Frontends[frontendName]
+		if !ok {
+			continue
+		}
+		pipeline := &FrontendPipeline{
+			Frontend:    frontendName,
+			Provider:    providerName,
+			EntryPoints: map[string][]PipelineStep{},
+		}
+		for _, entryPointName := range frontend.EntryPoints {
+			entryPoint, ok := server.globalConfiguration.EntryPoints[entryPointName]
+			if !ok {
+				continue
+			}
+			steps := server.entryPointPipeline(entryPoint)
+			steps = append(steps, server.frontendPipeline(frontendName, frontend, entryPoint)...)
+			if entryPoint.Redirect == nil {
+				steps = append(steps, server.backendPipeline(frontend, configuration.Backends[frontend.Backend])...)
+			}
+			pipeline.EntryPoints[entryPointName] = steps
+		}
+		return pipeline
+	}
+	return nil
+}
+
+// hasFrontend returns true if a frontend of the current configurations is named frontendName.
+func (server *Server) hasFrontend(frontendName string) bool {
+	for _, configuration := range server.currentConfigurations.Get().(configs) {
+		if configuration == nil {
+			continue
+		}
+		if _, ok := configuration.Frontends[frontendName]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (server *Server) entryPointPipeline(entryPoint *EntryPoint) []PipelineStep {
+	steps := []PipelineStep{}
+	if realIP := server.globalConfiguration.RealIP; realIP != nil {
+		steps = append(steps, PipelineStep{Name: "realIP", Level: "entrypoint", Description: strings.Join(realIP.CDNs, ", ")})
+	}
+	steps = append(steps,
+		PipelineStep{Name: "accessLog", Level: "entrypoint"},
+		PipelineStep{Name: "metrics", Level: "entrypoint"},
+	)
+	if entryPoint.TLS != nil {
+		steps = append(steps, PipelineStep{Name: "tlsClients", Level: "entrypoint"})
+	}
+	if server.usageRecorder != nil {
+		steps = append(steps, PipelineStep{Name: "usage", Level: "entrypoint"})
+	}
+	if server.globalConfiguration.Web != nil && server.globalConfiguration.Web.Statistics != nil {
+		steps = append(steps, PipelineStep{Name: "statistics", Level: "entrypoint"})
+	}
+	if entryPoint.SecurityHeaders != nil {
+		steps = append(steps, PipelineStep{Name: "securityHeaders", Level: "entrypoint", Description: entryPoint.SecurityHeaders.Profile})
+	}
+	if entryPoint.TLS != nil && entryPoint.TLS.StrictSNI {
+		steps = append(steps, PipelineStep{Name: "strictSNI", Level: "entrypoint", Description: strings.Join(entryPoint.TLS.SNIExemptions, ", ")})
+	}
+	if entryPoint.Headers != nil {
+		steps = append(steps, PipelineStep{Name: "sanitizeHeaders", Level: "entrypoint", Description: headersDescription(entryPoint.Headers)})
+	}
+	if entryPoint.Paths != nil {
+		steps = append(steps, PipelineStep{Name: "normalizePaths", Level: "entrypoint", Description: pathsDescription(entryPoint.Paths)})
+	}
+	if redirectMap := entryPoint.RedirectMap; redirectMap != nil {
+		source := redirectMap.File
+		if len(source) == 0 {
+			source = redirectMap.KVBackend + " " + redirectMap.KVPrefix
+		}
+		steps = append(steps, PipelineStep{Name: "redirectMap", Level: "entrypoint", Description: source})
+	}
+	if entryPoint.Auth != nil {
+		steps = append(steps, PipelineStep{Name: "auth", Level: "entrypoint", Description: authDescription(entryPoint.Auth)})
+	}
+	if entryPoint.Compress {
+		steps = append(steps, PipelineStep{Name: "compress", Level: "entrypoint"})
+	}
+	return steps
+}
+
+func (server *Server) frontendPipeline(frontendName string, frontend *types.Frontend, entryPoint *EntryPoint) []PipelineStep {
+	rules := []string{}
+	stripPrefixes := []string{}
+	for _, route := range frontend.Routes {
+		rules = append(rules, route.Rule)
+		serverRoute := &serverRoute{route: mux.NewRouter().NewRoute()}
+		if err := getRoute(serverRoute, &route); err == nil {
+			stripPrefixes = append(stripPrefixes, serverRoute.stripPrefixes...)
+		}
+	}
+	sort.Strings(rules)
+	steps := []PipelineStep{{Name: "router", Level: "frontend", Description: strings.Join(rules, " | ")}}
+
+	if entryPoint.Redirect != nil {
+		redirect := entryPoint.Redirect.EntryPoint
+		if len(redirect) == 0 {
+			redirect = entryPoint.Redirect.Regex + " -> " + entryPoint.Redirect.Replacement
+		}
+		return append(steps, PipelineStep{Name: "redirect", Level: "entrypoint", Description: redirect})
+	}
+	if len(stripPrefixes) > 0 {
+		sort.Strings(stripPrefixes)
+		steps = append(steps, PipelineStep{Name: "stripPrefix", Level: "frontend", Description: strings.Join(stripPrefixes, ",")})
+	}
+	if drain := frontendDrains.Get(frontendName); drain != nil {
+		description := "503 until resumed"
+		if len(drain.Redirect) > 0 {
+			description = "redirect to " + drain.Redirect
+		}
+		steps = append(steps, PipelineStep{Name: "drain", Level: "frontend", Description: fmt.Sprintf("%s, %d in-flight requests", description, drain.InFlight)})
+	}
+	if frontend.SecurityHeaders != nil {
+		steps = append(steps, PipelineStep{Name: "securityHeaders", Level: "frontend", Description: frontend.SecurityHeaders.Profile})
+	}
+	if concurrencyLimit := frontend.ConcurrencyLimit; concurrencyLimit != nil {
+		extractorFunc := concurrencyLimit.ExtractorFunc
+		if len(extractorFunc) == 0 {
+			extractorFunc = "client.ip"
+		}
+		steps = append(steps, PipelineStep{Name: "concurrencyLimit", Level: "frontend", Description: fmt.Sprintf("%d in-flight requests by %s", concurrencyLimit.Amount, extractorFunc)})
+	}
+	if clientCA := frontend.ClientCA; clientCA != nil {
+		description := strings.Join(clientCA.Files, ", ")
+		if len(clientCA.AllowedSubjects) > 0 {
+			description += ", subjects " + strings.Join(clientCA.AllowedSubjects, ", ")
+		}
+		if clientCA.Optional {
+			description += ", optional"
+		}
+		steps = append(steps, PipelineStep{Name: "clientCA", Level: "frontend", Description: description})
+	}
+	if clientHeaders := frontend.ClientHeaders; clientHeaders != nil {
+		headers := []string{}
+		for _, header := range []string{clientHeaders.Certificate, clientHeaders.Subject, clientHeaders.SANs, clientHeaders.Serial, clientHeaders.NotAfter} {
+			if len(header) > 0 {
+				headers = append(headers, header)
+			}
+		}
+		steps = append(steps, PipelineStep{Name: "clientHeaders", Level: "frontend", Description: strings.Join(headers, ", ")})
+	}
+	if frontend.GraphQL != nil {
+		steps = append(steps, PipelineStep{Name: "graphql", Level: "frontend", Description: fmt.Sprintf("max depth %d, max complexity %d", frontend.GraphQL.MaxDepth, frontend.GraphQL.MaxComplexity)})
+	}
+	if frontend.Auth != nil {
+		steps = append(steps, PipelineStep{Name: "auth", Level: "frontend", Description: authDescription(frontend.Auth)})
+	}
+	if acl := frontend.ACL; acl != nil {
+		defaultAction := acl.DefaultAction
+		if len(defaultAction) == 0 {
+			defaultAction = "allow"
+		}
+		steps = append(steps, PipelineStep{Name: "acl", Level: "frontend", Description: fmt.Sprintf("%d rules, %s by default", len(acl.Rules), defaultAction)})
+	}
+	if opa := frontend.OPA; opa != nil {
+		description := opa.Path
+		if opa.FailOpen {
+			description += ", fail open"
+		}
+		steps = append(steps, PipelineStep{Name: "opa", Level: "frontend", Description: description})
+	}
+	if len(frontend.Middlewares) > 0 {
+		steps = append(steps, PipelineStep{Name: "middlewares", Level: "frontend", Description: strings.Join(frontend.Middlewares, ", ")})
+	}
+	if server.featureFlags != nil {
+		steps = append(steps, PipelineStep{Name: "featureFlags", Level: "frontend", Description: featureflags.Maintenance + ", " + featureflags.Compress})
+	}
+	if frontend.SLO != nil {
+		steps = append(steps, PipelineStep{Name: "slo", Level: "frontend", Description: fmt.Sprintf("availability %v%%, latency %dms", frontend.SLO.Availability, frontend.SLO.Latency)})
+	}
+	if frontend.Capture != nil {
+		description := fmt.Sprintf("%v%% of the requests", frontend.Capture.SampleRate*100)
+		if frontend.Capture.SampleRate == 0 {
+			description = "all the requests"
+		}
+		steps = append(steps, PipelineStep{Name: "capture", Level: "frontend", Description: description})
+	}
+	if frontend.Fault != nil {
+		status := frontend.Fault.AbortStatus
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		description := fmt.Sprintf("delay %dms %v%%, abort %d %v%%, drop %v%%", frontend.Fault.Delay, frontend.Fault.DelayPercent, status, frontend.Fault.AbortPercent, frontend.Fault.DropPercent)
+		if !faultInjector.Enabled() {
+			description += ", switched off"
+		}
+		steps = append(steps, PipelineStep{Name: "fault", Level: "frontend", Description: description})
+	}
+	if coalesce := frontend.Coalesce; coalesce != nil {
+		description := "GET and HEAD requests"
+		if len(coalesce.VaryHeaders) > 0 {
+			description += ", vary " + strings.Join(coalesce.VaryHeaders, ", ")
+		}
+		steps = append(steps, PipelineStep{Name: "coalesce", Level: "frontend", Description: description})
+	}
+	if frontend.Experiment != nil {
+		variants := []string{}
+		for variantName, variant := range frontend.Experiment.Variants {
+			backend := frontend.Backend
+			if len(variant.Backend) > 0 {
+				backend = variant.Backend
+			}
+			variants = append(variants, fmt.Sprintf("%s %d -> %s", variantName, variant.Weight, backend))
+		}
+		sort.Strings(variants)
+		steps = append(steps, PipelineStep{Name: "experiment", Level: "frontend", Description: strings.Join(variants, ", ")})
+	}
+	return steps
+}
+
+func (server *Server) backendPipeline(frontend *types.Frontend, backend *types.Backend) []PipelineStep {
+	if backend == nil {
+		return []PipelineStep{{Name: "backend", Level: "backend", Description: "undefined backend " + frontend.Backend}}
+	}
+	steps := []PipelineStep{}
+	if backend.CircuitBreaker != nil {
+		steps = append(steps, PipelineStep{Name: "circuitBreaker", Level: "backend", Description: backend.CircuitBreaker.Expression})
+	}
+	if server.globalConfiguration.Retry != nil && !backend.Streaming {
+		retries := len(backend.Servers)
+		if server.globalConfiguration.Retry.Attempts > 0 {
+			retries = server.globalConfiguration.Retry.Attempts
+		}
+		steps = append(steps, PipelineStep{Name: "retry", Level: "backend", Description: fmt.Sprintf("%d attempts", retries)})
+	}
+	if backend.MaxConn != nil && backend.MaxConn.Amount != 0 {
+		steps = append(steps, PipelineStep{Name: "maxConn", Level: "backend", Description: fmt.Sprintf("%d by %s", backend.MaxConn.Amount, backend.MaxConn.ExtractorFunc)})
+	}
+	lbMethod, _ := types.NewLoadBalancerMethod(backend.LoadBalancer)
+	loadBalancer := "wrr"
+	if lbMethod == types.Drr {
+		loadBalancer = "drr"
+	}
+	if backend.LoadBalancer != nil && backend.LoadBalancer.Sticky {
+		loadBalancer += ", sticky"
+	}
+	steps = append(steps, PipelineStep{Name: "loadBalancer", Level: "backend", Description: fmt.Sprintf("%s, %d servers", loadBalancer, len(backend.Servers))})
+	forwarder := frontend.Backend
+	if frontend.PassHostHeader {
+		forwarder += ", pass host header"
+	}
+	if backend.TLS != nil {
+		forwarder += ", TLS " + backendTLSDescription(backend.TLS)
+	}
+	if backend.Expect != nil {
+		mode := backend.Expect.Mode
+		if len(mode) == 0 {
+			mode = expectForward
+		}
+		forwarder += ", expect " + mode
+	}
+	if backend.Streaming {
+		forwarder += ", streaming"
+	}
+	if backend.Timeouts != nil && (backend.Timeouts.MaxDuration > 0 || backend.Timeouts.Stall > 0) {
+		forwarder += fmt.Sprintf(", timeouts %ds max duration, %ds stall", backend.Timeouts.MaxDuration, backend.Timeouts.Stall)
+	}
+	return append(steps, PipelineStep{Name: "forwarder", Level: "backend", Description: forwarder})
+}
+
+func headersDescription(headers *types.HeaderPolicy) string {
+	options := []string{}
+	if headers.StripForwarded {
+		options = append(options, "strip forwarded")
+	}
+	if headers.StripConnection {
+		options = append(options, "strip connection")
+	}
+	if len(headers.StripHeaders) > 0 {
+		options = append(options, "strip "+strings.Join(headers.StripHeaders, " "))
+	}
+	if headers.EmitForwarded {
+		options = append(options, "emit forwarded")
+	}
+	if headers.MaxValueLength > 0 {
+		options = append(options, fmt.Sprintf("max value length %d", headers.MaxValueLength))
+	}
+	if headers.RejectControlChars {
+		options = append(options, "reject control characters")
+	}
+	return strings.Join(options, ", ")
+}
+
+func pathsDescription(paths *types.PathNormalization) string {
+	options := []string{}
+	if paths.MergeSlashes {
+		options = append(options, "merge slashes")
+	}
+	if paths.RemoveDotSegments {
+		options = append(options, "remove dot segments")
+	}
+	if paths.NormalizeEscapes {
+		options = append(options, "normalize escapes")
+	}
+	if paths.Strict {
+		options = append(options, "strict")
+	}
+	return strings.Join(options, ", ")
+}
+
+func authDescription(auth *types.Auth) string {
+	description := "basic"
+	if auth.Digest != nil {
+		description = "digest"
+	} else if auth.Basic != nil && auth.Basic.Lockout != nil {
+		description = "basic, lockout"
+	} else if auth.Basic == nil && auth.LDAP != nil {
+		description = "ldap"
+	}
+	if auth.TOTP != nil {
+		description += ", totp"
+	}
+	if auth.SSO != nil {
+		description += ", sso " + auth.SSO.Group
+	}
+	return description
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/containous/traefik/types"
+)
+
+func TestGetFrontendPipeline(t *testing.T) {
+	server := &Server{
+		globalConfiguration: GlobalConfiguration{
+			EntryPoints: EntryPoints{
+				"http":  &EntryPoint{Redirect: &Redirect{EntryPoint: "https"}},
+				"https": &EntryPoint{Compress: true, Auth: &types.Auth{Basic: &types.Basic{}}},
+			},
+			Retry: &Retry{},
+		},
+	}
+	server.currentConfigurations.Set(configs{
+		"file": &types.Configuration{
+			Backends: map[string]*types.Backend{
+				"backend1": {
+					Servers:        map[string]types.Server{"server1": {URL: "http://127.0.0.1:8080"}, "server2": {URL: "http://127.0.0.1:8081"}},
+					CircuitBreaker: &types.CircuitBreaker{Expression: "NetworkErrorRatio() > 0.5"},
+					LoadBalancer:   &types.LoadBalancer{Method: "drr"},
+				},
+			},
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {
+					EntryPoints: []string{"http", "https"},
+					Backend:     "backend1",
+					Routes:      map[string]types.Route{"route1": {Rule: "PathPrefixStrip:/api"}},
+				},
+			},
+		},
+	})
+
+	if pipeline := server.getFrontendPipeline("unknown"); pipeline != nil {
+		t.Fatalf("expected no pipeline for an unknown frontend, got %+v", pipeline)
+	}
+
+	pipeline := server.getFrontendPipeline("frontend1")
+	if pipeline == nil {
+		t.Fatal("expected a pipeline for frontend1")
+	}
+	if pipeline.Provider != "file" {
+		t.Errorf("expected provider file, got %s", pipeline.Provider)
+	}
+
+	expected := map[string][]string{
+		"http":  {"accessLog", "metrics", "router", "redirect"},
+		"https": {"accessLog", "metrics", "auth", "compress", "router", "stripPrefix", "circuitBreaker", "retry", "loadBalancer", "forwarder"},
+	}
+	for entryPointName, names := range expected {
+		actual := []string{}
+		for _, step := range pipeline.EntryPoints[entryPointName] {
+			actual = append(actual, step.Name)
+		}
+		if !reflect.DeepEqual(actual, names) {
+			t.Errorf("expected pipeline %v on entrypoint %s, got %v", names, entryPointName, actual)
+		}
+	}
+
+	steps := pipeline.EntryPoints["https"]
+	if retry := steps[len(steps)-3]; retry.Description != "2 attempts" {
+		t.Errorf("expected 2 retry attempts, got %s", retry.Description)
+	}
+	if loadBalancer := steps[len(steps)-2]; loadBalancer.Description != "drr, 2 servers" {
+		t.Errorf("expected drr load balancer with 2 servers, got %s", loadBalancer.Description)
+	}
+}
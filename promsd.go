@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/url"
+	"sort"
+)
+
+// PrometheusTargetGroup is a target group of the Prometheus HTTP service discovery.
+type PrometheusTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// prometheusTargets returns a target group per server of the backends used by
+// at least one frontend, labelled with the provider, frontend, backend and server.
+func prometheusTargets(configurations configs) []*PrometheusTargetGroup {
+	groups := []*PrometheusTargetGroup{}
+	providerNames := []string{}
+	for providerName := range configurations {
+		providerNames = append(providerNames, providerName)
+	}
+	sort.Strings(providerNames)
+
+	for _, providerName := range providerNames {
+		configuration := configurations[providerName]
+		if configuration == nil {
+			continue
+		}
+		for _, frontendName := range sortedFrontendNamesForConfig(configuration) {
+			frontend := configuration.Frontends[frontendName]
+			backend, ok := configuration.Backends[frontend.Backend]
+			if !ok {
+				continue
+			}
+			serverNames := []string{}
+			for serverName := range backend.Servers {
+				serverNames = append(serverNames, serverName)
+			}
+			sort.Strings(serverNames)
+			for _, serverName := range serverNames {
+				serverURL, err := url.Parse(backend.Servers[serverName].URL)
+				if err != nil || len(serverURL.Host) == 0 {
+					continue
+				}
+				groups = append(groups, &PrometheusTargetGroup{
+					Targets: []string{serverURL.Host},
+					Labels: map[string]string{
+						"__scheme__": serverURL.Scheme,
+						"provider":   providerName,
+						"frontend":   frontendName,
+						"backend":    frontend.Backend,
+						"server":     serverName,
+					},
+				})
+			}
+		}
+	}
+	return groups
+}
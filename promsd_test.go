@@ -0,0 +1,39 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/containous/traefik/types"
+)
+
+func TestPrometheusTargets(t *testing.T) {
+	configurations := configs{
+		"file": &types.Configuration{
+			Backends: map[string]*types.Backend{
+				"backend1": {Servers: map[string]types.Server{
+					"server2": {URL: "https://10.0.0.2:8443"},
+					"server1": {URL: "http://10.0.0.1:8080"},
+				}},
+				"unused": {Servers: map[string]types.Server{"server1": {URL: "http://10.0.0.3:8080"}}},
+			},
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {Backend: "backend1"},
+				"frontend2": {Backend: "undefined"},
+			},
+		},
+	}
+	expected := []*PrometheusTargetGroup{
+		{
+			Targets: []string{"10.0.0.1:8080"},
+			Labels:  map[string]string{"__scheme__": "http", "provider": "file", "frontend": "frontend1", "backend": "backend1", "server": "server1"},
+		},
+		{
+			Targets: []string{"10.0.0.2:8443"},
+			Labels:  map[string]string{"__scheme__": "https", "provider": "file", "frontend": "frontend1", "backend": "backend1", "server": "server2"},
+		},
+	}
+	if actual := prometheusTargets(configurations); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
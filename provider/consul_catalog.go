@@ -23,22 +23,71 @@ const (
 	DefaultWatchWaitTime = 15 * time.Second
 	// DefaultConsulCatalogTagPrefix is a prefix for additional service/node configurations
 	DefaultConsulCatalogTagPrefix = "traefik"
+	// DefaultConsulCatalogConnectService is the service name of the Connect identity of traefik
+	DefaultConsulCatalogConnectService = "traefik"
 )
 
 var _ Provider = (*ConsulCatalog)(nil)
 
 // ConsulCatalog holds configurations of the Consul catalog provider.
 type ConsulCatalog struct {
-	BaseProvider `mapstructure:",squash"`
-	Endpoint     string `description:"Consul server endpoint"`
-	Domain       string `description:"Default domain used"`
-	client       *api.Client
-	Prefix       string
+	BaseProvider   `mapstructure:",squash"`
+	Endpoint       string `description:"Consul server endpoint"`
+	Domain         string `description:"Default domain used"`
+	client         *api.Client
+	Prefix         string
+	Connect        bool   `description:"Route to the Consul Connect services over mutual TLS"`
+	ConnectService string `description:"Service name of the Connect identity of traefik"`
+	datacenter     string
+	connectLeaf    *connectLeaf
+	connectRoots   *connectRoots
 }
 
 type serviceUpdate struct {
 	ServiceName string
 	Attributes  []string
+	Connect     bool
+}
+
+// connectLeaf is the leaf certificate of the Connect identity of traefik.
+type connectLeaf struct {
+	SerialNumber  string
+	CertPEM       string
+	PrivateKeyPEM string
+}
+
+// connectRoots are the root certificates of the Connect CA.
+type connectRoots struct {
+	TrustDomain string
+	Roots       []connectRoot
+}
+
+type connectRoot struct {
+	RootCert string
+}
+
+// catalogServiceEntry is an instance of a service, with the fields of the
+// newer Consul versions the vendored api lacks. The instances of a
+// Connect-enabled service are its Connect-native instances, and the proxies
+// of its instances.
+type catalogServiceEntry struct {
+	Node    *api.Node
+	Service *catalogAgentService
+	Checks  []*api.HealthCheck
+}
+
+type catalogAgentService struct {
+	api.AgentService
+	Kind    string
+	Meta    map[string]string
+	Weights *catalogServiceWeights
+}
+
+// catalogServiceWeights are the native weights of an instance, since Consul
+// 1.2.3: its weight while its checks are passing, and while one is warning.
+type catalogServiceWeights struct {
+	Passing int
+	Warning int
 }
 
 type catalogUpdate struct {
@@ -114,14 +163,61 @@ func (provider *ConsulCatalog) watchServices(stopCh <-chan struct{}) <-chan map[
 	return watchCh
 }
 
+// watchConnect sends the value of the Connect endpoint of the local agent to
+// the returned channel each time it changes, like the leaf certificate of
+// traefik when it is renewed.
+func (provider *ConsulCatalog) watchConnect(stopCh <-chan struct{}, endpoint string, newValue func() interface{}) <-chan interface{} {
+	watchCh := make(chan interface{})
+
+	raw := provider.client.Raw()
+
+	safe.Go(func() {
+		defer close(watchCh)
+
+		opts := &api.QueryOptions{WaitTime: DefaultWatchWaitTime}
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+
+			value := newValue()
+			meta, err := raw.Query(endpoint, value, opts)
+			if err != nil {
+				log.WithError(err).Errorf("Failed to fetch %s", endpoint)
+				return
+			}
+
+			if opts.WaitIndex == meta.LastIndex {
+				continue
+			}
+			opts.WaitIndex = meta.LastIndex
+
+			select {
+			case <-stopCh:
+				return
+			case watchCh <- value:
+			}
+		}
+	})
+
+	return watchCh
+}
+
 func (provider *ConsulCatalog) healthyNodes(service string) (catalogUpdate, error) {
-	health := provider.client.Health()
-	opts := &api.QueryOptions{}
-	data, _, err := health.Service(service, "", true, opts)
-	if err != nil {
+	var entries []*catalogServiceEntry
+	if _, err := provider.client.Raw().Query("/v1/health/service/"+service, &entries, &api.QueryOptions{}); err != nil {
 		log.WithError(err).Errorf("Failed to fetch details of " + service)
 		return catalogUpdate{}, err
 	}
+	data := []*api.ServiceEntry{}
+	for _, entry := range entries {
+		if node, ok := provider.serviceEntry(entry); ok {
+			data = append(data, node)
+		}
+	}
 
 	nodes := fun.Filter(func(node *api.ServiceEntry) bool {
 		constraintTags := provider.getContraintTags(node.Service.Tags)
@@ -149,6 +245,119 @@ func (provider *ConsulCatalog) healthyNodes(service string) (catalogUpdate, erro
 	}, nil
 }
 
+// connectNodes returns the passing Connect-native instances and proxies of
+// service, the proxies being routed as instances of service, and the names of
+// the proxy services.
+func (provider *ConsulCatalog) connectNodes(service string) ([]*api.ServiceEntry, []string, error) {
+	var entries []*catalogServiceEntry
+	if _, err := provider.client.Raw().Query("/v1/health/connect/"+service, &entries, &api.QueryOptions{}); err != nil {
+		log.WithError(err).Errorf("Failed to fetch Connect details of " + service)
+		return nil, nil, err
+	}
+
+	nodes := []*api.ServiceEntry{}
+	proxies := []string{}
+	for _, entry := range entries {
+		if entry.Service == nil {
+			continue
+		}
+		if entry.Service.Kind == "connect-proxy" {
+			proxies = append(proxies, strings.ToLower(entry.Service.Service))
+			entry.Service.Service = service
+		}
+		if node, ok := provider.serviceEntry(entry); ok {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, proxies, nil
+}
+
+// serviceEntry returns the instance of entry to route to, or false if it isn't
+// routed: an instance with a critical check, or a warning one without a
+// warning weight. The attributes of the meta of the instance replace the ones
+// of its tags, and without a backend.weight attribute, the weight of the
+// instance is its native weight of the status of its checks, set as a
+// traefik.backend.weight tag.
+func (provider *ConsulCatalog) serviceEntry(entry *catalogServiceEntry) (*api.ServiceEntry, bool) {
+	if entry.Service == nil {
+		return nil, false
+	}
+	weight := ""
+	switch checksStatus(entry.Checks) {
+	case "passing":
+		if entry.Service.Weights != nil {
+			weight = strconv.Itoa(entry.Service.Weights.Passing)
+		}
+	case "warning":
+		if entry.Service.Weights == nil || entry.Service.Weights.Warning <= 0 {
+			return nil, false
+		}
+		weight = strconv.Itoa(entry.Service.Weights.Warning)
+	default:
+		return nil, false
+	}
+
+	agentService := entry.Service.AgentService
+	agentService.Tags = mergeMetaAttributes(entry.Service.Meta, agentService.Tags)
+	if len(weight) > 0 && len(provider.getAttribute("backend.weight", agentService.Tags, "")) == 0 {
+		agentService.Tags = append(agentService.Tags, DefaultConsulCatalogTagPrefix+".backend.weight="+weight)
+	}
+	return &api.ServiceEntry{Node: entry.Node, Service: &agentService, Checks: entry.Checks}, true
+}
+
+// mergeMetaAttributes returns the traefik-<attribute> keys of meta as
+// traefik.<attribute>=<value> tags, the dashes of the attribute being dots
+// (traefik-frontend-rule is the frontend.rule attribute), followed by the tags
+// whose attribute isn't in meta.
+func mergeMetaAttributes(meta map[string]string, tags []string) []string {
+	merged := []string{}
+	metaAttributes := map[string]bool{}
+	for key, value := range meta {
+		if !strings.HasPrefix(strings.ToLower(key), DefaultConsulCatalogTagPrefix+"-") {
+			continue
+		}
+		name := strings.Replace(key[len(DefaultConsulCatalogTagPrefix+"-"):], "-", ".", -1)
+		metaAttributes[strings.ToLower(name)] = true
+		merged = append(merged, DefaultConsulCatalogTagPrefix+"."+name+"="+value)
+	}
+	// the keys of a map aren't ordered, and the tags name the backend servers
+	sort.Strings(merged)
+	for _, tag := range tags {
+		if !metaAttributes[tagAttribute(tag)] {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+// tagAttribute returns the lowercased name of the attribute of a
+// traefik.<attribute>=<value> tag, or an empty string for another tag.
+func tagAttribute(tag string) string {
+	if strings.Index(strings.ToLower(tag), DefaultConsulCatalogTagPrefix+".") != 0 {
+		return ""
+	}
+	if kv := strings.SplitN(tag[len(DefaultConsulCatalogTagPrefix+"."):], "=", 2); len(kv) == 2 {
+		return strings.ToLower(kv[0])
+	}
+	return ""
+}
+
+// checksStatus returns the status of an instance of its checks: critical if
+// a check isn't passing nor warning, warning if one is, passing otherwise.
+func checksStatus(checks []*api.HealthCheck) string {
+	status := "passing"
+	for _, check := range checks {
+		switch check.Status {
+		case "passing":
+		case "warning":
+			status = "warning"
+		default:
+			return "critical"
+		}
+	}
+	return status
+}
+
 func (provider *ConsulCatalog) getEntryPoints(list string) []string {
 	return strings.Split(list, ",")
 }
@@ -198,6 +407,27 @@ func (provider *ConsulCatalog) getAttribute(name string, tags []string, defaultV
 	return defaultValue
 }
 
+func (provider *ConsulCatalog) getConnectRootCAs() []string {
+	rootCAs := []string{}
+	for _, root := range provider.connectRoots.Roots {
+		rootCAs = append(rootCAs, root.RootCert)
+	}
+	return rootCAs
+}
+
+func (provider *ConsulCatalog) getConnectCertificate() string {
+	return provider.connectLeaf.CertPEM
+}
+
+func (provider *ConsulCatalog) getConnectKey() string {
+	return provider.connectLeaf.PrivateKeyPEM
+}
+
+// getConnectURI returns the SPIFFE ID of the Connect identity of service.
+func (provider *ConsulCatalog) getConnectURI(service string) string {
+	return "spiffe://" + provider.connectRoots.TrustDomain + "/ns/default/dc/" + provider.datacenter + "/svc/" + service
+}
+
 func (provider *ConsulCatalog) getContraintTags(tags []string) []string {
 	var list []string
 
@@ -212,14 +442,30 @@ func (provider *ConsulCatalog) getContraintTags(tags []string) []string {
 }
 
 func (provider *ConsulCatalog) buildConfig(catalog []catalogUpdate) *types.Configuration {
+	connectServices := map[string]bool{}
+	for _, info := range catalog {
+		if info.Service.Connect {
+			connectServices[info.Service.ServiceName] = true
+		}
+	}
 	var FuncMap = template.FuncMap{
-		"getBackend":           provider.getBackend,
-		"getFrontendRule":      provider.getFrontendRule,
-		"getBackendName":       provider.getBackendName,
-		"getBackendAddress":    provider.getBackendAddress,
-		"getAttribute":         provider.getAttribute,
-		"getEntryPoints":       provider.getEntryPoints,
-		"hasMaxconnAttributes": provider.hasMaxconnAttributes,
+		"getBackend":        provider.getBackend,
+		"getFrontendRule":   provider.getFrontendRule,
+		"getBackendName":    provider.getBackendName,
+		"getBackendAddress": provider.getBackendAddress,
+		"getAttribute":      provider.getAttribute,
+		"getProtocol": func(node *api.ServiceEntry) string {
+			if connectServices[provider.getBackend(node)] {
+				return "https"
+			}
+			return provider.getAttribute("protocol", node.Service.Tags, "http")
+		},
+		"getEntryPoints":        provider.getEntryPoints,
+		"hasMaxconnAttributes":  provider.hasMaxconnAttributes,
+		"getConnectRootCAs":     provider.getConnectRootCAs,
+		"getConnectCertificate": provider.getConnectCertificate,
+		"getConnectKey":         provider.getConnectKey,
+		"getConnectURI":         provider.getConnectURI,
 	}
 
 	allNodes := []*api.ServiceEntry{}
@@ -266,6 +512,26 @@ func (provider *ConsulCatalog) hasMaxconnAttributes(attributes []string) bool {
 func (provider *ConsulCatalog) getNodes(index map[string][]string) ([]catalogUpdate, error) {
 	visited := make(map[string]bool)
 
+	// the Connect-enabled services are routed to their Connect-native
+	// instances and proxies, and the proxy services are not routed
+	connectNodes := make(map[string][]*api.ServiceEntry)
+	if provider.Connect {
+		for service := range index {
+			name := strings.ToLower(service)
+			if strings.Contains(name, " ") || connectNodes[name] != nil {
+				continue
+			}
+			nodes, proxies, err := provider.connectNodes(name)
+			if err != nil {
+				return nil, err
+			}
+			connectNodes[name] = nodes
+			for _, proxy := range proxies {
+				visited[proxy] = true
+			}
+		}
+	}
+
 	nodes := []catalogUpdate{}
 	for service := range index {
 		name := strings.ToLower(service)
@@ -280,6 +546,10 @@ func (provider *ConsulCatalog) getNodes(index map[string][]string) ([]catalogUpd
 			}
 			// healthy.Nodes can be empty if constraints do not match, without throwing error
 			if healthy.Service != nil && len(healthy.Nodes) > 0 {
+				if len(connectNodes[name]) > 0 {
+					healthy.Service.Connect = true
+					healthy.Nodes = connectNodes[name]
+				}
 				nodes = append(nodes, healthy)
 			}
 		}
@@ -290,27 +560,50 @@ func (provider *ConsulCatalog) getNodes(index map[string][]string) ([]catalogUpd
 func (provider *ConsulCatalog) watch(configurationChan chan<- types.ConfigMessage, stop chan bool) error {
 	stopCh := make(chan struct{})
 	serviceCatalog := provider.watchServices(stopCh)
+	var leafCh, rootsCh <-chan interface{}
+	if provider.Connect {
+		leafCh = provider.watchConnect(stopCh, "/v1/agent/connect/ca/leaf/"+provider.ConnectService, func() interface{} { return &connectLeaf{} })
+		rootsCh = provider.watchConnect(stopCh, "/v1/agent/connect/ca/roots", func() interface{} { return &connectRoots{} })
+	}
 
 	defer close(stopCh)
 
+	var index map[string][]string
 	for {
 		select {
 		case <-stop:
 			return nil
-		case index, ok := <-serviceCatalog:
+		case services, ok := <-serviceCatalog:
 			if !ok {
 				return errors.New("Consul service list nil")
 			}
 			log.Debug("List of services changed")
-			nodes, err := provider.getNodes(index)
-			if err != nil {
-				return err
+			index = services
+		case leaf, ok := <-leafCh:
+			if !ok {
+				return errors.New("Consul Connect leaf certificate nil")
 			}
-			configuration := provider.buildConfig(nodes)
-			configurationChan <- types.ConfigMessage{
-				ProviderName:  "consul_catalog",
-				Configuration: configuration,
+			log.Debugf("Connect leaf certificate changed: %s", leaf.(*connectLeaf).SerialNumber)
+			provider.connectLeaf = leaf.(*connectLeaf)
+		case roots, ok := <-rootsCh:
+			if !ok {
+				return errors.New("Consul Connect CA roots nil")
 			}
+			log.Debug("Connect CA roots changed")
+			provider.connectRoots = roots.(*connectRoots)
+		}
+		// the routes need the services, and the Connect certificates when enabled
+		if index == nil || provider.Connect && (provider.connectLeaf == nil || provider.connectRoots == nil) {
+			continue
+		}
+		nodes, err := provider.getNodes(index)
+		if err != nil {
+			return err
+		}
+		configuration := provider.buildConfig(nodes)
+		configurationChan <- types.ConfigMessage{
+			ProviderName:  "consul_catalog",
+			Configuration: configuration,
 		}
 	}
 }
@@ -326,6 +619,16 @@ func (provider *ConsulCatalog) Provide(configurationChan chan<- types.ConfigMess
 	}
 	provider.client = client
 	provider.Constraints = append(provider.Constraints, constraints...)
+	if provider.Connect {
+		if len(provider.ConnectService) == 0 {
+			provider.ConnectService = DefaultConsulCatalogConnectService
+		}
+		self, err := client.Agent().Self()
+		if err != nil {
+			return err
+		}
+		provider.datacenter, _ = self["Config"]["Datacenter"].(string)
+	}
 
 	pool.Go(func(stop chan bool) {
 		notify := func(err error, time time.Duration) {
@@ -3,11 +3,14 @@ package provider
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -44,12 +47,47 @@ var _ Provider = (*Docker)(nil)
 // Docker holds configurations of the Docker provider.
 type Docker struct {
 	BaseProvider     `mapstructure:",squash"`
-	Endpoint         string     `description:"Docker server endpoint. Can be a tcp or a unix socket endpoint"`
-	Domain           string     `description:"Default domain used"`
-	TLS              *ClientTLS `description:"Enable Docker TLS support"`
-	ExposedByDefault bool       `description:"Expose containers by default"`
-	UseBindPortIP    bool       `description:"Use the ip address from the bound port, rather than from the inner network"`
-	SwarmMode        bool       `description:"Use Docker on Swarm Mode"`
+	Endpoint         string           `description:"Docker server endpoint. Can be a tcp or a unix socket endpoint"`
+	Domain           string           `description:"Default domain used"`
+	TLS              *ClientTLS       `description:"Enable Docker TLS support"`
+	ExposedByDefault bool             `description:"Expose containers by default"`
+	UseBindPortIP    bool             `description:"Use the ip address from the bound port, rather than from the inner network"`
+	SwarmMode        bool             `description:"Use Docker on Swarm Mode"`
+	UseExposedPorts  bool             `description:"Use the lowest port exposed by the image of the containers without traefik.port label"`
+	Endpoints        []DockerEndpoint `description:"Watch several Docker daemons or Swarm managers instead of endpoint, using format: --docker.endpoints='name,tcp://host:port'"`
+	LabelPrefix      string           `description:"Prefix of the labels of the containers read instead of traefik, like myproxy for myproxy.frontend.rule"`
+}
+
+// DockerEndpoint is a Docker daemon or Swarm manager watched with the others
+// of the Docker provider. Its name tags the backends of its containers.
+type DockerEndpoint struct {
+	Name     string     `description:"Name of the endpoint"`
+	Endpoint string     `description:"Docker server endpoint. Can be a tcp or a unix socket endpoint"`
+	TLS      *ClientTLS `description:"Enable Docker TLS support"`
+}
+
+// DockerEndpoints parses []DockerEndpoint
+type DockerEndpoints []DockerEndpoint
+
+// Set adds an endpoint from str, "name,endpoint"
+func (e *DockerEndpoints) Set(str string) error {
+	slice := strings.SplitN(str, ",", 2)
+	if len(slice) != 2 || len(slice[0]) == 0 || len(slice[1]) == 0 {
+		return fmt.Errorf("invalid docker endpoint %q, expected name,endpoint", str)
+	}
+	*e = append(*e, DockerEndpoint{Name: slice[0], Endpoint: slice[1]})
+	return nil
+}
+
+// Get []DockerEndpoint
+func (e *DockerEndpoints) Get() interface{} { return []DockerEndpoint(*e) }
+
+// String returns []DockerEndpoint in string
+func (e *DockerEndpoints) String() string { return fmt.Sprintf("%+v", *e) }
+
+// SetValue sets []DockerEndpoint into the parser
+func (e *DockerEndpoints) SetValue(val interface{}) {
+	*e = DockerEndpoints(val.([]DockerEndpoint))
 }
 
 // dockerData holds the need data to the Docker provider
@@ -58,6 +96,9 @@ type dockerData struct {
 	Labels          map[string]string // List of labels set to container or service
 	NetworkSettings networkSettings
 	Health          string
+	ExposedPorts    nat.PortSet       // Ports exposed by the image of the container
+	Endpoint        string            // Name of the Docker endpoint of the container, with several endpoints
+	Env             map[string]string // Environment variables of the container or service, for the custom templates
 }
 
 // NetworkSettings holds the networks data to the Docker provider
@@ -76,20 +117,20 @@ type networkData struct {
 	ID       string
 }
 
-func (provider *Docker) createClient() (client.APIClient, error) {
+func (provider *Docker) createClient(endpoint string, clientTLS *ClientTLS) (client.APIClient, error) {
 	var httpClient *http.Client
 	httpHeaders := map[string]string{
 		"User-Agent": "Traefik " + version.Version,
 	}
-	if provider.TLS != nil {
-		config, err := provider.TLS.CreateTLSConfig()
+	if clientTLS != nil {
+		config, err := clientTLS.CreateTLSConfig()
 		if err != nil {
 			return nil, err
 		}
 		tr := &http.Transport{
 			TLSClientConfig: config,
 		}
-		proto, addr, _, err := client.ParseHost(provider.Endpoint)
+		proto, addr, _, err := client.ParseHost(endpoint)
 		if err != nil {
 			return nil, err
 		}
@@ -107,7 +148,7 @@ func (provider *Docker) createClient() (client.APIClient, error) {
 	} else {
 		version = DockerAPIVersion
 	}
-	return client.NewClient(provider.Endpoint, version, httpClient, httpHeaders)
+	return client.NewClient(endpoint, version, httpClient, httpHeaders)
 
 }
 
@@ -115,12 +156,56 @@ func (provider *Docker) createClient() (client.APIClient, error) {
 // using the given configuration channel.
 func (provider *Docker) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, constraints types.Constraints) error {
 	provider.Constraints = append(provider.Constraints, constraints...)
+	publish := func(configuration *types.Configuration) {
+		configurationChan <- types.ConfigMessage{
+			ProviderName:  "docker",
+			Configuration: configuration,
+		}
+	}
+	if len(provider.Endpoints) == 0 {
+		provider.watchEndpoint(DockerEndpoint{Endpoint: provider.Endpoint, TLS: provider.TLS}, pool, func(dockerDataList []dockerData, initial bool) {
+			configuration := provider.loadDockerConfig(dockerDataList)
+			if configuration != nil || initial {
+				publish(configuration)
+			}
+		})
+		return nil
+	}
+
+	endpointNames := map[string]bool{}
+	for _, endpoint := range provider.Endpoints {
+		if len(endpoint.Name) == 0 || len(endpoint.Endpoint) == 0 {
+			return fmt.Errorf("invalid docker endpoint %+v, expected a name and an endpoint", endpoint)
+		}
+		if endpointNames[endpoint.Name] {
+			return fmt.Errorf("duplicated docker endpoint %s", endpoint.Name)
+		}
+		endpointNames[endpoint.Name] = true
+	}
+	endpointsData := newDockerEndpointsData()
+	for _, endpoint := range provider.Endpoints {
+		endpointName := endpoint.Name
+		provider.watchEndpoint(endpoint, pool, func(dockerDataList []dockerData, initial bool) {
+			endpointsData.update(endpointName, dockerDataList, func(merged []dockerData) {
+				if configuration := provider.loadDockerConfig(merged); configuration != nil {
+					publish(configuration)
+				}
+			})
+		})
+	}
+	return nil
+}
+
+// watchEndpoint lists the containers, or the services in Swarm mode, of
+// endpoint, and lists them again on its events when watching. The lists are
+// handed to load, initial for the first one of a connection.
+func (provider *Docker) watchEndpoint(endpoint DockerEndpoint, pool *safe.Pool, load func(dockerDataList []dockerData, initial bool)) {
 	// TODO register this routine in pool, and watch for stop channel
 	safe.Go(func() {
 		operation := func() error {
 			var err error
 
-			dockerClient, err := provider.createClient()
+			dockerClient, err := provider.createClient(endpoint.Endpoint, endpoint.TLS)
 			if err != nil {
 				log.Errorf("Failed to create a client for docker, error: %s", err)
 				return err
@@ -144,11 +229,7 @@ func (provider *Docker) Provide(configurationChan chan<- types.ConfigMessage, po
 				}
 			}
 
-			configuration := provider.loadDockerConfig(dockerDataList)
-			configurationChan <- types.ConfigMessage{
-				ProviderName:  "docker",
-				Configuration: configuration,
-			}
+			load(dockerDataList, true)
 			if provider.Watch {
 				ctx, cancel := context.WithCancel(ctx)
 				if provider.SwarmMode {
@@ -163,13 +244,7 @@ func (provider *Docker) Provide(configurationChan chan<- types.ConfigMessage, po
 									log.Errorf("Failed to list services for docker, error %s", err)
 									return
 								}
-								configuration := provider.loadDockerConfig(services)
-								if configuration != nil {
-									configurationChan <- types.ConfigMessage{
-										ProviderName:  "docker",
-										Configuration: configuration,
-									}
-								}
+								load(services, false)
 
 							case <-stop:
 								ticker.Stop()
@@ -204,13 +279,7 @@ func (provider *Docker) Provide(configurationChan chan<- types.ConfigMessage, po
 							cancel()
 							return
 						}
-						configuration := provider.loadDockerConfig(containers)
-						if configuration != nil {
-							configurationChan <- types.ConfigMessage{
-								ProviderName:  "docker",
-								Configuration: configuration,
-							}
-						}
+						load(containers, false)
 					}
 					eventHandler.Handle("start", startStopHandle)
 					eventHandler.Handle("die", startStopHandle)
@@ -234,13 +303,46 @@ func (provider *Docker) Provide(configurationChan chan<- types.ConfigMessage, po
 			log.Errorf("Cannot connect to docker server %+v", err)
 		}
 	})
+}
 
-	return nil
+// dockerEndpointsData holds the last containers or services listed from each
+// endpoint, tagged with its name.
+type dockerEndpointsData struct {
+	lock sync.Mutex
+	data map[string][]dockerData
+}
+
+func newDockerEndpointsData() *dockerEndpointsData {
+	return &dockerEndpointsData{data: map[string][]dockerData{}}
+}
+
+// update replaces the containers of the endpoint endpointName, and hands the
+// containers of all the endpoints to load, in the order of their names.
+func (d *dockerEndpointsData) update(endpointName string, dockerDataList []dockerData, load func(merged []dockerData)) {
+	tagged := make([]dockerData, 0, len(dockerDataList))
+	for _, container := range dockerDataList {
+		container.Endpoint = endpointName
+		tagged = append(tagged, container)
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.data[endpointName] = tagged
+	endpointNames := make([]string, 0, len(d.data))
+	for name := range d.data {
+		endpointNames = append(endpointNames, name)
+	}
+	sort.Strings(endpointNames)
+	merged := []dockerData{}
+	for _, name := range endpointNames {
+		merged = append(merged, d.data[name]...)
+	}
+	load(merged)
 }
 
 func (provider *Docker) loadDockerConfig(containersInspected []dockerData) *types.Configuration {
 	var DockerFuncMap = template.FuncMap{
 		"getBackend":                  provider.getBackend,
+		"getServerName":               provider.getServerName,
 		"getIPAddress":                provider.getIPAddress,
 		"getPort":                     provider.getPort,
 		"getWeight":                   provider.getWeight,
@@ -249,6 +351,7 @@ func (provider *Docker) loadDockerConfig(containersInspected []dockerData) *type
 		"getPassHostHeader":           provider.getPassHostHeader,
 		"getPriority":                 provider.getPriority,
 		"getEntryPoints":              provider.getEntryPoints,
+		"getMiddlewares":              provider.getMiddlewares,
 		"getFrontendRule":             provider.getFrontendRule,
 		"hasCircuitBreakerLabel":      provider.hasCircuitBreakerLabel,
 		"getCircuitBreakerExpression": provider.getCircuitBreakerExpression,
@@ -261,6 +364,10 @@ func (provider *Docker) loadDockerConfig(containersInspected []dockerData) *type
 		"replace":                     replace,
 	}
 
+	if prefix := strings.TrimSuffix(provider.LabelPrefix, "."); len(prefix) > 0 && prefix != "traefik" {
+		containersInspected = relabelContainers(containersInspected, prefix)
+	}
+
 	// filter containers
 	filteredContainers := fun.Filter(func(container dockerData) bool {
 		return provider.containerFilter(container)
@@ -359,7 +466,8 @@ func (provider *Docker) getMaxConnExtractorFunc(container dockerData) string {
 
 func (provider *Docker) containerFilter(container dockerData) bool {
 	_, err := strconv.Atoi(container.Labels["traefik.port"])
-	if len(container.NetworkSettings.Ports) == 0 && err != nil {
+	exposed := provider.UseExposedPorts && len(container.ExposedPorts) > 0
+	if len(container.NetworkSettings.Ports) == 0 && !exposed && err != nil {
 		log.Debugf("Filtering container without port and no traefik.port label %s", container.Name)
 		return false
 	}
@@ -403,9 +511,23 @@ func (provider *Docker) getBackend(container dockerData) string {
 	if label, err := getLabel(container, "traefik.backend"); err == nil {
 		return normalize(label)
 	}
+	if len(container.Endpoint) > 0 {
+		return normalize(container.Endpoint + "-" + container.Name)
+	}
 	return normalize(container.Name)
 }
 
+// getServerName returns the name of the server of container in its backend,
+// prefixed with its endpoint, so that the containers of the same name of
+// several endpoints don't replace each other.
+func (provider *Docker) getServerName(container dockerData) string {
+	name := strings.Replace(strings.Replace(container.Name, "/", "", -1), ".", "-", -1)
+	if len(container.Endpoint) > 0 {
+		return normalize(container.Endpoint) + "-" + name
+	}
+	return name
+}
+
 func (provider *Docker) getIPAddress(container dockerData) string {
 	if label, err := getLabel(container, "traefik.docker.network"); err == nil && label != "" {
 		networkSettings := container.NetworkSettings
@@ -444,12 +566,35 @@ func (provider *Docker) getPort(container dockerData) string {
 	if label, err := getLabel(container, "traefik.port"); err == nil {
 		return label
 	}
+	if provider.UseExposedPorts {
+		if port := lowestExposedPort(container.ExposedPorts); len(port) > 0 {
+			return port
+		}
+	}
 	for key := range container.NetworkSettings.Ports {
 		return key.Port()
 	}
 	return ""
 }
 
+// lowestExposedPort returns the lowest TCP port of ports, so that the port of
+// an image exposing several ones doesn't depend on the order of a map.
+func lowestExposedPort(ports nat.PortSet) string {
+	lowest := 0
+	for port := range ports {
+		if port.Proto() != "tcp" {
+			continue
+		}
+		if number := port.Int(); number > 0 && (lowest == 0 || number < lowest) {
+			lowest = number
+		}
+	}
+	if lowest == 0 {
+		return ""
+	}
+	return strconv.Itoa(lowest)
+}
+
 func (provider *Docker) getWeight(container dockerData) string {
 	if label, err := getLabel(container, "traefik.weight"); err == nil {
 		return label
@@ -499,6 +644,42 @@ func (provider *Docker) getEntryPoints(container dockerData) []string {
 	return []string{}
 }
 
+func (provider *Docker) getMiddlewares(container dockerData) []string {
+	if middlewares, err := getLabel(container, "traefik.frontend.middlewares"); err == nil {
+		return strings.Split(middlewares, ",")
+	}
+	return nil
+}
+
+// relabelContainers returns containers with their labels prefixed with
+// prefix renamed to the traefik labels, which are dropped, so that the
+// containers configured for another traefik are ignored.
+func relabelContainers(containers []dockerData, prefix string) []dockerData {
+	relabeled := make([]dockerData, 0, len(containers))
+	for _, container := range containers {
+		labels := map[string]string{}
+		for key, value := range container.Labels {
+			if strings.HasPrefix(key, prefix+".") {
+				labels["traefik."+strings.TrimPrefix(key, prefix+".")] = value
+			}
+		}
+		container.Labels = labels
+		relabeled = append(relabeled, container)
+	}
+	return relabeled
+}
+
+// parseEnv returns the environment variables of env, as KEY=value strings.
+func parseEnv(env []string) map[string]string {
+	variables := map[string]string{}
+	for _, variable := range env {
+		if separator := strings.Index(variable, "="); separator > 0 {
+			variables[variable[:separator]] = variable[separator+1:]
+		}
+	}
+	return variables
+}
+
 func isContainerEnabled(container dockerData, exposedByDefault bool) bool {
 	return exposedByDefault && container.Labels["traefik.enable"] != "false" || container.Labels["traefik.enable"] == "true"
 }
@@ -569,6 +750,11 @@ func parseContainer(container dockertypes.ContainerJSON) dockerData {
 		dockerData.Labels = container.Config.Labels
 	}
 
+	if container.Config != nil {
+		dockerData.ExposedPorts = container.Config.ExposedPorts
+		dockerData.Env = parseEnv(container.Config.Env)
+	}
+
 	if container.NetworkSettings != nil {
 		if container.NetworkSettings.Ports != nil {
 			dockerData.NetworkSettings.Ports = container.NetworkSettings.Ports
@@ -630,6 +816,7 @@ func parseService(service swarmtypes.Service, networkMap map[string]*dockertypes
 		Name:            service.Spec.Annotations.Name,
 		Labels:          service.Spec.Annotations.Labels,
 		NetworkSettings: networkSettings{},
+		Env:             parseEnv(service.Spec.TaskTemplate.ContainerSpec.Env),
 	}
 
 	if service.Spec.EndpointSpec != nil {
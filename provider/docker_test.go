@@ -208,6 +208,79 @@ func TestDockerGetBackend(t *testing.T) {
 	}
 }
 
+func TestDockerEndpoints(t *testing.T) {
+	endpoints := DockerEndpoints{}
+	if err := endpoints.Set("prod,tcp://10.0.0.1:2376"); err != nil {
+		t.Fatal(err)
+	}
+	expected := DockerEndpoints{{Name: "prod", Endpoint: "tcp://10.0.0.1:2376"}}
+	if !reflect.DeepEqual(endpoints, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, endpoints)
+	}
+	if err := endpoints.Set("tcp://10.0.0.2:2376"); err == nil {
+		t.Fatal("expected an error for an endpoint without name")
+	}
+
+	provider := &Docker{}
+	container := dockerData{Name: "/web.1", Endpoint: "prod"}
+	if backend := provider.getBackend(container); backend != "prod-web-1" {
+		t.Fatalf("expected backend prod-web-1, got %s", backend)
+	}
+	if server := provider.getServerName(container); server != "prod-web-1" {
+		t.Fatalf("expected server prod-web-1, got %s", server)
+	}
+	container.Labels = map[string]string{"traefik.backend": "web"}
+	if backend := provider.getBackend(container); backend != "web" {
+		t.Fatalf("expected the backend of the label, got %s", backend)
+	}
+
+	endpointsData := newDockerEndpointsData()
+	var merged []dockerData
+	load := func(dockerDataList []dockerData) {
+		merged = dockerDataList
+	}
+	endpointsData.update("staging", []dockerData{{Name: "/web"}}, load)
+	endpointsData.update("prod", []dockerData{{Name: "/web"}, {Name: "/api"}}, load)
+	if len(merged) != 3 || merged[0].Endpoint != "prod" || merged[1].Endpoint != "prod" || merged[2].Endpoint != "staging" {
+		t.Fatalf("expected the containers of prod then staging, got %+v", merged)
+	}
+	endpointsData.update("prod", nil, load)
+	if len(merged) != 1 || merged[0].Endpoint != "staging" {
+		t.Fatalf("expected the containers of staging, got %+v", merged)
+	}
+}
+
+func TestDockerLabelPrefix(t *testing.T) {
+	containers := relabelContainers([]dockerData{
+		{
+			Name: "/web",
+			Labels: map[string]string{
+				"myproxy.frontend.rule": "Host:web.example.com",
+				"myproxy.port":          "8080",
+				"traefik.port":          "80",
+				"traefik.enable":        "false",
+			},
+		},
+	}, "myproxy")
+	provider := &Docker{ExposedByDefault: true}
+	container := containers[0]
+	if !provider.containerFilter(container) {
+		t.Fatal("expected the container enabled, the traefik labels being ignored")
+	}
+	if rule := provider.getFrontendRule(container); rule != "Host:web.example.com" {
+		t.Fatalf("expected the rule of the myproxy label, got %s", rule)
+	}
+	if port := provider.getPort(container); port != "8080" {
+		t.Fatalf("expected the port of the myproxy label, got %s", port)
+	}
+
+	env := parseEnv([]string{"VIRTUAL_HOST=web.example.com", "EMPTY=", "INVALID", "OPTS=a=b"})
+	expected := map[string]string{"VIRTUAL_HOST": "web.example.com", "EMPTY": "", "OPTS": "a=b"}
+	if !reflect.DeepEqual(env, expected) {
+		t.Fatalf("expected %v, got %v", expected, env)
+	}
+}
+
 func TestDockerGetIPAddress(t *testing.T) { // TODO
 	provider := &Docker{}
 
@@ -422,6 +495,99 @@ func TestDockerGetPort(t *testing.T) {
 	}
 }
 
+func TestDockerGetPortFromExposedPorts(t *testing.T) {
+	provider := &Docker{ExposedByDefault: true, UseExposedPorts: true}
+
+	containers := []struct {
+		container docker.ContainerJSON
+		expected  string
+		filtered  bool
+	}{
+		{
+			container: docker.ContainerJSON{
+				ContainerJSONBase: &docker.ContainerJSONBase{
+					Name:       "host-network",
+					HostConfig: &container.HostConfig{NetworkMode: "host"},
+				},
+				Config: &container.Config{
+					ExposedPorts: nat.PortSet{
+						"8443/tcp": {},
+						"8080/tcp": {},
+						"53/udp":   {},
+					},
+				},
+				NetworkSettings: &docker.NetworkSettings{},
+			},
+			expected: "8080",
+		},
+		{
+			container: docker.ContainerJSON{
+				ContainerJSONBase: &docker.ContainerJSONBase{
+					Name: "label",
+				},
+				Config: &container.Config{
+					Labels: map[string]string{
+						"traefik.port": "9000",
+					},
+					ExposedPorts: nat.PortSet{
+						"8080/tcp": {},
+					},
+				},
+				NetworkSettings: &docker.NetworkSettings{},
+			},
+			expected: "9000",
+		},
+		{
+			container: docker.ContainerJSON{
+				ContainerJSONBase: &docker.ContainerJSONBase{
+					Name: "udp-only",
+				},
+				Config: &container.Config{
+					ExposedPorts: nat.PortSet{
+						"53/udp": {},
+					},
+				},
+				NetworkSettings: &docker.NetworkSettings{
+					NetworkSettingsBase: docker.NetworkSettingsBase{
+						Ports: nat.PortMap{
+							"80/tcp": {},
+						},
+					},
+				},
+			},
+			expected: "80",
+		},
+		{
+			container: docker.ContainerJSON{
+				ContainerJSONBase: &docker.ContainerJSONBase{
+					Name: "nothing-exposed",
+				},
+				Config:          &container.Config{},
+				NetworkSettings: &docker.NetworkSettings{},
+			},
+			expected: "",
+			filtered: true,
+		},
+	}
+
+	for _, e := range containers {
+		dockerData := parseContainer(e.container)
+		actual := provider.getPort(dockerData)
+		if actual != e.expected {
+			t.Fatalf("expected %q, got %q", e.expected, actual)
+		}
+		if provider.containerFilter(dockerData) == e.filtered {
+			t.Fatalf("expected container %s filtered %v", dockerData.Name, e.filtered)
+		}
+	}
+
+	// without the option, the ports of the image are ignored
+	dockerData := parseContainer(containers[0].container)
+	if port := (&Docker{}).getPort(dockerData); port != "" {
+		t.Fatalf("expected no port, got %q", port)
+	}
+}
+
 func TestDockerGetWeight(t *testing.T) {
 	provider := &Docker{}
 
@@ -583,6 +749,45 @@ func TestDockerGetPassHostHeader(t *testing.T) {
 	}
 }
 
+func TestDockerGetMiddlewares(t *testing.T) {
+	provider := &Docker{}
+	containers := []struct {
+		container docker.ContainerJSON
+		expected  []string
+	}{
+		{
+			container: docker.ContainerJSON{
+				ContainerJSONBase: &docker.ContainerJSONBase{
+					Name: "foo",
+				},
+				Config: &container.Config{},
+			},
+			expected: nil,
+		},
+		{
+			container: docker.ContainerJSON{
+				ContainerJSONBase: &docker.ContainerJSONBase{
+					Name: "test",
+				},
+				Config: &container.Config{
+					Labels: map[string]string{
+						"traefik.frontend.middlewares": "audit,geoblock",
+					},
+				},
+			},
+			expected: []string{"audit", "geoblock"},
+		},
+	}
+
+	for _, e := range containers {
+		dockerData := parseContainer(e.container)
+		actual := provider.getMiddlewares(dockerData)
+		if !reflect.DeepEqual(actual, e.expected) {
+			t.Fatalf("expected %q, got %q", e.expected, actual)
+		}
+	}
+}
+
 func TestDockerGetLabel(t *testing.T) {
 	containers := []struct {
 		container docker.ContainerJSON
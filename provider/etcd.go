@@ -2,6 +2,8 @@ package provider
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	"github.com/docker/libkv/store"
@@ -12,7 +14,8 @@ var _ Provider = (*Etcd)(nil)
 
 // Etcd holds configurations of the Etcd provider.
 type Etcd struct {
-	Kv `mapstructure:",squash"`
+	Kv       `mapstructure:",squash"`
+	UseAPIV3 bool `description:"Use the etcd v3 API, through the JSON gateway of the etcd servers"`
 }
 
 // Provide allows the provider to provide configurations to traefik
@@ -29,6 +32,13 @@ func (provider *Etcd) Provide(configurationChan chan<- types.ConfigMessage, pool
 // CreateStore creates the KV store
 func (provider *Etcd) CreateStore() (store.Store, error) {
 	provider.storeType = store.ETCD
+	if provider.UseAPIV3 {
+		storeConfig, err := provider.storeConfig()
+		if err != nil {
+			return nil, err
+		}
+		return newEtcdV3Store(strings.Split(provider.Endpoint, ","), storeConfig), nil
+	}
 	etcd.Register()
 	return provider.createStore()
 }
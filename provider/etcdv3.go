@@ -0,0 +1,790 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/docker/libkv/store"
+)
+
+const (
+	// defaultEtcdV3LockTTL is the TTL of the lease of a lock, when the lock
+	// options don't set one.
+	defaultEtcdV3LockTTL = 20 * time.Second
+	// etcdV3MinMinor and etcdV3MaxMinor are the range of the etcd 3 versions
+	// whose gateway is supported.
+	etcdV3MinMinor = 2
+	etcdV3MaxMinor = 5
+)
+
+// etcdV3Store is a libkv store of the etcd v3 API. It is not a gRPC client:
+// it talks to the JSON gateway of the etcd servers, which serves the v3 API
+// as JSON over HTTP. The watches are streams of the gateway, and the locks
+// and the TTLs are leases kept alive through it.
+type etcdV3Store struct {
+	endpoints []string
+	client    *http.Client
+	// streams have no timeout
+	streamClient *http.Client
+	lock         sync.Mutex
+	current      int
+	apiPrefix    string
+	// the leases of the keys written with a TTL, reused by their next writes
+	leases     map[string]etcdV3Lease
+	leasesLock sync.Mutex
+}
+
+var _ store.Store = (*etcdV3Store)(nil)
+
+type etcdV3Header struct {
+	Revision int64 `json:"revision,string"`
+}
+
+type etcdV3KeyValue struct {
+	Key            []byte `json:"key"`
+	Value          []byte `json:"value"`
+	CreateRevision int64  `json:"create_revision,string"`
+	ModRevision    int64  `json:"mod_revision,string"`
+}
+
+type etcdV3RangeRequest struct {
+	Key      []byte `json:"key"`
+	RangeEnd []byte `json:"range_end,omitempty"`
+}
+
+type etcdV3RangeResponse struct {
+	Header etcdV3Header     `json:"header"`
+	Kvs    []etcdV3KeyValue `json:"kvs"`
+}
+
+type etcdV3PutRequest struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value,omitempty"`
+	Lease int64  `json:"lease,omitempty,string"`
+}
+
+type etcdV3DeleteRangeResponse struct {
+	Header  etcdV3Header `json:"header"`
+	Deleted int64        `json:"deleted,string"`
+}
+
+type etcdV3Compare struct {
+	Target         string `json:"target"`
+	Key            []byte `json:"key"`
+	CreateRevision *int64 `json:"create_revision,omitempty,string"`
+	ModRevision    *int64 `json:"mod_revision,omitempty,string"`
+}
+
+type etcdV3RequestOp struct {
+	RequestPut         *etcdV3PutRequest   `json:"request_put,omitempty"`
+	RequestDeleteRange *etcdV3RangeRequest `json:"request_delete_range,omitempty"`
+}
+
+type etcdV3TxnRequest struct {
+	Compare []etcdV3Compare   `json:"compare"`
+	Success []etcdV3RequestOp `json:"success"`
+}
+
+type etcdV3TxnResponse struct {
+	Header    etcdV3Header `json:"header"`
+	Succeeded bool         `json:"succeeded"`
+}
+
+type etcdV3Lease struct {
+	ID  int64 `json:"ID,omitempty,string"`
+	TTL int64 `json:"TTL,omitempty,string"`
+}
+
+type etcdV3WatchRequest struct {
+	CreateRequest struct {
+		Key           []byte `json:"key"`
+		RangeEnd      []byte `json:"range_end,omitempty"`
+		StartRevision int64  `json:"start_revision,omitempty,string"`
+	} `json:"create_request"`
+}
+
+type etcdV3Event struct {
+	// PUT is the default type, omitted by the gateway
+	Type string         `json:"type"`
+	Kv   etcdV3KeyValue `json:"kv"`
+}
+
+type etcdV3WatchResponse struct {
+	Result struct {
+		Canceled bool          `json:"canceled"`
+		Events   []etcdV3Event `json:"events"`
+	} `json:"result"`
+}
+
+type etcdV3Error struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// newEtcdV3Store returns a store of the etcd v3 API of the servers at endpoints.
+func newEtcdV3Store(endpoints []string, config *store.Config) *etcdV3Store {
+	scheme := "http://"
+	if config.TLS != nil {
+		scheme = "https://"
+	}
+	transport := &http.Transport{
+		Dial: (&net.Dialer{
+			Timeout:   config.ConnectionTimeout,
+			KeepAlive: 30 * time.Second,
+		}).Dial,
+		TLSClientConfig:     config.TLS,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	s := &etcdV3Store{
+		client:       &http.Client{Transport: transport, Timeout: config.ConnectionTimeout},
+		streamClient: &http.Client{Transport: transport},
+		leases:       map[string]etcdV3Lease{},
+	}
+	for _, endpoint := range endpoints {
+		if !strings.Contains(endpoint, "://") {
+			endpoint = scheme + endpoint
+		}
+		s.endpoints = append(s.endpoints, strings.TrimSuffix(endpoint, "/"))
+	}
+	return s
+}
+
+// etcdV3Version returns the major and minor numbers of the etcd version, 0 if unknown.
+func etcdV3Version(version string) (int, int) {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0
+	}
+	major, _ := strconv.Atoi(parts[0])
+	minor, _ := strconv.Atoi(parts[1])
+	return major, minor
+}
+
+// etcdV3Supported returns whether the gateway of the etcd cluster of version is supported.
+func etcdV3Supported(version string) bool {
+	major, minor := etcdV3Version(version)
+	return major == 3 && minor >= etcdV3MinMinor && minor <= etcdV3MaxMinor
+}
+
+// etcdV3APIPrefix returns the path of the gateway of the etcd cluster of version.
+func etcdV3APIPrefix(version string) string {
+	major, minor := etcdV3Version(version)
+	switch {
+	case major == 3 && minor <= 2:
+		return "/v3alpha"
+	case major == 3 && minor == 3:
+		return "/v3beta"
+	}
+	return "/v3"
+}
+
+// discover sets the path of the gateway from the version of the etcd cluster
+// of endpoint, once.
+func (s *etcdV3Store) discover(endpoint string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.apiPrefix) > 0 {
+		return nil
+	}
+	response, err := s.client.Get(endpoint + "/version")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	var version struct {
+		Cluster string `json:"etcdcluster"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&version); err != nil {
+		return fmt.Errorf("invalid etcd version response: %v", err)
+	}
+	s.apiPrefix = etcdV3APIPrefix(version.Cluster)
+	log.Debugf("Using the etcd v3 API at %s of the etcd cluster %s", s.apiPrefix, version.Cluster)
+	if !etcdV3Supported(version.Cluster) {
+		log.Warnf("The JSON gateway of the etcd cluster %s is not supported, only those of etcd 3.%d to 3.%d are", version.Cluster, etcdV3MinMinor, etcdV3MaxMinor)
+	}
+	return nil
+}
+
+// post sends request to the path of the gateway, trying each endpoint until
+// one answers, and returns the response body.
+func (s *etcdV3Store) post(client *http.Client, path string, request interface{}) (io.ReadCloser, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	s.lock.Lock()
+	current := s.current
+	s.lock.Unlock()
+	for i := range s.endpoints {
+		endpoint := s.endpoints[(current+i)%len(s.endpoints)]
+		if err = s.discover(endpoint); err != nil {
+			continue
+		}
+		var response *http.Response
+		response, err = client.Post(endpoint+s.apiPrefix+path, "application/json", bytes.NewReader(data))
+		if err != nil {
+			continue
+		}
+		s.lock.Lock()
+		s.current = (current + i) % len(s.endpoints)
+		s.lock.Unlock()
+		if response.StatusCode != http.StatusOK {
+			defer response.Body.Close()
+			var gatewayError etcdV3Error
+			body, _ := ioutil.ReadAll(response.Body)
+			if json.Unmarshal(body, &gatewayError) == nil && len(gatewayError.Error+gatewayError.Message) > 0 {
+				return nil, fmt.Errorf("etcd %s: %s%s", path, gatewayError.Error, gatewayError.Message)
+			}
+			return nil, fmt.Errorf("etcd %s: unexpected status %d", path, response.StatusCode)
+		}
+		return response.Body, nil
+	}
+	return nil, err
+}
+
+// call sends request to the path of the gateway, and decodes its response to response.
+func (s *etcdV3Store) call(path string, request, response interface{}) error {
+	body, err := s.post(s.client, path, request)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return json.NewDecoder(body).Decode(response)
+}
+
+// etcdV3Key returns the etcd key of the libkv key, with the single leading
+// slash of the etcd v2 keys so that the keys migrated from v2 are found.
+func etcdV3Key(key string) []byte {
+	return []byte("/" + strings.Trim(key, "/"))
+}
+
+// etcdV3Directory returns the etcd key prefix of the keys of the libkv directory.
+func etcdV3Directory(directory string) []byte {
+	return []byte(strings.TrimSuffix("/"+strings.Trim(directory, "/"), "/") + "/")
+}
+
+// etcdV3RangeEnd returns the end of the range of the keys starting with prefix.
+func etcdV3RangeEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// the prefix is all 0xff: to the end of the keys
+	return []byte{0}
+}
+
+func etcdV3Pair(kv etcdV3KeyValue) *store.KVPair {
+	return &store.KVPair{Key: string(kv.Key), Value: kv.Value, LastIndex: uint64(kv.ModRevision)}
+}
+
+func (s *etcdV3Store) rangeKeys(key, rangeEnd []byte) (*etcdV3RangeResponse, error) {
+	response := &etcdV3RangeResponse{}
+	if err := s.call("/kv/range", etcdV3RangeRequest{Key: key, RangeEnd: rangeEnd}, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// etcdV3LeaseTTL returns the TTL in seconds of a lease of ttl.
+func etcdV3LeaseTTL(ttl time.Duration) int64 {
+	seconds := int64(ttl / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// grant returns a lease of ttl.
+func (s *etcdV3Store) grant(ttl time.Duration) (etcdV3Lease, error) {
+	var lease etcdV3Lease
+	if err := s.call("/lease/grant", etcdV3Lease{TTL: etcdV3LeaseTTL(ttl)}, &lease); err != nil {
+		return lease, err
+	}
+	if lease.ID == 0 {
+		return lease, errors.New("etcd granted no lease")
+	}
+	return lease, nil
+}
+
+// keepAlive renews lease, and returns its remaining TTL in seconds, 0 once expired.
+func (s *etcdV3Store) keepAlive(id int64) (int64, error) {
+	var response struct {
+		Result etcdV3Lease `json:"result"`
+	}
+	if err := s.call("/lease/keepalive", etcdV3Lease{ID: id}, &response); err != nil {
+		return 0, err
+	}
+	return response.Result.TTL, nil
+}
+
+// revoke revokes lease, deleting its keys.
+func (s *etcdV3Store) revoke(id int64) error {
+	s.lock.Lock()
+	apiPrefix := s.apiPrefix
+	s.lock.Unlock()
+	path := "/lease/revoke"
+	if apiPrefix != "/v3" {
+		// the gateways of etcd 3.3 and before serve it under /kv only
+		path = "/kv/lease/revoke"
+	}
+	var response struct{}
+	return s.call(path, etcdV3Lease{ID: id}, &response)
+}
+
+// keyLease returns the lease of a write of key expiring after ttl: the lease
+// of the previous write of key renewed, if it has the same TTL, or a new one.
+func (s *etcdV3Store) keyLease(key []byte, ttl time.Duration) (etcdV3Lease, error) {
+	seconds := etcdV3LeaseTTL(ttl)
+	s.leasesLock.Lock()
+	lease, ok := s.leases[string(key)]
+	s.leasesLock.Unlock()
+	if ok && lease.TTL == seconds {
+		if remaining, err := s.keepAlive(lease.ID); err == nil && remaining > 0 {
+			return lease, nil
+		}
+	}
+	lease, err := s.grant(ttl)
+	lease.TTL = seconds
+	return lease, err
+}
+
+// setKeyLease records the lease of key once written, 0 without TTL, and
+// revokes the previous lease of key, which no longer holds any key.
+func (s *etcdV3Store) setKeyLease(key []byte, lease etcdV3Lease) {
+	s.leasesLock.Lock()
+	previous, ok := s.leases[string(key)]
+	if lease.ID == 0 {
+		delete(s.leases, string(key))
+	} else {
+		s.leases[string(key)] = lease
+	}
+	s.leasesLock.Unlock()
+	if ok && previous.ID != lease.ID {
+		if err := s.revoke(previous.ID); err != nil {
+			log.Debugf("Error revoking the etcd lease of %s: %v", key, err)
+		}
+	}
+}
+
+// watch returns the events of the keys from key to rangeEnd, from revision,
+// until stopCh. The channel is closed when the watch ends.
+func (s *etcdV3Store) watch(key, rangeEnd []byte, revision int64, stopCh <-chan struct{}) (<-chan []etcdV3Event, error) {
+	var request etcdV3WatchRequest
+	request.CreateRequest.Key = key
+	request.CreateRequest.RangeEnd = rangeEnd
+	request.CreateRequest.StartRevision = revision
+	body, err := s.post(s.streamClient, "/watch", request)
+	if err != nil {
+		return nil, err
+	}
+	eventsCh := make(chan []etcdV3Event)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-stopCh:
+		case <-done:
+		}
+		body.Close()
+	}()
+	go func() {
+		defer close(eventsCh)
+		defer close(done)
+		decoder := json.NewDecoder(body)
+		for {
+			var response etcdV3WatchResponse
+			if err := decoder.Decode(&response); err != nil {
+				log.Debugf("etcd watch of %s ended: %v", key, err)
+				return
+			}
+			if response.Result.Canceled {
+				log.Debugf("etcd watch of %s canceled", key)
+				return
+			}
+			if len(response.Result.Events) == 0 {
+				continue
+			}
+			select {
+			case eventsCh <- response.Result.Events:
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return eventsCh, nil
+}
+
+// Get returns the value of key.
+func (s *etcdV3Store) Get(key string) (*store.KVPair, error) {
+	response, err := s.rangeKeys(etcdV3Key(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Kvs) == 0 {
+		return nil, store.ErrKeyNotFound
+	}
+	return etcdV3Pair(response.Kvs[0]), nil
+}
+
+// Put sets the value of key, expiring after the TTL of options if any.
+func (s *etcdV3Store) Put(key string, value []byte, options *store.WriteOptions) error {
+	request := etcdV3PutRequest{Key: etcdV3Key(key), Value: value}
+	var lease etcdV3Lease
+	if options != nil && options.TTL > 0 {
+		var err error
+		if lease, err = s.keyLease(request.Key, options.TTL); err != nil {
+			return err
+		}
+		request.Lease = lease.ID
+	}
+	var response struct{}
+	if err := s.call("/kv/put", request, &response); err != nil {
+		return err
+	}
+	s.setKeyLease(request.Key, lease)
+	return nil
+}
+
+// Delete deletes key.
+func (s *etcdV3Store) Delete(key string) error {
+	var response etcdV3DeleteRangeResponse
+	if err := s.call("/kv/deleterange", etcdV3RangeRequest{Key: etcdV3Key(key)}, &response); err != nil {
+		return err
+	}
+	s.setKeyLease(etcdV3Key(key), etcdV3Lease{})
+	if response.Deleted == 0 {
+		return store.ErrKeyNotFound
+	}
+	return nil
+}
+
+// Exists returns whether key exists.
+func (s *etcdV3Store) Exists(key string) (bool, error) {
+	_, err := s.Get(key)
+	if err == store.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List returns the keys under directory, at any depth. The flat keyspace of
+// the v3 API has no directory: a key without children is listed empty, like
+// an etcd v2 file.
+func (s *etcdV3Store) List(directory string) ([]*store.KVPair, error) {
+	pairs, _, err := s.list(directory)
+	return pairs, err
+}
+
+func (s *etcdV3Store) list(directory string) ([]*store.KVPair, int64, error) {
+	prefix := etcdV3Directory(directory)
+	response, err := s.rangeKeys(prefix, etcdV3RangeEnd(prefix))
+	if err != nil {
+		return nil, 0, err
+	}
+	pairs := []*store.KVPair{}
+	for _, kv := range response.Kvs {
+		pairs = append(pairs, etcdV3Pair(kv))
+	}
+	if len(pairs) == 0 {
+		if _, err := s.Get(directory); err != nil {
+			return nil, response.Header.Revision, err
+		}
+	}
+	return pairs, response.Header.Revision, nil
+}
+
+// DeleteTree deletes the keys under directory.
+func (s *etcdV3Store) DeleteTree(directory string) error {
+	prefix := etcdV3Directory(directory)
+	var response etcdV3DeleteRangeResponse
+	return s.call("/kv/deleterange", etcdV3RangeRequest{Key: prefix, RangeEnd: etcdV3RangeEnd(prefix)}, &response)
+}
+
+// Watch sends the value of key, and then its values as it changes, until stopCh.
+func (s *etcdV3Store) Watch(key string, stopCh <-chan struct{}) (<-chan *store.KVPair, error) {
+	response, err := s.rangeKeys(etcdV3Key(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Kvs) == 0 {
+		return nil, store.ErrKeyNotFound
+	}
+	events, err := s.watch(etcdV3Key(key), nil, response.Header.Revision+1, stopCh)
+	if err != nil {
+		return nil, err
+	}
+	watchCh := make(chan *store.KVPair)
+	go func() {
+		defer close(watchCh)
+		pairs := []*store.KVPair{etcdV3Pair(response.Kvs[0])}
+		for {
+			for _, pair := range pairs {
+				select {
+				case watchCh <- pair:
+				case <-stopCh:
+					return
+				}
+			}
+			batch, ok := <-events
+			if !ok {
+				return
+			}
+			pairs = pairs[:0]
+			for _, event := range batch {
+				pairs = append(pairs, etcdV3Pair(event.Kv))
+			}
+		}
+	}()
+	return watchCh, nil
+}
+
+// WatchTree sends the keys under directory, and then again each time they
+// change, until stopCh.
+func (s *etcdV3Store) WatchTree(directory string, stopCh <-chan struct{}) (<-chan []*store.KVPair, error) {
+	pairs, revision, err := s.list(directory)
+	if err != nil && err != store.ErrKeyNotFound {
+		return nil, err
+	}
+	prefix := etcdV3Directory(directory)
+	events, err := s.watch(prefix, etcdV3RangeEnd(prefix), revision+1, stopCh)
+	if err != nil {
+		return nil, err
+	}
+	watchCh := make(chan []*store.KVPair)
+	go func() {
+		defer close(watchCh)
+		for {
+			select {
+			case watchCh <- pairs:
+			case <-stopCh:
+				return
+			}
+			if _, ok := <-events; !ok {
+				return
+			}
+			var err error
+			if pairs, _, err = s.list(directory); err != nil && err != store.ErrKeyNotFound {
+				log.Errorf("Error listing the etcd keys of %s: %v", directory, err)
+				return
+			}
+		}
+	}()
+	return watchCh, nil
+}
+
+// txn puts request if compare holds, and returns whether it did and the revision of the store.
+func (s *etcdV3Store) txn(compare etcdV3Compare, request etcdV3RequestOp) (bool, int64, error) {
+	var response etcdV3TxnResponse
+	err := s.call("/kv/txn", etcdV3TxnRequest{Compare: []etcdV3Compare{compare}, Success: []etcdV3RequestOp{request}}, &response)
+	return response.Succeeded, response.Header.Revision, err
+}
+
+// AtomicPut sets the value of key if it is still at previous, or creates it
+// if previous is nil.
+func (s *etcdV3Store) AtomicPut(key string, value []byte, previous *store.KVPair, options *store.WriteOptions) (bool, *store.KVPair, error) {
+	request := &etcdV3PutRequest{Key: etcdV3Key(key), Value: value}
+	var lease etcdV3Lease
+	if options != nil && options.TTL > 0 {
+		var err error
+		if lease, err = s.keyLease(request.Key, options.TTL); err != nil {
+			return false, nil, err
+		}
+		request.Lease = lease.ID
+	}
+	compare := etcdV3Compare{Key: request.Key}
+	if previous == nil {
+		var absent int64
+		compare.Target, compare.CreateRevision = "CREATE", &absent
+	} else {
+		revision := int64(previous.LastIndex)
+		compare.Target, compare.ModRevision = "MOD", &revision
+	}
+	succeeded, revision, err := s.txn(compare, etcdV3RequestOp{RequestPut: request})
+	if err != nil {
+		return false, nil, err
+	}
+	if !succeeded {
+		if previous == nil {
+			return false, nil, store.ErrKeyExists
+		}
+		return false, nil, store.ErrKeyModified
+	}
+	s.setKeyLease(request.Key, lease)
+	return true, &store.KVPair{Key: string(request.Key), Value: value, LastIndex: uint64(revision)}, nil
+}
+
+// AtomicDelete deletes key if it is still at previous.
+func (s *etcdV3Store) AtomicDelete(key string, previous *store.KVPair) (bool, error) {
+	if previous == nil {
+		return false, store.ErrPreviousNotSpecified
+	}
+	revision := int64(previous.LastIndex)
+	compare := etcdV3Compare{Target: "MOD", Key: etcdV3Key(key), ModRevision: &revision}
+	succeeded, _, err := s.txn(compare, etcdV3RequestOp{RequestDeleteRange: &etcdV3RangeRequest{Key: compare.Key}})
+	if err != nil {
+		return false, err
+	}
+	if !succeeded {
+		return false, store.ErrKeyModified
+	}
+	s.setKeyLease(compare.Key, etcdV3Lease{})
+	return true, nil
+}
+
+// NewLock returns a lock of key, held with a lease.
+func (s *etcdV3Store) NewLock(key string, options *store.LockOptions) (store.Locker, error) {
+	lock := &etcdV3Lock{store: s, key: etcdV3Key(key), ttl: defaultEtcdV3LockTTL}
+	if options != nil {
+		lock.value = options.Value
+		lock.renewCh = options.RenewLock
+		if options.TTL > 0 {
+			lock.ttl = options.TTL
+		}
+	}
+	return lock, nil
+}
+
+// Close closes the idle connections of the store.
+func (s *etcdV3Store) Close() {
+	if transport, ok := s.client.Transport.(*http.Transport); ok {
+		transport.CloseIdleConnections()
+	}
+}
+
+// etcdV3Lock is a lock of the etcd v3 API: the lock key is created with a
+// lease by its holder, who keeps the lease alive until Unlock.
+type etcdV3Lock struct {
+	store    *etcdV3Store
+	key      []byte
+	value    []byte
+	ttl      time.Duration
+	renewCh  chan struct{}
+	lock     sync.Mutex
+	lease    int64
+	unlockCh chan struct{}
+}
+
+// Lock waits until the lock is acquired, and returns a channel closed when it
+// is lost. It returns nil if stopChan is closed or sent to first.
+func (l *etcdV3Lock) Lock(stopChan chan struct{}) (<-chan struct{}, error) {
+	lease, err := l.store.grant(l.ttl)
+	if err != nil {
+		return nil, err
+	}
+	var absent int64
+	compare := etcdV3Compare{Target: "CREATE", Key: l.key, CreateRevision: &absent}
+	request := etcdV3RequestOp{RequestPut: &etcdV3PutRequest{Key: l.key, Value: l.value, Lease: lease.ID}}
+	for {
+		succeeded, revision, err := l.store.txn(compare, request)
+		if err != nil {
+			l.store.revoke(lease.ID)
+			return nil, err
+		}
+		if succeeded {
+			break
+		}
+		stopped, err := l.waitRelease(revision, stopChan)
+		if err != nil || stopped {
+			l.store.revoke(lease.ID)
+			return nil, err
+		}
+	}
+
+	l.lock.Lock()
+	l.lease = lease.ID
+	l.unlockCh = make(chan struct{})
+	unlockCh := l.unlockCh
+	l.lock.Unlock()
+	lostCh := make(chan struct{})
+	go l.hold(lease, unlockCh, lostCh)
+	return lostCh, nil
+}
+
+// waitRelease waits for the deletion of the lock key after revision, and
+// returns whether stopChan stopped the wait.
+func (l *etcdV3Lock) waitRelease(revision int64, stopChan chan struct{}) (bool, error) {
+	stop := make(chan struct{})
+	defer close(stop)
+	events, err := l.store.watch(l.key, nil, revision+1, stop)
+	if err != nil {
+		return false, err
+	}
+	for {
+		select {
+		case <-stopChan:
+			return true, nil
+		case batch, ok := <-events:
+			if !ok {
+				// the watch ended, the lock is tried again
+				return false, nil
+			}
+			for _, event := range batch {
+				if event.Type == "DELETE" {
+					return false, nil
+				}
+			}
+		}
+	}
+}
+
+// hold keeps lease alive until Unlock or the end of the renewals, and closes
+// lostCh when it stops or the lease expires.
+func (l *etcdV3Lock) hold(lease etcdV3Lease, unlockCh, lostCh chan struct{}) {
+	defer close(lostCh)
+	ttl := time.Duration(lease.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = l.ttl
+	}
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-unlockCh:
+			return
+		case <-l.renewCh:
+			return
+		case <-ticker.C:
+			remaining, err := l.store.keepAlive(lease.ID)
+			if err == nil && remaining > 0 {
+				renewed = time.Now()
+				continue
+			}
+			if err == nil {
+				log.Warnf("Lost the etcd lock %s: its lease expired", l.key)
+				return
+			}
+			if time.Since(renewed) >= ttl {
+				log.Warnf("Lost the etcd lock %s: %v", l.key, err)
+				return
+			}
+			log.Debugf("Error renewing the etcd lock %s: %v", l.key, err)
+		}
+	}
+}
+
+// Unlock releases the lock, revoking its lease.
+func (l *etcdV3Lock) Unlock() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.lease == 0 {
+		return nil
+	}
+	close(l.unlockCh)
+	lease := l.lease
+	l.lease = 0
+	return l.store.revoke(lease)
+}
@@ -0,0 +1,391 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/libkv/store"
+)
+
+// fakeEtcdV3 is an in-memory etcd server serving the v3 API through the
+// paths of its gateway.
+type fakeEtcdV3 struct {
+	lock     sync.Mutex
+	revision int64
+	leases   int64
+	kvs      map[string]etcdV3KeyValue
+	watchers map[chan etcdV3Event][2]string
+}
+
+func newFakeEtcdV3() *httptest.Server {
+	etcd := &fakeEtcdV3{kvs: map[string]etcdV3KeyValue{}, watchers: map[chan etcdV3Event][2]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version", func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"etcdserver":"3.3.1","etcdcluster":"3.3.0"}`))
+	})
+	mux.HandleFunc("/v3beta/kv/range", func(rw http.ResponseWriter, r *http.Request) {
+		var request etcdV3RangeRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		etcd.lock.Lock()
+		defer etcd.lock.Unlock()
+		response := etcdV3RangeResponse{Header: etcdV3Header{Revision: etcd.revision}}
+		for _, key := range etcd.keys(request.Key, request.RangeEnd) {
+			response.Kvs = append(response.Kvs, etcd.kvs[key])
+		}
+		json.NewEncoder(rw).Encode(response)
+	})
+	mux.HandleFunc("/v3beta/kv/put", func(rw http.ResponseWriter, r *http.Request) {
+		var request etcdV3PutRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		etcd.lock.Lock()
+		defer etcd.lock.Unlock()
+		etcd.put(request)
+		json.NewEncoder(rw).Encode(etcdV3TxnResponse{Header: etcdV3Header{Revision: etcd.revision}})
+	})
+	mux.HandleFunc("/v3beta/kv/deleterange", func(rw http.ResponseWriter, r *http.Request) {
+		var request etcdV3RangeRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		etcd.lock.Lock()
+		defer etcd.lock.Unlock()
+		deleted := etcd.deleteRange(request)
+		json.NewEncoder(rw).Encode(etcdV3DeleteRangeResponse{Header: etcdV3Header{Revision: etcd.revision}, Deleted: deleted})
+	})
+	mux.HandleFunc("/v3beta/kv/txn", func(rw http.ResponseWriter, r *http.Request) {
+		var request etcdV3TxnRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		etcd.lock.Lock()
+		defer etcd.lock.Unlock()
+		compare := request.Compare[0]
+		current := etcd.kvs[string(compare.Key)]
+		succeeded := (compare.Target == "CREATE" && current.CreateRevision == *compare.CreateRevision) ||
+			(compare.Target == "MOD" && current.ModRevision == *compare.ModRevision)
+		if succeeded {
+			if put := request.Success[0].RequestPut; put != nil {
+				etcd.put(*put)
+			} else {
+				etcd.deleteRange(*request.Success[0].RequestDeleteRange)
+			}
+		}
+		json.NewEncoder(rw).Encode(etcdV3TxnResponse{Header: etcdV3Header{Revision: etcd.revision}, Succeeded: succeeded})
+	})
+	mux.HandleFunc("/v3beta/lease/grant", func(rw http.ResponseWriter, r *http.Request) {
+		var request etcdV3Lease
+		json.NewDecoder(r.Body).Decode(&request)
+		etcd.lock.Lock()
+		defer etcd.lock.Unlock()
+		etcd.leases++
+		json.NewEncoder(rw).Encode(etcdV3Lease{ID: etcd.leases, TTL: request.TTL})
+	})
+	mux.HandleFunc("/v3beta/lease/keepalive", func(rw http.ResponseWriter, r *http.Request) {
+		var request etcdV3Lease
+		json.NewDecoder(r.Body).Decode(&request)
+		json.NewEncoder(rw).Encode(map[string]etcdV3Lease{"result": {ID: request.ID, TTL: 1}})
+	})
+	mux.HandleFunc("/v3beta/kv/lease/revoke", func(rw http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID int64 `json:"ID,string"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		etcd.lock.Lock()
+		defer etcd.lock.Unlock()
+		for key, kv := range etcd.kvs {
+			if kv.CreateRevision < 0 && -kv.CreateRevision == request.ID {
+				etcd.deleteRange(etcdV3RangeRequest{Key: []byte(key)})
+			}
+		}
+		rw.Write([]byte("{}"))
+	})
+	mux.HandleFunc("/v3beta/watch", func(rw http.ResponseWriter, r *http.Request) {
+		var request etcdV3WatchRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		events := make(chan etcdV3Event, 10)
+		etcd.lock.Lock()
+		etcd.watchers[events] = [2]string{string(request.CreateRequest.Key), string(request.CreateRequest.RangeEnd)}
+		etcd.lock.Unlock()
+		defer func() {
+			etcd.lock.Lock()
+			delete(etcd.watchers, events)
+			etcd.lock.Unlock()
+		}()
+		rw.Write([]byte(`{"result":{"created":true}}` + "\n"))
+		rw.(http.Flusher).Flush()
+		for {
+			select {
+			case <-rw.(http.CloseNotifier).CloseNotify():
+				return
+			case event := <-events:
+				var response etcdV3WatchResponse
+				response.Result.Events = []etcdV3Event{event}
+				json.NewEncoder(rw).Encode(response)
+				rw.(http.Flusher).Flush()
+			}
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func (etcd *fakeEtcdV3) keys(key, rangeEnd []byte) []string {
+	var keys []string
+	for k := range etcd.kvs {
+		if k == string(key) || (len(rangeEnd) > 0 && k >= string(key) && k < string(rangeEnd)) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// put stores the lease of the key as a negative create revision, which the
+// keys of the client don't read.
+func (etcd *fakeEtcdV3) put(request etcdV3PutRequest) {
+	etcd.revision++
+	kv := etcdV3KeyValue{Key: request.Key, Value: request.Value, CreateRevision: etcd.revision, ModRevision: etcd.revision}
+	if request.Lease > 0 {
+		kv.CreateRevision = -request.Lease
+	}
+	etcd.kvs[string(request.Key)] = kv
+	etcd.notify(etcdV3Event{Kv: kv})
+}
+
+func (etcd *fakeEtcdV3) deleteRange(request etcdV3RangeRequest) int64 {
+	keys := etcd.keys(request.Key, request.RangeEnd)
+	if len(keys) > 0 {
+		etcd.revision++
+	}
+	for _, key := range keys {
+		delete(etcd.kvs, key)
+		etcd.notify(etcdV3Event{Type: "DELETE", Kv: etcdV3KeyValue{Key: []byte(key), ModRevision: etcd.revision}})
+	}
+	return int64(len(keys))
+}
+
+func (etcd *fakeEtcdV3) notify(event etcdV3Event) {
+	for events, keyRange := range etcd.watchers {
+		key := string(event.Kv.Key)
+		if key == keyRange[0] || (len(keyRange[1]) > 0 && key >= keyRange[0] && key < keyRange[1]) {
+			events <- event
+		}
+	}
+}
+
+func TestEtcdV3StoreKeys(t *testing.T) {
+	server := newFakeEtcdV3()
+	defer server.Close()
+	kv := newEtcdV3Store([]string{"127.0.0.1:1", server.URL}, &store.Config{ConnectionTimeout: time.Second})
+
+	if err := kv.Put("traefik/backends/backend1/servers/server1/url", []byte("http://172.17.0.2:80"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := kv.Put("traefik/backends/backend1/servers/server1/weight", []byte("10"), nil); err != nil {
+		t.Fatal(err)
+	}
+	pair, err := kv.Get("traefik/backends/backend1/servers/server1/url")
+	if err != nil || string(pair.Value) != "http://172.17.0.2:80" {
+		t.Fatalf("expected the url of server1, got %v %v", pair, err)
+	}
+	if pair.Key != "/traefik/backends/backend1/servers/server1/url" {
+		t.Fatalf("expected the key with a leading slash, got %s", pair.Key)
+	}
+	if _, err := kv.Get("traefik/backends/backend2"); err != store.ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	pairs, err := kv.List("/traefik/backends/")
+	if err != nil || len(pairs) != 2 {
+		t.Fatalf("expected the 2 keys of the backends, got %v %v", pairs, err)
+	}
+	pairs, err = kv.List("traefik/backends/backend1/servers/server1/url")
+	if err != nil || len(pairs) != 0 {
+		t.Fatalf("expected a key without children, got %v %v", pairs, err)
+	}
+	if _, err := kv.List("traefik/frontends"); err != store.ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	if ok, _, err := kv.AtomicPut("traefik/lock", []byte("a"), nil, nil); !ok || err != nil {
+		t.Fatalf("expected the creation of the key, got %v", err)
+	}
+	if _, _, err := kv.AtomicPut("traefik/lock", []byte("b"), nil, nil); err != store.ErrKeyExists {
+		t.Fatalf("expected ErrKeyExists, got %v", err)
+	}
+	previous, _ := kv.Get("traefik/lock")
+	ok, pair, err := kv.AtomicPut("traefik/lock", []byte("b"), previous, nil)
+	if !ok || err != nil {
+		t.Fatalf("expected the update of the key, got %v", err)
+	}
+	if _, _, err := kv.AtomicPut("traefik/lock", []byte("c"), previous, nil); err != store.ErrKeyModified {
+		t.Fatalf("expected ErrKeyModified, got %v", err)
+	}
+	if _, err := kv.AtomicDelete("traefik/lock", previous); err != store.ErrKeyModified {
+		t.Fatalf("expected ErrKeyModified, got %v", err)
+	}
+	if ok, err := kv.AtomicDelete("traefik/lock", pair); !ok || err != nil {
+		t.Fatalf("expected the deletion of the key, got %v", err)
+	}
+
+	if err := kv.DeleteTree("traefik/backends"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := kv.Exists("traefik/backends/backend1/servers/server1/url"); exists || err != nil {
+		t.Fatalf("expected the deletion of the tree, got %v", err)
+	}
+}
+
+func TestEtcdV3StoreWatchTree(t *testing.T) {
+	server := newFakeEtcdV3()
+	defer server.Close()
+	kv := newEtcdV3Store([]string{server.URL}, &store.Config{ConnectionTimeout: time.Second})
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	events, err := kv.WatchTree("traefik", stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receive := func() []*store.KVPair {
+		select {
+		case pairs := <-events:
+			return pairs
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected a change of the tree")
+		}
+		return nil
+	}
+	if pairs := receive(); len(pairs) != 0 {
+		t.Fatalf("expected an empty tree, got %v", pairs)
+	}
+	kv.Put("other/key", []byte("value"), nil)
+	kv.Put("traefik/frontends/frontend1/backend", []byte("backend1"), nil)
+	pairs := receive()
+	if len(pairs) != 1 || !strings.HasSuffix(pairs[0].Key, "/frontend1/backend") {
+		t.Fatalf("expected the key of frontend1, got %v", pairs)
+	}
+}
+
+func TestEtcdV3StoreLock(t *testing.T) {
+	server := newFakeEtcdV3()
+	defer server.Close()
+	kv := newEtcdV3Store([]string{server.URL}, &store.Config{ConnectionTimeout: time.Second})
+
+	first, _ := kv.NewLock("traefik/leader", &store.LockOptions{Value: []byte("node1"), TTL: 3 * time.Second})
+	lostCh, err := first.Lock(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := kv.Get("traefik/leader")
+	if err != nil || string(pair.Value) != "node1" {
+		t.Fatalf("expected the lock of node1, got %v %v", pair, err)
+	}
+
+	second, _ := kv.NewLock("traefik/leader", &store.LockOptions{Value: []byte("node2"), TTL: 3 * time.Second})
+	stopCh := make(chan struct{})
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		stopCh <- struct{}{}
+	}()
+	if lostCh, err := second.Lock(stopCh); lostCh != nil || err != nil {
+		t.Fatalf("expected the wait for the lock to stop, got %v", err)
+	}
+
+	acquired := make(chan error)
+	go func() {
+		_, err := second.Lock(nil)
+		acquired <- err
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("expected the lock to be held by node1")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := first.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-lostCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the release of the lock")
+	}
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the lock of node2")
+	}
+	pair, _ = kv.Get("traefik/leader")
+	if !bytes.Equal(pair.Value, []byte("node2")) {
+		t.Fatalf("expected the lock of node2, got %s", pair.Value)
+	}
+	second.Unlock()
+}
+
+func TestEtcdV3StoreLeases(t *testing.T) {
+	server := newFakeEtcdV3()
+	defer server.Close()
+	kv := newEtcdV3Store([]string{server.URL}, &store.Config{ConnectionTimeout: time.Second})
+
+	key := "traefik/cluster/node1"
+	if err := kv.Put(key, []byte("a"), &store.WriteOptions{TTL: 10 * time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	first := kv.leases[string(etcdV3Key(key))]
+	if err := kv.Put(key, []byte("b"), &store.WriteOptions{TTL: 10 * time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if lease := kv.leases[string(etcdV3Key(key))]; lease.ID != first.ID {
+		t.Fatalf("expected the lease %d to be reused, got %d", first.ID, lease.ID)
+	}
+
+	previous, _ := kv.Get(key)
+	if ok, _, err := kv.AtomicPut(key, []byte("c"), previous, &store.WriteOptions{TTL: 20 * time.Second}); !ok || err != nil {
+		t.Fatalf("expected the update of the key, got %v", err)
+	}
+	if lease := kv.leases[string(etcdV3Key(key))]; lease.ID == first.ID || lease.TTL != 20 {
+		t.Fatalf("expected a new lease of 20s, got %v", lease)
+	}
+	if pair, err := kv.Get(key); err != nil || string(pair.Value) != "c" {
+		t.Fatalf("expected the key to outlive the revocation of its previous lease, got %v %v", pair, err)
+	}
+
+	if err := kv.Put(key, []byte("d"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := kv.leases[string(etcdV3Key(key))]; ok {
+		t.Fatal("expected the lease to be forgotten once the key is written without TTL")
+	}
+	if pair, err := kv.Get(key); err != nil || string(pair.Value) != "d" {
+		t.Fatalf("expected the key without TTL, got %v %v", pair, err)
+	}
+}
+
+func TestEtcdV3Versions(t *testing.T) {
+	cases := []struct {
+		version   string
+		prefix    string
+		supported bool
+	}{
+		{"3.1.0", "/v3alpha", false},
+		{"3.2.0", "/v3alpha", true},
+		{"3.3.0", "/v3beta", true},
+		{"3.4.0", "/v3", true},
+		{"3.5.0", "/v3", true},
+		{"3.6.0", "/v3", false},
+		{"not applicable", "/v3", false},
+	}
+	for _, c := range cases {
+		if prefix := etcdV3APIPrefix(c.version); prefix != c.prefix {
+			t.Errorf("%s: expected the gateway at %s, got %s", c.version, c.prefix, prefix)
+		}
+		if supported := etcdV3Supported(c.version); supported != c.supported {
+			t.Errorf("%s: expected supported %v, got %v", c.version, c.supported, supported)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package provider
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -17,11 +19,15 @@ var _ Provider = (*File)(nil)
 // File holds configurations of the File provider.
 type File struct {
 	BaseProvider `mapstructure:",squash"`
+	Directory    string `description:"Load the configuration from all the *.toml files of a directory, instead of a single file"`
 }
 
 // Provide allows the provider to provide configurations to traefik
 // using the given configuration channel.
 func (provider *File) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, constraints types.Constraints) error {
+	if len(provider.Directory) > 0 {
+		return provider.provideDirectory(configurationChan, pool)
+	}
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		log.Error("Error creating file watcher", err)
@@ -74,6 +80,56 @@ func (provider *File) Provide(configurationChan chan<- types.ConfigMessage, pool
 	return nil
 }
 
+// provideDirectory provides the configuration merged from the files of the
+// directory, and again each time a file is added, changed or removed.
+func (provider *File) provideDirectory(configurationChan chan<- types.ConfigMessage, pool *safe.Pool) error {
+	if _, err := ioutil.ReadDir(provider.Directory); err != nil {
+		log.Error("Error reading directory", err)
+		return err
+	}
+	if provider.Watch {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			log.Error("Error creating file watcher", err)
+			return err
+		}
+		if err := watcher.Add(provider.Directory); err != nil {
+			watcher.Close()
+			log.Error("Error adding directory watcher", err)
+			return err
+		}
+		pool.Go(func(stop chan bool) {
+			defer watcher.Close()
+			for {
+				select {
+				case <-stop:
+					return
+				case event := <-watcher.Events:
+					if filepath.Ext(event.Name) == ".toml" {
+						log.Debug("Directory event:", event)
+						configuration := provider.loadDirectoryConfig(provider.Directory)
+						if configuration != nil {
+							configurationChan <- types.ConfigMessage{
+								ProviderName:  "file",
+								Configuration: configuration,
+							}
+						}
+					}
+				case error := <-watcher.Errors:
+					log.Error("Watcher event error", error)
+				}
+			}
+		})
+	}
+
+	configuration := provider.loadDirectoryConfig(provider.Directory)
+	configurationChan <- types.ConfigMessage{
+		ProviderName:  "file",
+		Configuration: configuration,
+	}
+	return nil
+}
+
 func (provider *File) loadFileConfig(filename string) *types.Configuration {
 	configuration := new(types.Configuration)
 	if _, err := toml.DecodeFile(filename, configuration); err != nil {
@@ -82,3 +138,45 @@ func (provider *File) loadFileConfig(filename string) *types.Configuration {
 	}
 	return configuration
 }
+
+// loadDirectoryConfig merges the backends and the frontends of the *.toml
+// files of directory, in lexical order. A backend or a frontend defined by
+// several files is kept from the first one. It returns nil if a file can't
+// be read, so that the configuration stays the same until it is fixed.
+func (provider *File) loadDirectoryConfig(directory string) *types.Configuration {
+	files, err := filepath.Glob(filepath.Join(directory, "*.toml"))
+	if err != nil {
+		log.Error("Error listing directory:", err)
+		return nil
+	}
+	sort.Strings(files)
+	configuration := &types.Configuration{
+		Backends:  map[string]*types.Backend{},
+		Frontends: map[string]*types.Frontend{},
+	}
+	backendFiles := map[string]string{}
+	frontendFiles := map[string]string{}
+	for _, file := range files {
+		fragment := provider.loadFileConfig(file)
+		if fragment == nil {
+			return nil
+		}
+		for name, backend := range fragment.Backends {
+			if previous, ok := backendFiles[name]; ok {
+				log.Errorf("Backend %s of %s already defined in %s, ignoring it", name, file, previous)
+				continue
+			}
+			backendFiles[name] = file
+			configuration.Backends[name] = backend
+		}
+		for name, frontend := range fragment.Frontends {
+			if previous, ok := frontendFiles[name]; ok {
+				log.Errorf("Frontend %s of %s already defined in %s, ignoring it", name, file, previous)
+				continue
+			}
+			frontendFiles[name] = file
+			configuration.Frontends[name] = frontend
+		}
+	}
+	return configuration
+}
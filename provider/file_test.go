@@ -1 +1,110 @@
 package provider
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+)
+
+func TestFileLoadDirectoryConfig(t *testing.T) {
+	directory, err := ioutil.TempDir("", "traefik-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	fragments := map[string]string{
+		"service1.toml": `
+[backends.backend1.servers.server1]
+url = "http://172.17.0.2:80"
+[frontends.frontend1]
+backend = "backend1"
+`,
+		"service2.toml": `
+[backends.backend2.servers.server1]
+url = "http://172.17.0.3:80"
+[backends.backend1.servers.server1]
+url = "http://172.17.0.4:80"
+`,
+		"notes.txt": `not a configuration`,
+	}
+	for name, content := range fragments {
+		if err := ioutil.WriteFile(filepath.Join(directory, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	provider := &File{Directory: directory}
+	configuration := provider.loadDirectoryConfig(directory)
+	if configuration == nil {
+		t.Fatal("expected a configuration")
+	}
+	if len(configuration.Backends) != 2 || len(configuration.Frontends) != 1 {
+		t.Fatalf("expected 2 backends and 1 frontend, got %+v", configuration)
+	}
+	// the first file defining a backend wins
+	if url := configuration.Backends["backend1"].Servers["server1"].URL; url != "http://172.17.0.2:80" {
+		t.Fatalf("expected the backend1 of service1.toml, got %s", url)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(directory, "broken.toml"), []byte("[backends"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if configuration := provider.loadDirectoryConfig(directory); configuration != nil {
+		t.Fatalf("expected no configuration with an invalid file, got %+v", configuration)
+	}
+}
+
+func TestFileProvideDirectory(t *testing.T) {
+	directory, err := ioutil.TempDir("", "traefik-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	provider := &File{Directory: directory}
+	provider.Watch = true
+	configurationChan := make(chan types.ConfigMessage, 10)
+	pool := safe.NewPool(context.Background())
+	defer pool.Stop()
+	if err := provider.Provide(configurationChan, pool, nil); err != nil {
+		t.Fatal(err)
+	}
+	receive := func() *types.Configuration {
+		select {
+		case message := <-configurationChan:
+			return message.Configuration
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected a configuration")
+		}
+		return nil
+	}
+	if configuration := receive(); len(configuration.Backends) != 0 {
+		t.Fatalf("expected an empty configuration, got %+v", configuration)
+	}
+
+	service := filepath.Join(directory, "service1.toml")
+	if err := ioutil.WriteFile(service, []byte("[backends.backend1.servers.server1]\nurl = \"http://172.17.0.2:80\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configuration := receive()
+	for len(configuration.Backends) == 0 {
+		// the creation may be seen before the content
+		configuration = receive()
+	}
+	if configuration.Backends["backend1"] == nil {
+		t.Fatalf("expected backend1, got %+v", configuration)
+	}
+
+	if err := os.Remove(service); err != nil {
+		t.Fatal(err)
+	}
+	for len(configuration.Backends) != 0 {
+		configuration = receive()
+	}
+}
@@ -1,6 +1,8 @@
 package k8s
 
 import (
+	"crypto/tls"
+	"net/http"
 	"time"
 
 	"k8s.io/client-go/1.5/kubernetes"
@@ -74,6 +76,30 @@ func NewInClusterClientWithEndpoint(endpoint string) (Client, error) {
 	}, nil
 }
 
+// NewClusterClient returns a new Kubernetes client of the API server at
+// endpoint, authenticated by bearerToken if set, with tlsConfig if set
+func NewClusterClient(endpoint, bearerToken string, tlsConfig *tls.Config) (Client, error) {
+	config := &rest.Config{
+		Host:        endpoint,
+		BearerToken: bearerToken,
+	}
+	if tlsConfig != nil {
+		config.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		}
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &clientImpl{
+		clientset: clientset,
+	}, nil
+}
+
 // GetIngresses returns all ingresses in the cluster
 func (c *clientImpl) GetIngresses(namespaces Namespaces) []*v1beta1.Ingress {
 	ingList := c.ingStore.List()
@@ -1,6 +1,11 @@
 package provider
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
 	"reflect"
 	"strconv"
 	"strings"
@@ -24,13 +29,55 @@ var _ Provider = (*Kubernetes)(nil)
 // Kubernetes holds configurations of the Kubernetes provider.
 type Kubernetes struct {
 	BaseProvider           `mapstructure:",squash"`
-	Endpoint               string         `description:"Kubernetes server endpoint"`
-	DisablePassHostHeaders bool           `description:"Kubernetes disable PassHost Headers"`
-	Namespaces             k8s.Namespaces `description:"Kubernetes namespaces"`
-	LabelSelector          string         `description:"Kubernetes api label selector to use"`
+	Endpoint               string              `description:"Kubernetes server endpoint"`
+	DisablePassHostHeaders bool                `description:"Kubernetes disable PassHost Headers"`
+	Namespaces             k8s.Namespaces      `description:"Kubernetes namespaces"`
+	LabelSelector          string              `description:"Kubernetes api label selector to use"`
+	Clusters               []KubernetesCluster `description:"Kubernetes clusters to watch, instead of the cluster traefik runs in"`
 	lastConfiguration      safe.Safe
 }
 
+// KubernetesCluster is a Kubernetes cluster whose ingresses are merged with
+// the ingresses of the other clusters.
+type KubernetesCluster struct {
+	Name      string     `description:"Name of the cluster"`
+	Endpoint  string     `description:"Kubernetes API server endpoint"`
+	TokenFile string     `description:"File of the bearer token authenticating to the API server"`
+	TLS       *ClientTLS `description:"Enable TLS support"`
+	Weight    int        `description:"Weight of the servers of the cluster"`
+	Priority  int        `description:"Failover order of the cluster: the backends only get the servers of their clusters of the lowest priority"`
+}
+
+// KubernetesClusters parses []KubernetesCluster
+type KubernetesClusters []KubernetesCluster
+
+// Set adds a cluster from str, "name,endpoint"
+func (c *KubernetesClusters) Set(str string) error {
+	slice := strings.SplitN(str, ",", 2)
+	if len(slice) != 2 || len(slice[0]) == 0 || len(slice[1]) == 0 {
+		return fmt.Errorf("invalid kubernetes cluster %q, expected name,endpoint", str)
+	}
+	*c = append(*c, KubernetesCluster{Name: slice[0], Endpoint: slice[1]})
+	return nil
+}
+
+// Get []KubernetesCluster
+func (c *KubernetesClusters) Get() interface{} { return []KubernetesCluster(*c) }
+
+// String returns []KubernetesCluster in string
+func (c *KubernetesClusters) String() string { return fmt.Sprintf("%+v", *c) }
+
+// SetValue sets []KubernetesCluster into the parser
+func (c *KubernetesClusters) SetValue(val interface{}) {
+	*c = KubernetesClusters(val.([]KubernetesCluster))
+}
+
+// kubernetesClusterClient is the client of a cluster watched by the provider.
+type kubernetesClusterClient struct {
+	cluster KubernetesCluster
+	client  k8s.Client
+}
+
 func (provider *Kubernetes) newK8sClient() (k8s.Client, error) {
 	if provider.Endpoint != "" {
 		log.Infof("Creating in cluster Kubernetes client with endpoint %", provider.Endpoint)
@@ -40,10 +87,52 @@ func (provider *Kubernetes) newK8sClient() (k8s.Client, error) {
 	return k8s.NewInClusterClient()
 }
 
+func (cluster KubernetesCluster) newK8sClient() (k8s.Client, error) {
+	bearerToken := ""
+	if len(cluster.TokenFile) > 0 {
+		token, err := ioutil.ReadFile(cluster.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		bearerToken = strings.TrimSpace(string(token))
+	}
+	var tlsConfig *tls.Config
+	if cluster.TLS != nil {
+		var err error
+		tlsConfig, err = cluster.TLS.CreateTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+	log.Infof("Creating Kubernetes client of cluster %s with endpoint %s", cluster.Name, cluster.Endpoint)
+	return k8s.NewClusterClient(cluster.Endpoint, bearerToken, tlsConfig)
+}
+
+// newK8sClients returns the clients of the clusters, or of the cluster traefik
+// runs in without clusters.
+func (provider *Kubernetes) newK8sClients() ([]kubernetesClusterClient, error) {
+	if len(provider.Clusters) == 0 {
+		k8sClient, err := provider.newK8sClient()
+		if err != nil {
+			return nil, err
+		}
+		return []kubernetesClusterClient{{client: k8sClient}}, nil
+	}
+	clients := []kubernetesClusterClient{}
+	for _, cluster := range provider.Clusters {
+		k8sClient, err := cluster.newK8sClient()
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %v", cluster.Name, err)
+		}
+		clients = append(clients, kubernetesClusterClient{cluster: cluster, client: k8sClient})
+	}
+	return clients, nil
+}
+
 // Provide allows the provider to provide configurations to traefik
 // using the given configuration channel.
 func (provider *Kubernetes) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, constraints types.Constraints) error {
-	k8sClient, err := provider.newK8sClient()
+	k8sClients, err := provider.newK8sClients()
 	if err != nil {
 		return err
 	}
@@ -55,7 +144,7 @@ func (provider *Kubernetes) Provide(configurationChan chan<- types.ConfigMessage
 				stopWatch := make(chan bool, 5)
 				defer close(stopWatch)
 				log.Debugf("Using label selector: '%s'", provider.LabelSelector)
-				eventsChan, err := k8sClient.WatchAll(provider.LabelSelector, stopWatch)
+				eventsChan, err := provider.watchClusters(k8sClients, stopWatch)
 				if err != nil {
 					log.Errorf("Error watching kubernetes events: %v", err)
 					timer := time.NewTimer(1 * time.Second)
@@ -73,7 +162,7 @@ func (provider *Kubernetes) Provide(configurationChan chan<- types.ConfigMessage
 						return nil
 					case event := <-eventsChan:
 						log.Debugf("Received event from kubernetes %+v", event)
-						templateObjects, err := provider.loadIngresses(k8sClient)
+						templateObjects, err := provider.loadClusters(k8sClients)
 						if err != nil {
 							return err
 						}
@@ -103,6 +192,83 @@ func (provider *Kubernetes) Provide(configurationChan chan<- types.ConfigMessage
 	return nil
 }
 
+// watchClusters merges the events of the clusters.
+func (provider *Kubernetes) watchClusters(k8sClients []kubernetesClusterClient, stopWatch chan bool) (chan interface{}, error) {
+	if len(k8sClients) == 1 {
+		return k8sClients[0].client.WatchAll(provider.LabelSelector, stopWatch)
+	}
+	eventsChan := make(chan interface{}, 100)
+	for _, k8sClient := range k8sClients {
+		clusterEventsChan, err := k8sClient.client.WatchAll(provider.LabelSelector, stopWatch)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %v", k8sClient.cluster.Name, err)
+		}
+		go func(clusterEventsChan chan interface{}) {
+			for event := range clusterEventsChan {
+				select {
+				case eventsChan <- event:
+				case <-stopWatch:
+					return
+				}
+			}
+		}(clusterEventsChan)
+	}
+	return eventsChan, nil
+}
+
+// loadClusters merges the ingresses of the clusters. The servers of a backend
+// are the servers of its clusters of the lowest priority, prefixed with the
+// name of their cluster, their weight multiplied by the weight of the cluster,
+// so that a backend fails over to the clusters of the next priority once the
+// clusters of the lowest one have no servers for it.
+func (provider *Kubernetes) loadClusters(k8sClients []kubernetesClusterClient) (*types.Configuration, error) {
+	if len(provider.Clusters) == 0 {
+		return provider.loadIngresses(k8sClients[0].client)
+	}
+	templateObjects := types.Configuration{
+		map[string]*types.Backend{},
+		map[string]*types.Frontend{},
+	}
+	priorities := make(map[string]int)
+	for _, k8sClient := range k8sClients {
+		cluster := k8sClient.cluster
+		clusterObjects, err := provider.loadIngresses(k8sClient.client)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %v", cluster.Name, err)
+		}
+		for frontendName, frontend := range clusterObjects.Frontends {
+			if _, exists := templateObjects.Frontends[frontendName]; !exists {
+				templateObjects.Frontends[frontendName] = frontend
+			}
+		}
+		weight := cluster.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		for backendName, backend := range clusterObjects.Backends {
+			merged, exists := templateObjects.Backends[backendName]
+			if !exists {
+				merged = &types.Backend{Servers: make(map[string]types.Server)}
+				templateObjects.Backends[backendName] = merged
+			}
+			if len(backend.Servers) == 0 {
+				continue
+			}
+			if priority, hasServers := priorities[backendName]; !hasServers || cluster.Priority < priority {
+				merged.Servers = make(map[string]types.Server)
+				priorities[backendName] = cluster.Priority
+			} else if cluster.Priority > priority {
+				continue
+			}
+			for serverName, server := range backend.Servers {
+				server.Weight *= weight
+				merged.Servers[cluster.Name+"-"+serverName] = server
+			}
+		}
+	}
+	return &templateObjects, nil
+}
+
 func (provider *Kubernetes) loadIngresses(k8sClient k8s.Client) (*types.Configuration, error) {
 	ingresses := k8sClient.GetIngresses(provider.Namespaces)
 
@@ -125,6 +291,7 @@ func (provider *Kubernetes) loadIngresses(k8sClient k8s.Client) (*types.Configur
 						PassHostHeader: PassHostHeader,
 						Routes:         make(map[string]types.Route),
 						Priority:       len(pa.Path),
+						Middlewares:    getMiddlewaresAnnotation(i.Annotations),
 					}
 				}
 				if len(r.Host) > 0 {
@@ -164,6 +331,19 @@ func (provider *Kubernetes) loadIngresses(k8sClient k8s.Client) (*types.Configur
 					continue
 				}
 
+				if service.Spec.Type == v1.ServiceTypeExternalName {
+					server, err := externalNameServer(service, pa.Backend.ServicePort)
+					if err != nil {
+						log.Warnf("Error proxying to the external name of service %s/%s: %v", service.ObjectMeta.Namespace, service.ObjectMeta.Name, err)
+						delete(templateObjects.Frontends, r.Host+pa.Path)
+						continue
+					}
+					// the external service is reached by its own name
+					templateObjects.Frontends[r.Host+pa.Path].PassHostHeader = false
+					templateObjects.Backends[r.Host+pa.Path].Servers[service.Spec.ExternalName] = server
+					continue
+				}
+
 				protocol := "http"
 				for _, port := range service.Spec.Ports {
 					if equalPorts(port, pa.Backend.ServicePort) {
@@ -219,6 +399,60 @@ func endpointPortNumber(servicePort v1.ServicePort, endpointPorts []v1.EndpointP
 	return int(servicePort.Port)
 }
 
+// externalNameServer returns the server of an ExternalName service, proxying
+// to its external DNS name. The port is the port of the service the ingress
+// port refers to, or the ingress port itself when the service declares no
+// such port. The protocol is set by the traefik.backend.protocol annotation
+// of the service, https for the port 443 by default.
+func externalNameServer(service *v1.Service, ingressPort intstr.IntOrString) (types.Server, error) {
+	if len(service.Spec.ExternalName) == 0 {
+		return types.Server{}, errors.New("no external name")
+	}
+	port := 0
+	for _, servicePort := range service.Spec.Ports {
+		if equalPorts(servicePort, ingressPort) {
+			port = int(servicePort.Port)
+			break
+		}
+	}
+	if port == 0 {
+		if ingressPort.Type != intstr.Int || ingressPort.IntValue() <= 0 {
+			return types.Server{}, fmt.Errorf("no port %s", ingressPort.String())
+		}
+		port = ingressPort.IntValue()
+	}
+	protocol := "http"
+	if port == 443 {
+		protocol = "https"
+	}
+	switch annotation := strings.ToLower(service.Annotations["traefik.backend.protocol"]); annotation {
+	case "":
+	case "http", "https":
+		protocol = annotation
+	default:
+		return types.Server{}, fmt.Errorf("unknown protocol %s", annotation)
+	}
+	return types.Server{
+		URL:    protocol + "://" + net.JoinHostPort(service.Spec.ExternalName, strconv.Itoa(port)),
+		Weight: 1,
+	}, nil
+}
+
+// getMiddlewaresAnnotation returns the registered middlewares listed by the
+// traefik.frontend.middlewares annotation of an ingress.
+func getMiddlewaresAnnotation(annotations map[string]string) []string {
+	middlewares := []string{}
+	for _, middleware := range strings.Split(annotations["traefik.frontend.middlewares"], ",") {
+		if middleware = strings.TrimSpace(middleware); len(middleware) > 0 {
+			middlewares = append(middlewares, middleware)
+		}
+	}
+	if len(middlewares) == 0 {
+		return nil
+	}
+	return middlewares
+}
+
 func equalPorts(servicePort v1.ServicePort, ingressPort intstr.IntOrString) bool {
 	if int(servicePort.Port) == ingressPort.IntValue() {
 		return true
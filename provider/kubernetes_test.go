@@ -1259,6 +1259,255 @@ func TestHostlessIngress(t *testing.T) {
 	}
 }
 
+func TestExternalNameService(t *testing.T) {
+	ingresses := []*v1beta1.Ingress{{
+		ObjectMeta: v1.ObjectMeta{
+			Namespace: "testing",
+		},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{
+				{
+					Host: "foo",
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: []v1beta1.HTTPIngressPath{
+								{
+									Path: "/api",
+									Backend: v1beta1.IngressBackend{
+										ServiceName: "service1",
+										ServicePort: intstr.FromString("https"),
+									},
+								},
+								{
+									Path: "/static",
+									Backend: v1beta1.IngressBackend{
+										ServiceName: "service2",
+										ServicePort: intstr.FromInt(8443),
+									},
+								},
+								{
+									Path: "/unknown",
+									Backend: v1beta1.IngressBackend{
+										ServiceName: "service2",
+										ServicePort: intstr.FromString("http"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+	services := []*v1.Service{
+		{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "service1",
+				Namespace: "testing",
+				UID:       "1",
+			},
+			Spec: v1.ServiceSpec{
+				Type:         v1.ServiceTypeExternalName,
+				ExternalName: "api.example.com",
+				Ports: []v1.ServicePort{
+					{
+						Name: "https",
+						Port: 443,
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "service2",
+				Namespace: "testing",
+				UID:       "2",
+				Annotations: map[string]string{
+					"traefik.backend.protocol": "https",
+				},
+			},
+			Spec: v1.ServiceSpec{
+				Type:         v1.ServiceTypeExternalName,
+				ExternalName: "static.example.com",
+			},
+		},
+	}
+	watchChan := make(chan interface{})
+	client := clientMock{
+		ingresses: ingresses,
+		services:  services,
+		watchChan: watchChan,
+	}
+	provider := Kubernetes{}
+	actual, err := provider.loadIngresses(client)
+	if err != nil {
+		t.Fatalf("error %+v", err)
+	}
+
+	expected := &types.Configuration{
+		Backends: map[string]*types.Backend{
+			"foo/api": {
+				Servers: map[string]types.Server{
+					"api.example.com": {
+						URL:    "https://api.example.com:443",
+						Weight: 1,
+					},
+				},
+			},
+			"foo/static": {
+				Servers: map[string]types.Server{
+					"static.example.com": {
+						URL:    "https://static.example.com:8443",
+						Weight: 1,
+					},
+				},
+			},
+			"foo/unknown": {
+				Servers: map[string]types.Server{},
+			},
+		},
+		Frontends: map[string]*types.Frontend{
+			"foo/api": {
+				Backend:  "foo/api",
+				Priority: len("/api"),
+				Routes: map[string]types.Route{
+					"/api": {
+						Rule: "PathPrefix:/api",
+					},
+					"foo": {
+						Rule: "Host:foo",
+					},
+				},
+			},
+			"foo/static": {
+				Backend:  "foo/static",
+				Priority: len("/static"),
+				Routes: map[string]types.Route{
+					"/static": {
+						Rule: "PathPrefix:/static",
+					},
+					"foo": {
+						Rule: "Host:foo",
+					},
+				},
+			},
+		},
+	}
+	actualJSON, _ := json.Marshal(actual)
+	expectedJSON, _ := json.Marshal(expected)
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %+v, got %+v", string(expectedJSON), string(actualJSON))
+	}
+}
+
+func TestLoadClusters(t *testing.T) {
+	ingresses := []*v1beta1.Ingress{{
+		ObjectMeta: v1.ObjectMeta{
+			Namespace: "testing",
+		},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{
+				{
+					Host: "foo",
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: []v1beta1.HTTPIngressPath{
+								{
+									Path: "/bar",
+									Backend: v1beta1.IngressBackend{
+										ServiceName: "service1",
+										ServicePort: intstr.FromInt(80),
+									},
+								},
+								{
+									Path: "/baz",
+									Backend: v1beta1.IngressBackend{
+										ServiceName: "service2",
+										ServicePort: intstr.FromInt(80),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+	service := func(objectMeta v1.ObjectMeta, clusterIP string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: objectMeta,
+			Spec: v1.ServiceSpec{
+				ClusterIP: clusterIP,
+				Ports: []v1.ServicePort{
+					{
+						Port: 80,
+					},
+				},
+			},
+		}
+	}
+	clusterClient := func(cluster KubernetesCluster, services ...*v1.Service) kubernetesClusterClient {
+		return kubernetesClusterClient{
+			cluster: cluster,
+			client: clientMock{
+				ingresses: ingresses,
+				services:  services,
+			},
+		}
+	}
+	provider := Kubernetes{
+		Clusters: []KubernetesCluster{
+			{Name: "east", Weight: 2},
+			{Name: "central"},
+			{Name: "west", Priority: 1},
+		},
+	}
+	k8sClients := []kubernetesClusterClient{
+		clusterClient(provider.Clusters[0], service(v1.ObjectMeta{Name: "service1", Namespace: "testing", UID: "service1"}, "10.0.0.1")),
+		clusterClient(provider.Clusters[1], service(v1.ObjectMeta{Name: "service1", Namespace: "testing", UID: "service1"}, "10.1.0.1")),
+		clusterClient(provider.Clusters[2],
+			service(v1.ObjectMeta{Name: "service1", Namespace: "testing", UID: "service1"}, "10.2.0.1"),
+			service(v1.ObjectMeta{Name: "service2", Namespace: "testing", UID: "service2"}, "10.2.0.2"),
+		),
+	}
+	actual, err := provider.loadClusters(k8sClients)
+	if err != nil {
+		t.Fatalf("error %+v", err)
+	}
+
+	expectedBackends := map[string]*types.Backend{
+		"foo/bar": {
+			Servers: map[string]types.Server{
+				"east-service1": {
+					URL:    "http://10.0.0.1:80",
+					Weight: 2,
+				},
+				"central-service1": {
+					URL:    "http://10.1.0.1:80",
+					Weight: 1,
+				},
+			},
+		},
+		"foo/baz": {
+			Servers: map[string]types.Server{
+				"west-service2": {
+					URL:    "http://10.2.0.2:80",
+					Weight: 1,
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(actual.Backends, expectedBackends) {
+		actualJSON, _ := json.Marshal(actual.Backends)
+		expectedJSON, _ := json.Marshal(expectedBackends)
+		t.Fatalf("expected %+v, got %+v", string(expectedJSON), string(actualJSON))
+	}
+	if len(actual.Frontends) != 2 || actual.Frontends["foo/bar"] == nil || actual.Frontends["foo/baz"] == nil {
+		t.Fatalf("expected the frontends foo/bar and foo/baz, got %+v", actual.Frontends)
+	}
+}
+
 type clientMock struct {
 	ingresses []*v1beta1.Ingress
 	services  []*v1.Service
@@ -29,6 +29,18 @@ type Kv struct {
 }
 
 func (provider *Kv) createStore() (store.Store, error) {
+	storeConfig, err := provider.storeConfig()
+	if err != nil {
+		return nil, err
+	}
+	return libkv.NewStore(
+		provider.storeType,
+		strings.Split(provider.Endpoint, ","),
+		storeConfig,
+	)
+}
+
+func (provider *Kv) storeConfig() (*store.Config, error) {
 	storeConfig := &store.Config{
 		ConnectionTimeout: 30 * time.Second,
 		Bucket:            "traefik",
@@ -41,11 +53,7 @@ func (provider *Kv) createStore() (store.Store, error) {
 			return nil, err
 		}
 	}
-	return libkv.NewStore(
-		provider.storeType,
-		strings.Split(provider.Endpoint, ","),
-		storeConfig,
-	)
+	return storeConfig, nil
 }
 
 func (provider *Kv) watchKv(configurationChan chan<- types.ConfigMessage, prefix string, stop chan bool) error {
@@ -115,6 +123,10 @@ func (provider *Kv) provide(configurationChan chan<- types.ConfigMessage, pool *
 }
 
 func (provider *Kv) loadConfig() *types.Configuration {
+	if err := provider.checkSchemaVersion(); err != nil {
+		log.Error(err)
+		return nil
+	}
 	templateObjects := struct {
 		Prefix string
 	}{
@@ -292,7 +292,17 @@ type Mock struct {
 }
 
 func (s *Mock) Put(key string, value []byte, opts *store.WriteOptions) error {
-	return errors.New("Put not supported")
+	if s.Error {
+		return errors.New("Error")
+	}
+	for _, kvPair := range s.KVPairs {
+		if kvPair.Key == key {
+			kvPair.Value = value
+			return nil
+		}
+	}
+	s.KVPairs = append(s.KVPairs, &store.KVPair{Key: key, Value: value})
+	return nil
 }
 
 func (s *Mock) Get(key string) (*store.KVPair, error) {
@@ -308,7 +318,16 @@ func (s *Mock) Get(key string) (*store.KVPair, error) {
 }
 
 func (s *Mock) Delete(key string) error {
-	return errors.New("Delete not supported")
+	if s.Error {
+		return errors.New("Error")
+	}
+	for i, kvPair := range s.KVPairs {
+		if kvPair.Key == key {
+			s.KVPairs = append(s.KVPairs[:i], s.KVPairs[i+1:]...)
+			return nil
+		}
+	}
+	return store.ErrKeyNotFound
 }
 
 // Exists mock
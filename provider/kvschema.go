@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	"github.com/docker/libkv/store"
+)
+
+// kvSchemaVersionKey is the key, under the prefix of a KV store, of the
+// version of the layout of its keys. A store without it has the version 0.
+const kvSchemaVersionKey = "/schemaversion"
+
+// kvMigration upgrades the keys of a KV store from the version of its index
+// in kvMigrations to the next one, and returns the keys changed.
+type kvMigration struct {
+	description string
+	migrate     func(provider *Kv) ([]string, error)
+}
+
+// kvMigrations are the upgrades of the layout of the keys, in order. A
+// migration must leave the keys already in the layout it upgrades to
+// untouched, so that it can be run again after a partial upgrade.
+var kvMigrations = []kvMigration{
+	{
+		description: "Merge the value of the routes into their rule",
+		migrate:     migrateKvRouteValues,
+	},
+}
+
+// KvSchemaVersion is the version of the layout of the keys read by the KV providers.
+var KvSchemaVersion = len(kvMigrations)
+
+// KvMigration is the result of the migration of a KV store.
+type KvMigration struct {
+	Prefix string   `json:"prefix"`
+	From   int      `json:"from"`
+	To     int      `json:"to"`
+	Keys   []string `json:"keys"`
+}
+
+// MigrateKvStore upgrades the keys under prefix in kvStore to KvSchemaVersion.
+// The version of the store is stored after each migration, so that a failed
+// migration resumes from where it stopped.
+func MigrateKvStore(kvStore store.Store, prefix string) (*KvMigration, error) {
+	provider := &Kv{Prefix: prefix, kvclient: kvStore}
+	version, err := provider.schemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if version > KvSchemaVersion {
+		return nil, fmt.Errorf("KV store %s has the schema version %d, newer than %d", prefix, version, KvSchemaVersion)
+	}
+	migration := &KvMigration{Prefix: prefix, From: version, To: KvSchemaVersion, Keys: []string{}}
+	for ; version < KvSchemaVersion; version++ {
+		log.Infof("Migrating KV store %s to the schema version %d: %s", prefix, version+1, kvMigrations[version].description)
+		keys, err := kvMigrations[version].migrate(provider)
+		if err != nil {
+			return migration, fmt.Errorf("Error migrating KV store %s to the schema version %d: %v", prefix, version+1, err)
+		}
+		migration.Keys = append(migration.Keys, keys...)
+		if err := provider.put(strconv.Itoa(version+1), prefix, kvSchemaVersionKey); err != nil {
+			return migration, fmt.Errorf("Error storing the schema version %d of KV store %s: %v", version+1, prefix, err)
+		}
+	}
+	return migration, nil
+}
+
+// schemaVersion returns the version of the layout of the keys of the store.
+func (provider *Kv) schemaVersion() (int, error) {
+	value := provider.get("0", provider.Prefix, kvSchemaVersionKey)
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("Invalid schema version %q in KV store %s", value, provider.Prefix)
+	}
+	return version, nil
+}
+
+// checkSchemaVersion returns an error when the keys of the store have a layout
+// newer than the one read, and warns when they have an older one.
+func (provider *Kv) checkSchemaVersion() error {
+	version, err := provider.schemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > KvSchemaVersion {
+		return fmt.Errorf("KV store %s has the schema version %d, newer than %d: upgrade traefik", provider.Prefix, version, KvSchemaVersion)
+	}
+	if version < KvSchemaVersion {
+		log.Warnf("KV store %s has the schema version %d, older than %d: run traefik migrateconfig", provider.Prefix, version, KvSchemaVersion)
+	}
+	return nil
+}
+
+func (provider *Kv) put(value string, keys ...string) error {
+	return provider.kvclient.Put(strings.TrimPrefix(strings.Join(keys, ""), "/"), []byte(value), nil)
+}
+
+func (provider *Kv) delete(keys ...string) error {
+	return provider.kvclient.Delete(strings.TrimPrefix(strings.Join(keys, ""), "/"))
+}
+
+// migrateKvRouteValues merges the value key of the routes, from the layout
+// where the rule only held the matcher, into their rule:
+// routes/<route>/rule "Host" and routes/<route>/value "test.localhost"
+// become routes/<route>/rule "Host:test.localhost".
+func migrateKvRouteValues(provider *Kv) ([]string, error) {
+	keys := []string{}
+	prefix := strings.TrimSuffix(provider.get(provider.Prefix, provider.Prefix+"/alias"), "/")
+	for _, frontend := range provider.list(prefix, "/frontends/") {
+		for _, route := range provider.list(frontend, "/routes/") {
+			value := provider.get("", route, "/value")
+			rule := provider.get("", route, "/rule")
+			if len(value) == 0 || len(rule) == 0 {
+				continue
+			}
+			// the rule is already merged if a previous migration failed to delete the value
+			if !strings.HasSuffix(rule, ":"+value) {
+				if err := provider.put(rule+":"+value, route, "/rule"); err != nil {
+					return keys, err
+				}
+				keys = append(keys, route+"/rule")
+			}
+			if err := provider.delete(route, "/value"); err != nil {
+				return keys, err
+			}
+			keys = append(keys, route+"/value")
+		}
+	}
+	return keys, nil
+}
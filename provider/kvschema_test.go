@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/docker/libkv/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateKvStore(t *testing.T) {
+	kvStore := &Mock{
+		KVPairs: []*store.KVPair{
+			{Key: "traefik/frontends/frontend1", Value: []byte("")},
+			{Key: "traefik/frontends/frontend1/routes", Value: []byte("")},
+			{Key: "traefik/frontends/frontend1/routes/test_1", Value: []byte("")},
+			{Key: "traefik/frontends/frontend1/routes/test_1/rule", Value: []byte("Host")},
+			{Key: "traefik/frontends/frontend1/routes/test_1/value", Value: []byte("test.localhost")},
+			{Key: "traefik/frontends/frontend2", Value: []byte("")},
+			{Key: "traefik/frontends/frontend2/routes", Value: []byte("")},
+			{Key: "traefik/frontends/frontend2/routes/test_2", Value: []byte("")},
+			{Key: "traefik/frontends/frontend2/routes/test_2/rule", Value: []byte("PathPrefix:/test")},
+		},
+	}
+
+	migration, err := MigrateKvStore(kvStore, "traefik")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, migration.From)
+	assert.Equal(t, KvSchemaVersion, migration.To)
+	assert.Equal(t, []string{"traefik/frontends/frontend1/routes/test_1/rule", "traefik/frontends/frontend1/routes/test_1/value"}, migration.Keys)
+
+	provider := &Kv{Prefix: "traefik", kvclient: kvStore}
+	assert.Equal(t, "Host:test.localhost", provider.get("", "traefik/frontends/frontend1/routes/test_1/rule"))
+	assert.Equal(t, "", provider.get("", "traefik/frontends/frontend1/routes/test_1/value"))
+	assert.Equal(t, "PathPrefix:/test", provider.get("", "traefik/frontends/frontend2/routes/test_2/rule"))
+	assert.Equal(t, strconv.Itoa(KvSchemaVersion), provider.get("", "traefik/schemaversion"))
+
+	migration, err = MigrateKvStore(kvStore, "traefik")
+	assert.NoError(t, err)
+	assert.Equal(t, KvSchemaVersion, migration.From)
+	assert.Empty(t, migration.Keys, "the store is already migrated")
+}
+
+func TestMigrateKvStoreNewerVersion(t *testing.T) {
+	kvStore := &Mock{
+		KVPairs: []*store.KVPair{
+			{Key: "traefik/schemaversion", Value: []byte(strconv.Itoa(KvSchemaVersion + 1))},
+		},
+	}
+	_, err := MigrateKvStore(kvStore, "traefik")
+	assert.Error(t, err)
+
+	provider := &Kv{Prefix: "traefik", kvclient: kvStore}
+	assert.Nil(t, provider.loadConfig(), "the keys of a newer layout aren't read")
+}
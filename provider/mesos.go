@@ -1,7 +1,9 @@
 package provider
 
 import (
+	"encoding/json"
 	"errors"
+	"net/http"
 	"strconv"
 	"strings"
 	"text/template"
@@ -37,9 +39,17 @@ type Mesos struct {
 	RefreshSeconds     int    `description:"Polling interval (in seconds)"`
 	IPSources          string `description:"IPSources (e.g. host, docker, mesos, rkt)"` // e.g. "host", "docker", "mesos", "rkt"
 	StateTimeoutSecond int    `description:"HTTP Timeout (in seconds)"`
+	AgentAttributes    string `description:"Attributes of the agents whose tasks are exposed (e.g. rack:r1,rack:r2,zone:eu-west)"`
 	Masters            []string
 }
 
+// mesosAgent is an agent listed by the /master/slaves endpoint of the masters.
+type mesosAgent struct {
+	ID         string                 `json:"id"`
+	Hostname   string                 `json:"hostname"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
 // Provide allows the provider to provide configurations to traefik
 // using the given configuration channel.
 func (provider *Mesos) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, constraints types.Constraints) error {
@@ -146,6 +156,15 @@ func (provider *Mesos) loadMesosConfig() *types.Configuration {
 	}
 	tasks := provider.taskRecords(sj)
 
+	if len(provider.AgentAttributes) > 0 {
+		agents, err := provider.getAgents()
+		if err != nil {
+			log.Errorf("Failed to get the agents of mesos, error: %s", err)
+			return nil
+		}
+		tasks = filterMesosAgentTasks(tasks, agents, parseMesosAgentAttributes(provider.AgentAttributes))
+	}
+
 	//filter tasks
 	filteredTasks := fun.Filter(func(task state.Task) bool {
 		return mesosTaskFilter(task, provider.ExposedByDefault)
@@ -195,8 +214,58 @@ func labels(task state.Task, key string) string {
 	return ""
 }
 
+// mesosPortMapping returns the ports the task is reached on, set by its
+// traefik.portMapping label: discovery (default), host or container.
+func mesosPortMapping(task state.Task) string {
+	if portMapping := strings.ToLower(labels(task, "traefik.portMapping")); len(portMapping) > 0 {
+		return portMapping
+	}
+	return "discovery"
+}
+
+// mesosTaskPorts returns the ports of the task of its port mapping: the host
+// ports allocated in its resources with host, its discovery ports otherwise.
+func mesosTaskPorts(task state.Task) []int {
+	if mesosPortMapping(task) == "host" {
+		return mesosHostPorts(task.Resources.PortRanges)
+	}
+	ports := []int{}
+	for _, port := range task.DiscoveryInfo.Ports.DiscoveryPorts {
+		ports = append(ports, port.Number)
+	}
+	return ports
+}
+
+// mesosHostPorts returns the ports of the port ranges of the resources of a
+// task, like "[31000-31001, 31005-31005]".
+func mesosHostPorts(portRanges string) []int {
+	ports := []int{}
+	for _, portRange := range strings.Split(strings.Trim(portRanges, "[]"), ",") {
+		bounds := strings.SplitN(strings.TrimSpace(portRange), "-", 2)
+		if len(bounds) != 2 {
+			continue
+		}
+		begin, errBegin := strconv.Atoi(bounds[0])
+		end, errEnd := strconv.Atoi(bounds[1])
+		if errBegin != nil || errEnd != nil {
+			continue
+		}
+		for port := begin; port <= end; port++ {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
 func mesosTaskFilter(task state.Task, exposedByDefaultFlag bool) bool {
-	if len(task.DiscoveryInfo.Ports.DiscoveryPorts) == 0 {
+	switch mesosPortMapping(task) {
+	case "discovery", "host", "container":
+	default:
+		log.Debugf("Filtering mesos task %s with unexpected value for traefik.portMapping label", task.Name)
+		return false
+	}
+	ports := mesosTaskPorts(task)
+	if len(ports) == 0 {
 		log.Debugf("Filtering mesos task without port %s", task.Name)
 		return false
 	}
@@ -214,7 +283,7 @@ func mesosTaskFilter(task state.Task, exposedByDefaultFlag bool) bool {
 	}
 	if portIndexLabel != "" {
 		index, err := strconv.Atoi(labels(task, "traefik.portIndex"))
-		if err != nil || index < 0 || index > len(task.DiscoveryInfo.Ports.DiscoveryPorts)-1 {
+		if err != nil || index < 0 || index > len(ports)-1 {
 			log.Debugf("Filtering mesos task %s with unexpected value for traefik.portIndex label", task.Name)
 			return false
 		}
@@ -227,8 +296,8 @@ func mesosTaskFilter(task state.Task, exposedByDefaultFlag bool) bool {
 		}
 
 		var foundPort bool
-		for _, exposedPort := range task.DiscoveryInfo.Ports.DiscoveryPorts {
-			if port == exposedPort.Number {
+		for _, exposedPort := range ports {
+			if port == exposedPort {
 				foundPort = true
 				break
 			}
@@ -278,17 +347,18 @@ func (provider *Mesos) getPort(task state.Task, applications []state.Task) strin
 		return ""
 	}
 
+	ports := mesosTaskPorts(task)
 	if portIndexLabel, err := provider.getLabel(application, "traefik.portIndex"); err == nil {
-		if index, err := strconv.Atoi(portIndexLabel); err == nil {
-			return strconv.Itoa(task.DiscoveryInfo.Ports.DiscoveryPorts[index].Number)
+		if index, err := strconv.Atoi(portIndexLabel); err == nil && index >= 0 && index < len(ports) {
+			return strconv.Itoa(ports[index])
 		}
 	}
 	if portValueLabel, err := provider.getLabel(application, "traefik.port"); err == nil {
 		return portValueLabel
 	}
 
-	for _, port := range task.DiscoveryInfo.Ports.DiscoveryPorts {
-		return strconv.Itoa(port.Number)
+	for _, port := range ports {
+		return strconv.Itoa(port)
 	}
 	return ""
 }
@@ -371,7 +441,16 @@ func (provider *Mesos) getFrontendBackend(task state.Task) string {
 	return "-" + cleanupSpecialChars(task.DiscoveryInfo.Name)
 }
 
+// getHost returns the host the task is reached on: the agent of the task for
+// the host ports, the IP of its container for the container ports, the first
+// IP of the IP sources otherwise.
 func (provider *Mesos) getHost(task state.Task) string {
+	switch mesosPortMapping(task) {
+	case "host":
+		return task.SlaveIP
+	case "container":
+		return task.IP("netinfo", "mesos", "docker")
+	}
 	return task.IP(strings.Split(provider.IPSources, ",")...)
 }
 
@@ -422,6 +501,95 @@ func (provider *Mesos) taskRecords(sj state.State) []state.Task {
 	return p
 }
 
+// getAgents returns the agents listed by the first master answering, by ID.
+func (provider *Mesos) getAgents() (map[string]mesosAgent, error) {
+	client := &http.Client{Timeout: time.Duration(provider.StateTimeoutSecond) * time.Second}
+	err := errors.New("no mesos master")
+	for _, master := range provider.Masters {
+		if !strings.Contains(master, "://") {
+			master = "http://" + master
+		}
+		var agents []mesosAgent
+		agents, err = getMesosAgents(client, strings.TrimSuffix(master, "/")+"/master/slaves")
+		if err != nil {
+			log.Debugf("Failed to get the agents of mesos master %s, error: %s", master, err)
+			continue
+		}
+		agentsByID := make(map[string]mesosAgent)
+		for _, agent := range agents {
+			agentsByID[agent.ID] = agent
+		}
+		return agentsByID, nil
+	}
+	return nil, err
+}
+
+func getMesosAgents(client *http.Client, url string) ([]mesosAgent, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	agents := struct {
+		Slaves []mesosAgent `json:"slaves"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&agents); err != nil {
+		return nil, err
+	}
+	return agents.Slaves, nil
+}
+
+// parseMesosAgentAttributes parses the attributes the agents must have, like
+// "rack:r1,rack:r2,zone:eu-west", to their values by name.
+func parseMesosAgentAttributes(str string) map[string][]string {
+	attributes := make(map[string][]string)
+	for _, attribute := range strings.Split(str, ",") {
+		nameValue := strings.SplitN(strings.TrimSpace(attribute), ":", 2)
+		if len(nameValue) != 2 {
+			log.Warnf("Ignoring mesos agent attribute %q, expected name:value", attribute)
+			continue
+		}
+		attributes[nameValue[0]] = append(attributes[nameValue[0]], nameValue[1])
+	}
+	return attributes
+}
+
+// filterMesosAgentTasks returns the tasks running on an agent having, for
+// each name of attributes, one of its values.
+func filterMesosAgentTasks(tasks []state.Task, agents map[string]mesosAgent, attributes map[string][]string) []state.Task {
+	filteredTasks := []state.Task{}
+	for _, task := range tasks {
+		agent, ok := agents[task.SlaveID]
+		if !ok {
+			log.Debugf("Filtering mesos task %s on unknown agent %s", task.Name, task.SlaveID)
+			continue
+		}
+		if !mesosAgentMatches(agent, attributes) {
+			log.Debugf("Filtering mesos task %s on agent %s not matching the agent attributes", task.Name, agent.Hostname)
+			continue
+		}
+		filteredTasks = append(filteredTasks, task)
+	}
+	return filteredTasks
+}
+
+func mesosAgentMatches(agent mesosAgent, attributes map[string][]string) bool {
+	for name, values := range attributes {
+		attribute, ok := agent.Attributes[name]
+		if !ok {
+			return false
+		}
+		// scalar attributes are numbers, text attributes strings
+		if !fun.In(fmt.Sprint(attribute), values) {
+			return false
+		}
+	}
+	return true
+}
+
 // ErrorFunction A function definition that returns an error
 // to be passed to the Ignore or Panic error handler
 type ErrorFunction func() error
@@ -1,6 +1,9 @@
 package provider
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
@@ -275,6 +278,129 @@ func TestMesosGetSubDomain(t *testing.T) {
 	}
 }
 
+func TestMesosPortMapping(t *testing.T) {
+	provider := &Mesos{IPSources: "host"}
+	cases := []struct {
+		mesosTask    state.Task
+		expectedHost string
+		expectedPort string
+	}{
+		{
+			mesosTask: task(
+				setLabels("traefik.enable", "true"),
+				discovery(setDiscoveryPort("TCP", 80, "WEB")),
+				setResourcesPorts("[31000-31001]"),
+			),
+			expectedHost: "10.0.0.1",
+			expectedPort: "80",
+		},
+		{
+			mesosTask: task(
+				setLabels("traefik.enable", "true",
+					"traefik.portMapping", "host",
+					"traefik.portIndex", "1"),
+				discovery(setDiscoveryPort("TCP", 80, "WEB")),
+				setResourcesPorts("[31000-31001]"),
+			),
+			expectedHost: "10.0.0.1",
+			expectedPort: "31001",
+		},
+	}
+
+	for _, c := range cases {
+		c.mesosTask.SlaveIP = "10.0.0.1"
+		if !mesosTaskFilter(c.mesosTask, true) {
+			t.Fatalf("expected task %+v not to be filtered", c.mesosTask)
+		}
+		applications := []state.Task{c.mesosTask}
+		if actual := provider.getHost(c.mesosTask); actual != c.expectedHost {
+			t.Errorf("expected host %q, got %q", c.expectedHost, actual)
+		}
+		if actual := provider.getPort(c.mesosTask, applications); actual != c.expectedPort {
+			t.Errorf("expected port %q, got %q", c.expectedPort, actual)
+		}
+	}
+
+	withoutHostPorts := task(
+		setLabels("traefik.enable", "true", "traefik.portMapping", "host"),
+		discovery(setDiscoveryPort("TCP", 80, "WEB")),
+	)
+	if mesosTaskFilter(withoutHostPorts, true) {
+		t.Fatal("expected the task without host ports to be filtered")
+	}
+	unknownMapping := task(
+		setLabels("traefik.enable", "true", "traefik.portMapping", "bridge"),
+		discovery(setDiscoveryPort("TCP", 80, "WEB")),
+	)
+	if mesosTaskFilter(unknownMapping, true) {
+		t.Fatal("expected the task with an unknown port mapping to be filtered")
+	}
+}
+
+func TestMesosHostPorts(t *testing.T) {
+	actual := mesosHostPorts("[31000-31002, 31005-31005]")
+	expected := []int{31000, 31001, 31002, 31005}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	if actual := mesosHostPorts(""); len(actual) != 0 {
+		t.Fatalf("expected no ports, got %v", actual)
+	}
+}
+
+func TestMesosAgentAttributes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/master/slaves" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"slaves":[
+			{"id":"agent1","hostname":"10.0.0.1","attributes":{"rack":"r1","zone":"eu-west","gpus":2}},
+			{"id":"agent2","hostname":"10.0.0.2","attributes":{"rack":"r2","zone":"eu-west"}},
+			{"id":"agent3","hostname":"10.0.0.3","attributes":{"rack":"r1","zone":"us-east"}}
+		]}`)
+	}))
+	defer server.Close()
+
+	provider := &Mesos{
+		Masters:            []string{"127.0.0.1:1", server.URL},
+		StateTimeoutSecond: 5,
+	}
+	agents, err := provider.getAgents()
+	if err != nil {
+		t.Fatalf("error %+v", err)
+	}
+	if len(agents) != 3 {
+		t.Fatalf("expected 3 agents, got %+v", agents)
+	}
+
+	tasks := []state.Task{
+		{ID: "task1", SlaveID: "agent1"},
+		{ID: "task2", SlaveID: "agent2"},
+		{ID: "task3", SlaveID: "agent3"},
+		{ID: "task4", SlaveID: "agent4"},
+	}
+	cases := []struct {
+		attributes string
+		expected   []string
+	}{
+		{"zone:eu-west", []string{"task1", "task2"}},
+		{"rack:r1,zone:eu-west", []string{"task1"}},
+		{"rack:r1,rack:r2", []string{"task1", "task2", "task3"}},
+		{"gpus:2", []string{"task1"}},
+		{"zone:ap-south", []string{}},
+	}
+	for _, c := range cases {
+		actual := []string{}
+		for _, task := range filterMesosAgentTasks(tasks, agents, parseMesosAgentAttributes(c.attributes)) {
+			actual = append(actual, task.ID)
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.attributes, c.expected, actual)
+		}
+	}
+}
+
 // test helpers
 
 type (
@@ -370,6 +496,12 @@ func setDiscoveryPorts(proto1 string, port1 int, name1 string, proto2 string, po
 	}
 }
 
+func setResourcesPorts(portRanges string) taskOpt {
+	return func(t *state.Task) {
+		t.Resources.PortRanges = portRanges
+	}
+}
+
 func setState(st string) statusOpt {
 	return func(s *state.Status) {
 		s.State = st
@@ -0,0 +1,368 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenk/backoff"
+	"github.com/containous/traefik/job"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/http2"
+)
+
+// PluginProtocolVersion is the version of the protocol of plugin.proto spoken with the plugins.
+const PluginProtocolVersion = 1
+
+const (
+	pluginProtocolVersionEnv = "TRAEFIK_PLUGIN_PROTOCOL_VERSION"
+	pluginWatchPath          = "/traefik.plugin.v1.Provider/Watch"
+	pluginHandshakeTimeout   = 10 * time.Second
+	pluginDialTimeout        = 10 * time.Second
+	// pluginMaxMessageSize bounds the configurations received, as gRPC does by default.
+	pluginMaxMessageSize = 4 << 20
+)
+
+var _ Provider = (*Plugin)(nil)
+
+// Plugin holds configurations of the plugin provider, receiving the
+// configurations of an external process speaking the gRPC protocol of
+// plugin.proto. The process is spawned from Command, or reached at Address.
+type Plugin struct {
+	BaseProvider `mapstructure:",squash"`
+	Name         string     `description:"Name of the plugin"`
+	Command      string     `description:"Command spawning the plugin, with its arguments"`
+	Address      string     `description:"Address of the plugin: host:port or unix:///path/to/socket"`
+	TLS          *ClientTLS `description:"Enable TLS support"`
+}
+
+// pluginWatchRequest is the WatchRequest message of plugin.proto.
+type pluginWatchRequest struct {
+	ProtocolVersion int32  `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion" json:"protocol_version,omitempty"`
+	Name            string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *pluginWatchRequest) Reset()         { *m = pluginWatchRequest{} }
+func (m *pluginWatchRequest) String() string { return proto.CompactTextString(m) }
+func (*pluginWatchRequest) ProtoMessage()    {}
+
+// pluginConfiguration is the Configuration message of plugin.proto.
+type pluginConfiguration struct {
+	JSON []byte `protobuf:"bytes,1,opt,name=json,proto3" json:"json,omitempty"`
+}
+
+func (m *pluginConfiguration) Reset()         { *m = pluginConfiguration{} }
+func (m *pluginConfiguration) String() string { return proto.CompactTextString(m) }
+func (*pluginConfiguration) ProtoMessage()    {}
+
+// ConfigurationName returns the name of the configurations of the plugin.
+func (provider *Plugin) ConfigurationName() string {
+	if len(provider.Name) == 0 {
+		return "plugin"
+	}
+	return "plugin-" + provider.Name
+}
+
+// Provide allows the provider to provide configurations to traefik
+// using the given configuration channel.
+func (provider *Plugin) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, _ types.Constraints) error {
+	if len(provider.Command) == 0 && len(provider.Address) == 0 {
+		return errors.New("the plugin provider needs a command or an address")
+	}
+	pool.Go(func(stop chan bool) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		safe.Go(func() {
+			select {
+			case <-stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		})
+
+		operation := func() error {
+			err := provider.watch(ctx, configurationChan)
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		notify := func(err error, time time.Duration) {
+			log.Errorf("Plugin %s error %+v, retrying in %s", provider.ConfigurationName(), err, time)
+		}
+		err := backoff.RetryNotify(operation, job.NewBackOff(backoff.NewExponentialBackOff()), notify)
+		if err != nil {
+			log.Errorf("Cannot connect to plugin %s %+v", provider.ConfigurationName(), err)
+		}
+	})
+	return nil
+}
+
+// watch receives the configurations of the plugin, spawned if needed, until
+// its stream ends, or after the first one when the provider doesn't watch.
+func (provider *Plugin) watch(ctx context.Context, configurationChan chan<- types.ConfigMessage) error {
+	network, address, err := parsePluginAddress(provider.Address)
+	if err != nil {
+		return err
+	}
+	if len(provider.Command) > 0 {
+		cmd, stdout, err := provider.spawn(ctx)
+		if err != nil {
+			return err
+		}
+		defer cmd.Wait()
+		defer cmd.Process.Kill()
+		network, address, err = readPluginHandshake(stdout, pluginHandshakeTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid handshake of plugin %s: %v", provider.ConfigurationName(), err)
+		}
+		safe.Go(func() {
+			logPluginOutput(provider.ConfigurationName(), stdout)
+		})
+	}
+
+	client, url, err := provider.newClient(network, address)
+	if err != nil {
+		return err
+	}
+	request := &pluginWatchRequest{ProtocolVersion: PluginProtocolVersion, Name: provider.Name}
+	body := &bytes.Buffer{}
+	if err := writeGRPCMessage(body, request); err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url+pluginWatchPath, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/grpc+proto")
+	req.Header.Set("TE", "trailers")
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("plugin %s answered with status %d", provider.ConfigurationName(), resp.StatusCode)
+	}
+	log.Infof("Watching plugin %s at %s", provider.ConfigurationName(), address)
+
+	for {
+		message := &pluginConfiguration{}
+		err := readGRPCMessage(resp.Body, message)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		configuration := &types.Configuration{}
+		if err := json.Unmarshal(message.JSON, configuration); err != nil {
+			log.Errorf("Invalid configuration from plugin %s: %v", provider.ConfigurationName(), err)
+			continue
+		}
+		configurationChan <- types.ConfigMessage{
+			ProviderName:  provider.ConfigurationName(),
+			Configuration: configuration,
+		}
+		if !provider.Watch {
+			return nil
+		}
+	}
+	if err := grpcStatus(resp); err != nil {
+		return err
+	}
+	return fmt.Errorf("plugin %s ended its stream", provider.ConfigurationName())
+}
+
+// spawn starts the command of the plugin, killed once ctx is done, and
+// returns its standard output.
+func (provider *Plugin) spawn(ctx context.Context) (*exec.Cmd, *bufio.Reader, error) {
+	args := strings.Fields(provider.Command)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), pluginProtocolVersionEnv+"="+strconv.Itoa(PluginProtocolVersion))
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("cannot spawn plugin %s: %v", provider.ConfigurationName(), err)
+	}
+	safe.Go(func() {
+		logPluginOutput(provider.ConfigurationName(), stderr)
+	})
+	return cmd, bufio.NewReader(stdout), nil
+}
+
+// newClient returns the HTTP/2 client of the plugin served at address, and
+// the URL of its server. Without TLS configuration, HTTP/2 is spoken in clear.
+func (provider *Plugin) newClient(network, address string) (*http.Client, string, error) {
+	var tlsConfig *tls.Config
+	url := "http://" + address
+	if provider.TLS != nil {
+		var err error
+		tlsConfig, err = provider.TLS.CreateTLSConfig()
+		if err != nil {
+			return nil, "", err
+		}
+		url = "https://" + address
+	}
+	if network == "unix" {
+		url = strings.SplitN(url, "://", 2)[0] + "://" + provider.ConfigurationName()
+	}
+	transport := &http2.Transport{
+		AllowHTTP:       true,
+		TLSClientConfig: tlsConfig,
+		DialTLS: func(_, _ string, config *tls.Config) (net.Conn, error) {
+			conn, err := net.DialTimeout(network, address, pluginDialTimeout)
+			if err != nil || tlsConfig == nil {
+				return conn, err
+			}
+			tlsConn := tls.Client(conn, config)
+			if err := tlsConn.Handshake(); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return tlsConn, nil
+		},
+	}
+	return &http.Client{Transport: transport}, url, nil
+}
+
+// parsePluginAddress returns the network and the address of the plugin
+// reached at address: host:port or unix:///path/to/socket.
+func parsePluginAddress(address string) (string, string, error) {
+	if len(address) == 0 {
+		return "", "", nil
+	}
+	if strings.HasPrefix(address, "unix://") {
+		return "unix", strings.TrimPrefix(address, "unix://"), nil
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return "", "", fmt.Errorf("invalid plugin address %s: %v", address, err)
+	}
+	return "tcp", address, nil
+}
+
+// readPluginHandshake reads the first line written by a spawned plugin:
+// "<protocol version>|<network>|<address>".
+func readPluginHandshake(stdout *bufio.Reader, timeout time.Duration) (string, string, error) {
+	lines := make(chan string, 1)
+	errs := make(chan error, 1)
+	safe.Go(func() {
+		line, err := stdout.ReadString('\n')
+		if err != nil {
+			errs <- err
+			return
+		}
+		lines <- line
+	})
+	var line string
+	select {
+	case line = <-lines:
+	case err := <-errs:
+		return "", "", err
+	case <-time.After(timeout):
+		return "", "", fmt.Errorf("no handshake after %s", timeout)
+	}
+	return parsePluginHandshake(line)
+}
+
+func parsePluginHandshake(line string) (string, string, error) {
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 3 {
+		return "", "", fmt.Errorf("expected <protocol version>|<network>|<address>, got %q", line)
+	}
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", "", fmt.Errorf("invalid protocol version %q", parts[0])
+	}
+	if version != PluginProtocolVersion {
+		return "", "", fmt.Errorf("unsupported protocol version %d, expected %d", version, PluginProtocolVersion)
+	}
+	if parts[1] != "tcp" && parts[1] != "unix" {
+		return "", "", fmt.Errorf("unsupported network %q", parts[1])
+	}
+	return parts[1], parts[2], nil
+}
+
+func logPluginOutput(name string, output io.Reader) {
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		log.Debugf("Plugin %s: %s", name, scanner.Text())
+	}
+}
+
+// writeGRPCMessage writes message in a gRPC frame: an uncompressed flag, the
+// length of the message on 4 bytes, and the message.
+func writeGRPCMessage(w io.Writer, message proto.Message) error {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header[1:], uint32(len(data)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// readGRPCMessage reads a gRPC frame into message, and returns io.EOF at the
+// end of the stream.
+func readGRPCMessage(r io.Reader, message proto.Message) error {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return errors.New("truncated gRPC frame")
+		}
+		return err
+	}
+	if header[0] != 0 {
+		return errors.New("compressed gRPC messages aren't supported")
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > pluginMaxMessageSize {
+		return fmt.Errorf("gRPC message of %d bytes larger than %d", length, pluginMaxMessageSize)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return errors.New("truncated gRPC frame")
+	}
+	return proto.Unmarshal(data, message)
+}
+
+// grpcStatus returns the error of the gRPC status of a response, read from
+// its trailers, or its headers when the response has no body.
+func grpcStatus(resp *http.Response) error {
+	status := resp.Trailer.Get("Grpc-Status")
+	message := resp.Trailer.Get("Grpc-Message")
+	if len(status) == 0 {
+		status = resp.Header.Get("Grpc-Status")
+		message = resp.Header.Get("Grpc-Message")
+	}
+	if len(status) == 0 || status == "0" {
+		return nil
+	}
+	return fmt.Errorf("gRPC status %s: %s", status, message)
+}
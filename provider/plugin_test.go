@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+)
+
+func TestParsePluginHandshake(t *testing.T) {
+	cases := []struct {
+		line            string
+		expectedNetwork string
+		expectedAddress string
+		expectedError   bool
+	}{
+		{line: "1|tcp|127.0.0.1:4242\n", expectedNetwork: "tcp", expectedAddress: "127.0.0.1:4242"},
+		{line: "1|unix|/tmp/plugin.sock\n", expectedNetwork: "unix", expectedAddress: "/tmp/plugin.sock"},
+		{line: "2|tcp|127.0.0.1:4242\n", expectedError: true},
+		{line: "1|udp|127.0.0.1:4242\n", expectedError: true},
+		{line: "listening on 127.0.0.1:4242\n", expectedError: true},
+	}
+
+	for _, c := range cases {
+		network, address, err := parsePluginHandshake(c.line)
+		if c.expectedError {
+			assert.Error(t, err, c.line)
+			continue
+		}
+		assert.NoError(t, err, c.line)
+		assert.Equal(t, c.expectedNetwork, network, c.line)
+		assert.Equal(t, c.expectedAddress, address, c.line)
+	}
+}
+
+func TestPluginWatch(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	requests := make(chan *pluginWatchRequest, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &pluginWatchRequest{}
+		if r.URL.Path != pluginWatchPath || readGRPCMessage(r.Body, request) != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests <- request
+		w.Header().Set("Content-Type", "application/grpc+proto")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		for _, configuration := range []string{
+			`{"backends": {"backend1": {"servers": {"server1": {"url": "http://10.0.0.1:80"}}}}}`,
+			`{"backends": `,
+			`{"frontends": {"frontend1": {"backend": "backend1"}}}`,
+		} {
+			writeGRPCMessage(w, &pluginConfiguration{JSON: []byte(configuration)})
+			w.(http.Flusher).Flush()
+		}
+		w.Header().Set("Grpc-Status", "14")
+		w.Header().Set("Grpc-Message", "registry unavailable")
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go (&http2.Server{}).ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+		}
+	}()
+
+	provider := &Plugin{Name: "registry", Address: listener.Addr().String()}
+	provider.Watch = true
+	configurationChan := make(chan types.ConfigMessage, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = provider.watch(ctx, configurationChan)
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "registry unavailable"), err.Error())
+	}
+
+	request := <-requests
+	assert.Equal(t, int32(PluginProtocolVersion), request.ProtocolVersion)
+	assert.Equal(t, "registry", request.Name)
+
+	if assert.Len(t, configurationChan, 2, "the invalid configuration is skipped") {
+		message := <-configurationChan
+		assert.Equal(t, "plugin-registry", message.ProviderName)
+		assert.Equal(t, "http://10.0.0.1:80", message.Configuration.Backends["backend1"].Servers["server1"].URL)
+		message = <-configurationChan
+		assert.Equal(t, "backend1", message.Configuration.Frontends["frontend1"].Backend)
+	}
+}
+
+func TestGRPCMessage(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, writeGRPCMessage(buffer, &pluginWatchRequest{ProtocolVersion: 1, Name: "registry"}))
+	assert.Equal(t, []byte{0, 0, 0, 0, 12}, buffer.Bytes()[:5])
+
+	request := &pluginWatchRequest{}
+	assert.NoError(t, readGRPCMessage(bytes.NewReader(buffer.Bytes()), request))
+	assert.Equal(t, &pluginWatchRequest{ProtocolVersion: 1, Name: "registry"}, request)
+
+	assert.Error(t, readGRPCMessage(bytes.NewReader(buffer.Bytes()[:8]), request), "truncated frame")
+	assert.Error(t, readGRPCMessage(bytes.NewReader([]byte{1, 0, 0, 0, 0}), request), "compressed frame")
+}
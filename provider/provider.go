@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"strings"
 	"text/template"
+	"time"
 	"unicode"
 
 	"crypto/tls"
@@ -28,9 +29,23 @@ type Provider interface {
 
 // BaseProvider should be inherited by providers
 type BaseProvider struct {
-	Watch       bool              `description:"Watch provider"`
-	Filename    string            `description:"Override default configuration template. For advanced users :)"`
-	Constraints types.Constraints `description:"Filter services by constraint, matching with Traefik tags."`
+	Watch            bool              `description:"Watch provider"`
+	Filename         string            `description:"Override default configuration template. For advanced users :)"`
+	Constraints      types.Constraints `description:"Filter services by constraint, matching with Traefik tags."`
+	DryRun           bool              `description:"Publish the configuration to the API without applying it"`
+	ThrottleDuration time.Duration     `description:"Minimum duration between 2 events from the provider before applying a new configuration, instead of providersThrottleDuration"`
+}
+
+// IsDryRun returns true if the configurations of the provider must only be
+// published to the API, and never applied.
+func (p *BaseProvider) IsDryRun() bool {
+	return p.DryRun
+}
+
+// GetThrottleDuration returns the throttle duration of the provider, 0 for
+// the providersThrottleDuration.
+func (p *BaseProvider) GetThrottleDuration() time.Duration {
+	return p.ThrottleDuration
 }
 
 // MatchConstraints must match with EVERY single contraint
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/provider"
+)
+
+const (
+	providerStatusOK       = "ok"
+	providerStatusDegraded = "degraded"
+)
+
+// ProviderStatus is the status of a provider: ok while its configurations are
+// applied, degraded once it failed, or one of its configurations failed to
+// load, until a configuration of it is applied again. The last configuration
+// applied of a degraded provider stays active.
+type ProviderStatus struct {
+	Provider    string     `json:"provider"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Failures    int64      `json:"failures"`
+	LastApplied *time.Time `json:"lastApplied,omitempty"`
+	LastFailure *time.Time `json:"lastFailure,omitempty"`
+}
+
+// ProviderStatuses tracks the status of the providers.
+type ProviderStatuses struct {
+	mutex    sync.Mutex
+	statuses map[string]*ProviderStatus
+}
+
+// NewProviderStatuses returns a ProviderStatuses without providers.
+func NewProviderStatuses() *ProviderStatuses {
+	return &ProviderStatuses{statuses: make(map[string]*ProviderStatus)}
+}
+
+// status returns the status of providerName, created if needed. s.mutex must be held.
+func (s *ProviderStatuses) status(providerName string) *ProviderStatus {
+	status, ok := s.statuses[providerName]
+	if !ok {
+		status = &ProviderStatus{Provider: providerName, Status: providerStatusOK}
+		s.statuses[providerName] = status
+	}
+	return status
+}
+
+// Applied records that a configuration of providerName was applied.
+func (s *ProviderStatuses) Applied(providerName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now().UTC()
+	status := s.status(providerName)
+	status.Status = providerStatusOK
+	status.Error = ""
+	status.LastApplied = &now
+}
+
+// Failed records that providerName failed with err.
+func (s *ProviderStatuses) Failed(providerName string, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now().UTC()
+	status := s.status(providerName)
+	status.Status = providerStatusDegraded
+	status.Error = err.Error()
+	status.Failures++
+	status.LastFailure = &now
+}
+
+// Data returns the statuses of the providers, sorted by name.
+func (s *ProviderStatuses) Data() []*ProviderStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data := []*ProviderStatus{}
+	for _, status := range s.statuses {
+		statusCopy := *status
+		data = append(data, &statusCopy)
+	}
+	sort.Sort(providerStatusesByName(data))
+	return data
+}
+
+type providerStatusesByName []*ProviderStatus
+
+func (s providerStatusesByName) Len() int           { return len(s) }
+func (s providerStatusesByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s providerStatusesByName) Less(i, j int) bool { return s[i].Provider < s[j].Provider }
+
+// Readiness is the readiness of traefik, served by /ping/ready.
+type Readiness struct {
+	Status    string            `json:"status"`
+	Providers []*ProviderStatus `json:"providers"`
+}
+
+// Readiness returns the readiness of traefik: starting until a configuration
+// is applied, degraded while a provider is, ready otherwise.
+func (s *ProviderStatuses) Readiness() *Readiness {
+	data := s.Data()
+	applied, degraded := false, false
+	for _, status := range data {
+		applied = applied || status.LastApplied != nil
+		degraded = degraded || status.Status == providerStatusDegraded
+	}
+	readiness := &Readiness{Status: "ready", Providers: data}
+	if !applied {
+		readiness.Status = "starting"
+	} else if degraded {
+		readiness.Status = providerStatusDegraded
+	}
+	return readiness
+}
+
+// writeProviderMetrics writes the statuses of the providers in the Prometheus text format.
+func writeProviderMetrics(w io.Writer, statuses []*ProviderStatus) {
+	if len(statuses) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP traefik_provider_degraded Whether the provider is degraded, its last configuration staying applied.\n")
+	fmt.Fprintf(w, "# TYPE traefik_provider_degraded gauge\n")
+	for _, status := range statuses {
+		degraded := 0
+		if status.Status == providerStatusDegraded {
+			degraded = 1
+		}
+		fmt.Fprintf(w, "traefik_provider_degraded{provider=%q} %d\n", status.Provider, degraded)
+	}
+	fmt.Fprintf(w, "# HELP traefik_provider_failures_total Failures of the provider, and of its configurations to load.\n")
+	fmt.Fprintf(w, "# TYPE traefik_provider_failures_total counter\n")
+	for _, status := range statuses {
+		fmt.Fprintf(w, "traefik_provider_failures_total{provider=%q} %d\n", status.Provider, status.Failures)
+	}
+	fmt.Fprintf(w, "# HELP traefik_provider_last_applied_timestamp_seconds Time of the last configuration of the provider applied.\n")
+	fmt.Fprintf(w, "# TYPE traefik_provider_last_applied_timestamp_seconds gauge\n")
+	for _, status := range statuses {
+		if status.LastApplied != nil {
+			fmt.Fprintf(w, "traefik_provider_last_applied_timestamp_seconds{provider=%q} %d\n", status.Provider, status.LastApplied.Unix())
+		}
+	}
+}
+
+// providerConfigurationName returns the name of the configurations of currentProvider.
+func providerConfigurationName(currentProvider provider.Provider) string {
+	switch p := currentProvider.(type) {
+	case *provider.Docker:
+		return "docker"
+	case *provider.Marathon:
+		return "marathon"
+	case *provider.File:
+		return "file"
+	case *WebProvider:
+		return "web"
+	case *provider.Consul:
+		return "consul"
+	case *provider.ConsulCatalog:
+		return "consul_catalog"
+	case *provider.Etcd:
+		return "etcd"
+	case *provider.Zookepper:
+		return "zk"
+	case *provider.BoltDb:
+		return "boltdb"
+	case *provider.Kubernetes:
+		return "kubernetes"
+	case *provider.Mesos:
+		return "mesos"
+	case *provider.Eureka:
+		return "eureka"
+	case *provider.WebAPI:
+		return "webapi"
+	case *provider.Plugin:
+		return p.ConfigurationName()
+	default:
+		return fmt.Sprintf("%T", p)
+	}
+}
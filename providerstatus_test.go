@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderStatuses(t *testing.T) {
+	statuses := NewProviderStatuses()
+	assert.Equal(t, "starting", statuses.Readiness().Status)
+
+	statuses.Failed("kubernetes", errors.New("connection refused"))
+	assert.Equal(t, "starting", statuses.Readiness().Status, "no configuration is applied yet")
+
+	statuses.Applied("docker")
+	readiness := statuses.Readiness()
+	assert.Equal(t, "degraded", readiness.Status)
+	if assert.Len(t, readiness.Providers, 2) {
+		assert.Equal(t, "docker", readiness.Providers[0].Provider)
+		assert.Equal(t, "ok", readiness.Providers[0].Status)
+		assert.Equal(t, "kubernetes", readiness.Providers[1].Provider)
+		assert.Equal(t, "degraded", readiness.Providers[1].Status)
+		assert.Equal(t, "connection refused", readiness.Providers[1].Error)
+		assert.Equal(t, int64(1), readiness.Providers[1].Failures)
+	}
+
+	statuses.Applied("kubernetes")
+	readiness = statuses.Readiness()
+	assert.Equal(t, "ready", readiness.Status)
+	assert.Empty(t, readiness.Providers[1].Error)
+	assert.Equal(t, int64(1), readiness.Providers[1].Failures, "the failures are counted since the start")
+
+	statuses.Failed("kubernetes", errors.New("invalid configuration"))
+	buffer := &bytes.Buffer{}
+	writeProviderMetrics(buffer, statuses.Data())
+	metrics := buffer.String()
+	assert.True(t, strings.Contains(metrics, `traefik_provider_degraded{provider="docker"} 0`), metrics)
+	assert.True(t, strings.Contains(metrics, `traefik_provider_degraded{provider="kubernetes"} 1`), metrics)
+	assert.True(t, strings.Contains(metrics, `traefik_provider_failures_total{provider="kubernetes"} 2`), metrics)
+	assert.True(t, strings.Contains(metrics, `traefik_provider_last_applied_timestamp_seconds{provider="docker"} `), metrics)
+}
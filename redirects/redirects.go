@@ -0,0 +1,261 @@
+package redirects
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// Redirect is the destination of a source of a redirect map.
+type Redirect struct {
+	URL    string
+	Status int
+}
+
+// Map holds the redirects of the sources of a map, reloaded when they change.
+type Map struct {
+	source   source
+	status   int
+	interval time.Duration
+	mutex    sync.RWMutex
+	hosts    map[string]*node
+	checked  time.Time
+	loading  bool
+}
+
+// node is a path segment of the trie of the sources of a host.
+type node struct {
+	children map[string]*node
+	// exact is the redirect of the path of the node, prefix of its subpaths
+	exact  *Redirect
+	prefix *Redirect
+}
+
+// New returns the redirect map of config, loaded from its file or KV store.
+func New(config *types.RedirectMap) (*Map, error) {
+	m := &Map{status: config.Status}
+	if m.status == 0 {
+		m.status = http.StatusFound
+	}
+	if err := checkStatus(m.status); err != nil {
+		return nil, err
+	}
+	switch {
+	case len(config.File) > 0:
+		m.source = &fileSource{filename: config.File}
+		m.interval = fileCheckInterval
+	case len(config.KVBackend) > 0:
+		if len(config.KVPrefix) == 0 {
+			return nil, errors.New("the KV prefix of the redirect map is required")
+		}
+		m.source = &kvSource{backend: config.KVBackend, endpoint: config.KVEndpoint, prefix: strings.Trim(config.KVPrefix, "/")}
+		m.interval = kvCheckInterval
+	default:
+		return nil, errors.New("the redirect map requires a file or a KV store")
+	}
+	lines, err := m.source.load()
+	if err != nil {
+		if _, ok := m.source.(*kvSource); !ok {
+			return nil, err
+		}
+		// the store may not be reachable yet
+		log.Errorf("Error loading the redirect map: %v", err)
+	}
+	if err == nil {
+		if m.hosts, err = m.parse(lines); err != nil {
+			return nil, err
+		}
+	}
+	m.checked = time.Now()
+	return m, nil
+}
+
+// Lookup returns the redirect of the request to host and path, or nil if the
+// map has none. The sources of the host have precedence over the sources of
+// any host, the exact sources over the prefixes, and the longest prefix wins.
+// The query of the request is added to the destination.
+func (m *Map) Lookup(host, path, rawQuery string) *Redirect {
+	hosts := m.current()
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	redirect, remainder := lookup(hosts[host], path)
+	if redirect == nil && len(host) > 0 {
+		redirect, remainder = lookup(hosts[""], path)
+	}
+	if redirect == nil {
+		return nil
+	}
+	destination := redirect.URL
+	if len(remainder) > 0 {
+		destination = strings.TrimSuffix(destination, "/") + "/" + remainder
+	}
+	if len(rawQuery) > 0 {
+		if strings.Contains(destination, "?") {
+			destination += "&" + rawQuery
+		} else {
+			destination += "?" + rawQuery
+		}
+	}
+	return &Redirect{URL: destination, Status: redirect.Status}
+}
+
+// lookup returns the redirect of path in the trie of root, and the remainder
+// of the path to add to the destination of a matching prefix.
+func lookup(root *node, path string) (*Redirect, string) {
+	if root == nil {
+		return nil, ""
+	}
+	segments := splitPath(path)
+	var prefix *Redirect
+	prefixLength := 0
+	current := root
+	for i, segment := range segments {
+		if current.prefix != nil {
+			prefix, prefixLength = current.prefix, i
+		}
+		if current = current.children[segment]; current == nil {
+			break
+		}
+	}
+	if current != nil {
+		if current.exact != nil {
+			return current.exact, ""
+		}
+		if current.prefix != nil {
+			return current.prefix, ""
+		}
+	}
+	if prefix == nil {
+		return nil, ""
+	}
+	return prefix, strings.Join(segments[prefixLength:], "/")
+}
+
+// current returns the redirects of the map, reloading them in the background
+// when the check interval has elapsed.
+func (m *Map) current() map[string]*node {
+	m.mutex.RLock()
+	hosts, stale := m.hosts, !m.loading && time.Since(m.checked) >= m.interval
+	m.mutex.RUnlock()
+	if stale {
+		m.mutex.Lock()
+		if !m.loading && time.Since(m.checked) >= m.interval {
+			m.loading = true
+			go m.reload()
+		}
+		m.mutex.Unlock()
+	}
+	return hosts
+}
+
+func (m *Map) reload() {
+	lines, err := m.source.load()
+	var hosts map[string]*node
+	if err == nil && lines != nil {
+		hosts, err = m.parse(lines)
+	}
+	if err != nil {
+		log.Errorf("Error reloading the redirect map, keeping the previous one: %v", err)
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err == nil && lines != nil {
+		m.hosts = hosts
+		log.Debugf("Reloaded the redirect map")
+	}
+	m.checked = time.Now()
+	m.loading = false
+}
+
+// parse builds the tries of the "source destination [status]" lines, but the
+// empty ones and the # comments. The
+// sources are paths, for any host, or host/path, their /* suffix makes them
+// prefixes of their subpaths.
+func (m *Map) parse(lines []string) (map[string]*node, error) {
+	hosts := map[string]*node{}
+	for number, line := range lines {
+		if line = strings.TrimSpace(line); len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("line %d: expected source destination [status]", number+1)
+		}
+		redirect := &Redirect{URL: fields[1], Status: m.status}
+		if _, err := url.Parse(redirect.URL); err != nil {
+			return nil, fmt.Errorf("line %d: invalid destination: %v", number+1, err)
+		}
+		if len(fields) == 3 {
+			status, err := strconv.Atoi(fields[2])
+			if err == nil {
+				err = checkStatus(status)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid status %s", number+1, fields[2])
+			}
+			redirect.Status = status
+		}
+		host, path := "", fields[0]
+		if !strings.HasPrefix(path, "/") {
+			host, path = path, "/"
+			if i := strings.Index(host, "/"); i >= 0 {
+				host, path = host[:i], host[i:]
+			}
+			host = strings.ToLower(host)
+		}
+		prefix := strings.HasSuffix(path, "/*")
+		path = strings.TrimSuffix(path, "*")
+		current, ok := hosts[host]
+		if !ok {
+			current = &node{}
+			hosts[host] = current
+		}
+		for _, segment := range splitPath(path) {
+			child, ok := current.children[segment]
+			if !ok {
+				if current.children == nil {
+					current.children = map[string]*node{}
+				}
+				child = &node{}
+				current.children[segment] = child
+			}
+			current = child
+		}
+		if prefix {
+			current.prefix = redirect
+		} else {
+			current.exact = redirect
+		}
+	}
+	return hosts, nil
+}
+
+// splitPath returns the segments of path, ignoring the empty ones
+func splitPath(path string) []string {
+	segments := []string{}
+	for _, segment := range strings.Split(path, "/") {
+		if len(segment) > 0 {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+func checkStatus(status int) error {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, 308:
+		return nil
+	}
+	return fmt.Errorf("invalid redirect status %d, expected 301, 302, 303, 307 or 308", status)
+}
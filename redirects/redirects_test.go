@@ -0,0 +1,88 @@
+package redirects
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const testMap = `
+# marketing links
+/promo https://www.example.com/landing?utm_source=promo 301
+/docs/* https://docs.example.com/
+/docs/legacy https://docs.example.com/v1/
+example.com/promo https://example.com/promo-2017
+example.com/* https://www.example.com/ 308
+`
+
+func TestLookup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "redirects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "redirects.map")
+	if err := ioutil.WriteFile(filename, []byte(testMap), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(&types.RedirectMap{File: filename})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		host, path, query string
+		expected          *Redirect
+	}{
+		{"test.org", "/promo", "", &Redirect{URL: "https://www.example.com/landing?utm_source=promo", Status: 301}},
+		{"test.org:8080", "/promo/", "id=1", &Redirect{URL: "https://www.example.com/landing?utm_source=promo&id=1", Status: 301}},
+		{"test.org", "/docs", "", &Redirect{URL: "https://docs.example.com/", Status: 302}},
+		{"test.org", "/docs/guide/install", "v=2", &Redirect{URL: "https://docs.example.com/guide/install?v=2", Status: 302}},
+		{"test.org", "/docs/legacy", "", &Redirect{URL: "https://docs.example.com/v1/", Status: 302}},
+		{"test.org", "/docs/legacy/page", "", &Redirect{URL: "https://docs.example.com/legacy/page", Status: 302}},
+		{"Example.com", "/promo", "", &Redirect{URL: "https://example.com/promo-2017", Status: 302}},
+		{"example.com", "/about/team", "", &Redirect{URL: "https://www.example.com/about/team", Status: 308}},
+		{"test.org", "/other", "", nil},
+		{"test.org", "/", "", nil},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, m.Lookup(c.host, c.path, c.query), c.host+c.path)
+	}
+
+	// the modified file is reloaded in the background
+	if err := ioutil.WriteFile(filename, []byte("/promo https://www.example.com/new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Minute)
+	os.Chtimes(filename, modTime, modTime)
+	m.mutex.Lock()
+	m.checked = time.Time{}
+	m.mutex.Unlock()
+	m.Lookup("test.org", "/promo", "")
+	for i := 0; i < 100 && m.Lookup("test.org", "/docs", "") != nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, &Redirect{URL: "https://www.example.com/new", Status: 302}, m.Lookup("test.org", "/promo", ""))
+}
+
+func TestParseErrors(t *testing.T) {
+	m := &Map{status: 302}
+	for _, line := range []string{
+		"/promo",
+		"/promo https://example.com 200",
+		"/promo https://example.com 301 extra",
+		"/promo %zz",
+	} {
+		_, err := m.parse([]string{line})
+		assert.Error(t, err, line)
+	}
+	_, err := New(&types.RedirectMap{})
+	assert.Error(t, err)
+	_, err = New(&types.RedirectMap{File: "redirects.map", Status: 200})
+	assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package redirects
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/libkv"
+	"github.com/docker/libkv/store"
+	"github.com/docker/libkv/store/boltdb"
+	"github.com/docker/libkv/store/consul"
+	"github.com/docker/libkv/store/etcd"
+	"github.com/docker/libkv/store/zookeeper"
+)
+
+const (
+	// fileCheckInterval is the interval between the checks of the modifications of the file of a map
+	fileCheckInterval = time.Second
+	// kvCheckInterval is the interval between the reloads of a map from a KV store
+	kvCheckInterval = 30 * time.Second
+)
+
+func init() {
+	boltdb.Register()
+	consul.Register()
+	etcd.Register()
+	zookeeper.Register()
+}
+
+// source returns the lines of a map, nil if they didn't change since the last load.
+type source interface {
+	load() ([]string, error)
+}
+
+// fileSource reads the lines of a file, when it is modified
+type fileSource struct {
+	filename string
+	modTime  time.Time
+}
+
+func (s *fileSource) load() ([]string, error) {
+	info, err := os.Stat(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	s.modTime = info.ModTime()
+	return strings.Split(string(data), "\n"), nil
+}
+
+// kvSource reads the prefix/host/path keys of a KV store, holding "destination
+// [status]" values. The _ host stands for any host.
+type kvSource struct {
+	backend  string
+	endpoint string
+	prefix   string
+	kvclient store.Store
+}
+
+func (s *kvSource) load() ([]string, error) {
+	if s.kvclient == nil {
+		kvclient, err := libkv.NewStore(
+			store.Backend(s.backend),
+			strings.Split(s.endpoint, ","),
+			&store.Config{ConnectionTimeout: 30 * time.Second, Bucket: "traefik"},
+		)
+		if err != nil {
+			return nil, err
+		}
+		s.kvclient = kvclient
+	}
+	pairs, err := s.kvclient.List(s.prefix)
+	if err == store.ErrKeyNotFound {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lines := []string{}
+	for _, pair := range pairs {
+		source := strings.TrimPrefix(strings.TrimPrefix(pair.Key, s.prefix), "/")
+		if len(source) == 0 || len(strings.TrimSpace(string(pair.Value))) == 0 {
+			continue
+		}
+		if strings.HasPrefix(source, "_/") {
+			source = source[1:]
+		}
+		lines = append(lines, source+" "+string(pair.Value))
+	}
+	return lines, nil
+}
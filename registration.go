@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cenk/backoff"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/registration"
+	"github.com/containous/traefik/safe"
+)
+
+// entryPointPort returns the port an entrypoint listens on.
+func entryPointPort(entryPoint *EntryPoint) (int, error) {
+	_, portString, err := net.SplitHostPort(entryPoint.Address)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(portString)
+}
+
+// retryRegistration runs register in the background until it succeeds or the backoff gives up.
+func retryRegistration(description string, register func() error) {
+	safe.Go(func() {
+		notify := func(err error, time time.Duration) {
+			log.Errorf("Error registering in %s, retrying in %s: %v", description, time, err)
+		}
+		if err := backoff.RetryNotify(register, backoff.NewExponentialBackOff(), notify); err != nil {
+			log.Errorf("Cannot register in %s: %v", description, err)
+		}
+	})
+}
+
+// registerTargetGroup registers the entrypoint of the target group configuration
+// in the target group, retrying in the background on failures.
+func (server *Server) registerTargetGroup() {
+	targetGroup := server.globalConfiguration.TargetGroup
+	if targetGroup == nil {
+		return
+	}
+	entryPoint, ok := server.globalConfiguration.EntryPoints[targetGroup.EntryPoint]
+	if !ok {
+		log.Errorf("Error registering in target group %s: unknown entrypoint %s", targetGroup.ARN, targetGroup.EntryPoint)
+		return
+	}
+	port, err := entryPointPort(entryPoint)
+	if err != nil {
+		log.Errorf("Error registering in target group %s: invalid address %s of entrypoint %s", targetGroup.ARN, entryPoint.Address, targetGroup.EntryPoint)
+		return
+	}
+	retryRegistration("target group "+targetGroup.ARN, func() error {
+		if err := targetGroup.Register(port); err != nil {
+			return err
+		}
+		log.Infof("Registered entrypoint %s in target group %s", targetGroup.EntryPoint, targetGroup.ARN)
+		return nil
+	})
+}
+
+// registerConsul registers the entrypoints as services of the Consul agent,
+// retrying in the background on failures.
+func (server *Server) registerConsul() {
+	consul := server.globalConfiguration.ConsulRegistration
+	if consul == nil {
+		return
+	}
+	entryPointNames := []string{}
+	for entryPointName := range server.globalConfiguration.EntryPoints {
+		entryPointNames = append(entryPointNames, entryPointName)
+	}
+	sort.Strings(entryPointNames)
+	services := []registration.Service{}
+	for _, entryPointName := range entryPointNames {
+		entryPoint := server.globalConfiguration.EntryPoints[entryPointName]
+		port, err := entryPointPort(entryPoint)
+		if err != nil {
+			log.Errorf("Error registering entrypoint %s in Consul: invalid address %s", entryPointName, entryPoint.Address)
+			continue
+		}
+		services = append(services, registration.Service{EntryPoint: entryPointName, Port: port, TLS: entryPoint.TLS != nil})
+	}
+
+	pingURL := ""
+	if web := server.globalConfiguration.Web; web != nil {
+		host, port, err := net.SplitHostPort(web.Address)
+		if err == nil {
+			if len(host) == 0 {
+				// the checks are run by the local agent
+				host = "127.0.0.1"
+			}
+			scheme := "http"
+			if len(web.CertFile) > 0 {
+				scheme = "https"
+			}
+			pingURL = fmt.Sprintf("%s://%s/ping", scheme, net.JoinHostPort(host, port))
+		}
+	}
+
+	retryRegistration("Consul", func() error {
+		return consul.Register(services, pingURL)
+	})
+	server.routinesPool.Go(func(stop chan bool) {
+		consul.Run(stop)
+	})
+}
+
+// deregister deregisters traefik from the target group and Consul, and keeps
+// serving while the load-balancer drains the connections and the clients notice it.
+func (server *Server) deregister() {
+	var delay time.Duration
+	if targetGroup := server.globalConfiguration.TargetGroup; targetGroup != nil {
+		targetGroupDelay, err := targetGroup.Deregister()
+		if err != nil {
+			log.Errorf("Error deregistering from target group %s: %v", targetGroup.ARN, err)
+		}
+		if targetGroupDelay > delay {
+			delay = targetGroupDelay
+		}
+	}
+	if consul := server.globalConfiguration.ConsulRegistration; consul != nil {
+		consulDelay, err := consul.Deregister()
+		if err != nil {
+			log.Errorf("Error deregistering from Consul: %v", err)
+		}
+		if consulDelay > delay {
+			delay = consulDelay
+		}
+	}
+	if delay > 0 {
+		log.Infof("Deregistered, waiting %s for the connections to drain", delay)
+		time.Sleep(delay)
+	}
+}
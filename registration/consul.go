@@ -0,0 +1,149 @@
+package registration
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/hashicorp/consul/api"
+)
+
+// Consul registers the entrypoints of traefik as services of the local Consul agent
+type Consul struct {
+	Endpoint                       string `description:"Consul agent endpoint"`
+	Name                           string `description:"Name of the registered services"`
+	Address                        string `description:"Address of the registered services. Defaults to the address of the agent node"`
+	Check                          string `description:"Health check of the services: ttl, or http to check the ping endpoint of the web provider"`
+	CheckInterval                  string `description:"Interval of the health checks, and TTL of the ttl checks"`
+	DeregisterCriticalServiceAfter string `description:"Duration after which Consul deregisters services that stay critical, when traefik dies without deregistering them"`
+	DeregistrationDelay            int64  `description:"Seconds to keep serving after the deregistration, while the clients notice it"`
+	client                         *api.Client
+	serviceIDs                     []string
+	lock                           sync.Mutex
+}
+
+// Service is an entrypoint registered as a Consul service
+type Service struct {
+	EntryPoint string
+	Port       int
+	TLS        bool
+}
+
+func (c *Consul) checkInterval() time.Duration {
+	interval, err := time.ParseDuration(c.CheckInterval)
+	if err != nil || interval <= 0 {
+		return 10 * time.Second
+	}
+	return interval
+}
+
+func (c *Consul) serviceID(service Service) string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%s-%s-%d", c.Name, hostname, service.EntryPoint, service.Port)
+}
+
+// Register registers services in the Consul agent. pingURL is checked by the
+// http checks, it is ignored by the ttl checks.
+func (c *Consul) Register(services []Service, pingURL string) error {
+	if len(c.Name) == 0 {
+		return errors.New("Consul service name must be set")
+	}
+	if c.Check != "ttl" && c.Check != "http" {
+		return fmt.Errorf("Unknown Consul check %s, expected ttl or http", c.Check)
+	}
+	if c.Check == "http" && len(pingURL) == 0 {
+		return errors.New("Consul http checks require the web provider")
+	}
+	config := api.DefaultConfig()
+	if len(c.Endpoint) > 0 {
+		config.Address = c.Endpoint
+	}
+	client, err := api.NewClient(config)
+	if err != nil {
+		return err
+	}
+
+	serviceIDs := []string{}
+	for _, service := range services {
+		check := &api.AgentServiceCheck{DeregisterCriticalServiceAfter: c.DeregisterCriticalServiceAfter}
+		if c.Check == "http" {
+			check.HTTP = pingURL
+			check.Interval = c.checkInterval().String()
+		} else {
+			check.TTL = c.checkInterval().String()
+		}
+		tags := []string{service.EntryPoint, "http"}
+		if service.TLS {
+			tags[1] = "https"
+		}
+		registration := &api.AgentServiceRegistration{
+			ID:      c.serviceID(service),
+			Name:    c.Name,
+			Tags:    tags,
+			Port:    service.Port,
+			Address: c.Address,
+			Check:   check,
+		}
+		if err := client.Agent().ServiceRegister(registration); err != nil {
+			return err
+		}
+		log.Infof("Registered entrypoint %s as Consul service %s", service.EntryPoint, registration.ID)
+		serviceIDs = append(serviceIDs, registration.ID)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.client = client
+	c.serviceIDs = serviceIDs
+	return nil
+}
+
+func (c *Consul) registered() (*api.Client, []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.client, c.serviceIDs
+}
+
+// Run passes the ttl checks of the registered services until stop is closed.
+func (c *Consul) Run(stop chan bool) {
+	if c.Check != "ttl" {
+		return
+	}
+	ticker := time.NewTicker(c.checkInterval() / 2)
+	defer ticker.Stop()
+	for {
+		client, serviceIDs := c.registered()
+		for _, serviceID := range serviceIDs {
+			if err := client.Agent().UpdateTTL("service:"+serviceID, "traefik is running", api.HealthPassing); err != nil {
+				log.Errorf("Error passing the TTL check of Consul service %s: %v", serviceID, err)
+			}
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Deregister deregisters the registered services, and returns the delay to
+// keep serving while the clients notice it.
+func (c *Consul) Deregister() (time.Duration, error) {
+	c.lock.Lock()
+	client, serviceIDs := c.client, c.serviceIDs
+	c.serviceIDs = nil
+	c.lock.Unlock()
+	if client == nil {
+		return 0, errors.New("Services are not registered")
+	}
+	for _, serviceID := range serviceIDs {
+		if err := client.Agent().ServiceDeregister(serviceID); err != nil {
+			return 0, err
+		}
+		log.Infof("Deregistered Consul service %s", serviceID)
+	}
+	return time.Duration(c.DeregistrationDelay) * time.Second, nil
+}
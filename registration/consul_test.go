@@ -0,0 +1,104 @@
+package registration
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+type fakeAgent struct {
+	lock     sync.Mutex
+	requests []string
+	services []*api.AgentServiceRegistration
+}
+
+func (f *fakeAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	if r.URL.Path == "/v1/agent/service/register" {
+		body, _ := ioutil.ReadAll(r.Body)
+		service := &api.AgentServiceRegistration{}
+		json.Unmarshal(body, service)
+		f.services = append(f.services, service)
+	}
+}
+
+func TestConsulRegistration(t *testing.T) {
+	agent := &fakeAgent{}
+	ts := httptest.NewServer(agent)
+	defer ts.Close()
+
+	consul := &Consul{
+		Endpoint:            strings.TrimPrefix(ts.URL, "http://"),
+		Name:                "traefik",
+		Check:               "ttl",
+		CheckInterval:       "20ms",
+		DeregistrationDelay: 5,
+	}
+	err := consul.Register([]Service{{EntryPoint: "http", Port: 80}, {EntryPoint: "https", Port: 443, TLS: true}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan bool)
+	done := make(chan bool)
+	go func() {
+		consul.Run(stop)
+		close(done)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	close(stop)
+	<-done
+	delay, err := consul.Deregister()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delay != 5*time.Second {
+		t.Fatalf("expected a 5s delay, got %s", delay)
+	}
+	if _, err := consul.Deregister(); err != nil {
+		t.Fatal(err)
+	}
+
+	agent.lock.Lock()
+	defer agent.lock.Unlock()
+	if len(agent.services) != 2 {
+		t.Fatalf("expected 2 services, got %d", len(agent.services))
+	}
+	https := agent.services[1]
+	if https.Name != "traefik" || https.Port != 443 || !reflect.DeepEqual(https.Tags, []string{"https", "https"}) || https.Check.TTL != "20ms" {
+		t.Fatalf("unexpected https service %+v %+v", https, https.Check)
+	}
+	httpID := agent.services[0].ID
+	expected := map[string]bool{
+		"PUT /v1/agent/check/update/service:" + httpID: true,
+		"PUT /v1/agent/service/deregister/" + httpID:   true,
+	}
+	for _, request := range agent.requests {
+		delete(expected, request)
+	}
+	if len(expected) > 0 {
+		t.Fatalf("missing requests %v in %v", expected, agent.requests)
+	}
+}
+
+func TestConsulRegistrationErrors(t *testing.T) {
+	cases := []*Consul{
+		{Check: "ttl"},
+		{Name: "traefik", Check: "tcp"},
+		{Name: "traefik", Check: "http"},
+	}
+	for i, consul := range cases {
+		if err := consul.Register([]Service{{EntryPoint: "http", Port: 80}}, ""); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+}
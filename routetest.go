@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/types"
+)
+
+// stickyCookieName is the cookie used by the load-balancers for sticky sessions.
+const stickyCookieName = "_TRAEFIK_BACKEND"
+
+// RouteTest describes a synthetic request to run through the routing table.
+type RouteTest struct {
+	EntryPoint string            `json:"entryPoint,omitempty"`
+	Method     string            `json:"method,omitempty"`
+	Host       string            `json:"host"`
+	Path       string            `json:"path,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+}
+
+// RouteTestResult reports how an entrypoint would handle a synthetic request.
+type RouteTestResult struct {
+	EntryPoint string         `json:"entryPoint"`
+	Matched    bool           `json:"matched"`
+	Frontend   string         `json:"frontend,omitempty"`
+	Provider   string         `json:"provider,omitempty"`
+	Pipeline   []PipelineStep `json:"pipeline,omitempty"`
+	Backend    string         `json:"backend,omitempty"`
+	Server     string         `json:"server,omitempty"`
+	Servers    []string       `json:"servers,omitempty"`
+	Selection  string         `json:"selection,omitempty"`
+}
+
+// testRoute runs routeTest through the live routing table of the requested
+// entrypoint, or of all entrypoints, without forwarding it.
+func (server *Server) testRoute(routeTest *RouteTest) ([]*RouteTestResult, error) {
+	if len(routeTest.Method) == 0 {
+		routeTest.Method = "GET"
+	}
+	if len(routeTest.Path) == 0 {
+		routeTest.Path = "/"
+	}
+	if !strings.HasPrefix(routeTest.Path, "/") {
+		return nil, errors.New("Path must start with /")
+	}
+
+	entryPointNames := []string{}
+	if len(routeTest.EntryPoint) > 0 {
+		if _, ok := server.serverEntryPoints[routeTest.EntryPoint]; !ok {
+			return nil, errors.New("Unknown entrypoint " + routeTest.EntryPoint)
+		}
+		entryPointNames = append(entryPointNames, routeTest.EntryPoint)
+	} else {
+		for entryPointName := range server.serverEntryPoints {
+			entryPointNames = append(entryPointNames, entryPointName)
+		}
+		sort.Strings(entryPointNames)
+	}
+
+	results := []*RouteTestResult{}
+	for _, entryPointName := range entryPointNames {
+		request, err := http.NewRequest(routeTest.Method, "http://"+routeTest.Host+routeTest.Path, nil)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range routeTest.Headers {
+			request.Header.Set(name, value)
+		}
+		if server.globalConfiguration.EntryPoints[entryPointName].TLS != nil {
+			request.URL.Scheme = "https"
+		}
+		results = append(results, server.testEntryPointRoute(entryPointName, request))
+	}
+	return results, nil
+}
+
+func (server *Server) testEntryPointRoute(entryPointName string, request *http.Request) *RouteTestResult {
+	result := &RouteTestResult{EntryPoint: entryPointName}
+	router := server.serverEntryPoints[entryPointName].httpRouter.GetHandler()
+
+	// routes are named after their frontend, try them in the router order
+	candidates := frontendRoutes{}
+	for _, configuration := range server.currentConfigurations.Get().(configs) {
+		for frontendName := range configuration.Frontends {
+			if route := router.Get(frontendName); route != nil {
+				candidates = append(candidates, frontendRoute{name: frontendName, route: route})
+			}
+		}
+	}
+	sort.Sort(candidates)
+	for _, candidate := range candidates {
+		if candidate.route.Match(request, &mux.RouteMatch{}) {
+			result.Matched = true
+			result.Frontend = candidate.name
+			break
+		}
+	}
+	if !result.Matched {
+		return result
+	}
+
+	pipeline := server.getFrontendPipeline(result.Frontend)
+	if pipeline == nil {
+		return result
+	}
+	result.Provider = pipeline.Provider
+	result.Pipeline = pipeline.EntryPoints[entryPointName]
+	if server.globalConfiguration.EntryPoints[entryPointName].Redirect != nil {
+		result.Selection = "redirect"
+		return result
+	}
+
+	configuration := server.currentConfigurations.Get().(configs)[pipeline.Provider]
+	result.Backend = configuration.Frontends[result.Frontend].Backend
+	backend := configuration.Backends[result.Backend]
+	if backend == nil {
+		return result
+	}
+	selectServer(result, backend, request)
+	return result
+}
+
+// selectServer reports the server the load-balancer of backend would pick for
+// request, when it can be known without altering the load-balancer state.
+func selectServer(result *RouteTestResult, backend *types.Backend, request *http.Request) {
+	for _, server := range backend.Servers {
+		result.Servers = append(result.Servers, server.URL)
+	}
+	sort.Strings(result.Servers)
+
+	if backend.LoadBalancer != nil && backend.LoadBalancer.Sticky {
+		if cookie, err := request.Cookie(stickyCookieName); err == nil {
+			if stickyURL, err := url.Parse(cookie.Value); err == nil {
+				for _, server := range result.Servers {
+					if serverURL, err := url.Parse(server); err == nil && serverURL.String() == stickyURL.String() {
+						result.Server = server
+						result.Selection = "sticky session"
+						return
+					}
+				}
+			}
+		}
+	}
+
+	switch {
+	case len(result.Servers) == 0:
+		result.Selection = "no server"
+	case len(result.Servers) == 1:
+		result.Server = result.Servers[0]
+		result.Selection = "single server"
+	default:
+		lbMethod, _ := types.NewLoadBalancerMethod(backend.LoadBalancer)
+		if lbMethod == types.Drr {
+			result.Selection = "dynamic round robin"
+		} else {
+			result.Selection = "weighted round robin"
+		}
+	}
+}
+
+type frontendRoute struct {
+	name  string
+	route *mux.Route
+}
+
+type frontendRoutes []frontendRoute
+
+func (r frontendRoutes) Len() int      { return len(r) }
+func (r frontendRoutes) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r frontendRoutes) Less(i, j int) bool {
+	if r[i].route.GetPriority() == r[j].route.GetPriority() {
+		return r[i].name < r[j].name
+	}
+	return r[i].route.GetPriority() > r[j].route.GetPriority()
+}
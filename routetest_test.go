@@ -0,0 +1,160 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/containous/traefik/types"
+)
+
+func TestTestRoute(t *testing.T) {
+	globalConfiguration := GlobalConfiguration{
+		EntryPoints: EntryPoints{
+			"http":  &EntryPoint{Address: ":80", Redirect: &Redirect{EntryPoint: "https"}},
+			"https": &EntryPoint{Address: ":443"},
+		},
+	}
+	currentConfigurations := configs{
+		"file": &types.Configuration{
+			Backends: map[string]*types.Backend{
+				"backend1": {
+					Servers:      map[string]types.Server{"server1": {URL: "http://127.0.0.1:8080", Weight: 1}},
+					LoadBalancer: &types.LoadBalancer{Method: "wrr"},
+				},
+				"backend2": {
+					Servers:      map[string]types.Server{"server1": {URL: "http://127.0.0.1:8081", Weight: 1}, "server2": {URL: "http://127.0.0.1:8082", Weight: 1}},
+					LoadBalancer: &types.LoadBalancer{Method: "wrr", Sticky: true},
+				},
+			},
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {
+					EntryPoints: []string{"http", "https"},
+					Backend:     "backend1",
+					Routes:      map[string]types.Route{"route1": {Rule: "Host:test.localhost"}},
+				},
+				"frontend2": {
+					EntryPoints: []string{"https"},
+					Backend:     "backend2",
+					Routes:      map[string]types.Route{"route1": {Rule: "Host:test.localhost;PathPrefix:/api"}},
+				},
+			},
+		},
+	}
+	server := &Server{globalConfiguration: globalConfiguration}
+	serverEntryPoints, err := server.loadConfig(currentConfigurations, globalConfiguration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.serverEntryPoints = serverEntryPoints
+	server.currentConfigurations.Set(currentConfigurations)
+
+	if _, err := server.testRoute(&RouteTest{EntryPoint: "unknown", Host: "test.localhost"}); err == nil {
+		t.Error("expected an error for an unknown entrypoint")
+	}
+
+	results, err := server.testRoute(&RouteTest{Host: "test.localhost", Path: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected a result per entrypoint, got %d", len(results))
+	}
+	if http := results[0]; http.EntryPoint != "http" || http.Frontend != "frontend1" || http.Selection != "redirect" || http.Server != "" {
+		t.Errorf("expected frontend1 to redirect on entrypoint http, got %+v", http)
+	}
+	if https := results[1]; https.Frontend != "frontend1" || https.Backend != "backend1" || https.Server != "http://127.0.0.1:8080" || https.Selection != "single server" {
+		t.Errorf("expected frontend1 to forward to its single server on entrypoint https, got %+v", https)
+	}
+
+	results, err = server.testRoute(&RouteTest{
+		EntryPoint: "https",
+		Host:       "test.localhost",
+		Path:       "/api/users",
+		Headers:    map[string]string{"Cookie": stickyCookieName + "=http://127.0.0.1:8082"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &RouteTestResult{
+		EntryPoint: "https",
+		Matched:    true,
+		Frontend:   "frontend2",
+		Provider:   "file",
+		Pipeline:   server.getFrontendPipeline("frontend2").EntryPoints["https"],
+		Backend:    "backend2",
+		Server:     "http://127.0.0.1:8082",
+		Servers:    []string{"http://127.0.0.1:8081", "http://127.0.0.1:8082"},
+		Selection:  "sticky session",
+	}
+	if len(results) != 1 || !reflect.DeepEqual(results[0], expected) {
+		t.Errorf("expected %+v, got %+v", expected, results[0])
+	}
+
+	results, err = server.testRoute(&RouteTest{EntryPoint: "https", Host: "other.localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Matched {
+		t.Errorf("expected no frontend to match other.localhost, got %s", results[0].Frontend)
+	}
+}
+
+func TestTestRouteAutoRedirect(t *testing.T) {
+	globalConfiguration := GlobalConfiguration{
+		EntryPoints: EntryPoints{
+			"http":  &EntryPoint{Address: ":80", Redirect: &Redirect{EntryPoint: "https", Auto: true}},
+			"https": &EntryPoint{Address: ":443"},
+			"admin": &EntryPoint{Address: ":8443"},
+		},
+		DefaultEntryPoints: DefaultEntryPoints{"https"},
+	}
+	configuration := &types.Configuration{
+		Backends: map[string]*types.Backend{
+			"backend1": {
+				Servers:      map[string]types.Server{"server1": {URL: "http://127.0.0.1:8080", Weight: 1}},
+				LoadBalancer: &types.LoadBalancer{Method: "wrr"},
+			},
+		},
+		Frontends: map[string]*types.Frontend{
+			"frontend1": {
+				Backend: "backend1",
+				Routes:  map[string]types.Route{"route1": {Rule: "Host:test.localhost"}},
+			},
+			"frontend2": {
+				EntryPoints: []string{"admin"},
+				Backend:     "backend1",
+				Routes:      map[string]types.Route{"route1": {Rule: "Host:admin.localhost"}},
+			},
+		},
+	}
+	server := &Server{globalConfiguration: globalConfiguration}
+	server.defaultConfigurationValues(configuration)
+	if expected := []string{"https", "http"}; !reflect.DeepEqual(configuration.Frontends["frontend1"].EntryPoints, expected) {
+		t.Fatalf("expected the entrypoints %v for frontend1, got %v", expected, configuration.Frontends["frontend1"].EntryPoints)
+	}
+	if len(globalConfiguration.DefaultEntryPoints) != 1 {
+		t.Fatalf("the default entrypoints were modified: %v", globalConfiguration.DefaultEntryPoints)
+	}
+	currentConfigurations := configs{"file": configuration}
+	serverEntryPoints, err := server.loadConfig(currentConfigurations, globalConfiguration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.serverEntryPoints = serverEntryPoints
+	server.currentConfigurations.Set(currentConfigurations)
+
+	results, err := server.testRoute(&RouteTest{EntryPoint: "http", Host: "test.localhost", Path: "/blog?page=2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if http := results[0]; http.Frontend != "frontend1" || http.Selection != "redirect" {
+		t.Errorf("expected frontend1 to redirect on entrypoint http, got %+v", http)
+	}
+	results, err = server.testRoute(&RouteTest{EntryPoint: "http", Host: "admin.localhost", Path: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if http := results[0]; http.Matched {
+		t.Errorf("expected no redirect of frontend2 on entrypoint http, got %+v", http)
+	}
+}
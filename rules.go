@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/BurntSushi/ty/fun"
 	"github.com/containous/mux"
+	"github.com/containous/traefik/middlewares"
 	"github.com/containous/traefik/types"
 	"net"
 	"net/http"
@@ -96,17 +97,72 @@ func (r *Rules) headersRegexp(headers ...string) *mux.Route {
 	return r.route.route.HeadersRegexp(headers...)
 }
 
+func (r *Rules) grpcService(services ...string) *mux.Route {
+	return r.route.route.MatcherFunc(func(req *http.Request, route *mux.RouteMatch) bool {
+		service, _, ok := grpcMethod(req)
+		return ok && fun.In(service, services)
+	})
+}
+
+func (r *Rules) grpcMethod(methods ...string) *mux.Route {
+	return r.route.route.MatcherFunc(func(req *http.Request, route *mux.RouteMatch) bool {
+		service, method, ok := grpcMethod(req)
+		return ok && fun.In(service+"/"+method, methods)
+	})
+}
+
+// grpcMethod returns the service and the method called by the gRPC request
+// req, from its /package.Service/Method path, and false if req isn't gRPC.
+func grpcMethod(req *http.Request) (string, string, bool) {
+	if contentType := req.Header.Get("Content-Type"); contentType != "application/grpc" && !strings.HasPrefix(contentType, "application/grpc+") && !strings.HasPrefix(contentType, "application/grpc;") {
+		return "", "", false
+	}
+	parts := strings.Split(req.URL.Path, "/")
+	if len(parts) != 3 || len(parts[0]) > 0 || len(parts[1]) == 0 || len(parts[2]) == 0 {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+func (r *Rules) graphQLOperation(names ...string) *mux.Route {
+	return r.route.route.MatcherFunc(func(req *http.Request, route *mux.RouteMatch) bool {
+		operations, _ := middlewares.GetGraphQLOperations(req)
+		for _, operation := range operations {
+			if fun.In(operation.Name, names) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *Rules) graphQLType(operationTypes ...string) *mux.Route {
+	return r.route.route.MatcherFunc(func(req *http.Request, route *mux.RouteMatch) bool {
+		operations, _ := middlewares.GetGraphQLOperations(req)
+		for _, operation := range operations {
+			if fun.In(operation.Type, operationTypes) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
 func (r *Rules) parseRules(expression string, onRule func(functionName string, function interface{}, arguments []string) error) error {
 	functions := map[string]interface{}{
-		"Host":            r.host,
-		"HostRegexp":      r.hostRegexp,
-		"Path":            r.path,
-		"PathStrip":       r.pathStrip,
-		"PathPrefix":      r.pathPrefix,
-		"PathPrefixStrip": r.pathPrefixStrip,
-		"Method":          r.methods,
-		"Headers":         r.headers,
-		"HeadersRegexp":   r.headersRegexp,
+		"Host":             r.host,
+		"HostRegexp":       r.hostRegexp,
+		"Path":             r.path,
+		"PathStrip":        r.pathStrip,
+		"PathPrefix":       r.pathPrefix,
+		"PathPrefixStrip":  r.pathPrefixStrip,
+		"Method":           r.methods,
+		"Headers":          r.headers,
+		"HeadersRegexp":    r.headersRegexp,
+		"GRPCService":      r.grpcService,
+		"GRPCMethod":       r.grpcMethod,
+		"GraphQLOperation": r.graphQLOperation,
+		"GraphQLType":      r.graphQLType,
 	}
 
 	if len(expression) == 0 {
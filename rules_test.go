@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -85,6 +86,64 @@ func TestParseDomains(t *testing.T) {
 	}
 }
 
+func TestGRPCRules(t *testing.T) {
+	cases := []struct {
+		expression  string
+		contentType string
+		path        string
+		expected    bool
+	}{
+		{"GRPCService: helloworld.Greeter", "application/grpc", "/helloworld.Greeter/SayHello", true},
+		{"GRPCService: helloworld.Greeter", "application/grpc+proto", "/helloworld.Greeter/SayGoodbye", true},
+		{"GRPCService: helloworld.Greeter", "application/json", "/helloworld.Greeter/SayHello", false},
+		{"GRPCService: helloworld.Greeter", "application/grpc", "/helloworld.Greeter", false},
+		{"GRPCService: routeguide.RouteGuide, helloworld.Greeter", "application/grpc", "/helloworld.Greeter/SayHello", true},
+		{"GRPCMethod: helloworld.Greeter/SayHello", "application/grpc", "/helloworld.Greeter/SayHello", true},
+		{"GRPCMethod: helloworld.Greeter/SayHello", "application/grpc", "/helloworld.Greeter/SayGoodbye", false},
+		{"GRPCMethod: helloworld.Greeter/SayHello", "application/grpc", "/helloworld.Greeter/SayHello/more", false},
+		{"Host: foo.bar; GRPCMethod: helloworld.Greeter/SayHello", "application/grpc", "/helloworld.Greeter/SayHello", true},
+	}
+	for _, c := range cases {
+		rules := &Rules{route: &serverRoute{route: mux.NewRouter().NewRoute()}}
+		route, err := rules.Parse(c.expression)
+		if err != nil {
+			t.Fatalf("Error while building route for %s: %v", c.expression, err)
+		}
+		request, _ := http.NewRequest("POST", "http://foo.bar"+c.path, nil)
+		request.Header.Set("Content-Type", c.contentType)
+		if match := route.Match(request, &mux.RouteMatch{Route: route}); match != c.expected {
+			t.Errorf("%s with %s %s: expected match %v", c.expression, c.contentType, c.path, c.expected)
+		}
+	}
+}
+
+func TestGraphQLRules(t *testing.T) {
+	cases := []struct {
+		expression string
+		query      string
+		expected   bool
+	}{
+		{"GraphQLOperation: GetUser, ListUsers", "query GetUser { user { id } }", true},
+		{"GraphQLOperation: GetUser", "query GetOrder { order { id } }", false},
+		{"GraphQLOperation: GetUser", "{ user { id } }", false},
+		{"GraphQLType: mutation", "mutation Save { save }", true},
+		{"GraphQLType: mutation", "query GetUser { user { id } }", false},
+		{"GraphQLType: mutation", "mutation Save { save", false},
+	}
+	for _, c := range cases {
+		rules := &Rules{route: &serverRoute{route: mux.NewRouter().NewRoute()}}
+		route, err := rules.Parse(c.expression)
+		if err != nil {
+			t.Fatalf("Error while building route for %s: %v", c.expression, err)
+		}
+		request, _ := http.NewRequest("POST", "http://foo.bar/graphql", strings.NewReader(`{"query": "`+c.query+`"}`))
+		request.Header.Set("Content-Type", "application/json")
+		if match := route.Match(request, &mux.RouteMatch{Route: route}); match != c.expected {
+			t.Errorf("%s with %s: expected match %v", c.expression, c.query, c.expected)
+		}
+	}
+}
+
 func TestPriorites(t *testing.T) {
 	router := mux.NewRouter()
 	router.StrictSlash(true)
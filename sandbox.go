@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/types"
+)
+
+// listenFDsStart is the first file descriptor passed with the systemd socket
+// activation protocol.
+const listenFDsStart = 3
+
+var (
+	activatedListenersOnce sync.Once
+	activatedListeners     map[string]net.Listener
+	activatedListenersLock sync.Mutex
+)
+
+// checkSandbox returns an error if the sandbox denies what the configuration
+// needs: the plugin spawned by traefik is started with execve, denied by the
+// seccomp filter.
+func checkSandbox(sandbox *types.Sandbox, plugin *provider.Plugin) error {
+	if sandbox.Seccomp && plugin != nil && len(plugin.Command) > 0 {
+		return errors.New("the seccomp filter denies spawning the plugin command, set the plugin address instead")
+	}
+	return nil
+}
+
+// parseListenFDs returns the file descriptors passed with the systemd socket
+// activation protocol, by name: systemd sets LISTEN_PID to the pid of the
+// process, the sandbox of traefik passes its sockets to the process it runs
+// as the user without it.
+func parseListenFDs(getenv func(string) string, pid int) (map[string]int, error) {
+	if listenPID := getenv("LISTEN_PID"); len(listenPID) > 0 && listenPID != strconv.Itoa(pid) {
+		return nil, nil
+	}
+	listenFDs := getenv("LISTEN_FDS")
+	if len(listenFDs) == 0 {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(listenFDs)
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", listenFDs)
+	}
+	var names []string
+	if listenFDNames := getenv("LISTEN_FDNAMES"); len(listenFDNames) > 0 {
+		names = strings.Split(listenFDNames, ":")
+	}
+	if len(names) != count {
+		return nil, fmt.Errorf("LISTEN_FDNAMES has %d names for %d sockets, the sockets must be named like their entrypoint", len(names), count)
+	}
+	fds := map[string]int{}
+	for i, name := range names {
+		fds[name] = listenFDsStart + i
+	}
+	return fds, nil
+}
+
+// loadActivatedListeners creates the listeners of the sockets passed with
+// the systemd socket activation protocol, and unsets its variables so that
+// the processes started by traefik don't inherit them.
+func loadActivatedListeners() {
+	fds, err := parseListenFDs(os.Getenv, os.Getpid())
+	for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		os.Unsetenv(name)
+	}
+	if err != nil {
+		log.Errorf("Error loading the activated sockets: %v", err)
+		return
+	}
+	activatedListeners = map[string]net.Listener{}
+	for name, fd := range fds {
+		file := os.NewFile(uintptr(fd), name)
+		listener, err := net.FileListener(file)
+		// the listener holds a duplicate of the descriptor
+		file.Close()
+		if err != nil {
+			log.Errorf("Error loading the activated socket %s: %v", name, err)
+			continue
+		}
+		activatedListeners[name] = listener
+	}
+}
+
+// listenEntryPoint returns the listener of the entrypoint name: the socket
+// passed by systemd or by the sandbox under its name, or a new one bound to
+// address otherwise.
+func listenEntryPoint(name string, address string) (net.Listener, error) {
+	activatedListenersOnce.Do(loadActivatedListeners)
+	activatedListenersLock.Lock()
+	listener, ok := activatedListeners[name]
+	delete(activatedListeners, name)
+	activatedListenersLock.Unlock()
+	if ok {
+		log.Infof("Using the activated socket %s for entrypoint %s", listener.Addr(), name)
+	} else {
+		var err error
+		if listener, err = net.Listen("tcp", address); err != nil {
+			return nil, err
+		}
+	}
+	if tcpListener, ok := listener.(*net.TCPListener); ok {
+		return tcpKeepAliveListener{tcpListener}, nil
+	}
+	return listener, nil
+}
+
+// tcpKeepAliveListener sets TCP keep-alive timeouts on accepted connections,
+// as net/http does for the listeners it binds.
+type tcpKeepAliveListener struct {
+	*net.TCPListener
+}
+
+func (l tcpKeepAliveListener) Accept() (net.Conn, error) {
+	conn, err := l.AcceptTCP()
+	if err != nil {
+		return nil, err
+	}
+	conn.SetKeepAlive(true)
+	conn.SetKeepAlivePeriod(3 * time.Minute)
+	return conn, nil
+}
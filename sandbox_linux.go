@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"os/user"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	bpfLdWAbs = 0x20
+	bpfJeqK   = 0x15
+	bpfJgeK   = 0x35
+	bpfRetK   = 0x06
+
+	seccompRetKill  = 0x00000000
+	seccompRetErrno = 0x00050000
+	seccompRetAllow = 0x7fff0000
+
+	seccompSetModeFilter   = 1
+	seccompFilterFlagTsync = 1
+	prSetNoNewPrivs        = 38
+
+	// the system calls of the x32 ABI of amd64 have this bit set
+	x32SyscallBit = 0x40000000
+)
+
+// seccompArchs holds the audit architecture and the number of the seccomp
+// system call of the architectures supported by the seccomp filter.
+var seccompArchs = map[string]struct {
+	audit   uint32
+	seccomp uintptr
+}{
+	"386":   {0x40000003, 354},
+	"amd64": {0xc000003e, 317},
+	"arm":   {0x40000028, 383},
+	"arm64": {0xc00000b7, 277},
+}
+
+// seccompDenied are the system calls denied by the seccomp filter, which
+// traefik doesn't need once started: they only serve an attacker taking over
+// the process. checkSandbox refuses the plugin commands, spawned with execve.
+var seccompDenied = []uintptr{
+	syscall.SYS_EXECVE,
+	syscall.SYS_PTRACE,
+	syscall.SYS_MOUNT,
+	syscall.SYS_UMOUNT2,
+	syscall.SYS_PIVOT_ROOT,
+	syscall.SYS_CHROOT,
+	syscall.SYS_REBOOT,
+	syscall.SYS_KEXEC_LOAD,
+	syscall.SYS_INIT_MODULE,
+	syscall.SYS_DELETE_MODULE,
+	syscall.SYS_SWAPON,
+	syscall.SYS_SWAPOFF,
+}
+
+type sockFilter struct {
+	code uint16
+	jt   uint8
+	jf   uint8
+	k    uint32
+}
+
+type sockFprog struct {
+	len    uint16
+	filter *sockFilter
+}
+
+// runAsUser binds the entrypoints, and runs traefik again as the user of
+// sandbox with their sockets, since Go can't change the user of a running
+// process. It forwards the signals to the process, and returns its exit code
+// once it exits.
+func runAsUser(sandbox *types.Sandbox, entryPoints map[string]*EntryPoint) (int, error) {
+	credential, err := sandboxCredential(sandbox)
+	if err != nil {
+		return 0, err
+	}
+
+	var names []string
+	for name := range entryPoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var files []*os.File
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	for _, name := range names {
+		listener, err := listenEntryPoint(name, entryPoints[name].Address)
+		if err != nil {
+			return 0, fmt.Errorf("error binding entrypoint %s: %v", name, err)
+		}
+		filer, ok := listener.(interface {
+			File() (*os.File, error)
+		})
+		if !ok {
+			listener.Close()
+			return 0, fmt.Errorf("the socket of entrypoint %s can't be passed", name)
+		}
+		file, err := filer.File()
+		// the file holds a duplicate of the socket
+		listener.Close()
+		if err != nil {
+			return 0, err
+		}
+		files = append(files, file)
+	}
+
+	// the binary is re-executed even if it was replaced or removed since the start
+	cmd := exec.Command("/proc/self/exe", os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(), "LISTEN_FDS="+strconv.Itoa(len(files)), "LISTEN_FDNAMES="+strings.Join(names, ":"))
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: credential, Pdeathsig: syscall.SIGTERM}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	log.Infof("Running as user %s, uid %d and gid %d, with pid %d", sandbox.User, credential.Uid, credential.Gid, cmd.Process.Pid)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			cmd.Process.Signal(sig)
+		}
+	}()
+	err = cmd.Wait()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus(), nil
+		}
+	}
+	return 0, err
+}
+
+// sandboxCredential returns the credential of the user and group of sandbox,
+// without supplementary groups.
+func sandboxCredential(sandbox *types.Sandbox) (*syscall.Credential, error) {
+	sandboxUser, err := user.Lookup(sandbox.User)
+	if err != nil {
+		if sandboxUser, err = user.LookupId(sandbox.User); err != nil {
+			return nil, fmt.Errorf("unknown user %s", sandbox.User)
+		}
+	}
+	gid := sandboxUser.Gid
+	if len(sandbox.Group) > 0 {
+		group, err := user.LookupGroup(sandbox.Group)
+		if err != nil {
+			if group, err = user.LookupGroupId(sandbox.Group); err != nil {
+				return nil, fmt.Errorf("unknown group %s", sandbox.Group)
+			}
+		}
+		gid = group.Gid
+	}
+	uidValue, err := strconv.ParseUint(sandboxUser.Uid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	gidValue, err := strconv.ParseUint(gid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	return &syscall.Credential{Uid: uint32(uidValue), Gid: uint32(gidValue), Groups: []uint32{}}, nil
+}
+
+// applySeccomp denies the system calls of seccompDenied to all the threads
+// of traefik, and to the processes it would start.
+func applySeccomp() error {
+	arch, ok := seccompArchs[runtime.GOARCH]
+	if !ok {
+		return fmt.Errorf("seccomp isn't supported on %s", runtime.GOARCH)
+	}
+	filter := seccompFilter(arch.audit, seccompDenied)
+	prog := sockFprog{len: uint16(len(filter)), filter: &filter[0]}
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+		return errno
+	}
+	result, _, errno := syscall.RawSyscall(arch.seccomp, seccompSetModeFilter, seccompFilterFlagTsync, uintptr(unsafe.Pointer(&prog)))
+	if errno != 0 {
+		return errno
+	}
+	if result != 0 {
+		return fmt.Errorf("thread %d can't be filtered", result)
+	}
+	return nil
+}
+
+// seccompFilter returns the BPF program killing the process on another
+// architecture than auditArch, and failing the system calls of denied, and
+// those of the x32 ABI, with EPERM.
+func seccompFilter(auditArch uint32, denied []uintptr) []sockFilter {
+	count := len(denied)
+	filter := []sockFilter{
+		// seccomp_data.arch
+		{code: bpfLdWAbs, k: 4},
+		{code: bpfJeqK, jt: 1, k: auditArch},
+		{code: bpfRetK, k: seccompRetKill},
+		// seccomp_data.nr
+		{code: bpfLdWAbs, k: 0},
+		{code: bpfJgeK, jt: uint8(count + 1), k: x32SyscallBit},
+	}
+	for i, nr := range denied {
+		filter = append(filter, sockFilter{code: bpfJeqK, jt: uint8(count - i), k: uint32(nr)})
+	}
+	return append(filter,
+		sockFilter{code: bpfRetK, k: seccompRetAllow},
+		sockFilter{code: bpfRetK, k: seccompRetErrno | uint32(syscall.EPERM)})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeccompFilter(t *testing.T) {
+	filter := seccompFilter(0xc000003e, []uintptr{59, 101})
+	assert.Len(t, filter, 9)
+	// the x32 system calls and the denied ones jump to the last instruction, returning EPERM
+	for i := 4; i < 7; i++ {
+		assert.Equal(t, len(filter)-1, i+1+int(filter[i].jt), "instruction %d", i)
+	}
+	assert.Equal(t, sockFilter{code: bpfRetK, k: seccompRetAllow}, filter[7])
+	assert.Equal(t, sockFilter{code: bpfRetK, k: seccompRetErrno | uint32(syscall.EPERM)}, filter[8])
+}
+
+func TestSandboxCredential(t *testing.T) {
+	credential, err := sandboxCredential(&types.Sandbox{User: "root"})
+	assert.NoError(t, err)
+	assert.Equal(t, &syscall.Credential{Uid: 0, Gid: 0, Groups: []uint32{}}, credential)
+
+	credential, err = sandboxCredential(&types.Sandbox{User: "0", Group: "0"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), credential.Uid)
+
+	_, err = sandboxCredential(&types.Sandbox{User: "traefik-unknown-user"})
+	assert.Error(t, err)
+	_, err = sandboxCredential(&types.Sandbox{User: "root", Group: "traefik-unknown-group"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,17 @@
+// +build !linux
+
+package main
+
+import (
+	"errors"
+
+	"github.com/containous/traefik/types"
+)
+
+func runAsUser(sandbox *types.Sandbox, entryPoints map[string]*EntryPoint) (int, error) {
+	return 0, errors.New("running as another user is only supported on Linux")
+}
+
+func applySeccomp() error {
+	return errors.New("seccomp is only supported on Linux")
+}
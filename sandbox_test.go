@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseListenFDs(t *testing.T) {
+	env := func(values map[string]string) func(string) string {
+		return func(name string) string { return values[name] }
+	}
+
+	fds, err := parseListenFDs(env(map[string]string{"LISTEN_PID": "42", "LISTEN_FDS": "2", "LISTEN_FDNAMES": "http:https"}), 42)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"http": 3, "https": 4}, fds)
+
+	// passed by the sandbox, without pid
+	fds, err = parseListenFDs(env(map[string]string{"LISTEN_FDS": "1", "LISTEN_FDNAMES": "http"}), 42)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"http": 3}, fds)
+
+	// passed to another process
+	fds, err = parseListenFDs(env(map[string]string{"LISTEN_PID": "41", "LISTEN_FDS": "1", "LISTEN_FDNAMES": "http"}), 42)
+	assert.NoError(t, err)
+	assert.Nil(t, fds)
+
+	fds, err = parseListenFDs(env(map[string]string{}), 42)
+	assert.NoError(t, err)
+	assert.Nil(t, fds)
+
+	_, err = parseListenFDs(env(map[string]string{"LISTEN_FDS": "2", "LISTEN_FDNAMES": "http"}), 42)
+	assert.Error(t, err)
+	_, err = parseListenFDs(env(map[string]string{"LISTEN_FDS": "two"}), 42)
+	assert.Error(t, err)
+}
+
+func TestListenEntryPointActivated(t *testing.T) {
+	activated, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	activatedListenersOnce.Do(func() {})
+	activatedListenersLock.Lock()
+	activatedListeners = map[string]net.Listener{"activated": activated}
+	activatedListenersLock.Unlock()
+
+	listener, err := listenEntryPoint("activated", "127.0.0.1:0")
+	assert.NoError(t, err)
+	assert.Equal(t, activated.Addr(), listener.Addr())
+	listener.Close()
+
+	// an activated socket is used once, then the entrypoint binds its address
+	listener, err = listenEntryPoint("activated", "127.0.0.1:0")
+	assert.NoError(t, err)
+	assert.NotEqual(t, activated.Addr(), listener.Addr())
+	listener.Close()
+}
+
+func TestCheckSandbox(t *testing.T) {
+	plugin := &provider.Plugin{Command: "/usr/local/bin/traefik-registry-plugin"}
+	assert.Error(t, checkSandbox(&types.Sandbox{Seccomp: true}, plugin))
+	assert.NoError(t, checkSandbox(&types.Sandbox{}, plugin))
+	assert.NoError(t, checkSandbox(&types.Sandbox{Seccomp: true}, &provider.Plugin{Address: "127.0.0.1:4242"}))
+	assert.NoError(t, checkSandbox(&types.Sandbox{Seccomp: true}, nil))
+}
@@ -9,7 +9,9 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -23,13 +25,14 @@ import (
 	"github.com/codegangsta/negroni"
 	"github.com/containous/mux"
 	"github.com/containous/traefik/cluster"
+	"github.com/containous/traefik/externaldns"
+	"github.com/containous/traefik/featureflags"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/middlewares"
 	"github.com/containous/traefik/provider"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	"github.com/mailgun/manners"
-	"github.com/streamrail/concurrent-map"
 	"github.com/vulcand/oxy/cbreaker"
 	"github.com/vulcand/oxy/connlimit"
 	"github.com/vulcand/oxy/forward"
@@ -52,6 +55,20 @@ type Server struct {
 	loggerMiddleware           *middlewares.Logger
 	routinesPool               *safe.Pool
 	leadership                 *cluster.Leadership
+	dnsPublisher               *externaldns.Publisher
+	realIP                     *middlewares.RealIP
+	featureFlags               *featureflags.Flags
+	usageRecorder              *UsageRecorder
+	configurationCache         *configurationCache
+	configurationFreeze        *configurationFreeze
+	configurationHistory       *configurationHistory
+	dryRunConfigurations       *dryRunConfigurations
+	rollbackChan               chan *configurationRollback
+	certificateStores          map[string]*certificateStore
+	defaultCertificate         *tls.Certificate
+	sessionTickets             *sessionTickets
+	forwardProxies             map[string]*middlewares.ForwardProxy
+	providersThrottle          *providersThrottle
 }
 
 type serverEntryPoints map[string]*serverEntryPoint
@@ -71,21 +88,103 @@ func NewServer(globalConfiguration GlobalConfiguration) *Server {
 	server := new(Server)
 
 	server.serverEntryPoints = make(map[string]*serverEntryPoint)
+	server.certificateStores = make(map[string]*certificateStore)
+	server.forwardProxies = make(map[string]*middlewares.ForwardProxy)
 	server.configurationChan = make(chan types.ConfigMessage, 100)
 	server.configurationValidatedChan = make(chan types.ConfigMessage, 100)
 	server.signals = make(chan os.Signal, 1)
 	server.stopChan = make(chan bool, 1)
 	server.providers = []provider.Provider{}
 	signal.Notify(server.signals, syscall.SIGINT, syscall.SIGTERM)
+	notifyShutdown(server.signals)
 	currentConfigurations := make(configs)
 	server.currentConfigurations.Set(currentConfigurations)
+	server.dryRunConfigurations = newDryRunConfigurations()
 	server.globalConfiguration = globalConfiguration
 	server.loggerMiddleware = middlewares.NewLogger(globalConfiguration.AccessLogsFile)
 	server.routinesPool = safe.NewPool(context.Background())
+	server.providersThrottle = newProvidersThrottle(globalConfiguration.ProvidersThrottleDuration)
 	if globalConfiguration.Cluster != nil {
 		// leadership creation if cluster mode
 		server.leadership = cluster.NewLeadership(server.routinesPool.Ctx(), globalConfiguration.Cluster)
 	}
+	if globalConfiguration.DNS != nil {
+		dnsPublisher, err := externaldns.NewPublisher(globalConfiguration.DNS)
+		if err != nil {
+			log.Fatal("Error creating DNS publisher: ", err)
+		}
+		server.dnsPublisher = dnsPublisher
+	}
+	if globalConfiguration.RealIP != nil {
+		realIP, err := middlewares.NewRealIP(globalConfiguration.RealIP)
+		if err != nil {
+			log.Fatal("Error creating real IP middleware: ", err)
+		}
+		server.realIP = realIP
+	}
+	if globalConfiguration.InternalCA != nil {
+		var err error
+		if server.leadership == nil {
+			err = globalConfiguration.InternalCA.CreateLocalConfig()
+		} else {
+			err = globalConfiguration.InternalCA.CreateClusterConfig(server.leadership)
+		}
+		if err != nil {
+			log.Fatal("Error creating internal CA: ", err)
+		}
+	}
+	if globalConfiguration.DefaultCertificate != nil {
+		defaultCertificate, err := loadDefaultCertificate(globalConfiguration.DefaultCertificate)
+		if err != nil {
+			log.Fatal("Error creating default certificate: ", err)
+		}
+		server.defaultCertificate = defaultCertificate
+		if globalConfiguration.ACME != nil {
+			globalConfiguration.ACME.SetDefaultCertificate(defaultCertificate)
+		}
+	}
+	if globalConfiguration.SessionTickets != nil {
+		sessionTickets, err := newSessionTickets(globalConfiguration.SessionTickets)
+		if err == nil {
+			if server.leadership == nil {
+				err = sessionTickets.createLocalConfig()
+			} else {
+				err = sessionTickets.createClusterConfig(server.leadership)
+			}
+		}
+		if err != nil {
+			log.Fatal("Error creating session ticket keys: ", err)
+		}
+		server.sessionTickets = sessionTickets
+	}
+	if globalConfiguration.FeatureFlags != nil {
+		featureFlags, err := featureflags.New(globalConfiguration.FeatureFlags)
+		if err != nil {
+			log.Fatal("Error creating feature flags: ", err)
+		}
+		server.featureFlags = featureFlags
+	}
+	if globalConfiguration.Usage != nil {
+		usageRecorder, err := NewUsageRecorder(globalConfiguration.Usage)
+		if err != nil {
+			log.Fatal("Error creating usage recorder: ", err)
+		}
+		server.usageRecorder = usageRecorder
+	}
+	if globalConfiguration.ConfigurationCache != nil {
+		server.configurationCache = newConfigurationCache(globalConfiguration.ConfigurationCache)
+	}
+	if globalConfiguration.ConfigurationHistory != nil {
+		server.configurationHistory = newConfigurationHistory(globalConfiguration.ConfigurationHistory)
+		server.rollbackChan = make(chan *configurationRollback)
+	}
+	if globalConfiguration.ConfigurationFreeze != nil {
+		configurationFreeze, err := newConfigurationFreeze(globalConfiguration.ConfigurationFreeze, server.featureFlags)
+		if err != nil {
+			log.Fatal("Error creating configuration freeze: ", err)
+		}
+		server.configurationFreeze = configurationFreeze
+	}
 
 	return server
 }
@@ -93,6 +192,11 @@ func NewServer(globalConfiguration GlobalConfiguration) *Server {
 // Start starts the server.
 func (server *Server) Start() {
 	server.startHTTPServers()
+	if server.fipsEnabled() {
+		server.logFIPSViolations()
+	}
+	server.registerTargetGroup()
+	server.registerConsul()
 	server.startLeadership()
 	server.routinesPool.Go(func(stop chan bool) {
 		server.listenProviders(stop)
@@ -100,7 +204,76 @@ func (server *Server) Start() {
 	server.routinesPool.Go(func(stop chan bool) {
 		server.listenConfigurations(stop)
 	})
+	if server.dnsPublisher != nil {
+		server.routinesPool.Go(func(stop chan bool) {
+			server.dnsPublisher.Run(stop)
+		})
+	}
+	if server.sessionTickets != nil {
+		server.routinesPool.Go(func(stop chan bool) {
+			server.sessionTickets.run(stop, server.leadership)
+		})
+	}
+	if server.realIP != nil {
+		refreshInterval := time.Duration(server.globalConfiguration.RealIP.RefreshInterval) * time.Second
+		if refreshInterval <= 0 {
+			refreshInterval = 24 * time.Hour
+		}
+		server.routinesPool.Go(func(stop chan bool) {
+			server.realIP.Run(stop, refreshInterval)
+		})
+	}
+	if server.featureFlags != nil {
+		pollInterval := time.Duration(server.globalConfiguration.FeatureFlags.PollInterval) * time.Second
+		if pollInterval <= 0 {
+			pollInterval = 10 * time.Second
+		}
+		server.routinesPool.Go(func(stop chan bool) {
+			server.featureFlags.Run(stop, pollInterval)
+		})
+	}
+	server.routinesPool.Go(func(stop chan bool) {
+		trafficCapture.Run(stop)
+	})
+	server.routinesPool.Go(func(stop chan bool) {
+		unixSockets.Run(stop, unixSocketCheckInterval)
+	})
+	for _, store := range server.certificateStores {
+		store := store
+		server.routinesPool.Go(func(stop chan bool) {
+			store.Run(stop, certificatesCheckInterval)
+		})
+	}
+	if server.globalConfiguration.CertificateExpiry != nil {
+		checkInterval := time.Duration(server.globalConfiguration.CertificateExpiry.CheckInterval) * time.Second
+		if checkInterval <= 0 {
+			checkInterval = time.Hour
+		}
+		scanner := newCertificateExpiryScanner(server.globalConfiguration.CertificateExpiry, server.getCertificates)
+		server.routinesPool.Go(func(stop chan bool) {
+			scanner.Run(stop, checkInterval)
+		})
+	}
+	if server.usageRecorder != nil {
+		flushInterval := time.Duration(server.globalConfiguration.Usage.FlushInterval) * time.Second
+		if flushInterval <= 0 {
+			flushInterval = time.Minute
+		}
+		server.routinesPool.Go(func(stop chan bool) {
+			server.usageRecorder.Run(stop, flushInterval)
+		})
+	}
+	if server.configurationFreeze != nil {
+		server.routinesPool.Go(func(stop chan bool) {
+			server.configurationFreeze.Run(stop, server.configurationValidatedChan)
+		})
+	}
 	server.configureProviders()
+	if server.configurationCache != nil {
+		server.routinesPool.Go(func(stop chan bool) {
+			server.configurationCache.boot(server, stop)
+		})
+	}
 	server.startProviders()
 	go server.listenSignals()
 }
@@ -112,6 +285,7 @@ func (server *Server) Wait() {
 
 // Stop stops the server
 func (server *Server) Stop() {
+	server.deregister()
 	for serverEntryPointName, serverEntryPoint := range server.serverEntryPoints {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(server.globalConfiguration.GraceTimeOut)*time.Second)
 		go func() {
@@ -140,6 +314,7 @@ func (server *Server) Close() {
 	server.routinesPool.Cleanup()
 	close(server.configurationChan)
 	close(server.configurationValidatedChan)
+	stopShutdown(server.signals)
 	signal.Stop(server.signals)
 	close(server.signals)
 	close(server.stopChan)
@@ -166,16 +341,68 @@ func (server *Server) stopLeadership() {
 
 func (server *Server) startHTTPServers() {
 	server.serverEntryPoints = server.buildEntryPoints(server.globalConfiguration)
+	if acme := server.globalConfiguration.ACME; acme != nil && acme.HTTPChallenge != nil {
+		if _, ok := server.serverEntryPoints[acme.HTTPChallenge.EntryPoint]; !ok {
+			log.Fatal("Unknown entrypoint " + acme.HTTPChallenge.EntryPoint + " for ACME HTTP challenge")
+		}
+	}
 	for newServerEntryPointName, newServerEntryPoint := range server.serverEntryPoints {
-		serverMiddlewares := []negroni.Handler{server.loggerMiddleware, metrics}
+		serverMiddlewares := []negroni.Handler{}
+		if server.realIP != nil {
+			// restore the client IP before it is logged
+			serverMiddlewares = append(serverMiddlewares, server.realIP)
+		}
+		serverMiddlewares = append(serverMiddlewares, server.loggerMiddleware, errorRecorder, metrics)
+		if server.globalConfiguration.EntryPoints[newServerEntryPointName].TLS != nil {
+			serverMiddlewares = append(serverMiddlewares, tlsClients.Handler(newServerEntryPointName))
+		}
+		if server.usageRecorder != nil {
+			serverMiddlewares = append(serverMiddlewares, server.usageRecorder)
+		}
 		if server.globalConfiguration.Web != nil && server.globalConfiguration.Web.Statistics != nil {
 			statsRecorder = &StatsRecorder{
 				numRecentErrors: server.globalConfiguration.Web.Statistics.RecentErrors,
 			}
 			serverMiddlewares = append(serverMiddlewares, statsRecorder)
 		}
+		if forwardProxyConfig := server.globalConfiguration.EntryPoints[newServerEntryPointName].ForwardProxy; forwardProxyConfig != nil {
+			// the proxied requests are not routed, nor authenticated by the entrypoint
+			forwardProxy, err := middlewares.NewForwardProxy(forwardProxyConfig)
+			if err != nil {
+				log.Fatal("Error creating forward proxy: ", err)
+			}
+			server.forwardProxies[newServerEntryPointName] = forwardProxy
+			serverMiddlewares = append(serverMiddlewares, forwardProxy)
+		}
+		if securityHeaders := server.globalConfiguration.EntryPoints[newServerEntryPointName].SecurityHeaders; securityHeaders != nil {
+			securityHeadersMiddleware, err := middlewares.NewSecurityHeaders(securityHeaders)
+			if err != nil {
+				log.Fatal("Error starting server: ", err)
+			}
+			serverMiddlewares = append(serverMiddlewares, securityHeadersMiddleware)
+		}
+		if tlsOption := server.globalConfiguration.EntryPoints[newServerEntryPointName].TLS; tlsOption != nil && tlsOption.StrictSNI {
+			serverMiddlewares = append(serverMiddlewares, middlewares.NewSNIChecker(tlsOption.SNIExemptions))
+		}
+		if headers := server.globalConfiguration.EntryPoints[newServerEntryPointName].Headers; headers != nil {
+			serverMiddlewares = append(serverMiddlewares, middlewares.NewHeaderSanitizer(headers))
+		}
+		if paths := server.globalConfiguration.EntryPoints[newServerEntryPointName].Paths; paths != nil {
+			serverMiddlewares = append(serverMiddlewares, middlewares.NewPathNormalizer(paths))
+		}
+		if redirectMap := server.globalConfiguration.EntryPoints[newServerEntryPointName].RedirectMap; redirectMap != nil {
+			redirectMapMiddleware, err := middlewares.NewRedirectMap(redirectMap)
+			if err != nil {
+				log.Fatal("Error loading redirect map: ", err)
+			}
+			serverMiddlewares = append(serverMiddlewares, redirectMapMiddleware)
+		}
+		if acme := server.globalConfiguration.ACME; acme != nil && acme.HTTPChallenge != nil && acme.HTTPChallenge.EntryPoint == newServerEntryPointName {
+			// the challenges are answered before the authentication
+			serverMiddlewares = append(serverMiddlewares, acme.HTTPChallengeHandler())
+		}
 		if server.globalConfiguration.EntryPoints[newServerEntryPointName].Auth != nil {
-			authMiddleware, err := middlewares.NewAuthenticator(server.globalConfiguration.EntryPoints[newServerEntryPointName].Auth)
+			authMiddleware, err := authLockouts.Authenticator(newServerEntryPointName, "", server.globalConfiguration.EntryPoints[newServerEntryPointName].Auth)
 			if err != nil {
 				log.Fatal("Error starting server: ", err)
 			}
@@ -190,13 +417,16 @@ func (server *Server) startHTTPServers() {
 		}
 		serverEntryPoint := server.serverEntryPoints[newServerEntryPointName]
 		serverEntryPoint.httpServer = newsrv
-		go server.startServer(serverEntryPoint.httpServer, server.globalConfiguration)
+		// bound before the providers start, they must be serving once traefik is ready
+		listener, err := listenEntryPoint(newServerEntryPointName, server.globalConfiguration.EntryPoints[newServerEntryPointName].Address)
+		if err != nil {
+			log.Fatal("Error creating server: ", err)
+		}
+		go server.startServer(serverEntryPoint.httpServer, listener)
 	}
 }
 
 func (server *Server) listenProviders(stop chan bool) {
-	lastReceivedConfiguration := safe.New(time.Unix(0, 0))
-	lastConfigs := cmap.New()
 	for {
 		select {
 		case <-stop:
@@ -209,36 +439,41 @@ func (server *Server) listenProviders(stop chan bool) {
 			currentConfigurations := server.currentConfigurations.Get().(configs)
 			jsonConf, _ := json.Marshal(configMsg.Configuration)
 			log.Debugf("Configuration received from provider %s: %s", configMsg.ProviderName, string(jsonConf))
-			if configMsg.Configuration == nil || configMsg.Configuration.Backends == nil && configMsg.Configuration.Frontends == nil {
+			if configMsg.Configuration == nil {
+				log.Infof("Skipping empty Configuration for provider %s", configMsg.ProviderName)
+				providerStatuses.Failed(configMsg.ProviderName, errors.New("invalid configuration, the last one stays applied"))
+			} else if configMsg.Configuration.Backends == nil && configMsg.Configuration.Frontends == nil {
 				log.Infof("Skipping empty Configuration for provider %s", configMsg.ProviderName)
 			} else if reflect.DeepEqual(currentConfigurations[configMsg.ProviderName], configMsg.Configuration) {
 				log.Infof("Skipping same configuration for provider %s", configMsg.ProviderName)
 			} else {
-				lastConfigs.Set(configMsg.ProviderName, &configMsg)
-				lastReceivedConfigurationValue := lastReceivedConfiguration.Get().(time.Time)
-				if time.Now().After(lastReceivedConfigurationValue.Add(time.Duration(server.globalConfiguration.ProvidersThrottleDuration))) {
-					log.Debugf("Last %s config received more than %s, OK", configMsg.ProviderName, server.globalConfiguration.ProvidersThrottleDuration.String())
-					// last config received more than n s ago
-					server.configurationValidatedChan <- configMsg
-				} else {
-					log.Debugf("Last %s config received less than %s, waiting...", configMsg.ProviderName, server.globalConfiguration.ProvidersThrottleDuration.String())
-					safe.Go(func() {
-						<-time.After(server.globalConfiguration.ProvidersThrottleDuration)
-						lastReceivedConfigurationValue := lastReceivedConfiguration.Get().(time.Time)
-						if time.Now().After(lastReceivedConfigurationValue.Add(time.Duration(server.globalConfiguration.ProvidersThrottleDuration))) {
-							log.Debugf("Waited for %s config, OK", configMsg.ProviderName)
-							if lastConfig, ok := lastConfigs.Get(configMsg.ProviderName); ok {
-								server.configurationValidatedChan <- *lastConfig.(*types.ConfigMessage)
-							}
-						}
-					})
-				}
-				lastReceivedConfiguration.Set(time.Now())
+				server.providersThrottle.throttle(configMsg, server.configurationValidatedChan)
 			}
 		}
 	}
 }
 
+// autoRedirectEntryPoints returns entryPoints with the entrypoints redirecting
+// automatically to one of them, sorted by name.
+func (server *Server) autoRedirectEntryPoints(entryPoints []string) []string {
+	listed := map[string]bool{}
+	for _, name := range entryPoints {
+		listed[name] = true
+	}
+	names := []string{}
+	for name, entryPoint := range server.globalConfiguration.EntryPoints {
+		if entryPoint.Redirect != nil && entryPoint.Redirect.Auto && !listed[name] && listed[entryPoint.Redirect.EntryPoint] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return entryPoints
+	}
+	sort.Strings(names)
+	// entryPoints may be the default entrypoints
+	return append(append([]string{}, entryPoints...), names...)
+}
+
 func (server *Server) defaultConfigurationValues(configuration *types.Configuration) {
 	if configuration == nil || configuration.Frontends == nil {
 		return
@@ -248,6 +483,7 @@ func (server *Server) defaultConfigurationValues(configuration *types.Configurat
 		if len(frontend.EntryPoints) == 0 {
 			frontend.EntryPoints = server.globalConfiguration.DefaultEntryPoints
 		}
+		frontend.EntryPoints = server.autoRedirectEntryPoints(frontend.EntryPoints)
 	}
 	for backendName, backend := range configuration.Backends {
 		_, err := types.NewLoadBalancerMethod(backend.LoadBalancer)
@@ -259,6 +495,10 @@ func (server *Server) defaultConfigurationValues(configuration *types.Configurat
 }
 
 func (server *Server) listenConfigurations(stop chan bool) {
+	minApplyInterval := server.globalConfiguration.ProvidersMinApplyInterval
+	pending := pendingConfigurations{}
+	var lastApplied time.Time
+	var applyTimer <-chan time.Time
 	for {
 		select {
 		case <-stop:
@@ -268,29 +508,109 @@ func (server *Server) listenConfigurations(stop chan bool) {
 				return
 			}
 			currentConfigurations := server.currentConfigurations.Get().(configs)
-
-			// Copy configurations to new map so we don't change current if LoadConfig fails
-			newConfigurations := make(configs)
-			for k, v := range currentConfigurations {
-				newConfigurations[k] = v
+			if server.configurationCache != nil && server.configurationCache.isStale(configMsg, currentConfigurations) {
+				log.Infof("Skipping cached configuration for provider %s, a new one was received", configMsg.ProviderName)
+				continue
 			}
-			newConfigurations[configMsg.ProviderName] = configMsg.Configuration
-
-			newServerEntryPoints, err := server.loadConfig(newConfigurations, server.globalConfiguration)
-			if err == nil {
-				for newServerEntryPointName, newServerEntryPoint := range newServerEntryPoints {
-					server.serverEntryPoints[newServerEntryPointName].httpRouter.UpdateHandler(newServerEntryPoint.httpRouter.GetHandler())
-					log.Infof("Server configuration reloaded on %s", server.serverEntryPoints[newServerEntryPointName].httpServer.Addr)
-				}
-				server.currentConfigurations.Set(newConfigurations)
-				server.postLoadConfig()
-			} else {
-				log.Error("Error loading new configuration, aborted ", err)
+			if server.configurationFreeze != nil && server.configurationFreeze.hold(configMsg, currentConfigurations) {
+				continue
 			}
+			pending[configMsg.ProviderName] = configMsg
+			if applyTimer != nil {
+				log.Debugf("Coalescing the configuration of provider %s with %s", configMsg.ProviderName, pending.providerNames())
+				continue
+			}
+			if wait := lastApplied.Add(minApplyInterval).Sub(time.Now()); wait > 0 {
+				log.Debugf("Last configuration applied less than %s ago, waiting %s...", minApplyInterval, wait)
+				applyTimer = time.After(wait)
+				continue
+			}
+			server.applyPendingConfigurations(pending)
+			pending = pendingConfigurations{}
+			lastApplied = time.Now()
+		case <-applyTimer:
+			applyTimer = nil
+			server.applyPendingConfigurations(pending)
+			pending = pendingConfigurations{}
+			lastApplied = time.Now()
+		case rollback := <-server.rollbackChan:
+			version, err := server.rollbackConfigurations(rollback.version)
+			rollback.result <- configurationRollbackResult{version: version, err: err}
 		}
 	}
 }
 
+// applyPendingConfigurations applies the pending configurations of the
+// providers together, on top of the current ones. When they fail to load,
+// they are applied one by one, so that the configuration of a provider
+// failing to load doesn't hold back the others, its last configuration
+// staying applied.
+func (server *Server) applyPendingConfigurations(pending pendingConfigurations) {
+	if server.applyProvidersConfigurations(pending) == nil || len(pending) == 1 {
+		return
+	}
+	providerNames := []string{}
+	for providerName := range pending {
+		providerNames = append(providerNames, providerName)
+	}
+	sort.Strings(providerNames)
+	for _, providerName := range providerNames {
+		server.applyProvidersConfigurations(pendingConfigurations{providerName: pending[providerName]})
+	}
+}
+
+// applyProvidersConfigurations applies the configurations of pending on top
+// of the current ones, and records the statuses of their providers.
+func (server *Server) applyProvidersConfigurations(pending pendingConfigurations) error {
+	currentConfigurations := server.currentConfigurations.Get().(configs)
+	// Copy configurations to new map so we don't change current if LoadConfig fails
+	newConfigurations := make(configs)
+	for k, v := range currentConfigurations {
+		newConfigurations[k] = v
+	}
+	for providerName, configMsg := range pending {
+		newConfigurations[providerName] = configMsg.Configuration
+	}
+
+	if err := server.applyConfigurations(newConfigurations); err != nil {
+		log.Errorf("Error loading new configuration of %s, aborted %v", pending.providerNames(), err)
+		if len(pending) == 1 {
+			providerStatuses.Failed(pending.providerNames(), err)
+		}
+		return err
+	}
+	for providerName := range pending {
+		providerStatuses.Applied(providerName)
+	}
+	if server.configurationHistory != nil {
+		server.configurationHistory.record(pending.providerNames(), 0, currentConfigurations, newConfigurations)
+	}
+	return nil
+}
+
+// applyConfigurations loads newConfigurations, and replaces the current ones
+// unless they fail to load.
+func (server *Server) applyConfigurations(newConfigurations configs) error {
+	newServerEntryPoints, err := server.loadConfig(newConfigurations, server.globalConfiguration)
+	if err != nil {
+		return err
+	}
+	for newServerEntryPointName, newServerEntryPoint := range newServerEntryPoints {
+		server.serverEntryPoints[newServerEntryPointName].httpRouter.UpdateHandler(newServerEntryPoint.httpRouter.GetHandler())
+		log.Infof("Server configuration reloaded on %s", server.serverEntryPoints[newServerEntryPointName].httpServer.Addr)
+	}
+	server.currentConfigurations.Set(newConfigurations)
+	for _, conflict := range analyzeRouteConflicts(newConfigurations) {
+		log.Warn(conflict)
+	}
+	server.postLoadConfig()
+	server.publishDNSRecords()
+	if server.configurationCache != nil {
+		server.configurationCache.save(newConfigurations)
+	}
+	return nil
+}
+
 func (server *Server) postLoadConfig() {
 	if server.globalConfiguration.ACME == nil {
 		return
@@ -317,6 +637,33 @@ func (server *Server) postLoadConfig() {
 	}
 }
 
+// publishDNSRecords sends the hosts of the frontends to the DNS publisher.
+// In cluster mode, only the leader publishes records.
+func (server *Server) publishDNSRecords() {
+	if server.dnsPublisher == nil {
+		return
+	}
+	if server.leadership != nil && !server.leadership.IsLeader() {
+		return
+	}
+	hosts := []string{}
+	currentConfigurations := server.currentConfigurations.Get().(configs)
+	for _, configuration := range currentConfigurations {
+		for _, frontend := range configuration.Frontends {
+			for _, route := range frontend.Routes {
+				rules := Rules{}
+				domains, err := rules.ParseDomains(route.Rule)
+				if err != nil {
+					log.Errorf("Error parsing domains: %v", err)
+					continue
+				}
+				hosts = append(hosts, domains...)
+			}
+		}
+	}
+	server.dnsPublisher.Update(hosts)
+}
+
 func (server *Server) configureProviders() {
 	// configure providers
 	if server.globalConfiguration.Docker != nil {
@@ -359,6 +706,9 @@ func (server *Server) configureProviders() {
 	if server.globalConfiguration.WebAPI != nil {
 		server.providers = append(server.providers, server.globalConfiguration.WebAPI)
 	}
+	if server.globalConfiguration.Plugin != nil {
+		server.providers = append(server.providers, server.globalConfiguration.Plugin)
+	}
 }
 
 func (server *Server) startProviders() {
@@ -367,11 +717,33 @@ func (server *Server) startProviders() {
 		jsonConf, _ := json.Marshal(provider)
 		log.Infof("Starting provider %v %s", reflect.TypeOf(provider), jsonConf)
 		currentProvider := provider
-		safe.Go(func() {
-			err := currentProvider.Provide(server.configurationChan, server.routinesPool, server.globalConfiguration.Constraints)
+		configurationChan := server.configurationChan
+		if dryRun, ok := provider.(dryRunner); ok && dryRun.IsDryRun() {
+			log.Warnf("Provider %v runs in dry-run mode, its configurations are not applied", reflect.TypeOf(provider))
+			dryRunChan := make(chan types.ConfigMessage, 100)
+			server.routinesPool.Go(func(stop chan bool) {
+				server.dryRunConfigurations.listen(stop, dryRunChan, server.defaultConfigurationValues)
+			})
+			configurationChan = dryRunChan
+		} else if throttled, ok := provider.(throttler); ok && throttled.GetThrottleDuration() > 0 {
+			throttleDuration := throttled.GetThrottleDuration()
+			log.Infof("Provider %v throttled for %s", reflect.TypeOf(provider), throttleDuration)
+			providerChan := make(chan types.ConfigMessage, 100)
+			server.routinesPool.Go(func(stop chan bool) {
+				server.providersThrottle.forward(stop, providerChan, server.configurationChan, throttleDuration)
+			})
+			configurationChan = providerChan
+		}
+		name := providerConfigurationName(provider)
+		safe.GoWithRecover(func() {
+			err := currentProvider.Provide(configurationChan, server.routinesPool, server.globalConfiguration.Constraints)
 			if err != nil {
 				log.Errorf("Error starting provider %s", err)
+				providerStatuses.Failed(name, err)
 			}
+		}, func(err interface{}) {
+			log.Errorf("Error in provider %s: %v", name, err)
+			providerStatuses.Failed(name, fmt.Errorf("panic: %v", err))
 		})
 	}
 }
@@ -411,6 +783,10 @@ func (server *Server) createTLSConfig(entryPointName string, tlsOption *TLS, rou
 		}
 		config.ClientCAs = pool
 		config.ClientAuth = tls.RequireAndVerifyClientCert
+	} else if tlsOption.RequestClientCert {
+		// the frontends verify the client certificates against their own CAs,
+		// as the handshakes happen before the requests are routed
+		config.ClientAuth = tls.RequestClientCert
 	}
 
 	if server.globalConfiguration.ACME != nil {
@@ -441,43 +817,44 @@ func (server *Server) createTLSConfig(entryPointName string, tlsOption *TLS, rou
 			return nil, errors.New("Unknown entrypoint " + server.globalConfiguration.ACME.EntryPoint + " for ACME configuration")
 		}
 	}
+	if len(config.Certificates) == 0 && server.defaultCertificate != nil {
+		config.Certificates = append(config.Certificates, *server.defaultCertificate)
+	}
 	if len(config.Certificates) == 0 {
 		return nil, errors.New("No certificates found for TLS entrypoint " + entryPointName)
 	}
 	// BuildNameToCertificate parses the CommonName and SubjectAlternateName fields
 	// in each certificate and populates the config.NameToCertificate map.
 	config.BuildNameToCertificate()
-	//Set the minimum TLS version if set in the config TOML
-	if minConst, exists := minVersion[server.globalConfiguration.EntryPoints[entryPointName].TLS.MinVersion]; exists {
-		config.PreferServerCipherSuites = true
-		config.MinVersion = minConst
-	}
-	//Set the list of CipherSuites if set in the config TOML
-	if server.globalConfiguration.EntryPoints[entryPointName].TLS.CipherSuites != nil {
-		//if our list of CipherSuites is defined in the entrypoint config, we can re-initilize the suites list as empty
-		config.CipherSuites = make([]uint16, 0)
-		for _, cipher := range server.globalConfiguration.EntryPoints[entryPointName].TLS.CipherSuites {
-			if cipherConst, exists := cipherSuites[cipher]; exists {
-				config.CipherSuites = append(config.CipherSuites, cipherConst)
-			} else {
-				//CipherSuite listed in the toml does not exist in our listed
-				return nil, errors.New("Invalid CipherSuite: " + cipher)
-			}
-		}
+	store := newCertificateStore(entryPointName, tlsOption.Certificates, config)
+	if store != nil {
+		config.GetCertificate = store.match(config.GetCertificate)
+	}
+	tlsHandshakes.CheckServerName(entryPointName, config)
+	if store != nil {
+		config.GetCertificate = store.fallback(config.GetCertificate)
+		server.certificateStores[entryPointName] = store
+	}
+	//Set the TLS versions and the list of CipherSuites if set in the config TOML
+	if err := tlsOption.applyVersions(config); err != nil {
+		return nil, err
+	}
+	if server.fipsEnabled() {
+		enforceFIPS(config)
+	}
+	if server.sessionTickets != nil {
+		server.sessionTickets.register(entryPointName, config)
 	}
 	return config, nil
 }
 
-func (server *Server) startServer(srv *manners.GracefulServer, globalConfiguration GlobalConfiguration) {
+func (server *Server) startServer(srv *manners.GracefulServer, listener net.Listener) {
 	log.Infof("Starting server on %s", srv.Addr)
 	if srv.TLSConfig != nil {
-		if err := srv.ListenAndServeTLSWithConfig(srv.TLSConfig); err != nil {
-			log.Fatal("Error creating server: ", err)
-		}
-	} else {
-		if err := srv.ListenAndServe(); err != nil {
-			log.Fatal("Error creating server: ", err)
-		}
+		listener = tls.NewListener(listener, srv.TLSConfig)
+	}
+	if err := srv.Serve(listener); err != nil {
+		log.Fatal("Error creating server: ", err)
 	}
 	log.Info("Server stopped")
 }
@@ -496,19 +873,19 @@ func (server *Server) prepareServer(entryPointName string, router *middlewares.H
 		return nil, err
 	}
 
-	if oldServer == nil {
-		return manners.NewWithServer(
-			&http.Server{
-				Addr:      entryPoint.Address,
-				Handler:   negroni,
-				TLSConfig: tlsConfig,
-			}), nil
-	}
-	gracefulServer, err := oldServer.HijackListener(&http.Server{
+	httpServer := &http.Server{
 		Addr:      entryPoint.Address,
 		Handler:   negroni,
 		TLSConfig: tlsConfig,
-	}, tlsConfig)
+		ErrorLog:  tlsHandshakes.ErrorLog(entryPointName),
+	}
+	if entryPoint.TLS != nil && entryPoint.TLS.HandshakeTimeout > 0 {
+		httpServer.ConnState = handshakeDeadline(time.Duration(entryPoint.TLS.HandshakeTimeout) * time.Second)
+	}
+	if oldServer == nil {
+		return manners.NewWithServer(httpServer), nil
+	}
+	gracefulServer, err := oldServer.HijackListener(httpServer, tlsConfig)
 	if err != nil {
 		log.Errorf("Error hijacking server %s", err)
 		return nil, err
@@ -535,6 +912,11 @@ func (server *Server) loadConfig(configurations configs, globalConfiguration Glo
 
 	backends := map[string]http.Handler{}
 	backend2FrontendMap := map[string]string{}
+	sloObjectives := map[string]types.SLO{}
+	captures := map[string]bool{}
+	tenants := map[string]string{}
+	authFrontends := map[string]bool{}
+	concurrencyFrontends := map[string]bool{}
 	for _, configuration := range configurations {
 		frontendNames := sortedFrontendNamesForConfig(configuration)
 	frontend:
@@ -542,14 +924,10 @@ func (server *Server) loadConfig(configurations configs, globalConfiguration Glo
 			frontend := configuration.Frontends[frontendName]
 
 			log.Debugf("Creating frontend %s", frontendName)
-
-			fwd, err := forward.New(forward.Logger(oxyLogger), forward.PassHostHeader(frontend.PassHostHeader))
-			if err != nil {
-				log.Errorf("Error creating forwarder for frontend %s: %v", frontendName, err)
-				log.Errorf("Skipping frontend %s...", frontendName)
-				continue frontend
+			if len(frontend.Tenant) > 0 {
+				tenants[frontendName] = frontend.Tenant
 			}
-			saveBackend := middlewares.NewSaveBackend(fwd)
+
 			if len(frontend.EntryPoints) == 0 {
 				log.Errorf("No entrypoint defined for frontend %s, defaultEntryPoints:%s", frontendName, globalConfiguration.DefaultEntryPoints)
 				log.Errorf("Skipping frontend %s...", frontendName)
@@ -585,125 +963,152 @@ func (server *Server) loadConfig(configurations configs, globalConfiguration Glo
 						redirectHandlers[entryPointName] = handler
 					}
 				} else {
-					if backends[frontend.Backend] == nil {
-						log.Debugf("Creating backend %s", frontend.Backend)
-						var lb http.Handler
-						rr, _ := roundrobin.New(saveBackend)
-						if configuration.Backends[frontend.Backend] == nil {
-							log.Errorf("Undefined backend '%s' for frontend %s", frontend.Backend, frontendName)
+					if err := server.loadFrontendBackends(configuration, globalConfiguration, frontendName, frontend, backends, backend2FrontendMap); err != nil {
+						log.Errorf("Error creating backend for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					if frontend.Priority > 0 {
+						newServerRoute.route.Priority(frontend.Priority)
+					}
+					handler := backends[frontend.Backend]
+					if frontend.Experiment != nil {
+						experiment, err := server.loadExperiment(frontendName, frontend, backends)
+						if err != nil {
+							log.Errorf("Error creating experiment for frontend %s: %v", frontendName, err)
 							log.Errorf("Skipping frontend %s...", frontendName)
 							continue frontend
 						}
-
-						lbMethod, err := types.NewLoadBalancerMethod(configuration.Backends[frontend.Backend].LoadBalancer)
+						handler = experiment
+					}
+					if frontend.Coalesce != nil {
+						var experimentHeader string
+						if frontend.Experiment != nil {
+							experimentHeader = frontend.Experiment.Header
+						}
+						coalescer, err := middlewares.NewCoalescer(frontend.Coalesce, experimentHeader)
 						if err != nil {
-							log.Errorf("Error loading load balancer method '%+v' for frontend %s: %v", configuration.Backends[frontend.Backend].LoadBalancer, frontendName, err)
+							log.Errorf("Error creating request coalescing for frontend %s: %v", frontendName, err)
 							log.Errorf("Skipping frontend %s...", frontendName)
 							continue frontend
 						}
-
-						stickysession := configuration.Backends[frontend.Backend].LoadBalancer.Sticky
-						cookiename := "_TRAEFIK_BACKEND"
-						var sticky *roundrobin.StickySession
-
-						if stickysession {
-							sticky = roundrobin.NewStickySession(cookiename)
+						handler = coalescer.Handler(handler)
+					}
+					if frontend.Fault != nil {
+						if err := validateFault(frontend.Fault); err != nil {
+							log.Errorf("Error creating fault injection for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
 						}
-
-						switch lbMethod {
-						case types.Drr:
-							log.Debugf("Creating load-balancer drr")
-							rebalancer, _ := roundrobin.NewRebalancer(rr, roundrobin.RebalancerLogger(oxyLogger))
-							if stickysession {
-								log.Debugf("Sticky session with cookie %v", cookiename)
-								rebalancer, _ = roundrobin.NewRebalancer(rr, roundrobin.RebalancerLogger(oxyLogger), roundrobin.RebalancerStickySession(sticky))
-							}
-							lb = rebalancer
-							for serverName, server := range configuration.Backends[frontend.Backend].Servers {
-								url, err := url.Parse(server.URL)
-								if err != nil {
-									log.Errorf("Error parsing server URL %s: %v", server.URL, err)
-									log.Errorf("Skipping frontend %s...", frontendName)
-									continue frontend
-								}
-								backend2FrontendMap[url.String()] = frontendName
-								log.Debugf("Creating server %s at %s with weight %d", serverName, url.String(), server.Weight)
-								if err := rebalancer.UpsertServer(url, roundrobin.Weight(server.Weight)); err != nil {
-									log.Errorf("Error adding server %s to load balancer: %v", server.URL, err)
-									log.Errorf("Skipping frontend %s...", frontendName)
-									continue frontend
-								}
-							}
-						case types.Wrr:
-							log.Debugf("Creating load-balancer wrr")
-							if stickysession {
-								log.Debugf("Sticky session with cookie %v", cookiename)
-								rr, _ = roundrobin.New(saveBackend, roundrobin.EnableStickySession(sticky))
-							}
-							lb = rr
-							for serverName, server := range configuration.Backends[frontend.Backend].Servers {
-								url, err := url.Parse(server.URL)
-								if err != nil {
-									log.Errorf("Error parsing server URL %s: %v", server.URL, err)
-									log.Errorf("Skipping frontend %s...", frontendName)
-									continue frontend
-								}
-								backend2FrontendMap[url.String()] = frontendName
-								log.Debugf("Creating server %s at %s with weight %d", serverName, url.String(), server.Weight)
-								if err := rr.UpsertServer(url, roundrobin.Weight(server.Weight)); err != nil {
-									log.Errorf("Error adding server %s to load balancer: %v", server.URL, err)
-									log.Errorf("Skipping frontend %s...", frontendName)
-									continue frontend
-								}
-							}
+						handler = faultInjector.Handler(frontend.Fault, handler)
+					}
+					if frontend.Capture != nil {
+						if err := validateCapture(frontend.Capture); err != nil {
+							log.Errorf("Error creating capture for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						captures[frontendName] = true
+						handler = trafficCapture.Handler(frontendName, frontend.Capture, handler)
+					}
+					if frontend.SLO != nil {
+						log.Debugf("Recording SLO %+v for frontend %s", *frontend.SLO, frontendName)
+						sloObjectives[frontendName] = *frontend.SLO
+						handler = sloRecorder.Handler(frontendName, handler)
+					}
+					if server.featureFlags != nil {
+						handler = server.featureFlags.Handler(frontendName, handler)
+					}
+					for i := len(frontend.Middlewares) - 1; i >= 0; i-- {
+						registered, err := middlewares.NewRegistered(frontend.Middlewares[i], frontendName, handler)
+						if err != nil {
+							log.Errorf("Error creating middleware %s for frontend %s: %v", frontend.Middlewares[i], frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						handler = registered
+					}
+					if frontend.OPA != nil {
+						opa, err := middlewares.NewOPA(frontend.OPA)
+						if err != nil {
+							log.Errorf("Error creating OPA authorization for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
 						}
-						maxConns := configuration.Backends[frontend.Backend].MaxConn
-						if maxConns != nil && maxConns.Amount != 0 {
-							extractFunc, err := utils.NewExtractor(maxConns.ExtractorFunc)
-							if err != nil {
-								log.Errorf("Error creating connlimit: %v", err)
-								log.Errorf("Skipping frontend %s...", frontendName)
-								continue frontend
-							}
-							log.Debugf("Creating loadd-balancer connlimit")
-							lb, err = connlimit.New(lb, extractFunc, maxConns.Amount, connlimit.Logger(oxyLogger))
-							if err != nil {
-								log.Errorf("Error creating connlimit: %v", err)
-								log.Errorf("Skipping frontend %s...", frontendName)
-								continue frontend
-							}
+						handler = opa.Handler(handler)
+					}
+					if frontend.ACL != nil {
+						acl, err := middlewares.NewACL(frontend.ACL)
+						if err != nil {
+							log.Errorf("Error creating ACL for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
 						}
-						// retry ?
-						if globalConfiguration.Retry != nil {
-							retries := len(configuration.Backends[frontend.Backend].Servers)
-							if globalConfiguration.Retry.Attempts > 0 {
-								retries = globalConfiguration.Retry.Attempts
-							}
-							lb = middlewares.NewRetry(retries, lb)
-							log.Debugf("Creating retries max attempts %d", retries)
+						handler = acl.Handler(handler)
+					}
+					if frontend.Auth != nil {
+						authenticator, err := authLockouts.Authenticator("", frontendName, frontend.Auth)
+						if err != nil {
+							log.Errorf("Error creating authentication for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
 						}
-
-						var negroni = negroni.New()
-						if configuration.Backends[frontend.Backend].CircuitBreaker != nil {
-							log.Debugf("Creating circuit breaker %s", configuration.Backends[frontend.Backend].CircuitBreaker.Expression)
-							cbreaker, err := middlewares.NewCircuitBreaker(lb, configuration.Backends[frontend.Backend].CircuitBreaker.Expression, cbreaker.Logger(oxyLogger))
-							if err != nil {
-								log.Errorf("Error creating circuit breaker: %v", err)
-								log.Errorf("Skipping frontend %s...", frontendName)
-								continue frontend
-							}
-							negroni.Use(cbreaker)
-						} else {
-							negroni.UseHandler(lb)
+						authFrontends[frontendName] = true
+						handler = authHandler(authenticator, handler)
+					}
+					if frontend.GraphQL != nil {
+						graphQLLimiter, err := middlewares.NewGraphQLLimiter(frontend.GraphQL)
+						if err != nil {
+							log.Errorf("Error creating GraphQL limits for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
 						}
-						backends[frontend.Backend] = negroni
-					} else {
-						log.Debugf("Reusing backend %s", frontend.Backend)
+						handler = graphQLLimiter.Handler(handler)
 					}
-					if frontend.Priority > 0 {
-						newServerRoute.route.Priority(frontend.Priority)
+					if frontend.ClientHeaders != nil {
+						clientHeaders, err := middlewares.NewClientHeaders(frontend.ClientHeaders)
+						if err != nil {
+							log.Errorf("Error creating client certificate headers for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						handler = clientHeaders.Handler(handler)
+					}
+					if frontend.ClientCA != nil {
+						if entryPoint.TLS == nil || (len(entryPoint.TLS.ClientCAFiles) == 0 && !entryPoint.TLS.RequestClientCert) {
+							log.Warnf("The entrypoint %s doesn't request the client certificates required by frontend %s, set its RequestClientCert", entryPointName, frontendName)
+						}
+						clientCertChecker, err := middlewares.NewClientCertChecker(frontend.ClientCA)
+						if err != nil {
+							log.Errorf("Error creating client certificates verification for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						handler = clientCertChecker.Handler(handler)
+					}
+					if frontend.ConcurrencyLimit != nil {
+						concurrencyLimiter, err := concurrencies.Limiter(frontendName, frontend.ConcurrencyLimit)
+						if err != nil {
+							log.Errorf("Error creating concurrency limit for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						concurrencyFrontends[frontendName] = true
+						handler = concurrencyLimiter.Handler(handler)
+					}
+					if frontend.SecurityHeaders != nil {
+						securityHeaders, err := middlewares.NewSecurityHeaders(frontend.SecurityHeaders)
+						if err != nil {
+							log.Errorf("Error creating security headers for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						handler = securityHeaders.Handler(handler)
 					}
-					server.wireFrontendBackend(newServerRoute, backends[frontend.Backend])
+					handler = frontendDrains.Handler(frontendName, handler)
+					handler = requestTap.Handler(frontendName, handler)
+					handler = middlewares.FrontendHandler(frontendName, handler)
+					server.wireFrontendBackend(newServerRoute, handler)
 				}
 				err := newServerRoute.route.GetError()
 				if err != nil {
@@ -713,6 +1118,18 @@ func (server *Server) loadConfig(configurations configs, globalConfiguration Glo
 		}
 	}
 	middlewares.SetBackend2FrontendMap(&backend2FrontendMap)
+	sloRecorder.SetObjectives(sloObjectives)
+	trafficCapture.SetFrontends(captures)
+	authLockouts.SetFrontends(authFrontends)
+	concurrencies.SetFrontends(concurrencyFrontends)
+	backendNames := map[string]bool{}
+	for backendName := range backends {
+		backendNames[backendName] = true
+	}
+	unixSockets.SetBackends(backendNames)
+	if server.usageRecorder != nil {
+		server.usageRecorder.SetTenants(tenants)
+	}
 	//sort routes
 	for _, serverEntryPoint := range serverEntryPoints {
 		serverEntryPoint.httpRouter.GetHandler().SortRoutes()
@@ -761,6 +1178,234 @@ func (server *Server) loadEntryPointConfig(entryPointName string, entryPoint *En
 	return negroni, nil
 }
 
+// loadFrontendBackends creates the backend of frontend, and the backends of the
+// variants of its experiment, if they don't exist yet in backends.
+func (server *Server) loadFrontendBackends(configuration *types.Configuration, globalConfiguration GlobalConfiguration, frontendName string, frontend *types.Frontend, backends map[string]http.Handler, backend2FrontendMap map[string]string) error {
+	backendNames := []string{frontend.Backend}
+	if frontend.Experiment != nil {
+		variantNames := []string{}
+		for variantName := range frontend.Experiment.Variants {
+			variantNames = append(variantNames, variantName)
+		}
+		sort.Strings(variantNames)
+		for _, variantName := range variantNames {
+			if backendName := frontend.Experiment.Variants[variantName].Backend; len(backendName) > 0 {
+				backendNames = append(backendNames, backendName)
+			}
+		}
+	}
+	for _, backendName := range backendNames {
+		if backends[backendName] != nil {
+			log.Debugf("Reusing backend %s", backendName)
+			continue
+		}
+		log.Debugf("Creating backend %s", backendName)
+		backend, err := server.loadBackend(configuration, globalConfiguration, backendName, frontendName, frontend.PassHostHeader, backend2FrontendMap)
+		if err != nil {
+			return err
+		}
+		backends[backendName] = backend
+	}
+	return nil
+}
+
+func (server *Server) loadBackend(configuration *types.Configuration, globalConfiguration GlobalConfiguration, backendName string, frontendName string, passHostHeader bool, backend2FrontendMap map[string]string) (http.Handler, error) {
+	if configuration.Backends[backendName] == nil {
+		return nil, fmt.Errorf("undefined backend '%s'", backendName)
+	}
+	expect := configuration.Backends[backendName].Expect
+	if expect != nil {
+		if err := checkExpect(expect); err != nil {
+			return nil, err
+		}
+	}
+	transport := http.DefaultTransport
+	backendTLS := configuration.Backends[backendName].TLS
+	backendProxy := configuration.Backends[backendName].Proxy
+	backendSource := configuration.Backends[backendName].Source
+	if backendTLS != nil || backendProxy != nil || backendSource != nil {
+		if backendTLS == nil {
+			backendTLS = &types.BackendTLS{}
+		}
+		log.Debugf("Creating transport %s", backendTLSDescription(backendTLS))
+		if backendProxy != nil {
+			log.Debugf("Dialing the servers through %s", backendProxy.URL)
+		}
+		if backendSource != nil {
+			log.Debugf("Dialing the servers from %s%s", backendSource.IP, backendSource.Interface)
+		}
+		backendTransport, err := createBackendTransport(globalConfiguration, backendTLS, backendProxy, backendSource, expectTimeout(expect))
+		if err != nil {
+			return nil, fmt.Errorf("error creating backend transport: %v", err)
+		}
+		transport = backendTransport
+	} else if expect != nil && expect.Timeout > 0 {
+		transport = defaultBackendTransport(expectTimeout(expect))
+	}
+	streaming := configuration.Backends[backendName].Streaming
+	if streaming {
+		log.Debugf("Creating streaming transport")
+		streamingTransport, err := createStreamingTransport(transport, expectTimeout(expect))
+		if err != nil {
+			return nil, fmt.Errorf("error creating streaming transport: %v", err)
+		}
+		transport = streamingTransport
+	}
+	sockets := map[string]string{}
+	for _, server := range configuration.Backends[backendName].Servers {
+		if url, socket, err := parseServerURL(server.URL); err == nil && len(socket) > 0 {
+			sockets[url.Host] = socket
+		}
+	}
+	if len(sockets) > 0 {
+		log.Debugf("Creating unix socket transport")
+		transport = newUnixSocketTransport(sockets, transport, expectTimeout(expect))
+	}
+	fwd, err := forward.New(forward.Logger(oxyLogger), forward.PassHostHeader(passHostHeader), forward.ErrorHandler(middlewares.ForwardErrorHandler), forward.RoundTripper(transport), forward.StreamResponse(streaming))
+	if err != nil {
+		return nil, fmt.Errorf("error creating forwarder: %v", err)
+	}
+	var forwarder http.Handler = fwd
+	if expect != nil {
+		forwarder = expectHandler(expect, fwd)
+	}
+	if timeouts := configuration.Backends[backendName].Timeouts; timeouts != nil && (timeouts.MaxDuration > 0 || timeouts.Stall > 0) {
+		log.Debugf("Creating timeouts %ds max duration, %ds stall", timeouts.MaxDuration, timeouts.Stall)
+		forwarder = streamAborts.Handler(backendName, timeouts, forwarder)
+	}
+	saveBackend := middlewares.NewSaveBackend(forwarder)
+
+	var lb http.Handler
+	var balancer unixSocketBalancer
+	unixSocketServers := &unixSocketBackend{name: backendName}
+	rr, _ := roundrobin.New(saveBackend, roundrobin.ErrorHandler(middlewares.LoadBalancerErrorHandler))
+
+	lbMethod, err := types.NewLoadBalancerMethod(configuration.Backends[backendName].LoadBalancer)
+	if err != nil {
+		return nil, fmt.Errorf("error loading load balancer method '%+v': %v", configuration.Backends[backendName].LoadBalancer, err)
+	}
+
+	stickysession := configuration.Backends[backendName].LoadBalancer.Sticky
+	cookiename := stickyCookieName
+	var sticky *roundrobin.StickySession
+
+	if stickysession {
+		sticky = roundrobin.NewStickySession(cookiename)
+	}
+
+	switch lbMethod {
+	case types.Drr:
+		log.Debugf("Creating load-balancer drr")
+		rebalancer, _ := roundrobin.NewRebalancer(rr, roundrobin.RebalancerLogger(oxyLogger), roundrobin.RebalancerErrorHandler(middlewares.LoadBalancerErrorHandler))
+		if stickysession {
+			log.Debugf("Sticky session with cookie %v", cookiename)
+			rebalancer, _ = roundrobin.NewRebalancer(rr, roundrobin.RebalancerLogger(oxyLogger), roundrobin.RebalancerErrorHandler(middlewares.LoadBalancerErrorHandler), roundrobin.RebalancerStickySession(sticky))
+		}
+		lb = rebalancer
+		balancer = rebalancer
+		for serverName, server := range configuration.Backends[backendName].Servers {
+			url, socket, err := parseServerURL(server.URL)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing server URL %s: %v", server.URL, err)
+			}
+			backend2FrontendMap[url.String()] = frontendName
+			if len(socket) > 0 {
+				log.Debugf("Creating server %s at %s with weight %d", serverName, server.URL, server.Weight)
+				unixSocketServers.servers = append(unixSocketServers.servers, &unixSocketServer{name: serverName, url: url, socket: socket, weight: server.Weight})
+				continue
+			}
+			log.Debugf("Creating server %s at %s with weight %d", serverName, url.String(), server.Weight)
+			if err := rebalancer.UpsertServer(url, roundrobin.Weight(server.Weight)); err != nil {
+				return nil, fmt.Errorf("error adding server %s to load balancer: %v", server.URL, err)
+			}
+		}
+	case types.Wrr:
+		log.Debugf("Creating load-balancer wrr")
+		if stickysession {
+			log.Debugf("Sticky session with cookie %v", cookiename)
+			rr, _ = roundrobin.New(saveBackend, roundrobin.ErrorHandler(middlewares.LoadBalancerErrorHandler), roundrobin.EnableStickySession(sticky))
+		}
+		lb = rr
+		balancer = rr
+		for serverName, server := range configuration.Backends[backendName].Servers {
+			url, socket, err := parseServerURL(server.URL)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing server URL %s: %v", server.URL, err)
+			}
+			backend2FrontendMap[url.String()] = frontendName
+			if len(socket) > 0 {
+				log.Debugf("Creating server %s at %s with weight %d", serverName, server.URL, server.Weight)
+				unixSocketServers.servers = append(unixSocketServers.servers, &unixSocketServer{name: serverName, url: url, socket: socket, weight: server.Weight})
+				continue
+			}
+			log.Debugf("Creating server %s at %s with weight %d", serverName, url.String(), server.Weight)
+			if err := rr.UpsertServer(url, roundrobin.Weight(server.Weight)); err != nil {
+				return nil, fmt.Errorf("error adding server %s to load balancer: %v", server.URL, err)
+			}
+		}
+	}
+	if len(unixSocketServers.servers) > 0 {
+		unixSocketServers.balancer = balancer
+		unixSockets.Load(unixSocketServers)
+	}
+	maxConns := configuration.Backends[backendName].MaxConn
+	if maxConns != nil && maxConns.Amount != 0 {
+		extractFunc, err := utils.NewExtractor(maxConns.ExtractorFunc)
+		if err != nil {
+			return nil, fmt.Errorf("error creating connlimit: %v", err)
+		}
+		log.Debugf("Creating loadd-balancer connlimit")
+		lb, err = connlimit.New(lb, extractFunc, maxConns.Amount, connlimit.Logger(oxyLogger), connlimit.ErrorHandler(middlewares.ConnLimitErrorHandler))
+		if err != nil {
+			return nil, fmt.Errorf("error creating connlimit: %v", err)
+		}
+	}
+	// retry ?
+	if globalConfiguration.Retry != nil && streaming {
+		// the retries buffer the responses
+		log.Debugf("Skipping retries of streaming backend %s", backendName)
+	} else if globalConfiguration.Retry != nil {
+		retries := len(configuration.Backends[backendName].Servers)
+		if globalConfiguration.Retry.Attempts > 0 {
+			retries = globalConfiguration.Retry.Attempts
+		}
+		lb = middlewares.NewRetry(retries, lb)
+		log.Debugf("Creating retries max attempts %d", retries)
+	}
+
+	var negroni = negroni.New()
+	if configuration.Backends[backendName].CircuitBreaker != nil {
+		log.Debugf("Creating circuit breaker %s", configuration.Backends[backendName].CircuitBreaker.Expression)
+		cbreaker, err := middlewares.NewCircuitBreaker(lb, configuration.Backends[backendName].CircuitBreaker.Expression, cbreaker.Logger(oxyLogger), cbreaker.Fallback(middlewares.CircuitBreakerFallback))
+		if err != nil {
+			return nil, fmt.Errorf("error creating circuit breaker: %v", err)
+		}
+		negroni.Use(cbreaker)
+	} else {
+		negroni.UseHandler(lb)
+	}
+	return negroni, nil
+}
+
+// loadExperiment returns the A/B testing middleware of frontend, sending each
+// variant to its backend in backends. The experiment is named after the frontend by default.
+func (server *Server) loadExperiment(frontendName string, frontend *types.Frontend, backends map[string]http.Handler) (http.Handler, error) {
+	experiment := *frontend.Experiment
+	if len(experiment.Name) == 0 {
+		experiment.Name = frontendName
+	}
+	handlers := map[string]http.Handler{}
+	for variantName, variant := range frontend.Experiment.Variants {
+		backendName := frontend.Backend
+		if len(variant.Backend) > 0 {
+			backendName = variant.Backend
+		}
+		handlers[variantName] = backends[backendName]
+	}
+	log.Debugf("Creating experiment %s", experiment.Name)
+	return middlewares.NewExperiment(&experiment, handlers)
+}
+
 func (server *Server) buildDefaultHTTPRouter() *mux.Router {
 	router := mux.NewRouter()
 	router.NotFoundHandler = http.HandlerFunc(notFoundHandler)
@@ -0,0 +1,30 @@
+// +build !windows
+
+package main
+
+import (
+	"errors"
+
+	"github.com/Sirupsen/logrus"
+)
+
+var errNotWindows = errors.New("Windows services and the EventLog are only supported on Windows")
+
+func installService(name string, configFile string) error {
+	return errNotWindows
+}
+
+func uninstallService(name string) error {
+	return errNotWindows
+}
+
+func runService(traefikConfiguration *TraefikConfiguration) error {
+	return errNotWindows
+}
+
+// notifyConsoleShutdown does nothing, the console shutdown is a signal.
+func notifyConsoleShutdown() {}
+
+func newEventLogHook(source string) (logrus.Hook, error) {
+	return nil, errNotWindows
+}
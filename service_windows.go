@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/containous/traefik/safe"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const (
+	ctrlCloseEvent    = 2
+	ctrlLogoffEvent   = 5
+	ctrlShutdownEvent = 6
+)
+
+var (
+	kernel32              = syscall.NewLazyDLL("kernel32.dll")
+	setConsoleCtrlHandler = kernel32.NewProc("SetConsoleCtrlHandler")
+	getModuleFileName     = kernel32.NewProc("GetModuleFileNameW")
+)
+
+// executablePath returns the path of the running binary.
+func executablePath() (string, error) {
+	buffer := make([]uint16, syscall.MAX_PATH)
+	for {
+		n, _, err := getModuleFileName.Call(0, uintptr(unsafe.Pointer(&buffer[0])), uintptr(len(buffer)))
+		if n == 0 {
+			return "", err
+		}
+		// the path is truncated to the size of the buffer
+		if int(n) < len(buffer) {
+			return syscall.UTF16ToString(buffer[:n]), nil
+		}
+		buffer = make([]uint16, 2*len(buffer))
+	}
+}
+
+// installService registers the Windows service name, started automatically
+// with the runservice command and configFile, and its EventLog source.
+func installService(name string, configFile string) error {
+	exePath, err := executablePath()
+	if err != nil {
+		return err
+	}
+	args := []string{"runservice", "--serviceName=" + name}
+	if len(configFile) > 0 {
+		// services are started in the system directory
+		configFile, err = filepath.Abs(configFile)
+		if err != nil {
+			return err
+		}
+		args = append(args, "--configFile="+configFile)
+	}
+
+	manager, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+	if service, err := manager.OpenService(name); err == nil {
+		service.Close()
+		return fmt.Errorf("service %s already exists", name)
+	}
+	service, err := manager.CreateService(name, exePath, mgr.Config{
+		DisplayName: "Traefik (" + name + ")",
+		Description: "Traefik, a modern HTTP reverse proxy and load balancer",
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	if err := eventlog.InstallAsEventCreate(name, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+		service.Delete()
+		return fmt.Errorf("error installing the EventLog source %s: %v", name, err)
+	}
+	return nil
+}
+
+// uninstallService removes the Windows service name and its EventLog source.
+func uninstallService(name string) error {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+	service, err := manager.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+	defer service.Close()
+	if err := service.Delete(); err != nil {
+		return err
+	}
+	if err := eventlog.Remove(name); err != nil {
+		return fmt.Errorf("error removing the EventLog source %s: %v", name, err)
+	}
+	return nil
+}
+
+// runService runs traefik as the Windows service of the configuration.
+func runService(traefikConfiguration *TraefikConfiguration) error {
+	return svc.Run(traefikConfiguration.ServiceName, &windowsService{traefikConfiguration: traefikConfiguration})
+}
+
+type windowsService struct {
+	traefikConfiguration *TraefikConfiguration
+}
+
+// Execute runs traefik until the service control manager stops it, the
+// server being stopped gracefully as with SIGTERM.
+func (s *windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+	done := make(chan struct{})
+	safe.Go(func() {
+		run(s.traefikConfiguration)
+		close(done)
+	})
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case <-done:
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				changes <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending}
+				if !requestShutdown() {
+					// the server isn't started yet, the process exits
+					return false, 0
+				}
+			}
+		}
+	}
+}
+
+// notifyConsoleShutdown stops the server gracefully on the close, logoff and
+// shutdown events of the console, which aren't delivered as signals. Windows
+// terminates the process when the handler returns, so it blocks until the
+// process exits.
+func notifyConsoleShutdown() {
+	handler := syscall.NewCallback(func(event uintptr) uintptr {
+		switch event {
+		case ctrlCloseEvent, ctrlLogoffEvent, ctrlShutdownEvent:
+			if requestShutdown() {
+				select {}
+			}
+		}
+		return 0
+	})
+	setConsoleCtrlHandler.Call(handler, 1)
+}
+
+// eventLogHook writes the log entries to the Windows EventLog.
+type eventLogHook struct {
+	log *eventlog.Log
+}
+
+func newEventLogHook(source string) (logrus.Hook, error) {
+	log, err := eventlog.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	return &eventLogHook{log: log}, nil
+}
+
+func (h *eventLogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *eventLogHook) Fire(entry *logrus.Entry) error {
+	message, err := entry.String()
+	if err != nil {
+		return err
+	}
+	switch entry.Level {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		return h.log.Error(1, message)
+	case logrus.WarnLevel:
+		return h.log.Warning(1, message)
+	default:
+		return h.log.Info(1, message)
+	}
+}
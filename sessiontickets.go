@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/containous/staert"
+	"github.com/containous/traefik/cluster"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// SessionTicketKeys are the session ticket keys of the entrypoints, newest
+// first, as shared through the cluster store.
+type SessionTicketKeys struct {
+	Keys    [][]byte
+	Rotated time.Time
+}
+
+// sessionTickets sets the session ticket keys of the TLS entrypoints: keys
+// generated and rotated by traefik, by the leader in cluster mode so that all
+// the instances resume the sessions of each other, or read from a file.
+type sessionTickets struct {
+	config  types.SessionTickets
+	store   cluster.Store
+	lock    sync.Mutex
+	keys    SessionTicketKeys
+	configs map[string]*tls.Config
+}
+
+func newSessionTickets(config *types.SessionTickets) (*sessionTickets, error) {
+	if config.RotationInterval <= 0 {
+		return nil, errors.New("the session tickets rotation interval must be positive")
+	}
+	if config.Keys <= 0 {
+		return nil, errors.New("the number of session ticket keys must be positive")
+	}
+	return &sessionTickets{config: *config, configs: map[string]*tls.Config{}}, nil
+}
+
+// createLocalConfig generates the first keys, or reads the key file.
+func (s *sessionTickets) createLocalConfig() error {
+	return s.rotate()
+}
+
+// createClusterConfig shares the keys through the cluster store: the leader
+// rotates them, and every instance applies them when they change.
+func (s *sessionTickets) createClusterConfig(leadership *cluster.Leadership) error {
+	if len(s.config.KeyFile) > 0 {
+		return s.rotate()
+	}
+	if len(s.config.Storage) == 0 {
+		return errors.New("Empty Store, please provide a key for the session ticket keys storage")
+	}
+	listener := func(object cluster.Object) error {
+		s.setKeys(*object.(*SessionTicketKeys))
+		return nil
+	}
+	datastore, err := cluster.NewDataStore(
+		leadership.Pool.Ctx(),
+		staert.KvSource{
+			Store:  leadership.Store,
+			Prefix: s.config.Storage,
+		},
+		&SessionTicketKeys{},
+		listener)
+	if err != nil {
+		return err
+	}
+	s.store = datastore
+	leadership.AddListener(func(elected bool) error {
+		if !elected {
+			return nil
+		}
+		if _, err := s.store.Load(); err != nil {
+			return err
+		}
+		return s.rotateShared(leadership, false)
+	})
+	return nil
+}
+
+// run rotates the keys at each rotation interval, until stop.
+func (s *sessionTickets) run(stop chan bool, leadership *cluster.Leadership) {
+	ticker := time.NewTicker(time.Duration(s.config.RotationInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			var err error
+			if s.store != nil {
+				err = s.rotateShared(leadership, true)
+			} else {
+				err = s.rotate()
+			}
+			if err != nil {
+				log.Errorf("Error rotating the session ticket keys: %v", err)
+			}
+		}
+	}
+}
+
+// rotate generates a new key, or reloads the key file.
+func (s *sessionTickets) rotate() error {
+	if len(s.config.KeyFile) > 0 {
+		keys, err := readSessionTicketKeys(s.config.KeyFile)
+		if err != nil {
+			return err
+		}
+		s.setKeys(SessionTicketKeys{Keys: keys, Rotated: time.Now()})
+		return nil
+	}
+	s.lock.Lock()
+	current := s.keys
+	s.lock.Unlock()
+	keys, err := rotateSessionTicketKeys(current, s.config.Keys)
+	if err != nil {
+		return err
+	}
+	s.setKeys(keys)
+	return nil
+}
+
+// rotateShared rotates the keys of the cluster store when the leader, if they
+// are older than the rotation interval or always when force is set.
+func (s *sessionTickets) rotateShared(leadership *cluster.Leadership, force bool) error {
+	if !leadership.IsLeader() {
+		return nil
+	}
+	current := *s.store.Get().(*SessionTicketKeys)
+	interval := time.Duration(s.config.RotationInterval) * time.Second
+	if !force && len(current.Keys) > 0 && time.Since(current.Rotated) < interval {
+		s.setKeys(current)
+		return nil
+	}
+	transaction, object, err := s.store.Begin()
+	if err != nil {
+		return err
+	}
+	keys, err := rotateSessionTicketKeys(*object.(*SessionTicketKeys), s.config.Keys)
+	if err != nil {
+		return err
+	}
+	if err := transaction.Commit(&keys); err != nil {
+		return err
+	}
+	log.Debugf("Rotated the shared session ticket keys")
+	s.setKeys(keys)
+	return nil
+}
+
+// setKeys sets keys to the TLS configs of the entrypoints.
+func (s *sessionTickets) setKeys(keys SessionTicketKeys) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.keys = keys
+	ticketKeys := keys.ticketKeys()
+	if len(ticketKeys) == 0 {
+		return
+	}
+	for _, config := range s.configs {
+		config.SetSessionTicketKeys(ticketKeys)
+	}
+}
+
+// register sets the keys to the TLS config of entryPointName, and to the next keys.
+func (s *sessionTickets) register(entryPointName string, config *tls.Config) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.configs[entryPointName] = config
+	if ticketKeys := s.keys.ticketKeys(); len(ticketKeys) > 0 {
+		config.SetSessionTicketKeys(ticketKeys)
+	}
+}
+
+func (k SessionTicketKeys) ticketKeys() [][32]byte {
+	var ticketKeys [][32]byte
+	for _, key := range k.Keys {
+		if len(key) != 32 {
+			continue
+		}
+		var ticketKey [32]byte
+		copy(ticketKey[:], key)
+		ticketKeys = append(ticketKeys, ticketKey)
+	}
+	return ticketKeys
+}
+
+// rotateSessionTicketKeys returns a new key followed by the keys of current,
+// count keys at most.
+func rotateSessionTicketKeys(current SessionTicketKeys, count int) (SessionTicketKeys, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return SessionTicketKeys{}, err
+	}
+	keys := append([][]byte{key}, current.Keys...)
+	if len(keys) > count {
+		keys = keys[:count]
+	}
+	return SessionTicketKeys{Keys: keys, Rotated: time.Now()}, nil
+}
+
+// readSessionTicketKeys reads the base64 encoded 32 bytes keys of file, one per line.
+func readSessionTicketKeys(file string) ([][]byte, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var keys [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(string(line))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("invalid session ticket key in %s, expected 32 bytes base64 encoded", file)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no session ticket key in " + file)
+	}
+	return keys, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateSessionTicketKeys(t *testing.T) {
+	sessionTickets, err := newSessionTickets(&types.SessionTickets{RotationInterval: 3600, Keys: 2})
+	assert.NoError(t, err)
+	assert.NoError(t, sessionTickets.createLocalConfig())
+	first := sessionTickets.keys.Keys[0]
+	assert.Len(t, first, 32)
+
+	assert.NoError(t, sessionTickets.rotate())
+	assert.NoError(t, sessionTickets.rotate())
+	// the previous keys are kept to decrypt the tickets issued before the rotation
+	assert.Len(t, sessionTickets.keys.Keys, 2)
+	assert.NotEqual(t, first, sessionTickets.keys.Keys[0])
+	assert.NotEqual(t, first, sessionTickets.keys.Keys[1])
+
+	_, err = newSessionTickets(&types.SessionTickets{Keys: 2})
+	assert.Error(t, err)
+	_, err = newSessionTickets(&types.SessionTickets{RotationInterval: 3600})
+	assert.Error(t, err)
+}
+
+func TestReadSessionTicketKeys(t *testing.T) {
+	file, err := ioutil.TempFile("", "traefik-sessiontickets")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	file.WriteString("# newest first\n" + key + "\n\n" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", 32))) + "\n")
+	file.Close()
+
+	keys, err := readSessionTicketKeys(file.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(strings.Repeat("k", 32)), []byte(strings.Repeat("o", 32))}, keys)
+
+	ioutil.WriteFile(file.Name(), []byte(base64.StdEncoding.EncodeToString([]byte("short"))), 0600)
+	_, err = readSessionTicketKeys(file.Name())
+	assert.Error(t, err)
+	ioutil.WriteFile(file.Name(), []byte("# empty\n"), 0600)
+	_, err = readSessionTicketKeys(file.Name())
+	assert.Error(t, err)
+}
+
+func TestSessionTicketsResumption(t *testing.T) {
+	certificate := generateTestCertificate(t, "resume.localhost", time.Now().Add(time.Hour))
+	sessionTickets, err := newSessionTickets(&types.SessionTickets{RotationInterval: 3600, Keys: 2})
+	assert.NoError(t, err)
+	assert.NoError(t, sessionTickets.createLocalConfig())
+	// two instances sharing the keys
+	instance1 := &tls.Config{Certificates: []tls.Certificate{certificate}, MaxVersion: tls.VersionTLS12}
+	instance2 := &tls.Config{Certificates: []tls.Certificate{certificate}, MaxVersion: tls.VersionTLS12}
+	sessionTickets.register("https", instance1)
+	sessionTickets.register("https2", instance2)
+
+	client := &tls.Config{InsecureSkipVerify: true, ServerName: "resume.localhost", ClientSessionCache: tls.NewLRUClientSessionCache(1)}
+	assert.False(t, handshake(t, instance1, client).DidResume)
+	assert.True(t, handshake(t, instance2, client).DidResume)
+
+	// the sessions are resumed after a rotation, until their key is dropped
+	assert.NoError(t, sessionTickets.rotate())
+	assert.True(t, handshake(t, instance1, client).DidResume)
+	assert.NoError(t, sessionTickets.rotate())
+	assert.NoError(t, sessionTickets.rotate())
+	assert.False(t, handshake(t, instance1, client).DidResume)
+}
+
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) tls.ConnectionState {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	server := tls.Server(serverConn, serverConfig)
+	go server.Handshake()
+	client := tls.Client(clientConn, clientConfig)
+	if err := client.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	return client.ConnectionState()
+}
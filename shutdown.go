@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"syscall"
+)
+
+var (
+	shutdownLock    sync.Mutex
+	shutdownSignals = map[chan<- os.Signal]bool{}
+)
+
+// notifyShutdown makes requestShutdown send SIGTERM to signals, until
+// stopShutdown is called.
+func notifyShutdown(signals chan<- os.Signal) {
+	shutdownLock.Lock()
+	defer shutdownLock.Unlock()
+	shutdownSignals[signals] = true
+}
+
+func stopShutdown(signals chan<- os.Signal) {
+	shutdownLock.Lock()
+	defer shutdownLock.Unlock()
+	delete(shutdownSignals, signals)
+}
+
+// requestShutdown stops the servers gracefully, as SIGTERM does, for the
+// shutdown requests which aren't signals: the service control requests and
+// the console events of Windows. It returns false if no server is running.
+func requestShutdown() bool {
+	shutdownLock.Lock()
+	defer shutdownLock.Unlock()
+	for signals := range shutdownSignals {
+		select {
+		case signals <- syscall.SIGTERM:
+		default:
+			// a signal is already pending
+		}
+	}
+	return len(shutdownSignals) > 0
+}
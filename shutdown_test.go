@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestShutdown(t *testing.T) {
+	signals := make(chan os.Signal, 1)
+	notifyShutdown(signals)
+	assert.True(t, requestShutdown())
+	// the pending signal isn't duplicated
+	assert.True(t, requestShutdown())
+	assert.Equal(t, syscall.SIGTERM, <-signals)
+	assert.Len(t, signals, 0)
+
+	stopShutdown(signals)
+	requestShutdown()
+	assert.Len(t, signals, 0)
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+const (
+	// sloBucketDuration is the resolution of the SLO rolling windows.
+	sloBucketDuration = time.Minute
+	// sloBuckets is the number of buckets kept per frontend, it must cover the longest window.
+	sloBuckets = 60
+	// sloDefaultLatencyTarget is the percentage of requests that must be faster than the
+	// latency threshold when only the threshold is configured.
+	sloDefaultLatencyTarget = 99
+	// sloAlertBurnRate is the error budget burn rate above which a frontend is alerting
+	// on every window (a 30 days budget consumed in about 2 days).
+	sloAlertBurnRate = 14.4
+)
+
+// sloWindows are the rolling windows over which SLO compliance is computed:
+// the short one reacts quickly, the long one shows sustained budget consumption.
+var sloWindows = []time.Duration{5 * time.Minute, time.Hour}
+
+// SLORecorder records per frontend availability and latency over rolling
+// windows, and computes the compliance with the frontend service level
+// objectives. Its state survives configuration reloads.
+type SLORecorder struct {
+	// mutex guards the map, each frontend has its own lock so that the
+	// requests of different frontends are recorded concurrently
+	mutex     sync.RWMutex
+	frontends map[string]*sloFrontend
+}
+
+type sloFrontend struct {
+	mutex     sync.Mutex
+	objective types.SLO
+	buckets   [sloBuckets]sloBucket
+}
+
+type sloBucket struct {
+	start          int64
+	total          int64
+	errors         int64
+	slow           int64
+	streams        int64
+	streamDuration time.Duration
+}
+
+// SLOStatus is the SLO compliance of a frontend.
+type SLOStatus struct {
+	Frontend     string       `json:"frontend"`
+	Objective    types.SLO    `json:"objective"`
+	Windows      []*SLOWindow `json:"windows"`
+	Alerting     bool         `json:"alerting"`
+	AlertingText string       `json:"alerting_text,omitempty"`
+}
+
+// SLOWindow is the SLO compliance of a frontend over a rolling window.
+type SLOWindow struct {
+	Window                string  `json:"window"`
+	Requests              int64   `json:"requests"`
+	Errors                int64   `json:"errors"`
+	SlowRequests          int64   `json:"slow_requests"`
+	Streams               int64   `json:"streams"`
+	StreamsDuration       string  `json:"streams_average_duration,omitempty"`
+	Availability          float64 `json:"availability"`
+	LatencyCompliance     float64 `json:"latency_compliance"`
+	AvailabilityBurnRate  float64 `json:"availability_burn_rate"`
+	LatencyBurnRate       float64 `json:"latency_burn_rate"`
+	AvailabilityCompliant bool    `json:"availability_compliant"`
+	LatencyCompliant      bool    `json:"latency_compliant"`
+}
+
+// NewSLORecorder returns an empty SLORecorder.
+func NewSLORecorder() *SLORecorder {
+	return &SLORecorder{frontends: make(map[string]*sloFrontend)}
+}
+
+// SetObjectives replaces the recorded frontends objectives. Frontends that
+// are not in objectives anymore are dropped, the others keep their history.
+func (s *SLORecorder) SetObjectives(objectives map[string]types.SLO) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for name := range s.frontends {
+		if _, ok := objectives[name]; !ok {
+			delete(s.frontends, name)
+		}
+	}
+	for name, objective := range objectives {
+		if objective.Latency > 0 && objective.LatencyTarget == 0 {
+			objective.LatencyTarget = sloDefaultLatencyTarget
+		}
+		if frontend, ok := s.frontends[name]; ok {
+			frontend.mutex.Lock()
+			frontend.objective = objective
+			frontend.mutex.Unlock()
+		} else {
+			s.frontends[name] = &sloFrontend{objective: objective}
+		}
+	}
+}
+
+// Handler returns a handler recording the requests served by next for frontendName.
+// The streams, whose durations are up to the clients, are excluded from the latency objective.
+func (s *SLORecorder) Handler(frontendName string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &sloResponseWriter{ResponseWriter: rw, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		if recorder.hijacked || isStreamingResponse(recorder.Header()) || s.isStreamingRequest(frontendName, r) {
+			s.recordStream(frontendName, recorder.statusCode, time.Since(start), start)
+		} else {
+			s.record(frontendName, recorder.statusCode, time.Since(start), start)
+		}
+	})
+}
+
+// isStreamingRequest returns true for the websockets and server-sent events
+// requests, and the requests to the streaming paths of the frontend objective.
+func (s *SLORecorder) isStreamingRequest(frontendName string, r *http.Request) bool {
+	if len(r.Header.Get("Upgrade")) > 0 || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return true
+	}
+	frontend := s.frontend(frontendName)
+	if frontend == nil {
+		return false
+	}
+	frontend.mutex.Lock()
+	defer frontend.mutex.Unlock()
+	for _, path := range frontend.objective.StreamingPaths {
+		if strings.HasPrefix(r.URL.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SLORecorder) frontend(frontendName string) *sloFrontend {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.frontends[frontendName]
+}
+
+func isStreamingResponse(header http.Header) bool {
+	return strings.HasPrefix(header.Get("Content-Type"), "text/event-stream")
+}
+
+func (s *SLORecorder) record(frontendName string, statusCode int, duration time.Duration, now time.Time) {
+	s.add(frontendName, statusCode, duration, false, now)
+}
+
+func (s *SLORecorder) recordStream(frontendName string, statusCode int, duration time.Duration, now time.Time) {
+	s.add(frontendName, statusCode, duration, true, now)
+}
+
+func (s *SLORecorder) add(frontendName string, statusCode int, duration time.Duration, stream bool, now time.Time) {
+	frontend := s.frontend(frontendName)
+	if frontend == nil {
+		return
+	}
+	frontend.mutex.Lock()
+	defer frontend.mutex.Unlock()
+	bucketStart := now.Unix() / int64(sloBucketDuration/time.Second)
+	bucket := &frontend.buckets[bucketStart%sloBuckets]
+	if bucket.start != bucketStart {
+		*bucket = sloBucket{start: bucketStart}
+	}
+	bucket.total++
+	if statusCode >= 500 {
+		bucket.errors++
+	}
+	if stream {
+		bucket.streams++
+		bucket.streamDuration += duration
+	} else if frontend.objective.Latency > 0 && duration > time.Duration(frontend.objective.Latency)*time.Millisecond {
+		bucket.slow++
+	}
+}
+
+// Data returns the SLO compliance of all frontends, sorted by frontend name.
+func (s *SLORecorder) Data() []*SLOStatus {
+	return s.data(time.Now())
+}
+
+func (s *SLORecorder) data(now time.Time) []*SLOStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	currentBucket := now.Unix() / int64(sloBucketDuration/time.Second)
+	statuses := []*SLOStatus{}
+	for name, frontend := range s.frontends {
+		frontend.mutex.Lock()
+		status := &SLOStatus{
+			Frontend:  name,
+			Objective: frontend.objective,
+			Alerting:  true,
+		}
+		for _, window := range sloWindows {
+			sloWindow := frontend.window(window, currentBucket)
+			status.Windows = append(status.Windows, sloWindow)
+			if sloWindow.AvailabilityBurnRate < sloAlertBurnRate && sloWindow.LatencyBurnRate < sloAlertBurnRate {
+				status.Alerting = false
+			}
+		}
+		if status.Alerting {
+			status.AlertingText = "error budget burning too fast on every window"
+		}
+		frontend.mutex.Unlock()
+		statuses = append(statuses, status)
+	}
+	sort.Sort(sloStatusesByFrontend(statuses))
+	return statuses
+}
+
+func (f *sloFrontend) window(window time.Duration, currentBucket int64) *SLOWindow {
+	sloWindow := &SLOWindow{
+		Window:       window.String(),
+		Availability: 100,
+	}
+	first := currentBucket - int64(window/sloBucketDuration)
+	var streamDuration time.Duration
+	for _, bucket := range f.buckets {
+		if bucket.start > first && bucket.start <= currentBucket {
+			sloWindow.Requests += bucket.total
+			sloWindow.Errors += bucket.errors
+			sloWindow.SlowRequests += bucket.slow
+			sloWindow.Streams += bucket.streams
+			streamDuration += bucket.streamDuration
+		}
+	}
+	sloWindow.LatencyCompliance = 100
+	if sloWindow.Requests > 0 {
+		sloWindow.Availability = 100 * float64(sloWindow.Requests-sloWindow.Errors) / float64(sloWindow.Requests)
+	}
+	if measured := sloWindow.Requests - sloWindow.Streams; measured > 0 {
+		sloWindow.LatencyCompliance = 100 * float64(measured-sloWindow.SlowRequests) / float64(measured)
+	}
+	if sloWindow.Streams > 0 {
+		sloWindow.StreamsDuration = (streamDuration / time.Duration(sloWindow.Streams)).String()
+	}
+	sloWindow.AvailabilityBurnRate = burnRate(sloWindow.Availability, f.objective.Availability)
+	sloWindow.LatencyBurnRate = burnRate(sloWindow.LatencyCompliance, f.objective.LatencyTarget)
+	sloWindow.AvailabilityCompliant = f.objective.Availability == 0 || sloWindow.Availability >= f.objective.Availability
+	sloWindow.LatencyCompliant = f.objective.LatencyTarget == 0 || sloWindow.LatencyCompliance >= f.objective.LatencyTarget
+	return sloWindow
+}
+
+// burnRate returns how fast the error budget of objective is consumed, 1 meaning
+// that the budget will be exactly exhausted at the end of the SLO period.
+func burnRate(measured, objective float64) float64 {
+	if objective <= 0 || objective >= 100 {
+		return 0
+	}
+	return (100 - measured) / (100 - objective)
+}
+
+// writeSLOMetrics writes the SLO compliance of the frontends over the rolling
+// windows in the Prometheus text format. The windows roll, so their values
+// are gauges.
+func writeSLOMetrics(w io.Writer, statuses []*SLOStatus) {
+	if len(statuses) == 0 {
+		return
+	}
+	gauges := []struct {
+		name  string
+		help  string
+		value func(*SLOWindow) float64
+	}{
+		{"traefik_slo_requests", "Requests of the frontend over the window.", func(w *SLOWindow) float64 { return float64(w.Requests) }},
+		{"traefik_slo_availability_ratio", "Ratio of the requests of the frontend not returning a 5XX status code over the window.", func(w *SLOWindow) float64 { return w.Availability / 100 }},
+		{"traefik_slo_latency_compliance_ratio", "Ratio of the requests of the frontend, streams excluded, faster than the latency objective over the window.", func(w *SLOWindow) float64 { return w.LatencyCompliance / 100 }},
+		{"traefik_slo_availability_burn_rate", "Burn rate of the availability error budget of the frontend over the window.", func(w *SLOWindow) float64 { return w.AvailabilityBurnRate }},
+		{"traefik_slo_latency_burn_rate", "Burn rate of the latency error budget of the frontend over the window.", func(w *SLOWindow) float64 { return w.LatencyBurnRate }},
+	}
+	for _, gauge := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n", gauge.name, gauge.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", gauge.name)
+		for _, status := range statuses {
+			for _, window := range status.Windows {
+				fmt.Fprintf(w, "%s{frontend=\"%s\",window=\"%s\"} %g\n", gauge.name,
+					prometheusLabelEscaper.Replace(status.Frontend), window.Window, gauge.value(window))
+			}
+		}
+	}
+	fmt.Fprintln(w, "# HELP traefik_slo_alerting Whether the error budget of the frontend is burning too fast on every window.")
+	fmt.Fprintln(w, "# TYPE traefik_slo_alerting gauge")
+	for _, status := range statuses {
+		alerting := 0
+		if status.Alerting {
+			alerting = 1
+		}
+		fmt.Fprintf(w, "traefik_slo_alerting{frontend=\"%s\"} %d\n", prometheusLabelEscaper.Replace(status.Frontend), alerting)
+	}
+}
+
+type sloStatusesByFrontend []*SLOStatus
+
+func (a sloStatusesByFrontend) Len() int           { return len(a) }
+func (a sloStatusesByFrontend) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a sloStatusesByFrontend) Less(i, j int) bool { return a[i].Frontend < a[j].Frontend }
+
+// sloResponseWriter records the status code of the response, and whether the
+// connection was hijacked by an upgraded protocol.
+type sloResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	hijacked   bool
+}
+
+func (w *sloResponseWriter) WriteHeader(status int) {
+	w.ResponseWriter.WriteHeader(status)
+	w.statusCode = status
+}
+
+func (w *sloResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *sloResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *sloResponseWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
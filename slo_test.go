@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+func TestSLORecorderWindows(t *testing.T) {
+	recorder := NewSLORecorder()
+	recorder.SetObjectives(map[string]types.SLO{
+		"frontend1": {Availability: 99, Latency: 100},
+	})
+
+	now := time.Unix(1000*60, 0)
+	for i := 0; i < 90; i++ {
+		recorder.record("frontend1", http.StatusOK, 10*time.Millisecond, now)
+	}
+	for i := 0; i < 10; i++ {
+		recorder.record("frontend1", http.StatusBadGateway, 200*time.Millisecond, now)
+	}
+	// old requests, only in the long window
+	for i := 0; i < 100; i++ {
+		recorder.record("frontend1", http.StatusOK, 10*time.Millisecond, now.Add(-30*time.Minute))
+	}
+	// ignored, unknown frontend
+	recorder.record("frontend2", http.StatusBadGateway, 10*time.Millisecond, now)
+
+	statuses := recorder.data(now)
+	if len(statuses) != 1 {
+		t.Fatalf("expected 1 frontend, got %d", len(statuses))
+	}
+	status := statuses[0]
+	if status.Objective.LatencyTarget != sloDefaultLatencyTarget {
+		t.Errorf("expected default latency target %v, got %v", sloDefaultLatencyTarget, status.Objective.LatencyTarget)
+	}
+
+	short, long := status.Windows[0], status.Windows[1]
+	if short.Requests != 100 || short.Errors != 10 || short.SlowRequests != 10 {
+		t.Errorf("unexpected short window %+v", short)
+	}
+	if short.Availability != 90 || short.AvailabilityCompliant {
+		t.Errorf("unexpected short window availability %+v", short)
+	}
+	if short.AvailabilityBurnRate < 9.99 || short.AvailabilityBurnRate > 10.01 {
+		t.Errorf("expected short window burn rate 10, got %v", short.AvailabilityBurnRate)
+	}
+	if long.Requests != 200 || long.Availability != 95 {
+		t.Errorf("unexpected long window %+v", long)
+	}
+	if status.Alerting {
+		t.Errorf("burn rate %v should not be alerting", short.AvailabilityBurnRate)
+	}
+}
+
+func TestSLORecorderAlerting(t *testing.T) {
+	recorder := NewSLORecorder()
+	recorder.SetObjectives(map[string]types.SLO{"frontend1": {Availability: 99.9}})
+
+	now := time.Now()
+	recorder.record("frontend1", http.StatusOK, time.Millisecond, now)
+	recorder.record("frontend1", http.StatusServiceUnavailable, time.Millisecond, now)
+
+	statuses := recorder.data(now)
+	if !statuses[0].Alerting {
+		t.Errorf("frontend should be alerting, got %+v", statuses[0].Windows[0])
+	}
+
+	recorder.SetObjectives(map[string]types.SLO{})
+	if len(recorder.Data()) != 0 {
+		t.Errorf("removed frontends should not be reported anymore")
+	}
+}
+
+func TestSLORecorderHandler(t *testing.T) {
+	recorder := NewSLORecorder()
+	recorder.SetObjectives(map[string]types.SLO{"frontend1": {Availability: 99}})
+
+	handler := recorder.Handler("frontend1", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://localhost/", nil))
+
+	window := recorder.Data()[0].Windows[0]
+	if window.Requests != 1 || window.Errors != 1 {
+		t.Errorf("unexpected window %+v", window)
+	}
+}
+
+func TestSLORecorderStreams(t *testing.T) {
+	recorder := NewSLORecorder()
+	recorder.SetObjectives(map[string]types.SLO{"frontend1": {Latency: 10, StreamingPaths: []string{"/poll"}}})
+
+	handler := recorder.Handler("frontend1", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sse" {
+			rw.Header().Set("Content-Type", "text/event-stream")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	websocket := httptest.NewRequest("GET", "http://localhost/ws", nil)
+	websocket.Header.Set("Upgrade", "websocket")
+	for _, request := range []*http.Request{
+		websocket,
+		httptest.NewRequest("GET", "http://localhost/sse", nil),
+		httptest.NewRequest("GET", "http://localhost/poll/1", nil),
+		httptest.NewRequest("GET", "http://localhost/slow", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	window := recorder.Data()[0].Windows[0]
+	if window.Requests != 4 || window.Streams != 3 || window.SlowRequests != 1 {
+		t.Errorf("unexpected window %+v", window)
+	}
+	// only the slow request is measured
+	if window.LatencyCompliance != 0 {
+		t.Errorf("expected latency compliance 0, got %v", window.LatencyCompliance)
+	}
+	if duration, err := time.ParseDuration(window.StreamsDuration); err != nil || duration < 20*time.Millisecond {
+		t.Errorf("unexpected streams average duration %q", window.StreamsDuration)
+	}
+}
+
+func TestWriteSLOMetrics(t *testing.T) {
+	recorder := NewSLORecorder()
+	var buffer bytes.Buffer
+	writeSLOMetrics(&buffer, recorder.Data())
+	if buffer.Len() != 0 {
+		t.Errorf("expected no metrics without objectives, got %s", buffer.String())
+	}
+
+	recorder.SetObjectives(map[string]types.SLO{"frontend1": {Availability: 99}})
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		recorder.record("frontend1", http.StatusOK, time.Millisecond, now)
+	}
+	recorder.record("frontend1", http.StatusBadGateway, time.Millisecond, now)
+	writeSLOMetrics(&buffer, recorder.data(now))
+	metrics := buffer.String()
+	for _, expected := range []string{
+		`traefik_slo_requests{frontend="frontend1",window="5m0s"} 4`,
+		`traefik_slo_availability_ratio{frontend="frontend1",window="1h0m0s"} 0.75`,
+		`traefik_slo_availability_burn_rate{frontend="frontend1",window="5m0s"} 25`,
+		`traefik_slo_alerting{frontend="frontend1"} 1`,
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("expected %s in %s", expected, metrics)
+		}
+	}
+}
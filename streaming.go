@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// createStreamingTransport returns the transport of the servers of a streaming
+// backend. Without TLS configuration, the connections to the https servers
+// negotiate HTTP/2, so that the gRPC servers can be reached; the transports
+// verifying the TLS connections of a backend stay on HTTP/1.1.
+func createStreamingTransport(transport http.RoundTripper, expectContinueTimeout time.Duration) (http.RoundTripper, error) {
+	if transport == http.DefaultTransport {
+		transport = defaultBackendTransport(expectContinueTimeout)
+	}
+	streamingTransport, ok := transport.(*http.Transport)
+	if !ok || streamingTransport.DialTLS != nil {
+		return transport, nil
+	}
+	if err := http2.ConfigureTransport(streamingTransport); err != nil {
+		return nil, err
+	}
+	return streamingTransport, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamingBackend(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			// trailers-only response of a gRPC error
+			w.Header().Set("Grpc-Status", "5")
+			return
+		}
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Write([]byte("first\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("second\n"))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	defer ts.Close()
+
+	configuration := &types.Configuration{
+		Backends: map[string]*types.Backend{
+			"backend1": {
+				Servers:      map[string]types.Server{"server1": {URL: ts.URL}},
+				Streaming:    true,
+				LoadBalancer: &types.LoadBalancer{Method: "wrr"},
+			},
+		},
+	}
+	server := &Server{}
+	// the retries would buffer the responses
+	handler, err := server.loadBackend(configuration, GlobalConfiguration{Retry: &Retry{}}, "backend1", "frontend1", false, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the statistics wrap the response writers of the entrypoints
+	stats := &StatsRecorder{numRecentErrors: 10}
+	frontend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stats.ServeHTTP(w, r, handler.ServeHTTP)
+	}))
+	defer frontend.Close()
+	defer close(release)
+
+	var response *http.Response
+	var body *bufio.Reader
+	first := make(chan string)
+	go func() {
+		var err error
+		if response, err = http.Get(frontend.URL); err != nil {
+			first <- err.Error()
+			return
+		}
+		body = bufio.NewReader(response.Body)
+		line, _ := body.ReadString('\n')
+		first <- line
+	}()
+	select {
+	case line := <-first:
+		if !assert.Equal(t, "first\n", line) {
+			return
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first chunk was not flushed")
+	}
+	defer response.Body.Close()
+	release <- struct{}{}
+	rest, err := ioutil.ReadAll(body)
+	assert.NoError(t, err)
+	assert.Equal(t, "second\n", string(rest))
+	assert.Equal(t, "0", response.Trailer.Get("Grpc-Status"))
+
+	response, err = http.Get(frontend.URL + "/error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "5", response.Header.Get("Grpc-Status"))
+}
+
+func TestStreamingTransport(t *testing.T) {
+	transport, err := createStreamingTransport(http.DefaultTransport, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, transport.(*http.Transport).TLSNextProto["h2"])
+
+	backendTransport, err := createBackendTransport(GlobalConfiguration{}, &types.BackendTLS{}, nil, nil, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, err = createStreamingTransport(backendTransport, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, backendTransport, transport)
+}
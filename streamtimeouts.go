@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/types"
+)
+
+var errStreamAborted = errors.New("stream aborted")
+
+// StreamAborts counts the requests aborted by the timeouts of the backends,
+// per backend and reason.
+type StreamAborts struct {
+	mutex  sync.RWMutex
+	counts map[streamAbortKey]int64
+}
+
+type streamAbortKey struct {
+	backend string
+	reason  string
+}
+
+// StreamAbortCount is the number of requests of a backend aborted for the same reason.
+type StreamAbortCount struct {
+	Backend string `json:"backend"`
+	Reason  string `json:"reason"`
+	Count   int64  `json:"count"`
+}
+
+// NewStreamAborts returns an empty StreamAborts.
+func NewStreamAborts() *StreamAborts {
+	return &StreamAborts{counts: make(map[streamAbortKey]int64)}
+}
+
+// Handler returns a handler aborting the requests forwarded by next to the
+// servers of backendName when they exceed the timeouts.
+func (s *StreamAborts) Handler(backendName string, timeouts *types.BackendTimeouts, next http.Handler) http.Handler {
+	return &streamGuard{
+		aborts:      s,
+		backend:     backendName,
+		maxDuration: time.Duration(timeouts.MaxDuration) * time.Second,
+		stall:       time.Duration(timeouts.Stall) * time.Second,
+		next:        next,
+	}
+}
+
+// Data returns the abort counts, sorted by backend and reason.
+func (s *StreamAborts) Data() []*StreamAbortCount {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	counts := []*StreamAbortCount{}
+	for key, count := range s.counts {
+		counts = append(counts, &StreamAbortCount{Backend: key.backend, Reason: key.reason, Count: count})
+	}
+	sort.Sort(streamAbortCountsByKey(counts))
+	return counts
+}
+
+func (s *StreamAborts) record(backendName, reason string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.counts[streamAbortKey{backend: backendName, reason: reason}]++
+}
+
+// streamGuard aborts the requests lasting more than maxDuration, and the
+// streams that transferred no bytes of the request or of the response body for
+// stall. The keep-alives of the connections don't count as transfers.
+type streamGuard struct {
+	aborts      *StreamAborts
+	backend     string
+	maxDuration time.Duration
+	stall       time.Duration
+	next        http.Handler
+}
+
+func (g *streamGuard) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		// the websockets hijack the connections
+		g.next.ServeHTTP(rw, r)
+		return
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	stream := &streamActivity{cancel: cancel}
+	stream.touch()
+	if r.Body != nil {
+		r.Body = &streamBody{ReadCloser: r.Body, stream: stream}
+	}
+	writer := &streamResponseWriter{ResponseWriter: rw, stream: stream}
+	done := make(chan struct{})
+	go g.watch(stream, done)
+	g.next.ServeHTTP(writer, r.WithContext(ctx))
+	close(done)
+
+	reason := stream.abortReason()
+	if len(reason) == 0 {
+		return
+	}
+	g.aborts.record(g.backend, reason)
+	middlewares.SetErrorReason(r, reason)
+	if !writer.wroteHeader {
+		http.Error(rw, http.StatusText(http.StatusGatewayTimeout), http.StatusGatewayTimeout)
+		return
+	}
+	// the response is already started, closing the connection tells the client it is incomplete
+	log.Debugf("Aborting the stream of %s to backend %s: %s", r.URL, g.backend, reason)
+	if hijacker, ok := rw.(http.Hijacker); ok {
+		if conn, _, err := hijacker.Hijack(); err == nil {
+			conn.Close()
+		}
+	}
+}
+
+func (g *streamGuard) watch(stream *streamActivity, done chan struct{}) {
+	start := time.Now()
+	interval := g.stall / 4
+	if g.maxDuration > 0 && (interval == 0 || g.maxDuration/4 < interval) {
+		interval = g.maxDuration / 4
+	}
+	if interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			if g.maxDuration > 0 && now.Sub(start) >= g.maxDuration {
+				stream.abort(middlewares.ReasonMaxDuration)
+				return
+			}
+			if g.stall > 0 && now.Sub(stream.lastTransfer()) >= g.stall {
+				stream.abort(middlewares.ReasonStreamStalled)
+				return
+			}
+		}
+	}
+}
+
+// streamActivity records the last transfer of a stream, and why it was aborted.
+type streamActivity struct {
+	last   int64
+	mutex  sync.Mutex
+	reason string
+	cancel context.CancelFunc
+}
+
+func (s *streamActivity) touch() {
+	atomic.StoreInt64(&s.last, time.Now().UnixNano())
+}
+
+func (s *streamActivity) lastTransfer() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&s.last))
+}
+
+func (s *streamActivity) abort(reason string) {
+	s.mutex.Lock()
+	s.reason = reason
+	s.mutex.Unlock()
+	s.cancel()
+}
+
+func (s *streamActivity) abortReason() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.reason
+}
+
+type streamBody struct {
+	io.ReadCloser
+	stream *streamActivity
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.stream.touch()
+	}
+	return n, err
+}
+
+// streamResponseWriter records the transfers of the response body, and drops
+// the error responses of the forwarder once the stream is aborted.
+type streamResponseWriter struct {
+	http.ResponseWriter
+	stream      *streamActivity
+	wroteHeader bool
+}
+
+func (w *streamResponseWriter) WriteHeader(status int) {
+	if len(w.stream.abortReason()) > 0 {
+		return
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *streamResponseWriter) Write(data []byte) (int, error) {
+	if len(w.stream.abortReason()) > 0 {
+		return 0, errStreamAborted
+	}
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(data)
+	if n > 0 {
+		w.stream.touch()
+	}
+	return n, err
+}
+
+func (w *streamResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *streamResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *streamResponseWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+type streamAbortCountsByKey []*StreamAbortCount
+
+func (a streamAbortCountsByKey) Len() int      { return len(a) }
+func (a streamAbortCountsByKey) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a streamAbortCountsByKey) Less(i, j int) bool {
+	if a[i].Backend != a[j].Backend {
+		return a[i].Backend < a[j].Backend
+	}
+	return a[i].Reason < a[j].Reason
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/middlewares"
+	"github.com/stretchr/testify/assert"
+)
+
+// forwarder waits for its context like the forwarder, writing chunks every interval if set
+func forwarder(interval time.Duration, chunks int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if interval == 0 {
+			<-r.Context().Done()
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		for i := 0; i < chunks; i++ {
+			select {
+			case <-r.Context().Done():
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			case <-time.After(interval):
+			}
+			w.Write([]byte("chunk\n"))
+			w.(http.Flusher).Flush()
+		}
+	})
+}
+
+func TestStreamGuardStalled(t *testing.T) {
+	aborts := NewStreamAborts()
+	guard := &streamGuard{aborts: aborts, backend: "backend1", stall: 40 * time.Millisecond, next: forwarder(0, 0)}
+	request := middlewares.WithRequestInfo(httptest.NewRequest("GET", "/", nil))
+	recorder := httptest.NewRecorder()
+	guard.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+	assert.Equal(t, middlewares.ReasonStreamStalled, middlewares.GetErrorReason(request))
+	assert.Equal(t, []*StreamAbortCount{{Backend: "backend1", Reason: middlewares.ReasonStreamStalled, Count: 1}}, aborts.Data())
+}
+
+func TestStreamGuardActive(t *testing.T) {
+	aborts := NewStreamAborts()
+	guard := &streamGuard{aborts: aborts, backend: "backend1", stall: 80 * time.Millisecond, next: forwarder(10*time.Millisecond, 20)}
+	recorder := httptest.NewRecorder()
+	guard.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 20*len("chunk\n"), recorder.Body.Len())
+	assert.Empty(t, aborts.Data())
+}
+
+func TestStreamGuardMaxDuration(t *testing.T) {
+	aborts := NewStreamAborts()
+	guard := &streamGuard{aborts: aborts, backend: "backend1", maxDuration: 100 * time.Millisecond, stall: time.Second, next: forwarder(10*time.Millisecond, 1000)}
+	ts := httptest.NewServer(guard)
+	defer ts.Close()
+
+	response, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	// the connection is closed before the end of the chunked response
+	_, err = ioutil.ReadAll(response.Body)
+	assert.Error(t, err)
+	assert.Equal(t, []*StreamAbortCount{{Backend: "backend1", Reason: middlewares.ReasonMaxDuration, Count: 1}}, aborts.Data())
+}
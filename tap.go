@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tapDefaultDuration is the duration of a tap when none is requested.
+	tapDefaultDuration = 30 * time.Second
+	// tapMaxDuration bounds the duration of a tap, so a forgotten client doesn't tap forever.
+	tapMaxDuration = 5 * time.Minute
+	// tapBufferSize is the number of events buffered per tap, events are dropped when it is full.
+	tapBufferSize = 100
+	// tapRedacted replaces the values of the credential headers in the tapped requests.
+	tapRedacted = "[redacted]"
+)
+
+// tapCredentialHeaders are the request headers holding the credentials of the
+// clients, whose values are never streamed to the tapping clients.
+var tapCredentialHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+// RequestTap streams the metadata of the requests served by frontends to
+// tapping clients. Frontends without tapping clients are not slowed down.
+type RequestTap struct {
+	mutex       sync.RWMutex
+	subscribers map[string]map[*tapSubscriber]struct{}
+}
+
+type tapSubscriber struct {
+	events     chan *TapEvent
+	sampleRate float64
+	headers    []string
+}
+
+// TapEvent holds the metadata of a tapped request.
+type TapEvent struct {
+	Frontend string            `json:"frontend"`
+	Time     time.Time         `json:"time"`
+	Method   string            `json:"method"`
+	Host     string            `json:"host"`
+	Path     string            `json:"path"`
+	Status   int               `json:"status"`
+	Duration float64           `json:"duration_ms"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+// TapOptions holds the parameters of a tap.
+type TapOptions struct {
+	Duration   time.Duration
+	SampleRate float64
+	Headers    []string
+}
+
+// NewRequestTap returns a RequestTap without subscribers.
+func NewRequestTap() *RequestTap {
+	return &RequestTap{subscribers: make(map[string]map[*tapSubscriber]struct{})}
+}
+
+// parseTapOptions reads the duration, sample and headers query parameters of a tap request.
+func parseTapOptions(request *http.Request) (*TapOptions, error) {
+	options := &TapOptions{Duration: tapDefaultDuration, SampleRate: 1}
+	query := request.URL.Query()
+	if duration := query.Get("duration"); len(duration) > 0 {
+		parsed, err := time.ParseDuration(duration)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("Invalid tap duration %s", duration)
+		}
+		options.Duration = parsed
+	}
+	if options.Duration > tapMaxDuration {
+		options.Duration = tapMaxDuration
+	}
+	if sample := query.Get("sample"); len(sample) > 0 {
+		parsed, err := strconv.ParseFloat(sample, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			return nil, fmt.Errorf("Invalid tap sample rate %s, expected a number in ]0, 1]", sample)
+		}
+		options.SampleRate = parsed
+	}
+	for _, header := range strings.Split(query.Get("headers"), ",") {
+		if header = strings.TrimSpace(header); len(header) > 0 {
+			options.Headers = append(options.Headers, http.CanonicalHeaderKey(header))
+		}
+	}
+	return options, nil
+}
+
+// Handler returns a handler publishing the requests served by next for frontendName.
+func (t *RequestTap) Handler(frontendName string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		t.mutex.RLock()
+		tapped := len(t.subscribers[frontendName]) > 0
+		t.mutex.RUnlock()
+		if !tapped {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		start := time.Now()
+		recorder := &responseRecorder{rw, http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		t.publish(frontendName, r, recorder.statusCode, start, time.Since(start))
+	})
+}
+
+func (t *RequestTap) publish(frontendName string, r *http.Request, statusCode int, start time.Time, duration time.Duration) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	for subscriber := range t.subscribers[frontendName] {
+		if subscriber.sampleRate < 1 && rand.Float64() >= subscriber.sampleRate {
+			continue
+		}
+		event := &TapEvent{
+			Frontend: frontendName,
+			Time:     start,
+			Method:   r.Method,
+			Host:     r.Host,
+			Path:     r.URL.Path,
+			Status:   statusCode,
+			Duration: float64(duration) / float64(time.Millisecond),
+		}
+		for _, header := range subscriber.headers {
+			if value := r.Header.Get(header); len(value) > 0 {
+				if event.Headers == nil {
+					event.Headers = make(map[string]string)
+				}
+				if tapCredentialHeaders[header] {
+					value = tapRedacted
+				}
+				event.Headers[header] = value
+			}
+		}
+		select {
+		case subscriber.events <- event:
+		default:
+			// slow client, drop the event rather than slowing down the frontend
+		}
+	}
+}
+
+func (t *RequestTap) subscribe(frontendName string, options *TapOptions) *tapSubscriber {
+	subscriber := &tapSubscriber{
+		events:     make(chan *TapEvent, tapBufferSize),
+		sampleRate: options.SampleRate,
+		headers:    options.Headers,
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.subscribers[frontendName] == nil {
+		t.subscribers[frontendName] = make(map[*tapSubscriber]struct{})
+	}
+	t.subscribers[frontendName][subscriber] = struct{}{}
+	return subscriber
+}
+
+func (t *RequestTap) unsubscribe(frontendName string, subscriber *tapSubscriber) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.subscribers[frontendName], subscriber)
+	if len(t.subscribers[frontendName]) == 0 {
+		delete(t.subscribers, frontendName)
+	}
+}
+
+// Stream sends the requests served by frontendName to response as server-sent
+// events, until the tap duration expires or the client goes away.
+func (t *RequestTap) Stream(response http.ResponseWriter, request *http.Request, frontendName string, options *TapOptions) {
+	flusher, ok := response.(http.Flusher)
+	if !ok {
+		http.Error(response, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	subscriber := t.subscribe(frontendName, options)
+	defer t.unsubscribe(frontendName, subscriber)
+
+	response.Header().Set("Content-Type", "text/event-stream")
+	response.Header().Set("Cache-Control", "no-cache")
+	response.Header().Set("Connection", "keep-alive")
+	response.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	timer := time.NewTimer(options.Duration)
+	defer timer.Stop()
+	for {
+		select {
+		case event := <-subscriber.events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(response, "event: request\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-timer.C:
+			fmt.Fprintf(response, "event: end\ndata: {}\n\n")
+			flusher.Flush()
+			return
+		case <-request.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTapOptions(t *testing.T) {
+	cases := []struct {
+		query    string
+		expected *TapOptions
+		err      bool
+	}{
+		{"", &TapOptions{Duration: tapDefaultDuration, SampleRate: 1}, false},
+		{"duration=10s&sample=0.5&headers=user-agent,%20x-request-id", &TapOptions{Duration: 10 * time.Second, SampleRate: 0.5, Headers: []string{"User-Agent", "X-Request-Id"}}, false},
+		{"duration=1h", &TapOptions{Duration: tapMaxDuration, SampleRate: 1}, false},
+		{"duration=foo", nil, true},
+		{"sample=2", nil, true},
+		{"sample=0", nil, true},
+	}
+	for _, c := range cases {
+		request := httptest.NewRequest("GET", "/api/frontends/frontend1/tap?"+c.query, nil)
+		options, err := parseTapOptions(request)
+		if c.err {
+			if err == nil {
+				t.Errorf("expected an error for %q", c.query)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", c.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(options, c.expected) {
+			t.Errorf("expected %+v for %q, got %+v", c.expected, c.query, options)
+		}
+	}
+}
+
+func TestRequestTapHandler(t *testing.T) {
+	tap := NewRequestTap()
+	handler := tap.Handler("frontend1", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	}))
+
+	// not tapped: nothing is published
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	subscriber := tap.subscribe("frontend1", &TapOptions{SampleRate: 1, Headers: []string{"User-Agent", "Authorization"}})
+	request := httptest.NewRequest("POST", "http://test.localhost/path", nil)
+	request.Header.Set("User-Agent", "tap-test")
+	request.Header.Set("Authorization", "secret")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	tap.unsubscribe("frontend1", subscriber)
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	if len(subscriber.events) != 1 {
+		t.Fatalf("expected 1 tapped request, got %d", len(subscriber.events))
+	}
+	event := <-subscriber.events
+	if event.Frontend != "frontend1" || event.Method != "POST" || event.Host != "test.localhost" || event.Path != "/path" || event.Status != http.StatusTeapot {
+		t.Errorf("unexpected tapped request %+v", event)
+	}
+	if !reflect.DeepEqual(event.Headers, map[string]string{"User-Agent": "tap-test", "Authorization": "[redacted]"}) {
+		t.Errorf("expected the User-Agent header and the redacted Authorization header, got %v", event.Headers)
+	}
+	if len(tap.subscribers) != 0 {
+		t.Errorf("expected no subscriber left, got %d", len(tap.subscribers))
+	}
+}
+
+func TestRequestTapStream(t *testing.T) {
+	tap := NewRequestTap()
+	recorder := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		tap.Stream(recorder, httptest.NewRequest("GET", "/", nil), "frontend1", &TapOptions{Duration: 100 * time.Millisecond, SampleRate: 1})
+		close(done)
+	}()
+	for {
+		tap.mutex.RLock()
+		subscribed := len(tap.subscribers["frontend1"]) > 0
+		tap.mutex.RUnlock()
+		if subscribed {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	tap.publish("frontend1", httptest.NewRequest("GET", "/path", nil), http.StatusOK, time.Now(), time.Millisecond)
+	<-done
+
+	body := recorder.Body.String()
+	if recorder.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("expected an event stream, got %s", recorder.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(body, "event: request\ndata: {\"frontend\":\"frontend1\"") || !strings.HasSuffix(body, "event: end\ndata: {}\n\n") {
+		t.Errorf("unexpected event stream %q", body)
+	}
+}
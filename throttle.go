@@ -0,0 +1,105 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+)
+
+// throttler is a provider overriding providersThrottleDuration.
+type throttler interface {
+	GetThrottleDuration() time.Duration
+}
+
+// providersThrottle holds back the configurations of each provider received
+// less than its throttle duration after its previous one, and sends its last
+// configuration once it's quiet for the duration. The providers are throttled
+// apart, so that a provider sending configurations continuously doesn't hold
+// back the others.
+type providersThrottle struct {
+	mutex           sync.Mutex
+	defaultDuration time.Duration
+	durations       map[string]time.Duration
+	received        map[string]time.Time
+	sequences       map[string]int
+}
+
+func newProvidersThrottle(defaultDuration time.Duration) *providersThrottle {
+	return &providersThrottle{
+		defaultDuration: defaultDuration,
+		durations:       make(map[string]time.Duration),
+		received:        make(map[string]time.Time),
+		sequences:       make(map[string]int),
+	}
+}
+
+// forward sends the configurations of providerChan, from a provider throttled
+// for duration, to configurationChan until stop.
+func (t *providersThrottle) forward(stop chan bool, providerChan <-chan types.ConfigMessage, configurationChan chan<- types.ConfigMessage, duration time.Duration) {
+	for {
+		select {
+		case <-stop:
+			return
+		case configMsg := <-providerChan:
+			t.mutex.Lock()
+			t.durations[configMsg.ProviderName] = duration
+			t.mutex.Unlock()
+			configurationChan <- configMsg
+		}
+	}
+}
+
+// throttle sends configMsg to validatedChan now if its provider sent no
+// configuration for its throttle duration, or after the duration if the
+// provider sent no other configuration in the meantime.
+func (t *providersThrottle) throttle(configMsg types.ConfigMessage, validatedChan chan<- types.ConfigMessage) {
+	providerName := configMsg.ProviderName
+	now := time.Now()
+	t.mutex.Lock()
+	duration, ok := t.durations[providerName]
+	if !ok {
+		duration = t.defaultDuration
+	}
+	lastReceived := t.received[providerName]
+	t.received[providerName] = now
+	t.sequences[providerName]++
+	sequence := t.sequences[providerName]
+	t.mutex.Unlock()
+
+	if now.After(lastReceived.Add(duration)) {
+		log.Debugf("Last %s config received more than %s, OK", providerName, duration)
+		validatedChan <- configMsg
+		return
+	}
+	log.Debugf("Last %s config received less than %s, waiting...", providerName, duration)
+	safe.Go(func() {
+		<-time.After(duration)
+		t.mutex.Lock()
+		last := t.sequences[providerName] == sequence
+		t.mutex.Unlock()
+		if last {
+			log.Debugf("Waited for %s config, OK", providerName)
+			validatedChan <- configMsg
+		}
+	})
+}
+
+// pendingConfigurations coalesces the configurations of the providers
+// validated less than providersMinApplyInterval after the last reload, so that
+// they are applied together by the next one.
+type pendingConfigurations map[string]types.ConfigMessage
+
+// providerNames returns the names of the providers of the pending configurations, sorted.
+func (p pendingConfigurations) providerNames() string {
+	names := []string{}
+	for providerName := range p {
+		names = append(names, providerName)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
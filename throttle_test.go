@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvidersThrottle(t *testing.T) {
+	throttle := newProvidersThrottle(200 * time.Millisecond)
+	validatedChan := make(chan types.ConfigMessage, 10)
+
+	stop := make(chan bool)
+	defer close(stop)
+	providerChan := make(chan types.ConfigMessage)
+	configurationChan := make(chan types.ConfigMessage)
+	go throttle.forward(stop, providerChan, configurationChan, time.Second)
+	providerChan <- types.ConfigMessage{ProviderName: "kubernetes"}
+	throttle.throttle(<-configurationChan, validatedChan)
+	assert.Equal(t, "kubernetes", (<-validatedChan).ProviderName, "the first configuration isn't held back")
+
+	// kubernetes flaps within its throttle duration of 1s
+	for i := 0; i < 3; i++ {
+		throttle.throttle(types.ConfigMessage{ProviderName: "kubernetes", Configuration: &types.Configuration{}}, validatedChan)
+	}
+	throttle.throttle(types.ConfigMessage{ProviderName: "docker"}, validatedChan)
+	assert.Equal(t, "docker", (<-validatedChan).ProviderName, "docker isn't held back by kubernetes")
+	throttle.throttle(types.ConfigMessage{ProviderName: "docker", Configuration: &types.Configuration{}}, validatedChan)
+
+	select {
+	case configMsg := <-validatedChan:
+		assert.Equal(t, "docker", configMsg.ProviderName, "docker is throttled for the default duration")
+		assert.NotNil(t, configMsg.Configuration)
+	case <-time.After(time.Second):
+		t.Fatal("expected the last configuration of docker")
+	}
+	select {
+	case configMsg := <-validatedChan:
+		assert.Equal(t, "kubernetes", configMsg.ProviderName)
+		assert.NotNil(t, configMsg.Configuration)
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the last configuration of kubernetes")
+	}
+	select {
+	case configMsg := <-validatedChan:
+		t.Fatalf("expected a single configuration of kubernetes, got %+v", configMsg)
+	case <-time.After(1500 * time.Millisecond):
+	}
+}
+
+func TestPendingConfigurationsProviderNames(t *testing.T) {
+	pending := pendingConfigurations{
+		"kubernetes": types.ConfigMessage{ProviderName: "kubernetes"},
+		"docker":     types.ConfigMessage{ProviderName: "docker"},
+	}
+	assert.Equal(t, "docker,kubernetes", pending.providerNames())
+}
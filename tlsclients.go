@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/containous/traefik/middlewares"
+)
+
+// TLSClients counts the requests received over TLS per entrypoint, TLS
+// version, cipher suite and application protocol, to find the clients still
+// using deprecated versions before raising the minimum version.
+type TLSClients struct {
+	mutex  sync.RWMutex
+	counts map[TLSClientKey]int64
+}
+
+// TLSClientKey holds what is counted of the TLS connections of the requests.
+type TLSClientKey struct {
+	EntryPoint  string `json:"entryPoint"`
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipherSuite"`
+	Protocol    string `json:"protocol,omitempty"`
+}
+
+// TLSClientCount is the number of requests of an entrypoint over the same kind of TLS connection.
+type TLSClientCount struct {
+	TLSClientKey
+	Count int64 `json:"count"`
+}
+
+// NewTLSClients returns an empty TLSClients.
+func NewTLSClients() *TLSClients {
+	return &TLSClients{counts: make(map[TLSClientKey]int64)}
+}
+
+// Handler returns the middleware counting the requests of entryPointName.
+func (c *TLSClients) Handler(entryPointName string) *TLSClientsHandler {
+	return &TLSClientsHandler{clients: c, entryPoint: entryPointName}
+}
+
+// Data returns the request counts, sorted by entrypoint, version, cipher suite and protocol.
+func (c *TLSClients) Data() []*TLSClientCount {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	counts := []*TLSClientCount{}
+	for key, count := range c.counts {
+		counts = append(counts, &TLSClientCount{TLSClientKey: key, Count: count})
+	}
+	sort.Sort(tlsClientCountsByKey(counts))
+	return counts
+}
+
+// TLSClientsHandler is a middleware counting the TLS connections of the requests of an entrypoint.
+type TLSClientsHandler struct {
+	clients    *TLSClients
+	entryPoint string
+}
+
+func (h *TLSClientsHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if info := middlewares.GetTLSInfo(r); info != nil {
+		key := TLSClientKey{EntryPoint: h.entryPoint, Version: info.Version, CipherSuite: info.CipherSuite, Protocol: info.Protocol}
+		h.clients.mutex.Lock()
+		h.clients.counts[key]++
+		h.clients.mutex.Unlock()
+	}
+	next(rw, r)
+}
+
+type tlsClientCountsByKey []*TLSClientCount
+
+func (a tlsClientCountsByKey) Len() int      { return len(a) }
+func (a tlsClientCountsByKey) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a tlsClientCountsByKey) Less(i, j int) bool {
+	switch {
+	case a[i].EntryPoint != a[j].EntryPoint:
+		return a[i].EntryPoint < a[j].EntryPoint
+	case a[i].Version != a[j].Version:
+		return a[i].Version < a[j].Version
+	case a[i].CipherSuite != a[j].CipherSuite:
+		return a[i].CipherSuite < a[j].CipherSuite
+	}
+	return a[i].Protocol < a[j].Protocol
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSClients(t *testing.T) {
+	clients := NewTLSClients()
+	handler := clients.Handler("https")
+	next := func(w http.ResponseWriter, r *http.Request) {}
+	for _, state := range []*tls.ConnectionState{
+		nil,
+		{Version: tls.VersionTLS10, CipherSuite: tls.TLS_RSA_WITH_AES_128_CBC_SHA},
+		{Version: tls.VersionTLS12, CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, NegotiatedProtocol: "h2"},
+		{Version: tls.VersionTLS10, CipherSuite: tls.TLS_RSA_WITH_AES_128_CBC_SHA},
+	} {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.TLS = state
+		handler.ServeHTTP(httptest.NewRecorder(), request, next)
+	}
+	assert.Equal(t, []*TLSClientCount{
+		{TLSClientKey: TLSClientKey{EntryPoint: "https", Version: "TLS1.0", CipherSuite: "TLS_RSA_WITH_AES_128_CBC_SHA"}, Count: 2},
+		{TLSClientKey: TLSClientKey{EntryPoint: "https", Version: "TLS1.2", CipherSuite: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", Protocol: "h2"}, Count: 1},
+	}, clients.Data())
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto/tls"
+	fmtlog "log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+)
+
+// Reasons of the TLS handshake errors
+const (
+	handshakeUnknownSNI      = "unknown_sni"
+	handshakeProtocolVersion = "protocol_version"
+	handshakeCipherSuite     = "cipher_suite"
+	handshakeClientAuth      = "client_auth"
+	handshakeBadCertificate  = "bad_certificate"
+	handshakeNotTLS          = "not_tls"
+	handshakeTimeout         = "timeout"
+	handshakeClosed          = "closed"
+	handshakeOther           = "other"
+	// handshakeLogInterval is the minimum interval between the logs of the errors of an entrypoint with the same reason
+	handshakeLogInterval = 10 * time.Second
+)
+
+// handshakeErrorPrefix starts the logs of the TLS handshake errors of net/http
+const handshakeErrorPrefix = "http: TLS handshake error from "
+
+// TLSHandshakes counts the TLS handshake errors of the entrypoints per reason,
+// and logs a sample of the clients and server names causing them.
+type TLSHandshakes struct {
+	mutex   sync.Mutex
+	errors  map[tlsHandshakeKey]*tlsHandshakeErrors
+	nowFunc func() time.Time
+}
+
+type tlsHandshakeKey struct {
+	entryPoint string
+	reason     string
+}
+
+type tlsHandshakeErrors struct {
+	count      int64
+	lastLog    time.Time
+	suppressed int64
+}
+
+// TLSHandshakeErrorCount is the number of TLS handshake errors of an entrypoint with the same reason.
+type TLSHandshakeErrorCount struct {
+	EntryPoint string `json:"entryPoint"`
+	Reason     string `json:"reason"`
+	Count      int64  `json:"count"`
+}
+
+// NewTLSHandshakes returns an empty TLSHandshakes.
+func NewTLSHandshakes() *TLSHandshakes {
+	return &TLSHandshakes{errors: make(map[tlsHandshakeKey]*tlsHandshakeErrors), nowFunc: time.Now}
+}
+
+// Data returns the error counts, sorted by entrypoint and reason.
+func (t *TLSHandshakes) Data() []*TLSHandshakeErrorCount {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	counts := []*TLSHandshakeErrorCount{}
+	for key, errors := range t.errors {
+		counts = append(counts, &TLSHandshakeErrorCount{EntryPoint: key.entryPoint, Reason: key.reason, Count: errors.count})
+	}
+	sort.Sort(tlsHandshakeErrorCountsByKey(counts))
+	return counts
+}
+
+// ErrorLog returns the error logger of the server of entryPointName, recording
+// its TLS handshake errors and forwarding its other errors to the log.
+func (t *TLSHandshakes) ErrorLog(entryPointName string) *fmtlog.Logger {
+	return fmtlog.New(&tlsErrorLogWriter{handshakes: t, entryPoint: entryPointName}, "", 0)
+}
+
+// CheckServerName wraps the GetCertificate of config to record the server
+// names matching no certificate of entryPointName. The default certificate is
+// still served to these clients, which usually reject it.
+func (t *TLSHandshakes) CheckServerName(entryPointName string, config *tls.Config) {
+	getCertificate := config.GetCertificate
+	config.GetCertificate = func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if getCertificate != nil {
+			if certificate, err := getCertificate(clientHello); certificate != nil || err != nil {
+				return certificate, err
+			}
+		}
+		if len(clientHello.ServerName) > 0 && !hasCertificate(config, clientHello.ServerName) {
+			t.record(entryPointName, handshakeUnknownSNI, "", clientHello.ServerName)
+		}
+		return nil, nil
+	}
+}
+
+func (t *TLSHandshakes) record(entryPointName, reason, client, serverName string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	key := tlsHandshakeKey{entryPoint: entryPointName, reason: reason}
+	errors, ok := t.errors[key]
+	if !ok {
+		errors = &tlsHandshakeErrors{}
+		t.errors[key] = errors
+	}
+	errors.count++
+	now := t.nowFunc()
+	if now.Sub(errors.lastLog) < handshakeLogInterval {
+		errors.suppressed++
+		return
+	}
+	description := "TLS handshake error " + reason + " on entrypoint " + entryPointName
+	if len(client) > 0 {
+		description += " from " + client
+	}
+	if len(serverName) > 0 {
+		description += " for server name " + serverName
+	}
+	if errors.suppressed > 0 {
+		log.Infof("%s, %d similar errors not logged", description, errors.suppressed)
+	} else {
+		log.Info(description)
+	}
+	errors.lastLog = now
+	errors.suppressed = 0
+}
+
+// tlsErrorLogWriter parses the logs of the errors of a server.
+type tlsErrorLogWriter struct {
+	handshakes *TLSHandshakes
+	entryPoint string
+}
+
+func (w *tlsErrorLogWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSpace(string(p))
+	if !strings.HasPrefix(line, handshakeErrorPrefix) {
+		log.Error(line)
+		return len(p), nil
+	}
+	// the client address is followed by the error
+	client := strings.TrimPrefix(line, handshakeErrorPrefix)
+	message := ""
+	if i := strings.Index(client, ": "); i >= 0 {
+		client, message = client[:i], client[i+2:]
+	}
+	if host, _, err := net.SplitHostPort(client); err == nil {
+		client = host
+	}
+	w.handshakes.record(w.entryPoint, handshakeReason(message), client, "")
+	return len(p), nil
+}
+
+// handshakeReason returns the reason of the TLS handshake error message
+func handshakeReason(message string) string {
+	switch {
+	case strings.Contains(message, "unsupported versions"), strings.Contains(message, "unsupported, maximum protocol version"),
+		strings.Contains(message, "protocol version"):
+		return handshakeProtocolVersion
+	case strings.Contains(message, "cipher suite"):
+		return handshakeCipherSuite
+	case strings.Contains(message, "client didn't provide a certificate"), strings.Contains(message, "client's certificate"),
+		strings.Contains(message, "client certificate"):
+		return handshakeClientAuth
+	case strings.Contains(message, "remote error: tls: bad certificate"), strings.Contains(message, "remote error: tls: unknown certificate"):
+		// the client rejected the certificate of the server
+		return handshakeBadCertificate
+	case strings.Contains(message, "does not look like a TLS handshake"), strings.Contains(message, "HTTP request to an HTTPS server"),
+		strings.Contains(message, "oversized record"):
+		return handshakeNotTLS
+	case strings.Contains(message, "i/o timeout"):
+		return handshakeTimeout
+	case message == "EOF", strings.Contains(message, "connection reset"), strings.Contains(message, "broken pipe"):
+		return handshakeClosed
+	}
+	return handshakeOther
+}
+
+// hasCertificate returns true if a certificate of config matches serverName,
+// looked up like crypto/tls does.
+func hasCertificate(config *tls.Config, serverName string) bool {
+	name := strings.TrimRight(strings.ToLower(serverName), ".")
+	if _, ok := config.NameToCertificate[name]; ok {
+		return true
+	}
+	labels := strings.Split(name, ".")
+	labels[0] = "*"
+	_, ok := config.NameToCertificate[strings.Join(labels, ".")]
+	return ok
+}
+
+// handshakeDeadline returns a connection state hook closing the new TLS
+// connections that don't complete their handshake within timeout.
+func handshakeDeadline(timeout time.Duration) func(net.Conn, http.ConnState) {
+	return func(conn net.Conn, state http.ConnState) {
+		tlsConn, ok := conn.(*tls.Conn)
+		if !ok || state != http.StateNew {
+			return
+		}
+		tlsConn.SetDeadline(time.Now().Add(timeout))
+		go func() {
+			// waits for the handshake of the server, and lifts the deadline once it is complete
+			if err := tlsConn.Handshake(); err == nil {
+				tlsConn.SetDeadline(time.Time{})
+			}
+		}()
+	}
+}
+
+type tlsHandshakeErrorCountsByKey []*TLSHandshakeErrorCount
+
+func (a tlsHandshakeErrorCountsByKey) Len() int      { return len(a) }
+func (a tlsHandshakeErrorCountsByKey) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a tlsHandshakeErrorCountsByKey) Less(i, j int) bool {
+	if a[i].EntryPoint != a[j].EntryPoint {
+		return a[i].EntryPoint < a[j].EntryPoint
+	}
+	return a[i].Reason < a[j].Reason
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSHandshakeErrors(t *testing.T) {
+	handshakes := NewTLSHandshakes()
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Config.ErrorLog = handshakes.ErrorLog("https")
+	ts.Config.ConnState = handshakeDeadline(100 * time.Millisecond)
+	ts.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	ts.StartTLS()
+	defer ts.Close()
+	ts.TLS.BuildNameToCertificate()
+	handshakes.CheckServerName("https", ts.TLS)
+
+	address := ts.Listener.Addr().String()
+	// TLS 1.1 client
+	if conn, err := tls.Dial("tcp", address, &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS11}); err == nil {
+		conn.Close()
+		t.Fatal("expected a protocol version error")
+	}
+	// server name matching no certificate, the client rejects the default certificate
+	if conn, err := tls.Dial("tcp", address, &tls.Config{ServerName: "unknown.org"}); err == nil {
+		conn.Close()
+		t.Fatal("expected a certificate error")
+	}
+	// plain HTTP client
+	if response, err := http.Get("http://" + address); err == nil {
+		response.Body.Close()
+	}
+	// client not starting the handshake
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = ioutil.ReadAll(conn)
+	assert.NoError(t, err, "the connection should be closed by the server")
+
+	// a complete handshake lifts the deadline
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	response, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	time.Sleep(200 * time.Millisecond)
+	response, err = client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	// the errors are logged asynchronously by the server
+	expected := []*TLSHandshakeErrorCount{
+		{EntryPoint: "https", Reason: handshakeBadCertificate, Count: 1},
+		{EntryPoint: "https", Reason: handshakeNotTLS, Count: 1},
+		{EntryPoint: "https", Reason: handshakeProtocolVersion, Count: 1},
+		{EntryPoint: "https", Reason: handshakeTimeout, Count: 1},
+		{EntryPoint: "https", Reason: handshakeUnknownSNI, Count: 1},
+	}
+	for i := 0; i < 50 && len(handshakes.Data()) < len(expected); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, expected, handshakes.Data())
+}
+
+func TestHandshakeReason(t *testing.T) {
+	for message, reason := range map[string]string{
+		"tls: client offered only unsupported versions: [302 301]":  handshakeProtocolVersion,
+		"tls: no cipher suite supported by both client and server":  handshakeCipherSuite,
+		"tls: client didn't provide a certificate":                  handshakeClientAuth,
+		"tls: failed to verify client's certificate: x509: unknown": handshakeClientAuth,
+		"remote error: tls: bad certificate":                        handshakeBadCertificate,
+		"tls: first record does not look like a TLS handshake":      handshakeNotTLS,
+		"read tcp 127.0.0.1:443->127.0.0.1:5000: i/o timeout":       handshakeTimeout,
+		"EOF":                     handshakeClosed,
+		"tls: unexpected message": handshakeOther,
+	} {
+		assert.Equal(t, reason, handshakeReason(message), message)
+	}
+}
@@ -0,0 +1,148 @@
+package totp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/docker/libkv"
+	"github.com/docker/libkv/store"
+	"github.com/docker/libkv/store/boltdb"
+	"github.com/docker/libkv/store/consul"
+	"github.com/docker/libkv/store/etcd"
+	"github.com/docker/libkv/store/zookeeper"
+)
+
+const (
+	// kvSeedTTL is the duration the seeds read from a KV store are kept
+	kvSeedTTL = 30 * time.Second
+	// kvMaxCachedSeeds is the number of cached seeds above which the expired ones are dropped
+	kvMaxCachedSeeds = 1000
+)
+
+func init() {
+	boltdb.Register()
+	consul.Register()
+	etcd.Register()
+	zookeeper.Register()
+}
+
+// seedSource returns the seed of a user, nil if the user has none.
+type seedSource interface {
+	seed(user string) ([]byte, error)
+}
+
+// fileSource reads the seeds from the "user:seed" lines of a file, reloaded when it is modified
+type fileSource struct {
+	filename string
+	mutex    sync.Mutex
+	modTime  time.Time
+	seeds    map[string][]byte
+}
+
+func (s *fileSource) seed(user string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	info, err := os.Stat(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	if s.seeds == nil || !info.ModTime().Equal(s.modTime) {
+		data, err := ioutil.ReadFile(s.filename)
+		if err != nil {
+			return nil, err
+		}
+		seeds, err := parseSeeds(data)
+		if err != nil {
+			return nil, fmt.Errorf("error reading TOTP seeds %s: %v", s.filename, err)
+		}
+		s.seeds, s.modTime = seeds, info.ModTime()
+	}
+	return s.seeds[user], nil
+}
+
+func parseSeeds(data []byte) (map[string][]byte, error) {
+	seeds := map[string][]byte{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		separator := strings.LastIndex(line, ":")
+		if separator < 1 {
+			return nil, fmt.Errorf("line %d: expected user:seed", number)
+		}
+		seed, err := decodeSeed(line[separator+1:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid seed: %v", number, err)
+		}
+		seeds[line[:separator]] = seed
+	}
+	return seeds, scanner.Err()
+}
+
+// kvSource reads the seed of a user from the prefix/user key of a KV store
+type kvSource struct {
+	backend  string
+	endpoint string
+	prefix   string
+	mutex    sync.Mutex
+	kvclient store.Store
+	seeds    map[string]*kvSeed
+}
+
+type kvSeed struct {
+	seed    []byte
+	expires time.Time
+}
+
+func (s *kvSource) seed(user string) ([]byte, error) {
+	// the users are path components of the keys
+	if strings.ContainsAny(user, "/") || user == "." || user == ".." {
+		return nil, nil
+	}
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if cached, ok := s.seeds[user]; ok && now.Before(cached.expires) {
+		return cached.seed, nil
+	}
+	if s.kvclient == nil {
+		kvclient, err := libkv.NewStore(
+			store.Backend(s.backend),
+			strings.Split(s.endpoint, ","),
+			&store.Config{ConnectionTimeout: 30 * time.Second, Bucket: "traefik"},
+		)
+		if err != nil {
+			return nil, err
+		}
+		s.kvclient = kvclient
+	}
+	var seed []byte
+	pair, err := s.kvclient.Get(s.prefix + "/" + user)
+	if err != nil && err != store.ErrKeyNotFound {
+		return nil, err
+	}
+	if err == nil {
+		seed, err = decodeSeed(string(pair.Value))
+		if err != nil {
+			log.Errorf("Invalid TOTP seed for user %s: %v", user, err)
+		}
+	}
+	if len(s.seeds) >= kvMaxCachedSeeds {
+		for cachedUser, cached := range s.seeds {
+			if now.After(cached.expires) {
+				delete(s.seeds, cachedUser)
+			}
+		}
+	}
+	s.seeds[user] = &kvSeed{seed: seed, expires: now.Add(kvSeedTTL)}
+	return seed, nil
+}
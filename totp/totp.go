@@ -0,0 +1,189 @@
+package totp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+const (
+	defaultHeader    = "X-TOTP-Code"
+	defaultFormField = "totp"
+	defaultDigits    = 6
+	defaultPeriod    = 30
+	defaultSkew      = 1
+	// maxFormSize bounds the POSTed forms read to find the code
+	maxFormSize = 64 * 1024
+)
+
+// Verifier checks the one-time passwords of the users against their seeds.
+// A password is accepted only once, so that an intercepted one can't be replayed.
+type Verifier struct {
+	config    types.TOTP
+	source    seedSource
+	mutex     sync.Mutex
+	lastSteps map[string]uint64
+}
+
+var (
+	verifiersMutex sync.Mutex
+	verifiers      = map[string]*Verifier{}
+)
+
+// GetVerifier returns the Verifier of config, shared by the identical
+// configurations so that the used passwords are remembered across reloads.
+func GetVerifier(config *types.TOTP) (*Verifier, error) {
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	verifiersMutex.Lock()
+	defer verifiersMutex.Unlock()
+	if verifier, ok := verifiers[string(encoded)]; ok {
+		return verifier, nil
+	}
+	verifier, err := NewVerifier(config)
+	if err != nil {
+		return nil, err
+	}
+	verifiers[string(encoded)] = verifier
+	return verifier, nil
+}
+
+// NewVerifier returns a Verifier for config.
+func NewVerifier(config *types.TOTP) (*Verifier, error) {
+	v := &Verifier{config: *config, lastSteps: map[string]uint64{}}
+	if len(config.SeedsFile) > 0 && len(config.KVBackend) > 0 {
+		return nil, errors.New("TOTP seeds must be read from either a file or a KV store")
+	}
+	if len(config.SeedsFile) > 0 {
+		v.source = &fileSource{filename: config.SeedsFile}
+	} else if len(config.KVBackend) > 0 {
+		v.source = &kvSource{backend: config.KVBackend, endpoint: config.KVEndpoint, prefix: strings.TrimSuffix(config.KVPrefix, "/"), seeds: map[string]*kvSeed{}}
+	} else {
+		return nil, errors.New("TOTP seeds file or KV store is required")
+	}
+	if len(v.config.Header) == 0 {
+		v.config.Header = defaultHeader
+	}
+	if len(v.config.FormField) == 0 {
+		v.config.FormField = defaultFormField
+	}
+	if v.config.Digits == 0 {
+		v.config.Digits = defaultDigits
+	}
+	if v.config.Digits < 6 || v.config.Digits > 8 {
+		return nil, fmt.Errorf("invalid TOTP digits %d, expected 6 to 8", v.config.Digits)
+	}
+	if v.config.Period <= 0 {
+		v.config.Period = defaultPeriod
+	}
+	if v.config.Skew <= 0 {
+		v.config.Skew = defaultSkew
+	}
+	return v, nil
+}
+
+// RequestCode returns the one-time password sent with r, or an empty string.
+func (v *Verifier) RequestCode(r *http.Request) string {
+	if code := r.Header.Get(v.config.Header); len(code) > 0 {
+		return strings.TrimSpace(code)
+	}
+	if code := r.URL.Query().Get(v.config.FormField); len(code) > 0 {
+		return strings.TrimSpace(code)
+	}
+	if r.Method != "POST" || r.Body == nil {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" {
+		return ""
+	}
+	// the form is kept in the body, for the backend
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxFormSize))
+	r.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil {
+		return ""
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(form.Get(v.config.FormField))
+}
+
+// Verify returns true if code is the current one-time password of user, and
+// was not used yet. An error is returned if the seed of user couldn't be loaded.
+func (v *Verifier) Verify(user, code string, now time.Time) (bool, error) {
+	if len(code) != v.config.Digits {
+		return false, nil
+	}
+	seed, err := v.source.seed(user)
+	if err != nil || seed == nil {
+		return false, err
+	}
+	step := uint64(now.Unix()) / uint64(v.config.Period)
+	for skew := -v.config.Skew; skew <= v.config.Skew; skew++ {
+		candidate := step + uint64(skew)
+		if subtle.ConstantTimeCompare([]byte(generate(seed, candidate, v.config.Digits)), []byte(code)) != 1 {
+			continue
+		}
+		v.mutex.Lock()
+		defer v.mutex.Unlock()
+		if last, ok := v.lastSteps[user]; ok && candidate <= last {
+			return false, nil
+		}
+		v.lastSteps[user] = candidate
+		return true, nil
+	}
+	return false, nil
+}
+
+// generate returns the HOTP (RFC 4226) of seed for counter
+func generate(seed []byte, counter uint64, digits int) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, counter)
+	mac := hmac.New(sha1.New, seed)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
+
+// decodeSeed decodes a base32 seed, which may be lower case, spaced or unpadded
+func decodeSeed(encoded string) ([]byte, error) {
+	encoded = strings.ToUpper(strings.Replace(strings.TrimSpace(encoded), " ", "", -1))
+	encoded = strings.TrimRight(encoded, "=")
+	if padding := len(encoded) % 8; padding > 0 {
+		encoded += strings.Repeat("=", 8-padding)
+	}
+	seed, err := base32.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) == 0 {
+		return nil, errors.New("empty seed")
+	}
+	return seed, nil
+}
@@ -0,0 +1,122 @@
+package totp
+
+import (
+	"encoding/base32"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSeed is the seed of the SHA1 test vectors of RFC 6238
+var rfcSeed = []byte("12345678901234567890")
+
+func TestGenerate(t *testing.T) {
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+	for unix, expected := range vectors {
+		assert.Equal(t, expected, generate(rfcSeed, uint64(unix)/30, 8), "time %d", unix)
+	}
+}
+
+func TestDecodeSeed(t *testing.T) {
+	encoded := base32.StdEncoding.EncodeToString(rfcSeed)
+	for _, variant := range []string{encoded, strings.ToLower(encoded), strings.TrimRight(encoded, "="), " " + encoded[:8] + " " + encoded[8:]} {
+		seed, err := decodeSeed(variant)
+		assert.NoError(t, err, variant)
+		assert.Equal(t, rfcSeed, seed, variant)
+	}
+	_, err := decodeSeed("not base32!")
+	assert.Error(t, err)
+	_, err = decodeSeed("")
+	assert.Error(t, err)
+}
+
+func newFileVerifier(t *testing.T, seeds string) (*Verifier, func()) {
+	file, err := ioutil.TempFile("", "totp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(seeds)
+	file.Close()
+	verifier, err := NewVerifier(&types.TOTP{SeedsFile: file.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier, func() { os.Remove(file.Name()) }
+}
+
+func TestVerify(t *testing.T) {
+	verifier, remove := newFileVerifier(t, "# users\nalice:"+base32.StdEncoding.EncodeToString(rfcSeed)+"\n")
+	defer remove()
+
+	now := time.Unix(1111111111, 0)
+	step := uint64(now.Unix()) / 30
+	current := generate(rfcSeed, step, 6)
+
+	ok, err := verifier.Verify("bob", current, now)
+	assert.NoError(t, err)
+	assert.False(t, ok, "a user without a seed should be rejected")
+	ok, _ = verifier.Verify("alice", "000000", now)
+	assert.False(t, ok)
+	ok, _ = verifier.Verify("alice", generate(rfcSeed, step-2, 6), now)
+	assert.False(t, ok, "a code out of the skew should be rejected")
+
+	ok, _ = verifier.Verify("alice", generate(rfcSeed, step-1, 6), now)
+	assert.True(t, ok, "the previous code should be accepted")
+	ok, _ = verifier.Verify("alice", current, now)
+	assert.True(t, ok)
+	ok, _ = verifier.Verify("alice", current, now)
+	assert.False(t, ok, "a code should not be replayed")
+	ok, _ = verifier.Verify("alice", generate(rfcSeed, step-1, 6), now)
+	assert.False(t, ok, "an older code should not be accepted after a newer one")
+}
+
+func TestSeedsFileErrors(t *testing.T) {
+	verifier, remove := newFileVerifier(t, "alice\n")
+	defer remove()
+	_, err := verifier.Verify("alice", "123456", time.Now())
+	assert.Error(t, err)
+
+	_, err = NewVerifier(&types.TOTP{})
+	assert.Error(t, err)
+	_, err = NewVerifier(&types.TOTP{SeedsFile: "seeds", KVBackend: "consul"})
+	assert.Error(t, err)
+	_, err = NewVerifier(&types.TOTP{SeedsFile: "seeds", Digits: 4})
+	assert.Error(t, err)
+}
+
+func TestRequestCode(t *testing.T) {
+	verifier, err := NewVerifier(&types.TOTP{SeedsFile: "seeds"})
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("X-TOTP-Code", " 123456 ")
+	assert.Equal(t, "123456", verifier.RequestCode(request))
+
+	request = httptest.NewRequest("GET", "/?totp=234567", nil)
+	assert.Equal(t, "234567", verifier.RequestCode(request))
+
+	request = httptest.NewRequest("POST", "/", strings.NewReader("user=alice&totp=345678"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal(t, "345678", verifier.RequestCode(request))
+	body, _ := ioutil.ReadAll(request.Body)
+	assert.Equal(t, "user=alice&totp=345678", string(body), "the form should be kept for the backend")
+
+	request = httptest.NewRequest("POST", "/", strings.NewReader(`{"totp": "456789"}`))
+	request.Header.Set("Content-Type", "application/json")
+	assert.Equal(t, "", verifier.RequestCode(request))
+	assert.Equal(t, "", verifier.RequestCode(&http.Request{Method: "GET", URL: request.URL, Header: http.Header{}}))
+}
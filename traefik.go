@@ -18,8 +18,11 @@ import (
 	"github.com/containous/staert"
 	"github.com/containous/traefik/acme"
 	"github.com/containous/traefik/cluster"
+	"github.com/containous/traefik/externaldns"
+	"github.com/containous/traefik/internalca"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/provider"
 	"github.com/containous/traefik/provider/k8s"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
@@ -41,6 +44,8 @@ func main() {
 	//traefik config inits
 	traefikConfiguration := NewTraefikConfiguration()
 	traefikPointersConfiguration := NewTraefikDefaultPointersConfiguration()
+	// output of the used command, known once the command line is parsed
+	var output *commandOutput
 	//traefik Command init
 	traefikCmd := &flaeg.Command{
 		Name: "traefik",
@@ -49,30 +54,27 @@ Complete documentation is available at https://traefik.io`,
 		Config:                traefikConfiguration,
 		DefaultPointersConfig: traefikPointersConfiguration,
 		Run: func() error {
+			notifyConsoleShutdown()
 			run(traefikConfiguration)
-			return nil
+			return output.success(nil)
 		},
 	}
 
 	//version Command init
+	versionConfiguration := &VersionConfiguration{Output: outputText}
 	versionCmd := &flaeg.Command{
 		Name:                  "version",
 		Description:           `Print version`,
-		Config:                struct{}{},
-		DefaultPointersConfig: struct{}{},
+		Config:                versionConfiguration,
+		DefaultPointersConfig: &VersionConfiguration{},
 		Run: func() error {
-			tmpl, err := template.New("").Parse(versionTemplate)
-			if err != nil {
-				return err
-			}
-
 			v := struct {
-				Version   string
-				Codename  string
-				GoVersion string
-				BuildTime string
-				Os        string
-				Arch      string
+				Version   string `json:"version"`
+				Codename  string `json:"codename"`
+				GoVersion string `json:"goVersion"`
+				BuildTime string `json:"buildTime"`
+				Os        string `json:"os"`
+				Arch      string `json:"arch"`
 			}{
 				Version:   version.Version,
 				Codename:  version.Codename,
@@ -81,7 +83,14 @@ Complete documentation is available at https://traefik.io`,
 				Os:        runtime.GOOS,
 				Arch:      runtime.GOARCH,
 			}
+			if output.isJSON() {
+				return output.success(v)
+			}
 
+			tmpl, err := template.New("").Parse(versionTemplate)
+			if err != nil {
+				return err
+			}
 			if err := tmpl.Execute(os.Stdout, v); err != nil {
 				return err
 			}
@@ -108,11 +117,21 @@ Complete documentation is available at https://traefik.io`,
 			if err != nil {
 				return err
 			}
-			fmtlog.Printf("Storing configuration: %s\n", jsonConf)
+			if !output.isJSON() {
+				fmtlog.Printf("Storing configuration: %s\n", jsonConf)
+			}
 			err = kv.StoreConfig(traefikConfiguration.GlobalConfiguration)
 			if err != nil {
 				return err
 			}
+			result := struct {
+				Prefix        string          `json:"prefix"`
+				Configuration json.RawMessage `json:"configuration"`
+				ACMEStorage   string          `json:"acmeStorage,omitempty"`
+			}{
+				Prefix:        kv.Prefix,
+				Configuration: jsonConf,
+			}
 			if traefikConfiguration.GlobalConfiguration.ACME != nil && len(traefikConfiguration.GlobalConfiguration.ACME.StorageFile) > 0 {
 				// convert ACME json file to KV store
 				store := acme.NewLocalStore(traefikConfiguration.GlobalConfiguration.ACME.StorageFile)
@@ -133,8 +152,81 @@ Complete documentation is available at https://traefik.io`,
 				if err != nil {
 					return err
 				}
+				result.ACMEStorage = traefikConfiguration.GlobalConfiguration.ACME.Storage
 			}
-			return nil
+			return output.success(result)
+		},
+		Metadata: map[string]string{
+			"parseAllSources": "true",
+		},
+	}
+
+	//migrateconfig Command init
+	migrateconfigCmd := &flaeg.Command{
+		Name:                  "migrateconfig",
+		Description:           `Upgrade the keys of the dynamic configuration in the Key-value store to the layout of this version. Traefik will not start.`,
+		Config:                traefikConfiguration,
+		DefaultPointersConfig: traefikPointersConfiguration,
+		Run: func() error {
+			if kv == nil {
+				return fmt.Errorf("Error using command migrateconfig, no Key-value store defined")
+			}
+			migration, err := provider.MigrateKvStore(kv.Store, kv.Prefix)
+			if err != nil {
+				return err
+			}
+			if !output.isJSON() {
+				fmtlog.Printf("Migrated %d keys from schema version %d to %d\n", len(migration.Keys), migration.From, migration.To)
+			}
+			return output.success(migration)
+		},
+		Metadata: map[string]string{
+			"parseAllSources": "true",
+		},
+	}
+
+	//Windows service Commands init
+	serviceResult := func() interface{} {
+		return struct {
+			Name string `json:"name"`
+		}{
+			Name: traefikConfiguration.ServiceName,
+		}
+	}
+	installServiceCmd := &flaeg.Command{
+		Name:                  "installservice",
+		Description:           `Install traefik as a Windows service, run with the configuration file. Traefik will not start.`,
+		Config:                traefikConfiguration,
+		DefaultPointersConfig: traefikPointersConfiguration,
+		Run: func() error {
+			if err := installService(traefikConfiguration.ServiceName, traefikConfiguration.ConfigFile); err != nil {
+				return err
+			}
+			return output.success(serviceResult())
+		},
+		Metadata: map[string]string{
+			"parseAllSources": "true",
+		},
+	}
+	uninstallServiceCmd := &flaeg.Command{
+		Name:                  "uninstallservice",
+		Description:           `Uninstall the Windows service of traefik.`,
+		Config:                traefikConfiguration,
+		DefaultPointersConfig: traefikPointersConfiguration,
+		Run: func() error {
+			if err := uninstallService(traefikConfiguration.ServiceName); err != nil {
+				return err
+			}
+			return output.success(serviceResult())
+		},
+	}
+	runServiceCmd := &flaeg.Command{
+		Name:                  "runservice",
+		Description:           `Run traefik as a Windows service, started by the service control manager.`,
+		Config:                traefikConfiguration,
+		DefaultPointersConfig: traefikPointersConfiguration,
+		Run: func() error {
+			return runService(traefikConfiguration)
 		},
 		Metadata: map[string]string{
 			"parseAllSources": "true",
@@ -147,22 +239,44 @@ Complete documentation is available at https://traefik.io`,
 	f.AddParser(reflect.TypeOf(EntryPoints{}), &EntryPoints{})
 	f.AddParser(reflect.TypeOf(DefaultEntryPoints{}), &DefaultEntryPoints{})
 	f.AddParser(reflect.TypeOf(types.Constraints{}), &types.Constraints{})
+	f.AddParser(reflect.TypeOf(types.CDNs{}), &types.CDNs{})
+	f.AddParser(reflect.TypeOf(types.SANs{}), &types.SANs{})
+	f.AddParser(reflect.TypeOf(internalca.AllowedNames{}), &internalca.AllowedNames{})
 	f.AddParser(reflect.TypeOf(k8s.Namespaces{}), &k8s.Namespaces{})
 	f.AddParser(reflect.TypeOf([]acme.Domain{}), &acme.Domains{})
+	f.AddParser(reflect.TypeOf([]provider.DockerEndpoint{}), &provider.DockerEndpoints{})
+	f.AddParser(reflect.TypeOf([]provider.KubernetesCluster{}), &provider.KubernetesClusters{})
+	f.AddParser(reflect.TypeOf(acme.DelegatedDomains{}), &acme.DelegatedDomains{})
+	f.AddParser(reflect.TypeOf(acme.Resolvers{}), &acme.Resolvers{})
+	f.AddParser(reflect.TypeOf(externaldns.Domains{}), &externaldns.Domains{})
+	f.AddParser(reflect.TypeOf(Includes{}), &Includes{})
+	f.AddParser(reflect.TypeOf(WebCertRoles{}), &WebCertRoles{})
 
 	//add commands
 	f.AddCommand(versionCmd)
 	f.AddCommand(storeconfigCmd)
+	f.AddCommand(migrateconfigCmd)
+	f.AddCommand(installServiceCmd)
+	f.AddCommand(uninstallServiceCmd)
+	f.AddCommand(runServiceCmd)
 
 	usedCmd, err := f.GetCommand()
 	if err != nil {
 		fmtlog.Println(err)
-		os.Exit(-1)
+		os.Exit(exitCodeUsage)
 	}
 
-	if _, err := f.Parse(usedCmd); err != nil {
-		fmtlog.Printf("Error parsing command: %s\n", err)
-		os.Exit(-1)
+	_, err = f.Parse(usedCmd)
+	outputFormat := traefikConfiguration.Output
+	if usedCmd == versionCmd {
+		outputFormat = versionConfiguration.Output
+	}
+	output, outputErr := newCommandOutput(usedCmd.Name, outputFormat)
+	if err != nil {
+		output.exit(exitCodeUsage, fmt.Errorf("Error parsing command: %s", err))
+	}
+	if outputErr != nil {
+		output.exit(exitCodeUsage, outputErr)
 	}
 
 	//staert init
@@ -172,22 +286,21 @@ Complete documentation is available at https://traefik.io`,
 
 	//add sources to staert
 	s.AddSource(toml)
+	s.AddSource(&includeSource{main: toml})
 	s.AddSource(f)
 	if _, err := s.LoadConfig(); err != nil {
-		fmtlog.Println(fmt.Errorf("Error reading TOML config file %s : %s", toml.ConfigFileUsed(), err))
-		os.Exit(-1)
+		output.exit(exitCodeConfig, fmt.Errorf("Error reading TOML config file %s : %s", toml.ConfigFileUsed(), err))
 	}
 
 	traefikConfiguration.ConfigFile = toml.ConfigFileUsed()
 
 	kv, err = CreateKvSource(traefikConfiguration)
 	if err != nil {
-		fmtlog.Printf("Error creating kv store: %s\n", err)
-		os.Exit(-1)
+		output.exit(exitCodeConfig, fmt.Errorf("Error creating kv store: %s", err))
 	}
 
 	// IF a KV Store is enable and no sub-command called in args
-	if kv != nil && usedCmd == traefikCmd {
+	if kv != nil && (usedCmd == traefikCmd || usedCmd == runServiceCmd) {
 		if traefikConfiguration.Cluster == nil {
 			traefikConfiguration.Cluster = &types.Cluster{Node: uuid.NewV4().String()}
 		}
@@ -196,17 +309,15 @@ Complete documentation is available at https://traefik.io`,
 		}
 		s.AddSource(kv)
 		if _, err := s.LoadConfig(); err != nil {
-			fmtlog.Printf("Error loading configuration: %s\n", err)
-			os.Exit(-1)
+			output.exit(exitCodeConfig, fmt.Errorf("Error loading configuration: %s", err))
 		}
 	}
 
 	if err := s.Run(); err != nil {
-		fmtlog.Printf("Error running traefik: %s\n", err)
-		os.Exit(-1)
+		output.exit(exitCodeError, fmt.Errorf("Error running traefik: %s", err))
 	}
 
-	os.Exit(0)
+	os.Exit(exitCodeOK)
 }
 
 func run(traefikConfiguration *TraefikConfiguration) {
@@ -219,10 +330,7 @@ func run(traefikConfiguration *TraefikConfiguration) {
 	if globalConfiguration.InsecureSkipVerify {
 		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
-	loggerMiddleware := middlewares.NewLogger(globalConfiguration.AccessLogsFile)
-	defer loggerMiddleware.Close()
-
-	if globalConfiguration.File != nil && len(globalConfiguration.File.Filename) == 0 {
+	if globalConfiguration.File != nil && len(globalConfiguration.File.Filename) == 0 && len(globalConfiguration.File.Directory) == 0 {
 		// no filename, setting to global config file
 		if len(traefikConfiguration.ConfigFile) != 0 {
 			globalConfiguration.File.Filename = traefikConfiguration.ConfigFile
@@ -236,6 +344,28 @@ func run(traefikConfiguration *TraefikConfiguration) {
 		globalConfiguration.DefaultEntryPoints = []string{"http"}
 	}
 
+	// before opening any file as root
+	if sandbox := globalConfiguration.Sandbox; sandbox != nil {
+		if err := checkSandbox(sandbox, globalConfiguration.Plugin); err != nil {
+			log.Fatalf("Error in the sandbox configuration: %v", err)
+		}
+		if len(sandbox.User) > 0 && os.Getuid() == 0 {
+			exitCode, err := runAsUser(sandbox, globalConfiguration.EntryPoints)
+			if err != nil {
+				log.Fatalf("Error running as user %s: %v", sandbox.User, err)
+			}
+			os.Exit(exitCode)
+		}
+		if sandbox.Seccomp {
+			if err := applySeccomp(); err != nil {
+				log.Fatalf("Error applying the seccomp filter: %v", err)
+			}
+		}
+	}
+
+	loggerMiddleware := middlewares.NewLogger(globalConfiguration.AccessLogsFile)
+	defer loggerMiddleware.Close()
+
 	if globalConfiguration.Debug {
 		globalConfiguration.LogLevel = "DEBUG"
 	}
@@ -262,6 +392,14 @@ func run(traefikConfiguration *TraefikConfiguration) {
 	} else {
 		log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true, DisableSorting: true})
 	}
+	if len(globalConfiguration.EventLog) > 0 {
+		hook, err := newEventLogHook(globalConfiguration.EventLog)
+		if err != nil {
+			log.Error("Error opening the EventLog", err)
+		} else {
+			log.AddHook(hook)
+		}
+	}
 	jsonConf, _ := json.Marshal(globalConfiguration)
 	log.Infof("Traefik version %s built on %s", version.Version, version.BuildDate)
 
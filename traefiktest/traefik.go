@@ -0,0 +1,96 @@
+// Package traefiktest provides utilities to run traefik with fixtures in the
+// test suites, such as the integration tests of traefik or those of the
+// projects embedding traefik or writing custom providers.
+package traefiktest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+)
+
+var execCommand = exec.Command
+
+// RunCommand runs the specified command with arguments and returns
+// the output and the error if any.
+func RunCommand(binary string, args ...string) (*exec.Cmd, string, error) {
+	cmd := execCommand(binary, args...)
+	out, err := cmd.CombinedOutput()
+	output := string(out)
+	return cmd, output, err
+}
+
+// Traefik is a traefik process started by StartTraefik.
+type Traefik struct {
+	Cmd    *exec.Cmd
+	output bytes.Buffer
+}
+
+// StartTraefik starts binary with args in the background, recording its
+// output. The process must be stopped with Stop.
+func StartTraefik(binary string, args ...string) (*Traefik, error) {
+	traefik := &Traefik{Cmd: execCommand(binary, args...)}
+	traefik.Cmd.Stdout = &traefik.output
+	traefik.Cmd.Stderr = &traefik.output
+	if err := traefik.Cmd.Start(); err != nil {
+		return nil, err
+	}
+	return traefik, nil
+}
+
+// Output returns the output of the process, once it is stopped.
+func (t *Traefik) Output() string {
+	return t.output.String()
+}
+
+// Stop kills the process and waits for its end.
+func (t *Traefik) Stop() error {
+	if err := t.Cmd.Process.Kill(); err != nil {
+		return err
+	}
+	// the process was killed, its exit status is an error
+	t.Cmd.Wait()
+	return nil
+}
+
+// AdaptFileForHost renders the template of path with the DockerHost field,
+// the docker endpoint of the DOCKER_HOST environment variable, and returns
+// the path of the rendered file.
+func AdaptFileForHost(path string) (string, error) {
+	dockerHost := os.Getenv("DOCKER_HOST")
+	if dockerHost == "" {
+		// Default docker socket
+		dockerHost = "unix:///var/run/docker.sock"
+	}
+	tempObjects := struct{ DockerHost string }{dockerHost}
+	return AdaptFile(path, tempObjects)
+}
+
+// AdaptFile renders the template of path with tempObjects to a temporary
+// file of the same directory, and returns its path. The file must be
+// removed by the caller.
+func AdaptFile(path string, tempObjects interface{}) (string, error) {
+	// Load file
+	tmpl, err := template.ParseFiles(path)
+	if err != nil {
+		return "", err
+	}
+
+	folder, prefix := filepath.Split(path)
+	tmpFile, err := ioutil.TempFile(folder, prefix)
+	if err != nil {
+		return "", err
+	}
+	defer tmpFile.Close()
+
+	if err := tmpl.ExecuteTemplate(tmpFile, prefix, tempObjects); err != nil {
+		return "", err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		return "", err
+	}
+	return tmpFile.Name(), nil
+}
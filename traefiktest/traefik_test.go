@@ -1,9 +1,11 @@
-package utils
+package traefiktest
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -31,6 +33,50 @@ func TestRunCommandError(t *testing.T) {
 	}
 }
 
+func TestStartTraefik(t *testing.T) {
+	execCommand = fakeExecCommand
+	traefik, err := StartTraefik(traefikBinary, "it", "works")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the helper process may be over already
+	traefik.Cmd.Wait()
+	if output := traefik.Output(); output != "it works" {
+		t.Fatalf("Expected 'it works' as output, got : %q", output)
+	}
+}
+
+func TestAdaptFile(t *testing.T) {
+	directory, err := ioutil.TempDir("", "traefiktest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "fixture.toml")
+	if err := ioutil.WriteFile(path, []byte(`endpoint = "{{.DockerHost}}"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2375")
+	defer os.Unsetenv("DOCKER_HOST")
+	adapted, err := AdaptFileForHost(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(adapted)
+	content, err := ioutil.ReadFile(adapted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != `endpoint = "tcp://127.0.0.1:2375"` {
+		t.Fatalf("Unexpected adapted file: %q", content)
+	}
+
+	if _, err := AdaptFile(filepath.Join(directory, "missing.toml"), nil); err == nil {
+		t.Fatal("Expected an error for a missing file")
+	}
+}
+
 // Helpers :)
 
 // Type implementing the io.Writer interface for analyzing output.
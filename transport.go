@@ -0,0 +1,314 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/internalca"
+	"github.com/containous/traefik/types"
+	"golang.org/x/net/proxy"
+)
+
+// tlsHandshakeTimeout is the maximum duration of the TLS handshakes with the servers
+const tlsHandshakeTimeout = 10 * time.Second
+
+// backendDialTimeout is the maximum duration of the connections to the servers,
+// and of the setup of the tunnels to them
+const backendDialTimeout = 30 * time.Second
+
+var oidExtensionSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+// createBackendTransport returns the transport verifying the TLS connections
+// to the servers of a backend as configured by backendTLS, dialing them from
+// backendSource and through backendProxy if any, and waiting
+// expectContinueTimeout for their 100 Continue.
+func createBackendTransport(globalConfiguration GlobalConfiguration, backendTLS *types.BackendTLS, backendProxy *types.BackendProxy, backendSource *types.BackendSource, expectContinueTimeout time.Duration) (http.RoundTripper, error) {
+	var forward proxy.Dialer = &net.Dialer{
+		Timeout:   backendDialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	if backendSource != nil {
+		sourceDialer, err := createSourceDialer(backendSource, forward.(*net.Dialer))
+		if err != nil {
+			return nil, err
+		}
+		forward = sourceDialer
+	}
+	dialer := &backendDialer{
+		dial:               forward.Dial,
+		serverName:         backendTLS.ServerName,
+		insecureSkipVerify: backendTLS.InsecureSkipVerify,
+		pins:               map[string]bool{},
+		fips:               fipsBuild || globalConfiguration.FIPS,
+	}
+	for _, rootCA := range backendTLS.RootCAs {
+		pem, err := readPEM(rootCA)
+		if err != nil {
+			return nil, err
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in root CA %.40s", rootCA)
+		}
+		dialer.rootCAs = append(dialer.rootCAs, pem)
+	}
+	if backendTLS.InternalCA {
+		if globalConfiguration.InternalCA == nil {
+			return nil, errors.New("the internal CA is not enabled")
+		}
+		dialer.internalCA = globalConfiguration.InternalCA
+	}
+	for _, pin := range backendTLS.PinnedSPKI {
+		hash, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("invalid SPKI pin %s, expected a base64 encoded SHA-256 hash", pin)
+		}
+		dialer.pins[pin] = true
+	}
+	if len(backendTLS.Certificate) > 0 || len(backendTLS.Key) > 0 {
+		certPEM, err := readPEM(backendTLS.Certificate)
+		if err != nil {
+			return nil, err
+		}
+		keyPEM, err := readPEM(backendTLS.Key)
+		if err != nil {
+			return nil, err
+		}
+		certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %v", err)
+		}
+		dialer.certificates = []tls.Certificate{certificate}
+	}
+	if len(backendTLS.URISANs) > 0 {
+		dialer.uriSANs = map[string]bool{}
+		for _, uri := range backendTLS.URISANs {
+			dialer.uriSANs[uri] = true
+		}
+	}
+
+	proxyFunc := http.ProxyFromEnvironment
+	if backendProxy != nil {
+		dial, err := createProxyDialer(backendProxy, forward)
+		if err != nil {
+			return nil, err
+		}
+		dialer.dial = dial
+		proxyFunc = nil
+	}
+
+	return &http.Transport{
+		Proxy:                 proxyFunc,
+		Dial:                  dialer.dial,
+		DialTLS:               dialer.dialTLS,
+		MaxIdleConnsPerHost:   globalConfiguration.MaxIdleConnsPerHost,
+		TLSHandshakeTimeout:   tlsHandshakeTimeout,
+		ExpectContinueTimeout: expectContinueTimeout,
+	}, nil
+}
+
+// backendDialer opens the TLS connections to the servers of a backend.
+type backendDialer struct {
+	dial               dialFunc
+	serverName         string
+	insecureSkipVerify bool
+	rootCAs            [][]byte
+	internalCA         *internalca.CA
+	pins               map[string]bool
+	certificates       []tls.Certificate
+	uriSANs            map[string]bool
+	fips               bool
+	lock               sync.Mutex
+	pool               *x509.CertPool
+	poolCA             *x509.Certificate
+}
+
+// certPool returns the root CAs verifying the servers, nil for the system ones.
+// The pool is rebuilt when the internal CA is loaded or changes.
+func (d *backendDialer) certPool() (*x509.CertPool, error) {
+	if len(d.rootCAs) == 0 && d.internalCA == nil {
+		return nil, nil
+	}
+	var caCertificate *x509.Certificate
+	if d.internalCA != nil {
+		if caCertificate = d.internalCA.Certificate(); caCertificate == nil {
+			return nil, errors.New("the internal CA is not loaded yet")
+		}
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.pool == nil || d.poolCA != caCertificate {
+		pool := x509.NewCertPool()
+		for _, rootCA := range d.rootCAs {
+			pool.AppendCertsFromPEM(rootCA)
+		}
+		if caCertificate != nil {
+			pool.AddCert(caCertificate)
+		}
+		d.pool, d.poolCA = pool, caCertificate
+	}
+	return d.pool, nil
+}
+
+// dialTLS opens a verified TLS connection to addr, and checks that a
+// certificate of the server matches one of the pins, if any. With URI SANs,
+// the chain of the server is verified without its name, which must be one
+// of the URI SANs of its certificate instead.
+func (d *backendDialer) dialTLS(network, addr string) (net.Conn, error) {
+	pool, err := d.certPool()
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		ServerName:         d.serverName,
+		RootCAs:            pool,
+		Certificates:       d.certificates,
+		InsecureSkipVerify: d.insecureSkipVerify || d.uriSANs != nil,
+	}
+	if d.fips {
+		enforceFIPS(config)
+	}
+	if len(config.ServerName) == 0 {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		config.ServerName = host
+	}
+	conn, err := d.dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := tls.Client(conn, config)
+	conn.SetDeadline(time.Now().Add(tlsHandshakeTimeout))
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	if d.uriSANs != nil {
+		if err := verifyURISANs(tlsConn.ConnectionState(), pool, d.uriSANs, d.insecureSkipVerify); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("invalid certificate of %s: %v", addr, err)
+		}
+	}
+	if len(d.pins) > 0 && !matchPins(tlsConn.ConnectionState(), d.pins) {
+		conn.Close()
+		return nil, errors.New("no certificate of " + addr + " matches the pinned SPKI hashes")
+	}
+	return tlsConn, nil
+}
+
+func matchPins(state tls.ConnectionState, pins map[string]bool) bool {
+	chains := state.VerifiedChains
+	if len(chains) == 0 {
+		// verification is disabled, only the certificates sent by the server can be checked
+		chains = [][]*x509.Certificate{state.PeerCertificates}
+	}
+	for _, chain := range chains {
+		for _, certificate := range chain {
+			hash := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+			if pins[base64.StdEncoding.EncodeToString(hash[:])] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifyURISANs verifies the chain of the certificate of the server with pool,
+// unless insecure, and checks that it has one of uriSANs.
+func verifyURISANs(state tls.ConnectionState, pool *x509.CertPool, uriSANs map[string]bool, insecure bool) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("no certificate")
+	}
+	certificate := state.PeerCertificates[0]
+	if !insecure {
+		intermediates := x509.NewCertPool()
+		for _, intermediate := range state.PeerCertificates[1:] {
+			intermediates.AddCert(intermediate)
+		}
+		if _, err := certificate.Verify(x509.VerifyOptions{Roots: pool, Intermediates: intermediates}); err != nil {
+			return err
+		}
+	}
+	uris, err := certificateURIs(certificate)
+	if err != nil {
+		return err
+	}
+	for _, uri := range uris {
+		if uriSANs[uri] {
+			return nil
+		}
+	}
+	return fmt.Errorf("none of the URI SANs %v is expected", uris)
+}
+
+// certificateURIs returns the URI SANs of certificate, which x509 doesn't parse.
+func certificateURIs(certificate *x509.Certificate) ([]string, error) {
+	var uris []string
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(oidExtensionSubjectAltName) {
+			continue
+		}
+		var names []asn1.RawValue
+		if _, err := asn1.Unmarshal(extension.Value, &names); err != nil {
+			return nil, fmt.Errorf("invalid subject alternative names: %v", err)
+		}
+		for _, name := range names {
+			if name.Class == asn1.ClassContextSpecific && name.Tag == 6 {
+				uris = append(uris, string(name.Bytes))
+			}
+		}
+	}
+	return uris, nil
+}
+
+// readPEM returns the PEM contents of value, or of the file it names.
+func readPEM(value string) ([]byte, error) {
+	if _, err := os.Stat(value); err == nil {
+		return ioutil.ReadFile(value)
+	}
+	return []byte(value), nil
+}
+
+// backendTLSDescription describes the verification of the TLS connections to a backend.
+func backendTLSDescription(backendTLS *types.BackendTLS) string {
+	description := []string{}
+	if backendTLS.InsecureSkipVerify {
+		description = append(description, "insecure")
+	}
+	if len(backendTLS.RootCAs) > 0 {
+		description = append(description, fmt.Sprintf("%d root CAs", len(backendTLS.RootCAs)))
+	}
+	if backendTLS.InternalCA {
+		description = append(description, "internal CA")
+	}
+	if len(backendTLS.ServerName) > 0 {
+		description = append(description, "server name "+backendTLS.ServerName)
+	}
+	if len(backendTLS.PinnedSPKI) > 0 {
+		description = append(description, fmt.Sprintf("%d SPKI pins", len(backendTLS.PinnedSPKI)))
+	}
+	if len(backendTLS.Certificate) > 0 {
+		description = append(description, "client certificate")
+	}
+	if len(backendTLS.URISANs) > 0 {
+		description = append(description, fmt.Sprintf("%d URI SANs", len(backendTLS.URISANs)))
+	}
+	if len(description) == 0 {
+		return "system root CAs"
+	}
+	return strings.Join(description, ", ")
+}
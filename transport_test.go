@@ -0,0 +1,177 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+)
+
+func TestBackendTransport(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	certificate, err := x509.ParseCertificate(ts.TLS.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))
+	hash := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(hash[:])
+	otherHash := sha256.Sum256([]byte("other"))
+	otherPin := base64.StdEncoding.EncodeToString(otherHash[:])
+
+	cases := []struct {
+		desc       string
+		backendTLS *types.BackendTLS
+		expectOK   bool
+	}{
+		{"unknown CA", &types.BackendTLS{}, false},
+		{"root CA", &types.BackendTLS{RootCAs: []string{rootCA}}, true},
+		{"server name", &types.BackendTLS{RootCAs: []string{rootCA}, ServerName: "example.com"}, true},
+		{"wrong server name", &types.BackendTLS{RootCAs: []string{rootCA}, ServerName: "traefik.io"}, false},
+		{"pinned", &types.BackendTLS{RootCAs: []string{rootCA}, PinnedSPKI: []string{otherPin, pin}}, true},
+		{"wrong pin", &types.BackendTLS{RootCAs: []string{rootCA}, PinnedSPKI: []string{otherPin}}, false},
+		{"insecure pinned", &types.BackendTLS{InsecureSkipVerify: true, PinnedSPKI: []string{pin}}, true},
+		{"insecure wrong pin", &types.BackendTLS{InsecureSkipVerify: true, PinnedSPKI: []string{otherPin}}, false},
+	}
+	for _, c := range cases {
+		transport, err := createBackendTransport(GlobalConfiguration{}, c.backendTLS, nil, nil, time.Second)
+		if err != nil {
+			t.Fatalf("%s: %v", c.desc, err)
+		}
+		request, _ := http.NewRequest("GET", ts.URL, nil)
+		response, err := transport.RoundTrip(request)
+		if err == nil {
+			response.Body.Close()
+		}
+		if c.expectOK && err != nil {
+			t.Errorf("%s: unexpected error %v", c.desc, err)
+		} else if !c.expectOK && err == nil {
+			t.Errorf("%s: expected an error", c.desc)
+		}
+	}
+}
+
+func TestBackendTransportErrors(t *testing.T) {
+	for _, backendTLS := range []*types.BackendTLS{
+		{RootCAs: []string{"not a certificate"}},
+		{PinnedSPKI: []string{"not a pin"}},
+		{PinnedSPKI: []string{base64.StdEncoding.EncodeToString([]byte("short"))}},
+	} {
+		if _, err := createBackendTransport(GlobalConfiguration{}, backendTLS, nil, nil, time.Second); err == nil {
+			t.Errorf("expected an error for %+v", backendTLS)
+		}
+	}
+}
+
+// generateTestLeaf returns a certificate with the URI SAN uri, issued by ca,
+// or self-signed when ca is nil.
+func generateTestLeaf(t *testing.T, ca *tls.Certificate, uri string) tls.Certificate {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "connect"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	parent, signer := template, interface{}(privateKey)
+	if ca == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		if parent, err = x509.ParseCertificate(ca.Certificate[0]); err != nil {
+			t.Fatal(err)
+		}
+		signer = ca.PrivateKey
+	}
+	if len(uri) > 0 {
+		names, err := asn1.Marshal([]asn1.RawValue{{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte(uri)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.ExtraExtensions = []pkix.Extension{{Id: oidExtensionSubjectAltName, Value: names}}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &privateKey.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: privateKey}
+}
+
+func TestBackendTransportURISANs(t *testing.T) {
+	ca := generateTestLeaf(t, nil, "")
+	caCertificate, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate[0]}))
+	client := generateTestLeaf(t, &ca, "spiffe://example.consul/ns/default/dc/dc1/svc/traefik")
+	clientCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: client.Certificate[0]}))
+	keyDER, err := x509.MarshalECPrivateKey(client.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientKey := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	otherCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: generateTestLeaf(t, nil, "").Certificate[0]}))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(caCertificate)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{generateTestLeaf(t, &ca, "spiffe://example.consul/ns/default/dc/dc1/svc/web")},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	web := []string{"spiffe://example.consul/ns/default/dc/dc1/svc/web"}
+	cases := []struct {
+		desc       string
+		backendTLS *types.BackendTLS
+		expectOK   bool
+	}{
+		{"connect", &types.BackendTLS{RootCAs: []string{rootCA}, Certificate: clientCert, Key: clientKey, URISANs: web}, true},
+		{"other service", &types.BackendTLS{RootCAs: []string{rootCA}, Certificate: clientCert, Key: clientKey, URISANs: []string{"spiffe://example.consul/ns/default/dc/dc1/svc/db"}}, false},
+		{"other CA", &types.BackendTLS{RootCAs: []string{otherCA}, Certificate: clientCert, Key: clientKey, URISANs: web}, false},
+		{"no client certificate", &types.BackendTLS{RootCAs: []string{rootCA}, URISANs: web}, false},
+	}
+	for _, c := range cases {
+		transport, err := createBackendTransport(GlobalConfiguration{}, c.backendTLS, nil, nil, time.Second)
+		if err != nil {
+			t.Fatalf("%s: %v", c.desc, err)
+		}
+		request, _ := http.NewRequest("GET", ts.URL, nil)
+		response, err := transport.RoundTrip(request)
+		if err == nil {
+			response.Body.Close()
+		}
+		if c.expectOK && err != nil {
+			t.Errorf("%s: unexpected error %v", c.desc, err)
+		} else if !c.expectOK && err == nil {
+			t.Errorf("%s: expected an error", c.desc)
+		}
+	}
+
+	if _, err := createBackendTransport(GlobalConfiguration{}, &types.BackendTLS{Certificate: clientCert}, nil, nil, time.Second); err == nil {
+		t.Error("expected an error for a client certificate without key")
+	}
+}
@@ -15,6 +15,74 @@ type Backend struct {
 	CircuitBreaker *CircuitBreaker   `json:"circuitBreaker,omitempty"`
 	LoadBalancer   *LoadBalancer     `json:"loadBalancer,omitempty"`
 	MaxConn        *MaxConn          `json:"maxConn,omitempty"`
+	TLS            *BackendTLS       `json:"tls,omitempty"`
+	Expect         *Expect           `json:"expect,omitempty"`
+	Streaming      bool              `json:"streaming,omitempty"`
+	Timeouts       *BackendTimeouts  `json:"timeouts,omitempty"`
+	Proxy          *BackendProxy     `json:"proxy,omitempty"`
+	Source         *BackendSource    `json:"source,omitempty"`
+}
+
+// BackendSource holds the local address of the connections to the servers of
+// a backend, so that a multi-homed node routes them through a given network:
+// the source IP, or the network Interface, like a WireGuard tunnel, whose
+// address is used.
+type BackendSource struct {
+	IP        string `json:"ip,omitempty"`
+	Interface string `json:"interface,omitempty"`
+}
+
+// BackendProxy holds the proxy or the tunnel through which the servers of a
+// backend are dialed: the URL of a SOCKS5 proxy, socks5://host:port, of an HTTP
+// proxy tunneling with CONNECT, http(s)://host:port, or of an SSH server
+// forwarding the connections, ssh://host:port. Username and Password
+// authenticate traefik to the proxy. The SSH server is verified with HostKey,
+// a public key in the authorized_keys format, and authenticates traefik with
+// PrivateKey, a file path or PEM content, or with the password. The password
+// and the private key are never published.
+type BackendProxy struct {
+	URL        string `json:"url,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"-"`
+	HostKey    string `json:"hostKey,omitempty"`
+	PrivateKey string `json:"-"`
+}
+
+// BackendTimeouts holds the timeouts of the requests forwarded to the servers
+// of a backend: a request is aborted after MaxDuration seconds, or when no
+// byte of its body or of the response body was transferred for Stall seconds.
+type BackendTimeouts struct {
+	MaxDuration int `json:"maxDuration,omitempty"`
+	Stall       int `json:"stall,omitempty"`
+}
+
+// Expect holds the handling of the requests of a backend with an Expect:
+// 100-continue header. The forward Mode, the default, forwards the header and
+// waits Timeout milliseconds, 1000 if unset, for the 100 Continue of the server
+// before sending the body. The local mode answers 100 Continue to the clients
+// and forwards the requests without the header, the strip mode only removes it.
+type Expect struct {
+	Mode    string `json:"mode,omitempty"`
+	Timeout int    `json:"timeout,omitempty"`
+}
+
+// BackendTLS holds the verification of the TLS connections to the servers of a backend.
+// RootCAs are file paths or PEM contents, PinnedSPKI are base64 encoded SHA-256
+// hashes of the public key of a certificate of the verified chains. InternalCA
+// trusts the certificates issued by the internal CA of traefik. Certificate and
+// Key, file paths or PEM contents, are presented to the servers for mutual TLS;
+// the key is never published. When URISANs are set, the certificates of the
+// servers must have one of them, like the SPIFFE IDs of the Consul Connect
+// services, instead of the server name.
+type BackendTLS struct {
+	RootCAs            []string `json:"rootCAs,omitempty"`
+	InternalCA         bool     `json:"internalCA,omitempty"`
+	ServerName         string   `json:"serverName,omitempty"`
+	PinnedSPKI         []string `json:"pinnedSPKI,omitempty"`
+	InsecureSkipVerify bool     `json:"insecureSkipVerify,omitempty"`
+	Certificate        string   `json:"certificate,omitempty"`
+	Key                string   `json:"-"`
+	URISANs            []string `json:"uriSANs,omitempty"`
 }
 
 // MaxConn holds maximum connection configuration
@@ -47,11 +115,79 @@ type Route struct {
 
 // Frontend holds frontend configuration.
 type Frontend struct {
-	EntryPoints    []string         `json:"entryPoints,omitempty"`
-	Backend        string           `json:"backend,omitempty"`
-	Routes         map[string]Route `json:"routes,omitempty"`
-	PassHostHeader bool             `json:"passHostHeader,omitempty"`
-	Priority       int              `json:"priority"`
+	EntryPoints      []string          `json:"entryPoints,omitempty"`
+	Backend          string            `json:"backend,omitempty"`
+	Routes           map[string]Route  `json:"routes,omitempty"`
+	PassHostHeader   bool              `json:"passHostHeader,omitempty"`
+	Priority         int               `json:"priority"`
+	SLO              *SLO              `json:"slo,omitempty"`
+	Experiment       *Experiment       `json:"experiment,omitempty"`
+	Capture          *Capture          `json:"capture,omitempty"`
+	Fault            *Fault            `json:"fault,omitempty"`
+	Tenant           string            `json:"tenant,omitempty"`
+	Auth             *Auth             `json:"auth,omitempty"`
+	SecurityHeaders  *SecurityHeaders  `json:"securityHeaders,omitempty"`
+	GraphQL          *GraphQL          `json:"graphql,omitempty"`
+	ClientCA         *ClientCA         `json:"clientCA,omitempty"`
+	ClientHeaders    *ClientHeaders    `json:"clientHeaders,omitempty"`
+	ConcurrencyLimit *ConcurrencyLimit `json:"concurrencyLimit,omitempty"`
+	Coalesce         *Coalesce         `json:"coalesce,omitempty"`
+	ACL              *ACL              `json:"acl,omitempty"`
+	OPA              *OPA              `json:"opa,omitempty"`
+	Middlewares      []string          `json:"middlewares,omitempty"`
+}
+
+// SLO holds the service level objectives of a frontend.
+// Availability and LatencyTarget are percentages, Latency is a threshold in milliseconds.
+// The requests to the StreamingPaths prefixes, like the websockets and server-sent
+// events, are streams excluded from the latency objective.
+type SLO struct {
+	Availability   float64  `json:"availability,omitempty"`
+	Latency        int      `json:"latency,omitempty"`
+	LatencyTarget  float64  `json:"latencyTarget,omitempty"`
+	StreamingPaths []string `json:"streamingPaths,omitempty"`
+}
+
+// Experiment holds the A/B testing configuration of a frontend.
+// Requests are bucketed by a hash of the Header or Cookie value identifying the
+// user, and the clients without one are assigned a random variant.
+type Experiment struct {
+	Name     string             `json:"name,omitempty"`
+	Header   string             `json:"header,omitempty"`
+	Cookie   string             `json:"cookie,omitempty"`
+	Variants map[string]Variant `json:"variants,omitempty"`
+}
+
+// Variant holds a variant of an experiment. The requests of the variant are
+// sent to its Backend, or to the backend of the frontend when it is empty.
+type Variant struct {
+	Weight  int    `json:"weight"`
+	Backend string `json:"backend,omitempty"`
+}
+
+// Capture holds the traffic capture configuration of a frontend.
+// A SampleRate share of the requests, 1 if unset, is recorded in HAR files
+// written to Directory or uploaded with PUT requests under URL. The bodies are
+// truncated to MaxBodySize bytes, and the RedactHeaders values are masked, a
+// trailing * matching a prefix.
+type Capture struct {
+	SampleRate    float64  `json:"sampleRate,omitempty"`
+	Directory     string   `json:"directory,omitempty"`
+	URL           string   `json:"url,omitempty"`
+	MaxBodySize   int64    `json:"maxBodySize,omitempty"`
+	RedactHeaders []string `json:"redactHeaders,omitempty"`
+}
+
+// Fault holds the fault injection configuration of a frontend, for resilience tests.
+// Percentages are between 0 and 100: DelayPercent of the requests are delayed by
+// Delay milliseconds, AbortPercent are answered with AbortStatus, 503 if unset,
+// and DropPercent have their connection closed without a response.
+type Fault struct {
+	Delay        int     `json:"delay,omitempty"`
+	DelayPercent float64 `json:"delayPercent,omitempty"`
+	AbortStatus  int     `json:"abortStatus,omitempty"`
+	AbortPercent float64 `json:"abortPercent,omitempty"`
+	DropPercent  float64 `json:"dropPercent,omitempty"`
 }
 
 // LoadBalancerMethod holds the method of load balancing to use.
@@ -224,19 +360,121 @@ type Cluster struct {
 type Auth struct {
 	Basic  *Basic
 	Digest *Digest
+	LDAP   *LDAP
+	TOTP   *TOTP
+	SSO    *SSO
+}
+
+// LockoutConfig returns the lockout of the basic, digest or LDAP authentication,
+// the default lockout if the TOTP second factor is required without one so that
+// its codes can't be guessed, or nil.
+func (a *Auth) LockoutConfig() *Lockout {
+	var lockout *Lockout
+	switch {
+	case a.Basic != nil:
+		lockout = a.Basic.Lockout
+	case a.LDAP != nil:
+		lockout = a.LDAP.Lockout
+	case a.Digest != nil:
+		lockout = a.Digest.Lockout
+	}
+	if lockout == nil && a.TOTP != nil {
+		lockout = &Lockout{}
+	}
+	return lockout
 }
 
 // Users authentication users
 type Users []string
 
+// ForwardProxy holds the forward proxy mode of an entrypoint: the clients
+// tunnel their connections with CONNECT, or send their requests with an
+// absolute URI, to the destinations matching one of AllowedDestinations, host
+// glob patterns with an optional port like *.example.com:443. When Users are
+// set, in the format of the basic authentication, the clients authenticate
+// with the Proxy-Authorization header.
+type ForwardProxy struct {
+	AllowedDestinations []string `json:"allowedDestinations,omitempty"`
+	Users               Users    `json:"users,omitempty"`
+}
+
 // Basic HTTP basic authentication
+// FailureBody is sent with the FailureContentType in the 401 responses, and the
+// clients failing too many times in a row are locked out.
 type Basic struct {
-	Users `mapstructure:","`
+	Users              `mapstructure:","`
+	Realm              string   `json:"realm,omitempty"`
+	FailureBody        string   `json:"failureBody,omitempty"`
+	FailureContentType string   `json:"failureContentType,omitempty"`
+	Lockout            *Lockout `json:"lockout,omitempty"`
+}
+
+// Lockout holds the brute-force protection of an authentication. A client IP is
+// locked out after MaxFailures consecutive failures, for Duration seconds,
+// doubled on each new lockout up to MaxDuration seconds.
+type Lockout struct {
+	MaxFailures int `json:"maxFailures,omitempty"`
+	Duration    int `json:"duration,omitempty"`
+	MaxDuration int `json:"maxDuration,omitempty"`
+}
+
+// LDAP authentication of the basic auth credentials against an LDAP or Active
+// Directory server. The users bind with the UserDN template, or are searched
+// under BaseDN with the UserFilter by the BindDN account before binding.
+// Users must be members of one of the Groups when set, and successful
+// authentications are cached for CacheTTL seconds.
+type LDAP struct {
+	URL                string   `json:"url,omitempty"`
+	StartTLS           bool     `json:"startTLS,omitempty"`
+	InsecureSkipVerify bool     `json:"insecureSkipVerify,omitempty"`
+	CA                 string   `json:"ca,omitempty"`
+	BindDN             string   `json:"bindDN,omitempty"`
+	BindPassword       string   `json:"bindPassword,omitempty"`
+	BaseDN             string   `json:"baseDN,omitempty"`
+	UserFilter         string   `json:"userFilter,omitempty"`
+	UserDN             string   `json:"userDN,omitempty"`
+	GroupAttribute     string   `json:"groupAttribute,omitempty"`
+	Groups             []string `json:"groups,omitempty"`
+	CacheTTL           int      `json:"cacheTTL,omitempty"`
+	PoolSize           int      `json:"poolSize,omitempty"`
+	Timeout            int      `json:"timeout,omitempty"`
+	Realm              string   `json:"realm,omitempty"`
+	Lockout            *Lockout `json:"lockout,omitempty"`
+}
+
+// TOTP second factor of the basic, digest or LDAP authentication: the users
+// must also send the time-based one-time password (RFC 6238) of their seed in
+// the Header, or the FormField of the query or of a POSTed form. The base32
+// seeds are read from the "user:seed" lines of SeedsFile, or from the
+// KVPrefix/user keys of a KV store.
+type TOTP struct {
+	SeedsFile  string `json:"seedsFile,omitempty"`
+	KVBackend  string `json:"kvBackend,omitempty"`
+	KVEndpoint string `json:"kvEndpoint,omitempty"`
+	KVPrefix   string `json:"kvPrefix,omitempty"`
+	Header     string `json:"header,omitempty"`
+	FormField  string `json:"formField,omitempty"`
+	Digits     int    `json:"digits,omitempty"`
+	Period     int    `json:"period,omitempty"`
+	Skew       int    `json:"skew,omitempty"`
+}
+
+// SSO single sign-on of the frontends and entrypoints of the same Group:
+// once authenticated by one of them, the users get a cookie signed with
+// Secret, valid for MaxAge seconds on Domain and its subdomains, that the
+// others accept instead of authenticating them again.
+type SSO struct {
+	Group      string `json:"group,omitempty"`
+	Secret     string `json:"secret,omitempty"`
+	Domain     string `json:"domain,omitempty"`
+	CookieName string `json:"cookieName,omitempty"`
+	MaxAge     int    `json:"maxAge,omitempty"`
 }
 
 // Digest HTTP authentication
 type Digest struct {
-	Users `mapstructure:","`
+	Users   `mapstructure:","`
+	Lockout *Lockout `json:"lockout,omitempty"`
 }
 
 // CanonicalDomain returns a lower case domain with trim space
@@ -248,3 +486,281 @@ func CanonicalDomain(domain string) string {
 type Statistics struct {
 	RecentErrors int `description:"Number of recent errors logged"`
 }
+
+// RealIP holds the configuration of the client IP restoration behind a CDN
+type RealIP struct {
+	CDNs            CDNs  `description:"CDNs whose published IP ranges are trusted to send the client IP: cloudflare, fastly and cloudfront"`
+	RefreshInterval int64 `description:"Interval in seconds between two refreshes of the CDNs IP ranges"`
+}
+
+// RedirectMap holds the redirects of an entry point, answered before routing:
+// the "source destination [status]" lines of File, or the KVPrefix/host/path
+// keys of a KV store with "destination [status]" values, reloaded when they
+// change. Status is the default redirect status, 302 if unset.
+type RedirectMap struct {
+	File       string `json:"file,omitempty"`
+	KVBackend  string `json:"kvBackend,omitempty"`
+	KVEndpoint string `json:"kvEndpoint,omitempty"`
+	KVPrefix   string `json:"kvPrefix,omitempty"`
+	Status     int    `json:"status,omitempty"`
+}
+
+// PathNormalization holds the normalization of the request paths of an entry
+// point, applied before routing so that the path rules can't be bypassed by
+// equivalent paths. MergeSlashes collapses the duplicate slashes,
+// RemoveDotSegments resolves the . and .. segments and NormalizeEscapes decodes
+// the percent escapes of the unreserved characters and uppercases the others.
+// Strict rejects the paths with invalid escapes, escaped slashes, backslashes or
+// NUL, or .. segments climbing above the root.
+type PathNormalization struct {
+	MergeSlashes      bool
+	RemoveDotSegments bool
+	NormalizeEscapes  bool
+	Strict            bool
+}
+
+// HeaderPolicy holds the sanitation of the request headers of an entry point.
+// StripForwarded removes the X-Forwarded-*, Forwarded and X-Real-IP headers sent
+// by the clients, so that the backends only get the values set by traefik,
+// StripConnection removes the headers listed in the Connection header except
+// Upgrade, and StripHeaders removes other headers. EmitForwarded adds the client
+// to the Forwarded header (RFC 7239). The requests with a header value longer
+// than MaxValueLength are answered with 431, and with control characters in a
+// header value with 400 when RejectControlChars is set.
+type HeaderPolicy struct {
+	StripForwarded     bool
+	StripConnection    bool
+	StripHeaders       []string
+	EmitForwarded      bool
+	MaxValueLength     int
+	RejectControlChars bool
+}
+
+// SecurityHeaders holds the security headers of the responses of an entry point
+// or a frontend. The basic profile adds X-Content-Type-Options, X-Frame-Options
+// and Referrer-Policy, and strips X-Powered-By; the strict profile adds stricter
+// values and HSTS, and also strips Server and the X-AspNet-* versions. Headers
+// are added to those of the profile, an empty value removing a header of the
+// profile, STSSeconds overrides the max-age of HSTS, and StripHeaders are removed.
+type SecurityHeaders struct {
+	Profile      string            `json:"profile,omitempty"`
+	STSSeconds   int64             `json:"stsSeconds,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	StripHeaders []string          `json:"stripHeaders,omitempty"`
+}
+
+// ClientCA holds the client certificates required by a frontend, whatever the
+// ClientCAFiles of its entrypoints: issued by one of the CAs of Files, with a
+// common name or a subject alternative name matching one of the AllowedSubjects
+// glob patterns when set. The requests without a certificate are passed on when
+// Optional is set, those with an invalid certificate never are.
+type ClientCA struct {
+	Files           []string `json:"files,omitempty"`
+	Optional        bool     `json:"optional,omitempty"`
+	AllowedSubjects []string `json:"allowedSubjects,omitempty"`
+}
+
+// ClientHeaders holds the names of the request headers forwarding the verified
+// client certificate of the requests of a frontend to its backend: the
+// base64 DER of the certificate, its subject, its subject alternative names,
+// its serial number and its expiration. The headers left empty are not set.
+type ClientHeaders struct {
+	Certificate string `json:"certificate,omitempty"`
+	Subject     string `json:"subject,omitempty"`
+	SANs        string `json:"sans,omitempty"`
+	Serial      string `json:"serial,omitempty"`
+	NotAfter    string `json:"notAfter,omitempty"`
+}
+
+// ConcurrencyLimit holds the maximum number of in-flight requests of each client
+// of a frontend. The clients are identified by ExtractorFunc, like the maxConn of
+// the backends: client.ip, the default, request.host or request.header.<name>,
+// such as request.header.X-Api-Key.
+type ConcurrencyLimit struct {
+	Amount        int64  `json:"amount,omitempty"`
+	ExtractorFunc string `json:"extractorFunc,omitempty"`
+}
+
+// Coalesce holds the coalescing of the concurrent identical GET and HEAD
+// requests of a frontend into a single request to its backend. The requests
+// with different values of the VaryHeaders, or of the Authorization and
+// Cookie headers, are not identical. The responses larger than MaxBodySize
+// bytes, 1MB if unset, are not shared.
+type Coalesce struct {
+	VaryHeaders []string `json:"varyHeaders,omitempty"`
+	MaxBodySize int64    `json:"maxBodySize,omitempty"`
+}
+
+// ACL holds the access control list of a frontend: its Rules are evaluated in
+// order, and the first one matching a request allows or denies it. The
+// requests matching no rule get the DefaultAction, allow if unset.
+type ACL struct {
+	Rules         []ACLRule `json:"rules,omitempty"`
+	DefaultAction string    `json:"defaultAction,omitempty"`
+}
+
+// ACLRule matches the requests with one of its Methods, a path matching one of
+// its Paths glob patterns, from one of its Users authenticated by the frontend
+// or the entrypoint, and from one of its IPs ranges, the empty lists matching
+// all the requests. Action is allow or deny.
+type ACLRule struct {
+	Methods []string `json:"methods,omitempty"`
+	Paths   []string `json:"paths,omitempty"`
+	Users   []string `json:"users,omitempty"`
+	IPs     []string `json:"ips,omitempty"`
+	Action  string   `json:"action,omitempty"`
+}
+
+// OPA holds the authorization of the requests of a frontend by an Open Policy
+// Agent server, like a sidecar: the attributes of each request are the input of
+// the decision at Path of the server at URL, http://127.0.0.1:8181 if unset,
+// which allows or denies the request and can add headers to it. When the
+// decision fails after Timeout milliseconds, 1000 if unset, the requests are
+// denied unless FailOpen.
+type OPA struct {
+	URL      string `json:"url,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Timeout  int    `json:"timeout,omitempty"`
+	FailOpen bool   `json:"failOpen,omitempty"`
+}
+
+// GraphQL holds the limits of the GraphQL operations of a frontend: MaxDepth is
+// the deepest nesting of fields, and MaxComplexity the largest number of fields,
+// the fragments spread included. A zero limit is disabled.
+type GraphQL struct {
+	MaxDepth      int `json:"maxDepth,omitempty"`
+	MaxComplexity int `json:"maxComplexity,omitempty"`
+}
+
+// Usage holds the configuration of the bandwidth and requests accounting per frontend
+type Usage struct {
+	Storage       string `description:"File where the usage is saved periodically, to keep it across restarts"`
+	FlushInterval int64  `description:"Interval in seconds between two saves of the usage and refreshes of its health metrics"`
+	Retention     int64  `description:"Number of days of hourly usage kept"`
+}
+
+// ConfigCache holds the configuration of the dynamic configuration cache,
+// used to boot when the providers are unreachable
+type ConfigCache struct {
+	File    string `description:"File where the last loaded dynamic configuration is saved"`
+	Timeout int64  `description:"Duration in seconds to wait for each provider at startup before loading its cached configuration"`
+	Pin     bool   `description:"Load the cached configuration immediately at startup, while the providers warm up"`
+}
+
+// CertExpiry holds the configuration of the warnings of the certificates close to their expiry
+type CertExpiry struct {
+	WarnDays      int   `description:"Number of days before their expiry from which the certificates are reported"`
+	CheckInterval int64 `description:"Interval in seconds between the checks of the certificates"`
+}
+
+// DefaultCert holds the configuration of the self-signed certificate generated
+// for the TLS entrypoints without certificates
+type DefaultCert struct {
+	Subject  string `description:"Common name of the generated certificate"`
+	SANs     SANs   `description:"Domains of the generated certificate, a random .traefik.default one by default"`
+	KeyType  string `description:"Key type of the generated certificate: RSA2048, RSA4096, EC256 or EC384"`
+	Validity int    `description:"Validity in days of the generated certificate"`
+	CertFile string `description:"File the generated certificate is saved to, and loaded from at the next starts"`
+	KeyFile  string `description:"File the private key of the generated certificate is saved to"`
+}
+
+// SessionTickets holds the rotation of the TLS session ticket keys of the entrypoints
+type SessionTickets struct {
+	RotationInterval int64  `description:"Interval in seconds between two rotations of the session ticket keys"`
+	Keys             int    `description:"Number of session ticket keys accepted, the newest one encrypting the new tickets"`
+	KeyFile          string `description:"File of base64 encoded 32 bytes session ticket keys, one per line and the first one encrypting the new tickets, reloaded at each rotation interval instead of generated keys"`
+	Storage          string `description:"Key of the cluster store sharing the session ticket keys between the traefik instances, in cluster mode"`
+}
+
+// Sandbox holds the hardening of traefik once its entrypoints are bound
+type Sandbox struct {
+	User    string `description:"User, by name or uid, traefik runs as once the entrypoints are bound"`
+	Group   string `description:"Group, by name or gid, traefik runs as, the primary group of the user by default"`
+	Seccomp bool   `description:"Deny the system calls traefik doesn't need once started, like ptrace, mount or execve, which spawning plugins needs"`
+}
+
+// ConfigFreeze holds the configuration of the change freezes of the dynamic configuration
+type ConfigFreeze struct {
+	Windows FreezeWindows `description:"Change freeze windows, as start/end RFC3339 times"`
+	Reject  bool          `description:"Reject the configuration updates received during a freeze, instead of applying the last ones when it ends"`
+}
+
+// ConfigHistory holds the configuration of the history of the applied dynamic configurations
+type ConfigHistory struct {
+	Size       int    `description:"Number of applied dynamic configurations kept"`
+	KVBackend  string `description:"KV store saving the history: consul, etcd, zookeeper or boltdb"`
+	KVEndpoint string `description:"Comma separated server endpoints of the KV store"`
+	KVKey      string `description:"Key of the KV store holding the history"`
+}
+
+// FreezeWindows holds change freeze windows, as start/end RFC3339 times
+type FreezeWindows []string
+
+// Set adds strings elem into the the parser
+// it splits str on , and ;
+func (w *FreezeWindows) Set(str string) error {
+	fargs := func(c rune) bool {
+		return c == ',' || c == ';'
+	}
+	*w = append(*w, strings.FieldsFunc(str, fargs)...)
+	return nil
+}
+
+// Get []string
+func (w *FreezeWindows) Get() interface{} { return FreezeWindows(*w) }
+
+// String return slice in a string
+func (w *FreezeWindows) String() string { return fmt.Sprintf("%v", *w) }
+
+// SetValue sets []string into the parser
+func (w *FreezeWindows) SetValue(val interface{}) {
+	*w = FreezeWindows(val.(FreezeWindows))
+}
+
+// CDNs holds the names of the trusted CDNs
+type CDNs []string
+
+// Set adds strings elem into the the parser
+// it splits str on , and ;
+func (c *CDNs) Set(str string) error {
+	fargs := func(c rune) bool {
+		return c == ',' || c == ';'
+	}
+	*c = append(*c, strings.FieldsFunc(str, fargs)...)
+	return nil
+}
+
+// Get []string
+func (c *CDNs) Get() interface{} { return CDNs(*c) }
+
+// String return slice in a string
+func (c *CDNs) String() string { return fmt.Sprintf("%v", *c) }
+
+// SetValue sets []string into the parser
+func (c *CDNs) SetValue(val interface{}) {
+	*c = CDNs(val.(CDNs))
+}
+
+// SANs holds the domains of a certificate
+type SANs []string
+
+// Set adds strings elem into the the parser
+// it splits str on , and ;
+func (s *SANs) Set(str string) error {
+	fargs := func(c rune) bool {
+		return c == ',' || c == ';'
+	}
+	*s = append(*s, strings.FieldsFunc(str, fargs)...)
+	return nil
+}
+
+// Get []string
+func (s *SANs) Get() interface{} { return SANs(*s) }
+
+// String return slice in a string
+func (s *SANs) String() string { return fmt.Sprintf("%v", *s) }
+
+// SetValue sets []string into the parser
+func (s *SANs) SetValue(val interface{}) {
+	*s = SANs(val.(SANs))
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+// unixSocketCheckInterval is the interval between the checks of the sockets
+// of the unix socket servers
+const unixSocketCheckInterval = 5 * time.Second
+
+// parseServerURL parses the URL of a server. A unix:///path/to.sock URL is
+// replaced by an http URL whose host stands for the socket, returned with it,
+// as the load balancers and the forwarder only keep the scheme and the host
+// of the servers.
+func parseServerURL(rawURL string) (*url.URL, string, error) {
+	serverURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+	if serverURL.Scheme != "unix" {
+		return serverURL, "", nil
+	}
+	if len(serverURL.Host) > 0 || len(serverURL.Path) == 0 {
+		return nil, "", fmt.Errorf("invalid unix socket URL %s, expected unix:///path/to.sock", rawURL)
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(serverURL.Path))
+	return &url.URL{Scheme: "http", Host: fmt.Sprintf("unix-%016x", hash.Sum64())}, serverURL.Path, nil
+}
+
+// unixSocketTransport sends the requests to the unix socket servers of a
+// backend over their socket, and the others with transport.
+type unixSocketTransport struct {
+	sockets   map[string]string
+	unix      *http.Transport
+	transport http.RoundTripper
+}
+
+// newUnixSocketTransport returns the transport of a backend with the unix
+// socket servers of sockets, by host of their URL.
+func newUnixSocketTransport(sockets map[string]string, transport http.RoundTripper, expectContinueTimeout time.Duration) *unixSocketTransport {
+	return &unixSocketTransport{
+		sockets: sockets,
+		unix: &http.Transport{
+			Dial: func(network, addr string) (net.Conn, error) {
+				host, _, err := net.SplitHostPort(addr)
+				if err != nil {
+					return nil, err
+				}
+				socket, ok := sockets[host]
+				if !ok {
+					return nil, fmt.Errorf("no unix socket for %s", addr)
+				}
+				return net.DialTimeout("unix", socket, backendDialTimeout)
+			},
+			ExpectContinueTimeout: expectContinueTimeout,
+		},
+		transport: transport,
+	}
+}
+
+func (t *unixSocketTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, ok := t.sockets[req.URL.Host]; ok {
+		return t.unix.RoundTrip(req)
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// unixSocketBalancer is the load balancer of a backend, a round robin or a rebalancer.
+type unixSocketBalancer interface {
+	UpsertServer(u *url.URL, options ...roundrobin.ServerOption) error
+	RemoveServer(u *url.URL) error
+}
+
+// unixSocketServer is a unix socket server of a backend, in its load balancer
+// while its socket exists.
+type unixSocketServer struct {
+	name   string
+	url    *url.URL
+	socket string
+	weight int
+	up     bool
+}
+
+// unixSocketBackend holds the unix socket servers of a backend.
+type unixSocketBackend struct {
+	name     string
+	balancer unixSocketBalancer
+	servers  []*unixSocketServer
+}
+
+// check adds the servers whose socket exists to the load balancer, and
+// removes the others.
+func (b *unixSocketBackend) check() {
+	for _, server := range b.servers {
+		info, err := os.Stat(server.socket)
+		up := err == nil && info.Mode()&os.ModeSocket != 0
+		if up == server.up {
+			continue
+		}
+		if up {
+			if err := b.balancer.UpsertServer(server.url, roundrobin.Weight(server.weight)); err != nil {
+				log.Errorf("Error adding server %s of backend %s to load balancer: %v", server.name, b.name, err)
+				continue
+			}
+			log.Infof("Socket %s of server %s of backend %s is up", server.socket, server.name, b.name)
+		} else {
+			if err := b.balancer.RemoveServer(server.url); err != nil {
+				log.Errorf("Error removing server %s of backend %s from load balancer: %v", server.name, b.name, err)
+				continue
+			}
+			log.Warnf("Socket %s of server %s of backend %s is down, removing it from the load balancer", server.socket, server.name, b.name)
+		}
+		server.up = up
+	}
+}
+
+// UnixSocketChecks removes the unix socket servers from the load balancers
+// of their backends while their socket doesn't exist.
+type UnixSocketChecks struct {
+	mutex    sync.Mutex
+	backends map[string]*unixSocketBackend
+	loading  map[string]*unixSocketBackend
+}
+
+// NewUnixSocketChecks returns a UnixSocketChecks without backends.
+func NewUnixSocketChecks() *UnixSocketChecks {
+	return &UnixSocketChecks{
+		backends: make(map[string]*unixSocketBackend),
+		loading:  make(map[string]*unixSocketBackend),
+	}
+}
+
+// Load adds the servers whose socket exists to the load balancer of a backend
+// of the configuration being loaded, which is checked once the configuration
+// is loaded.
+func (c *UnixSocketChecks) Load(backend *unixSocketBackend) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	backend.check()
+	c.loading[backend.name] = backend
+}
+
+// SetBackends replaces the checked backends by the backends of the loaded
+// configuration, of backendNames.
+func (c *UnixSocketChecks) SetBackends(backendNames map[string]bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.backends = make(map[string]*unixSocketBackend)
+	for backendName, backend := range c.loading {
+		if backendNames[backendName] {
+			c.backends[backendName] = backend
+		}
+	}
+	c.loading = make(map[string]*unixSocketBackend)
+}
+
+// Check checks the sockets of the servers of the backends.
+func (c *UnixSocketChecks) Check() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, backend := range c.backends {
+		backend.check()
+	}
+}
+
+// Run checks the sockets every interval until stop.
+func (c *UnixSocketChecks) Run(stop chan bool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.Check()
+		}
+	}
+}
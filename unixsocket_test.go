@@ -0,0 +1,103 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+type fakeBalancer struct {
+	servers map[string]bool
+}
+
+func (b *fakeBalancer) UpsertServer(u *url.URL, options ...roundrobin.ServerOption) error {
+	b.servers[u.String()] = true
+	return nil
+}
+
+func (b *fakeBalancer) RemoveServer(u *url.URL) error {
+	delete(b.servers, u.String())
+	return nil
+}
+
+func TestParseServerURL(t *testing.T) {
+	serverURL, socket, err := parseServerURL("http://172.17.0.2:80")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://172.17.0.2:80", serverURL.String())
+	assert.Empty(t, socket)
+
+	serverURL, socket, err = parseServerURL("unix:///var/run/app.sock")
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/run/app.sock", socket)
+	assert.Equal(t, "http", serverURL.Scheme)
+	other, _, _ := parseServerURL("unix:///var/run/other.sock")
+	assert.NotEqual(t, serverURL.Host, other.Host)
+
+	for _, invalid := range []string{"unix://app.sock", "unix://"} {
+		_, _, err := parseServerURL(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestUnixSocketServers(t *testing.T) {
+	directory, err := ioutil.TempDir("", "traefik-unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	socket := filepath.Join(directory, "app.sock")
+	serverURL, _, err := parseServerURL("unix://" + socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	balancer := &fakeBalancer{servers: map[string]bool{}}
+	checks := NewUnixSocketChecks()
+	checks.Load(&unixSocketBackend{
+		name:     "backend1",
+		balancer: balancer,
+		servers:  []*unixSocketServer{{name: "server1", url: serverURL, socket: socket, weight: 1}},
+	})
+	checks.SetBackends(map[string]bool{"backend1": true})
+	assert.Empty(t, balancer.servers, "the socket doesn't exist yet")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go http.Serve(listener, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(r.URL.Path))
+	}))
+	checks.Check()
+	assert.True(t, balancer.servers[serverURL.String()])
+
+	transport := newUnixSocketTransport(map[string]string{serverURL.Host: socket}, http.DefaultTransport, time.Second)
+	response, err := (&http.Client{Transport: transport}).Get(serverURL.String() + "/ping")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		assert.Equal(t, "/ping", string(body))
+	}
+
+	listener.Close()
+	os.Remove(socket)
+	checks.Check()
+	assert.Empty(t, balancer.servers)
+
+	checks.SetBackends(map[string]bool{})
+	listener, err = net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	checks.Check()
+	assert.Empty(t, balancer.servers, "the backend is no longer loaded")
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/types"
+)
+
+// usageBucketDuration is the resolution of the usage history
+const usageBucketDuration = time.Hour
+
+// UsageRecorder is a middleware accounting the requests and the bytes received
+// and sent per frontend, for the chargeback of shared edges. The usage is kept
+// per hour for the configured retention, and saved periodically to its storage.
+type UsageRecorder struct {
+	config    *types.Usage
+	mutex     sync.Mutex
+	tenants   map[string]string
+	frontends map[string]*usageFrontend
+	totals    []*UsageCount
+}
+
+// usageFrontend is the usage of a frontend, as saved in the storage
+type usageFrontend struct {
+	Tenant string                   `json:"tenant,omitempty"`
+	Total  usageCounters            `json:"total"`
+	Hours  map[int64]*usageCounters `json:"hours"`
+}
+
+type usageCounters struct {
+	Requests int64 `json:"requests"`
+	BytesIn  int64 `json:"bytesIn"`
+	BytesOut int64 `json:"bytesOut"`
+}
+
+func (c *usageCounters) add(other *usageCounters) {
+	c.Requests += other.Requests
+	c.BytesIn += other.BytesIn
+	c.BytesOut += other.BytesOut
+}
+
+// UsageCount is the number of requests and bytes of the bodies received and sent by a frontend or a tenant.
+type UsageCount struct {
+	Frontend string `json:"frontend,omitempty"`
+	Tenant   string `json:"tenant,omitempty"`
+	Requests int64  `json:"requests"`
+	BytesIn  int64  `json:"bytes_in"`
+	BytesOut int64  `json:"bytes_out"`
+}
+
+// UsageReport is the usage of the frontends and tenants over a time window.
+type UsageReport struct {
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Frontends []*UsageCount `json:"frontends"`
+	Tenants   []*UsageCount `json:"tenants"`
+}
+
+// NewUsageRecorder returns a UsageRecorder, with the usage saved in the storage of config if any.
+func NewUsageRecorder(config *types.Usage) (*UsageRecorder, error) {
+	u := &UsageRecorder{config: config, tenants: map[string]string{}, frontends: map[string]*usageFrontend{}}
+	if len(config.Storage) > 0 {
+		data, err := ioutil.ReadFile(config.Storage)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &u.frontends); err != nil {
+				return nil, err
+			}
+			log.Infof("Loaded the usage of %d frontends from %s", len(u.frontends), config.Storage)
+		}
+	}
+	u.totals = u.computeTotals()
+	return u, nil
+}
+
+// SetTenants sets the tenant of each frontend, the usage of a frontend is
+// reported for its current tenant.
+func (u *UsageRecorder) SetTenants(tenants map[string]string) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.tenants = tenants
+	for frontendName, frontend := range u.frontends {
+		if tenant, ok := tenants[frontendName]; ok {
+			frontend.Tenant = tenant
+		}
+	}
+}
+
+func (u *UsageRecorder) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	r = middlewares.WithRequestInfo(r)
+	body := &usageBody{ReadCloser: r.Body}
+	if r.Body != nil {
+		r.Body = body
+	}
+	writer := &usageResponseWriter{ResponseWriter: rw, request: r, recorder: u}
+	next(writer, r)
+	frontendName := middlewares.GetFrontendName(r)
+	if len(frontendName) == 0 {
+		return
+	}
+	u.add(frontendName, &usageCounters{Requests: 1, BytesIn: atomic.LoadInt64(&body.count), BytesOut: writer.count})
+}
+
+func (u *UsageRecorder) add(frontendName string, counters *usageCounters) {
+	hour := time.Now().Truncate(usageBucketDuration).Unix()
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	frontend, ok := u.frontends[frontendName]
+	if !ok {
+		frontend = &usageFrontend{Hours: map[int64]*usageCounters{}}
+		u.frontends[frontendName] = frontend
+	}
+	if tenant, ok := u.tenants[frontendName]; ok {
+		frontend.Tenant = tenant
+	}
+	if frontend.Hours == nil {
+		frontend.Hours = map[int64]*usageCounters{}
+	}
+	bucket, ok := frontend.Hours[hour]
+	if !ok {
+		bucket = &usageCounters{}
+		frontend.Hours[hour] = bucket
+	}
+	bucket.add(counters)
+	frontend.Total.add(counters)
+}
+
+// Report returns the usage between from and to, rounded to the hours.
+func (u *UsageRecorder) Report(from, to time.Time) *UsageReport {
+	from = from.Truncate(usageBucketDuration)
+	report := &UsageReport{From: from, To: to, Frontends: []*UsageCount{}, Tenants: []*UsageCount{}}
+	tenants := map[string]*UsageCount{}
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	for frontendName, frontend := range u.frontends {
+		counters := &usageCounters{}
+		for hour, bucket := range frontend.Hours {
+			if hour >= from.Unix() && hour < to.Unix() {
+				counters.add(bucket)
+			}
+		}
+		if counters.Requests == 0 {
+			continue
+		}
+		report.Frontends = append(report.Frontends, newUsageCount(frontendName, frontend.Tenant, counters))
+		tenant, ok := tenants[frontend.Tenant]
+		if !ok {
+			tenant = &UsageCount{Tenant: frontend.Tenant}
+			tenants[frontend.Tenant] = tenant
+			report.Tenants = append(report.Tenants, tenant)
+		}
+		tenant.Requests += counters.Requests
+		tenant.BytesIn += counters.BytesIn
+		tenant.BytesOut += counters.BytesOut
+	}
+	sort.Sort(usageCountsByName(report.Frontends))
+	sort.Sort(usageCountsByName(report.Tenants))
+	return report
+}
+
+// Totals returns the usage of the frontends since they are recorded, as of the last flush.
+func (u *UsageRecorder) Totals() []*UsageCount {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	return u.totals
+}
+
+func (u *UsageRecorder) computeTotals() []*UsageCount {
+	totals := []*UsageCount{}
+	for frontendName, frontend := range u.frontends {
+		totals = append(totals, newUsageCount(frontendName, frontend.Tenant, &frontend.Total))
+	}
+	sort.Sort(usageCountsByName(totals))
+	return totals
+}
+
+// Flush drops the usage older than the retention, refreshes the totals and saves the usage to the storage.
+func (u *UsageRecorder) Flush() {
+	oldest := time.Now().Add(-time.Duration(u.config.Retention) * 24 * time.Hour).Unix()
+	u.mutex.Lock()
+	for _, frontend := range u.frontends {
+		for hour := range frontend.Hours {
+			if u.config.Retention > 0 && hour < oldest {
+				delete(frontend.Hours, hour)
+			}
+		}
+	}
+	u.totals = u.computeTotals()
+	var data []byte
+	var err error
+	if len(u.config.Storage) > 0 {
+		data, err = json.Marshal(u.frontends)
+	}
+	u.mutex.Unlock()
+	if err != nil {
+		log.Errorf("Error encoding the usage: %v", err)
+		return
+	}
+	if len(data) > 0 {
+		if err := writeUsageFile(u.config.Storage, data); err != nil {
+			log.Errorf("Error saving the usage to %s: %v", u.config.Storage, err)
+		}
+	}
+}
+
+// writeUsageFile replaces file atomically, so that a crash can't lose the usage.
+func writeUsageFile(file string, data []byte) error {
+	if err := ioutil.WriteFile(file+".tmp", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(file+".tmp", file)
+}
+
+// Run flushes the usage every interval until stop is closed, and a last time before returning.
+func (u *UsageRecorder) Run(stop chan bool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			u.Flush()
+			return
+		case <-ticker.C:
+			u.Flush()
+		}
+	}
+}
+
+func newUsageCount(frontendName, tenant string, counters *usageCounters) *UsageCount {
+	return &UsageCount{
+		Frontend: frontendName,
+		Tenant:   tenant,
+		Requests: counters.Requests,
+		BytesIn:  counters.BytesIn,
+		BytesOut: counters.BytesOut,
+	}
+}
+
+type usageCountsByName []*UsageCount
+
+func (a usageCountsByName) Len() int      { return len(a) }
+func (a usageCountsByName) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a usageCountsByName) Less(i, j int) bool {
+	if a[i].Frontend != a[j].Frontend {
+		return a[i].Frontend < a[j].Frontend
+	}
+	return a[i].Tenant < a[j].Tenant
+}
+
+// usageBody counts the bytes of the request body read by the backends.
+type usageBody struct {
+	io.ReadCloser
+	count int64
+}
+
+func (b *usageBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(&b.count, int64(n))
+	return n, err
+}
+
+// usageResponseWriter counts the bytes of the response body. The bytes
+// exchanged on hijacked connections, such as websockets, are accounted when
+// the connection is closed.
+type usageResponseWriter struct {
+	http.ResponseWriter
+	request  *http.Request
+	recorder *UsageRecorder
+	count    int64
+}
+
+func (w *usageResponseWriter) Write(data []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(data)
+	w.count += int64(n)
+	return n, err
+}
+
+func (w *usageResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *usageResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err != nil {
+		return conn, rw, err
+	}
+	return &usageConn{Conn: conn, frontendName: middlewares.GetFrontendName(w.request), recorder: w.recorder}, rw, nil
+}
+
+func (w *usageResponseWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+// usageConn counts the bytes of a hijacked connection.
+type usageConn struct {
+	net.Conn
+	frontendName string
+	recorder     *UsageRecorder
+	in, out      int64
+	closed       int32
+}
+
+func (c *usageConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	atomic.AddInt64(&c.in, int64(n))
+	return n, err
+}
+
+func (c *usageConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	atomic.AddInt64(&c.out, int64(n))
+	return n, err
+}
+
+func (c *usageConn) Close() error {
+	if atomic.CompareAndSwapInt32(&c.closed, 0, 1) && len(c.frontendName) > 0 {
+		c.recorder.add(c.frontendName, &usageCounters{BytesIn: atomic.LoadInt64(&c.in), BytesOut: atomic.LoadInt64(&c.out)})
+	}
+	return c.Conn.Close()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveUsage(recorder *UsageRecorder, frontendName string, body string) {
+	next := middlewares.FrontendHandler(frontendName, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		rw.Write([]byte("received " + string(data)))
+	}))
+	recorder.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(body)), next.ServeHTTP)
+}
+
+func TestUsageRecorder(t *testing.T) {
+	recorder, err := NewUsageRecorder(&types.Usage{Retention: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.SetTenants(map[string]string{"frontend1": "team1", "frontend2": "team1"})
+	serveUsage(recorder, "frontend1", "0123456789")
+	serveUsage(recorder, "frontend1", "01234")
+	serveUsage(recorder, "frontend2", "")
+	serveUsage(recorder, "frontend3", "0")
+	// the requests not routed to a frontend are not accounted
+	serveUsage(recorder, "", "0123456789")
+
+	now := time.Now()
+	report := recorder.Report(now.Add(-time.Hour), now.Add(time.Second))
+	assert.Equal(t, []*UsageCount{
+		{Frontend: "frontend1", Tenant: "team1", Requests: 2, BytesIn: 15, BytesOut: 33},
+		{Frontend: "frontend2", Tenant: "team1", Requests: 1, BytesIn: 0, BytesOut: 9},
+		{Frontend: "frontend3", Requests: 1, BytesIn: 1, BytesOut: 10},
+	}, report.Frontends)
+	assert.Equal(t, []*UsageCount{
+		{Requests: 1, BytesIn: 1, BytesOut: 10},
+		{Tenant: "team1", Requests: 3, BytesIn: 15, BytesOut: 42},
+	}, report.Tenants)
+
+	// the usage is reported per hour
+	report = recorder.Report(now.Add(time.Hour), now.Add(2*time.Hour))
+	assert.Empty(t, report.Frontends)
+
+	// the totals are refreshed by the flushes
+	assert.Empty(t, recorder.Totals())
+	recorder.Flush()
+	assert.Len(t, recorder.Totals(), 3)
+}
+
+func TestUsageRecorderStorage(t *testing.T) {
+	directory, err := ioutil.TempDir("", "usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	config := &types.Usage{Storage: filepath.Join(directory, "usage.json"), Retention: 1}
+
+	recorder, err := NewUsageRecorder(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serveUsage(recorder, "frontend1", "0123456789")
+	recorder.add("frontend1", &usageCounters{Requests: 1})
+	recorder.frontends["frontend1"].Hours[time.Now().Add(-72*time.Hour).Truncate(time.Hour).Unix()] = &usageCounters{Requests: 5}
+	recorder.Flush()
+
+	recorder, err = NewUsageRecorder(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*UsageCount{{Frontend: "frontend1", Requests: 2, BytesIn: 10, BytesOut: 19}}, recorder.Totals())
+	// the hours older than the retention are dropped
+	assert.Len(t, recorder.frontends["frontend1"].Hours, 1)
+}
+
+func TestUsageHandler(t *testing.T) {
+	provider := &WebProvider{server: &Server{}}
+	response := httptest.NewRecorder()
+	provider.getUsageHandler(response, httptest.NewRequest("GET", "/api/usage", nil))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	provider.server.usageRecorder, _ = NewUsageRecorder(&types.Usage{})
+	serveUsage(provider.server.usageRecorder, "frontend1", "")
+	response = httptest.NewRecorder()
+	provider.getUsageHandler(response, httptest.NewRequest("GET", "/api/usage?window=1h", nil))
+	assert.Equal(t, http.StatusOK, response.Code)
+	report := &UsageReport{}
+	if err := json.Unmarshal(response.Body.Bytes(), report); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, report.Frontends, 1)
+
+	for _, query := range []string{"window=-1h", "from=yesterday", "from=2016-11-02T10:00:00Z&to=2016-11-01T10:00:00Z"} {
+		response = httptest.NewRecorder()
+		provider.getUsageHandler(response, httptest.NewRequest("GET", "/api/usage?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, response.Code, query)
+	}
+}
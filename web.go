@@ -1,12 +1,19 @@
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"expvar"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/codegangsta/negroni"
 	"github.com/containous/mux"
@@ -22,8 +29,21 @@ import (
 )
 
 var (
-	metrics       = thoas_stats.New()
-	statsRecorder *StatsRecorder
+	metrics          = thoas_stats.New()
+	statsRecorder    *StatsRecorder
+	sloRecorder      = NewSLORecorder()
+	requestTap       = NewRequestTap()
+	errorRecorder    = NewErrorRecorder()
+	trafficCapture   = NewTrafficCapture()
+	faultInjector    = NewFaultInjector()
+	frontendDrains   = NewFrontendDrains()
+	authLockouts     = NewAuthLockouts()
+	streamAborts     = NewStreamAborts()
+	tlsHandshakes    = NewTLSHandshakes()
+	tlsClients       = NewTLSClients()
+	concurrencies    = NewConcurrencyLimits()
+	unixSockets      = NewUnixSocketChecks()
+	providerStatuses = NewProviderStatuses()
 )
 
 // WebProvider is a provider.Provider implementation that provides the UI.
@@ -34,6 +54,8 @@ type WebProvider struct {
 	KeyFile    string            `description:"SSL certificate"`
 	ReadOnly   bool              `description:"Enable read only API"`
 	Statistics *types.Statistics `description:"Enable more detailed statistics"`
+	ClientCA   string            `description:"CA of the required client certificates"`
+	CertRoles  WebCertRoles      `description:"Roles of the client certificates organizational units, as ou:role with admin, read-only or monitoring roles"`
 	server     *Server
 	Auth       *types.Auth
 }
@@ -63,9 +85,38 @@ func (provider *WebProvider) Provide(configurationChan chan<- types.ConfigMessag
 
 	// ping route
 	systemRouter.Methods("GET").Path("/ping").HandlerFunc(provider.getPingHandler)
+	systemRouter.Methods("GET").Path("/ping/ready").HandlerFunc(provider.getReadyHandler)
+
+	// metrics route
+	systemRouter.Methods("GET").Path("/metrics").HandlerFunc(provider.getMetricsHandler)
 	// API routes
 	systemRouter.Methods("GET").Path("/api").HandlerFunc(provider.getConfigHandler)
 	systemRouter.Methods("GET").Path("/api/version").HandlerFunc(provider.getVersionHandler)
+	systemRouter.Methods("GET").Path("/api/openapi.json").HandlerFunc(provider.getOpenAPIHandler)
+	systemRouter.Methods("GET").Path("/api/slo").HandlerFunc(provider.getSLOHandler)
+	systemRouter.Methods("GET").Path("/api/certificates").HandlerFunc(provider.getCertificatesHandler)
+	systemRouter.Methods("GET").Path("/api/fips").HandlerFunc(provider.getFIPSHandler)
+	systemRouter.Methods("GET").Path("/api/ca").HandlerFunc(provider.getCAHandler)
+	systemRouter.Methods("POST").Path("/api/ca/certificates").HandlerFunc(provider.postCACertificateHandler)
+	systemRouter.Methods("GET").Path("/api/frontends/{frontend}/pipeline").HandlerFunc(provider.getPipelineHandler)
+	systemRouter.Methods("GET").Path("/api/frontends/{frontend}/tap").HandlerFunc(provider.getTapHandler)
+	systemRouter.Methods("GET").Path("/api/frontends/{frontend}/drain").HandlerFunc(provider.getDrainHandler)
+	systemRouter.Methods("PUT").Path("/api/frontends/{frontend}/drain").HandlerFunc(provider.putDrainHandler)
+	systemRouter.Methods("DELETE").Path("/api/frontends/{frontend}/drain").HandlerFunc(provider.deleteDrainHandler)
+	systemRouter.Methods("GET").Path("/api/drains").HandlerFunc(provider.getDrainsHandler)
+	systemRouter.Methods("POST").Path("/api/test-route").HandlerFunc(provider.postTestRouteHandler)
+	systemRouter.Methods("GET").Path("/api/faults").HandlerFunc(provider.getFaultsHandler)
+	systemRouter.Methods("PUT").Path("/api/faults").HandlerFunc(provider.putFaultsHandler)
+	systemRouter.Methods("GET").Path("/api/config/history").HandlerFunc(provider.getHistoryHandler)
+	systemRouter.Methods("GET").Path("/api/config/history/{version:[0-9]+}").HandlerFunc(provider.getHistoryVersionHandler)
+	systemRouter.Methods("POST").Path("/api/config/history/{version:[0-9]+}/rollback").HandlerFunc(provider.postRollbackHandler)
+	systemRouter.Methods("GET").Path("/api/freeze").HandlerFunc(provider.getFreezeHandler)
+	systemRouter.Methods("PUT").Path("/api/freeze").HandlerFunc(provider.putFreezeHandler)
+	systemRouter.Methods("GET").Path("/api/usage").HandlerFunc(provider.getUsageHandler)
+	systemRouter.Methods("GET").Path("/api/conflicts").HandlerFunc(provider.getConflictsHandler)
+	systemRouter.Methods("GET").Path("/api/dryrun").HandlerFunc(provider.getDryRunsHandler)
+	systemRouter.Methods("GET").Path("/api/dryrun/{provider}").HandlerFunc(provider.getDryRunHandler)
+	systemRouter.Methods("GET").Path("/api/prometheus/targets").HandlerFunc(provider.getPrometheusTargetsHandler)
 	systemRouter.Methods("GET").Path("/api/providers").HandlerFunc(provider.getConfigHandler)
 	systemRouter.Methods("GET").Path("/api/providers/{provider}").HandlerFunc(provider.getProviderHandler)
 	systemRouter.Methods("PUT").Path("/api/providers/{provider}").HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
@@ -115,16 +166,34 @@ func (provider *WebProvider) Provide(configurationChan chan<- types.ConfigMessag
 	go func() {
 		var err error
 		var negroni = negroni.New()
+		var tlsConfig *tls.Config
+		if len(provider.ClientCA) > 0 {
+			if len(provider.CertFile) == 0 || len(provider.KeyFile) == 0 {
+				log.Fatal("Error creating server: the web client CA requires a certificate and a key")
+			}
+			tlsConfig, err = webClientTLSConfig(provider.ClientCA)
+			if err != nil {
+				log.Fatal("Error creating server: ", err)
+			}
+			rbac, err := newWebRBAC(provider.CertRoles)
+			if err != nil {
+				log.Fatal("Error creating server: ", err)
+			}
+			negroni.Use(skipWebAuth(rbac))
+		}
 		if provider.Auth != nil {
 			authMiddleware, err := middlewares.NewAuthenticator(provider.Auth)
 			if err != nil {
 				log.Fatal("Error creating Auth: ", err)
 			}
-			negroni.Use(authMiddleware)
+			negroni.Use(skipWebAuth(authMiddleware))
 		}
 		negroni.UseHandler(systemRouter)
 
-		if len(provider.CertFile) > 0 && len(provider.KeyFile) > 0 {
+		if tlsConfig != nil {
+			webServer := &http.Server{Addr: provider.Address, Handler: negroni, TLSConfig: tlsConfig}
+			err = webServer.ListenAndServeTLS(provider.CertFile, provider.KeyFile)
+		} else if len(provider.CertFile) > 0 && len(provider.KeyFile) > 0 {
 			err = http.ListenAndServeTLS(provider.Address, provider.CertFile, provider.KeyFile, negroni)
 		} else {
 			err = http.ListenAndServe(provider.Address, negroni)
@@ -142,20 +211,393 @@ func (provider *WebProvider) Provide(configurationChan chan<- types.ConfigMessag
 type healthResponse struct {
 	*thoas_stats.Data
 	*Stats
+	SLO                []*SLOStatus              `json:"slo,omitempty"`
+	Errors             []*ErrorCount             `json:"errors,omitempty"`
+	Usage              []*UsageCount             `json:"usage,omitempty"`
+	AuthLockouts       []*AuthLockoutStatus      `json:"auth_lockouts,omitempty"`
+	StreamAborts       []*StreamAbortCount       `json:"stream_aborts,omitempty"`
+	TLSHandshakeErrors []*TLSHandshakeErrorCount `json:"tls_handshake_errors,omitempty"`
+	TLSClients         []*TLSClientCount         `json:"tls_clients,omitempty"`
 }
 
 func (provider *WebProvider) getHealthHandler(response http.ResponseWriter, request *http.Request) {
-	health := &healthResponse{Data: metrics.Data()}
+	health := &healthResponse{Data: metrics.Data(), SLO: sloRecorder.Data(), Errors: errorRecorder.Data(), AuthLockouts: authLockouts.Data(), StreamAborts: streamAborts.Data(), TLSHandshakeErrors: tlsHandshakes.Data(), TLSClients: tlsClients.Data()}
 	if statsRecorder != nil {
 		health.Stats = statsRecorder.Data()
 	}
+	if provider.server != nil && provider.server.usageRecorder != nil {
+		health.Usage = provider.server.usageRecorder.Totals()
+	}
 	templatesRenderer.JSON(response, http.StatusOK, health)
 }
 
+func (provider *WebProvider) getSLOHandler(response http.ResponseWriter, request *http.Request) {
+	templatesRenderer.JSON(response, http.StatusOK, sloRecorder.Data())
+}
+
+func (provider *WebProvider) getCertificatesHandler(response http.ResponseWriter, request *http.Request) {
+	templatesRenderer.JSON(response, http.StatusOK, provider.server.getCertificates())
+}
+
+// getMetricsHandler serves the expiry of the certificates, the egress counters
+// of the forward proxies, the statuses of the providers, and the SLO compliance
+// of the frontends, in the Prometheus text format.
+func (provider *WebProvider) getMetricsHandler(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeCertificateMetrics(response, provider.server.getCertificates())
+	writeForwardProxyMetrics(response, provider.server.forwardProxies)
+	writeProviderMetrics(response, providerStatuses.Data())
+	writeSLOMetrics(response, sloRecorder.Data())
+}
+
+func (provider *WebProvider) getFIPSHandler(response http.ResponseWriter, request *http.Request) {
+	templatesRenderer.JSON(response, http.StatusOK, provider.server.getFIPSStatus())
+}
+
+func (provider *WebProvider) getCAHandler(response http.ResponseWriter, request *http.Request) {
+	ca := provider.server.globalConfiguration.InternalCA
+	if ca == nil {
+		http.Error(response, "Internal CA is not enabled", http.StatusNotFound)
+		return
+	}
+	certificate := ca.Certificate()
+	if certificate == nil {
+		http.Error(response, "Internal CA is not loaded yet", http.StatusServiceUnavailable)
+		return
+	}
+	response.Header().Set("Content-Type", "application/x-pem-file")
+	pem.Encode(response, &pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
+}
+
+// postCACertificateHandler issues a certificate from the internal CA for the PEM
+// encoded certificate request of the body, and answers the certificate chain.
+func (provider *WebProvider) postCACertificateHandler(response http.ResponseWriter, request *http.Request) {
+	ca := provider.server.globalConfiguration.InternalCA
+	if ca == nil {
+		http.Error(response, "Internal CA is not enabled", http.StatusNotFound)
+		return
+	}
+	if !ca.Authorized(strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")) {
+		http.Error(response, "Invalid internal CA token", http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(request.Body, 64*1024))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+	block, _ := pem.Decode(body)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		http.Error(response, "Expected a PEM encoded CERTIFICATE REQUEST", http.StatusBadRequest)
+		return
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+	certificate, err := ca.Sign(csr)
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response.Header().Set("Content-Type", "application/x-pem-file")
+	response.Write(certificate)
+	pem.Encode(response, &pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate().Raw})
+}
+
+func (provider *WebProvider) getPipelineHandler(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	pipeline := provider.server.getFrontendPipeline(vars["frontend"])
+	if pipeline == nil {
+		http.NotFound(response, request)
+		return
+	}
+	templatesRenderer.JSON(response, http.StatusOK, pipeline)
+}
+
+func (provider *WebProvider) getTapHandler(response http.ResponseWriter, request *http.Request) {
+	if provider.Auth == nil && len(provider.ClientCA) == 0 {
+		response.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(response, "Tapping frontends requires web authentication")
+		return
+	}
+	vars := mux.Vars(request)
+	frontendName := vars["frontend"]
+	if !provider.server.hasFrontend(frontendName) {
+		http.NotFound(response, request)
+		return
+	}
+	options, err := parseTapOptions(request)
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Infof("Tapping frontend %s for %s", frontendName, options.Duration)
+	requestTap.Stream(response, request, frontendName, options)
+}
+
+func (provider *WebProvider) postTestRouteHandler(response http.ResponseWriter, request *http.Request) {
+	routeTest := new(RouteTest)
+	body, err := ioutil.ReadAll(io.LimitReader(request.Body, 64*1024))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := json.Unmarshal(body, routeTest); err != nil {
+		http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+		return
+	}
+	results, err := provider.server.testRoute(routeTest)
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+	templatesRenderer.JSON(response, http.StatusOK, results)
+}
+
+func (provider *WebProvider) getConflictsHandler(response http.ResponseWriter, request *http.Request) {
+	currentConfigurations := provider.server.currentConfigurations.Get().(configs)
+	templatesRenderer.JSON(response, http.StatusOK, analyzeRouteConflicts(currentConfigurations))
+}
+
+func (provider *WebProvider) getDryRunsHandler(response http.ResponseWriter, request *http.Request) {
+	currentConfigurations := provider.server.currentConfigurations.Get().(configs)
+	templatesRenderer.JSON(response, http.StatusOK, provider.server.dryRunConfigurations.Results(currentConfigurations))
+}
+
+func (provider *WebProvider) getDryRunHandler(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	currentConfigurations := provider.server.currentConfigurations.Get().(configs)
+	result := provider.server.dryRunConfigurations.Get(vars["provider"], currentConfigurations)
+	if result == nil {
+		http.NotFound(response, request)
+		return
+	}
+	templatesRenderer.JSON(response, http.StatusOK, result)
+}
+
+func (provider *WebProvider) getFaultsHandler(response http.ResponseWriter, request *http.Request) {
+	templatesRenderer.JSON(response, http.StatusOK, &FaultInjectionStatus{Enabled: faultInjector.Enabled()})
+}
+
+// putFaultsHandler switches the fault injection on or off. Switching it off
+// is allowed in read-only mode, to stop a resilience test going wrong.
+func (provider *WebProvider) putFaultsHandler(response http.ResponseWriter, request *http.Request) {
+	status := &FaultInjectionStatus{}
+	if err := json.NewDecoder(io.LimitReader(request.Body, 64*1024)).Decode(status); err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if status.Enabled && provider.ReadOnly {
+		response.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(response, "REST API is in read-only mode")
+		return
+	}
+	faultInjector.SetEnabled(status.Enabled)
+	provider.getFaultsHandler(response, request)
+}
+
+func (provider *WebProvider) getHistoryHandler(response http.ResponseWriter, request *http.Request) {
+	if provider.server == nil || provider.server.configurationHistory == nil {
+		http.Error(response, "Configuration history is not enabled", http.StatusNotFound)
+		return
+	}
+	templatesRenderer.JSON(response, http.StatusOK, provider.server.configurationHistory.Versions())
+}
+
+func (provider *WebProvider) getHistoryVersionHandler(response http.ResponseWriter, request *http.Request) {
+	if provider.server == nil || provider.server.configurationHistory == nil {
+		http.Error(response, "Configuration history is not enabled", http.StatusNotFound)
+		return
+	}
+	number, _ := strconv.Atoi(mux.Vars(request)["version"])
+	version := provider.server.configurationHistory.Get(number)
+	if version == nil {
+		http.NotFound(response, request)
+		return
+	}
+	templatesRenderer.JSON(response, http.StatusOK, version)
+}
+
+// postRollbackHandler applies a version of the configuration history, the
+// providers replace their configuration as soon as they send a new one.
+func (provider *WebProvider) postRollbackHandler(response http.ResponseWriter, request *http.Request) {
+	if provider.server == nil || provider.server.configurationHistory == nil {
+		http.Error(response, "Configuration history is not enabled", http.StatusNotFound)
+		return
+	}
+	if provider.ReadOnly {
+		response.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(response, "REST API is in read-only mode")
+		return
+	}
+	number, _ := strconv.Atoi(mux.Vars(request)["version"])
+	rollback := &configurationRollback{version: number, result: make(chan configurationRollbackResult, 1)}
+	select {
+	case provider.server.rollbackChan <- rollback:
+	case <-request.Context().Done():
+		return
+	}
+	result := <-rollback.result
+	if result.err == errUnknownVersion {
+		http.NotFound(response, request)
+		return
+	}
+	if result.err != nil {
+		http.Error(response, result.err.Error(), http.StatusInternalServerError)
+		return
+	}
+	templatesRenderer.JSON(response, http.StatusOK, result.version)
+}
+
+func (provider *WebProvider) getFreezeHandler(response http.ResponseWriter, request *http.Request) {
+	if provider.server == nil || provider.server.configurationFreeze == nil {
+		http.Error(response, "Configuration freeze is not enabled", http.StatusNotFound)
+		return
+	}
+	templatesRenderer.JSON(response, http.StatusOK, provider.server.configurationFreeze.Status())
+}
+
+// putFreezeHandler switches the configuration freeze on or off. Switching it
+// on is allowed in read-only mode, to stop the changes going out. The
+// configurations held back are applied once no freeze applies anymore.
+func (provider *WebProvider) putFreezeHandler(response http.ResponseWriter, request *http.Request) {
+	if provider.server == nil || provider.server.configurationFreeze == nil {
+		http.Error(response, "Configuration freeze is not enabled", http.StatusNotFound)
+		return
+	}
+	status := &ConfigurationFreezeStatus{}
+	if err := json.NewDecoder(io.LimitReader(request.Body, 64*1024)).Decode(status); err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !status.Frozen && provider.ReadOnly {
+		response.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(response, "REST API is in read-only mode")
+		return
+	}
+	configurationFreeze := provider.server.configurationFreeze
+	configurationFreeze.SetFrozen(status.Frozen)
+	for _, configMsg := range configurationFreeze.release(time.Now()) {
+		log.Infof("Configuration freeze over, applying the configuration of provider %s", configMsg.ProviderName)
+		provider.server.configurationValidatedChan <- configMsg
+	}
+	provider.getFreezeHandler(response, request)
+}
+
+func (provider *WebProvider) getDrainsHandler(response http.ResponseWriter, request *http.Request) {
+	templatesRenderer.JSON(response, http.StatusOK, frontendDrains.Data())
+}
+
+func (provider *WebProvider) getDrainHandler(response http.ResponseWriter, request *http.Request) {
+	drain := frontendDrains.Get(mux.Vars(request)["frontend"])
+	if drain == nil {
+		http.NotFound(response, request)
+		return
+	}
+	templatesRenderer.JSON(response, http.StatusOK, drain)
+}
+
+// putDrainHandler drains a frontend of the current configurations: its new
+// requests are rejected or redirected while its in-flight requests bleed off.
+func (provider *WebProvider) putDrainHandler(response http.ResponseWriter, request *http.Request) {
+	if provider.ReadOnly {
+		response.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(response, "REST API is in read-only mode")
+		return
+	}
+	frontendName := mux.Vars(request)["frontend"]
+	if !provider.server.hasFrontend(frontendName) {
+		http.NotFound(response, request)
+		return
+	}
+	drain := &FrontendDrain{}
+	if err := json.NewDecoder(io.LimitReader(request.Body, 64*1024)).Decode(drain); err != nil && err != io.EOF {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+	current, err := frontendDrains.Drain(frontendName, drain)
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+	templatesRenderer.JSON(response, http.StatusOK, current)
+}
+
+func (provider *WebProvider) deleteDrainHandler(response http.ResponseWriter, request *http.Request) {
+	if provider.ReadOnly {
+		response.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(response, "REST API is in read-only mode")
+		return
+	}
+	if !frontendDrains.Resume(mux.Vars(request)["frontend"]) {
+		http.NotFound(response, request)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// getUsageHandler returns the usage between the from and to RFC3339 times, or
+// over the window duration up to now, by default the last 24 hours.
+func (provider *WebProvider) getUsageHandler(response http.ResponseWriter, request *http.Request) {
+	if provider.server == nil || provider.server.usageRecorder == nil {
+		http.Error(response, "Usage accounting is not enabled", http.StatusNotFound)
+		return
+	}
+	query := request.URL.Query()
+	to := time.Now()
+	if value := query.Get("to"); len(value) > 0 {
+		var err error
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(response, "Invalid to time: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	from := to.Add(-24 * time.Hour)
+	if value := query.Get("window"); len(value) > 0 {
+		window, err := time.ParseDuration(value)
+		if err != nil || window <= 0 {
+			http.Error(response, "Invalid window: "+value, http.StatusBadRequest)
+			return
+		}
+		from = to.Add(-window)
+	}
+	if value := query.Get("from"); len(value) > 0 {
+		var err error
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(response, "Invalid from time: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if !from.Before(to) {
+		http.Error(response, "The from time must be before the to time", http.StatusBadRequest)
+		return
+	}
+	templatesRenderer.JSON(response, http.StatusOK, provider.server.usageRecorder.Report(from, to))
+}
+
+func (provider *WebProvider) getPrometheusTargetsHandler(response http.ResponseWriter, request *http.Request) {
+	currentConfigurations := provider.server.currentConfigurations.Get().(configs)
+	templatesRenderer.JSON(response, http.StatusOK, prometheusTargets(currentConfigurations))
+}
+
 func (provider *WebProvider) getPingHandler(response http.ResponseWriter, request *http.Request) {
 	fmt.Fprintf(response, "OK")
 }
 
+// getReadyHandler serves the readiness of traefik, with the statuses of the
+// providers. It fails until a configuration is applied; a degraded provider
+// keeps its last configuration, so traefik stays ready.
+func (provider *WebProvider) getReadyHandler(response http.ResponseWriter, request *http.Request) {
+	readiness := providerStatuses.Readiness()
+	status := http.StatusOK
+	if readiness.Status == "starting" {
+		status = http.StatusServiceUnavailable
+	}
+	templatesRenderer.JSON(response, status, readiness)
+}
+
 func (provider *WebProvider) getConfigHandler(response http.ResponseWriter, request *http.Request) {
 	currentConfigurations := provider.server.currentConfigurations.Get().(configs)
 	templatesRenderer.JSON(response, http.StatusOK, currentConfigurations)
@@ -172,6 +614,11 @@ func (provider *WebProvider) getVersionHandler(response http.ResponseWriter, req
 	templatesRenderer.JSON(response, http.StatusOK, v)
 }
 
+func (provider *WebProvider) getOpenAPIHandler(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	response.Write(openAPISpec)
+}
+
 func (provider *WebProvider) getProviderHandler(response http.ResponseWriter, request *http.Request) {
 	vars := mux.Vars(request)
 	providerID := vars["provider"]
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/codegangsta/negroni"
+	"github.com/containous/traefik/log"
+)
+
+// Roles of the web provider client certificates
+const (
+	// webRoleAdmin can use the whole API and dashboard
+	webRoleAdmin = "admin"
+	// webRoleReadOnly can only read the API and the dashboard
+	webRoleReadOnly = "read-only"
+	// webRoleMonitoring can only read /health, /ping, /ping/ready and /metrics
+	webRoleMonitoring = "monitoring"
+)
+
+// webRoleRanks orders the roles, from the least to the most privileged
+var webRoleRanks = map[string]int{webRoleMonitoring: 1, webRoleReadOnly: 2, webRoleAdmin: 3}
+
+// WebCertRoles maps the organizational units of the client certificates to
+// the roles of the web provider, as "ou:role" strings
+type WebCertRoles []string
+
+// Set adds strings elem into the the parser
+// it splits str on , and ;
+func (r *WebCertRoles) Set(str string) error {
+	fargs := func(c rune) bool {
+		return c == ',' || c == ';'
+	}
+	*r = append(*r, strings.FieldsFunc(str, fargs)...)
+	return nil
+}
+
+// Get []string
+func (r *WebCertRoles) Get() interface{} { return WebCertRoles(*r) }
+
+// String return slice in a string
+func (r *WebCertRoles) String() string { return fmt.Sprintf("%v", *r) }
+
+// SetValue sets []string into the parser
+func (r *WebCertRoles) SetValue(val interface{}) {
+	*r = WebCertRoles(val.(WebCertRoles))
+}
+
+// webRBAC authorizes the requests of the web provider with the role of the
+// organizational units of their client certificate. Every certificate issued
+// by the client CA is an admin when no role is configured.
+type webRBAC struct {
+	roles map[string]string
+}
+
+func newWebRBAC(certRoles WebCertRoles) (*webRBAC, error) {
+	rbac := &webRBAC{roles: map[string]string{}}
+	for _, certRole := range certRoles {
+		separator := strings.LastIndex(certRole, ":")
+		if separator < 1 {
+			return nil, fmt.Errorf("invalid certificate role %q, expected ou:role", certRole)
+		}
+		ou, role := certRole[:separator], certRole[separator+1:]
+		if _, ok := webRoleRanks[role]; !ok {
+			return nil, fmt.Errorf("invalid certificate role %q, expected admin, read-only or monitoring", role)
+		}
+		rbac.roles[ou] = role
+	}
+	return rbac, nil
+}
+
+// role returns the most privileged role of the client certificate of r, or an empty string.
+func (rbac *webRBAC) role(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return ""
+	}
+	if len(rbac.roles) == 0 {
+		return webRoleAdmin
+	}
+	role := ""
+	for _, ou := range r.TLS.PeerCertificates[0].Subject.OrganizationalUnit {
+		if ouRole, ok := rbac.roles[ou]; ok && webRoleRanks[ouRole] > webRoleRanks[role] {
+			role = ouRole
+		}
+	}
+	return role
+}
+
+func (rbac *webRBAC) allowed(role string, r *http.Request) bool {
+	switch role {
+	case webRoleAdmin:
+		return true
+	case webRoleReadOnly:
+		return r.Method == "GET" || r.Method == "HEAD"
+	case webRoleMonitoring:
+		return (r.Method == "GET" || r.Method == "HEAD") && (r.URL.Path == "/health" || r.URL.Path == "/ping" || r.URL.Path == "/ping/ready" || r.URL.Path == "/metrics")
+	}
+	return false
+}
+
+func (rbac *webRBAC) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	role := rbac.role(r)
+	if !rbac.allowed(role, r) {
+		subject := ""
+		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			subject = r.TLS.PeerCertificates[0].Subject.CommonName
+		}
+		log.Debugf("Web request %s %s of certificate %q with role %q denied", r.Method, r.URL.Path, subject, role)
+		http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	next(rw, r)
+}
+
+// webClientTLSConfig returns the TLS configuration verifying the client
+// certificates issued by the CA of the caFile. The requests without one are
+// denied by webRBAC, except the ones authenticated by their own token.
+func webClientTLSConfig(caFile string) (*tls.Config, error) {
+	ca, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid client CA %s", caFile)
+	}
+	return &tls.Config{ClientCAs: pool, ClientAuth: tls.VerifyClientCertIfGiven}, nil
+}
+
+// webAuthExempted returns true for the requests authenticated by their own
+// token instead of the web authentication: the certificate requests of the
+// internal CA, sent by the backends.
+func webAuthExempted(r *http.Request) bool {
+	return r.Method == "POST" && r.URL.Path == "/api/ca/certificates"
+}
+
+// skipWebAuth wraps an authentication middleware of the web provider, to pass
+// the webAuthExempted requests through.
+func skipWebAuth(handler negroni.Handler) negroni.Handler {
+	return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if webAuthExempted(r) {
+			next(rw, r)
+			return
+		}
+		handler.ServeHTTP(rw, r, next)
+	})
+}
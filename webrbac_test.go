@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCertRequest(method, path string, ous ...string) *http.Request {
+	request := httptest.NewRequest(method, path, nil)
+	if ous != nil {
+		request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "client", OrganizationalUnit: ous}}}}
+	}
+	return request
+}
+
+func TestWebRBAC(t *testing.T) {
+	rbac, err := newWebRBAC(WebCertRoles{"ops:admin", "dev:read-only", "prometheus:monitoring"})
+	assert.NoError(t, err)
+
+	serve := func(request *http.Request) int {
+		recorder := httptest.NewRecorder()
+		rbac.ServeHTTP(recorder, request, func(http.ResponseWriter, *http.Request) {})
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusOK, serve(newCertRequest("PUT", "/api/providers/web", "ops")))
+	assert.Equal(t, http.StatusOK, serve(newCertRequest("GET", "/api/providers", "dev")))
+	assert.Equal(t, http.StatusForbidden, serve(newCertRequest("PUT", "/api/providers/web", "dev")))
+	assert.Equal(t, http.StatusOK, serve(newCertRequest("GET", "/health", "prometheus")))
+	assert.Equal(t, http.StatusOK, serve(newCertRequest("GET", "/metrics", "prometheus")))
+	assert.Equal(t, http.StatusForbidden, serve(newCertRequest("GET", "/api", "prometheus")))
+	// the most privileged role of the organizational units applies
+	assert.Equal(t, http.StatusOK, serve(newCertRequest("PUT", "/api/faults", "prometheus", "ops")))
+	assert.Equal(t, http.StatusForbidden, serve(newCertRequest("GET", "/health", "sales")))
+	assert.Equal(t, http.StatusForbidden, serve(newCertRequest("GET", "/health")))
+}
+
+func TestWebRBACWithoutRoles(t *testing.T) {
+	rbac, err := newWebRBAC(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, webRoleAdmin, rbac.role(newCertRequest("PUT", "/api/providers/web", "sales")))
+	assert.Equal(t, "", rbac.role(newCertRequest("GET", "/")))
+
+	_, err = newWebRBAC(WebCertRoles{"ops"})
+	assert.Error(t, err)
+	_, err = newWebRBAC(WebCertRoles{"ops:root"})
+	assert.Error(t, err)
+}
+
+func TestSkipWebAuth(t *testing.T) {
+	rbac, err := newWebRBAC(nil)
+	assert.NoError(t, err)
+	handler := skipWebAuth(rbac)
+
+	serve := func(request *http.Request) int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request, func(http.ResponseWriter, *http.Request) {})
+		return recorder.Code
+	}
+	// the certificate requests of the internal CA are authenticated by its token
+	assert.Equal(t, http.StatusOK, serve(newCertRequest("POST", "/api/ca/certificates")))
+	assert.Equal(t, http.StatusForbidden, serve(newCertRequest("GET", "/api/ca")))
+	assert.Equal(t, http.StatusForbidden, serve(newCertRequest("GET", "/api/ca/certificates")))
+}
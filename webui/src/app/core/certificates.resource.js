@@ -0,0 +1,14 @@
+'use strict';
+var angular = require('angular');
+
+var traefikCoreCertificates = 'traefik.core.certificates';
+module.exports = traefikCoreCertificates;
+
+angular
+  .module(traefikCoreCertificates, ['ngResource'])
+  .factory('Certificates', Certificates);
+
+  /** @ngInject */
+  function Certificates($resource) {
+    return $resource('../api/certificates');
+  }
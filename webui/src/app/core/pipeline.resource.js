@@ -0,0 +1,14 @@
+'use strict';
+var angular = require('angular');
+
+var traefikCorePipeline = 'traefik.core.pipeline';
+module.exports = traefikCorePipeline;
+
+angular
+  .module(traefikCorePipeline, ['ngResource'])
+  .factory('Pipeline', Pipeline);
+
+  /** @ngInject */
+  function Pipeline($resource) {
+    return $resource('../api/frontends/:frontend/pipeline', {frontend: '@frontend'});
+  }
@@ -0,0 +1,39 @@
+'use strict';
+var moment = require('moment');
+
+/** @ngInject */
+function CertificatesController($scope, $interval, $log, Certificates) {
+  var vm = this;
+
+  vm.certificates = Certificates.query();
+
+  vm.expiry = function (certificate) {
+    return moment(certificate.notAfter).fromNow();
+  };
+
+  vm.expiryClass = function (certificate) {
+    if (certificate.expired) {
+      return 'danger';
+    }
+    // less than 30 days left, that is when ACME renews
+    if (certificate.expiresIn < 30 * 24 * 3600) {
+      return 'warning';
+    }
+    return '';
+  };
+
+  var intervalId = $interval(function () {
+    Certificates.query(function (certificates) {
+      vm.certificates = certificates;
+    }, function (error) {
+      vm.certificates = [];
+      $log.error(error);
+    });
+  }, 10000);
+
+  $scope.$on('$destroy', function () {
+    $interval.cancel(intervalId);
+  });
+}
+
+module.exports = CertificatesController;
@@ -0,0 +1,24 @@
+'use strict';
+var angular = require('angular');
+var traefikCoreCertificates = require('../../core/certificates.resource');
+var CertificatesController = require('./certificates.controller');
+
+var traefikSectionCertificates = 'traefik.section.certificates';
+module.exports = traefikSectionCertificates;
+
+angular
+  .module(traefikSectionCertificates, [traefikCoreCertificates])
+  .controller('CertificatesController', CertificatesController)
+  .config(config);
+
+  /** @ngInject */
+  function config($stateProvider) {
+
+    $stateProvider.state('certificates', {
+      url: '/certificates',
+      template: require('./certificates.html'),
+      controller: 'CertificatesController',
+      controllerAs: 'certificatesCtrl'
+    });
+
+  }
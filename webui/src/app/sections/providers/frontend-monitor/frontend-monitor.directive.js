@@ -14,8 +14,24 @@ function frontendMonitor() {
   };
 }
 
-function FrontendMonitorController() {
-  // Nothing
+/** @ngInject */
+function FrontendMonitorController($log, Pipeline) {
+  var vm = this;
+
+  vm.pipeline = null;
+
+  vm.togglePipeline = function () {
+    if (vm.pipeline) {
+      vm.pipeline = null;
+      return;
+    }
+    Pipeline.get({frontend: vm.frontendId}, function (pipeline) {
+      vm.pipeline = pipeline;
+    }, function (error) {
+      vm.pipeline = null;
+      $log.error(error);
+    });
+  };
 }
 
 module.exports = frontendMonitor;
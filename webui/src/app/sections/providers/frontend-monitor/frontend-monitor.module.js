@@ -1,10 +1,11 @@
 'use strict';
 var angular = require('angular');
+var traefikCorePipeline = require('../../../core/pipeline.resource');
 var frontendMonitor = require('./frontend-monitor.directive');
 
 var traefikFrontendMonitor = 'traefik.section.providers.frontend-monitor';
 module.exports = traefikFrontendMonitor;
 
 angular
-  .module(traefikFrontendMonitor, [])
+  .module(traefikFrontendMonitor, [traefikCorePipeline])
   .directive('frontendMonitor', frontendMonitor);